@@ -2,10 +2,13 @@ import { http, type ApiEnvelope } from '../utils/request'
 import type { PaginatedData } from './admin-user'
 import type { OrderUserSummary } from './order'
 
-export type InstanceStatus = 'creating' | 'running' | 'stopped' | 'error' | 'releasing' | 'released'
-export type InstanceOperationAction = 'provision' | 'start' | 'stop' | 'release' | 'sync'
+export type InstanceStatus = 'creating' | 'running' | 'stopped' | 'suspended' | 'error' | 'releasing' | 'released'
+export type InstanceOperationAction = 'provision' | 'start' | 'stop' | 'reboot' | 'shutdown' | 'reset' | 'reinstall' | 'snapshot_create' | 'snapshot_rollback' | 'snapshot_delete' | 'backup_create' | 'backup_restore' | 'release' | 'sync' | 'suspend' | 'rescue_enter' | 'rescue_exit' | 'iso_mount' | 'iso_unmount' | 'migrate'
 export type InstanceOperationStatus = 'running' | 'succeeded' | 'failed'
 export type MappingStatus = 'active' | 'inactive'
+export type InstanceSnapshotStatus = 'creating' | 'available' | 'deleting' | 'deleted' | 'failed'
+export type InstanceBackupStatus = 'creating' | 'available' | 'deleting' | 'deleted' | 'failed'
+export type InstanceBackupFrequency = 'daily' | 'weekly'
 
 export interface InstanceMappingItem {
   id: number
@@ -16,10 +19,12 @@ export interface InstanceMappingItem {
   template_no: string
   network_type_no: string
   node: string
+  placement_nodes: string | null
   storage: string
   disk_source: string
   disk_format: string | null
   disk_interface: string | null
+  data_disk_storage: string | null
   snippets_storage: string | null
   ci_user: string | null
   ssh_keys: string | null
@@ -31,6 +36,9 @@ export interface InstanceMappingItem {
   vmid_start: number
   vmid_end: number
   next_vmid: number
+  reuse_released_vmids: boolean
+  vmid_remaining: number
+  vmid_low: boolean
   status: MappingStatus
   remark: string | null
   created_at: string
@@ -45,10 +53,12 @@ export interface InstanceMappingPayload {
   template_no: string
   network_type_no?: string
   node: string
+  placement_nodes?: string | null
   storage: string
   disk_source: string
   disk_format?: string | null
   disk_interface?: string | null
+  data_disk_storage?: string | null
   snippets_storage?: string | null
   ci_user?: string | null
   ssh_keys?: string | null
@@ -60,10 +70,105 @@ export interface InstanceMappingPayload {
   vmid_start: number
   vmid_end: number
   next_vmid: number
+  reuse_released_vmids: boolean
   status: MappingStatus
   remark?: string | null
 }
 
+export type IPPoolStatus = 'active' | 'inactive'
+export type IPAddressStatus = 'available' | 'reserved' | 'allocated'
+
+export interface IPPoolItem {
+  id: number
+  pool_no: string
+  name: string
+  region_no: string
+  network_type_no: string
+  cidr: string
+  prefix_length: number
+  gateway: string
+  status: IPPoolStatus
+  remark: string | null
+  total_count: number
+  available_count: number
+  reserved_count: number
+  allocated_count: number
+  created_at: string
+  updated_at: string
+}
+
+export interface IPPoolCreatePayload {
+  pool_no?: string
+  name: string
+  region_no: string
+  network_type_no: string
+  cidr: string
+  gateway: string
+  reserved_ranges: string[]
+  status: IPPoolStatus
+  remark?: string | null
+}
+
+export interface IPPoolUpdatePayload {
+  name: string
+  status: IPPoolStatus
+  remark?: string | null
+}
+
+export type ISOPurpose = 'rescue' | 'install'
+export type ISOMedia = 'iso' | 'disk'
+export type ISOStatus = 'active' | 'inactive'
+
+export interface ISOItem {
+  iso_no: string
+  name: string
+  purpose: ISOPurpose
+  media: ISOMedia
+  volume: string
+  os_family: string | null
+  description: string | null
+  status: ISOStatus
+  sort_order: number
+  in_use_count: number
+  created_at: string
+  updated_at: string
+}
+
+export interface ISOPayload {
+  iso_no?: string
+  name: string
+  purpose: ISOPurpose
+  media: ISOMedia
+  volume: string
+  os_family?: string | null
+  description?: string | null
+  status: ISOStatus
+  sort_order: number
+}
+
+export interface InstanceRescueState {
+  iso_no: string
+  iso_name: string
+  started_at: string | null
+  expires_at: string | null
+}
+
+export interface InstanceMountedISO {
+  iso_no: string
+  iso_name: string
+}
+
+export interface IPAddressItem {
+  id: number
+  pool_no: string
+  address: string
+  status: IPAddressStatus
+  instance_no: string | null
+  allocated_at: string | null
+  remark: string | null
+  updated_at: string
+}
+
 export interface InstanceItem {
   instance_no: string
   order_no: string
@@ -74,13 +179,18 @@ export interface InstanceItem {
   region_name: string
   network_type_name: string | null
   template_name: string
+  cluster_no: string
   external_node: string
   external_vmid: number
+  config_drift: string[]
   service_started_at: string | null
   expires_at: string | null
   expire_notice_sent_at: string | null
   expire_release_scheduled_at: string | null
   expire_released_at: string | null
+  suspend_source: 'expiry' | 'admin' | null
+  suspend_reason: string | null
+  suspended_at: string | null
   created_at: string
   released_at: string | null
 }
@@ -94,10 +204,57 @@ export interface InstanceOperation {
   resource_location: string | null
   error_code: string | null
   error_message: string | null
+  placement?: InstancePlacement
   created_at: string
   completed_at: string | null
 }
 
+export interface InstancePlacement {
+  mapping_no: string
+  node: string
+  ratios: { cpu: number; memory: number; storage: number; user_anti_affinity: boolean }
+  candidates: Array<{
+    node: string
+    eligible: boolean
+    reason?: string
+    load: number
+    max_cpu: number
+    cpu_usage: number
+    memory_total_mb: number
+    memory_used_mb: number
+    storage_total_gb: number
+    storage_used_gb: number
+    committed_cpu: number
+    committed_memory_mb: number
+    committed_disk_gb: number
+    user_instances: number
+  }>
+}
+
+export type InstancePlacementCandidate = InstancePlacement['candidates'][number]
+
+export type InstanceMigrateMode = 'auto' | 'online' | 'offline'
+
+export interface InstanceMigrationTargets {
+  instance_no: string
+  current_node: string
+  candidates: InstancePlacementCandidate[]
+}
+
+export interface NodeEvacuateItem {
+  instance_no: string
+  status: InstanceStatus
+  target_node?: string
+  reason?: string
+}
+
+export interface NodeEvacuateResult {
+  cluster_no: string
+  node: string
+  queued: NodeEvacuateItem[]
+  skipped: NodeEvacuateItem[]
+}
+
 export interface InstanceDetail extends InstanceItem {
   product_no: string
   plan_no: string
@@ -106,6 +263,8 @@ export interface InstanceDetail extends InstanceItem {
   system_disk_gb: number
   data_disk_gb: number
   bandwidth_mbps: number
+  traffic_gb: number | null
+  traffic_overage_action: InstanceTrafficOverageAction | null
   region_no: string
   network_type_no: string | null
   template_no: string
@@ -115,6 +274,12 @@ export interface InstanceDetail extends InstanceItem {
   external_resource_location: string | null
   last_error_code: string | null
   last_error_message: string | null
+  config_checked_at: string | null
+  firewall_status: InstanceFirewallStatus | null
+  firewall_synced_at: string | null
+  root_password_set: boolean
+  root_password_revealed_at: string | null
+  ip_addresses: string[]
   renewal_available: boolean
   latest_renewal_order: {
     order_no: string
@@ -127,6 +292,189 @@ export interface InstanceDetail extends InstanceItem {
     created_at: string
   } | null
   operations: InstanceOperation[]
+  rescue: InstanceRescueState | null
+  mounted_iso: InstanceMountedISO | null
+}
+
+export interface InstanceReinstallTemplate {
+  template_no: string
+  code: string
+  name: string
+  os_family: string
+  distribution: string
+  version: string
+  architecture: string
+  summary: string | null
+  current: boolean
+}
+
+export interface InstanceSnapshotItem {
+  snapshot_no: string
+  name: string
+  description: string | null
+  status: InstanceSnapshotStatus
+  created_by_user_id: number | null
+  created_by_admin_id: number | null
+  last_rolled_back_at: string | null
+  created_at: string
+}
+
+export interface InstanceSnapshotList {
+  quota: number
+  used: number
+  list: InstanceSnapshotItem[]
+}
+
+export interface InstanceBackupItem {
+  backup_no: string
+  source: 'manual' | 'scheduled'
+  status: InstanceBackupStatus
+  node: string
+  storage: string
+  volume_id: string | null
+  mode: string
+  note: string | null
+  task_no: string | null
+  cpu_cores: number
+  memory_mb: number
+  system_disk_gb: number
+  data_disk_gb: number
+  template_no: string
+  template_name: string
+  created_by_user_id: number | null
+  created_by_admin_id: number | null
+  completed_at: string | null
+  last_restored_at: string | null
+  created_at: string
+}
+
+export interface InstanceBackupPolicy {
+  frequency: InstanceBackupFrequency
+  weekday: number
+  hour: number
+  retention_count: number
+  enabled: boolean
+  next_run_at: string | null
+  last_run_at: string | null
+}
+
+export interface InstanceBackupPolicyPayload {
+  frequency: InstanceBackupFrequency
+  weekday: number
+  hour: number
+  retention_count: number
+  enabled: boolean
+}
+
+export interface InstanceBackupList {
+  manual_limit: number
+  manual_used: number
+  policy: InstanceBackupPolicy | null
+  list: InstanceBackupItem[]
+}
+
+export type InstanceConsoleType = 'vnc' | 'terminal'
+
+export interface InstanceConsoleSession {
+  type: InstanceConsoleType
+  websocket_path: string
+  password: string
+  expires_at: string
+  max_duration_seconds: number
+}
+
+export type InstanceMetricsRange = 'hour' | 'day' | 'week' | 'month'
+
+export interface MetricPoint {
+  time: string
+  cpu_percent: number | null
+  memory_used_mb: number | null
+  memory_total_mb: number | null
+  disk_read_bps: number | null
+  disk_write_bps: number | null
+  net_in_bps: number | null
+  net_out_bps: number | null
+}
+
+export interface InstanceMetrics {
+  range: InstanceMetricsRange
+  points: MetricPoint[]
+  generated_at: string
+}
+
+export type InstanceFirewallStatus = 'synced' | 'pending'
+
+export interface SecurityGroupRule {
+  direction: 'in' | 'out'
+  protocol: 'tcp' | 'udp' | 'icmp' | 'any'
+  port_range: string | null
+  cidr: string
+  description: string | null
+}
+
+export interface InstanceFirewall {
+  instance_no: string
+  status: InstanceFirewallStatus | null
+  synced_at: string | null
+  security_groups: {
+    group_no: string
+    name: string
+    description: string | null
+    rules: SecurityGroupRule[]
+    updated_at: string
+  }[]
+}
+
+export type InstanceTransferStatus = 'pending' | 'completed' | 'rejected' | 'cancelled'
+
+export interface InstanceTransferItem {
+  transfer_no: string
+  instance_no: string
+  from_user_id: number
+  from_username: string
+  to_user_id: number
+  to_username: string
+  status: InstanceTransferStatus
+  remark: string | null
+  forced_by: number | null
+  force_reason: string | null
+  responded_at: string | null
+  completed_at: string | null
+  created_at: string
+}
+
+export type InstanceTrafficOverageAction = 'throttle' | 'suspend' | 'bill'
+
+export interface InstanceTrafficUsage {
+  period: string
+  quota_gb: number | null
+  in_bytes: number
+  out_bytes: number
+  billed_bytes: number
+  used_percent: number
+  exceeded: boolean
+  overage_action: InstanceTrafficOverageAction | null
+  overage_billed_gb: number
+  overage_billed_cents: number
+  last_sampled_at: string | null
+}
+
+export interface InstanceTraffic {
+  instance_no: string
+  direction: 'out' | 'in' | 'both' | 'max'
+  overage_policy: InstanceTrafficOverageAction
+  overage_action: InstanceTrafficOverageAction | null
+  current: InstanceTrafficUsage
+  history: InstanceTrafficUsage[]
+}
+
+export interface NodeMetrics extends InstanceMetrics {
+  cluster_no: string
+  node: string
+  instances: number
+  allocated_cpu_cores: number
+  allocated_memory_mb: number
+  allocated_disk_gb: number
 }
 
 export interface ProvisionResponse {
@@ -134,6 +482,27 @@ export interface ProvisionResponse {
   operation: InstanceOperation
 }
 
+export type MCPClusterStatus = 'online' | 'unreachable' | 'disabled'
+
+export interface MCPClusterRegion {
+  region_no: string
+  name: string
+  status: string
+}
+
+export interface MCPCluster {
+  cluster_no: string
+  name: string
+  status: MCPClusterStatus
+  nodes: number
+  online_nodes: number
+  latency_ms: number
+  error: string | null
+  regions: MCPClusterRegion[]
+  instances: number
+  checked_at: string
+}
+
 export interface PveNode {
   node: string
   name: string
@@ -171,6 +540,106 @@ export async function updateInstanceMapping(id: number, payload: InstanceMapping
   return response.data.data
 }
 
+export interface CapacityNode {
+  node: string
+  storage: string
+  online: boolean
+  max_cpu: number
+  cpu_usage: number
+  cpu_limit: number
+  committed_cpu: number
+  memory_total_mb: number
+  memory_used_mb: number
+  memory_limit_mb: number
+  committed_memory_mb: number
+  storage_total_gb: number
+  storage_used_gb: number
+  storage_limit_gb: number
+  committed_disk_gb: number
+  instances: number
+}
+
+export interface CapacityRegion {
+  region_no: string
+  cpu_limit: number
+  committed_cpu: number
+  memory_limit_mb: number
+  committed_memory_mb: number
+  storage_limit_gb: number
+  committed_disk_gb: number
+  instances: number
+  nodes: CapacityNode[]
+}
+
+export interface CapacityPlan {
+  plan_no: string
+  plan_name: string
+  status: 'active' | 'sold_out'
+  capacity_sold_out_at: string | null
+  cpu_cores: number
+  memory_mb: number
+  disk_gb: number
+  available: boolean
+  regions: Array<{ region_no: string; available: boolean; node: string | null; reason: string | null }>
+}
+
+export interface CapacityResponse {
+  ratios: InstancePlacement['ratios']
+  regions: CapacityRegion[]
+  plans: CapacityPlan[]
+  generated_at: string
+}
+
+export async function getInstanceCapacity() {
+  const response = await http.get<ApiEnvelope<CapacityResponse>>('/instance-capacity')
+  return response.data.data
+}
+
+export async function getIPPools(params?: Record<string, unknown>) {
+  const response = await http.get<ApiEnvelope<PaginatedData<IPPoolItem>>>('/ip-pools', { params })
+  return response.data.data
+}
+
+export async function createIPPool(payload: IPPoolCreatePayload) {
+  const response = await http.post<ApiEnvelope<IPPoolItem>>('/ip-pools', payload)
+  return response.data.data
+}
+
+export async function updateIPPool(poolNo: string, payload: IPPoolUpdatePayload) {
+  const response = await http.patch<ApiEnvelope<IPPoolItem>>(`/ip-pools/${poolNo}`, payload)
+  return response.data.data
+}
+
+export async function getISOs(params?: Record<string, unknown>) {
+  const response = await http.get<ApiEnvelope<PaginatedData<ISOItem>>>('/instance-isos', { params })
+  return response.data.data
+}
+
+export async function createISO(payload: ISOPayload) {
+  const response = await http.post<ApiEnvelope<ISOItem>>('/instance-isos', payload)
+  return response.data.data
+}
+
+export async function updateISO(isoNo: string, payload: ISOPayload) {
+  const response = await http.patch<ApiEnvelope<ISOItem>>(`/instance-isos/${isoNo}`, payload)
+  return response.data.data
+}
+
+export async function getIPAddresses(poolNo: string, params?: Record<string, unknown>) {
+  const response = await http.get<ApiEnvelope<PaginatedData<IPAddressItem>>>(`/ip-pools/${poolNo}/addresses`, { params })
+  return response.data.data
+}
+
+export async function reserveIPAddress(poolNo: string, id: number, remark?: string | null) {
+  const response = await http.post<ApiEnvelope<IPAddressItem>>(`/ip-pools/${poolNo}/addresses/${id}/reserve`, { remark })
+  return response.data.data
+}
+
+export async function reclaimIPAddress(poolNo: string, id: number, remark?: string | null) {
+  const response = await http.post<ApiEnvelope<IPAddressItem>>(`/ip-pools/${poolNo}/addresses/${id}/reclaim`, { remark })
+  return response.data.data
+}
+
 export async function getInstances(params?: Record<string, unknown>) {
   const response = await http.get<ApiEnvelope<PaginatedData<InstanceItem>>>('/instances', { params })
   return response.data.data
@@ -191,6 +660,96 @@ export async function stopInstance(instanceNo: string) {
   return response.data.data
 }
 
+export async function rebootInstance(instanceNo: string) {
+  const response = await http.post<ApiEnvelope<InstanceDetail>>(`/instances/${instanceNo}/reboot`)
+  return response.data.data
+}
+
+export async function shutdownInstance(instanceNo: string) {
+  const response = await http.post<ApiEnvelope<InstanceDetail>>(`/instances/${instanceNo}/shutdown`)
+  return response.data.data
+}
+
+export async function resetInstance(instanceNo: string) {
+  const response = await http.post<ApiEnvelope<InstanceDetail>>(`/instances/${instanceNo}/reset`)
+  return response.data.data
+}
+
+export async function getInstanceReinstallTemplates(instanceNo: string) {
+  const response = await http.get<ApiEnvelope<InstanceReinstallTemplate[]>>(`/instances/${instanceNo}/reinstall-templates`)
+  return response.data.data
+}
+
+export async function reinstallInstance(instanceNo: string, templateNo: string) {
+  const response = await http.post<ApiEnvelope<InstanceDetail>>(`/instances/${instanceNo}/reinstall`, { template_no: templateNo })
+  return response.data.data
+}
+
+export async function getInstanceSnapshots(instanceNo: string) {
+  const response = await http.get<ApiEnvelope<InstanceSnapshotList>>(`/instances/${instanceNo}/snapshots`)
+  return response.data.data
+}
+
+export async function createInstanceSnapshot(instanceNo: string, description?: string | null) {
+  const response = await http.post<ApiEnvelope<InstanceSnapshotItem>>(`/instances/${instanceNo}/snapshots`, { description })
+  return response.data.data
+}
+
+export async function rollbackInstanceSnapshot(instanceNo: string, snapshotNo: string) {
+  const response = await http.post<ApiEnvelope<InstanceSnapshotItem>>(`/instances/${instanceNo}/snapshots/${snapshotNo}/rollback`)
+  return response.data.data
+}
+
+export async function deleteInstanceSnapshot(instanceNo: string, snapshotNo: string) {
+  const response = await http.delete<ApiEnvelope<InstanceSnapshotItem>>(`/instances/${instanceNo}/snapshots/${snapshotNo}`)
+  return response.data.data
+}
+
+export async function getInstanceBackups(instanceNo: string) {
+  const response = await http.get<ApiEnvelope<InstanceBackupList>>(`/instances/${instanceNo}/backups`)
+  return response.data.data
+}
+
+export async function createInstanceBackup(instanceNo: string, note?: string | null) {
+  const response = await http.post<ApiEnvelope<InstanceBackupItem>>(`/instances/${instanceNo}/backups`, { note })
+  return response.data.data
+}
+
+export async function restoreInstanceBackup(instanceNo: string, backupNo: string) {
+  const response = await http.post<ApiEnvelope<InstanceBackupItem>>(`/instances/${instanceNo}/backups/${backupNo}/restore`)
+  return response.data.data
+}
+
+export async function deleteInstanceBackup(instanceNo: string, backupNo: string) {
+  const response = await http.delete<ApiEnvelope<InstanceBackupItem>>(`/instances/${instanceNo}/backups/${backupNo}`)
+  return response.data.data
+}
+
+export async function updateInstanceBackupPolicy(instanceNo: string, payload: InstanceBackupPolicyPayload) {
+  const response = await http.put<ApiEnvelope<InstanceBackupPolicy>>(`/instances/${instanceNo}/backup-policy`, payload)
+  return response.data.data
+}
+
+export async function createInstanceConsole(instanceNo: string, type: InstanceConsoleType) {
+  const response = await http.post<ApiEnvelope<InstanceConsoleSession>>(`/instances/${instanceNo}/console`, { type })
+  return response.data.data
+}
+
+export async function getInstanceMetrics(instanceNo: string, range: InstanceMetricsRange) {
+  const response = await http.get<ApiEnvelope<InstanceMetrics>>(`/instances/${instanceNo}/metrics`, { params: { range } })
+  return response.data.data
+}
+
+export async function getInstanceTraffic(instanceNo: string) {
+  const response = await http.get<ApiEnvelope<InstanceTraffic>>(`/instances/${instanceNo}/traffic`)
+  return response.data.data
+}
+
+export async function getInstanceFirewall(instanceNo: string) {
+  const response = await http.get<ApiEnvelope<InstanceFirewall>>(`/instances/${instanceNo}/firewall`)
+  return response.data.data
+}
+
 export async function releaseInstance(instanceNo: string) {
   const response = await http.post<ApiEnvelope<InstanceDetail>>(`/instances/${instanceNo}/release`)
   return response.data.data
@@ -209,27 +768,82 @@ export async function updateInstanceExpiresAt(instanceNo: string, expiresAt: str
   return response.data.data
 }
 
+export async function suspendInstance(instanceNo: string, reason: string) {
+  const response = await http.post<ApiEnvelope<InstanceDetail>>(`/instances/${instanceNo}/suspend`, { reason })
+  return response.data.data
+}
+
+export async function unsuspendInstance(instanceNo: string, remark?: string | null) {
+  const response = await http.post<ApiEnvelope<InstanceDetail>>(`/instances/${instanceNo}/unsuspend`, { remark })
+  return response.data.data
+}
+
+export async function enterInstanceRescue(instanceNo: string, isoNo?: string | null) {
+  const response = await http.post<ApiEnvelope<InstanceDetail>>(`/instances/${instanceNo}/rescue`, { iso_no: isoNo || '' })
+  return response.data.data
+}
+
+export async function exitInstanceRescue(instanceNo: string) {
+  const response = await http.post<ApiEnvelope<InstanceDetail>>(`/instances/${instanceNo}/rescue/exit`)
+  return response.data.data
+}
+
+export async function getInstanceMigrationTargets(instanceNo: string) {
+  const response = await http.get<ApiEnvelope<InstanceMigrationTargets>>(`/instances/${instanceNo}/migration-targets`)
+  return response.data.data
+}
+
+export async function migrateInstance(instanceNo: string, payload: { target_node?: string; mode: InstanceMigrateMode }) {
+  const response = await http.post<ApiEnvelope<InstanceDetail>>(`/instances/${instanceNo}/migrate`, payload)
+  return response.data.data
+}
+
+export async function getInstanceTransfers(params: { page?: number; per_page?: number; status?: InstanceTransferStatus | ''; keyword?: string }) {
+  const response = await http.get<ApiEnvelope<PaginatedData<InstanceTransferItem>>>('/instance-transfers', { params })
+  return response.data.data
+}
+
+export async function forceTransferInstance(instanceNo: string, account: string, reason: string) {
+  const response = await http.post<ApiEnvelope<InstanceTransferItem>>(`/instances/${instanceNo}/transfer`, { account, reason })
+  return response.data.data
+}
+
 export async function provisionOrder(orderNo: string) {
   const response = await http.post<ApiEnvelope<ProvisionResponse>>(`/orders/${orderNo}/provision`)
   return response.data.data
 }
 
-export async function getPveNodes() {
-  const response = await http.get<ApiEnvelope<PveNode[]>>('/mcp-pve/nodes')
+export async function getPveClusters() {
+  const response = await http.get<ApiEnvelope<MCPCluster[]>>('/mcp-pve/clusters')
+  return response.data.data
+}
+
+export async function getPveNodes(clusterNo: string) {
+  const response = await http.get<ApiEnvelope<PveNode[]>>('/mcp-pve/nodes', { params: { cluster_no: clusterNo } })
+  return response.data.data
+}
+
+export async function getPveNode(clusterNo: string, node: string) {
+  const response = await http.get<ApiEnvelope<PveNode>>(`/mcp-pve/nodes/${node}`, { params: { cluster_no: clusterNo } })
+  return response.data.data
+}
+
+export async function getPveNodeVMs(clusterNo: string, node: string) {
+  const response = await http.get<ApiEnvelope<PveVM[]>>(`/mcp-pve/nodes/${node}/vms`, { params: { cluster_no: clusterNo } })
   return response.data.data
 }
 
-export async function getPveNode(node: string) {
-  const response = await http.get<ApiEnvelope<PveNode>>(`/mcp-pve/nodes/${node}`)
+export async function getPveNodeMetrics(clusterNo: string, node: string, range: InstanceMetricsRange) {
+  const response = await http.get<ApiEnvelope<NodeMetrics>>(`/mcp-pve/nodes/${node}/metrics`, { params: { cluster_no: clusterNo, range } })
   return response.data.data
 }
 
-export async function getPveNodeVMs(node: string) {
-  const response = await http.get<ApiEnvelope<PveVM[]>>(`/mcp-pve/nodes/${node}/vms`)
+export async function evacuatePveNode(clusterNo: string, node: string, mode: InstanceMigrateMode) {
+  const response = await http.post<ApiEnvelope<NodeEvacuateResult>>(`/mcp-pve/nodes/${node}/evacuate`, { cluster_no: clusterNo, mode })
   return response.data.data
 }
 
-export async function getPveStorage() {
-  const response = await http.get<ApiEnvelope<PveStorage[]>>('/mcp-pve/storage')
+export async function getPveStorage(clusterNo: string) {
+  const response = await http.get<ApiEnvelope<PveStorage[]>>('/mcp-pve/storage', { params: { cluster_no: clusterNo } })
   return response.data.data
 }
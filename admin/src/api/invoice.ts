@@ -7,7 +7,7 @@ export type InvoiceTitleType = 'personal' | 'company'
 
 export interface InvoiceOrderItem {
   order_no: string
-  order_type: 'purchase' | 'renewal'
+  order_type: 'purchase' | 'renewal' | 'change_plan'
   order_amount_cents: number
   currency: string
   payment_status: string
@@ -10,7 +10,7 @@ export interface OrderUserSummary {
 
 export interface AdminOrderItem {
   order_no: string
-  order_type: 'purchase' | 'renewal'
+  order_type: 'purchase' | 'renewal' | 'change_plan'
   payment_status: 'unpaid' | 'paid' | 'manual_confirmed' | 'refunded'
   user: OrderUserSummary
   status: 'pending' | 'provisioning' | 'fulfilled' | 'error' | 'cancelled' | 'closed'
@@ -30,6 +30,9 @@ export interface AdminOrderItem {
 
 export interface AdminOrderDetail extends AdminOrderItem {
   user_note: string | null
+  source_backup_no: string | null
+  change_from_plan_no: string | null
+  credit_amount_cents: number
   cancel_reason: string | null
   closed_reason: string | null
   product_no: string
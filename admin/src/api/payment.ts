@@ -20,7 +20,7 @@ export interface AdminPaymentItem {
   paid_at: string | null
   created_at: string
   order_status: string
-  order_type: 'purchase' | 'renewal'
+  order_type: 'purchase' | 'renewal' | 'change_plan'
 }
 
 export interface AdminPaymentDetail extends AdminPaymentItem {
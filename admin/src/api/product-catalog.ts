@@ -43,10 +43,12 @@ export interface ProductPlanItem {
   bandwidth_mbps: number
   traffic_gb: number | null
   public_ip_count: number
+  snapshot_quota: number
   virtualization: string
   architecture: string
   is_featured: boolean
   status: string
+  capacity_sold_out_at: string | null
   visible: boolean
   sort_order: number
   created_at: string
@@ -66,6 +68,7 @@ export interface ProductPlanPayload {
   bandwidth_mbps: number
   traffic_gb?: number | null
   public_ip_count: number
+  snapshot_quota: number
   virtualization: 'kvm'
   architecture: 'x86_64'
   is_featured: boolean
@@ -97,6 +100,7 @@ export interface PlanPricePayload {
 export interface SalesRegionItem {
   id: number
   region_no: string
+  cluster_no: string
   code: string
   name: string
   country: string | null
@@ -109,6 +113,7 @@ export interface SalesRegionItem {
 
 export interface SalesRegionPayload {
   region_no?: string
+  cluster_no?: string
   code: string
   name: string
   country?: string | null
@@ -0,0 +1,36 @@
+import { http, type ApiEnvelope } from '../utils/request'
+import type { PaginatedData } from './admin-user'
+
+export type PTRRecordStatus = 'pending_review' | 'applying' | 'active' | 'failed' | 'rejected'
+
+export interface PTRRecordItem {
+  id: number
+  address: string
+  instance_no: string
+  user_id: number
+  hostname: string
+  applied_hostname: string | null
+  status: PTRRecordStatus
+  last_error: string | null
+  reviewed_by: number | null
+  reviewed_at: string | null
+  review_remark: string | null
+  applied_at: string | null
+  created_at: string
+  updated_at: string
+}
+
+export async function getPTRRecords(params?: Record<string, unknown>) {
+  const response = await http.get<ApiEnvelope<PaginatedData<PTRRecordItem>>>('/ptr-records', { params })
+  return response.data.data
+}
+
+export async function approvePTRRecord(id: number, remark?: string | null) {
+  const response = await http.post<ApiEnvelope<PTRRecordItem>>(`/ptr-records/${id}/approve`, { remark })
+  return response.data.data
+}
+
+export async function rejectPTRRecord(id: number, remark: string) {
+  const response = await http.post<ApiEnvelope<PTRRecordItem>>(`/ptr-records/${id}/reject`, { remark })
+  return response.data.data
+}
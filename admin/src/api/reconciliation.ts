@@ -0,0 +1,91 @@
+import { http, type ApiEnvelope } from '../utils/request'
+import type { PaginatedData } from './admin-user'
+
+export type ReconcileReportStatus = 'running' | 'succeeded' | 'failed'
+export type ReconcileKind = 'orphan' | 'ghost' | 'drift'
+export type ReconcileItemStatus = 'open' | 'resolved'
+
+export interface ReconcileNodeError {
+  cluster_no?: string
+  node: string
+  message: string
+}
+
+export interface ReconcileReportItem {
+  report_no: string
+  status: ReconcileReportStatus
+  task_no: string | null
+  node_count: number
+  vm_count: number
+  instance_count: number
+  orphan_count: number
+  ghost_count: number
+  drift_count: number
+  node_errors: ReconcileNodeError[]
+  error_message: string | null
+  started_at: string
+  finished_at: string | null
+}
+
+export interface ReconcileItem {
+  id: number
+  report_no: string
+  kind: ReconcileKind
+  cluster_no: string
+  node: string
+  vmid: number
+  vm_name: string | null
+  vm_status: string | null
+  vm_cpus: number | null
+  vm_memory_mb: number | null
+  instance_no: string | null
+  instance_status: string | null
+  drift: string[]
+  status: ReconcileItemStatus
+  resolution: 'adopted' | 'marked_error' | 'deleted' | null
+  resolved_by: number | null
+  resolved_at: string | null
+  remark: string | null
+  created_at: string
+}
+
+export interface ReconcileAdoptPayload {
+  user_id: number
+  plan_no: string
+  billing_cycle: string
+  region_no: string
+  network_type_no: string
+  template_no: string
+  expires_at?: string | null
+  remark?: string | null
+}
+
+export async function getReconcileReports(params?: Record<string, unknown>) {
+  const response = await http.get<ApiEnvelope<PaginatedData<ReconcileReportItem>>>('/instance-reconcile-reports', { params })
+  return response.data.data
+}
+
+export async function runReconcile() {
+  const response = await http.post<ApiEnvelope<ReconcileReportItem>>('/instance-reconcile-reports')
+  return response.data.data
+}
+
+export async function getReconcileItems(reportNo: string, params?: Record<string, unknown>) {
+  const response = await http.get<ApiEnvelope<PaginatedData<ReconcileItem>>>(`/instance-reconcile-reports/${reportNo}/items`, { params })
+  return response.data.data
+}
+
+export async function adoptReconcileOrphan(id: number, payload: ReconcileAdoptPayload) {
+  const response = await http.post<ApiEnvelope<ReconcileItem>>(`/instance-reconcile-items/${id}/adopt`, payload)
+  return response.data.data
+}
+
+export async function markReconcileGhostError(id: number, remark?: string | null) {
+  const response = await http.post<ApiEnvelope<ReconcileItem>>(`/instance-reconcile-items/${id}/mark-error`, { remark })
+  return response.data.data
+}
+
+export async function deleteReconcileOrphan(id: number, remark?: string | null) {
+  const response = await http.post<ApiEnvelope<ReconcileItem>>(`/instance-reconcile-items/${id}/delete-vm`, { remark })
+  return response.data.data
+}
@@ -26,7 +26,7 @@ export interface WalletLedgerItem {
   wallet_no: string
   user: WalletUserSummary
   direction: 'credit' | 'debit'
-  entry_type: 'recharge' | 'payment' | 'refund'
+  entry_type: 'recharge' | 'payment' | 'refund' | 'plan_credit' | 'traffic_overage'
   amount_cents: number
   balance_before_cents: number
   balance_after_cents: number
@@ -22,6 +22,8 @@ export const ADMIN_ROUTE_PATH = {
   wallets: '/wallets',
   invoices: '/invoices',
   instances: '/instances',
+  reconciliation: '/reconciliation',
+  rdns: '/rdns',
   asyncTasks: '/async-tasks',
   tickets: '/tickets',
   forbidden: '/403',
@@ -50,6 +52,8 @@ export const ADMIN_ROUTE_NAME = {
   wallets: 'wallets',
   invoices: 'invoices',
   instances: 'instances',
+  reconciliation: 'reconciliation',
+  rdns: 'rdns',
   asyncTasks: 'async-tasks',
   tickets: 'tickets',
   forbidden: 'forbidden',
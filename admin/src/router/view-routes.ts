@@ -241,6 +241,28 @@ export const viewRoutes: RouteRecordRaw[] = [
       permission: ['page.instances'],
     },
   },
+  {
+    path: ADMIN_ROUTE_PATH.reconciliation,
+    name: ADMIN_ROUTE_NAME.reconciliation,
+    component: () => import('../views/reconciliation/index.vue'),
+    meta: {
+      title: '资源对账',
+      icon: 'GitCompare',
+      requiresAuth: true,
+      permission: ['page.reconciliation'],
+    },
+  },
+  {
+    path: ADMIN_ROUTE_PATH.rdns,
+    name: ADMIN_ROUTE_NAME.rdns,
+    component: () => import('../views/rdns/index.vue'),
+    meta: {
+      title: 'PTR 审批',
+      icon: 'Globe',
+      requiresAuth: true,
+      permission: ['page.rdns'],
+    },
+  },
   {
     path: ADMIN_ROUTE_PATH.asyncTasks,
     name: ADMIN_ROUTE_NAME.asyncTasks,
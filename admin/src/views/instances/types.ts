@@ -1,12 +1,13 @@
-import type { InstanceMappingPayload, InstanceStatus, MappingStatus } from '../../api/instance'
+import type { InstanceBackupPolicyPayload, InstanceBackupStatus, InstanceMappingPayload, InstanceMetricsRange, InstanceMigrateMode, InstanceSnapshotStatus, InstanceStatus, IPAddressStatus, IPPoolStatus, ISOMedia, ISOPurpose, MappingStatus, MCPClusterStatus } from '../../api/instance'
 
-export type InstanceTabKey = 'instances' | 'mappings' | 'mcp'
+export type InstanceTabKey = 'instances' | 'mappings' | 'ip-pools' | 'isos' | 'mcp'
 export type MappingDialogMode = 'create' | 'edit'
 
 export const instanceStatusText: Record<InstanceStatus, string> = {
   creating: '创建中',
   running: '运行中',
   stopped: '已停止',
+  suspended: '已暂停',
   error: '异常',
   releasing: '释放中',
   released: '已释放',
@@ -16,8 +17,52 @@ export const operationActionText: Record<string, string> = {
   provision: '交付',
   start: '开机',
   stop: '关机',
+  reboot: '重启',
+  shutdown: '正常关机',
+  reset: '强制重置',
+  reinstall: '重装系统',
+  resize: '调整规格',
+  reset_password: '重置密码',
+  snapshot_create: '创建快照',
+  snapshot_rollback: '回滚快照',
+  snapshot_delete: '删除快照',
+  backup_create: '创建备份',
+  backup_restore: '恢复备份',
   release: '释放',
   sync: '同步',
+  suspend: '暂停关机',
+  rescue_enter: '进入救援',
+  rescue_exit: '退出救援',
+  iso_mount: '挂载镜像',
+  iso_unmount: '弹出镜像',
+  migrate: '迁移',
+}
+
+export const ipPoolStatusText: Record<IPPoolStatus, string> = {
+  active: '启用',
+  inactive: '停用',
+}
+
+export const isoPurposeText: Record<ISOPurpose, string> = {
+  rescue: '救援介质',
+  install: '安装镜像',
+}
+
+export const isoMediaText: Record<ISOMedia, string> = {
+  iso: '光驱',
+  disk: '临时磁盘',
+}
+
+export const clusterStatusText: Record<MCPClusterStatus, string> = {
+  online: '在线',
+  unreachable: '不可达',
+  disabled: '未启用',
+}
+
+export const ipAddressStatusText: Record<IPAddressStatus, string> = {
+  available: '可用',
+  reserved: '保留',
+  allocated: '已分配',
 }
 
 export const operationStatusText: Record<string, string> = {
@@ -26,6 +71,71 @@ export const operationStatusText: Record<string, string> = {
   failed: '失败',
 }
 
+export const snapshotStatusText: Record<InstanceSnapshotStatus, string> = {
+  creating: '创建中',
+  available: '可用',
+  deleting: '删除中',
+  deleted: '已删除',
+  failed: '失败',
+}
+
+export const backupStatusText: Record<InstanceBackupStatus, string> = {
+  creating: '备份中',
+  available: '可用',
+  deleting: '删除中',
+  deleted: '已删除',
+  failed: '失败',
+}
+
+export const backupSourceText: Record<string, string> = {
+  manual: '手动',
+  scheduled: '定时',
+}
+
+export const configDriftText: Record<string, string> = {
+  cpu_cores: 'CPU',
+  memory_mb: '内存',
+  system_disk_gb: '系统盘',
+  data_disk_gb: '数据盘',
+  bandwidth_mbps: '带宽',
+}
+
+export const trafficDirectionText: Record<string, string> = {
+  out: '出方向',
+  in: '入方向',
+  both: '入出之和',
+  max: '入出较大者',
+}
+
+export const trafficOverageText: Record<string, string> = {
+  throttle: '限速',
+  suspend: '暂停',
+  bill: '按量扣费',
+}
+
+export const suspendSourceText: Record<string, string> = {
+  expiry: '到期暂停',
+  admin: '人工暂停',
+}
+
+export const transferStatusText: Record<string, string> = {
+  pending: '待接收',
+  completed: '已完成',
+  rejected: '已拒绝',
+  cancelled: '已撤销',
+}
+
+export const firewallStatusText: Record<string, string> = {
+  synced: '已生效',
+  pending: '待下发',
+}
+
+export const weekdayOptions = ['周日', '周一', '周二', '周三', '周四', '周五', '周六'].map((label, value) => ({ label, value }))
+
+export function makeDefaultBackupPolicy(): InstanceBackupPolicyPayload {
+  return { frequency: 'daily', weekday: 0, hour: 3, retention_count: 7, enabled: true }
+}
+
 export const mappingStatusText: Record<MappingStatus, string> = {
   active: '启用',
   inactive: '停用',
@@ -40,10 +150,12 @@ export function makeEmptyMappingForm(): InstanceMappingPayload {
     template_no: '',
     network_type_no: '',
     node: '',
+    placement_nodes: null,
     storage: '',
     disk_source: '',
     disk_format: null,
     disk_interface: null,
+    data_disk_storage: null,
     snippets_storage: null,
     ci_user: null,
     ssh_keys: null,
@@ -55,7 +167,21 @@ export function makeEmptyMappingForm(): InstanceMappingPayload {
     vmid_start: 100,
     vmid_end: 999,
     next_vmid: 100,
+    reuse_released_vmids: false,
     status: 'active',
     remark: null,
   }
 }
+
+export const metricsRangeOptions: { label: string; value: InstanceMetricsRange }[] = [
+  { label: '最近 1 小时', value: 'hour' },
+  { label: '最近 1 天', value: 'day' },
+  { label: '最近 1 周', value: 'week' },
+  { label: '最近 1 月', value: 'month' },
+]
+
+export const migrateModeOptions: { label: string; value: InstanceMigrateMode }[] = [
+  { label: '自动（在线失败后离线）', value: 'auto' },
+  { label: '仅在线迁移', value: 'online' },
+  { label: '离线迁移', value: 'offline' },
+]
@@ -8,6 +8,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/rdns"
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/cache"
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/database"
@@ -27,6 +28,7 @@ type App struct {
 	Logs        *logsusecase.Service
 	LogRecorder *weblogging.Recorder
 	MCPPVE      *mcppve.Client
+	RDNS        rdns.Backend
 	Routes      RouteSets
 }
 
@@ -61,6 +63,11 @@ func NewApp(ctx context.Context, configPath string) (*App, error) {
 		return nil, fmt.Errorf("初始化虚拟化管理接口失败: %w", err)
 	}
 
+	rdnsBackend, err := rdns.NewBackend(cfg.RDNS)
+	if err != nil {
+		return nil, fmt.Errorf("初始化反向解析接口失败: %w", err)
+	}
+
 	app := &App{
 		Config:      cfg,
 		DB:          db,
@@ -69,6 +76,7 @@ func NewApp(ctx context.Context, configPath string) (*App, error) {
 		Logs:        logsusecase.NewService(db),
 		LogRecorder: weblogging.NewRecorder(db),
 		MCPPVE:      mcpPVEClient,
+		RDNS:        rdnsBackend,
 	}
 	app.Routes = NewRouteSets(app)
 	return app, nil
@@ -145,14 +145,14 @@ func NewRouteSets(app *App) RouteSets {
 			SystemConfig:   systemconfighttp.NewSystemConfigHandler(systemconfigusecase.NewSystemConfigService(app.DB, auditService)),
 			WebUser:        webuserhttp.NewWebUserHandler(webuserusecase.NewWebUserService(app.DB, auditService)),
 			FileAttachment: fileattachmenthttp.NewFileAttachmentHandler(fileattachmentusecase.NewFileAttachmentService(app.DB, auditService, app.Config.Storage)),
-			ProductCatalog: productcataloghttp.NewProductCatalogHandler(productcatalogusecase.NewProductCatalogService(app.DB, auditService)),
+			ProductCatalog: productcataloghttp.NewProductCatalogHandler(productcatalogusecase.NewProductCatalogService(app.DB, auditService).SetMCPPVEConfig(app.Config.MCPPVE)),
 			RealName:       adminrealnamehttp.NewRealNameHandler(adminrealnameusecase.NewRealNameService(app.DB, app.Redis, auditService)),
 			Logs:           adminlogshttp.NewHandler(logsService),
 			Order:          adminorderhttp.NewHandler(adminorderusecase.NewService(app.DB, auditService, app.Config.InstanceLifecycle)),
 			Payment:        adminpaymenthttp.NewHandler(adminpaymentusecase.NewService(app.DB, webPaymentService, auditService).SetAlertRecorder(paymentAlertRecorder)),
 			Wallet:         adminwallethttp.NewHandler(adminwalletusecase.NewService(app.DB)),
 			Invoice:        admininvoicehttp.NewHandler(admininvoiceusecase.NewService(app.DB, auditService, app.Config.Storage)),
-			Instance:       admininstancehttp.NewHandler(admininstanceusecase.NewService(app.DB, app.MCPPVE, auditService, app.Config.InstanceLifecycle)),
+			Instance:       admininstancehttp.NewHandler(admininstanceusecase.NewService(app.DB, app.MCPPVE, auditService, app.Config.InstanceLifecycle).SetBackupConfig(app.Config.Backup).SetConsole(app.Redis, app.Config.Console).SetMetrics(app.Redis, app.Config.Metrics).SetCredentialConfig(app.Config.Credential).SetPlacementConfig(app.Config.Placement).SetTrafficConfig(app.Config.Traffic).SetRescueConfig(app.Config.Rescue).SetRDNS(app.Config.RDNS, app.RDNS, nil).SetRealName(webRealNameService)),
 			AsyncTask:      asynctaskhttp.NewHandler(asynctaskusecase.NewService(app.DB, auditService)),
 			Ticket:         admintickethttp.NewHandler(adminticketusecase.NewService(app.DB, auditService, app.Config.Storage)),
 			Audit:          audithttp.NewAdminAuditHandler(auditService, adminmiddleware.CurrentAdminPermissionCodes),
@@ -169,7 +169,7 @@ func NewRouteSets(app *App) RouteSets {
 			Payment:        webpaymenthttp.NewHandler(webPaymentService),
 			Wallet:         webwallethttp.NewHandler(webWalletService),
 			Invoice:        webinvoicehttp.NewHandler(webinvoiceusecase.NewService(app.DB, app.Config.Storage)),
-			Instance:       webinstancehttp.NewHandler(webinstanceusecase.NewService(app.DB, app.MCPPVE)),
+			Instance:       webinstancehttp.NewHandler(webinstanceusecase.NewService(app.DB, app.MCPPVE).SetBackupConfig(app.Config.Backup).SetConsole(app.Redis, app.Config.Console).SetMetrics(app.Redis, app.Config.Metrics).SetCredentialConfig(app.Config.Credential).SetTrafficConfig(app.Config.Traffic).SetRescueConfig(app.Config.Rescue).SetRDNS(app.Config.RDNS, app.RDNS, nil).SetRealName(webRealNameService)),
 			Ticket:         webtickethttp.NewHandler(webticketusecase.NewService(app.DB, app.Config.Storage)),
 			ClientLogs:     clientlogshttp.NewHandler("web", app.Redis, app.LogRecorder),
 			AuthMiddleware: webmiddleware.UserAuth(webAuthService),
@@ -9,6 +9,7 @@ import (
 
 	"github.com/AeolianCloud/pveCloud/server/internal/integration/mail"
 	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/rdns"
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/cache"
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/database"
@@ -42,7 +43,11 @@ func NewApp(ctx context.Context, configPath string) (*App, error) {
 	if err != nil {
 		return nil, fmt.Errorf("初始化虚拟化管理接口失败: %w", err)
 	}
+	rdnsBackend, err := rdns.NewBackend(cfg.RDNS)
+	if err != nil {
+		return nil, fmt.Errorf("初始化反向解析接口失败: %w", err)
+	}
 	app := &App{Config: cfg, DB: db, Redis: redisClient, Logger: log, MCPPVE: mcpPVEClient}
-	app.Runner = NewRunner(db, log, mcpPVEClient, mail.NewSender(cfg.Mail), cfg.Worker, cfg.InstanceLifecycle, cfg.Notification)
+	app.Runner = NewRunner(db, log, mcpPVEClient, mail.NewSender(cfg.Mail), cfg.Worker, cfg.InstanceLifecycle, cfg.Notification).SetBackupConfig(cfg.Backup).SetCredentialConfig(cfg.Credential).SetPlacementConfig(cfg.Placement).SetTrafficConfig(cfg.Traffic).SetRescueConfig(cfg.Rescue).SetRDNS(cfg.RDNS, rdnsBackend)
 	return app, nil
 }
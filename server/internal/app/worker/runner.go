@@ -15,6 +15,7 @@ import (
 	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
 	"github.com/AeolianCloud/pveCloud/server/internal/integration/mail"
 	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/rdns"
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
 	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
 	mysqlorder "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/order"
@@ -28,16 +29,29 @@ type Runner struct {
 	tasks        *mysqlinstance.Repository
 	orders       *mysqlorder.Repository
 	instanceSvc  *admininstance.Service
+	mcp          *mcppve.Client
 	mail         *mail.Sender
 	workerCfg    config.WorkerConfig
 	lifecycleCfg config.InstanceLifecycleConfig
 	notifyCfg    config.NotificationConfig
+	placementCfg config.PlacementConfig
+	trafficCfg   config.TrafficConfig
+	// capacitySlot、reconcileSlot 和 trafficSlot 是本进程最近一次投递对应周期任务的时间槽，避免每次轮询都写库。
+	capacitySlot  time.Time
+	reconcileSlot time.Time
+	trafficSlot   time.Time
 }
 
 type taskPayload struct {
-	InstanceNo     string `json:"instance_no,omitempty"`
-	ExpiresAt      string `json:"expires_at,omitempty"`
-	NotificationNo string `json:"notification_no,omitempty"`
+	InstanceNo     string  `json:"instance_no,omitempty"`
+	ExpiresAt      string  `json:"expires_at,omitempty"`
+	NotificationNo string  `json:"notification_no,omitempty"`
+	ScheduledAt    string  `json:"scheduled_at,omitempty"`
+	SourceNode     string  `json:"source_node,omitempty"`
+	TargetNode     string  `json:"target_node,omitempty"`
+	Mode           string  `json:"mode,omitempty"`
+	AdminID        *uint64 `json:"admin_id,omitempty"`
+	UserID         uint64  `json:"user_id,omitempty"`
 }
 
 var errPaymentProvisionSkipped = errors.New("payment provision task skipped")
@@ -49,6 +63,7 @@ func NewRunner(db *gorm.DB, log *slog.Logger, mcp *mcppve.Client, mailSender *ma
 		tasks:        mysqlinstance.NewRepository(db),
 		orders:       mysqlorder.NewRepository(db),
 		instanceSvc:  admininstance.NewService(db, mcp, nil, lifecycleCfg),
+		mcp:          mcp,
 		mail:         mailSender,
 		workerCfg:    workerCfg,
 		lifecycleCfg: lifecycleCfg,
@@ -56,6 +71,44 @@ func NewRunner(db *gorm.DB, log *slog.Logger, mcp *mcppve.Client, mailSender *ma
 	}
 }
 
+// SetCredentialConfig 注入实例凭据加密配置，供支付后自动交付生成 root 密码。
+func (r *Runner) SetCredentialConfig(cfg config.CredentialConfig) *Runner {
+	r.instanceSvc.SetCredentialConfig(cfg)
+	return r
+}
+
+// SetPlacementConfig 注入多节点交付调度配置，供支付后自动交付选择放置节点。
+func (r *Runner) SetPlacementConfig(cfg config.PlacementConfig) *Runner {
+	r.placementCfg = cfg
+	r.instanceSvc.SetPlacementConfig(cfg)
+	return r
+}
+
+// SetTrafficConfig 注入流量计量配置，供周期流量采样和超额处理使用。
+func (r *Runner) SetTrafficConfig(cfg config.TrafficConfig) *Runner {
+	r.trafficCfg = cfg
+	r.instanceSvc.SetTrafficConfig(cfg)
+	return r
+}
+
+// SetRDNS 注入 PTR 管理配置和 DNS 后端，供实例释放完成后清理反向解析记录。
+func (r *Runner) SetRDNS(cfg config.RDNSConfig, backend rdns.Backend) *Runner {
+	r.instanceSvc.SetRDNS(cfg, backend, nil)
+	return r
+}
+
+// SetRescueConfig 注入救援模式配置，救援到时自动退出由 Worker 执行。
+func (r *Runner) SetRescueConfig(cfg config.RescueConfig) *Runner {
+	r.instanceSvc.SetRescueConfig(cfg)
+	return r
+}
+
+// SetBackupConfig 注入备份存储配置，供定时备份任务使用。
+func (r *Runner) SetBackupConfig(cfg config.BackupConfig) *Runner {
+	r.instanceSvc.SetBackupConfig(cfg)
+	return r
+}
+
 func (r *Runner) Run(ctx context.Context) error {
 	if !r.workerCfg.Enabled {
 		r.log.Info("Worker 未启用，进程空闲等待退出")
@@ -65,6 +118,15 @@ func (r *Runner) Run(ctx context.Context) error {
 	ticker := time.NewTicker(r.pollInterval())
 	defer ticker.Stop()
 	for {
+		if err := r.schedulePeriodic(ctx, domaininstance.TaskTypeCapacitySync, "TASK-CAPACITY-", "catalog", r.placementCfg.CapacitySyncIntervalSeconds, &r.capacitySlot); err != nil {
+			r.log.Error("套餐容量同步任务投递失败", "error", err)
+		}
+		if err := r.schedulePeriodic(ctx, domaininstance.TaskTypeReconcile, "TASK-RECONCILE-", "instance", r.workerCfg.ReconcileIntervalSeconds, &r.reconcileSlot); err != nil {
+			r.log.Error("资源对账任务投递失败", "error", err)
+		}
+		if err := r.schedulePeriodic(ctx, domaininstance.TaskTypeTrafficMeter, "TASK-TRAFFIC-", "instance", r.trafficCfg.MeterIntervalSeconds, &r.trafficSlot); err != nil {
+			r.log.Error("流量采样任务投递失败", "error", err)
+		}
 		if err := r.PollOnce(ctx); err != nil {
 			r.log.Error("Worker 轮询失败", "error", err)
 		}
@@ -126,17 +188,158 @@ func (r *Runner) execute(ctx context.Context, task mysqlinstance.Task) error {
 		return r.expiryNotice(ctx, task)
 	case domaininstance.TaskTypeExpiryRelease:
 		return r.expiryRelease(ctx, task)
+	case domaininstance.TaskTypeExpirySuspend:
+		return r.expirySuspend(ctx, task)
 	case domaininstance.TaskTypePaymentProvision:
 		return r.paymentOrderProvision(ctx, task)
 	case domaininstance.TaskTypeEmailSend:
 		return r.notificationEmailSend(ctx, task)
 	case domaininstance.TaskTypeSMSPlaceholder:
 		return r.notificationPlaceholder(ctx, task)
+	case domaininstance.TaskTypeBackupScheduled:
+		return r.backupScheduled(ctx, task)
+	case domaininstance.TaskTypeChangePlan:
+		return r.changePlan(ctx, task)
+	case domaininstance.TaskTypeCapacitySync:
+		return r.capacitySync(ctx, task)
+	case domaininstance.TaskTypeReconcile:
+		return r.reconcile(ctx, task)
+	case domaininstance.TaskTypeTrafficMeter:
+		return r.trafficMeter(ctx, task)
+	case domaininstance.TaskTypeRescueExit:
+		return r.rescueExit(ctx, task)
+	case domaininstance.TaskTypeMigrate:
+		return r.migrate(ctx, task)
+	case domaininstance.TaskTypeCredentialReset:
+		return r.credentialReset(ctx, task)
 	default:
 		return fmt.Errorf("不支持的任务类型：%s", task.TaskType)
 	}
 }
 
+// backupScheduled 触发一次定时备份；备份仍在进行时延后重入，完成后按保留份数清理过期的定时备份。
+func (r *Runner) backupScheduled(ctx context.Context, task mysqlinstance.Task) error {
+	payload := parsePayload(task.Payload)
+	if strings.TrimSpace(payload.InstanceNo) == "" && task.ObjectNo != nil {
+		payload.InstanceNo = *task.ObjectNo
+	}
+	scheduledAt, err := time.Parse(time.RFC3339Nano, payload.ScheduledAt)
+	if err != nil {
+		return errors.New("定时备份任务缺少计划时间")
+	}
+	backup, err := r.instanceSvc.TriggerScheduledBackup(ctx, task.TaskNo, payload.InstanceNo, scheduledAt)
+	if errors.Is(err, admininstance.ErrBackupScheduleSkipped) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch backup.Status {
+	case domaininstance.BackupStatusCreating:
+		return admininstance.ErrOperationPending
+	case domaininstance.BackupStatusFailed:
+		return fmt.Errorf("定时备份 %s 创建失败，详见实例操作记录", backup.BackupNo)
+	}
+	return r.pruneBackups(ctx, backup.InstanceID)
+}
+
+// changePlan 为变更套餐订单发起规格调整；订单已结束时直接完成任务，实例有进行中的操作时延后重入。
+func (r *Runner) changePlan(ctx context.Context, task mysqlinstance.Task) error {
+	orderNo := strings.TrimSpace(pointerValue(task.ObjectNo))
+	if orderNo == "" {
+		return errors.New("变更套餐任务缺少订单编号")
+	}
+	_, err := r.instanceSvc.ApplyChangePlan(ctx, orderNo)
+	if errors.Is(err, admininstance.ErrChangePlanSkipped) {
+		return nil
+	}
+	return err
+}
+
+// schedulePeriodic 按间隔秒数为每个时间槽投递一条周期任务，间隔为 0 表示不投递；幂等键由任务类型和时间槽组成，
+// 多个 Worker 同时投递也只会生成一条任务。last 记录本进程最近一次投递的时间槽。
+func (r *Runner) schedulePeriodic(ctx context.Context, taskType string, taskNoPrefix string, objectType string, intervalSeconds int, last *time.Time) error {
+	interval := time.Duration(intervalSeconds) * time.Second
+	if interval <= 0 {
+		return nil
+	}
+	slot := time.Now().Truncate(interval)
+	if slot.Equal(*last) {
+		return nil
+	}
+	slotKey := slot.UTC().Format("20060102T150405Z")
+	idempotencyKey := taskType + ":" + slotKey
+	task := mysqlinstance.Task{TaskNo: taskNoPrefix + slotKey, TaskType: taskType, IdempotencyKey: &idempotencyKey, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, MaxAttempts: 3, ScheduledAt: slot}
+	if err := r.tasks.CreateTaskIgnoreDuplicate(ctx, nil, &task); err != nil {
+		return err
+	}
+	*last = slot
+	return nil
+}
+
+// capacitySync 按节点容量自动售罄或恢复套餐，并把变更数量写入任务结果。
+func (r *Runner) capacitySync(ctx context.Context, task mysqlinstance.Task) error {
+	changed, err := r.instanceSvc.SyncPlanCapacity(ctx)
+	if err != nil {
+		return err
+	}
+	return r.tasks.UpdateTask(ctx, nil, task.ID, map[string]any{"result": fmt.Sprintf(`{"changed_plans":%d}`, changed)})
+}
+
+// reconcile 比对各节点 VM 与实例记录生成对账报告，并把报告编号和差异数量写入任务结果。
+func (r *Runner) reconcile(ctx context.Context, task mysqlinstance.Task) error {
+	report, err := r.instanceSvc.ReconcileByWorker(ctx, task.TaskNo)
+	if err != nil {
+		return err
+	}
+	return r.tasks.UpdateTask(ctx, nil, task.ID, map[string]any{"result": fmt.Sprintf(`{"report_no":%q,"orphans":%d,"ghosts":%d,"drifts":%d}`, report.ReportNo, report.OrphanCount, report.GhostCount, report.DriftCount)})
+}
+
+// trafficMeter 采样实例网卡计数器并累计当月流量，把采样和超额处理数量写入任务结果。
+// 部分节点或实例失败时仍写入结果并返回错误，由任务重试补采。
+func (r *Runner) trafficMeter(ctx context.Context, task mysqlinstance.Task) error {
+	result, meterErr := r.instanceSvc.MeterTrafficByWorker(ctx)
+	data, _ := json.Marshal(result)
+	if err := r.tasks.UpdateTask(ctx, nil, task.ID, map[string]any{"result": string(data)}); err != nil {
+		return err
+	}
+	return meterErr
+}
+
+// pruneBackups 删除超出策略保留份数的定时备份；手动备份不参与轮转。
+func (r *Runner) pruneBackups(ctx context.Context, instanceID uint64) error {
+	policy, err := r.tasks.BackupPolicy(ctx, instanceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	expired, err := r.tasks.ExpiredScheduledBackups(ctx, instanceID, policy.RetentionCount)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, backup := range expired {
+		if backup.VolumeID == nil {
+			continue
+		}
+		if err := r.tasks.UpdateBackup(ctx, nil, backup.ID, map[string]any{"status": domaininstance.BackupStatusDeleting}); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := r.mcp.Cluster(backup.ClusterNo).DeleteBackup(ctx, backup.Node, backup.Storage, *backup.VolumeID); err != nil {
+			_ = r.tasks.UpdateBackup(context.Background(), nil, backup.ID, map[string]any{"status": domaininstance.BackupStatusAvailable})
+			errs = append(errs, fmt.Errorf("清理过期备份 %s 失败：%w", backup.BackupNo, err))
+			continue
+		}
+		if err := r.tasks.UpdateBackup(ctx, nil, backup.ID, map[string]any{"status": domaininstance.BackupStatusDeleted, "deleted_at": time.Now()}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (r *Runner) paymentOrderProvision(ctx context.Context, task mysqlinstance.Task) error {
 	orderNo := strings.TrimSpace(pointerValue(task.ObjectNo))
 	if orderNo == "" {
@@ -245,6 +448,51 @@ func (r *Runner) expiryRelease(ctx context.Context, task mysqlinstance.Task) err
 	return err
 }
 
+// expirySuspend 在实例到期时暂停实例；续费或调整到期时间后任务负载中的到期时间不再匹配，直接跳过。
+func (r *Runner) expirySuspend(ctx context.Context, task mysqlinstance.Task) error {
+	if !r.lifecycleCfg.AutoSuspendEnabled {
+		return nil
+	}
+	payload := parsePayload(task.Payload)
+	instanceNo := firstNonEmpty(payload.InstanceNo, pointerValue(task.ObjectNo))
+	expectedExpiresAt, ok := parseExpiresAt(payload.ExpiresAt)
+	if instanceNo == "" || !ok {
+		return nil
+	}
+	return r.instanceSvc.SuspendExpiredByWorker(ctx, task.TaskNo, instanceNo, expectedExpiresAt)
+}
+
+// rescueExit 在救援到时后自动退出救援；到期时间与实例当前救援不一致说明已手动退出或重新进入，直接跳过。
+func (r *Runner) rescueExit(ctx context.Context, task mysqlinstance.Task) error {
+	payload := parsePayload(task.Payload)
+	instanceNo := firstNonEmpty(payload.InstanceNo, pointerValue(task.ObjectNo))
+	expectedExpiresAt, ok := parseExpiresAt(payload.ScheduledAt)
+	if instanceNo == "" || !ok {
+		return nil
+	}
+	return r.instanceSvc.ExitRescueByWorker(ctx, instanceNo, expectedExpiresAt)
+}
+
+// migrate 执行节点疏散投递的单实例迁移；缺少源节点或目标节点的任务直接跳过。
+func (r *Runner) migrate(ctx context.Context, task mysqlinstance.Task) error {
+	payload := parsePayload(task.Payload)
+	instanceNo := firstNonEmpty(payload.InstanceNo, pointerValue(task.ObjectNo))
+	if instanceNo == "" || payload.SourceNode == "" || payload.TargetNode == "" {
+		return nil
+	}
+	return r.instanceSvc.MigrateByWorker(ctx, instanceNo, payload.SourceNode, payload.TargetNode, payload.Mode, payload.AdminID)
+}
+
+// credentialReset 在实例转移后为新所有者重置 root 密码；缺少实例或接收用户的任务直接跳过。
+func (r *Runner) credentialReset(ctx context.Context, task mysqlinstance.Task) error {
+	payload := parsePayload(task.Payload)
+	instanceNo := firstNonEmpty(payload.InstanceNo, pointerValue(task.ObjectNo))
+	if instanceNo == "" || payload.UserID == 0 {
+		return nil
+	}
+	return r.instanceSvc.ResetCredentialsByWorker(ctx, instanceNo, payload.UserID)
+}
+
 func (r *Runner) notificationEmailSend(ctx context.Context, task mysqlinstance.Task) error {
 	payload := parsePayload(task.Payload)
 	notificationNo := firstNonEmpty(payload.NotificationNo, pointerValue(task.ObjectNo))
@@ -321,7 +569,7 @@ func (r *Runner) enqueueNotificationTask(ctx context.Context, tx *gorm.DB, sourc
 		NotificationNo:    notificationNo,
 		UserID:            row.UserID,
 		Channel:           channel,
-		Scene:             "instance_expiry_notice",
+		Scene:             domaininstance.NotificationSceneExpiryNotice,
 		Target:            target,
 		Status:            status,
 		Subject:           stringPtr("实例即将到期"),
@@ -338,7 +586,11 @@ func (r *Runner) enqueueNotificationTask(ctx context.Context, tx *gorm.DB, sourc
 	return r.tasks.CreateTaskIgnoreDuplicate(ctx, tx, &task)
 }
 
+// markInstanceNoticeSent 只为到期提醒回写实例的 expire_notice_sent_at；流量等其他场景的实例通知不影响到期提醒状态。
 func (r *Runner) markInstanceNoticeSent(ctx context.Context, notification mysqlinstance.Notification, sentAt time.Time) error {
+	if notification.Scene != domaininstance.NotificationSceneExpiryNotice {
+		return nil
+	}
 	if notification.RelatedObjectType == nil || *notification.RelatedObjectType != "instance" || notification.RelatedObjectNo == nil {
 		return nil
 	}
@@ -374,7 +626,7 @@ func (r *Runner) markFailedOrRetry(ctx context.Context, task mysqlinstance.Task,
 		now := time.Now()
 		updates["status"] = domaininstance.TaskStatusFailed
 		updates["completed_at"] = now
-		if task.TaskType == domaininstance.TaskTypePaymentProvision {
+		if task.TaskType == domaininstance.TaskTypePaymentProvision || task.TaskType == domaininstance.TaskTypeChangePlan {
 			if updateErr := r.markPaymentProvisionError(ctx, task); updateErr != nil {
 				return updateErr
 			}
@@ -386,6 +638,7 @@ func (r *Runner) markFailedOrRetry(ctx context.Context, task mysqlinstance.Task,
 	return r.tasks.UpdateTask(ctx, nil, task.ID, updates)
 }
 
+// markPaymentProvisionError 在自动交付或变更套餐任务重试耗尽后把已支付订单置为 error，供管理端重试或退款。
 func (r *Runner) markPaymentProvisionError(ctx context.Context, task mysqlinstance.Task) error {
 	orderNo := strings.TrimSpace(pointerValue(task.ObjectNo))
 	if orderNo == "" {
@@ -403,7 +656,7 @@ func (r *Runner) markPaymentProvisionError(ctx context.Context, task mysqlinstan
 		if err != nil {
 			return err
 		}
-		if order.OrderType != domainorder.TypePurchase || order.PaymentStatus != domainorder.PaymentStatusPaid {
+		if (order.OrderType != domainorder.TypePurchase && order.OrderType != domainorder.TypeChangePlan) || order.PaymentStatus != domainorder.PaymentStatusPaid {
 			return nil
 		}
 		if order.Status != domainorder.StatusPending && order.Status != domainorder.StatusProvisioning {
@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	"gorm.io/gorm"
+
 	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
 	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
@@ -74,25 +76,7 @@ func TestPaymentProvisionTaskMarksOrderErrorAfterMaxAttempts(t *testing.T) {
 	mysqltest.Exec(t, db, asyncTasksSchema)
 
 	now := time.Now().Truncate(time.Millisecond)
-	if err := db.Exec(`
-INSERT INTO orders (
-  order_no, user_id, client_token, status, order_type, product_no, product_type,
-  product_name, plan_no, plan_code, plan_name, cpu_cores, memory_mb,
-  system_disk_gb, data_disk_gb, bandwidth_mbps, public_ip_count,
-  virtualization, architecture, billing_cycle, price_cents, currency, quantity,
-  total_amount_cents, payment_status, region_no, region_code, region_name,
-  network_type_no, network_type_code, network_type_name, template_no, template_code,
-  template_name, os_family, os_distribution, os_version, os_architecture,
-  created_at, updated_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		"ORD-payment-error", 1, "token-payment-error", domainorder.StatusPending, domainorder.TypePurchase,
-		"PROD-1", "server", "Product", "PLAN-1", "basic", "Plan", 2, 2048, 40, 0, 10, 1,
-		"kvm", "x86_64", "monthly", 1000, "CNY", 1, 1000, domainorder.PaymentStatusPaid,
-		"REG-1", "default", "Region", "NET-1", "default", "Default", "TPL-1", "debian-12",
-		"Debian 12", "linux", "debian", "12", "x86_64", now, now,
-	).Error; err != nil {
-		t.Fatalf("insert order: %v", err)
-	}
+	insertPaidOrder(t, db, "ORD-payment-error", domainorder.TypePurchase, domainorder.StatusPending)
 	objectType := "order"
 	objectNo := "ORD-payment-error"
 	if err := db.Exec(`
@@ -139,6 +123,86 @@ INSERT INTO async_tasks (
 	}
 }
 
+func TestChangePlanTaskMarksOrderErrorAfterMaxAttempts(t *testing.T) {
+	db := mysqltest.Open(t)
+	mysqltest.Exec(t, db, ordersSchema)
+	mysqltest.Exec(t, db, instancesSchema)
+	mysqltest.Exec(t, db, asyncTasksSchema)
+	insertPaidOrder(t, db, "ORD-change-error", domainorder.TypeChangePlan, domainorder.StatusProvisioning)
+	now := time.Now().Truncate(time.Millisecond)
+	if err := db.Exec(`
+INSERT INTO async_tasks (
+  task_no, task_type, idempotency_key, status, object_type, object_no,
+  attempts, max_attempts, scheduled_at, created_at, updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		"TASK-change-error", domaininstance.TaskTypeChangePlan, "instance_change_plan:ORD-change-error",
+		domaininstance.TaskStatusRunning, "order", "ORD-change-error", 10, 10, now, now, now,
+	).Error; err != nil {
+		t.Fatalf("insert task: %v", err)
+	}
+
+	runner := &Runner{db: db, tasks: mysqlinstance.NewRepository(db), orders: mysqlorder.NewRepository(db)}
+	task, err := runner.tasks.TaskByNo(context.Background(), "TASK-change-error")
+	if err != nil {
+		t.Fatalf("load task: %v", err)
+	}
+	if err := runner.markFailedOrRetry(context.Background(), task, errors.New("resize rejected")); err != nil {
+		t.Fatalf("mark change plan failed: %v", err)
+	}
+	var orderStatus string
+	if err := db.Table("orders").Select("status").Where("order_no = ?", "ORD-change-error").Row().Scan(&orderStatus); err != nil {
+		t.Fatalf("load order status: %v", err)
+	}
+	if orderStatus != domainorder.StatusError {
+		t.Fatalf("paid change plan order should move to error after exhausted retries so it can be refunded, got %s", orderStatus)
+	}
+}
+
+func TestPreparePaymentProvisionOrderSkipsChangePlanOrder(t *testing.T) {
+	db := mysqltest.Open(t)
+	mysqltest.Exec(t, db, ordersSchema)
+	mysqltest.Exec(t, db, instancesSchema)
+	insertPaidOrder(t, db, "ORD-change-provision", domainorder.TypeChangePlan, domainorder.StatusError)
+
+	runner := &Runner{db: db, tasks: mysqlinstance.NewRepository(db), orders: mysqlorder.NewRepository(db)}
+	// 变更套餐订单不能走新购交付，否则会为已有实例再创建一台 VM。
+	if err := runner.preparePaymentProvisionOrder(context.Background(), "ORD-change-provision"); !errors.Is(err, errPaymentProvisionSkipped) {
+		t.Fatalf("change plan order should be skipped by payment provision, got %v", err)
+	}
+	var orderStatus string
+	if err := db.Table("orders").Select("status").Where("order_no = ?", "ORD-change-provision").Row().Scan(&orderStatus); err != nil {
+		t.Fatalf("load order status: %v", err)
+	}
+	if orderStatus != domainorder.StatusError {
+		t.Fatalf("skipped change plan order should keep its status, got %s", orderStatus)
+	}
+}
+
+// insertPaidOrder 写入一条已支付订单，供交付和变更套餐任务的失败处理使用。
+func insertPaidOrder(t *testing.T, db *gorm.DB, orderNo string, orderType string, status string) {
+	t.Helper()
+	now := time.Now().Truncate(time.Millisecond)
+	if err := db.Exec(`
+INSERT INTO orders (
+  order_no, user_id, client_token, status, order_type, product_no, product_type,
+  product_name, plan_no, plan_code, plan_name, cpu_cores, memory_mb,
+  system_disk_gb, data_disk_gb, bandwidth_mbps, public_ip_count,
+  virtualization, architecture, billing_cycle, price_cents, currency, quantity,
+  total_amount_cents, payment_status, region_no, region_code, region_name,
+  network_type_no, network_type_code, network_type_name, template_no, template_code,
+  template_name, os_family, os_distribution, os_version, os_architecture,
+  created_at, updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		orderNo, 1, "token-"+orderNo, status, orderType,
+		"PROD-1", "server", "Product", "PLAN-1", "basic", "Plan", 2, 2048, 40, 0, 10, 1,
+		"kvm", "x86_64", "monthly", 1000, "CNY", 1, 1000, domainorder.PaymentStatusPaid,
+		"REG-1", "default", "Region", "NET-1", "default", "Default", "TPL-1", "debian-12",
+		"Debian 12", "linux", "debian", "12", "x86_64", now, now,
+	).Error; err != nil {
+		t.Fatalf("insert order: %v", err)
+	}
+}
+
 const instancesSchema = `
 CREATE TABLE instances (
   id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
@@ -156,6 +220,8 @@ CREATE TABLE instances (
   system_disk_gb INT NOT NULL,
   data_disk_gb INT NOT NULL,
   bandwidth_mbps INT NOT NULL,
+  traffic_gb INT NULL,
+  traffic_overage_action VARCHAR(16) NULL,
   region_no VARCHAR(64) NOT NULL,
   region_name VARCHAR(128) NOT NULL,
   network_type_no VARCHAR(64) NULL,
@@ -170,6 +236,12 @@ CREATE TABLE instances (
   external_resource_location VARCHAR(255) NULL,
   last_error_code VARCHAR(64) NULL,
   last_error_message VARCHAR(500) NULL,
+  config_drift VARCHAR(255) NULL,
+  config_checked_at DATETIME(3) NULL,
+  firewall_status VARCHAR(16) NULL,
+  firewall_synced_at DATETIME(3) NULL,
+  root_password_ciphertext TEXT NULL,
+  root_password_revealed_at DATETIME(3) NULL,
   service_started_at DATETIME(3) NULL,
   expires_at DATETIME(3) NULL,
   expire_notice_sent_at DATETIME(3) NULL,
@@ -192,6 +264,9 @@ CREATE TABLE orders (
   status VARCHAR(32) NOT NULL,
   order_type VARCHAR(32) NOT NULL DEFAULT 'purchase',
   related_instance_no VARCHAR(64) NULL,
+  change_from_plan_no VARCHAR(64) NULL,
+  source_backup_no VARCHAR(64) NULL,
+  ssh_keys TEXT NULL,
   product_no VARCHAR(64) NOT NULL,
   product_type VARCHAR(32) NOT NULL,
   product_name VARCHAR(128) NOT NULL,
@@ -215,6 +290,7 @@ CREATE TABLE orders (
   currency CHAR(3) NOT NULL,
   quantity INT NOT NULL DEFAULT 1,
   total_amount_cents BIGINT UNSIGNED NOT NULL,
+  credit_amount_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
   payment_status VARCHAR(32) NOT NULL DEFAULT 'unpaid',
   paid_at DATETIME(3) NULL,
   payment_provider VARCHAR(32) NULL,
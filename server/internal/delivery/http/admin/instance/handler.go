@@ -2,11 +2,15 @@ package instance
 
 import (
 	"context"
+	"io"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
 
 	"github.com/AeolianCloud/pveCloud/server/internal/delivery/http/admin/httputil"
 	"github.com/AeolianCloud/pveCloud/server/internal/delivery/http/admin/middleware"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
 	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
 	"github.com/AeolianCloud/pveCloud/server/internal/shared/response"
 	"github.com/AeolianCloud/pveCloud/server/internal/shared/validator"
@@ -14,6 +18,8 @@ import (
 	instanceusecase "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/instance"
 )
 
+const operationWebhookMaxBodyBytes = 1 << 20
+
 type Handler struct{ service *instanceusecase.Service }
 
 func NewHandler(service *instanceusecase.Service) *Handler { return &Handler{service: service} }
@@ -69,8 +75,199 @@ func (h *Handler) UpdateMapping(c *gin.Context) {
 	response.Success(c, result)
 }
 
+func (h *Handler) IPPools(c *gin.Context) {
+	var query admindto.IPPoolListQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.ListIPPools(c.Request.Context(), query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) CreateIPPool(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.IPPoolCreateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.CreateIPPool(c.Request.Context(), operatorID, req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) UpdateIPPool(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.IPPoolUpdateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.UpdateIPPool(c.Request.Context(), operatorID, c.Param("pool_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) ISOs(c *gin.Context) {
+	var query admindto.ISOListQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.ListISOs(c.Request.Context(), query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) CreateISO(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.ISORequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.CreateISO(c.Request.Context(), operatorID, req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) UpdateISO(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.ISORequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.UpdateISO(c.Request.Context(), operatorID, c.Param("iso_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) IPAddresses(c *gin.Context) {
+	var query admindto.IPAddressListQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.IPAddresses(c.Request.Context(), c.Param("pool_no"), query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) ReserveIPAddress(c *gin.Context) {
+	h.changeIPAddress(c, h.service.ReserveIPAddress)
+}
+
+func (h *Handler) ReclaimIPAddress(c *gin.Context) {
+	h.changeIPAddress(c, h.service.ReclaimIPAddress)
+}
+
+func (h *Handler) changeIPAddress(c *gin.Context, fn func(context.Context, uint64, string, uint64, admindto.IPAddressActionRequest) (admindto.IPAddressItem, error)) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	id, ok := httputil.AdminPathID(c)
+	if !ok {
+		return
+	}
+	var req admindto.IPAddressActionRequest
+	if c.Request.Body != nil && c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+	result, err := fn(c.Request.Context(), operatorID, c.Param("pool_no"), id, req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) PTRRecords(c *gin.Context) {
+	var query admindto.PTRRecordListQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.PTRRecords(c.Request.Context(), query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) ApprovePTRRecord(c *gin.Context) {
+	h.reviewPTRRecord(c, h.service.ApprovePTRRecord)
+}
+
+func (h *Handler) RejectPTRRecord(c *gin.Context) {
+	h.reviewPTRRecord(c, h.service.RejectPTRRecord)
+}
+
+func (h *Handler) reviewPTRRecord(c *gin.Context, fn func(context.Context, uint64, uint64, admindto.PTRReviewRequest) (admindto.PTRRecordItem, error)) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	id, ok := httputil.AdminPathID(c)
+	if !ok {
+		return
+	}
+	var req admindto.PTRReviewRequest
+	if c.Request.Body != nil && c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+	result, err := fn(c.Request.Context(), operatorID, id, req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Clusters(c *gin.Context) {
+	result, err := h.service.Clusters(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
 func (h *Handler) Nodes(c *gin.Context) {
-	result, err := h.service.Nodes(c.Request.Context())
+	var query admindto.MCPClusterQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.Nodes(c.Request.Context(), query)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -79,7 +276,11 @@ func (h *Handler) Nodes(c *gin.Context) {
 }
 
 func (h *Handler) Node(c *gin.Context) {
-	result, err := h.service.Node(c.Request.Context(), c.Param("node"))
+	var query admindto.MCPClusterQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.Node(c.Request.Context(), query, c.Param("node"))
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -88,7 +289,24 @@ func (h *Handler) Node(c *gin.Context) {
 }
 
 func (h *Handler) NodeVMs(c *gin.Context) {
-	result, err := h.service.NodeVMs(c.Request.Context(), c.Param("node"))
+	var query admindto.MCPClusterQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.NodeVMs(c.Request.Context(), query, c.Param("node"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) NodeMetrics(c *gin.Context) {
+	var query admindto.NodeMetricsQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.NodeMetrics(c.Request.Context(), c.Param("node"), query)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -97,7 +315,11 @@ func (h *Handler) NodeVMs(c *gin.Context) {
 }
 
 func (h *Handler) Storage(c *gin.Context) {
-	result, err := h.service.Storage(c.Request.Context())
+	var query admindto.MCPClusterQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.Storage(c.Request.Context(), query)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -105,12 +327,34 @@ func (h *Handler) Storage(c *gin.Context) {
 	response.Success(c, result)
 }
 
-func (h *Handler) ProvisionOrder(c *gin.Context) {
+func (h *Handler) Capacity(c *gin.Context) {
+	result, err := h.service.Capacity(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) ReconcileReports(c *gin.Context) {
+	var query admindto.ReconcileReportListQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.ListReconcileReports(c.Request.Context(), query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) RunReconcile(c *gin.Context) {
 	operatorID, ok := currentAdminID(c)
 	if !ok {
 		return
 	}
-	result, err := h.service.Provision(c.Request.Context(), operatorID, c.Param("order_no"))
+	result, err := h.service.Reconcile(c.Request.Context(), operatorID)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -118,12 +362,12 @@ func (h *Handler) ProvisionOrder(c *gin.Context) {
 	response.Success(c, result)
 }
 
-func (h *Handler) List(c *gin.Context) {
-	var query admindto.InstanceListQuery
+func (h *Handler) ReconcileItems(c *gin.Context) {
+	var query admindto.ReconcileItemListQuery
 	if !bindQuery(c, &query) {
 		return
 	}
-	result, err := h.service.List(c.Request.Context(), query)
+	result, err := h.service.ReconcileItems(c.Request.Context(), c.Param("report_no"), query)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -131,8 +375,20 @@ func (h *Handler) List(c *gin.Context) {
 	response.Success(c, result)
 }
 
-func (h *Handler) Detail(c *gin.Context) {
-	result, err := h.service.Detail(c.Request.Context(), c.Param("instance_no"))
+func (h *Handler) AdoptReconcileOrphan(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	id, ok := httputil.AdminPathID(c)
+	if !ok {
+		return
+	}
+	var req admindto.ReconcileAdoptRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.AdoptReconcileOrphan(c.Request.Context(), operatorID, id, req)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -140,32 +396,496 @@ func (h *Handler) Detail(c *gin.Context) {
 	response.Success(c, result)
 }
 
-func (h *Handler) Start(c *gin.Context) {
-	h.operate(c, h.service.Start)
-}
-
-func (h *Handler) Stop(c *gin.Context) {
-	h.operate(c, h.service.Stop)
+func (h *Handler) MarkReconcileGhostError(c *gin.Context) {
+	h.resolveReconcileItem(c, h.service.MarkReconcileGhostError)
 }
 
-func (h *Handler) Release(c *gin.Context) {
-	h.operate(c, h.service.Release)
+func (h *Handler) DeleteReconcileOrphan(c *gin.Context) {
+	h.resolveReconcileItem(c, h.service.DeleteReconcileOrphan)
 }
 
-func (h *Handler) Sync(c *gin.Context) {
-	h.operate(c, h.service.Sync)
+func (h *Handler) resolveReconcileItem(c *gin.Context, fn func(context.Context, uint64, uint64, admindto.ReconcileResolveRequest) (admindto.ReconcileItem, error)) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	id, ok := httputil.AdminPathID(c)
+	if !ok {
+		return
+	}
+	var req admindto.ReconcileResolveRequest
+	if c.Request.Body != nil && c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+	result, err := fn(c.Request.Context(), operatorID, id, req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
 }
 
-func (h *Handler) UpdateExpiresAt(c *gin.Context) {
+func (h *Handler) ProvisionOrder(c *gin.Context) {
 	operatorID, ok := currentAdminID(c)
 	if !ok {
 		return
 	}
-	var req admindto.InstanceExpiresAtRequest
-	if !bindJSON(c, &req) {
+	result, err := h.service.Provision(c.Request.Context(), operatorID, c.Param("order_no"))
+	if err != nil {
+		response.Error(c, err)
 		return
 	}
-	result, err := h.service.UpdateExpiresAt(c.Request.Context(), operatorID, c.Param("instance_no"), req)
+	response.Success(c, result)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	var query admindto.InstanceListQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.List(c.Request.Context(), query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Detail(c *gin.Context) {
+	result, err := h.service.Detail(c.Request.Context(), c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Metrics(c *gin.Context) {
+	var query admindto.InstanceMetricsQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.InstanceMetrics(c.Request.Context(), c.Param("instance_no"), query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Traffic(c *gin.Context) {
+	result, err := h.service.InstanceTraffic(c.Request.Context(), c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Firewall(c *gin.Context) {
+	result, err := h.service.InstanceFirewall(c.Request.Context(), c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Start(c *gin.Context) {
+	h.operate(c, h.service.Start)
+}
+
+func (h *Handler) Stop(c *gin.Context) {
+	h.operate(c, h.service.Stop)
+}
+
+func (h *Handler) Reboot(c *gin.Context) {
+	h.operate(c, h.service.Reboot)
+}
+
+func (h *Handler) Shutdown(c *gin.Context) {
+	h.operate(c, h.service.Shutdown)
+}
+
+func (h *Handler) Reset(c *gin.Context) {
+	h.operate(c, h.service.Reset)
+}
+
+func (h *Handler) ReinstallTemplates(c *gin.Context) {
+	result, err := h.service.ReinstallTemplates(c.Request.Context(), c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Reinstall(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.InstanceReinstallRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.Reinstall(c.Request.Context(), operatorID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Snapshots(c *gin.Context) {
+	result, err := h.service.Snapshots(c.Request.Context(), c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) CreateSnapshot(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.InstanceSnapshotCreateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.CreateSnapshot(c.Request.Context(), operatorID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) RollbackSnapshot(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.RollbackSnapshot(c.Request.Context(), operatorID, c.Param("instance_no"), c.Param("snapshot_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) DeleteSnapshot(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.DeleteSnapshot(c.Request.Context(), operatorID, c.Param("instance_no"), c.Param("snapshot_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Backups(c *gin.Context) {
+	result, err := h.service.Backups(c.Request.Context(), c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Backup(c *gin.Context) {
+	result, err := h.service.Backup(c.Request.Context(), c.Param("instance_no"), c.Param("backup_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) CreateBackup(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.InstanceBackupCreateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.CreateBackup(c.Request.Context(), operatorID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) RestoreBackup(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.RestoreBackup(c.Request.Context(), operatorID, c.Param("instance_no"), c.Param("backup_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) DeleteBackup(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.DeleteBackup(c.Request.Context(), operatorID, c.Param("instance_no"), c.Param("backup_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) UpdateBackupPolicy(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.InstanceBackupPolicyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.UpdateBackupPolicy(c.Request.Context(), operatorID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) CreateConsole(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.InstanceConsoleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.CreateConsole(c.Request.Context(), operatorID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// ConsoleWebsocket 消费一次性会话令牌并把浏览器 WebSocket 代理到上游控制台；令牌即本连接的凭据。
+func (h *Handler) ConsoleWebsocket(c *gin.Context) {
+	conn, err := h.service.ConnectConsole(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	served := false
+	server := websocket.Server{Handshake: consoleHandshake, Handler: func(ws *websocket.Conn) {
+		served = true
+		ws.PayloadType = websocket.BinaryFrame
+		h.service.ProxyConsole(c.Request.Context(), ws, conn)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+	if !served {
+		_ = conn.Upstream.Close()
+	}
+}
+
+// consoleHandshake 接受任意来源，noVNC 请求 binary 子协议时按 binary 应答。
+func consoleHandshake(config *websocket.Config, _ *http.Request) error {
+	for _, protocol := range config.Protocol {
+		if protocol == "binary" {
+			config.Protocol = []string{protocol}
+			return nil
+		}
+	}
+	config.Protocol = nil
+	return nil
+}
+
+// OperationWebhook 接收 MCP PVE 的操作完成回调；请求以集群回调密钥签名，不走管理员登录态。
+func (h *Handler) OperationWebhook(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, operationWebhookMaxBodyBytes)
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		response.Error(c, apperrors.ErrValidation.WithMessage("请求参数格式错误"))
+		return
+	}
+	timestamp, signature := c.GetHeader(mcppve.WebhookTimestampHeader), c.GetHeader(mcppve.WebhookSignatureHeader)
+	if err := h.service.OperationWebhook(c.Request.Context(), c.Param("cluster_no"), timestamp, signature, body); err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
+
+func (h *Handler) Release(c *gin.Context) {
+	h.operate(c, h.service.Release)
+}
+
+func (h *Handler) Sync(c *gin.Context) {
+	h.operate(c, h.service.Sync)
+}
+
+func (h *Handler) UpdateExpiresAt(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.InstanceExpiresAtRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.UpdateExpiresAt(c.Request.Context(), operatorID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Suspend(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.InstanceSuspendRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.Suspend(c.Request.Context(), operatorID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) EnterRescue(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.InstanceRescueRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.EnterRescue(c.Request.Context(), operatorID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) ExitRescue(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.ExitRescue(c.Request.Context(), operatorID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) MigrationTargets(c *gin.Context) {
+	result, err := h.service.MigrationTargets(c.Request.Context(), c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Migrate(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.InstanceMigrateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.Migrate(c.Request.Context(), operatorID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) EvacuateNode(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.NodeEvacuateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.EvacuateNode(c.Request.Context(), operatorID, c.Param("node"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Unsuspend(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.InstanceUnsuspendRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.Unsuspend(c.Request.Context(), operatorID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Transfers(c *gin.Context) {
+	var query admindto.InstanceTransferListQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.InstanceTransfers(c.Request.Context(), query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) ForceTransfer(c *gin.Context) {
+	operatorID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+	var req admindto.InstanceForceTransferRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.ForceTransfer(c.Request.Context(), operatorID, c.Param("instance_no"), req)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -21,6 +21,8 @@ func RegisterAdminRoutes(group *gin.RouterGroup, app *api.App) {
 	admin.GET("/ping", routes.System.Ping)
 	admin.GET("/auth/captcha", routes.Auth.Captcha)
 	admin.POST("/auth/login", routes.Auth.Login)
+	admin.GET("/instance-consoles/:token", routes.Instance.ConsoleWebsocket)
+	admin.POST("/mcp-pve/webhooks/:cluster_no", routes.Instance.OperationWebhook)
 
 	protected := admin.Group("")
 	protected.Use(routes.AuthMiddleware)
@@ -116,17 +118,66 @@ func RegisterAdminRoutes(group *gin.RouterGroup, app *api.App) {
 	protected.GET("/instance-provision-mappings", middleware.AdminPermission("page.instances"), routes.Instance.Mappings)
 	protected.POST("/instance-provision-mappings", middleware.AdminPermission("instance:provision"), routes.Instance.CreateMapping)
 	protected.PATCH("/instance-provision-mappings/:id", middleware.AdminPermission("instance:provision"), routes.Instance.UpdateMapping)
+	protected.GET("/ip-pools", middleware.AdminPermission("page.instances"), routes.Instance.IPPools)
+	protected.POST("/ip-pools", middleware.AdminPermission("instance:ip-pool"), routes.Instance.CreateIPPool)
+	protected.PATCH("/ip-pools/:pool_no", middleware.AdminPermission("instance:ip-pool"), routes.Instance.UpdateIPPool)
+	protected.GET("/ip-pools/:pool_no/addresses", middleware.AdminPermission("page.instances"), routes.Instance.IPAddresses)
+	protected.POST("/ip-pools/:pool_no/addresses/:id/reserve", middleware.AdminPermission("instance:ip-pool"), routes.Instance.ReserveIPAddress)
+	protected.POST("/ip-pools/:pool_no/addresses/:id/reclaim", middleware.AdminPermission("instance:ip-pool"), routes.Instance.ReclaimIPAddress)
+	protected.GET("/instance-isos", middleware.AdminPermission("page.instances"), routes.Instance.ISOs)
+	protected.POST("/instance-isos", middleware.AdminPermission("instance:iso"), routes.Instance.CreateISO)
+	protected.PATCH("/instance-isos/:iso_no", middleware.AdminPermission("instance:iso"), routes.Instance.UpdateISO)
+	protected.GET("/instance-capacity", middleware.AdminPermission("page.instances"), routes.Instance.Capacity)
+	protected.GET("/ptr-records", middleware.AdminPermission("page.rdns"), routes.Instance.PTRRecords)
+	protected.POST("/ptr-records/:id/approve", middleware.AdminPermission("rdns:review"), routes.Instance.ApprovePTRRecord)
+	protected.POST("/ptr-records/:id/reject", middleware.AdminPermission("rdns:review"), routes.Instance.RejectPTRRecord)
+	protected.GET("/instance-reconcile-reports", middleware.AdminPermission("page.reconciliation"), routes.Instance.ReconcileReports)
+	protected.POST("/instance-reconcile-reports", middleware.AdminPermission("reconciliation:run"), routes.Instance.RunReconcile)
+	protected.GET("/instance-reconcile-reports/:report_no/items", middleware.AdminPermission("page.reconciliation"), routes.Instance.ReconcileItems)
+	protected.POST("/instance-reconcile-items/:id/adopt", middleware.AdminPermission("reconciliation:resolve"), routes.Instance.AdoptReconcileOrphan)
+	protected.POST("/instance-reconcile-items/:id/mark-error", middleware.AdminPermission("reconciliation:resolve"), routes.Instance.MarkReconcileGhostError)
+	protected.POST("/instance-reconcile-items/:id/delete-vm", middleware.AdminPermission("reconciliation:resolve"), routes.Instance.DeleteReconcileOrphan)
+	protected.GET("/mcp-pve/clusters", middleware.AdminPermission("page.instances"), routes.Instance.Clusters)
 	protected.GET("/mcp-pve/nodes", middleware.AdminPermission("page.instances"), routes.Instance.Nodes)
 	protected.GET("/mcp-pve/nodes/:node", middleware.AdminPermission("page.instances"), routes.Instance.Node)
 	protected.GET("/mcp-pve/nodes/:node/vms", middleware.AdminPermission("page.instances"), routes.Instance.NodeVMs)
+	protected.GET("/mcp-pve/nodes/:node/metrics", middleware.AdminPermission("page.instances"), routes.Instance.NodeMetrics)
+	protected.POST("/mcp-pve/nodes/:node/evacuate", middleware.AdminPermission("instance:migrate"), routes.Instance.EvacuateNode)
 	protected.GET("/mcp-pve/storage", middleware.AdminPermission("page.instances"), routes.Instance.Storage)
 	protected.GET("/instances", middleware.AdminPermission("page.instances"), routes.Instance.List)
 	protected.GET("/instances/:instance_no", middleware.AdminPermission("page.instances"), routes.Instance.Detail)
 	protected.POST("/instances/:instance_no/start", middleware.AdminPermission("instance:operate"), routes.Instance.Start)
 	protected.POST("/instances/:instance_no/stop", middleware.AdminPermission("instance:operate"), routes.Instance.Stop)
+	protected.POST("/instances/:instance_no/reboot", middleware.AdminPermission("instance:operate"), routes.Instance.Reboot)
+	protected.POST("/instances/:instance_no/shutdown", middleware.AdminPermission("instance:operate"), routes.Instance.Shutdown)
+	protected.POST("/instances/:instance_no/reset", middleware.AdminPermission("instance:operate"), routes.Instance.Reset)
+	protected.GET("/instances/:instance_no/metrics", middleware.AdminPermission("page.instances"), routes.Instance.Metrics)
+	protected.GET("/instances/:instance_no/traffic", middleware.AdminPermission("page.instances"), routes.Instance.Traffic)
+	protected.GET("/instances/:instance_no/firewall", middleware.AdminPermission("page.instances"), routes.Instance.Firewall)
+	protected.GET("/instances/:instance_no/reinstall-templates", middleware.AdminPermission("page.instances"), routes.Instance.ReinstallTemplates)
+	protected.POST("/instances/:instance_no/reinstall", middleware.AdminPermission("instance:reinstall"), routes.Instance.Reinstall)
+	protected.GET("/instances/:instance_no/snapshots", middleware.AdminPermission("page.instances"), routes.Instance.Snapshots)
+	protected.POST("/instances/:instance_no/snapshots", middleware.AdminPermission("instance:snapshot"), routes.Instance.CreateSnapshot)
+	protected.POST("/instances/:instance_no/snapshots/:snapshot_no/rollback", middleware.AdminPermission("instance:snapshot"), routes.Instance.RollbackSnapshot)
+	protected.DELETE("/instances/:instance_no/snapshots/:snapshot_no", middleware.AdminPermission("instance:snapshot"), routes.Instance.DeleteSnapshot)
+	protected.GET("/instances/:instance_no/backups", middleware.AdminPermission("page.instances"), routes.Instance.Backups)
+	protected.POST("/instances/:instance_no/backups", middleware.AdminPermission("instance:backup"), routes.Instance.CreateBackup)
+	protected.GET("/instances/:instance_no/backups/:backup_no", middleware.AdminPermission("page.instances"), routes.Instance.Backup)
+	protected.POST("/instances/:instance_no/backups/:backup_no/restore", middleware.AdminPermission("instance:backup"), routes.Instance.RestoreBackup)
+	protected.DELETE("/instances/:instance_no/backups/:backup_no", middleware.AdminPermission("instance:backup"), routes.Instance.DeleteBackup)
+	protected.PUT("/instances/:instance_no/backup-policy", middleware.AdminPermission("instance:backup"), routes.Instance.UpdateBackupPolicy)
+	protected.POST("/instances/:instance_no/console", middleware.AdminPermission("instance:operate"), routes.Instance.CreateConsole)
 	protected.POST("/instances/:instance_no/release", middleware.AdminPermission("instance:release"), routes.Instance.Release)
 	protected.POST("/instances/:instance_no/sync", middleware.AdminPermission("instance:sync"), routes.Instance.Sync)
 	protected.PATCH("/instances/:instance_no/expires-at", middleware.AdminPermission("instance:renew"), routes.Instance.UpdateExpiresAt)
+	protected.POST("/instances/:instance_no/rescue", middleware.AdminPermission("instance:operate"), routes.Instance.EnterRescue)
+	protected.POST("/instances/:instance_no/rescue/exit", middleware.AdminPermission("instance:operate"), routes.Instance.ExitRescue)
+	protected.GET("/instances/:instance_no/migration-targets", middleware.AdminPermission("instance:migrate"), routes.Instance.MigrationTargets)
+	protected.POST("/instances/:instance_no/migrate", middleware.AdminPermission("instance:migrate"), routes.Instance.Migrate)
+	protected.POST("/instances/:instance_no/suspend", middleware.AdminPermission("instance:suspend"), routes.Instance.Suspend)
+	protected.POST("/instances/:instance_no/unsuspend", middleware.AdminPermission("instance:suspend"), routes.Instance.Unsuspend)
+	protected.POST("/instances/:instance_no/transfer", middleware.AdminPermission("instance:transfer"), routes.Instance.ForceTransfer)
+	protected.GET("/instance-transfers", middleware.AdminPermission("instance:transfer"), routes.Instance.Transfers)
 	protected.GET("/async-tasks", middleware.AdminPermission("page.async-tasks"), routes.AsyncTask.List)
 	protected.POST("/async-tasks/:task_no/retry", middleware.AdminPermission("async-task:retry"), routes.AsyncTask.Retry)
 	protected.GET("/tickets", middleware.AdminPermission("page.tickets"), routes.Ticket.List)
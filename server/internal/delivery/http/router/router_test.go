@@ -133,8 +133,11 @@ var publicRoutes = map[string]struct{}{
 	routeKey(http.MethodGet, "/admin-api/ping"):                              {},
 	routeKey(http.MethodGet, "/admin-api/auth/captcha"):                      {},
 	routeKey(http.MethodPost, "/admin-api/auth/login"):                       {},
+	routeKey(http.MethodGet, "/admin-api/instance-consoles/:token"):          {},
+	routeKey(http.MethodPost, "/admin-api/mcp-pve/webhooks/:cluster_no"):     {},
 	routeKey(http.MethodGet, "/api/site-config"):                             {},
 	routeKey(http.MethodGet, "/api/site-logo/:id"):                           {},
+	routeKey(http.MethodGet, "/api/instance-consoles/:token"):                {},
 	routeKey(http.MethodPost, "/api/real-name/provider-callbacks/:provider"): {},
 	routeKey(http.MethodPost, "/api/payment-callbacks/:provider"):            {},
 	routeKey(http.MethodGet, "/api/server-catalog"):                          {},
@@ -55,6 +55,7 @@ func serverCatalogPlans(plans []catalog.ServerCatalogPlan) []webdto.ServerCatalo
 			BandwidthMbps:  plan.BandwidthMbps,
 			TrafficGB:      plan.TrafficGB,
 			PublicIPCount:  plan.PublicIPCount,
+			SnapshotQuota:  plan.SnapshotQuota,
 			Virtualization: plan.Virtualization,
 			Architecture:   plan.Architecture,
 			IsFeatured:     plan.IsFeatured,
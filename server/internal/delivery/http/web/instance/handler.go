@@ -2,8 +2,13 @@ package instance
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
 
 	"github.com/AeolianCloud/pveCloud/server/internal/delivery/http/web/middleware"
 	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
@@ -55,6 +60,605 @@ func (h *Handler) Stop(c *gin.Context) {
 	h.operate(c, h.service.Stop)
 }
 
+func (h *Handler) Reboot(c *gin.Context) {
+	h.operate(c, h.service.Reboot)
+}
+
+func (h *Handler) Shutdown(c *gin.Context) {
+	h.operate(c, h.service.Shutdown)
+}
+
+func (h *Handler) Reset(c *gin.Context) {
+	h.operate(c, h.service.Reset)
+}
+
+func (h *Handler) ReinstallTemplates(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.ReinstallTemplates(c.Request.Context(), userID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Reinstall(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.InstanceReinstallRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.Reinstall(c.Request.Context(), userID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) ResetPassword(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.ResetPassword(c.Request.Context(), userID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) RevealRootPassword(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.RevealRootPassword(c.Request.Context(), userID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	response.Success(c, result)
+}
+
+func (h *Handler) ISOs(c *gin.Context) {
+	result, err := h.service.ISOs(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Rescue(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.Rescue(c.Request.Context(), userID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	response.Success(c, result)
+}
+
+func (h *Handler) EnterRescue(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.InstanceRescueRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.EnterRescue(c.Request.Context(), userID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) ExitRescue(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.ExitRescue(c.Request.Context(), userID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) MountISO(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.InstanceISOMountRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.MountISO(c.Request.Context(), userID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) UnmountISO(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.UnmountISO(c.Request.Context(), userID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Metrics(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var query webdto.InstanceMetricsQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.Metrics(c.Request.Context(), userID, c.Param("instance_no"), query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Traffic(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.Traffic(c.Request.Context(), userID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) SecurityGroups(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.SecurityGroups(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) SecurityGroup(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.SecurityGroup(c.Request.Context(), userID, c.Param("group_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) CreateSecurityGroup(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.SecurityGroupCreateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.CreateSecurityGroup(c.Request.Context(), userID, req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) UpdateSecurityGroup(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.SecurityGroupUpdateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.UpdateSecurityGroup(c.Request.Context(), userID, c.Param("group_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) DeleteSecurityGroup(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteSecurityGroup(c.Request.Context(), userID, c.Param("group_no")); err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, gin.H{})
+}
+
+func (h *Handler) Firewall(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.InstanceFirewall(c.Request.Context(), userID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) UpdateSecurityGroups(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.InstanceSecurityGroupsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.UpdateInstanceSecurityGroups(c.Request.Context(), userID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) PTR(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.InstancePTR(c.Request.Context(), userID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) UpdatePTR(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.InstancePTRRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.UpdateInstancePTR(c.Request.Context(), userID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) DeletePTR(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.DeleteInstancePTR(c.Request.Context(), userID, c.Param("instance_no"), c.Param("address"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Transfers(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var query webdto.InstanceTransferListQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.InstanceTransfers(c.Request.Context(), userID, query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) CreateTransfer(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.InstanceTransferRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.CreateInstanceTransfer(c.Request.Context(), userID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) AcceptTransfer(c *gin.Context) {
+	h.respondTransfer(c, h.service.AcceptInstanceTransfer)
+}
+
+func (h *Handler) RejectTransfer(c *gin.Context) {
+	h.respondTransfer(c, h.service.RejectInstanceTransfer)
+}
+
+func (h *Handler) CancelTransfer(c *gin.Context) {
+	h.respondTransfer(c, h.service.CancelInstanceTransfer)
+}
+
+func (h *Handler) respondTransfer(c *gin.Context, fn func(context.Context, uint64, string) (webdto.InstanceTransferItem, error)) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := fn(c.Request.Context(), userID, c.Param("transfer_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Snapshots(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.Snapshots(c.Request.Context(), userID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) CreateSnapshot(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.InstanceSnapshotCreateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.CreateSnapshot(c.Request.Context(), userID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) RollbackSnapshot(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.RollbackSnapshot(c.Request.Context(), userID, c.Param("instance_no"), c.Param("snapshot_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) DeleteSnapshot(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.DeleteSnapshot(c.Request.Context(), userID, c.Param("instance_no"), c.Param("snapshot_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Backups(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.Backups(c.Request.Context(), userID, c.Param("instance_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) Backup(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.BackupMetadata(c.Request.Context(), userID, c.Param("instance_no"), c.Param("backup_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// DownloadBackupMetadata 以 JSON 附件下载备份元数据，便于用户留存配置快照。
+func (h *Handler) DownloadBackupMetadata(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.BackupMetadata(c.Request.Context(), userID, c.Param("instance_no"), c.Param("backup_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(result.BackupNo+".json")))
+	c.Header("Cache-Control", "no-store, private")
+	c.Header("Pragma", "no-cache")
+	c.Header("Expires", "0")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+func (h *Handler) CreateBackup(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.InstanceBackupCreateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.CreateBackup(c.Request.Context(), userID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) RestoreBackup(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.RestoreBackup(c.Request.Context(), userID, c.Param("instance_no"), c.Param("backup_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) DeleteBackup(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.DeleteBackup(c.Request.Context(), userID, c.Param("instance_no"), c.Param("backup_no"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) UpdateBackupPolicy(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.InstanceBackupPolicyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.UpdateBackupPolicy(c.Request.Context(), userID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) CreateConsole(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.InstanceConsoleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.CreateConsole(c.Request.Context(), userID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// ConsoleWebsocket 消费一次性会话令牌并把浏览器 WebSocket 代理到上游控制台；令牌即本连接的凭据。
+func (h *Handler) ConsoleWebsocket(c *gin.Context) {
+	conn, err := h.service.ConnectConsole(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	served := false
+	server := websocket.Server{Handshake: consoleHandshake, Handler: func(ws *websocket.Conn) {
+		served = true
+		ws.PayloadType = websocket.BinaryFrame
+		h.service.ProxyConsole(c.Request.Context(), ws, conn)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+	if !served {
+		_ = conn.Upstream.Close()
+	}
+}
+
+// consoleHandshake 接受任意来源，noVNC 请求 binary 子协议时按 binary 应答。
+func consoleHandshake(config *websocket.Config, _ *http.Request) error {
+	for _, protocol := range config.Protocol {
+		if protocol == "binary" {
+			config.Protocol = []string{protocol}
+			return nil
+		}
+	}
+	config.Protocol = nil
+	return nil
+}
+
 func (h *Handler) CreateRenewalOrder(c *gin.Context) {
 	userID, ok := currentUserID(c)
 	if !ok {
@@ -72,6 +676,40 @@ func (h *Handler) CreateRenewalOrder(c *gin.Context) {
 	response.Success(c, result)
 }
 
+func (h *Handler) ChangePlanQuote(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var query webdto.ChangePlanQuoteQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	result, err := h.service.ChangePlanQuote(c.Request.Context(), userID, c.Param("instance_no"), query)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func (h *Handler) CreateChangePlanOrder(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webdto.ChangePlanOrderCreateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	result, err := h.service.CreateChangePlanOrder(c.Request.Context(), userID, c.Param("instance_no"), req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
 func (h *Handler) operate(c *gin.Context, fn func(context.Context, uint64, string) (webdto.InstanceDetail, error)) {
 	userID, ok := currentUserID(c)
 	if !ok {
@@ -305,6 +305,7 @@ CREATE TABLE product_plans (
   bandwidth_mbps INT NOT NULL,
   traffic_gb INT NULL,
   public_ip_count INT NOT NULL DEFAULT 1,
+  snapshot_quota INT NOT NULL DEFAULT 0,
   virtualization VARCHAR(32) NOT NULL DEFAULT 'kvm',
   architecture VARCHAR(32) NOT NULL DEFAULT 'x86_64',
   is_featured TINYINT(1) NOT NULL DEFAULT 0,
@@ -425,6 +426,9 @@ CREATE TABLE orders (
   status VARCHAR(32) NOT NULL DEFAULT 'pending',
   order_type VARCHAR(32) NOT NULL DEFAULT 'purchase',
   related_instance_no VARCHAR(64) NULL,
+  change_from_plan_no VARCHAR(64) NULL,
+  source_backup_no VARCHAR(64) NULL,
+  ssh_keys TEXT NULL,
   product_no VARCHAR(64) NOT NULL,
   product_type VARCHAR(32) NOT NULL,
   product_name VARCHAR(128) NOT NULL,
@@ -448,6 +452,7 @@ CREATE TABLE orders (
   currency VARCHAR(16) NOT NULL,
   quantity INT NOT NULL DEFAULT 1,
   total_amount_cents BIGINT UNSIGNED NOT NULL,
+  credit_amount_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
   payment_status VARCHAR(32) NOT NULL DEFAULT 'unpaid',
   paid_at DATETIME(3) NULL,
   payment_provider VARCHAR(32) NULL,
@@ -28,6 +28,7 @@ func RegisterWebRoutes(group *gin.RouterGroup, app *api.App) {
 	group.POST("/auth/password-reset/request", routes.Auth.RequestPasswordReset)
 	group.POST("/auth/password-reset/confirm", routes.Auth.ConfirmPasswordReset)
 	group.POST("/client-logs/errors", routes.ClientLogs.Create)
+	group.GET("/instance-consoles/:token", routes.Instance.ConsoleWebsocket)
 
 	protected := group.Group("")
 	protected.Use(routes.AuthMiddleware)
@@ -36,6 +37,9 @@ func RegisterWebRoutes(group *gin.RouterGroup, app *api.App) {
 	protected.POST("/auth/refresh", routes.Auth.Refresh)
 	protected.PATCH("/user/profile", routes.UserProfile.UpdateProfile)
 	protected.POST("/user/password", routes.UserProfile.ChangePassword)
+	protected.GET("/user/ssh-keys", routes.UserProfile.ListSSHKeys)
+	protected.POST("/user/ssh-keys", routes.UserProfile.CreateSSHKey)
+	protected.DELETE("/user/ssh-keys/:key_no", routes.UserProfile.DeleteSSHKey)
 	protected.GET("/user/real-name", routes.RealName.Status)
 	protected.POST("/user/real-name", routes.RealName.Submit)
 	protected.POST("/user/real-name/sync", routes.RealName.Sync)
@@ -59,7 +63,51 @@ func RegisterWebRoutes(group *gin.RouterGroup, app *api.App) {
 	protected.GET("/instances/:instance_no", routes.Instance.Detail)
 	protected.POST("/instances/:instance_no/start", routes.Instance.Start)
 	protected.POST("/instances/:instance_no/stop", routes.Instance.Stop)
+	protected.POST("/instances/:instance_no/reboot", routes.Instance.Reboot)
+	protected.POST("/instances/:instance_no/shutdown", routes.Instance.Shutdown)
+	protected.POST("/instances/:instance_no/reset", routes.Instance.Reset)
+	protected.GET("/instances/:instance_no/reinstall-templates", routes.Instance.ReinstallTemplates)
+	protected.POST("/instances/:instance_no/reinstall", routes.Instance.Reinstall)
+	protected.POST("/instances/:instance_no/reset-password", routes.Instance.ResetPassword)
+	protected.POST("/instances/:instance_no/root-password/reveal", routes.Instance.RevealRootPassword)
+	protected.GET("/instances/:instance_no/rescue", routes.Instance.Rescue)
+	protected.POST("/instances/:instance_no/rescue", routes.Instance.EnterRescue)
+	protected.POST("/instances/:instance_no/rescue/exit", routes.Instance.ExitRescue)
+	protected.POST("/instances/:instance_no/iso", routes.Instance.MountISO)
+	protected.DELETE("/instances/:instance_no/iso", routes.Instance.UnmountISO)
+	protected.GET("/instances/:instance_no/metrics", routes.Instance.Metrics)
+	protected.GET("/instances/:instance_no/traffic", routes.Instance.Traffic)
+	protected.GET("/instances/:instance_no/firewall", routes.Instance.Firewall)
+	protected.PUT("/instances/:instance_no/security-groups", routes.Instance.UpdateSecurityGroups)
+	protected.GET("/instances/:instance_no/ptr", routes.Instance.PTR)
+	protected.PUT("/instances/:instance_no/ptr", routes.Instance.UpdatePTR)
+	protected.DELETE("/instances/:instance_no/ptr/:address", routes.Instance.DeletePTR)
+	protected.POST("/instances/:instance_no/transfers", routes.Instance.CreateTransfer)
+	protected.GET("/instances/:instance_no/snapshots", routes.Instance.Snapshots)
+	protected.POST("/instances/:instance_no/snapshots", routes.Instance.CreateSnapshot)
+	protected.POST("/instances/:instance_no/snapshots/:snapshot_no/rollback", routes.Instance.RollbackSnapshot)
+	protected.DELETE("/instances/:instance_no/snapshots/:snapshot_no", routes.Instance.DeleteSnapshot)
+	protected.GET("/instances/:instance_no/backups", routes.Instance.Backups)
+	protected.POST("/instances/:instance_no/backups", routes.Instance.CreateBackup)
+	protected.GET("/instances/:instance_no/backups/:backup_no", routes.Instance.Backup)
+	protected.GET("/instances/:instance_no/backups/:backup_no/metadata", routes.Instance.DownloadBackupMetadata)
+	protected.POST("/instances/:instance_no/backups/:backup_no/restore", routes.Instance.RestoreBackup)
+	protected.DELETE("/instances/:instance_no/backups/:backup_no", routes.Instance.DeleteBackup)
+	protected.PUT("/instances/:instance_no/backup-policy", routes.Instance.UpdateBackupPolicy)
+	protected.POST("/instances/:instance_no/console", routes.Instance.CreateConsole)
 	protected.POST("/instances/:instance_no/renewal-orders", routes.Instance.CreateRenewalOrder)
+	protected.GET("/instances/:instance_no/change-plan-quote", routes.Instance.ChangePlanQuote)
+	protected.POST("/instances/:instance_no/change-plan-orders", routes.Instance.CreateChangePlanOrder)
+	protected.GET("/instance-isos", routes.Instance.ISOs)
+	protected.GET("/instance-transfers", routes.Instance.Transfers)
+	protected.POST("/instance-transfers/:transfer_no/accept", routes.Instance.AcceptTransfer)
+	protected.POST("/instance-transfers/:transfer_no/reject", routes.Instance.RejectTransfer)
+	protected.POST("/instance-transfers/:transfer_no/cancel", routes.Instance.CancelTransfer)
+	protected.GET("/security-groups", routes.Instance.SecurityGroups)
+	protected.POST("/security-groups", routes.Instance.CreateSecurityGroup)
+	protected.GET("/security-groups/:group_no", routes.Instance.SecurityGroup)
+	protected.PUT("/security-groups/:group_no", routes.Instance.UpdateSecurityGroup)
+	protected.DELETE("/security-groups/:group_no", routes.Instance.DeleteSecurityGroup)
 	protected.GET("/tickets", routes.Ticket.List)
 	protected.POST("/tickets", routes.Ticket.Create)
 	protected.GET("/tickets/:ticket_no", routes.Ticket.Detail)
@@ -85,3 +85,62 @@ func (h *UserProfileHandler) ChangePassword(c *gin.Context) {
 	}
 	response.Success(c, gin.H{})
 }
+
+/**
+ * ListSSHKeys 查询当前用户 SSH 公钥。
+ */
+func (h *UserProfileHandler) ListSSHKeys(c *gin.Context) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		response.Error(c, apperrors.ErrUnauthorized)
+		return
+	}
+	result, err := h.service.ListSSHKeys(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+/**
+ * CreateSSHKey 新增当前用户 SSH 公钥。
+ */
+func (h *UserProfileHandler) CreateSSHKey(c *gin.Context) {
+	var req webdto.SSHKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apperrors.ErrValidation.WithMessage("请求参数格式错误"))
+		return
+	}
+	if err := validator.Struct(req); err != nil {
+		response.Error(c, apperrors.ErrValidation.WithMessage("请求参数校验失败"))
+		return
+	}
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		response.Error(c, apperrors.ErrUnauthorized)
+		return
+	}
+	result, err := h.service.CreateSSHKey(c.Request.Context(), userID, req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+/**
+ * DeleteSSHKey 删除当前用户 SSH 公钥。
+ */
+func (h *UserProfileHandler) DeleteSSHKey(c *gin.Context) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		response.Error(c, apperrors.ErrUnauthorized)
+		return
+	}
+	if err := h.service.DeleteSSHKey(c.Request.Context(), userID, c.Param("key_no")); err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, gin.H{})
+}
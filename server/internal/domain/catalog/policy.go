@@ -22,3 +22,16 @@ func IsPublicServerPlan(status string, visible bool) bool {
 func HasRenderablePlanParts(priceCount int, regionCount int, templateCount int, networkTypeCount int) bool {
 	return priceCount > 0 && regionCount > 0 && templateCount > 0 && networkTypeCount > 0
 }
+
+// CapacityPlanStatus 根据地域容量计算套餐应处的售卖状态。autoSoldOut 表示当前售罄由容量同步自动设置；
+// 只有在售套餐会被自动售罄，也只有自动售罄的套餐会在容量恢复后自动恢复在售，人工设置的状态保持不变。
+func CapacityPlanStatus(status string, autoSoldOut bool, available bool) (string, bool) {
+	status = strings.TrimSpace(status)
+	switch {
+	case status == StatusActive && !available:
+		return StatusSoldOut, true
+	case status == StatusSoldOut && autoSoldOut && available:
+		return StatusActive, true
+	}
+	return status, false
+}
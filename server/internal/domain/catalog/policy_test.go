@@ -23,3 +23,25 @@ func TestCatalogVisibilityPolicy(t *testing.T) {
 	require.False(t, HasRenderablePlanParts(1, 1, 0, 1))
 	require.False(t, HasRenderablePlanParts(1, 1, 1, 0))
 }
+
+func TestCapacityPlanStatusOnlyRevertsAutomaticSoldOut(t *testing.T) {
+	status, changed := CapacityPlanStatus("active", false, false)
+	require.Equal(t, StatusSoldOut, status)
+	require.True(t, changed)
+
+	status, changed = CapacityPlanStatus("sold_out", true, true)
+	require.Equal(t, StatusActive, status)
+	require.True(t, changed)
+
+	status, changed = CapacityPlanStatus("sold_out", false, true)
+	require.Equal(t, StatusSoldOut, status)
+	require.False(t, changed)
+
+	status, changed = CapacityPlanStatus("active", false, true)
+	require.Equal(t, StatusActive, status)
+	require.False(t, changed)
+
+	status, changed = CapacityPlanStatus("inactive", false, false)
+	require.Equal(t, "inactive", status)
+	require.False(t, changed)
+}
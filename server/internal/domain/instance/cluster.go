@@ -0,0 +1,30 @@
+package instance
+
+const (
+	// ClusterStatusOnline 表示集群 MCP 接口可达；ClusterStatusUnreachable 表示探测失败；
+	// ClusterStatusDisabled 表示虚拟化接口整体未启用，不做探测。
+	ClusterStatusOnline      = "online"
+	ClusterStatusUnreachable = "unreachable"
+	ClusterStatusDisabled    = "disabled"
+)
+
+// ClusterHealth 根据探测结果给出集群状态和在线节点数。接口可达但没有在线节点时仍按不可达处理，
+// 此时集群无法交付也无法执行实例操作。
+func ClusterHealth(enabled bool, probeErr error, nodeStatuses []string) (string, int) {
+	if !enabled {
+		return ClusterStatusDisabled, 0
+	}
+	if probeErr != nil {
+		return ClusterStatusUnreachable, 0
+	}
+	online := 0
+	for _, status := range nodeStatuses {
+		if status == "online" {
+			online++
+		}
+	}
+	if online == 0 {
+		return ClusterStatusUnreachable, 0
+	}
+	return ClusterStatusOnline, online
+}
@@ -0,0 +1,161 @@
+package instance
+
+import (
+	"errors"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const (
+	FirewallDirectionIn  = "in"
+	FirewallDirectionOut = "out"
+
+	// FirewallProtocolAny 匹配全部协议，此时不能指定端口。
+	FirewallProtocolTCP  = "tcp"
+	FirewallProtocolUDP  = "udp"
+	FirewallProtocolICMP = "icmp"
+	FirewallProtocolAny  = "any"
+
+	// FirewallStatus 记录实例防火墙是否已下发到上游：pending 表示本地规则已变更但尚未确认下发成功，
+	// 由下次实例同步补齐；实例从未绑定过安全组时为空，不接管上游防火墙。
+	FirewallStatusSynced  = "synced"
+	FirewallStatusPending = "pending"
+
+	FirewallPolicyAccept = "ACCEPT"
+	FirewallPolicyDrop   = "DROP"
+
+	// MaxSecurityGroups 是单个用户可创建的安全组数量上限。
+	MaxSecurityGroups = 20
+	// MaxSecurityGroupRules 是单个安全组的规则数量上限。
+	MaxSecurityGroupRules = 50
+	// MaxInstanceSecurityGroups 是单个实例可同时绑定的安全组数量上限。
+	MaxInstanceSecurityGroups = 5
+)
+
+// FirewallRule 是安全组中的一条放行规则；入站规则的 CIDR 为来源地址，出站为目标地址。
+// PortRange 为单端口或 "起-止" 范围，ICMP 和全部协议时为空。
+type FirewallRule struct {
+	Direction string
+	Protocol  string
+	PortRange string
+	CIDR      string
+}
+
+func IsKnownFirewallDirection(value string) bool {
+	return value == FirewallDirectionIn || value == FirewallDirectionOut
+}
+
+func IsKnownFirewallProtocol(value string) bool {
+	switch value {
+	case FirewallProtocolTCP, FirewallProtocolUDP, FirewallProtocolICMP, FirewallProtocolAny:
+		return true
+	default:
+		return false
+	}
+}
+
+// NormalizeFirewallRule 校验并规范化规则：端口范围去空格，CIDR 转为网络地址形式，单个 IP 视为主机路由。
+func NormalizeFirewallRule(rule FirewallRule) (FirewallRule, error) {
+	rule.Direction = strings.ToLower(strings.TrimSpace(rule.Direction))
+	rule.Protocol = strings.ToLower(strings.TrimSpace(rule.Protocol))
+	if !IsKnownFirewallDirection(rule.Direction) {
+		return FirewallRule{}, errors.New("规则方向不支持")
+	}
+	if !IsKnownFirewallProtocol(rule.Protocol) {
+		return FirewallRule{}, errors.New("规则协议不支持")
+	}
+	port, err := normalizeFirewallPort(rule.Protocol, rule.PortRange)
+	if err != nil {
+		return FirewallRule{}, err
+	}
+	cidr, err := normalizeFirewallCIDR(rule.CIDR)
+	if err != nil {
+		return FirewallRule{}, err
+	}
+	rule.PortRange = port
+	rule.CIDR = cidr
+	return rule, nil
+}
+
+func normalizeFirewallPort(protocol string, value string) (string, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	if protocol == FirewallProtocolICMP || protocol == FirewallProtocolAny {
+		if value != "" {
+			return "", errors.New("ICMP 和全部协议规则不能指定端口")
+		}
+		return "", nil
+	}
+	if value == "" {
+		return "", errors.New("TCP/UDP 规则必须指定端口")
+	}
+	startText, endText, isRange := strings.Cut(value, "-")
+	start, err := parseFirewallPort(startText)
+	if err != nil {
+		return "", err
+	}
+	if !isRange {
+		return strconv.Itoa(start), nil
+	}
+	end, err := parseFirewallPort(endText)
+	if err != nil {
+		return "", err
+	}
+	if start > end {
+		return "", errors.New("端口范围起始值不能大于结束值")
+	}
+	if start == end {
+		return strconv.Itoa(start), nil
+	}
+	return strconv.Itoa(start) + "-" + strconv.Itoa(end), nil
+}
+
+func parseFirewallPort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, errors.New("端口必须在 1-65535 之间")
+	}
+	return port, nil
+}
+
+func normalizeFirewallCIDR(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", errors.New("规则地址不能为空")
+	}
+	if !strings.Contains(value, "/") {
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return "", errors.New("规则地址格式不正确")
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+	}
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return "", errors.New("规则地址格式不正确")
+	}
+	return prefix.Masked().String(), nil
+}
+
+// DefaultSecurityGroupRules 返回按系统族预置的入站规则：放行 ICMP，Linux/BSD 放行 SSH，Windows 放行远程桌面。
+func DefaultSecurityGroupRules(osFamily string) []FirewallRule {
+	rules := []FirewallRule{{Direction: FirewallDirectionIn, Protocol: FirewallProtocolICMP, CIDR: "0.0.0.0/0"}}
+	switch strings.ToLower(strings.TrimSpace(osFamily)) {
+	case "windows":
+		rules = append(rules, FirewallRule{Direction: FirewallDirectionIn, Protocol: FirewallProtocolTCP, PortRange: "3389", CIDR: "0.0.0.0/0"})
+	default:
+		rules = append(rules, FirewallRule{Direction: FirewallDirectionIn, Protocol: FirewallProtocolTCP, PortRange: "22", CIDR: "0.0.0.0/0"})
+	}
+	return rules
+}
+
+// FirewallPolicies 返回实例防火墙的默认策略：入站默认拒绝；出站在没有任何出站规则时放行，
+// 存在出站规则时只放行规则允许的流量。
+func FirewallPolicies(rules []FirewallRule) (string, string) {
+	for _, rule := range rules {
+		if rule.Direction == FirewallDirectionOut {
+			return FirewallPolicyDrop, FirewallPolicyDrop
+		}
+	}
+	return FirewallPolicyDrop, FirewallPolicyAccept
+}
@@ -0,0 +1,139 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+const (
+	IPPoolStatusActive   = "active"
+	IPPoolStatusInactive = "inactive"
+
+	IPAddressStatusAvailable = "available"
+	IPAddressStatusReserved  = "reserved"
+	IPAddressStatusAllocated = "allocated"
+
+	// MaxIPPoolAddresses 限制单个地址池的地址数，地址池创建时会逐个落库。
+	MaxIPPoolAddresses = 4096
+)
+
+var (
+	ErrInvalidCIDR         = errors.New("invalid ipv4 cidr")
+	ErrIPPoolTooLarge      = errors.New("ip pool too large")
+	ErrInvalidGateway      = errors.New("gateway outside cidr")
+	ErrInvalidReservedSpan = errors.New("invalid reserved range")
+)
+
+// PoolAddress 是地址池展开后的单个地址；Reserved 表示创建时即保留，不参与自动分配。
+type PoolAddress struct {
+	Address  string
+	Reserved bool
+	Remark   string
+}
+
+// PoolLayout 是解析后的地址池网段信息。
+type PoolLayout struct {
+	CIDR         string
+	PrefixLength int
+	Gateway      string
+	Addresses    []PoolAddress
+}
+
+func IsKnownIPPoolStatus(status string) bool {
+	switch status {
+	case "", IPPoolStatusActive, IPPoolStatusInactive:
+		return true
+	default:
+		return false
+	}
+}
+
+func IsKnownIPAddressStatus(status string) bool {
+	switch status {
+	case "", IPAddressStatusAvailable, IPAddressStatusReserved, IPAddressStatusAllocated:
+		return true
+	default:
+		return false
+	}
+}
+
+// ExpandIPPool 把 IPv4 网段展开为可管理的地址列表。网络地址和广播地址（/31、/32 除外）不入池，
+// 网关和 reservedRanges 命中的地址标记为保留；reservedRanges 支持单个地址或 "起始-结束" 区间。
+func ExpandIPPool(cidr string, gateway string, reservedRanges []string) (PoolLayout, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+	if err != nil || !prefix.Addr().Is4() {
+		return PoolLayout{}, ErrInvalidCIDR
+	}
+	prefix = prefix.Masked()
+	hostBits := 32 - prefix.Bits()
+	if hostBits > 12 {
+		return PoolLayout{}, ErrIPPoolTooLarge
+	}
+	gw, err := netip.ParseAddr(strings.TrimSpace(gateway))
+	if err != nil || !gw.Is4() || !prefix.Contains(gw) {
+		return PoolLayout{}, ErrInvalidGateway
+	}
+	spans := make([][2]netip.Addr, 0, len(reservedRanges))
+	for _, raw := range reservedRanges {
+		span, err := parseReservedSpan(raw)
+		if err != nil {
+			return PoolLayout{}, err
+		}
+		if !prefix.Contains(span[0]) || !prefix.Contains(span[1]) {
+			return PoolLayout{}, ErrInvalidReservedSpan
+		}
+		spans = append(spans, span)
+	}
+	first := prefix.Addr()
+	total := 1 << hostBits
+	layout := PoolLayout{CIDR: prefix.String(), PrefixLength: prefix.Bits(), Gateway: gw.String(), Addresses: make([]PoolAddress, 0, total)}
+	addr := first
+	for i := 0; i < total; i++ {
+		skip := hostBits > 1 && (i == 0 || i == total-1)
+		if !skip {
+			item := PoolAddress{Address: addr.String()}
+			if addr == gw {
+				item.Reserved = true
+				item.Remark = "网关"
+			} else if inSpans(addr, spans) {
+				item.Reserved = true
+			}
+			layout.Addresses = append(layout.Addresses, item)
+		}
+		addr = addr.Next()
+	}
+	return layout, nil
+}
+
+// IPConfig 生成 cloud-init ipconfig0 静态地址配置，例如 "ip=203.0.113.10/24,gw=203.0.113.1"。
+func IPConfig(address string, prefixLength int, gateway string) string {
+	return fmt.Sprintf("ip=%s/%d,gw=%s", address, prefixLength, gateway)
+}
+
+func parseReservedSpan(raw string) ([2]netip.Addr, error) {
+	raw = strings.TrimSpace(raw)
+	startText, endText, isRange := strings.Cut(raw, "-")
+	if !isRange {
+		endText = startText
+	}
+	start, err := netip.ParseAddr(strings.TrimSpace(startText))
+	if err != nil || !start.Is4() {
+		return [2]netip.Addr{}, ErrInvalidReservedSpan
+	}
+	end, err := netip.ParseAddr(strings.TrimSpace(endText))
+	if err != nil || !end.Is4() || end.Less(start) {
+		return [2]netip.Addr{}, ErrInvalidReservedSpan
+	}
+	return [2]netip.Addr{start, end}, nil
+}
+
+func inSpans(addr netip.Addr, spans [][2]netip.Addr) bool {
+	for _, span := range spans {
+		if !addr.Less(span[0]) && !span[1].Less(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package instance
+
+const (
+	MetricsRangeHour  = "hour"
+	MetricsRangeDay   = "day"
+	MetricsRangeWeek  = "week"
+	MetricsRangeMonth = "month"
+)
+
+func IsKnownMetricsRange(value string) bool {
+	switch value {
+	case MetricsRangeHour, MetricsRangeDay, MetricsRangeWeek, MetricsRangeMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+// MetricPoint 是归一化后的监控采样点：CPUPercent 为 0-100，内存单位 MiB，磁盘和网络单位字节/秒；
+// 字段为 nil 表示该时间段上游无数据。
+type MetricPoint struct {
+	Time          int64
+	CPUPercent    *float64
+	MemoryUsedMB  *float64
+	MemoryTotalMB *float64
+	DiskReadBps   *float64
+	DiskWriteBps  *float64
+	NetInBps      *float64
+	NetOutBps     *float64
+}
+
+// DownsampleMetrics 按时间顺序把采样点分成不超过 maxPoints 个桶，每桶取首个采样时间和各字段非空值的平均；
+// 整桶无数据的字段保持 nil，图表据此断开而不是画成 0。
+func DownsampleMetrics(points []MetricPoint, maxPoints int) []MetricPoint {
+	if maxPoints <= 0 || len(points) <= maxPoints {
+		return points
+	}
+	size := (len(points) + maxPoints - 1) / maxPoints
+	out := make([]MetricPoint, 0, maxPoints)
+	for start := 0; start < len(points); start += size {
+		bucket := points[start:min(start+size, len(points))]
+		out = append(out, MetricPoint{
+			Time:          bucket[0].Time,
+			CPUPercent:    averageMetric(bucket, func(p MetricPoint) *float64 { return p.CPUPercent }),
+			MemoryUsedMB:  averageMetric(bucket, func(p MetricPoint) *float64 { return p.MemoryUsedMB }),
+			MemoryTotalMB: averageMetric(bucket, func(p MetricPoint) *float64 { return p.MemoryTotalMB }),
+			DiskReadBps:   averageMetric(bucket, func(p MetricPoint) *float64 { return p.DiskReadBps }),
+			DiskWriteBps:  averageMetric(bucket, func(p MetricPoint) *float64 { return p.DiskWriteBps }),
+			NetInBps:      averageMetric(bucket, func(p MetricPoint) *float64 { return p.NetInBps }),
+			NetOutBps:     averageMetric(bucket, func(p MetricPoint) *float64 { return p.NetOutBps }),
+		})
+	}
+	return out
+}
+
+// ScaleMetric 把上游原始值乘以 factor，用于 CPU 比例转百分比和字节转 MiB；nil 保持 nil。
+func ScaleMetric(value *float64, factor float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	scaled := *value * factor
+	return &scaled
+}
+
+func averageMetric(bucket []MetricPoint, field func(MetricPoint) *float64) *float64 {
+	var sum float64
+	var count int
+	for _, point := range bucket {
+		if value := field(point); value != nil {
+			sum += *value
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	avg := sum / float64(count)
+	return &avg
+}
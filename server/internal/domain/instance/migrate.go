@@ -0,0 +1,51 @@
+package instance
+
+import "errors"
+
+const (
+	// OperationMigrate 把 VM 迁移到同地域的另一个节点；迁移成功后由同步回写实例所在节点。
+	OperationMigrate = "migrate"
+
+	// MigrateModeAuto 对运行中实例先尝试在线迁移，失败后自动改为离线迁移；MigrateModeOnline 只在线迁移，
+	// 失败即结束；MigrateModeOffline 直接离线迁移，运行中实例会在目标节点重新启动。
+	MigrateModeAuto    = "auto"
+	MigrateModeOnline  = "online"
+	MigrateModeOffline = "offline"
+
+	// MigrateStageStop 是离线迁移运行中实例前的关机阶段；PVE 不接受对运行中的 VM 离线迁移，关机完成后由同步继续迁移。
+	MigrateStageStop = "stop"
+
+	// TaskTypeMigrate 是节点疏散投递的单实例迁移任务，实例忙碌时延后重入。
+	TaskTypeMigrate = "instance_migrate"
+)
+
+var (
+	ErrMigrateModeInvalid    = errors.New("invalid migrate mode")
+	ErrMigrateOnlineRequired = errors.New("online migration requires a running instance")
+)
+
+// CanMigrate 允许迁移电源状态稳定的实例；暂停实例的 VM 已关机，随节点疏散离线迁移。
+func CanMigrate(status string) bool {
+	return status == StatusRunning || status == StatusStopped || status == StatusSuspended
+}
+
+// MigrateStrategy 按实例状态和迁移方式决定本次是否在线迁移，以及在线迁移失败后是否回退为离线迁移。
+// 空方式按 auto 处理；未运行的实例只能离线迁移。
+func MigrateStrategy(status string, mode string) (online bool, fallback bool, err error) {
+	switch mode {
+	case "", MigrateModeAuto:
+		if status == StatusRunning {
+			return true, true, nil
+		}
+		return false, false, nil
+	case MigrateModeOnline:
+		if status != StatusRunning {
+			return false, false, ErrMigrateOnlineRequired
+		}
+		return true, false, nil
+	case MigrateModeOffline:
+		return false, false, nil
+	default:
+		return false, false, ErrMigrateModeInvalid
+	}
+}
@@ -0,0 +1,122 @@
+package instance
+
+import (
+	"sort"
+	"strings"
+)
+
+const bytesPerMiB = 1024 * 1024
+const bytesPerGiB = 1024 * 1024 * 1024
+
+// NodeCapacity 是候选节点的实时容量（来自虚拟化接口）和本地未释放实例已分配的规格合计。
+// 存储容量未知时 StorageTotalBytes 为 0，此时不按存储裁决。
+type NodeCapacity struct {
+	Node              string
+	Online            bool
+	MaxCPU            int
+	CPUUsage          float64
+	MemoryTotalBytes  int64
+	MemoryUsedBytes   int64
+	StorageTotalBytes int64
+	StorageUsedBytes  int64
+	CommittedCPU      int
+	CommittedMemoryMB int64
+	CommittedDiskGB   int64
+	Instances         int
+	UserInstances     int
+}
+
+// PlacementDemand 是待交付实例需要占用的规格。
+type PlacementDemand struct {
+	CPUCores int
+	MemoryMB int
+	DiskGB   int
+}
+
+// OvercommitRatios 是 CPU、内存和存储的超分比例，1 表示不超分。
+type OvercommitRatios struct {
+	CPU     float64
+	Memory  float64
+	Storage float64
+}
+
+// PlacementCandidate 是单个候选节点的裁决结果；Reason 为空表示可放置。
+type PlacementCandidate struct {
+	NodeCapacity
+	Eligible bool
+	Reason   string
+	// Load 是放置后按超分容量计算的内存与 CPU 分配率中的较大值，越小越空闲。
+	Load float64
+}
+
+// ChoosePlacement 在候选节点中选择放置节点，返回按优先级排序的全部候选及裁决结果。
+// 离线、超出超分上限或实时可用内存/存储不足的节点不可放置；其余节点中，开启用户反亲和时
+// 优先选择该用户实例数更少的节点，再选择放置后负载更低的节点，最后按节点名稳定排序。
+func ChoosePlacement(nodes []NodeCapacity, demand PlacementDemand, ratios OvercommitRatios, userAntiAffinity bool) (string, []PlacementCandidate) {
+	candidates := make([]PlacementCandidate, 0, len(nodes))
+	for _, node := range nodes {
+		candidates = append(candidates, evaluatePlacement(node, demand, ratios))
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if userAntiAffinity && a.UserInstances != b.UserInstances {
+			return a.UserInstances < b.UserInstances
+		}
+		if a.Load != b.Load {
+			return a.Load < b.Load
+		}
+		return strings.Compare(a.Node, b.Node) < 0
+	})
+	if len(candidates) == 0 || !candidates[0].Eligible {
+		return "", candidates
+	}
+	return candidates[0].Node, candidates
+}
+
+func evaluatePlacement(node NodeCapacity, demand PlacementDemand, ratios OvercommitRatios) PlacementCandidate {
+	candidate := PlacementCandidate{NodeCapacity: node}
+	cpuLimit := float64(node.MaxCPU) * ratios.CPU
+	memoryLimit := float64(node.MemoryTotalBytes/bytesPerMiB) * ratios.Memory
+	cpuAfter := float64(node.CommittedCPU + demand.CPUCores)
+	memoryAfter := float64(node.CommittedMemoryMB + int64(demand.MemoryMB))
+	switch {
+	case !node.Online:
+		candidate.Reason = "节点离线或状态未知"
+	case node.MaxCPU <= 0 || node.MemoryTotalBytes <= 0:
+		candidate.Reason = "节点容量未知"
+	case cpuAfter > cpuLimit:
+		candidate.Reason = "CPU 超出超分上限"
+	case memoryAfter > memoryLimit:
+		candidate.Reason = "内存超出超分上限"
+	case (node.MemoryTotalBytes-node.MemoryUsedBytes)/bytesPerMiB < int64(demand.MemoryMB):
+		candidate.Reason = "实时可用内存不足"
+	case node.StorageTotalBytes > 0 && float64(node.CommittedDiskGB+int64(demand.DiskGB)) > float64(node.StorageTotalBytes/bytesPerGiB)*ratios.Storage:
+		candidate.Reason = "存储超出超分上限"
+	case node.StorageTotalBytes > 0 && (node.StorageTotalBytes-node.StorageUsedBytes)/bytesPerGiB < int64(demand.DiskGB):
+		candidate.Reason = "存储可用空间不足"
+	default:
+		candidate.Eligible = true
+	}
+	if cpuLimit > 0 && memoryLimit > 0 {
+		candidate.Load = max(cpuAfter/cpuLimit, memoryAfter/memoryLimit, node.CPUUsage)
+	}
+	return candidate
+}
+
+// PlacementNodes 合并映射主节点和额外候选节点，去重并保持顺序；extra 支持逗号、空白或换行分隔。
+func PlacementNodes(primary string, extra string) []string {
+	nodes := make([]string, 0, 4)
+	seen := map[string]bool{}
+	for _, node := range append([]string{primary}, strings.FieldsFunc(extra, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t' })...) {
+		node = strings.TrimSpace(node)
+		if node == "" || seen[node] {
+			continue
+		}
+		seen[node] = true
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
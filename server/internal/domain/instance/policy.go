@@ -1,5 +1,10 @@
 package instance
 
+import (
+	"math"
+	"time"
+)
+
 const (
 	StatusCreating  = "creating"
 	StatusRunning   = "running"
@@ -7,12 +12,50 @@ const (
 	StatusError     = "error"
 	StatusReleasing = "releasing"
 	StatusReleased  = "released"
+	// StatusSuspended 表示实例被到期或后台暂停：VM 已关机，除释放外的操作均被拒绝。
+	StatusSuspended = "suspended"
 
 	OperationProvision = "provision"
 	OperationStart     = "start"
 	OperationStop      = "stop"
+	OperationReboot    = "reboot"
+	OperationShutdown  = "shutdown"
+	OperationReset     = "reset"
+	OperationReinstall = "reinstall"
 	OperationRelease   = "release"
 	OperationSync      = "sync"
+	OperationResize    = "resize"
+
+	// OperationResetPassword 通过 guest agent 重置系统登录密码，并同步 cloud-init 密码配置。
+	OperationResetPassword = "reset_password"
+
+	OperationSnapshotCreate   = "snapshot_create"
+	OperationSnapshotRollback = "snapshot_rollback"
+	OperationSnapshotDelete   = "snapshot_delete"
+
+	SnapshotStatusCreating  = "creating"
+	SnapshotStatusAvailable = "available"
+	SnapshotStatusDeleting  = "deleting"
+	SnapshotStatusDeleted   = "deleted"
+	SnapshotStatusFailed    = "failed"
+
+	OperationBackupCreate  = "backup_create"
+	OperationBackupRestore = "backup_restore"
+
+	BackupStatusCreating  = "creating"
+	BackupStatusAvailable = "available"
+	BackupStatusDeleting  = "deleting"
+	BackupStatusDeleted   = "deleted"
+	BackupStatusFailed    = "failed"
+
+	BackupSourceManual    = "manual"
+	BackupSourceScheduled = "scheduled"
+
+	BackupFrequencyDaily  = "daily"
+	BackupFrequencyWeekly = "weekly"
+
+	ConsoleTypeVNC      = "vnc"
+	ConsoleTypeTerminal = "terminal"
 
 	OperationStatusRunning   = "running"
 	OperationStatusSucceeded = "succeeded"
@@ -21,9 +64,15 @@ const (
 	TaskTypeOperationSync    = "instance_operation_sync"
 	TaskTypeExpiryNotice     = "instance_expiry_notice"
 	TaskTypeExpiryRelease    = "instance_expiry_release"
+	TaskTypeExpirySuspend    = "instance_expiry_suspend"
 	TaskTypePaymentProvision = "payment_order_provision"
 	TaskTypeEmailSend        = "notification_email_send"
 	TaskTypeSMSPlaceholder   = "notification_sms_placeholder"
+	TaskTypeBackupScheduled  = "instance_backup_scheduled"
+	TaskTypeChangePlan       = "instance_change_plan"
+	TaskTypeCapacitySync     = "catalog_capacity_sync"
+	TaskTypeReconcile        = "instance_reconcile"
+	TaskTypeTrafficMeter     = "instance_traffic_meter"
 
 	TaskStatusPending   = "pending"
 	TaskStatusRunning   = "running"
@@ -37,11 +86,14 @@ const (
 	NotificationStatusSent    = "sent"
 	NotificationStatusFailed  = "failed"
 	NotificationStatusSkipped = "skipped"
+
+	// NotificationSceneExpiryNotice 是到期提醒邮件的通知场景，只有该场景发送后回写实例 expire_notice_sent_at。
+	NotificationSceneExpiryNotice = "instance_expiry_notice"
 )
 
 func IsKnownStatus(status string) bool {
 	switch status {
-	case "", StatusCreating, StatusRunning, StatusStopped, StatusError, StatusReleasing, StatusReleased:
+	case "", StatusCreating, StatusRunning, StatusStopped, StatusError, StatusReleasing, StatusReleased, StatusSuspended:
 		return true
 	default:
 		return false
@@ -56,6 +108,132 @@ func CanStop(status string) bool {
 	return status == StatusRunning
 }
 
+func CanReboot(status string) bool {
+	return status == StatusRunning
+}
+
+func CanShutdown(status string) bool {
+	return status == StatusRunning
+}
+
+// CanReset 允许对运行中的实例强制重置；来宾系统卡死时上游仍报告 running。
+func CanReset(status string) bool {
+	return status == StatusRunning
+}
+
+func CanReinstall(status string) bool {
+	return status == StatusRunning || status == StatusStopped
+}
+
+// CanResize 限制变更套餐调整规格只在实例处于稳定状态时执行。
+func CanResize(status string) bool {
+	return status == StatusRunning || status == StatusStopped
+}
+
+// CanSnapshot 限制快照创建、回滚和删除只在实例处于稳定状态时执行。
+func CanSnapshot(status string) bool {
+	return status == StatusRunning || status == StatusStopped
+}
+
+func CanRollbackSnapshot(snapshotStatus string) bool {
+	return snapshotStatus == SnapshotStatusAvailable
+}
+
+// CanDeleteSnapshot 允许删除可用快照；创建失败的快照只在本地标记删除。
+func CanDeleteSnapshot(snapshotStatus string) bool {
+	return snapshotStatus == SnapshotStatusAvailable || snapshotStatus == SnapshotStatusFailed
+}
+
+// SnapshotStatusesInQuota 返回占用套餐快照配额的快照状态。
+func SnapshotStatusesInQuota() []string {
+	return []string{SnapshotStatusCreating, SnapshotStatusAvailable, SnapshotStatusDeleting}
+}
+
+// SnapshotStatusAfterOperation 返回快照操作结束后快照应进入的状态，非快照操作返回空字符串。
+func SnapshotStatusAfterOperation(action string, succeeded bool) string {
+	switch action {
+	case OperationSnapshotCreate:
+		if succeeded {
+			return SnapshotStatusAvailable
+		}
+		return SnapshotStatusFailed
+	case OperationSnapshotDelete:
+		if succeeded {
+			return SnapshotStatusDeleted
+		}
+		return SnapshotStatusAvailable
+	case OperationSnapshotRollback:
+		return SnapshotStatusAvailable
+	default:
+		return ""
+	}
+}
+
+// CanBackup 允许对运行中或已关机的实例创建备份，运行中实例由上游按配置模式处理一致性。
+func CanBackup(status string) bool {
+	return status == StatusRunning || status == StatusStopped
+}
+
+// CanRestoreBackup 要求实例先关机，恢复会覆盖当前系统盘和数据盘。
+func CanRestoreBackup(status string) bool {
+	return status == StatusStopped
+}
+
+func CanDeleteBackup(backupStatus string) bool {
+	return backupStatus == BackupStatusAvailable || backupStatus == BackupStatusFailed
+}
+
+// BackupStatusesInQuota 返回占用手动备份数量上限的备份状态。
+func BackupStatusesInQuota() []string {
+	return []string{BackupStatusCreating, BackupStatusAvailable, BackupStatusDeleting}
+}
+
+// BackupStatusAfterOperation 返回备份操作结束后备份应进入的状态，非备份操作返回空字符串。
+func BackupStatusAfterOperation(action string, succeeded bool) string {
+	switch action {
+	case OperationBackupCreate:
+		if succeeded {
+			return BackupStatusAvailable
+		}
+		return BackupStatusFailed
+	case OperationBackupRestore:
+		return BackupStatusAvailable
+	default:
+		return ""
+	}
+}
+
+func IsKnownBackupFrequency(frequency string) bool {
+	return frequency == BackupFrequencyDaily || frequency == BackupFrequencyWeekly
+}
+
+// NextBackupRun 返回 after 之后最近一次整点备份时间；weekly 策略额外限定星期，0 表示星期日。
+func NextBackupRun(frequency string, weekday int, hour int, after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), hour, 0, 0, 0, after.Location())
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	if frequency == BackupFrequencyWeekly {
+		days := (weekday - int(next.Weekday()) + 7) % 7
+		next = next.AddDate(0, 0, days)
+	}
+	return next
+}
+
+// CanResetPassword 要求实例运行中，重置密码依赖 guest agent 在线。
+func CanResetPassword(status string) bool {
+	return status == StatusRunning
+}
+
+// CanOpenConsole 只允许连接运行中实例的控制台；关机实例没有可连接的显示或串口。
+func CanOpenConsole(status string) bool {
+	return status == StatusRunning
+}
+
+func IsKnownConsoleType(consoleType string) bool {
+	return consoleType == ConsoleTypeVNC || consoleType == ConsoleTypeTerminal
+}
+
 func CanRelease(status string) bool {
 	return status != StatusReleasing && status != StatusReleased
 }
@@ -82,9 +260,56 @@ func IsKnownTaskStatus(status string) bool {
 
 func IsKnownTaskType(taskType string) bool {
 	switch taskType {
-	case "", TaskTypeOperationSync, TaskTypeExpiryNotice, TaskTypeExpiryRelease, TaskTypePaymentProvision, TaskTypeEmailSend, TaskTypeSMSPlaceholder:
+	case "", TaskTypeOperationSync, TaskTypeExpiryNotice, TaskTypeExpiryRelease, TaskTypeExpirySuspend, TaskTypePaymentProvision, TaskTypeEmailSend, TaskTypeSMSPlaceholder, TaskTypeBackupScheduled, TaskTypeChangePlan, TaskTypeCapacitySync, TaskTypeReconcile, TaskTypeTrafficMeter, TaskTypeRescueExit, TaskTypeMigrate, TaskTypeCredentialReset:
 		return true
 	default:
 		return false
 	}
 }
+
+// Spec 是实例硬件规格；磁盘单位 GiB，带宽单位 Mbps，0 表示无数据盘或不限速。
+type Spec struct {
+	CPUCores      int
+	MemoryMB      int
+	SystemDiskGB  int
+	DataDiskGB    int
+	BandwidthMbps int
+}
+
+// NetworkRateMBps 把套餐带宽（Mbps）换算为 PVE 网卡限速（MB/s），0 表示不限速。
+func NetworkRateMBps(bandwidthMbps int) float64 {
+	if bandwidthMbps <= 0 {
+		return 0
+	}
+	return float64(bandwidthMbps) / 8
+}
+
+// BandwidthFromRate 把 PVE 网卡限速（MB/s）换算回 Mbps，四舍五入到整数。
+func BandwidthFromRate(rate float64) int {
+	if rate <= 0 {
+		return 0
+	}
+	return int(math.Round(rate * 8))
+}
+
+// ConfigDrift 返回实际规格偏离期望规格的字段名。系统盘允许大于期望值（模板镜像本身可能更大），
+// 其余字段必须完全一致。
+func ConfigDrift(expected, actual Spec) []string {
+	drift := []string{}
+	if actual.CPUCores != expected.CPUCores {
+		drift = append(drift, "cpu_cores")
+	}
+	if actual.MemoryMB != expected.MemoryMB {
+		drift = append(drift, "memory_mb")
+	}
+	if actual.SystemDiskGB < expected.SystemDiskGB {
+		drift = append(drift, "system_disk_gb")
+	}
+	if actual.DataDiskGB != expected.DataDiskGB {
+		drift = append(drift, "data_disk_gb")
+	}
+	if actual.BandwidthMbps != expected.BandwidthMbps {
+		drift = append(drift, "bandwidth_mbps")
+	}
+	return drift
+}
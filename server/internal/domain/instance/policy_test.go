@@ -1,6 +1,13 @@
 package instance
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestInstanceLifecyclePolicy(t *testing.T) {
 	if !CanStart(StatusStopped) || CanStart(StatusRunning) {
@@ -17,8 +24,71 @@ func TestInstanceLifecyclePolicy(t *testing.T) {
 	}
 }
 
+func TestInstancePowerPolicyRequiresRunningGuest(t *testing.T) {
+	for _, status := range []string{StatusCreating, StatusStopped, StatusError, StatusReleasing, StatusReleased} {
+		if CanReboot(status) || CanShutdown(status) || CanReset(status) {
+			t.Fatalf("status %q must not allow reboot, shutdown or reset", status)
+		}
+	}
+	if !CanReboot(StatusRunning) || !CanShutdown(StatusRunning) || !CanReset(StatusRunning) {
+		t.Fatal("running instances should allow reboot, shutdown and reset")
+	}
+}
+
+func TestConsolePolicyRequiresRunningGuestAndKnownType(t *testing.T) {
+	for _, status := range []string{StatusCreating, StatusStopped, StatusError, StatusReleasing, StatusReleased} {
+		if CanOpenConsole(status) {
+			t.Fatalf("status %q must not allow console", status)
+		}
+	}
+	if !CanOpenConsole(StatusRunning) {
+		t.Fatal("running instances should allow console")
+	}
+	if !IsKnownConsoleType(ConsoleTypeVNC) || !IsKnownConsoleType(ConsoleTypeTerminal) || IsKnownConsoleType("spice") {
+		t.Fatal("console types should be limited to vnc and terminal")
+	}
+}
+
+func TestInstanceReinstallPolicyRequiresSettledGuest(t *testing.T) {
+	if !CanReinstall(StatusRunning) || !CanReinstall(StatusStopped) {
+		t.Fatal("running or stopped instances should allow reinstall")
+	}
+	for _, status := range []string{StatusCreating, StatusError, StatusReleasing, StatusReleased} {
+		if CanReinstall(status) {
+			t.Fatalf("status %q must not allow reinstall", status)
+		}
+	}
+}
+
+func TestSnapshotStatusAfterOperationRestoresUsableState(t *testing.T) {
+	cases := []struct {
+		action    string
+		succeeded bool
+		want      string
+	}{
+		{OperationSnapshotCreate, true, SnapshotStatusAvailable},
+		{OperationSnapshotCreate, false, SnapshotStatusFailed},
+		{OperationSnapshotDelete, true, SnapshotStatusDeleted},
+		{OperationSnapshotDelete, false, SnapshotStatusAvailable},
+		{OperationSnapshotRollback, true, SnapshotStatusAvailable},
+		{OperationSnapshotRollback, false, SnapshotStatusAvailable},
+		{OperationStart, true, ""},
+	}
+	for _, tc := range cases {
+		if got := SnapshotStatusAfterOperation(tc.action, tc.succeeded); got != tc.want {
+			t.Fatalf("%s succeeded=%v should settle snapshot as %q, got %q", tc.action, tc.succeeded, tc.want, got)
+		}
+	}
+	if CanRollbackSnapshot(SnapshotStatusFailed) || !CanDeleteSnapshot(SnapshotStatusFailed) {
+		t.Fatal("failed snapshots can only be deleted")
+	}
+	if CanSnapshot(StatusCreating) || CanSnapshot(StatusError) {
+		t.Fatal("snapshots require a settled instance")
+	}
+}
+
 func TestTaskPolicyRecognizesWorkerLifecycleTypes(t *testing.T) {
-	for _, taskType := range []string{TaskTypeOperationSync, TaskTypeExpiryNotice, TaskTypeExpiryRelease, TaskTypeEmailSend, TaskTypeSMSPlaceholder} {
+	for _, taskType := range []string{TaskTypeOperationSync, TaskTypeExpiryNotice, TaskTypeExpiryRelease, TaskTypeEmailSend, TaskTypeSMSPlaceholder, TaskTypeBackupScheduled, TaskTypeChangePlan, TaskTypeCapacitySync, TaskTypeReconcile, TaskTypeTrafficMeter} {
 		if !IsKnownTaskType(taskType) {
 			t.Fatalf("task type %q should be known", taskType)
 		}
@@ -37,6 +107,24 @@ func TestTaskPolicyRecognizesWorkerLifecycleTypes(t *testing.T) {
 	}
 }
 
+func TestNextBackupRunPicksNextWholeHour(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	// 2026-10-16 是星期五。
+	after := time.Date(2026, 10, 16, 3, 0, 0, 0, loc)
+	if got := NextBackupRun(BackupFrequencyDaily, 0, 3, after); !got.Equal(time.Date(2026, 10, 17, 3, 0, 0, 0, loc)) {
+		t.Fatalf("daily run at the same hour should move to the next day, got %s", got)
+	}
+	if got := NextBackupRun(BackupFrequencyDaily, 0, 4, after); !got.Equal(time.Date(2026, 10, 16, 4, 0, 0, 0, loc)) {
+		t.Fatalf("daily run later today should stay today, got %s", got)
+	}
+	if got := NextBackupRun(BackupFrequencyWeekly, int(time.Monday), 2, after); !got.Equal(time.Date(2026, 10, 19, 2, 0, 0, 0, loc)) {
+		t.Fatalf("weekly run should land on the next Monday, got %s", got)
+	}
+	if got := NextBackupRun(BackupFrequencyWeekly, int(time.Friday), 3, after); !got.Equal(time.Date(2026, 10, 23, 3, 0, 0, 0, loc)) {
+		t.Fatalf("weekly run at the current slot should move a week ahead, got %s", got)
+	}
+}
+
 func TestMapVMStatusKeepsOnlyUserVisiblePowerStates(t *testing.T) {
 	if got := MapVMStatus(StatusRunning); got != StatusRunning {
 		t.Fatalf("running VM should map to running, got %q", got)
@@ -48,3 +136,404 @@ func TestMapVMStatusKeepsOnlyUserVisiblePowerStates(t *testing.T) {
 		t.Fatalf("unsupported VM status should map to error, got %q", got)
 	}
 }
+
+func TestConfigDriftComparesRealSpecWithOrder(t *testing.T) {
+	expected := Spec{CPUCores: 2, MemoryMB: 4096, SystemDiskGB: 40, DataDiskGB: 100, BandwidthMbps: 20}
+	if drift := ConfigDrift(expected, Spec{CPUCores: 2, MemoryMB: 4096, SystemDiskGB: 50, DataDiskGB: 100, BandwidthMbps: BandwidthFromRate(NetworkRateMBps(20))}); len(drift) != 0 {
+		t.Fatalf("matching spec with larger system disk should not drift, got %v", drift)
+	}
+	drift := ConfigDrift(expected, Spec{CPUCores: 4, MemoryMB: 4096, SystemDiskGB: 20, DataDiskGB: 0, BandwidthMbps: 0})
+	if !reflect.DeepEqual(drift, []string{"cpu_cores", "system_disk_gb", "data_disk_gb", "bandwidth_mbps"}) {
+		t.Fatalf("unexpected drift fields: %v", drift)
+	}
+	if NetworkRateMBps(0) != 0 || NetworkRateMBps(100) != 12.5 {
+		t.Fatal("bandwidth should convert to MB/s with 0 meaning unlimited")
+	}
+}
+
+func TestExpandIPPoolSkipsNetworkBroadcastAndReservesGateway(t *testing.T) {
+	layout, err := ExpandIPPool("203.0.113.7/29", "203.0.113.1", []string{"203.0.113.5-203.0.113.6"})
+	if err != nil {
+		t.Fatalf("expand pool: %v", err)
+	}
+	if layout.CIDR != "203.0.113.0/29" || layout.PrefixLength != 29 || len(layout.Addresses) != 6 {
+		t.Fatalf("unexpected layout: %+v", layout)
+	}
+	reserved := []string{}
+	for _, addr := range layout.Addresses {
+		if addr.Reserved {
+			reserved = append(reserved, addr.Address)
+		}
+	}
+	if !reflect.DeepEqual(reserved, []string{"203.0.113.1", "203.0.113.5", "203.0.113.6"}) {
+		t.Fatalf("unexpected reserved addresses: %v", reserved)
+	}
+	if IPConfig("203.0.113.2", 29, "203.0.113.1") != "ip=203.0.113.2/29,gw=203.0.113.1" {
+		t.Fatal("ipconfig should carry address, prefix and gateway")
+	}
+	if _, err := ExpandIPPool("10.0.0.0/16", "10.0.0.1", nil); err != ErrIPPoolTooLarge {
+		t.Fatalf("oversized pool should be rejected, got %v", err)
+	}
+	if _, err := ExpandIPPool("203.0.113.0/29", "198.51.100.1", nil); err != ErrInvalidGateway {
+		t.Fatalf("gateway outside cidr should be rejected, got %v", err)
+	}
+}
+
+func TestChoosePlacementAppliesOvercommitAndUserAntiAffinity(t *testing.T) {
+	const gib = int64(1024 * 1024 * 1024)
+	nodes := []NodeCapacity{
+		{Node: "pve1", Online: true, MaxCPU: 8, MemoryTotalBytes: 64 * gib, MemoryUsedBytes: 8 * gib, CommittedCPU: 2, CommittedMemoryMB: 4096, UserInstances: 1},
+		{Node: "pve2", Online: true, MaxCPU: 8, MemoryTotalBytes: 64 * gib, MemoryUsedBytes: 32 * gib, CommittedCPU: 10, CommittedMemoryMB: 32768},
+		{Node: "pve3", Online: true, MaxCPU: 4, MemoryTotalBytes: 64 * gib, CommittedCPU: 7},
+		{Node: "pve4", Online: false, MaxCPU: 64, MemoryTotalBytes: 512 * gib},
+	}
+	demand := PlacementDemand{CPUCores: 2, MemoryMB: 4096, DiskGB: 40}
+	ratios := OvercommitRatios{CPU: 2, Memory: 1, Storage: 1}
+
+	node, candidates := ChoosePlacement(nodes, demand, ratios, true)
+	if node != "pve2" {
+		t.Fatalf("anti-affinity should prefer node without user instances, got %q: %+v", node, candidates)
+	}
+	reasons := map[string]string{}
+	for _, candidate := range candidates {
+		reasons[candidate.Node] = candidate.Reason
+	}
+	if reasons["pve3"] != "CPU 超出超分上限" || reasons["pve4"] != "节点离线或状态未知" {
+		t.Fatalf("unexpected rejection reasons: %v", reasons)
+	}
+	if node, _ := ChoosePlacement(nodes, demand, ratios, false); node != "pve1" {
+		t.Fatalf("without anti-affinity the least loaded node should win, got %q", node)
+	}
+	if node, _ := ChoosePlacement(nodes[2:], demand, ratios, true); node != "" {
+		t.Fatalf("no eligible node should yield empty placement, got %q", node)
+	}
+	if got := PlacementNodes("pve1", "pve2, pve1\npve3"); !reflect.DeepEqual(got, []string{"pve1", "pve2", "pve3"}) {
+		t.Fatalf("unexpected placement nodes: %v", got)
+	}
+}
+
+func TestReconcileClassifiesOrphansGhostsAndDrift(t *testing.T) {
+	vms := []ReconcileVM{
+		{Node: "pve1", VMID: 100, Status: "running", CPUs: 2, MemoryMB: 2048},
+		{Node: "pve1", VMID: 101, Status: "stopped", CPUs: 4, MemoryMB: 2048},
+		{Node: "pve1", VMID: 102, Status: "running", CPUs: 1, MemoryMB: 1024},
+		{Node: "pve1", VMID: 9000, Status: "stopped", Template: true},
+		{Node: "pve1", VMID: 50, Status: "running", CPUs: 8, MemoryMB: 8192},
+		{Node: "pve1", VMID: 104, Status: "stopped"},
+	}
+	instances := []ReconcileInstance{
+		{InstanceNo: "INS-OK", Status: StatusRunning, Node: "pve1", VMID: 100, CPUCores: 2, MemoryMB: 2048},
+		{InstanceNo: "INS-DRIFT", Status: StatusRunning, Node: "pve1", VMID: 101, CPUCores: 2, MemoryMB: 2048},
+		{InstanceNo: "INS-GHOST", Status: StatusStopped, Node: "pve1", VMID: 103, CPUCores: 1, MemoryMB: 1024},
+		{InstanceNo: "INS-CREATING", Status: StatusCreating, Node: "pve1", VMID: 105, CPUCores: 1, MemoryMB: 1024},
+		{InstanceNo: "INS-UNSCANNED", Status: StatusRunning, Node: "pve2", VMID: 100, CPUCores: 1, MemoryMB: 1024},
+		{InstanceNo: "INS-RELEASING", Status: StatusReleasing, Node: "pve1", VMID: 104, CPUCores: 4, MemoryMB: 4096},
+	}
+	managed := func(node string, vmid uint) bool { return vmid >= 100 && vmid < 200 }
+
+	findings := Reconcile(vms, instances, []string{"pve1"}, managed)
+	var got []string
+	for _, finding := range findings {
+		got = append(got, fmt.Sprintf("%s:%s/%d", finding.Kind, finding.Node, finding.VMID))
+	}
+	want := []string{"drift:pve1/101", "orphan:pve1/102", "ghost:pve1/103"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected findings: %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(findings[0].Drift, []string{"status", "cpu_cores"}) {
+		t.Fatalf("unexpected drift fields: %v", findings[0].Drift)
+	}
+}
+
+func TestAllocateVMIDSkipsUsedIDsAndReusesReleased(t *testing.T) {
+	used := map[uint]bool{100: true, 101: true, 103: true}
+	allocation, ok := AllocateVMID(100, 110, 101, nil, used, false)
+	if !ok || allocation.VMID != 102 || allocation.NextVMID != 103 || allocation.Reused || !reflect.DeepEqual(allocation.Skipped, []uint{101}) {
+		t.Fatalf("unexpected allocation: %+v ok=%v", allocation, ok)
+	}
+	allocation, ok = AllocateVMID(100, 110, 104, []uint{100, 102}, used, true)
+	if !ok || allocation.VMID != 102 || allocation.NextVMID != 104 || !allocation.Reused {
+		t.Fatalf("released vmid should be reused first: %+v ok=%v", allocation, ok)
+	}
+	allocation, ok = AllocateVMID(100, 110, 104, []uint{102}, used, false)
+	if !ok || allocation.VMID != 104 || allocation.Reused {
+		t.Fatalf("released vmid must not be reused when disabled: %+v ok=%v", allocation, ok)
+	}
+	if _, ok := AllocateVMID(100, 103, 103, nil, used, true); ok {
+		t.Fatal("fully used range should be exhausted")
+	}
+	if VMIDRemaining(110, 104) != 7 || VMIDRemaining(110, 111) != 0 {
+		t.Fatal("unexpected remaining vmid count")
+	}
+}
+
+func TestDownsampleMetricsAveragesBucketsAndKeepsGaps(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+	points := []MetricPoint{
+		{Time: 0, CPUPercent: value(10), NetInBps: value(100)},
+		{Time: 60, CPUPercent: value(30)},
+		{Time: 120},
+		{Time: 180},
+		{Time: 240, CPUPercent: value(50)},
+	}
+	got := DownsampleMetrics(points, 3)
+	if len(got) != 3 || got[0].Time != 0 || got[1].Time != 120 || got[2].Time != 240 {
+		t.Fatalf("unexpected buckets: %+v", got)
+	}
+	if *got[0].CPUPercent != 20 || *got[0].NetInBps != 100 {
+		t.Fatalf("bucket should average present values: %+v", got[0])
+	}
+	if got[1].CPUPercent != nil || got[1].NetInBps != nil {
+		t.Fatalf("empty bucket should stay nil: %+v", got[1])
+	}
+	if len(DownsampleMetrics(points, 10)) != len(points) {
+		t.Fatal("points within limit should be returned as is")
+	}
+	if !IsKnownMetricsRange(MetricsRangeWeek) || IsKnownMetricsRange("year") {
+		t.Fatal("unexpected metrics range validation")
+	}
+}
+
+func TestTrafficMeteringCountersAndQuota(t *testing.T) {
+	if TrafficCounterDelta(100, 250) != 150 || TrafficCounterDelta(500, 40) != 40 {
+		t.Fatal("counter delta should restart from current value after VM restart")
+	}
+	if TrafficBilledBytes(10, 30, TrafficDirectionOut) != 30 || TrafficBilledBytes(10, 30, TrafficDirectionBoth) != 40 || TrafficBilledBytes(50, 30, TrafficDirectionMax) != 50 || TrafficBilledBytes(50, 30, TrafficDirectionIn) != 50 {
+		t.Fatal("unexpected billed bytes by direction")
+	}
+	quotaGB := 10
+	quota := TrafficQuotaBytes(&quotaGB)
+	if quota != 10*BytesPerGB || TrafficQuotaBytes(nil) != 0 {
+		t.Fatal("unexpected traffic quota bytes")
+	}
+	if TrafficUsedPercent(8*BytesPerGB, quota) != 80 || TrafficUsedPercent(BytesPerGB, 0) != 0 {
+		t.Fatal("unexpected traffic used percent")
+	}
+	if TrafficOverageGB(quota, quota) != 0 || TrafficOverageGB(quota+1, quota) != 1 || TrafficOverageGB(quota+2*BytesPerGB, quota) != 2 || TrafficOverageGB(quota*2, 0) != 0 {
+		t.Fatal("overage should round partial GB up and ignore unlimited quota")
+	}
+	if TrafficPeriod(time.Date(2026, 3, 31, 23, 59, 0, 0, time.Local)) != "2026-03" {
+		t.Fatal("unexpected traffic period")
+	}
+}
+
+func TestAllowedWhileTrafficSuspendedOnlyAllowsOperationsThatKeepVMOff(t *testing.T) {
+	allowed := map[string]bool{
+		OperationStop:             true,
+		OperationShutdown:         true,
+		OperationSnapshotCreate:   true,
+		OperationSnapshotDelete:   true,
+		OperationBackupCreate:     true,
+		OperationISOUnmount:       true,
+		OperationStart:            false,
+		OperationReboot:           false,
+		OperationReset:            false,
+		OperationReinstall:        false,
+		OperationResetPassword:    false,
+		OperationSnapshotRollback: false,
+		OperationBackupRestore:    false,
+		OperationRescueEnter:      false,
+		OperationRescueExit:       false,
+		OperationISOMount:         false,
+	}
+	for action, want := range allowed {
+		if got := AllowedWhileTrafficSuspended(action); got != want {
+			t.Fatalf("AllowedWhileTrafficSuspended(%s) = %v, want %v", action, got, want)
+		}
+	}
+}
+
+func TestNormalizeFirewallRuleAndDefaults(t *testing.T) {
+	rule, err := NormalizeFirewallRule(FirewallRule{Direction: "IN", Protocol: "TCP", PortRange: " 8000 - 8080 ", CIDR: "10.1.2.3/8"})
+	if err != nil || rule.Direction != FirewallDirectionIn || rule.Protocol != FirewallProtocolTCP || rule.PortRange != "8000-8080" || rule.CIDR != "10.0.0.0/8" {
+		t.Fatalf("unexpected normalized rule: %+v %v", rule, err)
+	}
+	if rule, err := NormalizeFirewallRule(FirewallRule{Direction: "in", Protocol: "udp", PortRange: "53-53", CIDR: "2001:db8::1"}); err != nil || rule.PortRange != "53" || rule.CIDR != "2001:db8::1/128" {
+		t.Fatalf("single port range and host address should collapse: %+v %v", rule, err)
+	}
+	invalid := []FirewallRule{
+		{Direction: "both", Protocol: "tcp", PortRange: "22", CIDR: "0.0.0.0/0"},
+		{Direction: "in", Protocol: "gre", CIDR: "0.0.0.0/0"},
+		{Direction: "in", Protocol: "tcp", CIDR: "0.0.0.0/0"},
+		{Direction: "in", Protocol: "icmp", PortRange: "22", CIDR: "0.0.0.0/0"},
+		{Direction: "in", Protocol: "tcp", PortRange: "70000", CIDR: "0.0.0.0/0"},
+		{Direction: "in", Protocol: "tcp", PortRange: "90-80", CIDR: "0.0.0.0/0"},
+		{Direction: "in", Protocol: "tcp", PortRange: "22", CIDR: "example.com"},
+	}
+	for _, item := range invalid {
+		if _, err := NormalizeFirewallRule(item); err == nil {
+			t.Fatalf("rule should be rejected: %+v", item)
+		}
+	}
+	linux := DefaultSecurityGroupRules("linux")
+	windows := DefaultSecurityGroupRules("windows")
+	if linux[len(linux)-1].PortRange != "22" || windows[len(windows)-1].PortRange != "3389" {
+		t.Fatalf("unexpected default rules: %+v %+v", linux, windows)
+	}
+	if in, out := FirewallPolicies(linux); in != FirewallPolicyDrop || out != FirewallPolicyAccept {
+		t.Fatal("outbound should be open without outbound rules")
+	}
+	if _, out := FirewallPolicies(append(linux, FirewallRule{Direction: FirewallDirectionOut, Protocol: FirewallProtocolAny, CIDR: "10.0.0.0/8"})); out != FirewallPolicyDrop {
+		t.Fatal("outbound rules should switch outbound policy to drop")
+	}
+}
+
+func TestNormalizePTRHostnameAndForwardConfirm(t *testing.T) {
+	if hostname, err := NormalizePTRHostname(" Mail.Example.COM. "); err != nil || hostname != "mail.example.com" {
+		t.Fatalf("unexpected normalized hostname: %q %v", hostname, err)
+	}
+	for _, item := range []string{"", "localhost", "-mail.example.com", "mail-.example.com", "mail..example.com", "mail_1.example.com", "mail.example.123", strings.Repeat("a", 64) + ".example.com"} {
+		if _, err := NormalizePTRHostname(item); err == nil {
+			t.Fatalf("hostname should be rejected: %q", item)
+		}
+	}
+	if !PTRForwardConfirmed("192.0.2.10", []string{"2001:db8::1", "192.0.2.10"}) || !PTRForwardConfirmed("2001:db8::1", []string{"2001:0db8:0:0::1"}) {
+		t.Fatal("matching forward record should confirm ptr")
+	}
+	if PTRForwardConfirmed("192.0.2.10", []string{"192.0.2.11"}) || PTRForwardConfirmed("192.0.2.10", nil) {
+		t.Fatal("ptr without matching forward record should not be confirmed")
+	}
+}
+
+func TestTransferStatusAndEligibility(t *testing.T) {
+	for _, status := range []string{"", TransferStatusPending, TransferStatusCompleted, TransferStatusRejected, TransferStatusCancelled} {
+		if !IsKnownTransferStatus(status) {
+			t.Fatalf("transfer status should be known: %q", status)
+		}
+	}
+	if IsKnownTransferStatus("expired") {
+		t.Fatal("unknown transfer status should be rejected")
+	}
+	if !CanTransfer(StatusRunning) || !CanTransfer(StatusStopped) {
+		t.Fatal("delivered instances should be transferable")
+	}
+	for _, status := range []string{StatusCreating, StatusError, StatusReleasing, StatusReleased} {
+		if CanTransfer(status) {
+			t.Fatalf("instance in %s should not be transferable", status)
+		}
+	}
+}
+
+func TestSuspendedStatusPolicy(t *testing.T) {
+	if !IsKnownStatus(StatusSuspended) || !IsKnownTaskType(TaskTypeExpirySuspend) {
+		t.Fatal("suspended status and expiry suspend task should be known")
+	}
+	if !CanSuspend(StatusRunning) || !CanSuspend(StatusStopped) {
+		t.Fatal("delivered instances should be suspendable")
+	}
+	for _, status := range []string{StatusCreating, StatusError, StatusReleasing, StatusReleased, StatusSuspended} {
+		if CanSuspend(status) {
+			t.Fatalf("instance in %s should not be suspendable", status)
+		}
+	}
+	if CanStart(StatusSuspended) || CanReboot(StatusSuspended) || CanReinstall(StatusSuspended) || CanTransfer(StatusSuspended) {
+		t.Fatal("suspended instance should block power and lifecycle operations")
+	}
+	if !CanRelease(StatusSuspended) {
+		t.Fatal("suspended instance should still be releasable after the grace window")
+	}
+	if !ResumesOnRenewal(StatusSuspended, SuspendSourceExpiry) || ResumesOnRenewal(StatusSuspended, SuspendSourceAdmin) || ResumesOnRenewal(StatusRunning, SuspendSourceExpiry) {
+		t.Fatal("only expiry suspension should resume on renewal")
+	}
+	if SyncedStatus(StatusSuspended, StatusStopped) != StatusSuspended || SyncedStatus(StatusSuspended, StatusError) != StatusSuspended || SyncedStatus(StatusRunning, StatusStopped) != StatusStopped {
+		t.Fatal("sync should keep suspended instances suspended")
+	}
+	suspended := ReconcileInstance{Status: StatusSuspended}
+	if drift := ReconcileDrift(suspended, ReconcileVM{Status: "stopped"}); len(drift) != 0 {
+		t.Fatalf("stopped vm of suspended instance should not drift: %v", drift)
+	}
+	if drift := ReconcileDrift(suspended, ReconcileVM{Status: "running"}); len(drift) != 1 || drift[0] != "status" {
+		t.Fatalf("running vm of suspended instance should drift: %v", drift)
+	}
+}
+
+func TestRescuePolicy(t *testing.T) {
+	if !IsKnownTaskType(TaskTypeRescueExit) {
+		t.Fatal("rescue exit task should be known")
+	}
+	if !CanEnterRescue(StatusRunning) || !CanEnterRescue(StatusStopped) || CanEnterRescue(StatusSuspended) || CanEnterRescue(StatusCreating) {
+		t.Fatal("only settled instances can enter rescue")
+	}
+	if !CanExitRescue(StatusSuspended) || !CanExitRescue(StatusError) || CanExitRescue(StatusReleased) {
+		t.Fatal("rescue exit should tolerate suspended and error instances but not released ones")
+	}
+	for _, action := range []string{OperationReinstall, OperationResetPassword, OperationSnapshotRollback, OperationBackupRestore, OperationRescueEnter, OperationISOMount} {
+		if !BlockedInRescue(action) {
+			t.Fatalf("%s should be blocked in rescue", action)
+		}
+	}
+	for _, action := range []string{OperationStart, OperationStop, OperationReboot, OperationRescueExit, OperationISOUnmount, OperationSnapshotCreate} {
+		if BlockedInRescue(action) {
+			t.Fatalf("%s should be allowed in rescue", action)
+		}
+	}
+	if err := ValidateISO(ISOPurposeRescue, ISOMediaDisk, "local-lvm:vm-9000-disk-0"); err != nil {
+		t.Fatalf("rescue disk should be valid: %v", err)
+	}
+	if err := ValidateISO(ISOPurposeInstall, ISOMediaISO, "cephfs:iso/debian-12.iso"); err != nil {
+		t.Fatalf("install iso should be valid: %v", err)
+	}
+	if err := ValidateISO(ISOPurposeInstall, ISOMediaDisk, "local-lvm:vm-9000-disk-0"); !errors.Is(err, ErrISOMediaInvalid) {
+		t.Fatalf("install media must be an iso: %v", err)
+	}
+	for _, volume := range []string{"", "debian.iso", "local:", ":iso/a.iso", "local:iso/a b.iso"} {
+		if err := ValidateISO(ISOPurposeInstall, ISOMediaISO, volume); !errors.Is(err, ErrISOVolumeInvalid) {
+			t.Fatalf("volume %q should be rejected: %v", volume, err)
+		}
+	}
+}
+
+func TestMigratePolicy(t *testing.T) {
+	if !IsKnownTaskType(TaskTypeMigrate) {
+		t.Fatal("migrate task should be known")
+	}
+	if !CanMigrate(StatusRunning) || !CanMigrate(StatusStopped) || !CanMigrate(StatusSuspended) || CanMigrate(StatusError) || CanMigrate(StatusReleasing) {
+		t.Fatal("only settled instances can migrate")
+	}
+	if !BlockedInRescue(OperationMigrate) {
+		t.Fatal("migrate should be blocked in rescue")
+	}
+	cases := []struct {
+		status   string
+		mode     string
+		online   bool
+		fallback bool
+		err      error
+	}{
+		{StatusRunning, "", true, true, nil},
+		{StatusRunning, MigrateModeAuto, true, true, nil},
+		{StatusStopped, MigrateModeAuto, false, false, nil},
+		{StatusSuspended, "", false, false, nil},
+		{StatusRunning, MigrateModeOnline, true, false, nil},
+		{StatusStopped, MigrateModeOnline, false, false, ErrMigrateOnlineRequired},
+		{StatusRunning, MigrateModeOffline, false, false, nil},
+		{StatusRunning, "live", false, false, ErrMigrateModeInvalid},
+	}
+	for _, tc := range cases {
+		online, fallback, err := MigrateStrategy(tc.status, tc.mode)
+		if online != tc.online || fallback != tc.fallback || !errors.Is(err, tc.err) {
+			t.Fatalf("MigrateStrategy(%q, %q) = %v, %v, %v", tc.status, tc.mode, online, fallback, err)
+		}
+	}
+}
+
+func TestClusterHealth(t *testing.T) {
+	cases := []struct {
+		enabled bool
+		err     error
+		nodes   []string
+		status  string
+		online  int
+	}{
+		{false, nil, []string{"online"}, ClusterStatusDisabled, 0},
+		{true, errors.New("timeout"), nil, ClusterStatusUnreachable, 0},
+		{true, nil, []string{"offline", "unknown"}, ClusterStatusUnreachable, 0},
+		{true, nil, []string{"online", "offline", "online"}, ClusterStatusOnline, 2},
+	}
+	for _, tc := range cases {
+		status, online := ClusterHealth(tc.enabled, tc.err, tc.nodes)
+		if status != tc.status || online != tc.online {
+			t.Fatalf("ClusterHealth(%v, %v, %v) = %q, %d", tc.enabled, tc.err, tc.nodes, status, online)
+		}
+	}
+}
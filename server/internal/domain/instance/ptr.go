@@ -0,0 +1,77 @@
+package instance
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+)
+
+const (
+	// PTRStatusPendingReview 表示自动写入关闭，等待管理员审批。
+	PTRStatusPendingReview = "pending_review"
+	// PTRStatusApplying 表示已通过校验，正在写入 DNS 后端。
+	PTRStatusApplying = "applying"
+	PTRStatusActive   = "active"
+	PTRStatusFailed   = "failed"
+	PTRStatusRejected = "rejected"
+
+	maxHostnameLength = 253
+	maxLabelLength    = 63
+)
+
+var (
+	ErrInvalidPTRHostname = errors.New("invalid ptr hostname")
+	ErrPTRNotConfirmed    = errors.New("ptr hostname does not resolve to address")
+)
+
+func IsKnownPTRStatus(status string) bool {
+	switch status {
+	case "", PTRStatusPendingReview, PTRStatusApplying, PTRStatusActive, PTRStatusFailed, PTRStatusRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+// NormalizePTRHostname 校验并规范化 PTR 主机名：转小写、去掉末尾的点，要求至少两级标签，
+// 每级只含字母、数字和连字符且不以连字符开头或结尾，顶级标签不能是纯数字。
+func NormalizePTRHostname(hostname string) (string, error) {
+	hostname = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(hostname)), ".")
+	if hostname == "" || len(hostname) > maxHostnameLength {
+		return "", ErrInvalidPTRHostname
+	}
+	labels := strings.Split(hostname, ".")
+	if len(labels) < 2 {
+		return "", ErrInvalidPTRHostname
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > maxLabelLength || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", ErrInvalidPTRHostname
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return "", ErrInvalidPTRHostname
+			}
+		}
+	}
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return "", ErrInvalidPTRHostname
+	}
+	return hostname, nil
+}
+
+// PTRForwardConfirmed 判断主机名的正向解析结果是否包含该地址（FCrDNS）；IPv6 地址按规范形式比较。
+func PTRForwardConfirmed(address string, resolved []string) bool {
+	want, err := netip.ParseAddr(strings.TrimSpace(address))
+	if err != nil {
+		return false
+	}
+	want = want.Unmap()
+	for _, item := range resolved {
+		got, err := netip.ParseAddr(strings.TrimSpace(item))
+		if err == nil && got.Unmap() == want {
+			return true
+		}
+	}
+	return false
+}
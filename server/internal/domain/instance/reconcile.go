@@ -0,0 +1,135 @@
+package instance
+
+import "sort"
+
+const (
+	ReconcileKindOrphan = "orphan"
+	ReconcileKindGhost  = "ghost"
+	ReconcileKindDrift  = "drift"
+
+	ReconcileReportRunning   = "running"
+	ReconcileReportSucceeded = "succeeded"
+	ReconcileReportFailed    = "failed"
+
+	ReconcileItemOpen     = "open"
+	ReconcileItemResolved = "resolved"
+
+	ReconcileResolutionAdopted     = "adopted"
+	ReconcileResolutionMarkedError = "marked_error"
+	ReconcileResolutionDeleted     = "deleted"
+)
+
+// ReconcileVM 是上游节点 VM 列表中的一台虚拟机，MemoryMB 由 maxmem 换算；Template 为 PVE 模板，不参与对账。
+type ReconcileVM struct {
+	Node     string
+	VMID     uint
+	Name     string
+	Status   string
+	CPUs     int
+	MemoryMB int
+	Template bool
+}
+
+// ReconcileInstance 是参与对账的未释放实例记录。
+type ReconcileInstance struct {
+	ID         uint64
+	InstanceNo string
+	Status     string
+	Node       string
+	VMID       uint
+	CPUCores   int
+	MemoryMB   int
+}
+
+// ReconcileFinding 是一条对账差异：孤儿只有 VM，幽灵只有实例，漂移两者都有并列出偏离的字段。
+type ReconcileFinding struct {
+	Kind     string
+	Node     string
+	VMID     uint
+	VM       *ReconcileVM
+	Instance *ReconcileInstance
+	Drift    []string
+}
+
+type vmKey struct {
+	node string
+	vmid uint
+}
+
+// Reconcile 按节点和 VMID 比对上游 VM 与本地实例，结果按节点和 VMID 排序。
+// 只有 managed 判定落在交付映射 VMID 区间内的 VM 才会报告为孤儿，节点上的基础设施 VM 不受影响；
+// 幽灵只在 scanned 中成功列出 VM 的节点上判定，列表失败不能说明 VM 已丢失。
+// 交付中和释放中的实例仍占用其 VM，但 VM 可能尚未创建或正在删除，因此不判定幽灵和漂移。
+func Reconcile(vms []ReconcileVM, instances []ReconcileInstance, scanned []string, managed func(node string, vmid uint) bool) []ReconcileFinding {
+	scannedNodes := map[string]bool{}
+	for _, node := range scanned {
+		scannedNodes[node] = true
+	}
+	byKey := map[vmKey]*ReconcileInstance{}
+	for i := range instances {
+		byKey[vmKey{instances[i].Node, instances[i].VMID}] = &instances[i]
+	}
+	seen := map[vmKey]bool{}
+	var findings []ReconcileFinding
+	for i := range vms {
+		vm := &vms[i]
+		if vm.Template {
+			continue
+		}
+		key := vmKey{vm.Node, vm.VMID}
+		seen[key] = true
+		instance, ok := byKey[key]
+		if !ok {
+			if managed(vm.Node, vm.VMID) {
+				findings = append(findings, ReconcileFinding{Kind: ReconcileKindOrphan, Node: vm.Node, VMID: vm.VMID, VM: vm})
+			}
+			continue
+		}
+		if instance.Status == StatusCreating || instance.Status == StatusReleasing {
+			continue
+		}
+		if drift := ReconcileDrift(*instance, *vm); len(drift) > 0 {
+			findings = append(findings, ReconcileFinding{Kind: ReconcileKindDrift, Node: vm.Node, VMID: vm.VMID, VM: vm, Instance: instance, Drift: drift})
+		}
+	}
+	for i := range instances {
+		instance := &instances[i]
+		if !scannedNodes[instance.Node] || seen[vmKey{instance.Node, instance.VMID}] {
+			continue
+		}
+		if instance.Status == StatusCreating || instance.Status == StatusReleasing {
+			continue
+		}
+		findings = append(findings, ReconcileFinding{Kind: ReconcileKindGhost, Node: instance.Node, VMID: instance.VMID, Instance: instance})
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Node != findings[j].Node {
+			return findings[i].Node < findings[j].Node
+		}
+		return findings[i].VMID < findings[j].VMID
+	})
+	return findings
+}
+
+// ReconcileDrift 比对实例记录与上游 VM 的电源状态和 CPU、内存规格，返回偏离的字段名；
+// 上游未返回规格时不判定规格漂移。异常实例的 VM 恢复运行或停止同样视为状态漂移，暂停实例的 VM 被开机也视为状态漂移。
+func ReconcileDrift(instance ReconcileInstance, vm ReconcileVM) []string {
+	var drift []string
+	switch instance.Status {
+	case StatusRunning, StatusStopped, StatusError:
+		if MapVMStatus(vm.Status) != instance.Status {
+			drift = append(drift, "status")
+		}
+	case StatusSuspended:
+		if MapVMStatus(vm.Status) == StatusRunning {
+			drift = append(drift, "status")
+		}
+	}
+	if vm.CPUs > 0 && vm.CPUs != instance.CPUCores {
+		drift = append(drift, "cpu_cores")
+	}
+	if vm.MemoryMB > 0 && vm.MemoryMB != instance.MemoryMB {
+		drift = append(drift, "memory_mb")
+	}
+	return drift
+}
@@ -0,0 +1,71 @@
+package instance
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// OperationRescueEnter 以救援介质引导 VM 并注入临时密码；OperationRescueExit 卸下救援介质并恢复从系统盘启动。
+	OperationRescueEnter = "rescue_enter"
+	OperationRescueExit  = "rescue_exit"
+
+	// OperationISOMount 把镜像库中的安装镜像挂载到 VM 光驱；OperationISOUnmount 弹出光驱并恢复启动顺序。
+	OperationISOMount   = "iso_mount"
+	OperationISOUnmount = "iso_unmount"
+
+	// ISOPurposeRescue 是只用于救援模式的介质；ISOPurposeInstall 是用户可自行挂载的安装镜像。
+	ISOPurposeRescue  = "rescue"
+	ISOPurposeInstall = "install"
+
+	// ISOMediaISO 以光驱方式挂载；ISOMediaDisk 以临时磁盘方式挂载，只允许用于救援介质。
+	ISOMediaISO  = "iso"
+	ISOMediaDisk = "disk"
+
+	ISOStatusActive   = "active"
+	ISOStatusInactive = "inactive"
+
+	TaskTypeRescueExit = "instance_rescue_exit"
+)
+
+var (
+	ErrISOVolumeInvalid = errors.New("invalid iso volume")
+	ErrISOMediaInvalid  = errors.New("disk media is only allowed for rescue")
+)
+
+// CanEnterRescue 允许对稳定电源状态的实例进入救援模式；上游负责关机后以救援介质重新引导。
+func CanEnterRescue(status string) bool {
+	return status == StatusRunning || status == StatusStopped
+}
+
+// CanExitRescue 允许退出救援的状态比进入更宽：暂停和异常实例也需要能恢复从系统盘启动。
+func CanExitRescue(status string) bool {
+	return status == StatusRunning || status == StatusStopped || status == StatusSuspended || status == StatusError
+}
+
+func CanMountISO(status string) bool {
+	return status == StatusRunning || status == StatusStopped
+}
+
+// BlockedInRescue 判断救援模式下是否拒绝该操作：救援系统中 guest agent 不可用，系统盘也可能被救援系统挂载，
+// 救援磁盘还可能位于节点本地存储，因此重装、重置密码、回滚、恢复、调整规格、挂载光驱和迁移都要先退出救援。
+func BlockedInRescue(action string) bool {
+	switch action {
+	case OperationReinstall, OperationResetPassword, OperationSnapshotRollback, OperationBackupRestore, OperationResize, OperationRescueEnter, OperationISOMount, OperationMigrate:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidateISO 校验镜像库条目；卷 ID 使用 PVE 存储卷格式，磁盘方式只用于救援介质。
+func ValidateISO(purpose string, media string, volume string) error {
+	storage, path, ok := strings.Cut(strings.TrimSpace(volume), ":")
+	if !ok || strings.TrimSpace(storage) == "" || strings.TrimSpace(path) == "" || strings.ContainsAny(volume, " \t\r\n") {
+		return ErrISOVolumeInvalid
+	}
+	if media == ISOMediaDisk && purpose != ISOPurposeRescue {
+		return ErrISOMediaInvalid
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package instance
+
+const (
+	// OperationSuspend 是暂停实例时关闭 VM 的操作，完成后实例保持 suspended。
+	OperationSuspend = "suspend"
+
+	// SuspendSourceExpiry 表示到期未续费暂停，续费后自动恢复；SuspendSourceAdmin 表示后台人工暂停，只能由后台解除。
+	SuspendSourceExpiry = "expiry"
+	SuspendSourceAdmin  = "admin"
+
+	// ExpirySuspendReason 是到期暂停展示给用户的原因。
+	ExpirySuspendReason = "实例已到期，续费后自动恢复"
+
+	NotificationSceneSuspended = "instance_suspended"
+)
+
+// CanSuspend 判断实例当前状态是否允许暂停；只有已交付且处于稳定电源状态的实例可以暂停。
+func CanSuspend(status string) bool {
+	return status == StatusRunning || status == StatusStopped
+}
+
+// ResumesOnRenewal 判断续费或延长服务期后是否自动解除暂停；后台人工暂停不随续费解除。
+func ResumesOnRenewal(status string, source string) bool {
+	return status == StatusSuspended && source == SuspendSourceExpiry
+}
+
+// SyncedStatus 返回同步 VM 状态后实例应处的状态；暂停中的实例不随 VM 电源状态变化。
+func SyncedStatus(current string, mapped string) string {
+	if current == StatusSuspended {
+		return StatusSuspended
+	}
+	return mapped
+}
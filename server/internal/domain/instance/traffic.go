@@ -0,0 +1,106 @@
+package instance
+
+import "time"
+
+const (
+	// TrafficDirection 决定哪些字节计入套餐流量：out 只计出方向，in 只计入方向，both 入出之和，max 取入出较大者。
+	TrafficDirectionOut  = "out"
+	TrafficDirectionIn   = "in"
+	TrafficDirectionBoth = "both"
+	TrafficDirectionMax  = "max"
+
+	// TrafficOveragePolicy 是超出套餐流量后的处理方式：throttle 把网卡限速降到配置值，suspend 关机并禁止开机，
+	// bill 按 GB 从钱包扣费，余额不足时改为限速。
+	TrafficOverageThrottle = "throttle"
+	TrafficOverageSuspend  = "suspend"
+	TrafficOverageBill     = "bill"
+
+	NotificationSceneTrafficWarning  = "instance_traffic_warning"
+	NotificationSceneTrafficExceeded = "instance_traffic_exceeded"
+
+	// TrafficWarningPercent 是发送流量预警邮件的已用比例。
+	TrafficWarningPercent = 80
+
+	BytesPerGB = 1 << 30
+)
+
+func IsKnownTrafficDirection(value string) bool {
+	switch value {
+	case TrafficDirectionOut, TrafficDirectionIn, TrafficDirectionBoth, TrafficDirectionMax:
+		return true
+	default:
+		return false
+	}
+}
+
+func IsKnownTrafficOveragePolicy(value string) bool {
+	switch value {
+	case TrafficOverageThrottle, TrafficOverageSuspend, TrafficOverageBill:
+		return true
+	default:
+		return false
+	}
+}
+
+// TrafficPeriod 返回 t 所在的计费月，按服务端时区的自然月划分，格式 2006-01。
+func TrafficPeriod(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+// TrafficCounterDelta 返回两次采样之间网卡计数器的增量。上游计数器从 VM 启动开始累计，
+// 当前值小于上次值说明 VM 重启过，此时当前值即为重启后的全部流量。
+func TrafficCounterDelta(last, current uint64) uint64 {
+	if current < last {
+		return current
+	}
+	return current - last
+}
+
+// TrafficBilledBytes 按计量方向返回计入套餐的字节数。
+func TrafficBilledBytes(inBytes, outBytes uint64, direction string) uint64 {
+	switch direction {
+	case TrafficDirectionIn:
+		return inBytes
+	case TrafficDirectionBoth:
+		return inBytes + outBytes
+	case TrafficDirectionMax:
+		return max(inBytes, outBytes)
+	default:
+		return outBytes
+	}
+}
+
+// TrafficQuotaBytes 把套餐流量 GB 换算为字节；nil 或不大于 0 表示不限流量，返回 0。
+func TrafficQuotaBytes(trafficGB *int) uint64 {
+	if trafficGB == nil || *trafficGB <= 0 {
+		return 0
+	}
+	return uint64(*trafficGB) * BytesPerGB
+}
+
+// TrafficUsedPercent 返回已用流量占配额的百分比，不限流量时返回 0。
+func TrafficUsedPercent(used, quota uint64) float64 {
+	if quota == 0 {
+		return 0
+	}
+	return float64(used) * 100 / float64(quota)
+}
+
+// TrafficOverageGB 返回超出配额的流量，不足 1 GB 的部分按 1 GB 计；未超出或不限流量时为 0。
+func TrafficOverageGB(used, quota uint64) uint64 {
+	if quota == 0 || used <= quota {
+		return 0
+	}
+	return (used - quota + BytesPerGB - 1) / BytesPerGB
+}
+
+// AllowedWhileTrafficSuspended 判断实例因流量超额被暂停时是否允许执行该操作；暂停期间只允许关机、快照、备份和弹出光驱等
+// 不会引导 VM 的操作，救援、挂载镜像、回滚、恢复和重装都会重新引导 VM，与开机一并禁止。
+func AllowedWhileTrafficSuspended(action string) bool {
+	switch action {
+	case OperationStop, OperationShutdown, OperationSnapshotCreate, OperationSnapshotDelete, OperationBackupCreate, OperationISOUnmount:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,30 @@
+package instance
+
+const (
+	// TransferStatusPending 表示所有者已发起转移，等待接收方确认。
+	TransferStatusPending   = "pending"
+	TransferStatusCompleted = "completed"
+	TransferStatusRejected  = "rejected"
+	TransferStatusCancelled = "cancelled"
+
+	NotificationSceneTransferRequested = "instance_transfer_requested"
+	NotificationSceneTransferCompleted = "instance_transfer_completed"
+	NotificationSceneTransferRejected  = "instance_transfer_rejected"
+
+	// TaskTypeCredentialReset 在转移完成后为新所有者重置 root 密码，使原所有者掌握的密码失效。
+	TaskTypeCredentialReset = "instance_credential_reset"
+)
+
+func IsKnownTransferStatus(status string) bool {
+	switch status {
+	case "", TransferStatusPending, TransferStatusCompleted, TransferStatusRejected, TransferStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanTransfer 判断实例当前状态是否允许转移所有权；只有已交付且未进入释放流程的实例可以转移。
+func CanTransfer(status string) bool {
+	return status == StatusRunning || status == StatusStopped
+}
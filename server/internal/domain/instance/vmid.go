@@ -0,0 +1,40 @@
+package instance
+
+// VMIDAllocation 是一次 VMID 分配结果；NextVMID 是映射应推进到的下一个编号，复用已释放编号时保持不变。
+type VMIDAllocation struct {
+	VMID     uint
+	NextVMID uint
+	Reused   bool
+	Skipped  []uint
+}
+
+// AllocateVMID 在映射 VMID 区间内选出未被占用的编号。used 包含上游候选节点上已存在的 VM 和未释放实例占用的编号；
+// reuse 为 true 时先按升序尝试 released 中的已释放编号，再从 next 起顺延并跳过被占用的编号。
+// 区间耗尽时返回 false。
+func AllocateVMID(start, end, next uint, released []uint, used map[uint]bool, reuse bool) (VMIDAllocation, bool) {
+	if reuse {
+		for _, vmid := range released {
+			if vmid >= start && vmid <= end && vmid < next && !used[vmid] {
+				return VMIDAllocation{VMID: vmid, NextVMID: next, Reused: true}, true
+			}
+		}
+	}
+	allocation := VMIDAllocation{NextVMID: next}
+	for vmid := max(next, start); vmid <= end; vmid++ {
+		if used[vmid] {
+			allocation.Skipped = append(allocation.Skipped, vmid)
+			continue
+		}
+		allocation.VMID, allocation.NextVMID = vmid, vmid+1
+		return allocation, true
+	}
+	return allocation, false
+}
+
+// VMIDRemaining 返回映射尚未分配过的 VMID 数量，不含可复用的已释放编号。
+func VMIDRemaining(end, next uint) int {
+	if next > end {
+		return 0
+	}
+	return int(end - next + 1)
+}
@@ -1,5 +1,7 @@
 package order
 
+import "time"
+
 const (
 	StatusPending      = "pending"
 	StatusProvisioning = "provisioning"
@@ -8,8 +10,9 @@ const (
 	StatusCancelled    = "cancelled"
 	StatusClosed       = "closed"
 
-	TypePurchase = "purchase"
-	TypeRenewal  = "renewal"
+	TypePurchase   = "purchase"
+	TypeRenewal    = "renewal"
+	TypeChangePlan = "change_plan"
 
 	PaymentStatusUnpaid          = "unpaid"
 	PaymentStatusPaid            = "paid"
@@ -44,7 +47,7 @@ func IsKnownStatus(status string) bool {
 
 func IsKnownType(orderType string) bool {
 	switch orderType {
-	case "", TypePurchase, TypeRenewal:
+	case "", TypePurchase, TypeRenewal, TypeChangePlan:
 		return true
 	default:
 		return false
@@ -65,3 +68,23 @@ func BillingCycleMonths(cycle string) (int, bool) {
 		return 0, false
 	}
 }
+
+// ProratedChange 按剩余服务期折算变更套餐的差价：升配返回需补缴金额，降配返回退还金额。
+// 单个周期长度以到期时间向前推 months 个月计算，剩余服务期超过一个周期时按比例累加。
+func ProratedChange(currentCycleCents, targetCycleCents uint64, months int, now, expiresAt time.Time) (chargeCents uint64, creditCents uint64) {
+	if months <= 0 || !expiresAt.After(now) || currentCycleCents == targetCycleCents {
+		return 0, 0
+	}
+	cycle := int64(expiresAt.Sub(expiresAt.AddDate(0, -months, 0)) / time.Second)
+	remaining := int64(expiresAt.Sub(now) / time.Second)
+	if cycle <= 0 {
+		return 0, 0
+	}
+	prorate := func(diff uint64) uint64 {
+		return (diff*uint64(remaining) + uint64(cycle)/2) / uint64(cycle)
+	}
+	if targetCycleCents > currentCycleCents {
+		return prorate(targetCycleCents - currentCycleCents), 0
+	}
+	return 0, prorate(currentCycleCents - targetCycleCents)
+}
@@ -1,6 +1,9 @@
 package order
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestRenewalConfirmationPolicy(t *testing.T) {
 	if !CanConfirmRenewal(StatusPending, TypeRenewal) {
@@ -26,3 +29,17 @@ func TestBillingCycleMonths(t *testing.T) {
 		t.Fatalf("unsupported cycle got (%d, %v), want (0, false)", got, ok)
 	}
 }
+
+func TestProratedChangeSplitsChargeAndCredit(t *testing.T) {
+	expiresAt := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	halfway := time.Date(2026, 6, 16, 0, 0, 0, 0, time.UTC)
+	if charge, credit := ProratedChange(3000, 9000, 1, halfway, expiresAt); charge != 3000 || credit != 0 {
+		t.Fatalf("upgrade halfway got charge=%d credit=%d, want 3000/0", charge, credit)
+	}
+	if charge, credit := ProratedChange(9000, 3000, 1, halfway, expiresAt); charge != 0 || credit != 3000 {
+		t.Fatalf("downgrade halfway got charge=%d credit=%d, want 0/3000", charge, credit)
+	}
+	if charge, credit := ProratedChange(3000, 9000, 1, expiresAt.Add(time.Hour), expiresAt); charge != 0 || credit != 0 {
+		t.Fatalf("expired instance got charge=%d credit=%d, want 0/0", charge, credit)
+	}
+}
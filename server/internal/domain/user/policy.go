@@ -13,6 +13,10 @@ const (
 	PasswordResetStatusActive  = "active"
 	PasswordResetStatusUsed    = "used"
 	PasswordResetStatusRevoked = "revoked"
+	// MaxSSHKeys 是单个用户可保存的 SSH 公钥数量上限。
+	MaxSSHKeys = 20
+	// MaxOrderSSHKeys 是单个订单可选择注入的 SSH 公钥数量上限。
+	MaxOrderSSHKeys = 10
 )
 
 func IsActive(status string) bool {
@@ -13,11 +13,16 @@ const (
 	EntryTypeRecharge = "recharge"
 	EntryTypePayment  = "payment"
 	EntryTypeRefund   = "refund"
+	// EntryTypePlanCredit 是变更套餐降配时按剩余服务期退还到钱包的差价。
+	EntryTypePlanCredit = "plan_credit"
+	// EntryTypeTrafficOverage 是实例超出套餐月流量后按 GB 从钱包扣除的超额流量费。
+	EntryTypeTrafficOverage = "traffic_overage"
 
 	RelatedTypeRecharge = "recharge"
 	RelatedTypePayment  = "payment"
 	RelatedTypeRefund   = "refund"
 	RelatedTypeOrder    = "order"
+	RelatedTypeInstance = "instance"
 
 	RechargeStatusPending = "pending"
 	RechargeStatusPaid    = "paid"
@@ -45,7 +50,7 @@ func IsKnownLedgerDirection(direction string) bool {
 
 func IsKnownEntryType(entryType string) bool {
 	switch entryType {
-	case EntryTypeRecharge, EntryTypePayment, EntryTypeRefund:
+	case EntryTypeRecharge, EntryTypePayment, EntryTypeRefund, EntryTypePlanCredit, EntryTypeTrafficOverage:
 		return true
 	default:
 		return false
@@ -0,0 +1,65 @@
+package mcppve
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker 是单个集群的熔断器。连续 threshold 次暂时不可用后打开，冷却期内调用直接失败；
+// 冷却结束后只放行一次试探调用，成功即关闭，失败则重新计时。threshold 为 0 时不熔断。
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	outcomeIgnored
+)
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow 判断是否可以发起调用；半开状态下放行的试探调用必须通过 done 回报结果。
+func (b *breaker) allow(now time.Time) bool {
+	if b == nil || b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// done 记录一次调用结果。调用方主动取消等无法判断上游状态的结果不计数，只释放试探名额。
+func (b *breaker) done(now time.Time, result outcome) {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch result {
+	case outcomeSuccess:
+		b.failures = 0
+	case outcomeFailure:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.openUntil = now.Add(b.cooldown)
+		}
+	}
+	b.probing = false
+}
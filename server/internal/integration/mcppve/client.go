@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,17 +12,38 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/requestcontext"
 )
 
+// Client 是某个 PVE 集群的 MCP 接口客户端。NewClient 返回默认集群的客户端，同时持有全部已登记集群，
+// 按实例或售卖地域的集群编号调用 Cluster 取得对应客户端。每个集群持有独立的熔断器。
 type Client struct {
-	baseURL    *url.URL
-	token      string
-	httpClient *http.Client
-	enabled    bool
+	baseURL     *url.URL
+	token       string
+	httpClient  *http.Client
+	timeout     time.Duration
+	readTimeout time.Duration
+	retries     int
+	backoff     time.Duration
+	breaker     *breaker
+	webhook     string
+	pollDelay   time.Duration
+	enabled     bool
+	clusterNo   string
+	name        string
+	registry    *registry
 }
 
+type registry struct {
+	order    []string
+	clusters map[string]*Client
+}
+
+// CreateVMRequest 创建 VM；DiskSize 为导入后系统盘扩容到的大小（GiB），DataDiskSize 为 0 时不创建数据盘，
+// NetworkRate 为网卡限速（MB/s，PVE rate 语义），0 表示不限速。
 type CreateVMRequest struct {
 	VMID            uint     `json:"vmid"`
 	Name            string   `json:"name"`
@@ -31,7 +53,28 @@ type CreateVMRequest struct {
 	DiskSource      string   `json:"diskSource"`
 	DiskFormat      string   `json:"diskFormat,omitempty"`
 	DiskInterface   string   `json:"diskInterface,omitempty"`
+	DiskSize        int      `json:"diskSize,omitempty"`
+	DataDiskSize    int      `json:"dataDiskSize,omitempty"`
+	DataDiskStorage string   `json:"dataDiskStorage,omitempty"`
 	Network         string   `json:"network,omitempty"`
+	NetworkRate     float64  `json:"networkRate,omitempty"`
+	CIUser          string   `json:"ciUser,omitempty"`
+	CIPassword      string   `json:"ciPassword,omitempty"`
+	SSHKeys         string   `json:"sshKeys,omitempty"`
+	IPConfig0       string   `json:"ipConfig0,omitempty"`
+	Nameserver      string   `json:"nameserver,omitempty"`
+	SearchDomain    string   `json:"searchDomain,omitempty"`
+	SnippetsStorage string   `json:"snippetsStorage,omitempty"`
+	CIPackages      []string `json:"ciPackages,omitempty"`
+	AptMirror       string   `json:"aptMirror,omitempty"`
+}
+
+type ReinstallVMRequest struct {
+	Storage         string   `json:"storage"`
+	DiskSource      string   `json:"diskSource"`
+	DiskFormat      string   `json:"diskFormat,omitempty"`
+	DiskInterface   string   `json:"diskInterface,omitempty"`
+	DiskSize        int      `json:"diskSize,omitempty"`
 	CIUser          string   `json:"ciUser,omitempty"`
 	SSHKeys         string   `json:"sshKeys,omitempty"`
 	IPConfig0       string   `json:"ipConfig0,omitempty"`
@@ -42,20 +85,169 @@ type CreateVMRequest struct {
 	AptMirror       string   `json:"aptMirror,omitempty"`
 }
 
+// ResizeVMRequest 调整 VM 的 CPU 核数、内存（MiB）和网卡限速（MB/s）；运行中 VM 是否即时生效取决于上游热插拔配置。
+type ResizeVMRequest struct {
+	Cores       int     `json:"cores"`
+	Memory      int     `json:"memory"`
+	NetworkRate float64 `json:"networkRate,omitempty"`
+}
+
+// SetVMPasswordRequest 重置 VM 内 cloud-init 用户的登录密码；上游通过 guest agent 即时生效并同步 cipassword，
+// 保证后续重装或重新生成 cloud-init 时密码一致。
+type SetVMPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// RescueVMRequest 以救援介质引导 VM：Media 为 iso 时挂载到光驱，为 disk 时把卷作为临时磁盘挂载，两者都设为首选启动设备；
+// Password 是救援系统的临时 root 密码，由上游在引导时注入，不写入 VM 的 cloud-init 配置。
+type RescueVMRequest struct {
+	Volume   string `json:"volume"`
+	Media    string `json:"media"`
+	Password string `json:"password"`
+}
+
+// MountISORequest 把 ISO 卷挂载到 VM 光驱；Boot 为 true 时同时把光驱设为首选启动设备，弹出时恢复原启动顺序。
+type MountISORequest struct {
+	Volume string `json:"volume"`
+	Boot   bool   `json:"boot,omitempty"`
+}
+
+// MigrateVMRequest 把 VM 迁移到同集群的 Target 节点。Online 为 false 时运行中的 VM 由上游关机、迁移后在目标节点重新启动；
+// 本地磁盘随迁移复制，目标节点需要有同名存储。
+type MigrateVMRequest struct {
+	Target         string `json:"target"`
+	Online         bool   `json:"online,omitempty"`
+	WithLocalDisks bool   `json:"withLocalDisks,omitempty"`
+}
+
+type CreateSnapshotRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	VMState     bool   `json:"vmstate,omitempty"`
+}
+
+type BackupVMRequest struct {
+	Storage  string `json:"storage"`
+	Mode     string `json:"mode,omitempty"`
+	Compress string `json:"compress,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// RestoreVMRequest 从备份卷恢复 VM；Force 覆盖同 VMID 的现有 VM，Unique 为恢复出的新 VM 重新生成 MAC 等唯一标识，
+// IPConfig0 非空时覆盖备份内的 cloud-init 网络配置。
+type RestoreVMRequest struct {
+	VMID      uint   `json:"vmid"`
+	Archive   string `json:"archive"`
+	Storage   string `json:"storage,omitempty"`
+	Force     bool   `json:"force,omitempty"`
+	Unique    bool   `json:"unique,omitempty"`
+	IPConfig0 string `json:"ipConfig0,omitempty"`
+}
+
 type AsyncAccepted struct {
 	Location          string
 	OperationLocation string
 	OperationID       string
 }
 
+// Node 是上游节点列表或节点详情中的一项；离线节点只返回 node 和 status，其余字段为零值。
+// CPU 为 0-1 使用率，内存单位为字节。
+type Node struct {
+	Node   string  `json:"node"`
+	Name   string  `json:"name"`
+	Status string  `json:"status"`
+	MaxCPU int     `json:"maxcpu"`
+	CPU    float64 `json:"cpu"`
+	MaxMem int64   `json:"maxmem"`
+	Mem    int64   `json:"mem"`
+}
+
+// VM 是节点 VM 列表或 VM 详情中的一项；NetIn/NetOut 为 VM 启动以来的网卡累计字节数，Template 为 1 表示模板。
 type VM struct {
-	VMID   uint   `json:"vmid"`
-	Name   string `json:"name"`
-	Status string `json:"status"`
-	CPUs   int    `json:"cpus"`
-	Mem    int64  `json:"mem"`
-	MaxMem int64  `json:"maxmem"`
-	Raw    map[string]any
+	VMID     uint   `json:"vmid"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	CPUs     int    `json:"cpus"`
+	Mem      int64  `json:"mem"`
+	MaxMem   int64  `json:"maxmem"`
+	NetIn    int64  `json:"netin"`
+	NetOut   int64  `json:"netout"`
+	Template int    `json:"template"`
+}
+
+// Storage 是集群存储列表中的一项。Node 为空表示共享存储；容量单位为字节，
+// 部分上游版本只返回 maxdisk/disk，调用方在 Total 为 0 时回退读取。
+type Storage struct {
+	Storage string `json:"storage"`
+	Name    string `json:"name"`
+	Node    string `json:"node"`
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Total   int64  `json:"total"`
+	Used    int64  `json:"used"`
+	MaxDisk int64  `json:"maxdisk"`
+	Disk    int64  `json:"disk"`
+}
+
+// Snapshot 是 VM 快照列表中的一项；SnapTime 为 Unix 秒，VMState 为 1 表示包含内存状态。
+type Snapshot struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Parent      string `json:"parent"`
+	SnapTime    int64  `json:"snaptime"`
+	VMState     int    `json:"vmstate"`
+}
+
+// VMConfig 是 VM 当前硬件配置摘要，用于核对实例实际规格；Disks 按设备键排序，第一块为系统盘。
+type VMConfig struct {
+	Cores       int      `json:"cores"`
+	Memory      int      `json:"memory"`
+	Disks       []VMDisk `json:"disks"`
+	NetworkRate float64  `json:"networkRate"`
+}
+
+// VMFirewall 是 VM 级防火墙的完整配置；写入时上游整体替换规则列表和出入站默认策略，
+// 并为 VM 网卡打开防火墙开关。Enabled 为 false 时关闭 VM 防火墙，规则列表应为空。
+type VMFirewall struct {
+	Enabled   bool           `json:"enable"`
+	PolicyIn  string         `json:"policyIn"`
+	PolicyOut string         `json:"policyOut"`
+	Rules     []FirewallRule `json:"rules"`
+}
+
+// FirewallRule 是一条防火墙规则；Type 为 in/out，Proto 为空表示全部协议，DPort 为单端口或 "起:止" 范围，
+// 入站规则的对端地址写在 Source，出站写在 Dest。
+type FirewallRule struct {
+	Type    string `json:"type"`
+	Action  string `json:"action"`
+	Proto   string `json:"proto,omitempty"`
+	DPort   string `json:"dport,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Dest    string `json:"dest,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// VMDisk 是 VM 的一块磁盘，SizeGB 单位 GiB。
+type VMDisk struct {
+	Key     string `json:"key"`
+	Storage string `json:"storage"`
+	SizeGB  int    `json:"sizeGb"`
+}
+
+// RRDPoint 是 PVE rrddata 的一个采样点，Time 为 Unix 秒。字段为采样区间平均值：CPU 为 0-1 使用率，
+// 内存为字节，磁盘和网络为字节/秒；上游在缺少数据的时间段只返回 time，对应字段为 nil。
+// 节点采样的内存字段为 memused/memtotal，VM 采样为 mem/maxmem，节点不返回磁盘读写。
+type RRDPoint struct {
+	Time      int64    `json:"time"`
+	CPU       *float64 `json:"cpu"`
+	Mem       *float64 `json:"mem"`
+	MaxMem    *float64 `json:"maxmem"`
+	MemUsed   *float64 `json:"memused"`
+	MemTotal  *float64 `json:"memtotal"`
+	DiskRead  *float64 `json:"diskread"`
+	DiskWrite *float64 `json:"diskwrite"`
+	NetIn     *float64 `json:"netin"`
+	NetOut    *float64 `json:"netout"`
 }
 
 type Operation struct {
@@ -75,42 +267,121 @@ type ErrorResponse struct {
 }
 
 func NewClient(cfg config.MCPPVEConfig) (*Client, error) {
-	base, err := url.Parse(strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/"))
-	if err != nil {
-		return nil, fmt.Errorf("解析虚拟化接口地址失败: %w", err)
+	reg := &registry{clusters: map[string]*Client{}}
+	for _, cluster := range cfg.ClusterConfigs() {
+		base, err := url.Parse(strings.TrimRight(strings.TrimSpace(cluster.BaseURL), "/"))
+		if err != nil {
+			return nil, fmt.Errorf("解析虚拟化接口地址失败（集群 %s）: %w", cluster.ClusterNo, err)
+		}
+		name := strings.TrimSpace(cluster.Name)
+		if name == "" {
+			name = cluster.ClusterNo
+		}
+		reg.order = append(reg.order, cluster.ClusterNo)
+		reg.clusters[cluster.ClusterNo] = &Client{
+			baseURL:     base,
+			token:       strings.TrimSpace(cluster.BearerToken),
+			httpClient:  &http.Client{},
+			timeout:     cluster.Timeout(),
+			readTimeout: min(cfg.ReadTimeout(), cluster.Timeout()),
+			retries:     cfg.RetryAttempts,
+			backoff:     cfg.RetryBackoff(),
+			breaker:     newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown()),
+			webhook:     strings.TrimSpace(cluster.WebhookSecret),
+			pollDelay:   cfg.WebhookFallback(),
+			enabled:     cfg.Enabled,
+			clusterNo:   cluster.ClusterNo,
+			name:        name,
+			registry:    reg,
+		}
 	}
-	return &Client{
-		baseURL:    base,
-		token:      strings.TrimSpace(cfg.BearerToken),
-		httpClient: &http.Client{Timeout: cfg.Timeout()},
-		enabled:    cfg.Enabled,
-	}, nil
+	return reg.clusters[config.DefaultMCPPVECluster], nil
 }
 
 func (c *Client) Enabled() bool {
 	return c != nil && c.enabled
 }
 
-func (c *Client) Nodes(ctx context.Context) (any, error) {
-	var out any
+// Cluster 返回指定集群的客户端；空编号表示默认集群。集群未登记时返回的客户端每次调用都报外部依赖不可用，
+// 调用方不需要单独判空。
+func (c *Client) Cluster(clusterNo string) *Client {
+	clusterNo = strings.TrimSpace(clusterNo)
+	if clusterNo == "" {
+		clusterNo = config.DefaultMCPPVECluster
+	}
+	if c != nil && c.registry != nil {
+		if cluster, ok := c.registry.clusters[clusterNo]; ok {
+			return cluster
+		}
+	}
+	return &Client{clusterNo: clusterNo, name: clusterNo}
+}
+
+// Clusters 返回全部已登记集群的客户端，默认集群排在最前。
+func (c *Client) Clusters() []*Client {
+	if c == nil || c.registry == nil {
+		return nil
+	}
+	items := make([]*Client, 0, len(c.registry.order))
+	for _, clusterNo := range c.registry.order {
+		items = append(items, c.registry.clusters[clusterNo])
+	}
+	return items
+}
+
+// HasCluster 判断集群编号是否已登记；空编号视为默认集群。
+func (c *Client) HasCluster(clusterNo string) bool {
+	return c.Cluster(clusterNo).registry != nil
+}
+
+func (c *Client) ClusterNo() string {
+	if c == nil {
+		return ""
+	}
+	return c.clusterNo
+}
+
+func (c *Client) Name() string {
+	if c == nil {
+		return ""
+	}
+	return c.name
+}
+
+func (c *Client) Nodes(ctx context.Context) ([]Node, error) {
+	var out []Node
 	err := c.doJSON(ctx, http.MethodGet, "/api/pve/nodes", nil, &out, nil)
 	return out, err
 }
 
-func (c *Client) Node(ctx context.Context, node string) (any, error) {
-	var out any
+func (c *Client) Node(ctx context.Context, node string) (Node, error) {
+	var out Node
 	err := c.doJSON(ctx, http.MethodGet, "/api/pve/nodes/"+url.PathEscape(node), nil, &out, nil)
 	return out, err
 }
 
-func (c *Client) NodeVMs(ctx context.Context, node string) (any, error) {
-	var out any
+func (c *Client) NodeVMs(ctx context.Context, node string) ([]VM, error) {
+	var out []VM
 	err := c.doJSON(ctx, http.MethodGet, "/api/pve/nodes/"+url.PathEscape(node)+"/vms", nil, &out, nil)
 	return out, err
 }
 
-func (c *Client) Storage(ctx context.Context) (any, error) {
-	var out any
+// NodeMetrics 返回节点在 timeframe（hour/day/week/month/year）内的 RRD 平均值采样。
+func (c *Client) NodeMetrics(ctx context.Context, node string, timeframe string) ([]RRDPoint, error) {
+	var out []RRDPoint
+	err := c.doJSON(ctx, http.MethodGet, "/api/pve/nodes/"+url.PathEscape(node)+"/rrddata?"+rrdQuery(timeframe), nil, &out, nil)
+	return out, err
+}
+
+// VMMetrics 返回 VM 在 timeframe（hour/day/week/month/year）内的 RRD 平均值采样。
+func (c *Client) VMMetrics(ctx context.Context, node string, vmid uint, timeframe string) ([]RRDPoint, error) {
+	var out []RRDPoint
+	err := c.doJSON(ctx, http.MethodGet, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/rrddata?"+rrdQuery(timeframe), nil, &out, nil)
+	return out, err
+}
+
+func (c *Client) Storage(ctx context.Context) ([]Storage, error) {
+	var out []Storage
 	err := c.doJSON(ctx, http.MethodGet, "/api/pve/storage", nil, &out, nil)
 	return out, err
 }
@@ -122,12 +393,27 @@ func (c *Client) CreateVM(ctx context.Context, node string, req CreateVMRequest)
 }
 
 func (c *Client) VM(ctx context.Context, node string, vmid uint) (VM, error) {
-	var raw map[string]any
-	err := c.doJSON(ctx, http.MethodGet, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10), nil, &raw, nil)
-	if err != nil {
-		return VM{}, err
-	}
-	return vmFromRaw(raw), nil
+	var out VM
+	err := c.doJSON(ctx, http.MethodGet, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10), nil, &out, nil)
+	out.Status = strings.TrimSpace(out.Status)
+	return out, err
+}
+
+func (c *Client) VMConfig(ctx context.Context, node string, vmid uint) (VMConfig, error) {
+	var out VMConfig
+	err := c.doJSON(ctx, http.MethodGet, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/config", nil, &out, nil)
+	return out, err
+}
+
+func (c *Client) VMFirewall(ctx context.Context, node string, vmid uint) (VMFirewall, error) {
+	var out VMFirewall
+	err := c.doJSON(ctx, http.MethodGet, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/firewall", nil, &out, nil)
+	return out, err
+}
+
+// SetVMFirewall 同步替换 VM 防火墙配置，返回时上游已生效。
+func (c *Client) SetVMFirewall(ctx context.Context, node string, vmid uint, req VMFirewall) error {
+	return c.doJSON(ctx, http.MethodPut, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/firewall", req, nil, nil)
 }
 
 func (c *Client) StartVM(ctx context.Context, node string, vmid uint) (AsyncAccepted, error) {
@@ -142,6 +428,119 @@ func (c *Client) StopVM(ctx context.Context, node string, vmid uint) (AsyncAccep
 	return accepted, err
 }
 
+func (c *Client) RebootVM(ctx context.Context, node string, vmid uint) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/reboot", nil, nil, &accepted)
+	return accepted, err
+}
+
+func (c *Client) ShutdownVM(ctx context.Context, node string, vmid uint) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/shutdown", nil, nil, &accepted)
+	return accepted, err
+}
+
+func (c *Client) ResetVM(ctx context.Context, node string, vmid uint) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/reset", nil, nil, &accepted)
+	return accepted, err
+}
+
+// ReinstallVM 销毁 VM 现有系统盘并按新的磁盘来源重建，VMID 和网络配置保持不变。
+func (c *Client) ReinstallVM(ctx context.Context, node string, vmid uint, req ReinstallVMRequest) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/reinstall", req, nil, &accepted)
+	return accepted, err
+}
+
+func (c *Client) ResizeVM(ctx context.Context, node string, vmid uint, req ResizeVMRequest) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/resize", req, nil, &accepted)
+	return accepted, err
+}
+
+func (c *Client) SetVMPassword(ctx context.Context, node string, vmid uint, req SetVMPasswordRequest) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/password", req, nil, &accepted)
+	return accepted, err
+}
+
+// EnterRescue 关机后以救援介质重新引导 VM，系统盘保持不变，供救援系统挂载修复。
+func (c *Client) EnterRescue(ctx context.Context, node string, vmid uint, req RescueVMRequest) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/rescue", req, nil, &accepted)
+	return accepted, err
+}
+
+// ExitRescue 卸下救援介质、恢复原启动顺序并从系统盘重新引导；VM 不在救援模式时上游直接成功。
+func (c *Client) ExitRescue(ctx context.Context, node string, vmid uint) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodDelete, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/rescue", nil, nil, &accepted)
+	return accepted, err
+}
+
+func (c *Client) MountISO(ctx context.Context, node string, vmid uint, req MountISORequest) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/cdrom", req, nil, &accepted)
+	return accepted, err
+}
+
+// UnmountISO 弹出 VM 光驱中的镜像并恢复挂载前的启动顺序。
+func (c *Client) UnmountISO(ctx context.Context, node string, vmid uint) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodDelete, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/cdrom", nil, nil, &accepted)
+	return accepted, err
+}
+
+func (c *Client) MigrateVM(ctx context.Context, node string, vmid uint, req MigrateVMRequest) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/migrate", req, nil, &accepted)
+	return accepted, err
+}
+
+func (c *Client) Snapshots(ctx context.Context, node string, vmid uint) ([]Snapshot, error) {
+	var out []Snapshot
+	err := c.doJSON(ctx, http.MethodGet, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/snapshots", nil, &out, nil)
+	return out, err
+}
+
+func (c *Client) CreateSnapshot(ctx context.Context, node string, vmid uint, req CreateSnapshotRequest) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/snapshots", req, nil, &accepted)
+	return accepted, err
+}
+
+// RollbackSnapshot 将 VM 回滚到指定快照；未保存内存状态的快照回滚后 VM 处于停止状态。
+func (c *Client) RollbackSnapshot(ctx context.Context, node string, vmid uint, name string) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/snapshots/"+url.PathEscape(name)+"/rollback", nil, nil, &accepted)
+	return accepted, err
+}
+
+func (c *Client) DeleteSnapshot(ctx context.Context, node string, vmid uint, name string) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodDelete, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/snapshots/"+url.PathEscape(name), nil, nil, &accepted)
+	return accepted, err
+}
+
+func (c *Client) BackupVM(ctx context.Context, node string, vmid uint, req BackupVMRequest) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+"/backups", req, nil, &accepted)
+	return accepted, err
+}
+
+func (c *Client) RestoreVM(ctx context.Context, node string, req RestoreVMRequest) (AsyncAccepted, error) {
+	var accepted AsyncAccepted
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/restore", req, nil, &accepted)
+	return accepted, err
+}
+
+// DeleteBackup 同步删除备份存储上的备份卷；卷 ID 含 "/"，因此放在请求体中而不是路径中。
+func (c *Client) DeleteBackup(ctx context.Context, node string, storage string, volumeID string) error {
+	input := map[string]string{"volume": volumeID}
+	return c.doJSON(ctx, http.MethodDelete, "/api/pve/nodes/"+url.PathEscape(node)+"/storage/"+url.PathEscape(storage)+"/backups", input, nil, nil)
+}
+
 func (c *Client) DeleteVM(ctx context.Context, node string, vmid uint) (AsyncAccepted, error) {
 	var accepted AsyncAccepted
 	err := c.doJSON(ctx, http.MethodDelete, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10), nil, nil, &accepted)
@@ -154,31 +553,74 @@ func (c *Client) Operation(ctx context.Context, id string) (Operation, error) {
 	return out, err
 }
 
+// doJSON 调用上游接口。查询类 GET 使用读超时并在暂时不可用时按指数退避重试，写操作只发送一次；
+// 每次尝试前检查集群熔断器，请求上下文中的请求 ID 通过 X-Request-ID 透传给上游。
 func (c *Client) doJSON(ctx context.Context, method string, relPath string, input any, output any, accepted *AsyncAccepted) error {
+	if c != nil && c.registry == nil && c.clusterNo != "" {
+		return &UnavailableError{Code: CodeClusterUnknown, Message: "虚拟化集群未登记：" + c.clusterNo}
+	}
 	if !c.Enabled() {
-		return &UnavailableError{Message: "虚拟化管理接口未启用"}
+		return &UnavailableError{Code: CodeDisabled, Message: "虚拟化管理接口未启用"}
 	}
-	var body io.Reader
+	var data []byte
 	if input != nil {
-		data, err := json.Marshal(input)
+		encoded, err := json.Marshal(input)
 		if err != nil {
 			return err
 		}
+		data = encoded
+	}
+	attempts, timeout := 1, c.timeout
+	if method == http.MethodGet {
+		attempts, timeout = 1+c.retries, c.readTimeout
+	}
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 && !sleep(ctx, c.backoff<<(attempt-1)) {
+			return err
+		}
+		if !c.breaker.allow(time.Now()) {
+			return &UnavailableError{Code: CodeCircuitOpen, Message: "虚拟化管理接口熔断中：" + c.clusterNo}
+		}
+		err = c.send(ctx, timeout, method, relPath, data, output, accepted)
+		switch {
+		case ctx.Err() != nil:
+			c.breaker.done(time.Now(), outcomeIgnored)
+			return err
+		case transient(err):
+			c.breaker.done(time.Now(), outcomeFailure)
+		default:
+			c.breaker.done(time.Now(), outcomeSuccess)
+			return err
+		}
+	}
+	return err
+}
+
+// send 发送一次请求并在单次超时内读完响应。
+func (c *Client) send(ctx context.Context, timeout time.Duration, method string, relPath string, data []byte, output any, accepted *AsyncAccepted) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var body io.Reader
+	if data != nil {
 		body = bytes.NewReader(data)
 	}
 	req, err := http.NewRequestWithContext(ctx, method, c.endpoint(relPath), body)
 	if err != nil {
 		return err
 	}
-	if input != nil {
+	if data != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 	if c.token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.token)
 	}
+	if requestID := requestcontext.RequestContextFrom(ctx).RequestID; requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return &UnavailableError{Message: "虚拟化管理接口请求失败"}
+		return transportError(err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
@@ -192,15 +634,47 @@ func (c *Client) doJSON(ctx context.Context, method string, relPath string, inpu
 	if output == nil {
 		return nil
 	}
-	return json.NewDecoder(resp.Body).Decode(output)
+	if err := json.NewDecoder(resp.Body).Decode(output); err != nil {
+		if ctx.Err() != nil {
+			return transportError(err)
+		}
+		return &UnavailableError{Code: CodeBadResponse, Message: "虚拟化管理接口响应解析失败"}
+	}
+	return nil
+}
+
+func transportError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &UnavailableError{Code: CodeTimeout, Message: "虚拟化管理接口请求超时"}
+	}
+	return &UnavailableError{Code: CodeNetwork, Message: "虚拟化管理接口请求失败"}
 }
 
+// sleep 等待重试退避时间；ctx 结束时提前返回 false。
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// endpoint 拼接接口地址；relPath 可携带已编码的查询串。
 func (c *Client) endpoint(relPath string) string {
 	copied := *c.baseURL
+	relPath, query, _ := strings.Cut(relPath, "?")
 	copied.Path = path.Join(c.baseURL.Path, relPath)
+	copied.RawQuery = query
 	return copied.String()
 }
 
+func rrdQuery(timeframe string) string {
+	return url.Values{"timeframe": {timeframe}, "cf": {"AVERAGE"}}.Encode()
+}
+
 func parseError(resp *http.Response) error {
 	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
 	var parsed ErrorResponse
@@ -217,50 +691,3 @@ func operationID(location string) string {
 	}
 	return path.Base(location)
 }
-
-func vmFromRaw(raw map[string]any) VM {
-	vm := VM{Raw: raw}
-	if value, ok := raw["vmid"].(float64); ok {
-		vm.VMID = uint(value)
-	}
-	if value, ok := raw["name"].(string); ok {
-		vm.Name = value
-	}
-	if value, ok := raw["status"].(string); ok {
-		vm.Status = strings.TrimSpace(value)
-	}
-	if value, ok := raw["cpus"].(float64); ok {
-		vm.CPUs = int(value)
-	}
-	if value, ok := raw["mem"].(float64); ok {
-		vm.Mem = int64(value)
-	}
-	if value, ok := raw["maxmem"].(float64); ok {
-		vm.MaxMem = int64(value)
-	}
-	return vm
-}
-
-type UnavailableError struct {
-	Message string
-}
-
-func (e *UnavailableError) Error() string {
-	if strings.TrimSpace(e.Message) == "" {
-		return "虚拟化管理接口不可用"
-	}
-	return e.Message
-}
-
-type UpstreamError struct {
-	StatusCode int
-	Code       string
-	Message    string
-}
-
-func (e *UpstreamError) Error() string {
-	if strings.TrimSpace(e.Message) == "" {
-		return "虚拟化管理接口返回错误"
-	}
-	return e.Message
-}
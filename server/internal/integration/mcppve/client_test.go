@@ -0,0 +1,138 @@
+package mcppve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/requestcontext"
+)
+
+func testClient(t *testing.T, baseURL string, retries int, threshold int) *Client {
+	t.Helper()
+	client, err := NewClient(config.MCPPVEConfig{Enabled: true, BaseURL: baseURL, TimeoutSeconds: 5, RetryAttempts: retries, RetryBackoffMS: 1, BreakerThreshold: threshold, BreakerCooldownSeconds: 60})
+	require.NoError(t, err)
+	return client
+}
+
+func TestNodesDecodesTypedResponseAndPropagatesRequestID(t *testing.T) {
+	var gotRequestID, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/pve/nodes", r.URL.Path)
+		gotRequestID, gotAuth = r.Header.Get("X-Request-ID"), r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`[{"node":"pve1","status":"online","maxcpu":32,"cpu":0.25,"maxmem":68719476736,"mem":17179869184},{"node":"pve2","status":"offline"}]`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(config.MCPPVEConfig{Enabled: true, BaseURL: server.URL, BearerToken: "secret", TimeoutSeconds: 5})
+	require.NoError(t, err)
+	ctx := requestcontext.WithRequestContext(context.Background(), requestcontext.RequestContext{RequestID: "req-123"})
+	nodes, err := client.Nodes(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []Node{{Node: "pve1", Status: "online", MaxCPU: 32, CPU: 0.25, MaxMem: 68719476736, Mem: 17179869184}, {Node: "pve2", Status: "offline"}}, nodes)
+	require.Equal(t, "req-123", gotRequestID)
+	require.Equal(t, "Bearer secret", gotAuth)
+}
+
+func TestGetRetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"vmid":101,"name":"vm-101","status":"running","netin":1024,"netout":4096}]`))
+	}))
+	defer server.Close()
+
+	vms, err := testClient(t, server.URL, 2, 0).NodeVMs(context.Background(), "pve1")
+	require.NoError(t, err)
+	require.Equal(t, int32(3), calls.Load())
+	require.Equal(t, []VM{{VMID: 101, Name: "vm-101", Status: "running", NetIn: 1024, NetOut: 4096}}, vms)
+}
+
+func TestGetDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":"vm_not_found","message":"VM 101 not found"}}`))
+	}))
+	defer server.Close()
+
+	_, err := testClient(t, server.URL, 2, 0).VM(context.Background(), "pve1", 101)
+	var upstream *UpstreamError
+	require.ErrorAs(t, err, &upstream)
+	require.Equal(t, http.StatusNotFound, upstream.StatusCode)
+	require.Equal(t, int32(1), calls.Load())
+	require.Equal(t, "vm_not_found", ErrorCode(err))
+	require.Equal(t, "虚拟化平台中找不到对应资源", UserMessage(err))
+}
+
+func TestWriteIsSentOnce(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := testClient(t, server.URL, 2, 0).StartVM(context.Background(), "pve1", 101)
+	require.Error(t, err)
+	require.Equal(t, int32(1), calls.Load())
+	require.Equal(t, "http_503", ErrorCode(err))
+}
+
+func TestBreakerFailsFastAfterConsecutiveFailures(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := testClient(t, server.URL, 0, 2)
+	for range 2 {
+		_, err := client.Nodes(context.Background())
+		require.Equal(t, "http_500", ErrorCode(err))
+	}
+	_, err := client.Nodes(context.Background())
+	require.Equal(t, CodeCircuitOpen, ErrorCode(err))
+	require.Equal(t, int32(2), calls.Load())
+
+	_, err = client.Cluster("hk-1").Nodes(context.Background())
+	require.Equal(t, CodeClusterUnknown, ErrorCode(err))
+}
+
+func TestBreakerHalfOpenProbeClosesOnSuccess(t *testing.T) {
+	b := newBreaker(1, time.Minute)
+	now := time.Now()
+	require.True(t, b.allow(now))
+	b.done(now, outcomeFailure)
+	require.False(t, b.allow(now.Add(30*time.Second)))
+
+	later := now.Add(time.Minute)
+	require.True(t, b.allow(later))
+	require.False(t, b.allow(later), "半开状态只放行一次试探")
+	b.done(later, outcomeSuccess)
+	require.True(t, b.allow(later))
+}
+
+func TestReadTimeoutMapsToTimeoutCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := testClient(t, server.URL, 0, 0)
+	client.readTimeout = 20 * time.Millisecond
+	_, err := client.Storage(context.Background())
+	require.Equal(t, CodeTimeout, ErrorCode(err))
+	require.Equal(t, "虚拟化管理接口响应超时，请稍后重试", UserMessage(err))
+}
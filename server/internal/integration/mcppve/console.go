@@ -0,0 +1,106 @@
+package mcppve
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// ConsoleTicket 是上游为单次控制台连接签发的短期票据；VNC 客户端以 Ticket 作为连接密码。
+type ConsoleTicket struct {
+	Ticket string `json:"ticket"`
+	Port   int    `json:"port"`
+	User   string `json:"user"`
+}
+
+// CreateConsoleTicket 申请 VNC 或串口终端票据；terminal 对应上游 termproxy，其余按 vncproxy 处理。
+func (c *Client) CreateConsoleTicket(ctx context.Context, node string, vmid uint, consoleType string) (ConsoleTicket, error) {
+	action := "/vncproxy"
+	if consoleType == "terminal" {
+		action = "/termproxy"
+	}
+	var out ConsoleTicket
+	input := map[string]bool{"websocket": true}
+	err := c.doJSON(ctx, http.MethodPost, "/api/pve/nodes/"+url.PathEscape(node)+"/vms/"+strconv.FormatUint(uint64(vmid), 10)+action, input, &out, nil)
+	return out, err
+}
+
+// DialConsole 使用票据连接上游控制台 WebSocket，返回的连接按二进制帧收发。
+func (c *Client) DialConsole(ctx context.Context, node string, vmid uint, ticket ConsoleTicket) (*websocket.Conn, error) {
+	if !c.Enabled() {
+		return nil, &UnavailableError{Code: CodeDisabled, Message: "虚拟化管理接口未启用"}
+	}
+	target, err := url.Parse(c.endpoint("/api/pve/nodes/" + url.PathEscape(node) + "/vms/" + strconv.FormatUint(uint64(vmid), 10) + "/vncwebsocket"))
+	if err != nil {
+		return nil, err
+	}
+	origin := *target
+	origin.Path, origin.RawQuery = "", ""
+	if target.Scheme == "https" {
+		target.Scheme = "wss"
+	} else {
+		target.Scheme = "ws"
+	}
+	query := url.Values{}
+	query.Set("port", strconv.Itoa(ticket.Port))
+	query.Set("vncticket", ticket.Ticket)
+	target.RawQuery = query.Encode()
+
+	config, err := websocket.NewConfig(target.String(), origin.String())
+	if err != nil {
+		return nil, err
+	}
+	config.Protocol = []string{"binary"}
+	config.Dialer = &net.Dialer{Timeout: c.timeout}
+	if c.token != "" {
+		config.Header = http.Header{"Authorization": []string{"Bearer " + c.token}}
+	}
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, &UnavailableError{Code: CodeNetwork, Message: "控制台连接失败"}
+	}
+	conn.PayloadType = websocket.BinaryFrame
+	return conn, nil
+}
+
+// ProxyConsole 在浏览器连接和上游控制台连接之间双向转发数据，任一端断开或 ctx 结束时关闭两端。
+func ProxyConsole(ctx context.Context, client io.ReadWriteCloser, upstream io.ReadWriteCloser) error {
+	var once sync.Once
+	closeBoth := func() {
+		once.Do(func() {
+			_ = client.Close()
+			_ = upstream.Close()
+		})
+	}
+	defer closeBoth()
+
+	errs := make(chan error, 2)
+	copyStream := func(dst io.Writer, src io.Reader) {
+		_, err := io.Copy(dst, src)
+		errs <- err
+		closeBoth()
+	}
+	go copyStream(upstream, client)
+	go copyStream(client, upstream)
+
+	select {
+	case <-ctx.Done():
+		closeBoth()
+		<-errs
+		<-errs
+		return ctx.Err()
+	case err := <-errs:
+		<-errs
+		if err == nil || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,112 @@
+package mcppve
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 不可用错误码。上游返回的业务错误保留上游自身的 code，见 UpstreamError。
+const (
+	CodeDisabled       = "disabled"
+	CodeClusterUnknown = "cluster_unknown"
+	CodeCircuitOpen    = "circuit_open"
+	CodeTimeout        = "timeout"
+	CodeNetwork        = "network_error"
+	CodeBadResponse    = "bad_response"
+)
+
+// UnavailableError 表示请求未得到上游的有效响应：接口未启用、集群未登记、熔断、超时、网络错误或响应无法解析。
+type UnavailableError struct {
+	Code    string
+	Message string
+}
+
+func (e *UnavailableError) Error() string {
+	if strings.TrimSpace(e.Message) == "" {
+		return "虚拟化管理接口不可用"
+	}
+	return e.Message
+}
+
+// UpstreamError 是上游返回的 4xx/5xx 错误；Code 和 Message 取自响应体 {"error":{"code","message"}}，Message 可能是英文诊断信息。
+type UpstreamError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *UpstreamError) Error() string {
+	if strings.TrimSpace(e.Message) == "" {
+		return "虚拟化管理接口返回错误"
+	}
+	return e.Message
+}
+
+// ErrorCode 返回错误的结构化编码：不可用错误为本包的 Code 常量，上游错误优先使用上游 code，缺失时为 http_<状态码>。
+// 非本包错误返回空串。
+func ErrorCode(err error) string {
+	var unavailable *UnavailableError
+	if errors.As(err, &unavailable) {
+		return unavailable.Code
+	}
+	var upstream *UpstreamError
+	if errors.As(err, &upstream) {
+		if code := strings.TrimSpace(upstream.Code); code != "" {
+			return code
+		}
+		return "http_" + strconv.Itoa(upstream.StatusCode)
+	}
+	return ""
+}
+
+// UserMessage 把错误映射为可以直接展示给用户的中文说明，不暴露上游地址和诊断信息。
+func UserMessage(err error) string {
+	var unavailable *UnavailableError
+	if errors.As(err, &unavailable) {
+		switch unavailable.Code {
+		case CodeDisabled:
+			return "虚拟化管理接口未启用"
+		case CodeClusterUnknown:
+			return "实例所在的虚拟化集群未登记"
+		case CodeCircuitOpen:
+			return "虚拟化管理接口连续失败，已暂停调用，请稍后重试"
+		case CodeTimeout:
+			return "虚拟化管理接口响应超时，请稍后重试"
+		case CodeBadResponse:
+			return "虚拟化管理接口响应异常"
+		}
+		return "虚拟化管理接口暂不可用"
+	}
+	var upstream *UpstreamError
+	if errors.As(err, &upstream) {
+		switch {
+		case upstream.StatusCode == http.StatusNotFound:
+			return "虚拟化平台中找不到对应资源"
+		case upstream.StatusCode == http.StatusConflict:
+			return "虚拟化平台资源正忙或状态冲突，请稍后重试"
+		case upstream.StatusCode == http.StatusTooManyRequests:
+			return "虚拟化管理接口请求过于频繁，请稍后重试"
+		case upstream.StatusCode == http.StatusUnauthorized || upstream.StatusCode == http.StatusForbidden:
+			return "虚拟化管理接口鉴权失败"
+		case upstream.StatusCode >= http.StatusInternalServerError:
+			return "虚拟化管理接口内部错误，请稍后重试"
+		}
+		return "虚拟化管理接口拒绝了请求"
+	}
+	return "虚拟化管理接口暂不可用"
+}
+
+// transient 判断错误是否为上游暂时不可用：超时、网络错误、429 和 5xx。查询调用据此重试，熔断器据此计数。
+func transient(err error) bool {
+	var unavailable *UnavailableError
+	if errors.As(err, &unavailable) {
+		return unavailable.Code == CodeTimeout || unavailable.Code == CodeNetwork
+	}
+	var upstream *UpstreamError
+	if errors.As(err, &upstream) {
+		return upstream.StatusCode == http.StatusTooManyRequests || upstream.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
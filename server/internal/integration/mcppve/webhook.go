@@ -0,0 +1,75 @@
+package mcppve
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 操作完成回调的签名请求头。X-MCP-Timestamp 为 Unix 秒；X-MCP-Signature 为 "sha256=" 加
+// HMAC-SHA256(webhook_secret, timestamp + "\n" + 原始请求体) 的十六进制小写编码。
+const (
+	WebhookTimestampHeader = "X-MCP-Timestamp"
+	WebhookSignatureHeader = "X-MCP-Signature"
+)
+
+// webhookTolerance 是回调时间戳允许的偏差，超出视为重放。
+const webhookTolerance = 5 * time.Minute
+
+var (
+	ErrWebhookDisabled  = errors.New("mcp webhook secret is not configured")
+	ErrWebhookSignature = errors.New("mcp webhook signature mismatch")
+	ErrWebhookExpired   = errors.New("mcp webhook timestamp outside tolerance")
+	ErrWebhookPayload   = errors.New("mcp webhook payload is invalid")
+)
+
+// WebhookEnabled 表示该集群是否配置了回调签名密钥；未配置时上游不推送操作完成事件，只能轮询。
+func (c *Client) WebhookEnabled() bool {
+	return c.Enabled() && c.webhook != ""
+}
+
+// OperationPollDelay 返回异步操作同步任务的首次轮询延迟：启用回调的集群以轮询作为兜底，推迟到回调大概率已到达之后；
+// 未启用回调或上游未返回 operation ID（回调无法定位操作）时立即轮询。
+func (c *Client) OperationPollDelay(accepted AsyncAccepted) time.Duration {
+	if !c.WebhookEnabled() || strings.TrimSpace(accepted.OperationID) == "" {
+		return 0
+	}
+	return c.pollDelay
+}
+
+// OperationEvent 校验回调签名和时间戳，返回事件中的操作结果；事件体与 GET /api/pve/operations/{id} 的响应结构一致。
+func (c *Client) OperationEvent(timestamp string, signature string, body []byte, now time.Time) (Operation, error) {
+	if !c.WebhookEnabled() {
+		return Operation{}, ErrWebhookDisabled
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return Operation{}, ErrWebhookSignature
+	}
+	signature = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	if !hmac.Equal([]byte(signature), []byte(webhookSignature(c.webhook, strings.TrimSpace(timestamp), body))) {
+		return Operation{}, ErrWebhookSignature
+	}
+	if diff := now.Sub(time.Unix(seconds, 0)); diff > webhookTolerance || diff < -webhookTolerance {
+		return Operation{}, ErrWebhookExpired
+	}
+	var event Operation
+	if err := json.Unmarshal(body, &event); err != nil || strings.TrimSpace(event.ID) == "" {
+		return Operation{}, ErrWebhookPayload
+	}
+	event.ID = strings.TrimSpace(event.ID)
+	return event, nil
+}
+
+func webhookSignature(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
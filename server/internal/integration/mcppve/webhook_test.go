@@ -0,0 +1,57 @@
+package mcppve
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+)
+
+const testWebhookSecret = "test_mcp_webhook_secret_32_bytes_long"
+
+func webhookClient(t *testing.T, secret string) *Client {
+	t.Helper()
+	client, err := NewClient(config.MCPPVEConfig{Enabled: true, BaseURL: "http://mcp.internal", TimeoutSeconds: 5, WebhookSecret: secret, WebhookFallbackSeconds: 90})
+	require.NoError(t, err)
+	return client
+}
+
+func TestOperationEventVerifiesSignature(t *testing.T) {
+	client := webhookClient(t, testWebhookSecret)
+	now := time.Unix(1760000000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"id":"op-1","status":"succeeded","resourceLocation":"/api/pve/nodes/pve1/qemu/101"}`)
+
+	event, err := client.OperationEvent(timestamp, "sha256="+webhookSignature(testWebhookSecret, timestamp, body), body, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, "op-1", event.ID)
+	require.Equal(t, "succeeded", event.Status)
+
+	_, err = client.OperationEvent(timestamp, "sha256="+webhookSignature("another_secret", timestamp, body), body, now)
+	require.ErrorIs(t, err, ErrWebhookSignature)
+
+	tampered := []byte(`{"id":"op-1","status":"failed","resourceLocation":"/api/pve/nodes/pve1/qemu/101"}`)
+	_, err = client.OperationEvent(timestamp, "sha256="+webhookSignature(testWebhookSecret, timestamp, body), tampered, now)
+	require.ErrorIs(t, err, ErrWebhookSignature)
+
+	_, err = client.OperationEvent(timestamp, "sha256="+webhookSignature(testWebhookSecret, timestamp, body), body, now.Add(10*time.Minute))
+	require.ErrorIs(t, err, ErrWebhookExpired)
+
+	empty := []byte(`{"status":"succeeded"}`)
+	_, err = client.OperationEvent(timestamp, "sha256="+webhookSignature(testWebhookSecret, timestamp, empty), empty, now)
+	require.ErrorIs(t, err, ErrWebhookPayload)
+}
+
+func TestOperationPollDelayFallsBackToImmediatePolling(t *testing.T) {
+	client := webhookClient(t, testWebhookSecret)
+	require.Equal(t, 90*time.Second, client.OperationPollDelay(AsyncAccepted{OperationID: "op-1"}))
+	require.Zero(t, client.OperationPollDelay(AsyncAccepted{}), "没有 operation ID 时回调无法定位操作")
+
+	disabled := webhookClient(t, "")
+	require.Zero(t, disabled.OperationPollDelay(AsyncAccepted{OperationID: "op-1"}))
+	_, err := disabled.OperationEvent("1760000000", "sha256=00", []byte(`{}`), time.Unix(1760000000, 0))
+	require.ErrorIs(t, err, ErrWebhookDisabled)
+}
@@ -0,0 +1,122 @@
+package rdns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+)
+
+// PowerDNS 通过 PowerDNS 权威服务器 HTTP API 的 rrset PATCH 接口维护 PTR 记录，
+// 反向解析区域需预先在 PowerDNS 中创建。
+type PowerDNS struct {
+	baseURL    *url.URL
+	apiKey     string
+	serverID   string
+	zones      []string
+	ttl        int
+	httpClient *http.Client
+}
+
+type pdnsPatch struct {
+	RRSets []pdnsRRSet `json:"rrsets"`
+}
+
+type pdnsRRSet struct {
+	Name       string       `json:"name"`
+	Type       string       `json:"type"`
+	TTL        int          `json:"ttl,omitempty"`
+	ChangeType string       `json:"changetype"`
+	Records    []pdnsRecord `json:"records"`
+}
+
+type pdnsRecord struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+// UpstreamError 是 DNS 后端返回的错误。
+type UpstreamError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *UpstreamError) Error() string {
+	if strings.TrimSpace(e.Message) == "" {
+		return fmt.Sprintf("DNS 后端返回错误（HTTP %d）", e.StatusCode)
+	}
+	return e.Message
+}
+
+func NewPowerDNS(cfg config.PowerDNSConfig) (*PowerDNS, error) {
+	base, err := url.Parse(strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("解析 PowerDNS 接口地址失败: %w", err)
+	}
+	serverID := strings.TrimSpace(cfg.ServerID)
+	if serverID == "" {
+		serverID = "localhost"
+	}
+	return &PowerDNS{
+		baseURL:    base,
+		apiKey:     strings.TrimSpace(cfg.APIKey),
+		serverID:   serverID,
+		zones:      cfg.Zones,
+		ttl:        cfg.TTL,
+		httpClient: &http.Client{Timeout: cfg.Timeout()},
+	}, nil
+}
+
+func (p *PowerDNS) SetPTR(ctx context.Context, address, hostname string) error {
+	name, err := ReverseName(address)
+	if err != nil {
+		return err
+	}
+	return p.patch(ctx, name, pdnsRRSet{Name: name, Type: "PTR", TTL: p.ttl, ChangeType: "REPLACE", Records: []pdnsRecord{{Content: canonical(hostname)}}})
+}
+
+func (p *PowerDNS) DeletePTR(ctx context.Context, address string) error {
+	name, err := ReverseName(address)
+	if err != nil {
+		return err
+	}
+	return p.patch(ctx, name, pdnsRRSet{Name: name, Type: "PTR", ChangeType: "DELETE", Records: []pdnsRecord{}})
+}
+
+func (p *PowerDNS) patch(ctx context.Context, name string, rrset pdnsRRSet) error {
+	data, err := json.Marshal(pdnsPatch{RRSets: []pdnsRRSet{rrset}})
+	if err != nil {
+		return err
+	}
+	endpoint := *p.baseURL
+	endpoint.Path = path.Join(p.baseURL.Path, "/api/v1/servers", p.serverID, "zones", ReverseZone(name, p.zones))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, endpoint.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("X-API-Key", p.apiKey)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return &UpstreamError{Message: "PowerDNS 接口请求失败"}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var parsed struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(body, &parsed)
+		return &UpstreamError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(parsed.Error)}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package rdns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+)
+
+func TestReverseNameAndZone(t *testing.T) {
+	name, err := ReverseName("192.0.2.10")
+	require.NoError(t, err)
+	require.Equal(t, "10.2.0.192.in-addr.arpa.", name)
+	require.Equal(t, "2.0.192.in-addr.arpa.", ReverseZone(name, nil))
+	require.Equal(t, "0.192.in-addr.arpa.", ReverseZone(name, []string{"0.192.in-addr.arpa", "3.0.192.in-addr.arpa."}))
+
+	name, err = ReverseName("2001:db8::1")
+	require.NoError(t, err)
+	require.Equal(t, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", name)
+	require.Equal(t, "0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", ReverseZone(name, nil))
+
+	_, err = ReverseName("example.com")
+	require.ErrorIs(t, err, ErrInvalidAddress)
+}
+
+func TestPowerDNSPatchesPTRRRSet(t *testing.T) {
+	var gotPath, gotKey string
+	var got pdnsPatch
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPatch, r.Method)
+		gotPath, gotKey = r.URL.Path, r.Header.Get("X-API-Key")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	backend, err := NewBackend(config.RDNSConfig{Enabled: true, Provider: ProviderPowerDNS, PowerDNS: config.PowerDNSConfig{BaseURL: server.URL, APIKey: "secret", ServerID: "localhost", TTL: 600}})
+	require.NoError(t, err)
+	require.NoError(t, backend.SetPTR(context.Background(), "192.0.2.10", "Mail.Example.com"))
+	require.Equal(t, "/api/v1/servers/localhost/zones/2.0.192.in-addr.arpa.", gotPath)
+	require.Equal(t, "secret", gotKey)
+	require.Len(t, got.RRSets, 1)
+	require.Equal(t, pdnsRRSet{Name: "10.2.0.192.in-addr.arpa.", Type: "PTR", TTL: 600, ChangeType: "REPLACE", Records: []pdnsRecord{{Content: "mail.example.com."}}}, got.RRSets[0])
+
+	require.NoError(t, backend.DeletePTR(context.Background(), "192.0.2.10"))
+	require.Equal(t, "DELETE", got.RRSets[0].ChangeType)
+	require.Empty(t, got.RRSets[0].Records)
+}
+
+func TestPowerDNSReturnsUpstreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"error":"Could not find domain"}`))
+	}))
+	defer server.Close()
+
+	backend, err := NewPowerDNS(config.PowerDNSConfig{BaseURL: server.URL, TTL: 600})
+	require.NoError(t, err)
+	err = backend.SetPTR(context.Background(), "192.0.2.10", "mail.example.com")
+	var upstream *UpstreamError
+	require.ErrorAs(t, err, &upstream)
+	require.Equal(t, http.StatusUnprocessableEntity, upstream.StatusCode)
+	require.Equal(t, "Could not find domain", upstream.Error())
+}
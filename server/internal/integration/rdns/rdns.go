@@ -0,0 +1,110 @@
+// Package rdns contains reverse DNS (PTR) backends and the forward resolver used
+// to confirm user-supplied hostnames.
+package rdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+)
+
+const ProviderPowerDNS = "powerdns"
+
+var (
+	ErrUnsupportedProvider = errors.New("unsupported rdns provider")
+	ErrInvalidAddress      = errors.New("invalid ip address")
+)
+
+// Resolver 查询主机名的正向解析地址；net.Resolver 满足该接口，测试可替换为固定结果。
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Backend 是 PTR 记录写入的业务边界；SetPTR 覆盖地址已有的 PTR，DeletePTR 对不存在的记录不报错。
+type Backend interface {
+	SetPTR(ctx context.Context, address, hostname string) error
+	DeletePTR(ctx context.Context, address string) error
+}
+
+// DefaultResolver 返回系统解析器。
+func DefaultResolver() Resolver {
+	return net.DefaultResolver
+}
+
+// NewBackend 按配置创建 DNS 后端；未启用 PTR 管理时返回 nil。
+func NewBackend(cfg config.RDNSConfig) (Backend, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	switch strings.TrimSpace(cfg.Provider) {
+	case ProviderPowerDNS:
+		backend, err := NewPowerDNS(cfg.PowerDNS)
+		if err != nil {
+			return nil, err
+		}
+		return backend, nil
+	default:
+		return nil, ErrUnsupportedProvider
+	}
+}
+
+// ReverseName 返回地址的反向解析记录名（带末尾的点），IPv4 使用 in-addr.arpa，IPv6 使用 ip6.arpa 半字节格式。
+func ReverseName(address string) (string, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(address))
+	if err != nil {
+		return "", ErrInvalidAddress
+	}
+	addr = addr.Unmap()
+	if addr.Is4() {
+		octets := addr.As4()
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", octets[3], octets[2], octets[1], octets[0]), nil
+	}
+	const hexDigits = "0123456789abcdef"
+	bytes := addr.As16()
+	var b strings.Builder
+	for i := len(bytes) - 1; i >= 0; i-- {
+		b.WriteByte(hexDigits[bytes[i]&0x0f])
+		b.WriteByte('.')
+		b.WriteByte(hexDigits[bytes[i]>>4])
+		b.WriteByte('.')
+	}
+	b.WriteString("ip6.arpa.")
+	return b.String(), nil
+}
+
+// ReverseZone 返回记录名所在的反向解析区域：优先取配置中最长的匹配区域，
+// 否则 IPv4 按 /24、IPv6 按 /64 推导。
+func ReverseZone(name string, zones []string) string {
+	best := ""
+	for _, zone := range zones {
+		zone = canonical(zone)
+		if zone != "" && (name == zone || strings.HasSuffix(name, "."+zone)) && len(zone) > len(best) {
+			best = zone
+		}
+	}
+	if best != "" {
+		return best
+	}
+	labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+	drop := 1
+	if strings.HasSuffix(name, ".ip6.arpa.") {
+		drop = 16
+	}
+	if len(labels) <= drop {
+		return name
+	}
+	return strings.Join(labels[drop:], ".") + "."
+}
+
+func canonical(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+	return strings.TrimSuffix(name, ".") + "."
+}
@@ -27,6 +27,14 @@ type Config struct {
 	Log               LogConfig               `yaml:"log"`
 	Storage           StorageConfig           `yaml:"storage"`
 	MCPPVE            MCPPVEConfig            `yaml:"mcp_pve"`
+	Backup            BackupConfig            `yaml:"backup"`
+	Console           ConsoleConfig           `yaml:"console"`
+	Metrics           MetricsConfig           `yaml:"metrics"`
+	Credential        CredentialConfig        `yaml:"credential"`
+	Placement         PlacementConfig         `yaml:"placement"`
+	Traffic           TrafficConfig           `yaml:"traffic"`
+	RDNS              RDNSConfig              `yaml:"rdns"`
+	Rescue            RescueConfig            `yaml:"rescue"`
 }
 
 /**
@@ -80,17 +88,24 @@ type JWTConfig struct {
 	AdminExpireMinutes int    `yaml:"admin_expire_minutes"`
 }
 
+/**
+ * WorkerConfig 表示异步任务 Worker 的轮询参数。
+ * ReconcileIntervalSeconds 为周期对账虚拟化平台 VM 与实例记录的间隔，0 表示只允许管理员手动对账。
+ */
 type WorkerConfig struct {
-	Enabled             bool   `yaml:"enabled"`
-	ID                  string `yaml:"id"`
-	PollIntervalSeconds int    `yaml:"poll_interval_seconds"`
-	LockTTLSeconds      int    `yaml:"lock_ttl_seconds"`
-	BatchSize           int    `yaml:"batch_size"`
+	Enabled                  bool   `yaml:"enabled"`
+	ID                       string `yaml:"id"`
+	PollIntervalSeconds      int    `yaml:"poll_interval_seconds"`
+	LockTTLSeconds           int    `yaml:"lock_ttl_seconds"`
+	BatchSize                int    `yaml:"batch_size"`
+	ReconcileIntervalSeconds int    `yaml:"reconcile_interval_seconds"`
 }
 
 type InstanceLifecycleConfig struct {
-	ExpireNoticeBeforeSeconds int  `yaml:"expire_notice_before_seconds"`
+	ExpireNoticeBeforeSeconds int `yaml:"expire_notice_before_seconds"`
+	// ExpireReleaseAfterSeconds 是到期暂停后的宽限期，宽限期内续费自动恢复，期满后才释放实例。
 	ExpireReleaseAfterSeconds int  `yaml:"expire_release_after_seconds"`
+	AutoSuspendEnabled        bool `yaml:"auto_suspend_enabled"`
 	AutoReleaseEnabled        bool `yaml:"auto_release_enabled"`
 }
 
@@ -142,14 +157,142 @@ type StorageConfig struct {
 
 /**
  * MCPPVEConfig 表示 MCP PVE client API 配置。
+ * 顶层地址是编号为 default 的默认集群；Clusters 登记其余 PVE 集群，售卖地域绑定集群编号后实例调用按集群路由。
+ * 查询类 GET 调用使用 ReadTimeoutSeconds 作为单次超时，并在网络错误、超时、429 和 5xx 时按指数退避重试 RetryAttempts 次；
+ * 写操作不重试，避免重复下发。每个集群独立熔断：连续 BreakerThreshold 次不可用后在冷却期内直接失败，BreakerThreshold 为 0 时不熔断。
+ * 集群配置了 WebhookSecret 时由上游推送操作完成事件，操作同步任务推迟 WebhookFallbackSeconds 后才开始轮询兜底。
  */
 type MCPPVEConfig struct {
-	Enabled        bool   `yaml:"enabled"`
+	Enabled                bool                  `yaml:"enabled"`
+	Name                   string                `yaml:"name"`
+	BaseURL                string                `yaml:"base_url"`
+	BearerToken            string                `yaml:"bearer_token"`
+	TimeoutSeconds         int                   `yaml:"timeout_seconds"`
+	ReadTimeoutSeconds     int                   `yaml:"read_timeout_seconds"`
+	RetryAttempts          int                   `yaml:"retry_attempts"`
+	RetryBackoffMS         int                   `yaml:"retry_backoff_ms"`
+	BreakerThreshold       int                   `yaml:"breaker_threshold"`
+	BreakerCooldownSeconds int                   `yaml:"breaker_cooldown_seconds"`
+	WebhookSecret          string                `yaml:"webhook_secret"`
+	WebhookFallbackSeconds int                   `yaml:"webhook_fallback_seconds"`
+	Clusters               []MCPPVEClusterConfig `yaml:"clusters"`
+}
+
+/**
+ * MCPPVEClusterConfig 表示一个附加 PVE 集群的 MCP 接口；TimeoutSeconds 为 0 时沿用顶层超时。
+ * WebhookSecret 与 BearerToken 一样按集群单独配置，不继承顶层。
+ */
+type MCPPVEClusterConfig struct {
+	ClusterNo      string `yaml:"cluster_no"`
+	Name           string `yaml:"name"`
 	BaseURL        string `yaml:"base_url"`
 	BearerToken    string `yaml:"bearer_token"`
+	WebhookSecret  string `yaml:"webhook_secret"`
 	TimeoutSeconds int    `yaml:"timeout_seconds"`
 }
 
+// DefaultMCPPVECluster 是顶层 mcp_pve 地址对应的集群编号，未绑定集群的售卖地域和存量实例都归属该集群。
+const DefaultMCPPVECluster = "default"
+
+/**
+ * BackupConfig 表示实例备份写入的 PVE 备份存储和手动备份上限。
+ */
+type BackupConfig struct {
+	Storage     string `yaml:"storage"`
+	Mode        string `yaml:"mode"`
+	Compress    string `yaml:"compress"`
+	ManualLimit int    `yaml:"manual_limit"`
+}
+
+/**
+ * ConsoleConfig 表示实例控制台会话的连接窗口和单次会话时长上限。
+ */
+type ConsoleConfig struct {
+	Enabled            bool `yaml:"enabled"`
+	ConnectTTLSeconds  int  `yaml:"connect_ttl_seconds"`
+	MaxDurationSeconds int  `yaml:"max_duration_seconds"`
+}
+
+/**
+ * RescueConfig 表示实例救援模式配置。TimeoutSeconds 是进入救援后自动退出的时长，
+ * 用户未主动退出时由 Worker 到时恢复从系统盘启动。救援临时密码复用 credential 的加密密钥和密码长度。
+ */
+type RescueConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	TimeoutSeconds int  `yaml:"timeout_seconds"`
+}
+
+/**
+ * MetricsConfig 表示实例与节点性能监控的缓存时长和单次返回的最大采样点数。
+ * 采样点多于 MaxPoints 时按时间顺序分桶取平均。
+ */
+type MetricsConfig struct {
+	CacheTTLSeconds int `yaml:"cache_ttl_seconds"`
+	MaxPoints       int `yaml:"max_points"`
+}
+
+/**
+ * CredentialConfig 表示实例登录凭据的加密存储配置。
+ * EncryptionKey 为空时不生成实例 root 密码，也不开放重置密码。
+ */
+type CredentialConfig struct {
+	EncryptionKey      string `yaml:"encryption_key"`
+	RootPasswordLength int    `yaml:"root_password_length"`
+}
+
+/**
+ * PlacementConfig 表示多节点交付调度的超分比例和用户反亲和策略。
+ * 超分比例按节点物理容量乘以比例计算可分配上限，1 表示不超分；容量统计和套餐自动售罄使用同一组比例。
+ * CapacitySyncIntervalSeconds 为 Worker 同步套餐售罄状态的间隔，0 表示不自动售罄。
+ * VMIDLowWatermark 为交付映射剩余未分配 VMID 的告警水位，0 表示只在耗尽时告警。
+ */
+type PlacementConfig struct {
+	CPUOvercommitRatio          float64 `yaml:"cpu_overcommit_ratio"`
+	MemoryOvercommitRatio       float64 `yaml:"memory_overcommit_ratio"`
+	StorageOvercommitRatio      float64 `yaml:"storage_overcommit_ratio"`
+	UserAntiAffinity            bool    `yaml:"user_anti_affinity"`
+	CapacitySyncIntervalSeconds int     `yaml:"capacity_sync_interval_seconds"`
+	VMIDLowWatermark            int     `yaml:"vmid_low_watermark"`
+}
+
+/**
+ * TrafficConfig 表示实例月流量计量和套餐流量超额处理配置。
+ * MeterIntervalSeconds 为 Worker 采样网卡计数器的间隔，0 表示关闭计量；Direction 为计入套餐的方向（out/in/both/max）。
+ * OveragePolicy 为超额处理方式（throttle/suspend/bill）；bill 按 OveragePriceCentsPerGB 从钱包扣费，余额不足时按 ThrottleMbps 限速。
+ */
+type TrafficConfig struct {
+	MeterIntervalSeconds   int    `yaml:"meter_interval_seconds"`
+	Direction              string `yaml:"direction"`
+	OveragePolicy          string `yaml:"overage_policy"`
+	ThrottleMbps           int    `yaml:"throttle_mbps"`
+	OveragePriceCentsPerGB uint64 `yaml:"overage_price_cents_per_gb"`
+}
+
+/**
+ * RDNSConfig 表示实例 IP 反向解析（PTR）记录的管理配置。
+ * 用户提交的主机名必须正向解析到对应 IP；AutoApply 为 false 时记录进入后台人工审批队列，审批通过后再写入 DNS。
+ * Provider 当前仅支持 powerdns。
+ */
+type RDNSConfig struct {
+	Enabled   bool           `yaml:"enabled"`
+	AutoApply bool           `yaml:"auto_apply"`
+	Provider  string         `yaml:"provider"`
+	PowerDNS  PowerDNSConfig `yaml:"powerdns"`
+}
+
+/**
+ * PowerDNSConfig 表示 PowerDNS HTTP API 配置。
+ * Zones 为托管的反向解析区域；为空或未匹配时按 IPv4 /24、IPv6 /64 推导区域名。
+ */
+type PowerDNSConfig struct {
+	BaseURL        string   `yaml:"base_url"`
+	APIKey         string   `yaml:"api_key"`
+	ServerID       string   `yaml:"server_id"`
+	Zones          []string `yaml:"zones"`
+	TTL            int      `yaml:"ttl"`
+	TimeoutSeconds int      `yaml:"timeout_seconds"`
+}
+
 /**
  * LoadConfig 读取并校验 YAML 配置文件。
  *
@@ -218,14 +361,16 @@ func defaultConfig() *Config {
 			AdminExpireMinutes: 480,
 		},
 		Worker: WorkerConfig{
-			ID:                  "worker-local-1",
-			PollIntervalSeconds: 5,
-			LockTTLSeconds:      120,
-			BatchSize:           20,
+			ID:                       "worker-local-1",
+			PollIntervalSeconds:      5,
+			LockTTLSeconds:           120,
+			BatchSize:                20,
+			ReconcileIntervalSeconds: 3600,
 		},
 		InstanceLifecycle: InstanceLifecycleConfig{
 			ExpireNoticeBeforeSeconds: 86400,
 			ExpireReleaseAfterSeconds: 3600,
+			AutoSuspendEnabled:        true,
 			AutoReleaseEnabled:        false,
 		},
 		Notification: NotificationConfig{
@@ -257,9 +402,61 @@ func defaultConfig() *Config {
 			},
 		},
 		MCPPVE: MCPPVEConfig{
-			Enabled:        false,
-			BaseURL:        "http://127.0.0.1:8081",
-			TimeoutSeconds: 15,
+			Enabled:                false,
+			BaseURL:                "http://127.0.0.1:8081",
+			TimeoutSeconds:         15,
+			ReadTimeoutSeconds:     10,
+			RetryAttempts:          2,
+			RetryBackoffMS:         200,
+			BreakerThreshold:       5,
+			BreakerCooldownSeconds: 30,
+			WebhookFallbackSeconds: 120,
+		},
+		Backup: BackupConfig{
+			Mode:        "snapshot",
+			Compress:    "zstd",
+			ManualLimit: 3,
+		},
+		Console: ConsoleConfig{
+			Enabled:            true,
+			ConnectTTLSeconds:  60,
+			MaxDurationSeconds: 3600,
+		},
+		Rescue: RescueConfig{
+			Enabled:        true,
+			TimeoutSeconds: 14400,
+		},
+		Metrics: MetricsConfig{
+			CacheTTLSeconds: 60,
+			MaxPoints:       120,
+		},
+		Credential: CredentialConfig{
+			RootPasswordLength: 16,
+		},
+		Placement: PlacementConfig{
+			CPUOvercommitRatio:          4,
+			MemoryOvercommitRatio:       1,
+			StorageOvercommitRatio:      1,
+			UserAntiAffinity:            true,
+			CapacitySyncIntervalSeconds: 300,
+			VMIDLowWatermark:            20,
+		},
+		Traffic: TrafficConfig{
+			MeterIntervalSeconds:   300,
+			Direction:              "out",
+			OveragePolicy:          "throttle",
+			ThrottleMbps:           1,
+			OveragePriceCentsPerGB: 100,
+		},
+		RDNS: RDNSConfig{
+			Enabled:   false,
+			AutoApply: true,
+			Provider:  "powerdns",
+			PowerDNS: PowerDNSConfig{
+				ServerID:       "localhost",
+				TTL:            3600,
+				TimeoutSeconds: 10,
+			},
 		},
 	}
 }
@@ -345,6 +542,50 @@ func (cfg *Config) Validate() error {
 		if cfg.MCPPVE.TimeoutSeconds <= 0 {
 			return fmt.Errorf("mcp_pve.timeout_seconds 必须大于 0")
 		}
+		if cfg.MCPPVE.ReadTimeoutSeconds < 0 {
+			return fmt.Errorf("mcp_pve.read_timeout_seconds 不能小于 0")
+		}
+		if cfg.MCPPVE.RetryAttempts < 0 || cfg.MCPPVE.RetryAttempts > 5 {
+			return fmt.Errorf("mcp_pve.retry_attempts 必须在 0 到 5 之间")
+		}
+		if cfg.MCPPVE.RetryBackoffMS < 0 {
+			return fmt.Errorf("mcp_pve.retry_backoff_ms 不能小于 0")
+		}
+		if cfg.MCPPVE.BreakerThreshold < 0 {
+			return fmt.Errorf("mcp_pve.breaker_threshold 不能小于 0")
+		}
+		if cfg.MCPPVE.BreakerThreshold > 0 && cfg.MCPPVE.BreakerCooldownSeconds <= 0 {
+			return fmt.Errorf("mcp_pve.breaker_cooldown_seconds 必须大于 0")
+		}
+		if secret := strings.TrimSpace(cfg.MCPPVE.WebhookSecret); secret != "" && len(secret) < 32 {
+			return fmt.Errorf("mcp_pve.webhook_secret 长度不能少于 32 个字符")
+		}
+		if cfg.MCPPVE.WebhookFallbackSeconds < 0 {
+			return fmt.Errorf("mcp_pve.webhook_fallback_seconds 不能小于 0")
+		}
+		seen := map[string]bool{DefaultMCPPVECluster: true}
+		for i, cluster := range cfg.MCPPVE.Clusters {
+			clusterNo := strings.TrimSpace(cluster.ClusterNo)
+			if clusterNo == "" {
+				return fmt.Errorf("mcp_pve.clusters[%d].cluster_no 不能为空", i)
+			}
+			if len(clusterNo) > 32 {
+				return fmt.Errorf("mcp_pve.clusters[%d].cluster_no 不能超过 32 个字符", i)
+			}
+			if seen[clusterNo] {
+				return fmt.Errorf("mcp_pve.clusters[%d].cluster_no 重复: %s", i, clusterNo)
+			}
+			seen[clusterNo] = true
+			if strings.TrimSpace(cluster.BaseURL) == "" {
+				return fmt.Errorf("mcp_pve.clusters[%d].base_url 不能为空", i)
+			}
+			if cluster.TimeoutSeconds < 0 {
+				return fmt.Errorf("mcp_pve.clusters[%d].timeout_seconds 不能小于 0", i)
+			}
+			if secret := strings.TrimSpace(cluster.WebhookSecret); secret != "" && len(secret) < 32 {
+				return fmt.Errorf("mcp_pve.clusters[%d].webhook_secret 长度不能少于 32 个字符", i)
+			}
+		}
 	}
 	if cfg.Worker.Enabled {
 		if strings.TrimSpace(cfg.Worker.ID) == "" {
@@ -359,6 +600,82 @@ func (cfg *Config) Validate() error {
 		if cfg.Worker.BatchSize <= 0 {
 			return fmt.Errorf("worker.batch_size 必须大于 0")
 		}
+		if cfg.Worker.ReconcileIntervalSeconds < 0 {
+			return fmt.Errorf("worker.reconcile_interval_seconds 不能小于 0")
+		}
+	}
+	switch cfg.Backup.Mode {
+	case "snapshot", "suspend", "stop":
+	default:
+		return fmt.Errorf("backup.mode 仅支持 snapshot、suspend 或 stop")
+	}
+	if cfg.Backup.ManualLimit < 0 {
+		return fmt.Errorf("backup.manual_limit 不能小于 0")
+	}
+	if cfg.Console.ConnectTTLSeconds <= 0 {
+		return fmt.Errorf("console.connect_ttl_seconds 必须大于 0")
+	}
+	if cfg.Console.MaxDurationSeconds <= 0 {
+		return fmt.Errorf("console.max_duration_seconds 必须大于 0")
+	}
+	if cfg.Rescue.TimeoutSeconds <= 0 {
+		return fmt.Errorf("rescue.timeout_seconds 必须大于 0")
+	}
+	if cfg.Metrics.CacheTTLSeconds < 0 {
+		return fmt.Errorf("metrics.cache_ttl_seconds 不能小于 0")
+	}
+	if cfg.Metrics.MaxPoints < 2 {
+		return fmt.Errorf("metrics.max_points 不能小于 2")
+	}
+	if strings.TrimSpace(cfg.Credential.EncryptionKey) != "" {
+		if err := validateJWTSecret("credential.encryption_key", cfg.Credential.EncryptionKey); err != nil {
+			return err
+		}
+	}
+	if cfg.Credential.RootPasswordLength < 12 || cfg.Credential.RootPasswordLength > 64 {
+		return fmt.Errorf("credential.root_password_length 必须在 12 到 64 之间")
+	}
+	if cfg.Placement.CPUOvercommitRatio <= 0 || cfg.Placement.MemoryOvercommitRatio <= 0 || cfg.Placement.StorageOvercommitRatio <= 0 {
+		return fmt.Errorf("placement 超分比例必须大于 0")
+	}
+	if cfg.Placement.CapacitySyncIntervalSeconds < 0 {
+		return fmt.Errorf("placement.capacity_sync_interval_seconds 不能小于 0")
+	}
+	if cfg.Placement.VMIDLowWatermark < 0 {
+		return fmt.Errorf("placement.vmid_low_watermark 不能小于 0")
+	}
+	if cfg.Traffic.MeterIntervalSeconds < 0 {
+		return fmt.Errorf("traffic.meter_interval_seconds 不能小于 0")
+	}
+	switch cfg.Traffic.Direction {
+	case "out", "in", "both", "max":
+	default:
+		return fmt.Errorf("traffic.direction 只能是 out、in、both 或 max")
+	}
+	switch cfg.Traffic.OveragePolicy {
+	case "throttle", "suspend", "bill":
+	default:
+		return fmt.Errorf("traffic.overage_policy 只能是 throttle、suspend 或 bill")
+	}
+	if cfg.Traffic.ThrottleMbps <= 0 {
+		return fmt.Errorf("traffic.throttle_mbps 必须大于 0")
+	}
+	if cfg.RDNS.Enabled {
+		if cfg.RDNS.Provider != "powerdns" {
+			return fmt.Errorf("rdns.provider 当前仅支持 powerdns")
+		}
+		if strings.TrimSpace(cfg.RDNS.PowerDNS.BaseURL) == "" {
+			return fmt.Errorf("rdns.powerdns.base_url 不能为空")
+		}
+		if strings.TrimSpace(cfg.RDNS.PowerDNS.ServerID) == "" {
+			return fmt.Errorf("rdns.powerdns.server_id 不能为空")
+		}
+		if cfg.RDNS.PowerDNS.TTL <= 0 {
+			return fmt.Errorf("rdns.powerdns.ttl 必须大于 0")
+		}
+		if cfg.RDNS.PowerDNS.TimeoutSeconds <= 0 {
+			return fmt.Errorf("rdns.powerdns.timeout_seconds 必须大于 0")
+		}
 	}
 	if cfg.InstanceLifecycle.ExpireNoticeBeforeSeconds <= 0 {
 		return fmt.Errorf("instance_lifecycle.expire_notice_before_seconds 必须大于 0")
@@ -376,6 +693,87 @@ func (cfg MCPPVEConfig) Timeout() time.Duration {
 	return time.Duration(cfg.TimeoutSeconds) * time.Second
 }
 
+// ReadTimeout 返回查询类调用的单次超时；未配置时沿用 timeout_seconds。
+func (cfg MCPPVEConfig) ReadTimeout() time.Duration {
+	if cfg.ReadTimeoutSeconds <= 0 {
+		return cfg.Timeout()
+	}
+	return time.Duration(cfg.ReadTimeoutSeconds) * time.Second
+}
+
+func (cfg MCPPVEConfig) RetryBackoff() time.Duration {
+	return time.Duration(cfg.RetryBackoffMS) * time.Millisecond
+}
+
+func (cfg MCPPVEConfig) BreakerCooldown() time.Duration {
+	return time.Duration(cfg.BreakerCooldownSeconds) * time.Second
+}
+
+func (cfg MCPPVEConfig) WebhookFallback() time.Duration {
+	return time.Duration(cfg.WebhookFallbackSeconds) * time.Second
+}
+
+// ClusterConfigs 返回包括默认集群在内的全部集群配置，默认集群排在最前，其余按配置顺序。
+func (cfg MCPPVEConfig) ClusterConfigs() []MCPPVEClusterConfig {
+	items := make([]MCPPVEClusterConfig, 0, len(cfg.Clusters)+1)
+	items = append(items, MCPPVEClusterConfig{ClusterNo: DefaultMCPPVECluster, Name: cfg.Name, BaseURL: cfg.BaseURL, BearerToken: cfg.BearerToken, WebhookSecret: cfg.WebhookSecret, TimeoutSeconds: cfg.TimeoutSeconds})
+	for _, cluster := range cfg.Clusters {
+		cluster.ClusterNo = strings.TrimSpace(cluster.ClusterNo)
+		if cluster.TimeoutSeconds <= 0 {
+			cluster.TimeoutSeconds = cfg.TimeoutSeconds
+		}
+		items = append(items, cluster)
+	}
+	return items
+}
+
+// HasCluster 判断集群编号是否已登记；空编号视为默认集群。
+func (cfg MCPPVEConfig) HasCluster(clusterNo string) bool {
+	clusterNo = strings.TrimSpace(clusterNo)
+	if clusterNo == "" || clusterNo == DefaultMCPPVECluster {
+		return true
+	}
+	for _, cluster := range cfg.Clusters {
+		if strings.TrimSpace(cluster.ClusterNo) == clusterNo {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg MCPPVEClusterConfig) Timeout() time.Duration {
+	return MCPPVEConfig{TimeoutSeconds: cfg.TimeoutSeconds}.Timeout()
+}
+
+// Enabled 表示是否已配置备份存储；未配置时备份接口返回冲突错误，不调用上游。
+func (cfg BackupConfig) Enabled() bool {
+	return strings.TrimSpace(cfg.Storage) != ""
+}
+
+func (cfg PowerDNSConfig) Timeout() time.Duration {
+	if cfg.TimeoutSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(cfg.TimeoutSeconds) * time.Second
+}
+
+func (cfg ConsoleConfig) ConnectTTL() time.Duration {
+	return time.Duration(cfg.ConnectTTLSeconds) * time.Second
+}
+
+func (cfg ConsoleConfig) MaxDuration() time.Duration {
+	return time.Duration(cfg.MaxDurationSeconds) * time.Second
+}
+
+func (cfg RescueConfig) Timeout() time.Duration {
+	return time.Duration(cfg.TimeoutSeconds) * time.Second
+}
+
+func (cfg MetricsConfig) CacheTTL() time.Duration {
+	return time.Duration(cfg.CacheTTLSeconds) * time.Second
+}
+
+// validateJWTSecret 校验签名或加密密钥的长度和弱口令，JWT 与凭据加密密钥共用同一规则。
 func validateJWTSecret(name string, value string) error {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
@@ -28,27 +28,30 @@ func (Product) TableName() string {
  * ProductPlan 映射 product_plans 服务器套餐表。
  */
 type ProductPlan struct {
-	ID             uint64    `gorm:"column:id;primaryKey"`
-	PlanNo         string    `gorm:"column:plan_no"`
-	ProductID      uint64    `gorm:"column:product_id"`
-	Code           string    `gorm:"column:code"`
-	Name           string    `gorm:"column:name"`
-	Summary        *string   `gorm:"column:summary"`
-	CPUCores       int       `gorm:"column:cpu_cores"`
-	MemoryMB       int       `gorm:"column:memory_mb"`
-	SystemDiskGB   int       `gorm:"column:system_disk_gb"`
-	DataDiskGB     int       `gorm:"column:data_disk_gb"`
-	BandwidthMbps  int       `gorm:"column:bandwidth_mbps"`
-	TrafficGB      *int      `gorm:"column:traffic_gb"`
-	PublicIPCount  int       `gorm:"column:public_ip_count"`
-	Virtualization string    `gorm:"column:virtualization"`
-	Architecture   string    `gorm:"column:architecture"`
-	IsFeatured     bool      `gorm:"column:is_featured"`
-	Status         string    `gorm:"column:status"`
-	Visible        bool      `gorm:"column:visible"`
-	SortOrder      int       `gorm:"column:sort_order"`
-	CreatedAt      time.Time `gorm:"column:created_at"`
-	UpdatedAt      time.Time `gorm:"column:updated_at"`
+	ID             uint64  `gorm:"column:id;primaryKey"`
+	PlanNo         string  `gorm:"column:plan_no"`
+	ProductID      uint64  `gorm:"column:product_id"`
+	Code           string  `gorm:"column:code"`
+	Name           string  `gorm:"column:name"`
+	Summary        *string `gorm:"column:summary"`
+	CPUCores       int     `gorm:"column:cpu_cores"`
+	MemoryMB       int     `gorm:"column:memory_mb"`
+	SystemDiskGB   int     `gorm:"column:system_disk_gb"`
+	DataDiskGB     int     `gorm:"column:data_disk_gb"`
+	BandwidthMbps  int     `gorm:"column:bandwidth_mbps"`
+	TrafficGB      *int    `gorm:"column:traffic_gb"`
+	PublicIPCount  int     `gorm:"column:public_ip_count"`
+	SnapshotQuota  int     `gorm:"column:snapshot_quota"`
+	Virtualization string  `gorm:"column:virtualization"`
+	Architecture   string  `gorm:"column:architecture"`
+	IsFeatured     bool    `gorm:"column:is_featured"`
+	Status         string  `gorm:"column:status"`
+	// CapacitySoldOutAt 非空表示当前售罄由容量同步自动设置，容量恢复后会自动恢复在售。
+	CapacitySoldOutAt *time.Time `gorm:"column:capacity_sold_out_at"`
+	Visible           bool       `gorm:"column:visible"`
+	SortOrder         int        `gorm:"column:sort_order"`
+	CreatedAt         time.Time  `gorm:"column:created_at"`
+	UpdatedAt         time.Time  `gorm:"column:updated_at"`
 }
 
 func (ProductPlan) TableName() string {
@@ -76,11 +79,12 @@ func (PlanPrice) TableName() string {
 }
 
 /**
- * SalesRegion 映射 sales_regions 销售地域表。
+ * SalesRegion 映射 sales_regions 销售地域表；ClusterNo 是地域绑定的 PVE 集群，地域下的交付和实例操作都路由到该集群。
  */
 type SalesRegion struct {
 	ID        uint64    `gorm:"column:id;primaryKey"`
 	RegionNo  string    `gorm:"column:region_no"`
+	ClusterNo string    `gorm:"column:cluster_no"`
 	Code      string    `gorm:"column:code"`
 	Name      string    `gorm:"column:name"`
 	Country   *string   `gorm:"column:country"`
@@ -246,8 +246,9 @@ func (r *Repository) UpdateProductStatus(ctx context.Context, db *gorm.DB, id ui
 	return r.queryDB(db).WithContext(ctx).Model(&Product{}).Where("id = ?", id).Update("status", status).Error
 }
 
+// UpdatePlanStatus 人工更新套餐状态，同时清除容量自动售罄标记。
 func (r *Repository) UpdatePlanStatus(ctx context.Context, db *gorm.DB, id uint64, status string) error {
-	return r.queryDB(db).WithContext(ctx).Model(&ProductPlan{}).Where("id = ?", id).Update("status", status).Error
+	return r.queryDB(db).WithContext(ctx).Model(&ProductPlan{}).Where("id = ?", id).Updates(map[string]any{"status": status, "capacity_sold_out_at": nil}).Error
 }
 
 func (r *Repository) CountPlansByProductID(ctx context.Context, db *gorm.DB, productID uint64) (int64, error) {
@@ -266,6 +267,31 @@ func (r *Repository) CountPlanRegionsByRegionID(ctx context.Context, db *gorm.DB
 	return count, nil
 }
 
+// CountRegionDeployments 统计地域下未释放的实例和启用中的交付映射，改绑集群前据此拒绝。
+func (r *Repository) CountRegionDeployments(ctx context.Context, db *gorm.DB, regionNo string) (instances int64, mappings int64, err error) {
+	if err = r.queryDB(db).WithContext(ctx).Table("instances").Where("region_no = ? AND status <> ?", regionNo, "released").Count(&instances).Error; err != nil {
+		return 0, 0, err
+	}
+	err = r.queryDB(db).WithContext(ctx).Table("instance_provision_mappings").Where("region_no = ? AND status = ?", regionNo, "active").Count(&mappings).Error
+	return instances, mappings, err
+}
+
+// RegionClusters 返回地域编号到集群编号的映射，实例用例据此把交付映射和容量统计归到集群。
+func (r *Repository) RegionClusters(ctx context.Context, regionNos []string) (map[string]string, error) {
+	clusters := make(map[string]string, len(regionNos))
+	if len(regionNos) == 0 {
+		return clusters, nil
+	}
+	var rows []SalesRegion
+	if err := r.db.WithContext(ctx).Select("region_no, cluster_no").Where("region_no IN ?", regionNos).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		clusters[row.RegionNo] = row.ClusterNo
+	}
+	return clusters, nil
+}
+
 func (r *Repository) CountPlanOSTemplatesByTemplateID(ctx context.Context, db *gorm.DB, templateID uint64) (int64, error) {
 	var count int64
 	if err := r.queryDB(db).WithContext(ctx).Model(&PlanOSTemplate{}).Where("template_id = ?", templateID).Count(&count).Error; err != nil {
@@ -485,6 +511,18 @@ func (r *Repository) VisibleServerPlans(ctx context.Context, productIDs []uint64
 	return plans, nil
 }
 
+// CapacityPlans 返回参与容量售罄判断的服务器套餐：在售和售罄状态，不要求产品或套餐对外可见。
+func (r *Repository) CapacityPlans(ctx context.Context) ([]ProductPlan, error) {
+	var plans []ProductPlan
+	err := r.db.WithContext(ctx).Table("product_plans AS plans").
+		Select("plans.*").
+		Joins("JOIN products ON products.id = plans.product_id AND products.type = ?", "server").
+		Where("plans.status IN ?", []string{"active", "sold_out"}).
+		Order("plans.sort_order ASC, plans.id ASC").
+		Find(&plans).Error
+	return plans, err
+}
+
 func (r *Repository) ActivePlanPrices(ctx context.Context, planIDs []uint64) ([]PlanPrice, error) {
 	var prices []PlanPrice
 	if err := r.db.WithContext(ctx).
@@ -0,0 +1,102 @@
+package instance
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+)
+
+func (r *Repository) CreateBackup(ctx context.Context, db *gorm.DB, backup *Backup) error {
+	return r.queryDB(db).WithContext(ctx).Create(backup).Error
+}
+
+func (r *Repository) UpdateBackup(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&Backup{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *Repository) UpdateBackupByNo(ctx context.Context, db *gorm.DB, backupNo string, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&Backup{}).Where("backup_no = ?", backupNo).Updates(updates).Error
+}
+
+func (r *Repository) BackupForUpdate(ctx context.Context, db *gorm.DB, instanceID uint64, backupNo string) (Backup, error) {
+	var row Backup
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("instance_id = ? AND backup_no = ? AND status <> ?", instanceID, backupNo, domaininstance.BackupStatusDeleted).
+		First(&row).Error
+	return row, err
+}
+
+// Backups 返回实例未删除的备份，按创建时间倒序。
+func (r *Repository) Backups(ctx context.Context, instanceID uint64) ([]Backup, error) {
+	var rows []Backup
+	err := r.db.WithContext(ctx).Where("instance_id = ? AND status <> ?", instanceID, domaininstance.BackupStatusDeleted).Order("id DESC").Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) BackupByNo(ctx context.Context, backupNo string) (Backup, error) {
+	var row Backup
+	err := r.db.WithContext(ctx).Where("backup_no = ?", backupNo).First(&row).Error
+	return row, err
+}
+
+// BackupByTaskNo 返回定时任务已触发的备份，Worker 重入时据此继续跟踪而不重复备份。
+func (r *Repository) BackupByTaskNo(ctx context.Context, taskNo string) (Backup, error) {
+	var row Backup
+	err := r.db.WithContext(ctx).Where("task_no = ?", taskNo).First(&row).Error
+	return row, err
+}
+
+func (r *Repository) CountManualBackups(ctx context.Context, db *gorm.DB, instanceID uint64) (int64, error) {
+	var total int64
+	err := r.queryDB(db).WithContext(ctx).Model(&Backup{}).
+		Where("instance_id = ? AND source = ? AND status IN ?", instanceID, domaininstance.BackupSourceManual, domaininstance.BackupStatusesInQuota()).
+		Count(&total).Error
+	return total, err
+}
+
+// ExpiredScheduledBackups 返回超出保留份数的可用定时备份，最新的 keep 份不返回。
+func (r *Repository) ExpiredScheduledBackups(ctx context.Context, instanceID uint64, keep int) ([]Backup, error) {
+	var rows []Backup
+	err := r.db.WithContext(ctx).
+		Where("instance_id = ? AND source = ? AND status = ?", instanceID, domaininstance.BackupSourceScheduled, domaininstance.BackupStatusAvailable).
+		Order("id DESC").Offset(keep).Limit(100).Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) BackupPolicy(ctx context.Context, instanceID uint64) (BackupPolicy, error) {
+	var row BackupPolicy
+	err := r.db.WithContext(ctx).Where("instance_id = ?", instanceID).First(&row).Error
+	return row, err
+}
+
+func (r *Repository) BackupPolicyForUpdate(ctx context.Context, db *gorm.DB, instanceID uint64) (BackupPolicy, error) {
+	var row BackupPolicy
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("instance_id = ?", instanceID).First(&row).Error
+	return row, err
+}
+
+func (r *Repository) CreateBackupPolicy(ctx context.Context, db *gorm.DB, policy *BackupPolicy) error {
+	return r.queryDB(db).WithContext(ctx).Create(policy).Error
+}
+
+func (r *Repository) UpdateBackupPolicy(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&BackupPolicy{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DisableBackupPolicy 在实例释放后停用定时备份；已有备份保留在备份存储中，可用于恢复为新实例。
+func (r *Repository) DisableBackupPolicy(ctx context.Context, db *gorm.DB, instanceID uint64) error {
+	return r.queryDB(db).WithContext(ctx).Model(&BackupPolicy{}).Where("instance_id = ?", instanceID).
+		Updates(map[string]any{"enabled": false, "next_run_at": nil}).Error
+}
@@ -0,0 +1,181 @@
+package instance
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+)
+
+type IPPoolFilters struct {
+	Status        string
+	RegionNo      string
+	NetworkTypeNo string
+}
+
+type IPAddressFilters struct {
+	PoolID  uint64
+	Status  string
+	Keyword string
+}
+
+func (r *Repository) CreateIPPool(ctx context.Context, db *gorm.DB, pool *IPPool) error {
+	return r.queryDB(db).WithContext(ctx).Create(pool).Error
+}
+
+func (r *Repository) CreateIPAddresses(ctx context.Context, db *gorm.DB, addresses []IPAddress) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).CreateInBatches(addresses, 500).Error
+}
+
+func (r *Repository) UpdateIPPool(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&IPPool{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *Repository) IPPoolByNo(ctx context.Context, poolNo string) (IPPool, error) {
+	var pool IPPool
+	err := r.db.WithContext(ctx).Where("pool_no = ?", poolNo).First(&pool).Error
+	return pool, err
+}
+
+func (r *Repository) IPPoolForUpdate(ctx context.Context, db *gorm.DB, poolNo string) (IPPool, error) {
+	var pool IPPool
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("pool_no = ?", poolNo).First(&pool).Error
+	return pool, err
+}
+
+// IPPoolRow 返回带地址使用统计的单个地址池。
+func (r *Repository) IPPoolRow(ctx context.Context, poolNo string) (IPPoolRow, error) {
+	var row IPPoolRow
+	err := r.ipPoolRowQuery(ctx).Where("ip_pools.pool_no = ?", poolNo).Take(&row).Error
+	return row, err
+}
+
+func (r *Repository) ListIPPools(ctx context.Context, filters IPPoolFilters, limit, offset int) ([]IPPoolRow, int64, error) {
+	var total int64
+	if err := r.applyIPPoolFilters(r.db.WithContext(ctx).Model(&IPPool{}), filters).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []IPPoolRow
+	if err := r.applyIPPoolFilters(r.ipPoolRowQuery(ctx), filters).Order("ip_pools.created_at DESC, ip_pools.id DESC").Limit(limit).Offset(offset).Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// IPPoolsForAllocation 锁定地域和网络类型下可分配的地址池，精确匹配网络类型的地址池优先，
+// 同一地址池的并发分配因此串行执行。
+func (r *Repository) IPPoolsForAllocation(ctx context.Context, db *gorm.DB, regionNo, networkTypeNo string) ([]IPPool, error) {
+	var pools []IPPool
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("region_no = ? AND status = ?", regionNo, domaininstance.IPPoolStatusActive).
+		Where("network_type_no = ? OR network_type_no = ''", strings.TrimSpace(networkTypeNo)).
+		Order("network_type_no DESC, id ASC").
+		Find(&pools).Error
+	return pools, err
+}
+
+// AllocateIPAddresses 从地址池按地址顺序分配 count 个可用地址给实例；可用地址不足时不做任何修改并返回 false。
+// 调用方必须先通过 IPPoolsForAllocation 锁定地址池。
+func (r *Repository) AllocateIPAddresses(ctx context.Context, db *gorm.DB, poolID uint64, instanceID uint64, instanceNo string, count int, now time.Time) (bool, error) {
+	var ids []uint64
+	if err := r.queryDB(db).WithContext(ctx).Model(&IPAddress{}).
+		Where("pool_id = ? AND status = ?", poolID, domaininstance.IPAddressStatusAvailable).
+		Order("id ASC").Limit(count).Pluck("id", &ids).Error; err != nil {
+		return false, err
+	}
+	if len(ids) < count {
+		return false, nil
+	}
+	result := r.queryDB(db).WithContext(ctx).Model(&IPAddress{}).
+		Where("id IN ? AND status = ?", ids, domaininstance.IPAddressStatusAvailable).
+		Updates(map[string]any{"status": domaininstance.IPAddressStatusAllocated, "instance_id": instanceID, "instance_no": instanceNo, "allocated_at": now})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == int64(count), nil
+}
+
+// InstanceIPLeases 返回实例已分配的地址，第一个地址作为主地址写入 ipconfig0。
+func (r *Repository) InstanceIPLeases(ctx context.Context, db *gorm.DB, instanceID uint64) ([]IPLease, error) {
+	var rows []IPLease
+	err := r.queryDB(db).WithContext(ctx).Table("ip_addresses").
+		Select("ip_addresses.address, ip_pools.pool_no, ip_pools.prefix_length, ip_pools.gateway").
+		Joins("JOIN ip_pools ON ip_pools.id = ip_addresses.pool_id").
+		Where("ip_addresses.instance_id = ? AND ip_addresses.status = ?", instanceID, domaininstance.IPAddressStatusAllocated).
+		Order("ip_addresses.id ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ReleaseInstanceIPAddresses 在实例释放后把其占用的地址退回地址池。
+func (r *Repository) ReleaseInstanceIPAddresses(ctx context.Context, db *gorm.DB, instanceID uint64) error {
+	return r.queryDB(db).WithContext(ctx).Model(&IPAddress{}).
+		Where("instance_id = ? AND status = ?", instanceID, domaininstance.IPAddressStatusAllocated).
+		Updates(map[string]any{"status": domaininstance.IPAddressStatusAvailable, "instance_id": nil, "instance_no": nil, "allocated_at": nil}).Error
+}
+
+func (r *Repository) ListIPAddresses(ctx context.Context, filters IPAddressFilters, limit, offset int) ([]IPAddress, int64, error) {
+	query := r.db.WithContext(ctx).Model(&IPAddress{}).Where("pool_id = ?", filters.PoolID)
+	if strings.TrimSpace(filters.Status) != "" {
+		query = query.Where("status = ?", strings.TrimSpace(filters.Status))
+	}
+	if keyword := strings.TrimSpace(filters.Keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("address LIKE ? OR instance_no LIKE ?", like, like)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []IPAddress
+	if err := query.Order("id ASC").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+func (r *Repository) IPAddressForUpdate(ctx context.Context, db *gorm.DB, poolID uint64, id uint64) (IPAddress, error) {
+	var row IPAddress
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND pool_id = ?", id, poolID).First(&row).Error
+	return row, err
+}
+
+func (r *Repository) UpdateIPAddress(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&IPAddress{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *Repository) ipPoolRowQuery(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Table("ip_pools").
+		Select("ip_pools.*, COUNT(ip_addresses.id) AS total_count, " +
+			"COALESCE(SUM(ip_addresses.status = 'available'), 0) AS available_count, " +
+			"COALESCE(SUM(ip_addresses.status = 'reserved'), 0) AS reserved_count, " +
+			"COALESCE(SUM(ip_addresses.status = 'allocated'), 0) AS allocated_count").
+		Joins("LEFT JOIN ip_addresses ON ip_addresses.pool_id = ip_pools.id").
+		Group("ip_pools.id")
+}
+
+func (r *Repository) applyIPPoolFilters(db *gorm.DB, filters IPPoolFilters) *gorm.DB {
+	if strings.TrimSpace(filters.Status) != "" {
+		db = db.Where("ip_pools.status = ?", strings.TrimSpace(filters.Status))
+	}
+	if strings.TrimSpace(filters.RegionNo) != "" {
+		db = db.Where("ip_pools.region_no = ?", strings.TrimSpace(filters.RegionNo))
+	}
+	if strings.TrimSpace(filters.NetworkTypeNo) != "" {
+		db = db.Where("ip_pools.network_type_no = ?", strings.TrimSpace(filters.NetworkTypeNo))
+	}
+	return db
+}
@@ -0,0 +1,100 @@
+package instance
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+)
+
+type ISOFilters struct {
+	Purpose string
+	Status  string
+	Keyword string
+}
+
+func (r *Repository) CreateISO(ctx context.Context, db *gorm.DB, iso *ISO) error {
+	return r.queryDB(db).WithContext(ctx).Create(iso).Error
+}
+
+func (r *Repository) UpdateISO(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&ISO{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *Repository) ISOByNo(ctx context.Context, isoNo string) (ISO, error) {
+	var iso ISO
+	err := r.db.WithContext(ctx).Where("iso_no = ?", isoNo).First(&iso).Error
+	return iso, err
+}
+
+func (r *Repository) ISOForUpdate(ctx context.Context, db *gorm.DB, isoNo string) (ISO, error) {
+	var iso ISO
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("iso_no = ?", isoNo).First(&iso).Error
+	return iso, err
+}
+
+// ActiveISO 返回指定用途下可用的镜像；isoNo 为空时返回排序最靠前的一条，用于进入救援时的默认介质。
+func (r *Repository) ActiveISO(ctx context.Context, db *gorm.DB, purpose string, isoNo string) (ISO, error) {
+	var iso ISO
+	query := r.queryDB(db).WithContext(ctx).Where("purpose = ? AND status = ?", purpose, domaininstance.ISOStatusActive)
+	if strings.TrimSpace(isoNo) != "" {
+		query = query.Where("iso_no = ?", strings.TrimSpace(isoNo))
+	}
+	err := query.Order("sort_order ASC, id ASC").First(&iso).Error
+	return iso, err
+}
+
+// ISONames 返回镜像编号到名称的映射，实例详情据此展示救援介质和已挂载镜像。
+func (r *Repository) ISONames(ctx context.Context, isoNos []string) (map[string]string, error) {
+	names := make(map[string]string, len(isoNos))
+	if len(isoNos) == 0 {
+		return names, nil
+	}
+	var rows []ISO
+	if err := r.db.WithContext(ctx).Select("iso_no, name").Where("iso_no IN ?", isoNos).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		names[row.ISONo] = row.Name
+	}
+	return names, nil
+}
+
+func (r *Repository) ListISOs(ctx context.Context, filters ISOFilters, limit, offset int) ([]ISO, int64, error) {
+	query := r.db.WithContext(ctx).Model(&ISO{})
+	if filters.Purpose != "" {
+		query = query.Where("purpose = ?", filters.Purpose)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if keyword := strings.TrimSpace(filters.Keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("iso_no LIKE ? OR name LIKE ?", like, like)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []ISO
+	if limit > 0 {
+		query = query.Limit(limit).Offset(offset)
+	}
+	err := query.Order("purpose ASC, sort_order ASC, id ASC").Find(&rows).Error
+	return rows, total, err
+}
+
+// CountInstancesUsingISO 统计正在使用该镜像作为救援介质或挂载在光驱中的未释放实例，停用或修改卷前据此提示。
+func (r *Repository) CountInstancesUsingISO(ctx context.Context, db *gorm.DB, isoNo string) (int64, error) {
+	var total int64
+	err := r.queryDB(db).WithContext(ctx).Model(&Instance{}).
+		Where("(rescue_iso_no = ? OR mounted_iso_no = ?) AND status <> ?", isoNo, isoNo, domaininstance.StatusReleased).
+		Count(&total).Error
+	return total, err
+}
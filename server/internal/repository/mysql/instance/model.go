@@ -11,10 +11,12 @@ type ProvisionMapping struct {
 	TemplateNo      string    `gorm:"column:template_no"`
 	NetworkTypeNo   string    `gorm:"column:network_type_no"`
 	Node            string    `gorm:"column:node"`
+	PlacementNodes  *string   `gorm:"column:placement_nodes"`
 	Storage         string    `gorm:"column:storage"`
 	DiskSource      string    `gorm:"column:disk_source"`
 	DiskFormat      *string   `gorm:"column:disk_format"`
 	DiskInterface   *string   `gorm:"column:disk_interface"`
+	DataDiskStorage *string   `gorm:"column:data_disk_storage"`
 	SnippetsStorage *string   `gorm:"column:snippets_storage"`
 	CIUser          *string   `gorm:"column:ci_user"`
 	SSHKeys         *string   `gorm:"column:ssh_keys"`
@@ -26,6 +28,7 @@ type ProvisionMapping struct {
 	VMIDStart       uint      `gorm:"column:vmid_start"`
 	VMIDEnd         uint      `gorm:"column:vmid_end"`
 	NextVMID        uint      `gorm:"column:next_vmid"`
+	ReuseVMIDs      bool      `gorm:"column:reuse_released_vmids"`
 	Status          string    `gorm:"column:status"`
 	Remark          *string   `gorm:"column:remark"`
 	CreatedAt       time.Time `gorm:"column:created_at"`
@@ -50,6 +53,8 @@ type Instance struct {
 	SystemDiskGB             int        `gorm:"column:system_disk_gb"`
 	DataDiskGB               int        `gorm:"column:data_disk_gb"`
 	BandwidthMbps            int        `gorm:"column:bandwidth_mbps"`
+	TrafficGB                *int       `gorm:"column:traffic_gb"`
+	TrafficOverageAction     *string    `gorm:"column:traffic_overage_action"`
 	RegionNo                 string     `gorm:"column:region_no"`
 	RegionName               string     `gorm:"column:region_name"`
 	NetworkTypeNo            *string    `gorm:"column:network_type_no"`
@@ -59,11 +64,26 @@ type Instance struct {
 	OSFamily                 string     `gorm:"column:os_family"`
 	OSDistribution           string     `gorm:"column:os_distribution"`
 	OSVersion                string     `gorm:"column:os_version"`
+	ClusterNo                string     `gorm:"column:cluster_no"`
 	ExternalNode             string     `gorm:"column:external_node"`
 	ExternalVMID             uint       `gorm:"column:external_vmid"`
 	ExternalResourceLocation *string    `gorm:"column:external_resource_location"`
 	LastErrorCode            *string    `gorm:"column:last_error_code"`
 	LastErrorMessage         *string    `gorm:"column:last_error_message"`
+	ConfigDrift              *string    `gorm:"column:config_drift"`
+	ConfigCheckedAt          *time.Time `gorm:"column:config_checked_at"`
+	FirewallStatus           *string    `gorm:"column:firewall_status"`
+	FirewallSyncedAt         *time.Time `gorm:"column:firewall_synced_at"`
+	SuspendSource            *string    `gorm:"column:suspend_source"`
+	SuspendReason            *string    `gorm:"column:suspend_reason"`
+	SuspendedAt              *time.Time `gorm:"column:suspended_at"`
+	RescueISONo              *string    `gorm:"column:rescue_iso_no"`
+	RescueStartedAt          *time.Time `gorm:"column:rescue_started_at"`
+	RescueExpiresAt          *time.Time `gorm:"column:rescue_expires_at"`
+	RescuePasswordCiphertext *string    `gorm:"column:rescue_password_ciphertext"`
+	MountedISONo             *string    `gorm:"column:mounted_iso_no"`
+	RootPasswordCiphertext   *string    `gorm:"column:root_password_ciphertext"`
+	RootPasswordRevealedAt   *time.Time `gorm:"column:root_password_revealed_at"`
 	ServiceStartedAt         *time.Time `gorm:"column:service_started_at"`
 	ExpiresAt                *time.Time `gorm:"column:expires_at"`
 	ExpireNoticeSentAt       *time.Time `gorm:"column:expire_notice_sent_at"`
@@ -90,6 +110,8 @@ type Operation struct {
 	ResourceLocation    *string    `gorm:"column:resource_location"`
 	ErrorCode           *string    `gorm:"column:error_code"`
 	ErrorMessage        *string    `gorm:"column:error_message"`
+	Payload             *string    `gorm:"column:payload"`
+	Placement           *string    `gorm:"column:placement"`
 	CreatedAt           time.Time  `gorm:"column:created_at"`
 	UpdatedAt           time.Time  `gorm:"column:updated_at"`
 	CompletedAt         *time.Time `gorm:"column:completed_at"`
@@ -97,6 +119,218 @@ type Operation struct {
 
 func (Operation) TableName() string { return "instance_operations" }
 
+// ExternalOperation 是按上游 operation ID 查到的操作及其实例编号，供操作完成回调定位实例。
+type ExternalOperation struct {
+	Operation
+	InstanceNo string
+}
+
+// Placement 是多节点交付的调度决策，保存在 provision 操作上供排障；Candidates 按调度优先级排序。
+type Placement struct {
+	MappingNo  string               `json:"mapping_no"`
+	Node       string               `json:"node"`
+	Ratios     PlacementRatios      `json:"ratios"`
+	Candidates []PlacementCandidate `json:"candidates"`
+}
+
+type PlacementRatios struct {
+	CPU              float64 `json:"cpu"`
+	Memory           float64 `json:"memory"`
+	Storage          float64 `json:"storage"`
+	UserAntiAffinity bool    `json:"user_anti_affinity"`
+}
+
+// PlacementCandidate 是单个候选节点的容量快照和裁决结果；Reason 为空表示可放置。
+type PlacementCandidate struct {
+	Node              string  `json:"node"`
+	Eligible          bool    `json:"eligible"`
+	Reason            string  `json:"reason,omitempty"`
+	Load              float64 `json:"load"`
+	MaxCPU            int     `json:"max_cpu"`
+	CPUUsage          float64 `json:"cpu_usage"`
+	MemoryTotalMB     int64   `json:"memory_total_mb"`
+	MemoryUsedMB      int64   `json:"memory_used_mb"`
+	StorageTotalGB    int64   `json:"storage_total_gb"`
+	StorageUsedGB     int64   `json:"storage_used_gb"`
+	CommittedCPU      int     `json:"committed_cpu"`
+	CommittedMemoryMB int64   `json:"committed_memory_mb"`
+	CommittedDiskGB   int64   `json:"committed_disk_gb"`
+	UserInstances     int     `json:"user_instances"`
+}
+
+// NodeAllocation 是节点上未释放实例已分配的规格合计。
+type NodeAllocation struct {
+	Node          string `gorm:"column:node"`
+	CPUCores      int    `gorm:"column:cpu_cores"`
+	MemoryMB      int64  `gorm:"column:memory_mb"`
+	DiskGB        int64  `gorm:"column:disk_gb"`
+	Instances     int    `gorm:"column:instances"`
+	UserInstances int    `gorm:"column:user_instances"`
+}
+
+// ReinstallPayload 是 reinstall 操作保存的目标系统模板快照，操作成功后回写实例。
+type ReinstallPayload struct {
+	MappingNo      string `json:"mapping_no"`
+	TemplateNo     string `json:"template_no"`
+	TemplateName   string `json:"template_name"`
+	OSFamily       string `json:"os_family"`
+	OSDistribution string `json:"os_distribution"`
+	OSVersion      string `json:"os_version"`
+}
+
+// ResizePayload 是变更套餐 resize 操作保存的目标套餐规格，操作成功后回写实例并结算订单。
+type ResizePayload struct {
+	OrderNo       string `json:"order_no"`
+	PlanNo        string `json:"plan_no"`
+	PlanName      string `json:"plan_name"`
+	CPUCores      int    `json:"cpu_cores"`
+	MemoryMB      int    `json:"memory_mb"`
+	BandwidthMbps int    `json:"bandwidth_mbps"`
+	TrafficGB     *int   `json:"traffic_gb,omitempty"`
+	CreditCents   uint64 `json:"credit_cents"`
+}
+
+// ResetPasswordPayload 是重置密码操作保存的新密码密文，操作成功后回写实例并重新允许查看一次；不得保存明文。
+type ResetPasswordPayload struct {
+	PasswordCiphertext string `json:"password_ciphertext"`
+}
+
+// RescuePayload 是进入救援操作保存的救援介质和临时密码密文，操作成功后回写实例救援字段；
+// ExpiresAt 是到时自动退出救援的时间。
+type RescuePayload struct {
+	ISONo              string    `json:"iso_no"`
+	ISOName            string    `json:"iso_name"`
+	PasswordCiphertext string    `json:"password_ciphertext"`
+	ExpiresAt          time.Time `json:"expires_at"`
+}
+
+// ISOPayload 是挂载光驱操作保存的镜像，操作成功后回写实例 mounted_iso_no。
+type ISOPayload struct {
+	ISONo   string `json:"iso_no"`
+	ISOName string `json:"iso_name"`
+	Boot    bool   `json:"boot"`
+}
+
+// MigratePayload 是迁移操作保存的源节点和目标节点，操作成功后回写实例 external_node；
+// Fallback 为 true 表示在线迁移失败后由同步以离线方式重新迁移到同一目标节点。
+// Stage 为 stop 时本操作只关机，不改变实例所在节点；Restart 为 true 表示 VM 由迁移关机，迁移结束后重新开机。
+type MigratePayload struct {
+	SourceNode string `json:"source_node"`
+	TargetNode string `json:"target_node"`
+	Mode       string `json:"mode"`
+	Online     bool   `json:"online"`
+	Fallback   bool   `json:"fallback"`
+	Stage      string `json:"stage,omitempty"`
+	Restart    bool   `json:"restart,omitempty"`
+}
+
+// ISO 是管理端维护的镜像库条目；Volume 为 PVE 存储卷 ID，应位于所有节点可访问的共享存储上。
+type ISO struct {
+	ID          uint64    `gorm:"column:id;primaryKey"`
+	ISONo       string    `gorm:"column:iso_no"`
+	Name        string    `gorm:"column:name"`
+	Purpose     string    `gorm:"column:purpose"`
+	Media       string    `gorm:"column:media"`
+	Volume      string    `gorm:"column:volume"`
+	OSFamily    *string   `gorm:"column:os_family"`
+	Description *string   `gorm:"column:description"`
+	Status      string    `gorm:"column:status"`
+	SortOrder   int       `gorm:"column:sort_order"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at"`
+}
+
+func (ISO) TableName() string { return "instance_isos" }
+
+// SnapshotPayload 是快照操作保存的目标快照，操作结束后据此回写快照状态。
+type SnapshotPayload struct {
+	SnapshotNo string `json:"snapshot_no"`
+	Name       string `json:"name"`
+}
+
+type Snapshot struct {
+	ID               uint64     `gorm:"column:id;primaryKey"`
+	SnapshotNo       string     `gorm:"column:snapshot_no"`
+	InstanceID       uint64     `gorm:"column:instance_id"`
+	Name             string     `gorm:"column:name"`
+	Description      *string    `gorm:"column:description"`
+	Status           string     `gorm:"column:status"`
+	CreatedByUserID  *uint64    `gorm:"column:created_by_user_id"`
+	CreatedByAdminID *uint64    `gorm:"column:created_by_admin_id"`
+	LastRolledBackAt *time.Time `gorm:"column:last_rolled_back_at"`
+	CreatedAt        time.Time  `gorm:"column:created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at"`
+	DeletedAt        *time.Time `gorm:"column:deleted_at"`
+}
+
+func (Snapshot) TableName() string { return "instance_snapshots" }
+
+// BackupPayload 是备份操作保存的目标备份，操作结束后据此回写备份状态和备份卷。
+type BackupPayload struct {
+	BackupNo string `json:"backup_no"`
+}
+
+// Backup 是备份目录记录，保存备份卷位置和备份时的实例规格、系统模板快照，供恢复为新实例时校验。
+type Backup struct {
+	ID               uint64     `gorm:"column:id;primaryKey"`
+	BackupNo         string     `gorm:"column:backup_no"`
+	InstanceID       uint64     `gorm:"column:instance_id"`
+	Source           string     `gorm:"column:source"`
+	Status           string     `gorm:"column:status"`
+	ClusterNo        string     `gorm:"column:cluster_no"`
+	Node             string     `gorm:"column:node"`
+	Storage          string     `gorm:"column:storage"`
+	VolumeID         *string    `gorm:"column:volume_id"`
+	Mode             string     `gorm:"column:mode"`
+	Note             *string    `gorm:"column:note"`
+	TaskNo           *string    `gorm:"column:task_no"`
+	CPUCores         int        `gorm:"column:cpu_cores"`
+	MemoryMB         int        `gorm:"column:memory_mb"`
+	SystemDiskGB     int        `gorm:"column:system_disk_gb"`
+	DataDiskGB       int        `gorm:"column:data_disk_gb"`
+	TemplateNo       string     `gorm:"column:template_no"`
+	TemplateName     string     `gorm:"column:template_name"`
+	OSFamily         string     `gorm:"column:os_family"`
+	OSDistribution   string     `gorm:"column:os_distribution"`
+	OSVersion        string     `gorm:"column:os_version"`
+	CreatedByUserID  *uint64    `gorm:"column:created_by_user_id"`
+	CreatedByAdminID *uint64    `gorm:"column:created_by_admin_id"`
+	CompletedAt      *time.Time `gorm:"column:completed_at"`
+	LastRestoredAt   *time.Time `gorm:"column:last_restored_at"`
+	CreatedAt        time.Time  `gorm:"column:created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at"`
+	DeletedAt        *time.Time `gorm:"column:deleted_at"`
+}
+
+func (Backup) TableName() string { return "instance_backups" }
+
+type BackupPolicy struct {
+	ID             uint64     `gorm:"column:id;primaryKey"`
+	InstanceID     uint64     `gorm:"column:instance_id"`
+	Frequency      string     `gorm:"column:frequency"`
+	Weekday        int        `gorm:"column:weekday"`
+	Hour           int        `gorm:"column:hour"`
+	RetentionCount int        `gorm:"column:retention_count"`
+	Enabled        bool       `gorm:"column:enabled"`
+	NextRunAt      *time.Time `gorm:"column:next_run_at"`
+	LastRunAt      *time.Time `gorm:"column:last_run_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at"`
+}
+
+func (BackupPolicy) TableName() string { return "instance_backup_policies" }
+
+type PlanTemplate struct {
+	TemplateNo   string
+	Code         string
+	Name         string
+	OSFamily     string
+	Distribution string
+	Version      string
+	Architecture string
+	Summary      *string
+}
+
 type Task struct {
 	ID               uint64     `gorm:"column:id;primaryKey"`
 	TaskNo           string     `gorm:"column:task_no"`
@@ -149,3 +383,232 @@ type InstanceRow struct {
 	Email       string
 	DisplayName *string
 }
+
+// IPPool 是按地域和网络类型划分的 IPv4 地址池；NetworkTypeNo 为空表示不限网络类型。
+type IPPool struct {
+	ID            uint64    `gorm:"column:id;primaryKey"`
+	PoolNo        string    `gorm:"column:pool_no"`
+	Name          string    `gorm:"column:name"`
+	RegionNo      string    `gorm:"column:region_no"`
+	NetworkTypeNo string    `gorm:"column:network_type_no"`
+	CIDR          string    `gorm:"column:cidr"`
+	PrefixLength  int       `gorm:"column:prefix_length"`
+	Gateway       string    `gorm:"column:gateway"`
+	Status        string    `gorm:"column:status"`
+	Remark        *string   `gorm:"column:remark"`
+	CreatedAt     time.Time `gorm:"column:created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at"`
+}
+
+func (IPPool) TableName() string { return "ip_pools" }
+
+// IPPoolRow 是带地址使用统计的地址池列表行。
+type IPPoolRow struct {
+	IPPool
+	TotalCount     int64
+	AvailableCount int64
+	ReservedCount  int64
+	AllocatedCount int64
+}
+
+type IPAddress struct {
+	ID          uint64     `gorm:"column:id;primaryKey"`
+	PoolID      uint64     `gorm:"column:pool_id"`
+	Address     string     `gorm:"column:address"`
+	Status      string     `gorm:"column:status"`
+	InstanceID  *uint64    `gorm:"column:instance_id"`
+	InstanceNo  *string    `gorm:"column:instance_no"`
+	AllocatedAt *time.Time `gorm:"column:allocated_at"`
+	Remark      *string    `gorm:"column:remark"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at"`
+}
+
+func (IPAddress) TableName() string { return "ip_addresses" }
+
+// IPLease 是实例已分配的地址及所在地址池的网段信息，用于生成 cloud-init ipconfig0。
+type IPLease struct {
+	Address      string
+	PoolNo       string
+	PrefixLength int
+	Gateway      string
+}
+
+// ReconcileReport 是一次虚拟化平台与实例记录的对账结果汇总；NodeErrors 为列表失败节点的 JSON。
+type ReconcileReport struct {
+	ID            uint64     `gorm:"column:id;primaryKey"`
+	ReportNo      string     `gorm:"column:report_no"`
+	Status        string     `gorm:"column:status"`
+	TaskNo        *string    `gorm:"column:task_no"`
+	AdminID       *uint64    `gorm:"column:admin_id"`
+	NodeCount     int        `gorm:"column:node_count"`
+	VMCount       int        `gorm:"column:vm_count"`
+	InstanceCount int        `gorm:"column:instance_count"`
+	OrphanCount   int        `gorm:"column:orphan_count"`
+	GhostCount    int        `gorm:"column:ghost_count"`
+	DriftCount    int        `gorm:"column:drift_count"`
+	NodeErrors    *string    `gorm:"column:node_errors"`
+	ErrorMessage  *string    `gorm:"column:error_message"`
+	StartedAt     time.Time  `gorm:"column:started_at"`
+	FinishedAt    *time.Time `gorm:"column:finished_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at"`
+}
+
+func (ReconcileReport) TableName() string { return "instance_reconcile_reports" }
+
+// ReconcileItem 是对账报告中的一条差异及其处理结果；VM 字段在幽灵差异中为空，实例字段在孤儿差异中为空。
+type ReconcileItem struct {
+	ID             uint64     `gorm:"column:id;primaryKey"`
+	ReportID       uint64     `gorm:"column:report_id"`
+	Kind           string     `gorm:"column:kind"`
+	ClusterNo      string     `gorm:"column:cluster_no"`
+	Node           string     `gorm:"column:node"`
+	VMID           uint       `gorm:"column:vmid"`
+	VMName         *string    `gorm:"column:vm_name"`
+	VMStatus       *string    `gorm:"column:vm_status"`
+	VMCPUs         *int       `gorm:"column:vm_cpus"`
+	VMMemoryMB     *int       `gorm:"column:vm_memory_mb"`
+	InstanceID     *uint64    `gorm:"column:instance_id"`
+	InstanceNo     *string    `gorm:"column:instance_no"`
+	InstanceStatus *string    `gorm:"column:instance_status"`
+	Drift          *string    `gorm:"column:drift"`
+	Status         string     `gorm:"column:status"`
+	Resolution     *string    `gorm:"column:resolution"`
+	ResolvedBy     *uint64    `gorm:"column:resolved_by"`
+	ResolvedAt     *time.Time `gorm:"column:resolved_at"`
+	Remark         *string    `gorm:"column:remark"`
+	CreatedAt      time.Time  `gorm:"column:created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at"`
+}
+
+func (ReconcileItem) TableName() string { return "instance_reconcile_items" }
+
+// ReconcileItemRow 是带报告编号的对账差异列表行。
+type ReconcileItemRow struct {
+	ReconcileItem
+	ReportNo string
+}
+
+// TrafficUsage 是实例单个计费月的流量累计；LastNetIn/LastNetOut 是上次采样的上游累计计数器，用于计算增量。
+type TrafficUsage struct {
+	ID                 uint64     `gorm:"column:id;primaryKey"`
+	InstanceID         uint64     `gorm:"column:instance_id"`
+	UserID             uint64     `gorm:"column:user_id"`
+	Period             string     `gorm:"column:period"`
+	QuotaGB            *int       `gorm:"column:quota_gb"`
+	InBytes            uint64     `gorm:"column:in_bytes"`
+	OutBytes           uint64     `gorm:"column:out_bytes"`
+	BilledBytes        uint64     `gorm:"column:billed_bytes"`
+	LastNetIn          uint64     `gorm:"column:last_netin"`
+	LastNetOut         uint64     `gorm:"column:last_netout"`
+	LastSampledAt      *time.Time `gorm:"column:last_sampled_at"`
+	WarningNotifiedAt  *time.Time `gorm:"column:warning_notified_at"`
+	ExceededNotifiedAt *time.Time `gorm:"column:exceeded_notified_at"`
+	OverageAction      *string    `gorm:"column:overage_action"`
+	OverageAppliedAt   *time.Time `gorm:"column:overage_applied_at"`
+	OverageBilledGB    uint64     `gorm:"column:overage_billed_gb"`
+	OverageBilledCents uint64     `gorm:"column:overage_billed_cents"`
+	CreatedAt          time.Time  `gorm:"column:created_at"`
+	UpdatedAt          time.Time  `gorm:"column:updated_at"`
+}
+
+func (TrafficUsage) TableName() string { return "instance_traffic_usages" }
+
+type SecurityGroup struct {
+	ID          uint64    `gorm:"column:id;primaryKey"`
+	GroupNo     string    `gorm:"column:group_no"`
+	UserID      uint64    `gorm:"column:user_id"`
+	Name        string    `gorm:"column:name"`
+	Description *string   `gorm:"column:description"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at"`
+}
+
+func (SecurityGroup) TableName() string { return "security_groups" }
+
+type SecurityGroupRule struct {
+	ID              uint64    `gorm:"column:id;primaryKey"`
+	SecurityGroupID uint64    `gorm:"column:security_group_id"`
+	Direction       string    `gorm:"column:direction"`
+	Protocol        string    `gorm:"column:protocol"`
+	PortRange       *string   `gorm:"column:port_range"`
+	CIDR            string    `gorm:"column:cidr"`
+	Description     *string   `gorm:"column:description"`
+	SortOrder       int       `gorm:"column:sort_order"`
+	CreatedAt       time.Time `gorm:"column:created_at"`
+}
+
+func (SecurityGroupRule) TableName() string { return "security_group_rules" }
+
+type InstanceSecurityGroup struct {
+	ID              uint64    `gorm:"column:id;primaryKey"`
+	InstanceID      uint64    `gorm:"column:instance_id"`
+	SecurityGroupID uint64    `gorm:"column:security_group_id"`
+	SortOrder       int       `gorm:"column:sort_order"`
+	CreatedAt       time.Time `gorm:"column:created_at"`
+}
+
+func (InstanceSecurityGroup) TableName() string { return "instance_security_groups" }
+
+// PTRRecord 是实例地址的反向解析记录；AppliedHostname 为当前已写入 DNS 的主机名，
+// 修改待审批期间旧记录仍然生效。
+type PTRRecord struct {
+	ID              uint64     `gorm:"column:id;primaryKey"`
+	IPAddressID     uint64     `gorm:"column:ip_address_id"`
+	Address         string     `gorm:"column:address"`
+	InstanceID      uint64     `gorm:"column:instance_id"`
+	InstanceNo      string     `gorm:"column:instance_no"`
+	UserID          uint64     `gorm:"column:user_id"`
+	Hostname        string     `gorm:"column:hostname"`
+	AppliedHostname *string    `gorm:"column:applied_hostname"`
+	Status          string     `gorm:"column:status"`
+	LastError       *string    `gorm:"column:last_error"`
+	ReviewedBy      *uint64    `gorm:"column:reviewed_by"`
+	ReviewedAt      *time.Time `gorm:"column:reviewed_at"`
+	ReviewRemark    *string    `gorm:"column:review_remark"`
+	AppliedAt       *time.Time `gorm:"column:applied_at"`
+	CreatedAt       time.Time  `gorm:"column:created_at"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at"`
+}
+
+func (PTRRecord) TableName() string { return "ip_ptr_records" }
+
+// InstanceAddress 是实例占用的单个地址。
+type InstanceAddress struct {
+	ID      uint64
+	Address string
+}
+
+type InstanceTransfer struct {
+	ID          uint64     `gorm:"column:id;primaryKey"`
+	TransferNo  string     `gorm:"column:transfer_no"`
+	InstanceID  uint64     `gorm:"column:instance_id"`
+	InstanceNo  string     `gorm:"column:instance_no"`
+	FromUserID  uint64     `gorm:"column:from_user_id"`
+	ToUserID    uint64     `gorm:"column:to_user_id"`
+	Status      string     `gorm:"column:status"`
+	Remark      *string    `gorm:"column:remark"`
+	ForcedBy    *uint64    `gorm:"column:forced_by"`
+	ForceReason *string    `gorm:"column:force_reason"`
+	RespondedAt *time.Time `gorm:"column:responded_at"`
+	CompletedAt *time.Time `gorm:"column:completed_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at"`
+}
+
+func (InstanceTransfer) TableName() string { return "instance_transfers" }
+
+// InstanceTransferRow 是带双方账号快照的转移记录。
+type InstanceTransferRow struct {
+	InstanceTransfer
+	FromUsername string
+	ToUsername   string
+}
+
+// TransferResult 是一次所有权变更实际迁移和取消的关联数据。
+type TransferResult struct {
+	CancelledOrderNos []string
+	MovedTickets      int64
+	DetachedGroups    bool
+}
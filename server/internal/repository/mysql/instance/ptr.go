@@ -0,0 +1,98 @@
+package instance
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+)
+
+type PTRRecordFilters struct {
+	Status  string
+	Keyword string
+}
+
+// InstanceAddresses 按分配顺序返回实例当前占用的地址。
+func (r *Repository) InstanceAddresses(ctx context.Context, db *gorm.DB, instanceID uint64) ([]InstanceAddress, error) {
+	var rows []InstanceAddress
+	err := r.queryDB(db).WithContext(ctx).Model(&IPAddress{}).
+		Select("id, address").
+		Where("instance_id = ? AND status = ?", instanceID, domaininstance.IPAddressStatusAllocated).
+		Order("id ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) InstancePTRRecords(ctx context.Context, db *gorm.DB, instanceID uint64) ([]PTRRecord, error) {
+	var rows []PTRRecord
+	err := r.queryDB(db).WithContext(ctx).Where("instance_id = ?", instanceID).Order("ip_address_id ASC").Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) PTRRecordByAddressForUpdate(ctx context.Context, db *gorm.DB, ipAddressID uint64) (PTRRecord, error) {
+	var row PTRRecord
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("ip_address_id = ?", ipAddressID).First(&row).Error
+	return row, err
+}
+
+func (r *Repository) PTRRecordByID(ctx context.Context, id uint64) (PTRRecord, error) {
+	var row PTRRecord
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&row).Error
+	return row, err
+}
+
+func (r *Repository) PTRRecordForUpdate(ctx context.Context, db *gorm.DB, id uint64) (PTRRecord, error) {
+	var row PTRRecord
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&row).Error
+	return row, err
+}
+
+func (r *Repository) CreatePTRRecord(ctx context.Context, db *gorm.DB, row *PTRRecord) error {
+	return r.queryDB(db).WithContext(ctx).Create(row).Error
+}
+
+func (r *Repository) UpdatePTRRecord(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&PTRRecord{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *Repository) DeletePTRRecord(ctx context.Context, db *gorm.DB, id uint64) error {
+	return r.queryDB(db).WithContext(ctx).Where("id = ?", id).Delete(&PTRRecord{}).Error
+}
+
+// DeleteInstancePTRRecords 删除实例全部 PTR 记录并返回删除前的记录，调用方据此清理 DNS 后端。
+func (r *Repository) DeleteInstancePTRRecords(ctx context.Context, db *gorm.DB, instanceID uint64) ([]PTRRecord, error) {
+	var rows []PTRRecord
+	if err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("instance_id = ?", instanceID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return rows, nil
+	}
+	return rows, r.queryDB(db).WithContext(ctx).Where("instance_id = ?", instanceID).Delete(&PTRRecord{}).Error
+}
+
+func (r *Repository) ListPTRRecords(ctx context.Context, filters PTRRecordFilters, limit, offset int) ([]PTRRecord, int64, error) {
+	query := r.db.WithContext(ctx).Model(&PTRRecord{})
+	if strings.TrimSpace(filters.Status) != "" {
+		query = query.Where("status = ?", strings.TrimSpace(filters.Status))
+	}
+	if keyword := strings.TrimSpace(filters.Keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("address LIKE ? OR hostname LIKE ? OR instance_no LIKE ?", like, like, like)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []PTRRecord
+	if err := query.Order("updated_at ASC, id ASC").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
@@ -0,0 +1,119 @@
+package instance
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReconcileItemFilters struct {
+	ReportID uint64
+	Kind     string
+	Status   string
+}
+
+func (r *Repository) CreateReconcileReport(ctx context.Context, db *gorm.DB, report *ReconcileReport) error {
+	return r.queryDB(db).WithContext(ctx).Create(report).Error
+}
+
+func (r *Repository) UpdateReconcileReport(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&ReconcileReport{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *Repository) CreateReconcileItems(ctx context.Context, db *gorm.DB, items []ReconcileItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).CreateInBatches(items, 500).Error
+}
+
+func (r *Repository) ReconcileReportByNo(ctx context.Context, reportNo string) (ReconcileReport, error) {
+	var report ReconcileReport
+	err := r.db.WithContext(ctx).Where("report_no = ?", reportNo).First(&report).Error
+	return report, err
+}
+
+func (r *Repository) ListReconcileReports(ctx context.Context, limit, offset int) ([]ReconcileReport, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&ReconcileReport{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []ReconcileReport
+	if err := r.db.WithContext(ctx).Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+func (r *Repository) ListReconcileItems(ctx context.Context, filters ReconcileItemFilters, limit, offset int) ([]ReconcileItemRow, int64, error) {
+	var total int64
+	if err := r.applyReconcileItemFilters(r.db.WithContext(ctx).Table("instance_reconcile_items AS items"), filters).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []ReconcileItemRow
+	if err := r.applyReconcileItemFilters(r.reconcileItemQuery(ctx), filters).Order("items.node ASC, items.vmid ASC").Limit(limit).Offset(offset).Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+func (r *Repository) ReconcileItemRow(ctx context.Context, id uint64) (ReconcileItemRow, error) {
+	var row ReconcileItemRow
+	err := r.reconcileItemQuery(ctx).Where("items.id = ?", id).Take(&row).Error
+	return row, err
+}
+
+func (r *Repository) ReconcileItemForUpdate(ctx context.Context, db *gorm.DB, id uint64) (ReconcileItem, error) {
+	var item ReconcileItem
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&item).Error
+	return item, err
+}
+
+func (r *Repository) UpdateReconcileItem(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&ReconcileItem{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ReconcileInstances 返回全部未释放实例，供对账按节点和 VMID 比对上游 VM。
+func (r *Repository) ReconcileInstances(ctx context.Context) ([]Instance, error) {
+	var rows []Instance
+	err := r.db.WithContext(ctx).Where("status <> ?", "released").Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// AllMappings 返回全部交付映射（含停用），对账据此判断 VM 是否落在平台管理的 VMID 区间内。
+func (r *Repository) AllMappings(ctx context.Context) ([]ProvisionMapping, error) {
+	var rows []ProvisionMapping
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// InstanceByExternalVM 返回占用集群节点和 VMID 的未释放实例；已释放实例的 VMID 可被复用，不再占用。
+func (r *Repository) InstanceByExternalVM(ctx context.Context, db *gorm.DB, clusterNo string, node string, vmid uint) (Instance, error) {
+	var instance Instance
+	err := r.queryDB(db).WithContext(ctx).Where("cluster_no = ? AND external_node = ? AND external_vmid = ? AND status <> ?", clusterNo, node, vmid, "released").First(&instance).Error
+	return instance, err
+}
+
+func (r *Repository) reconcileItemQuery(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Table("instance_reconcile_items AS items").
+		Select("items.*, reports.report_no").
+		Joins("JOIN instance_reconcile_reports AS reports ON reports.id = items.report_id")
+}
+
+func (r *Repository) applyReconcileItemFilters(db *gorm.DB, filters ReconcileItemFilters) *gorm.DB {
+	db = db.Where("items.report_id = ?", filters.ReportID)
+	if filters.Kind != "" {
+		db = db.Where("items.kind = ?", filters.Kind)
+	}
+	if filters.Status != "" {
+		db = db.Where("items.status = ?", filters.Status)
+	}
+	return db
+}
@@ -2,11 +2,14 @@ package instance
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
 )
 
 type Repository struct{ db *gorm.DB }
@@ -16,9 +19,11 @@ type InstanceFilters struct {
 	Status      string
 	InstanceNo  string
 	OrderNo     string
+	ClusterNo   string
 	UserKeyword string
 	DateFrom    string
 	DateTo      string
+	Drifted     bool
 }
 
 type MappingFilters struct {
@@ -95,14 +100,100 @@ func (r *Repository) MappingForProvision(ctx context.Context, db *gorm.DB, planN
 	return mapping, err
 }
 
+// ActiveMappings 返回全部启用的交付映射，供容量统计按套餐和地域匹配候选节点。
+func (r *Repository) ActiveMappings(ctx context.Context) ([]ProvisionMapping, error) {
+	var rows []ProvisionMapping
+	err := r.db.WithContext(ctx).Where("status = ?", "active").Order("region_no ASC, id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// NodeAllocations 按集群内节点汇总未释放实例已分配的规格，并统计其中属于 userID 的实例数，供交付调度计算超分占用。
+func (r *Repository) NodeAllocations(ctx context.Context, clusterNo string, nodes []string, userID uint64) ([]NodeAllocation, error) {
+	var rows []NodeAllocation
+	if len(nodes) == 0 {
+		return rows, nil
+	}
+	err := r.db.WithContext(ctx).Table("instances").
+		Select("external_node AS node, COALESCE(SUM(cpu_cores), 0) AS cpu_cores, COALESCE(SUM(memory_mb), 0) AS memory_mb, "+
+			"COALESCE(SUM(system_disk_gb + data_disk_gb), 0) AS disk_gb, COUNT(*) AS instances, COALESCE(SUM(user_id = ?), 0) AS user_instances", userID).
+		Where("cluster_no = ? AND external_node IN ? AND status <> ?", clusterNo, nodes, "released").
+		Group("external_node").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// NodeInstances 返回位于集群节点上的未释放实例，供节点疏散逐台迁移。
+func (r *Repository) NodeInstances(ctx context.Context, clusterNo string, node string) ([]Instance, error) {
+	var rows []Instance
+	err := r.db.WithContext(ctx).Where("cluster_no = ? AND external_node = ? AND status <> ?", clusterNo, node, "released").Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// PlanTemplates 返回套餐当前可用于重装的系统模板，不要求套餐仍在售。
+func (r *Repository) PlanTemplates(ctx context.Context, planNo string) ([]PlanTemplate, error) {
+	var rows []PlanTemplate
+	err := r.planTemplateQuery(ctx, planNo).
+		Order("plan_templates.sort_order ASC, templates.sort_order ASC, templates.id ASC").
+		Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) PlanTemplate(ctx context.Context, planNo, templateNo string) (PlanTemplate, error) {
+	var row PlanTemplate
+	err := r.planTemplateQuery(ctx, planNo).Where("templates.template_no = ?", strings.TrimSpace(templateNo)).Take(&row).Error
+	return row, err
+}
+
 func (r *Repository) AdvanceMappingVMID(ctx context.Context, db *gorm.DB, id uint64, nextVMID uint) error {
 	return r.queryDB(db).WithContext(ctx).Model(&ProvisionMapping{}).Where("id = ?", id).Update("next_vmid", nextVMID).Error
 }
 
+// ActiveVMIDs 返回集群候选节点上未释放实例在区间内占用的 VMID。
+func (r *Repository) ActiveVMIDs(ctx context.Context, db *gorm.DB, clusterNo string, nodes []string, start, end uint) ([]uint, error) {
+	var vmids []uint
+	err := r.queryDB(db).WithContext(ctx).Model(&Instance{}).
+		Where("cluster_no = ? AND external_node IN ? AND external_vmid BETWEEN ? AND ? AND status <> ?", clusterNo, nodes, start, end, "released").
+		Distinct().Pluck("external_vmid", &vmids).Error
+	return vmids, err
+}
+
+// ReleasedVMIDs 按升序返回集群候选节点上已释放实例在区间内用过的 VMID，供映射复用；是否已被重新占用由调用方判断。
+func (r *Repository) ReleasedVMIDs(ctx context.Context, db *gorm.DB, clusterNo string, nodes []string, start, end uint) ([]uint, error) {
+	var vmids []uint
+	err := r.queryDB(db).WithContext(ctx).Model(&Instance{}).
+		Where("cluster_no = ? AND external_node IN ? AND external_vmid BETWEEN ? AND ? AND status = ?", clusterNo, nodes, start, end, "released").
+		Distinct().Order("external_vmid ASC").Pluck("external_vmid", &vmids).Error
+	return vmids, err
+}
+
+// ClusterInstanceCounts 按集群统计未释放实例数，供集群健康状态展示。
+func (r *Repository) ClusterInstanceCounts(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		ClusterNo string
+		Total     int64
+	}
+	if err := r.db.WithContext(ctx).Model(&Instance{}).Select("cluster_no, COUNT(*) AS total").Where("status <> ?", "released").Group("cluster_no").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ClusterNo] = row.Total
+	}
+	return counts, nil
+}
+
 func (r *Repository) CreateInstance(ctx context.Context, db *gorm.DB, instance *Instance) error {
 	return r.queryDB(db).WithContext(ctx).Create(instance).Error
 }
 
+// MarkRootPasswordRevealed 原子标记初始密码已查看；返回 0 表示没有可查看的密码或已被查看过。
+func (r *Repository) MarkRootPasswordRevealed(ctx context.Context, db *gorm.DB, id uint64, now time.Time) (int64, error) {
+	result := r.queryDB(db).WithContext(ctx).Model(&Instance{}).
+		Where("id = ? AND root_password_ciphertext IS NOT NULL AND root_password_revealed_at IS NULL", id).
+		Update("root_password_revealed_at", now)
+	return result.RowsAffected, result.Error
+}
+
 func (r *Repository) UpdateInstance(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
 	if len(updates) == 0 {
 		return nil
@@ -110,6 +201,12 @@ func (r *Repository) UpdateInstance(ctx context.Context, db *gorm.DB, id uint64,
 	return r.queryDB(db).WithContext(ctx).Model(&Instance{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// UnsuspendInstance 解除实例暂停并清空暂停来源和原因；VM 仍保持关机，由用户自行开机，下次同步按实际电源状态校正。
+func (r *Repository) UnsuspendInstance(ctx context.Context, db *gorm.DB, id uint64) error {
+	return r.queryDB(db).WithContext(ctx).Model(&Instance{}).Where("id = ? AND status = ?", id, "suspended").
+		Updates(map[string]any{"status": "stopped", "suspend_source": nil, "suspend_reason": nil, "suspended_at": nil}).Error
+}
+
 func (r *Repository) InstanceForUpdate(ctx context.Context, db *gorm.DB, instanceNo string) (Instance, error) {
 	var row Instance
 	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("instance_no = ?", instanceNo).First(&row).Error
@@ -181,6 +278,13 @@ func (r *Repository) LatestRunningOperationForUpdate(ctx context.Context, db *go
 	return op, err
 }
 
+// OperationByExternalID 按集群和上游 operation ID 查找实例操作并附带实例编号；上游 ID 只在集群内唯一。
+func (r *Repository) OperationByExternalID(ctx context.Context, clusterNo string, externalID string) (ExternalOperation, error) {
+	var row ExternalOperation
+	err := r.db.WithContext(ctx).Table("instance_operations").Select("instance_operations.*, instances.instance_no").Joins("JOIN instances ON instances.id = instance_operations.instance_id").Where("instances.cluster_no = ? AND instance_operations.external_operation_id = ?", clusterNo, externalID).Order("instance_operations.id DESC").Take(&row).Error
+	return row, err
+}
+
 func (r *Repository) Operations(ctx context.Context, instanceID uint64, limit int) ([]Operation, error) {
 	var rows []Operation
 	err := r.db.WithContext(ctx).Where("instance_id = ?", instanceID).Order("created_at DESC, id DESC").Limit(limit).Find(&rows).Error
@@ -195,6 +299,16 @@ func (r *Repository) CreateTaskIgnoreDuplicate(ctx context.Context, db *gorm.DB,
 	return r.queryDB(db).WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(task).Error
 }
 
+// EnqueueExpirySuspend 在到期时间投递到期暂停任务；幂等键含实例编号和到期时间，同一到期时间重复投递会被忽略。
+// 后台改到期时间、后台确认续费和支付续费共用这一入口，避免任务类型、幂等键和重试次数各自漂移。
+func (r *Repository) EnqueueExpirySuspend(ctx context.Context, db *gorm.DB, instanceNo string, expiresAt time.Time, payload string) error {
+	objectType := "instance"
+	objectNo := strings.TrimSpace(instanceNo)
+	idempotencyKey := "expiry_suspend:" + objectNo + ":" + expiresAt.Format(time.RFC3339Nano)
+	task := Task{TaskNo: fmt.Sprintf("TASK-%d", time.Now().UnixNano()+2), TaskType: domaininstance.TaskTypeExpirySuspend, IdempotencyKey: &idempotencyKey, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &objectNo, Payload: &payload, MaxAttempts: 10, ScheduledAt: expiresAt}
+	return r.CreateTaskIgnoreDuplicate(ctx, db, &task)
+}
+
 func (r *Repository) UpdateTask(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
 	if len(updates) == 0 {
 		return nil
@@ -280,6 +394,14 @@ func (r *Repository) NotificationByNo(ctx context.Context, notificationNo string
 	return notification, err
 }
 
+func (r *Repository) planTemplateQuery(ctx context.Context, planNo string) *gorm.DB {
+	return r.db.WithContext(ctx).Table("product_plans AS plans").
+		Select("templates.template_no, templates.code, templates.name, templates.os_family, templates.distribution, templates.version, templates.architecture, templates.summary").
+		Joins("JOIN plan_os_templates AS plan_templates ON plan_templates.plan_id = plans.id AND plan_templates.status = ?", "active").
+		Joins("JOIN server_os_templates AS templates ON templates.id = plan_templates.template_id AND templates.status = ? AND templates.visible = 1", "active").
+		Where("plans.plan_no = ?", strings.TrimSpace(planNo))
+}
+
 func (r *Repository) queryDB(db *gorm.DB) *gorm.DB {
 	if db != nil {
 		return db
@@ -319,6 +441,9 @@ func (r *Repository) applyInstanceFilters(db *gorm.DB, filters InstanceFilters)
 	if strings.TrimSpace(filters.OrderNo) != "" {
 		db = db.Where("instances.order_no = ?", strings.TrimSpace(filters.OrderNo))
 	}
+	if strings.TrimSpace(filters.ClusterNo) != "" {
+		db = db.Where("instances.cluster_no = ?", strings.TrimSpace(filters.ClusterNo))
+	}
 	if keyword := strings.TrimSpace(filters.UserKeyword); keyword != "" {
 		like := "%" + keyword + "%"
 		db = db.Where("users.username LIKE ? OR users.email LIKE ? OR users.display_name LIKE ?", like, like, like)
@@ -329,6 +454,9 @@ func (r *Repository) applyInstanceFilters(db *gorm.DB, filters InstanceFilters)
 	if strings.TrimSpace(filters.DateTo) != "" {
 		db = db.Where("instances.created_at <= ?", strings.TrimSpace(filters.DateTo))
 	}
+	if filters.Drifted {
+		db = db.Where("instances.config_drift IS NOT NULL")
+	}
 	return db
 }
 
@@ -76,6 +76,34 @@ func TestClaimTasksDoesNotDoubleClaimConcurrentWorkers(t *testing.T) {
 	}
 }
 
+func TestEnqueueExpirySuspendIgnoresDuplicateExpiry(t *testing.T) {
+	db := mysqltest.Open(t)
+	mysqltest.Exec(t, db, asyncTasksSchema)
+
+	repo := NewRepository(db)
+	expiresAt := time.Now().Add(24 * time.Hour).Truncate(time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if err := repo.EnqueueExpirySuspend(context.Background(), nil, " INS-suspend ", expiresAt, `{"instance_no":"INS-suspend"}`); err != nil {
+			t.Fatalf("enqueue expiry suspend %d: %v", i, err)
+		}
+	}
+
+	var tasks []Task
+	if err := db.Where("object_no = ?", "INS-suspend").Find(&tasks).Error; err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("same expiry should enqueue one suspend task, got %d", len(tasks))
+	}
+	task := tasks[0]
+	if task.TaskType != domaininstance.TaskTypeExpirySuspend || task.Status != domaininstance.TaskStatusPending || !task.ScheduledAt.Equal(expiresAt) {
+		t.Fatalf("suspend task mismatch: type=%s status=%s scheduled_at=%s", task.TaskType, task.Status, task.ScheduledAt)
+	}
+	if task.IdempotencyKey == nil || *task.IdempotencyKey != "expiry_suspend:INS-suspend:"+expiresAt.Format(time.RFC3339Nano) {
+		t.Fatalf("suspend task idempotency key mismatch: %v", task.IdempotencyKey)
+	}
+}
+
 const asyncTasksSchema = `
 CREATE TABLE async_tasks (
   id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
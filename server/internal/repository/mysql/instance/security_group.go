@@ -0,0 +1,159 @@
+package instance
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *Repository) SecurityGroups(ctx context.Context, userID uint64) ([]SecurityGroup, error) {
+	var rows []SecurityGroup
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) SecurityGroupByNo(ctx context.Context, userID uint64, groupNo string) (SecurityGroup, error) {
+	var group SecurityGroup
+	err := r.db.WithContext(ctx).Where("user_id = ? AND group_no = ?", userID, groupNo).First(&group).Error
+	return group, err
+}
+
+func (r *Repository) SecurityGroupForUpdate(ctx context.Context, db *gorm.DB, userID uint64, groupNo string) (SecurityGroup, error) {
+	var group SecurityGroup
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ? AND group_no = ?", userID, groupNo).First(&group).Error
+	return group, err
+}
+
+// SecurityGroupsByNos 返回用户名下指定编号的安全组，不存在或属于他人的编号直接忽略，由调用方比对数量。
+func (r *Repository) SecurityGroupsByNos(ctx context.Context, db *gorm.DB, userID uint64, groupNos []string) ([]SecurityGroup, error) {
+	var rows []SecurityGroup
+	if len(groupNos) == 0 {
+		return rows, nil
+	}
+	err := r.queryDB(db).WithContext(ctx).Where("user_id = ? AND group_no IN ?", userID, groupNos).Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) CountSecurityGroups(ctx context.Context, db *gorm.DB, userID uint64) (int64, error) {
+	var total int64
+	err := r.queryDB(db).WithContext(ctx).Model(&SecurityGroup{}).Where("user_id = ?", userID).Count(&total).Error
+	return total, err
+}
+
+func (r *Repository) CreateSecurityGroup(ctx context.Context, db *gorm.DB, group *SecurityGroup) error {
+	return r.queryDB(db).WithContext(ctx).Create(group).Error
+}
+
+func (r *Repository) UpdateSecurityGroup(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&SecurityGroup{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteSecurityGroup 删除安全组及其规则；调用方需先确认没有实例绑定。
+func (r *Repository) DeleteSecurityGroup(ctx context.Context, db *gorm.DB, id uint64) error {
+	if err := r.queryDB(db).WithContext(ctx).Where("security_group_id = ?", id).Delete(&SecurityGroupRule{}).Error; err != nil {
+		return err
+	}
+	return r.queryDB(db).WithContext(ctx).Where("id = ?", id).Delete(&SecurityGroup{}).Error
+}
+
+// SecurityGroupRules 按安全组和规则顺序返回多个安全组的规则。
+func (r *Repository) SecurityGroupRules(ctx context.Context, db *gorm.DB, groupIDs []uint64) ([]SecurityGroupRule, error) {
+	var rows []SecurityGroupRule
+	if len(groupIDs) == 0 {
+		return rows, nil
+	}
+	err := r.queryDB(db).WithContext(ctx).Where("security_group_id IN ?", groupIDs).Order("security_group_id ASC, sort_order ASC, id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// ReplaceSecurityGroupRules 用新的规则列表整体替换安全组规则。
+func (r *Repository) ReplaceSecurityGroupRules(ctx context.Context, db *gorm.DB, groupID uint64, rules []SecurityGroupRule) error {
+	if err := r.queryDB(db).WithContext(ctx).Where("security_group_id = ?", groupID).Delete(&SecurityGroupRule{}).Error; err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	for i := range rules {
+		rules[i].SecurityGroupID = groupID
+		rules[i].SortOrder = i
+	}
+	return r.queryDB(db).WithContext(ctx).Create(&rules).Error
+}
+
+// SecurityGroupAttachmentCounts 返回各安全组当前绑定的实例数量。
+func (r *Repository) SecurityGroupAttachmentCounts(ctx context.Context, db *gorm.DB, groupIDs []uint64) (map[uint64]int64, error) {
+	counts := make(map[uint64]int64, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		SecurityGroupID uint64
+		Total           int64
+	}
+	err := r.queryDB(db).WithContext(ctx).Model(&InstanceSecurityGroup{}).
+		Select("security_group_id, COUNT(*) AS total").
+		Where("security_group_id IN ?", groupIDs).
+		Group("security_group_id").
+		Scan(&rows).Error
+	for _, row := range rows {
+		counts[row.SecurityGroupID] = row.Total
+	}
+	return counts, err
+}
+
+// SecurityGroupInstances 返回绑定了指定安全组的实例，规则变更后据此逐台下发。
+func (r *Repository) SecurityGroupInstances(ctx context.Context, db *gorm.DB, groupID uint64) ([]Instance, error) {
+	var rows []Instance
+	err := r.queryDB(db).WithContext(ctx).
+		Joins("JOIN instance_security_groups ON instance_security_groups.instance_id = instances.id").
+		Where("instance_security_groups.security_group_id = ?", groupID).
+		Order("instances.id ASC").
+		Find(&rows).Error
+	return rows, err
+}
+
+// InstanceSecurityGroups 按绑定顺序返回实例绑定的安全组。
+func (r *Repository) InstanceSecurityGroups(ctx context.Context, db *gorm.DB, instanceID uint64) ([]SecurityGroup, error) {
+	var rows []SecurityGroup
+	err := r.queryDB(db).WithContext(ctx).
+		Joins("JOIN instance_security_groups ON instance_security_groups.security_group_id = security_groups.id").
+		Where("instance_security_groups.instance_id = ?", instanceID).
+		Order("instance_security_groups.sort_order ASC, instance_security_groups.id ASC").
+		Find(&rows).Error
+	return rows, err
+}
+
+// ReplaceInstanceSecurityGroups 用新的安全组列表整体替换实例绑定，列表顺序即规则下发顺序。
+func (r *Repository) ReplaceInstanceSecurityGroups(ctx context.Context, db *gorm.DB, instanceID uint64, groupIDs []uint64) error {
+	if err := r.DeleteInstanceSecurityGroups(ctx, db, instanceID); err != nil {
+		return err
+	}
+	if len(groupIDs) == 0 {
+		return nil
+	}
+	rows := make([]InstanceSecurityGroup, 0, len(groupIDs))
+	for i, groupID := range groupIDs {
+		rows = append(rows, InstanceSecurityGroup{InstanceID: instanceID, SecurityGroupID: groupID, SortOrder: i})
+	}
+	return r.queryDB(db).WithContext(ctx).Create(&rows).Error
+}
+
+func (r *Repository) DeleteInstanceSecurityGroups(ctx context.Context, db *gorm.DB, instanceID uint64) error {
+	return r.queryDB(db).WithContext(ctx).Where("instance_id = ?", instanceID).Delete(&InstanceSecurityGroup{}).Error
+}
+
+// InstanceFirewallRules 按绑定顺序和规则顺序返回实例生效的全部安全组规则。
+func (r *Repository) InstanceFirewallRules(ctx context.Context, db *gorm.DB, instanceID uint64) ([]SecurityGroupRule, error) {
+	var rows []SecurityGroupRule
+	err := r.queryDB(db).WithContext(ctx).
+		Joins("JOIN instance_security_groups ON instance_security_groups.security_group_id = security_group_rules.security_group_id").
+		Where("instance_security_groups.instance_id = ?", instanceID).
+		Order("instance_security_groups.sort_order ASC, security_group_rules.sort_order ASC, security_group_rules.id ASC").
+		Find(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,73 @@
+package instance
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+)
+
+func (r *Repository) CreateSnapshot(ctx context.Context, db *gorm.DB, snapshot *Snapshot) error {
+	return r.queryDB(db).WithContext(ctx).Create(snapshot).Error
+}
+
+func (r *Repository) UpdateSnapshot(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&Snapshot{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *Repository) UpdateSnapshotByNo(ctx context.Context, db *gorm.DB, snapshotNo string, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&Snapshot{}).Where("snapshot_no = ?", snapshotNo).Updates(updates).Error
+}
+
+func (r *Repository) SnapshotForUpdate(ctx context.Context, db *gorm.DB, instanceID uint64, snapshotNo string) (Snapshot, error) {
+	var row Snapshot
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("instance_id = ? AND snapshot_no = ? AND status <> ?", instanceID, snapshotNo, domaininstance.SnapshotStatusDeleted).
+		First(&row).Error
+	return row, err
+}
+
+// Snapshots 返回实例未删除的快照，按创建时间倒序。
+func (r *Repository) Snapshots(ctx context.Context, instanceID uint64) ([]Snapshot, error) {
+	var rows []Snapshot
+	err := r.db.WithContext(ctx).Where("instance_id = ? AND status <> ?", instanceID, domaininstance.SnapshotStatusDeleted).Order("id DESC").Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) CountQuotaSnapshots(ctx context.Context, db *gorm.DB, instanceID uint64) (int64, error) {
+	var total int64
+	err := r.queryDB(db).WithContext(ctx).Model(&Snapshot{}).Where("instance_id = ? AND status IN ?", instanceID, domaininstance.SnapshotStatusesInQuota()).Count(&total).Error
+	return total, err
+}
+
+// PlanSnapshotQuota 读取套餐当前的快照配额，套餐被删除或不存在时按 0 处理。
+func (r *Repository) PlanSnapshotQuota(ctx context.Context, db *gorm.DB, planNo string) (int, error) {
+	var quotas []int
+	err := r.queryDB(db).WithContext(ctx).Table("product_plans").Where("plan_no = ?", planNo).Limit(1).Pluck("snapshot_quota", &quotas).Error
+	if err != nil || len(quotas) == 0 {
+		return 0, err
+	}
+	return quotas[0], nil
+}
+
+func (r *Repository) SnapshotByNo(ctx context.Context, snapshotNo string) (Snapshot, error) {
+	var row Snapshot
+	err := r.db.WithContext(ctx).Where("snapshot_no = ?", snapshotNo).First(&row).Error
+	return row, err
+}
+
+// MarkInstanceSnapshotsDeleted 在实例释放后标记全部快照删除，PVE 会随 VM 一并销毁快照。
+func (r *Repository) MarkInstanceSnapshotsDeleted(ctx context.Context, db *gorm.DB, instanceID uint64, deletedAt time.Time) error {
+	return r.queryDB(db).WithContext(ctx).Model(&Snapshot{}).
+		Where("instance_id = ? AND status <> ?", instanceID, domaininstance.SnapshotStatusDeleted).
+		Updates(map[string]any{"status": domaininstance.SnapshotStatusDeleted, "deleted_at": deletedAt}).Error
+}
@@ -0,0 +1,55 @@
+package instance
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MeteredInstances 返回需要采样流量的实例：已交付、未释放且记录了节点和 VMID。
+func (r *Repository) MeteredInstances(ctx context.Context) ([]Instance, error) {
+	var rows []Instance
+	err := r.db.WithContext(ctx).
+		Where("status <> ? AND external_vmid > 0 AND service_started_at IS NOT NULL", "released").
+		Order("external_node ASC, id ASC").
+		Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) InstanceByID(ctx context.Context, db *gorm.DB, id uint64) (Instance, error) {
+	var instance Instance
+	err := r.queryDB(db).WithContext(ctx).Where("id = ?", id).First(&instance).Error
+	return instance, err
+}
+
+func (r *Repository) CreateTrafficUsage(ctx context.Context, db *gorm.DB, usage *TrafficUsage) error {
+	return r.queryDB(db).WithContext(ctx).Create(usage).Error
+}
+
+func (r *Repository) UpdateTrafficUsage(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&TrafficUsage{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *Repository) TrafficUsageForUpdate(ctx context.Context, db *gorm.DB, instanceID uint64, period string) (TrafficUsage, error) {
+	var usage TrafficUsage
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("instance_id = ? AND period = ?", instanceID, period).First(&usage).Error
+	return usage, err
+}
+
+// LatestTrafficUsage 返回实例最近一个计费月的流量记录，新月份据此继承计数器基线。
+func (r *Repository) LatestTrafficUsage(ctx context.Context, db *gorm.DB, instanceID uint64) (TrafficUsage, error) {
+	var usage TrafficUsage
+	err := r.queryDB(db).WithContext(ctx).Where("instance_id = ?", instanceID).Order("period DESC").First(&usage).Error
+	return usage, err
+}
+
+// TrafficUsages 按计费月倒序返回实例最近若干个月的流量记录。
+func (r *Repository) TrafficUsages(ctx context.Context, instanceID uint64, limit int) ([]TrafficUsage, error) {
+	var rows []TrafficUsage
+	err := r.db.WithContext(ctx).Where("instance_id = ?", instanceID).Order("period DESC").Limit(limit).Find(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,165 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
+)
+
+const transferCancelReason = "实例所有权已转移"
+
+type InstanceTransferFilters struct {
+	// UserID 非零时只返回该用户发起或接收的记录，Direction 可进一步限定为 outgoing 或 incoming。
+	UserID    uint64
+	Direction string
+	Status    string
+	Keyword   string
+}
+
+func (r *Repository) CreateInstanceTransfer(ctx context.Context, db *gorm.DB, transfer *InstanceTransfer) error {
+	return r.queryDB(db).WithContext(ctx).Create(transfer).Error
+}
+
+func (r *Repository) UpdateInstanceTransfer(ctx context.Context, db *gorm.DB, id uint64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.queryDB(db).WithContext(ctx).Model(&InstanceTransfer{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *Repository) InstanceTransferForUpdate(ctx context.Context, db *gorm.DB, transferNo string) (InstanceTransfer, error) {
+	var row InstanceTransfer
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("transfer_no = ?", transferNo).First(&row).Error
+	return row, err
+}
+
+// PendingInstanceTransfers 返回实例仍待确认的转移请求；调用方需先锁定实例行。
+func (r *Repository) PendingInstanceTransfers(ctx context.Context, db *gorm.DB, instanceID uint64) ([]InstanceTransfer, error) {
+	var rows []InstanceTransfer
+	err := r.queryDB(db).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("instance_id = ? AND status = ?", instanceID, domaininstance.TransferStatusPending).
+		Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) InstanceTransferRow(ctx context.Context, transferNo string) (InstanceTransferRow, error) {
+	var row InstanceTransferRow
+	err := r.transferRows(r.db.WithContext(ctx)).Where("instance_transfers.transfer_no = ?", transferNo).Take(&row).Error
+	return row, err
+}
+
+func (r *Repository) ListInstanceTransfers(ctx context.Context, filters InstanceTransferFilters, limit, offset int) ([]InstanceTransferRow, int64, error) {
+	query := r.applyTransferFilters(r.db.WithContext(ctx).Model(&InstanceTransfer{}), filters)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []InstanceTransferRow
+	err := r.applyTransferFilters(r.transferRows(r.db.WithContext(ctx)), filters).
+		Order("instance_transfers.created_at DESC, instance_transfers.id DESC").Limit(limit).Offset(offset).Find(&rows).Error
+	return rows, total, err
+}
+
+// MoveInstanceOwner 把实例及其随实例流转的数据改归 toUserID：原所有者待支付的续费和变更套餐订单被取消，
+// 原所有者关联该实例的工单转给新所有者，PTR 记录改归新所有者；安全组属于原所有者账号，绑定关系被解除，
+// 并把防火墙标记为待下发，由调用方在事务提交后按空安全组重新下发。原所有者知道的 root 密码随之作废：
+// 清除密码密文和查看标记，并投递 root 密码重置任务，重置成功后新所有者可查看一次新密码。
+func (r *Repository) MoveInstanceOwner(ctx context.Context, db *gorm.DB, row Instance, toUserID uint64, transferNo string, now time.Time) (TransferResult, error) {
+	query := r.queryDB(db).WithContext(ctx)
+	var result TransferResult
+	if err := query.Table("orders").
+		Where("user_id = ? AND related_instance_no = ? AND status = ? AND order_type IN ?", row.UserID, row.InstanceNo, domainorder.StatusPending, []string{domainorder.TypeRenewal, domainorder.TypeChangePlan}).
+		Pluck("order_no", &result.CancelledOrderNos).Error; err != nil {
+		return TransferResult{}, err
+	}
+	if len(result.CancelledOrderNos) > 0 {
+		if err := query.Table("orders").Where("order_no IN ? AND status = ?", result.CancelledOrderNos, domainorder.StatusPending).
+			Updates(map[string]any{"status": domainorder.StatusCancelled, "cancel_reason": transferCancelReason, "cancelled_at": now}).Error; err != nil {
+			return TransferResult{}, err
+		}
+	}
+	var groups int64
+	if err := query.Model(&InstanceSecurityGroup{}).Where("instance_id = ?", row.ID).Count(&groups).Error; err != nil {
+		return TransferResult{}, err
+	}
+	updates := map[string]any{"user_id": toUserID, "root_password_ciphertext": nil, "root_password_revealed_at": nil}
+	if groups > 0 {
+		if err := query.Where("instance_id = ?", row.ID).Delete(&InstanceSecurityGroup{}).Error; err != nil {
+			return TransferResult{}, err
+		}
+		updates["firewall_status"] = domaininstance.FirewallStatusPending
+		result.DetachedGroups = true
+	}
+	if err := query.Model(&Instance{}).Where("id = ?", row.ID).Updates(updates).Error; err != nil {
+		return TransferResult{}, err
+	}
+	if err := query.Model(&PTRRecord{}).Where("instance_id = ?", row.ID).Update("user_id", toUserID).Error; err != nil {
+		return TransferResult{}, err
+	}
+	moved := query.Table("tickets").Where("instance_id = ? AND user_id = ?", row.ID, row.UserID).Update("user_id", toUserID)
+	if moved.Error != nil {
+		return TransferResult{}, moved.Error
+	}
+	result.MovedTickets = moved.RowsAffected
+	data, _ := json.Marshal(map[string]any{"instance_no": row.InstanceNo, "user_id": toUserID})
+	payload := string(data)
+	objectType := "instance"
+	key := domaininstance.TaskTypeCredentialReset + ":" + transferNo
+	task := Task{TaskNo: "TASK-CREDENTIAL-" + transferNo, TaskType: domaininstance.TaskTypeCredentialReset, IdempotencyKey: &key, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &row.InstanceNo, Payload: &payload, MaxAttempts: 10, ScheduledAt: now}
+	if err := r.CreateTaskIgnoreDuplicate(ctx, db, &task); err != nil {
+		return TransferResult{}, err
+	}
+	return result, nil
+}
+
+// EnqueueEmailNotification 创建邮件通知及其发送任务；通知编号重复时不重复创建。
+func (r *Repository) EnqueueEmailNotification(ctx context.Context, db *gorm.DB, notification Notification) error {
+	taskNo := "TASK-" + notification.NotificationNo
+	notification.Channel = domaininstance.NotificationChannelEmail
+	notification.Status = domaininstance.NotificationStatusPending
+	notification.TaskNo = &taskNo
+	if err := r.CreateNotificationIgnoreDuplicate(ctx, db, &notification); err != nil {
+		return err
+	}
+	data, _ := json.Marshal(map[string]string{"notification_no": notification.NotificationNo})
+	payload := string(data)
+	objectType := "notification"
+	idempotencyKey := "notification_send:" + notification.NotificationNo
+	task := Task{TaskNo: taskNo, TaskType: domaininstance.TaskTypeEmailSend, IdempotencyKey: &idempotencyKey, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &notification.NotificationNo, Payload: &payload, MaxAttempts: 10, ScheduledAt: time.Now()}
+	return r.CreateTaskIgnoreDuplicate(ctx, db, &task)
+}
+
+func (r *Repository) transferRows(db *gorm.DB) *gorm.DB {
+	return db.Table("instance_transfers").
+		Select("instance_transfers.*, from_users.username AS from_username, to_users.username AS to_username").
+		Joins("JOIN users AS from_users ON from_users.id = instance_transfers.from_user_id").
+		Joins("JOIN users AS to_users ON to_users.id = instance_transfers.to_user_id")
+}
+
+func (r *Repository) applyTransferFilters(db *gorm.DB, filters InstanceTransferFilters) *gorm.DB {
+	if filters.UserID != 0 {
+		switch filters.Direction {
+		case "outgoing":
+			db = db.Where("instance_transfers.from_user_id = ?", filters.UserID)
+		case "incoming":
+			db = db.Where("instance_transfers.to_user_id = ?", filters.UserID)
+		default:
+			db = db.Where("instance_transfers.from_user_id = ? OR instance_transfers.to_user_id = ?", filters.UserID, filters.UserID)
+		}
+	}
+	if status := strings.TrimSpace(filters.Status); status != "" {
+		db = db.Where("instance_transfers.status = ?", status)
+	}
+	if keyword := strings.TrimSpace(filters.Keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		db = db.Where("instance_transfers.transfer_no LIKE ? OR instance_transfers.instance_no LIKE ?", like, like)
+	}
+	return db
+}
@@ -213,6 +213,7 @@ func (r *Repository) eligibleBaseQuery(ctx context.Context) *gorm.DB {
 		Table("orders").
 		Where("orders.currency = ?", "CNY").
 		Where("orders.payment_status IN ?", []string{"paid", "manual_confirmed"}).
+		Where("orders.total_amount_cents > 0").
 		Where("orders.status NOT IN ?", []string{"cancelled", "closed"}).
 		Where(`NOT EXISTS (
 			SELECT 1 FROM invoice_application_orders iao
@@ -10,6 +10,9 @@ type Order struct {
 	Status                 string     `gorm:"column:status"`
 	OrderType              string     `gorm:"column:order_type"`
 	RelatedInstanceNo      *string    `gorm:"column:related_instance_no"`
+	ChangeFromPlanNo       *string    `gorm:"column:change_from_plan_no"`
+	SourceBackupNo         *string    `gorm:"column:source_backup_no"`
+	SSHKeys                *string    `gorm:"column:ssh_keys"`
 	ProductNo              string     `gorm:"column:product_no"`
 	ProductType            string     `gorm:"column:product_type"`
 	ProductName            string     `gorm:"column:product_name"`
@@ -33,6 +36,7 @@ type Order struct {
 	Currency               string     `gorm:"column:currency"`
 	Quantity               int        `gorm:"column:quantity"`
 	TotalAmountCents       uint64     `gorm:"column:total_amount_cents"`
+	CreditAmountCents      uint64     `gorm:"column:credit_amount_cents"`
 	PaymentStatus          string     `gorm:"column:payment_status"`
 	PaidAt                 *time.Time `gorm:"column:paid_at"`
 	PaymentProvider        *string    `gorm:"column:payment_provider"`
@@ -100,6 +104,15 @@ type CatalogSelection struct {
 	OSArchitecture     string
 }
 
+// RestorableBackup 是新购订单可选的恢复来源备份，规格和系统模板用于校验目标套餐能否承载。
+type RestorableBackup struct {
+	BackupNo     string
+	Status       string
+	SystemDiskGB int
+	DataDiskGB   int
+	TemplateNo   string
+}
+
 type RenewalQuote struct {
 	OrderType          string
 	RelatedInstanceNo  string
@@ -44,6 +44,17 @@ func (r *Repository) CatalogSelection(ctx context.Context, planNo, cycle, region
 	return row, err
 }
 
+// UserBackup 返回属于用户实例的未删除备份；实例释放后备份仍归原用户所有。
+func (r *Repository) UserBackup(ctx context.Context, userID uint64, backupNo string) (RestorableBackup, error) {
+	var row RestorableBackup
+	err := r.db.WithContext(ctx).Table("instance_backups AS backups").
+		Select("backups.backup_no, backups.status, backups.system_disk_gb, backups.data_disk_gb, backups.template_no").
+		Joins("JOIN instances ON instances.id = backups.instance_id").
+		Where("backups.backup_no = ? AND backups.status <> ? AND instances.user_id = ?", backupNo, "deleted", userID).
+		Take(&row).Error
+	return row, err
+}
+
 func (r *Repository) Create(ctx context.Context, db *gorm.DB, order *Order) error {
 	return r.queryDB(db).WithContext(ctx).Create(order).Error
 }
@@ -78,6 +89,27 @@ func (r *Repository) LatestRenewalByInstanceNo(ctx context.Context, instanceNo s
 	return order, err
 }
 
+// LatestUserRenewalByInstanceNo 只返回指定用户名下的最近续费订单；实例转移后新所有者看不到原所有者的续费订单。
+func (r *Repository) LatestUserRenewalByInstanceNo(ctx context.Context, userID uint64, instanceNo string) (Order, error) {
+	var order Order
+	err := r.db.WithContext(ctx).Where("user_id = ? AND order_type = ? AND related_instance_no = ?", userID, "renewal", instanceNo).Order("created_at DESC, id DESC").First(&order).Error
+	return order, err
+}
+
+// ActiveChangePlanByInstanceNo 返回实例尚未结束的变更套餐订单，用于阻止同一实例并发变更。
+func (r *Repository) ActiveChangePlanByInstanceNo(ctx context.Context, db *gorm.DB, instanceNo string) (Order, error) {
+	var order Order
+	err := r.queryDB(db).WithContext(ctx).Where("order_type = ? AND related_instance_no = ? AND status IN ?", "change_plan", instanceNo, []string{"pending", "provisioning"}).Order("id DESC").First(&order).Error
+	return order, err
+}
+
+// PendingRenewalByInstanceNo 返回实例待支付的续费订单；续费按当前套餐计价，存在时不能变更套餐。
+func (r *Repository) PendingRenewalByInstanceNo(ctx context.Context, db *gorm.DB, instanceNo string) (Order, error) {
+	var order Order
+	err := r.queryDB(db).WithContext(ctx).Where("order_type = ? AND related_instance_no = ? AND status = ?", "renewal", instanceNo, "pending").Order("id DESC").First(&order).Error
+	return order, err
+}
+
 func (r *Repository) UserOrder(ctx context.Context, userID uint64, orderNo string) (Order, error) {
 	var order Order
 	err := r.db.WithContext(ctx).Where("user_id = ? AND order_no = ?", userID, orderNo).First(&order).Error
@@ -71,3 +71,25 @@ type UserPasswordResetToken struct {
 func (UserPasswordResetToken) TableName() string {
 	return "user_password_reset_tokens"
 }
+
+/**
+ * UserSSHKey 映射 user_ssh_keys 用户 SSH 公钥表。
+ */
+type UserSSHKey struct {
+	ID          uint64    `gorm:"column:id;primaryKey"`
+	KeyNo       string    `gorm:"column:key_no"`
+	UserID      uint64    `gorm:"column:user_id"`
+	Name        string    `gorm:"column:name"`
+	KeyType     string    `gorm:"column:key_type"`
+	PublicKey   string    `gorm:"column:public_key"`
+	Fingerprint string    `gorm:"column:fingerprint"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at"`
+}
+
+/**
+ * TableName 返回用户 SSH 公钥表名。
+ */
+func (UserSSHKey) TableName() string {
+	return "user_ssh_keys"
+}
@@ -324,6 +324,33 @@ func (r *Repository) UpdatePasswordResetTokenState(ctx context.Context, db *gorm
 		Updates(updates).Error
 }
 
+func (r *Repository) UserSSHKeys(ctx context.Context, userID uint64) ([]UserSSHKey, error) {
+	var rows []UserSSHKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) UserSSHKeysByNos(ctx context.Context, db *gorm.DB, userID uint64, keyNos []string) ([]UserSSHKey, error) {
+	var rows []UserSSHKey
+	err := r.queryDB(db).WithContext(ctx).Where("user_id = ? AND key_no IN ?", userID, keyNos).Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) CountUserSSHKeys(ctx context.Context, db *gorm.DB, userID uint64) (int64, error) {
+	var total int64
+	err := r.queryDB(db).WithContext(ctx).Model(&UserSSHKey{}).Where("user_id = ?", userID).Count(&total).Error
+	return total, err
+}
+
+func (r *Repository) CreateUserSSHKey(ctx context.Context, db *gorm.DB, key *UserSSHKey) error {
+	return r.queryDB(db).WithContext(ctx).Create(key).Error
+}
+
+func (r *Repository) DeleteUserSSHKey(ctx context.Context, db *gorm.DB, userID uint64, keyNo string) (int64, error) {
+	result := r.queryDB(db).WithContext(ctx).Where("user_id = ? AND key_no = ?", userID, keyNo).Delete(&UserSSHKey{})
+	return result.RowsAffected, result.Error
+}
+
 func (r *Repository) applyUserListFilters(db *gorm.DB, filters UserListFilters) *gorm.DB {
 	if strings.TrimSpace(filters.Keyword) != "" {
 		keyword := "%" + strings.TrimSpace(filters.Keyword) + "%"
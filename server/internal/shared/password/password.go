@@ -1,6 +1,12 @@
 package password
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 /**
  * Hash 使用 bcrypt 生成密码哈希。
@@ -27,3 +33,47 @@ func Hash(plain string) (string, error) {
 func Verify(hash string, plain string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(plain)) == nil
 }
+
+// generateClasses 是生成随机密码使用的字符类别，去掉了易混淆字符和 shell、YAML 中需要转义的符号。
+var generateClasses = []string{
+	"ABCDEFGHJKLMNPQRSTUVWXYZ",
+	"abcdefghijkmnpqrstuvwxyz",
+	"23456789",
+	"@%+=-_.",
+}
+
+/**
+ * Generate 使用 crypto/rand 生成随机密码，保证包含大小写字母、数字和符号各至少一个。
+ *
+ * @param length 密码长度，小于 12 时按 12 处理
+ * @return string 随机密码
+ * @return error 随机数生成失败原因
+ */
+func Generate(length int) (string, error) {
+	if length < 12 {
+		length = 12
+	}
+	alphabet := strings.Join(generateClasses, "")
+	out := make([]byte, length)
+	for i := range out {
+		source := alphabet
+		if i < len(generateClasses) {
+			source = generateClasses[i]
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(source))))
+		if err != nil {
+			return "", err
+		}
+		out[i] = source[n.Int64()]
+	}
+	// 打乱位置，避免前几位固定为各字符类别。
+	for i := len(out) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		j := n.Int64()
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out), nil
+}
@@ -0,0 +1,23 @@
+package password
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateContainsEveryCharacterClass(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		plain, err := Generate(8)
+		if err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		if len(plain) != 12 {
+			t.Fatalf("short length should be raised to 12, got %d", len(plain))
+		}
+		for _, class := range generateClasses {
+			if !strings.ContainsAny(plain, class) {
+				t.Fatalf("password %q misses class %q", plain, class)
+			}
+		}
+	}
+}
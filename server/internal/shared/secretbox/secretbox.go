@@ -0,0 +1,90 @@
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// version 是密文前缀，轮换算法或密钥派生方式时递增，便于兼容旧数据。
+const version = "v1:"
+
+var (
+	ErrEmptyKey  = errors.New("加密密钥不能为空")
+	ErrMalformed = errors.New("密文格式不正确或已被篡改")
+)
+
+/**
+ * Box 使用 AES-256-GCM 加解密需要落库的短敏感字段，例如实例 root 密码。
+ */
+type Box struct {
+	aead cipher.AEAD
+}
+
+/**
+ * New 按配置密钥创建加解密器，密钥经 SHA-256 派生为 256 位 AES 密钥。
+ *
+ * @param key 配置中的加密密钥
+ * @return *Box 加解密器
+ * @return error 密钥为空时返回 ErrEmptyKey
+ */
+func New(key string) (*Box, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, ErrEmptyKey
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+/**
+ * Seal 加密明文，返回带版本前缀的 base64 密文；每次加密使用随机 nonce。
+ *
+ * @param plain 明文
+ * @return string 密文
+ * @return error 随机数生成失败原因
+ */
+func (b *Box) Seal(plain string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return version + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+/**
+ * Open 解密 Seal 生成的密文。
+ *
+ * @param sealed 带版本前缀的密文
+ * @return string 明文
+ * @return error 格式错误、密钥不匹配或密文被篡改时返回 ErrMalformed
+ */
+func (b *Box) Open(sealed string) (string, error) {
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(sealed), version)
+	if !ok {
+		return "", ErrMalformed
+	}
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", ErrMalformed
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plain, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,36 @@
+package secretbox
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSealOpenRoundTripAndRejectsTampering(t *testing.T) {
+	box, err := New("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("new box: %v", err)
+	}
+	sealed, err := box.Seal("Root-Pa55word")
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if plain, err := box.Open(sealed); err != nil || plain != "Root-Pa55word" {
+		t.Fatalf("open should return original plain text, got %q %v", plain, err)
+	}
+	other, _ := New("fedcba9876543210fedcba9876543210")
+	if _, err := other.Open(sealed); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("open with another key should fail, got %v", err)
+	}
+	tampered := []byte(sealed)
+	if tampered[len(tampered)-5] == 'A' {
+		tampered[len(tampered)-5] = 'B'
+	} else {
+		tampered[len(tampered)-5] = 'A'
+	}
+	if _, err := box.Open(string(tampered)); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("tampered cipher text should fail, got %v", err)
+	}
+	if _, err := New("  "); !errors.Is(err, ErrEmptyKey) {
+		t.Fatalf("empty key should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package sshkey
+
+import (
+	"crypto/rsa"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// minRSABits 是允许录入的 RSA 公钥最小长度。
+const minRSABits = 2048
+
+var (
+	ErrInvalid     = errors.New("SSH 公钥格式不正确")
+	ErrUnsupported = errors.New("SSH 公钥类型不支持")
+	ErrWeak        = errors.New("RSA 公钥长度不能小于 2048 位")
+)
+
+/**
+ * PublicKey 是校验后的 SSH 公钥。
+ */
+type PublicKey struct {
+	// Type 为公钥算法，如 ssh-ed25519。
+	Type string
+	// AuthorizedKey 为规范化后的 authorized_keys 单行内容，保留原注释。
+	AuthorizedKey string
+	// Comment 为公钥注释，可能为空。
+	Comment string
+	// Fingerprint 为 OpenSSH SHA256 指纹，格式 SHA256:...。
+	Fingerprint string
+}
+
+/**
+ * Parse 校验单行 OpenSSH 公钥并计算指纹。
+ *
+ * @param raw authorized_keys 格式的单个公钥，不允许携带 options
+ * @return PublicKey 规范化后的公钥
+ * @return error 格式错误、算法不支持或 RSA 长度不足
+ */
+func Parse(raw string) (PublicKey, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.ContainsAny(raw, "\r\n") {
+		return PublicKey{}, ErrInvalid
+	}
+	key, comment, options, rest, err := ssh.ParseAuthorizedKey([]byte(raw))
+	if err != nil || len(options) > 0 || len(strings.TrimSpace(string(rest))) > 0 {
+		return PublicKey{}, ErrInvalid
+	}
+	switch key.Type() {
+	case ssh.KeyAlgoED25519, ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521, ssh.KeyAlgoSKED25519, ssh.KeyAlgoSKECDSA256:
+	case ssh.KeyAlgoRSA:
+		if !strongRSA(key) {
+			return PublicKey{}, ErrWeak
+		}
+	default:
+		return PublicKey{}, ErrUnsupported
+	}
+	authorized := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+	comment = strings.TrimSpace(comment)
+	if comment != "" {
+		authorized += " " + comment
+	}
+	return PublicKey{Type: key.Type(), AuthorizedKey: authorized, Comment: comment, Fingerprint: ssh.FingerprintSHA256(key)}, nil
+}
+
+/**
+ * Merge 合并多组 authorized_keys 内容，按公钥本体去重并保持首次出现顺序。
+ *
+ * @param groups 每组为换行分隔的公钥列表
+ * @return string 合并后的换行分隔公钥，没有公钥时为空字符串
+ */
+func Merge(groups ...string) string {
+	seen := map[string]bool{}
+	lines := []string{}
+	for _, group := range groups {
+		for _, line := range strings.Split(group, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			fields := strings.Fields(line)
+			identity := line
+			if len(fields) >= 2 {
+				identity = fields[0] + " " + fields[1]
+			}
+			if seen[identity] {
+				continue
+			}
+			seen[identity] = true
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func strongRSA(key ssh.PublicKey) bool {
+	cryptoKey, ok := key.(ssh.CryptoPublicKey)
+	if !ok {
+		return false
+	}
+	rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey)
+	return ok && rsaKey.N.BitLen() >= minRSABits
+}
@@ -0,0 +1,56 @@
+package sshkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseNormalizesKeyAndRejectsWeakOrMultiline(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519: %v", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("wrap ed25519: %v", err)
+	}
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub)))
+	key, err := Parse("  " + line + "  alice@laptop ")
+	if err != nil {
+		t.Fatalf("parse ed25519: %v", err)
+	}
+	if key.Type != ssh.KeyAlgoED25519 || key.Comment != "alice@laptop" || key.AuthorizedKey != line+" alice@laptop" || key.Fingerprint != ssh.FingerprintSHA256(sshPub) {
+		t.Fatalf("unexpected parsed key: %#v", key)
+	}
+
+	if _, err := Parse(line + "\n" + line); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("multiple keys should be rejected, got %v", err)
+	}
+	if _, err := Parse(`command="ls" ` + line); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("keys with options should be rejected, got %v", err)
+	}
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate rsa: %v", err)
+	}
+	weakPub, _ := ssh.NewPublicKey(&weak.PublicKey)
+	if _, err := Parse(string(ssh.MarshalAuthorizedKey(weakPub))); !errors.Is(err, ErrWeak) {
+		t.Fatalf("1024-bit rsa should be rejected, got %v", err)
+	}
+}
+
+func TestMergeDeduplicatesByKeyBody(t *testing.T) {
+	merged := Merge("ssh-ed25519 AAAA user@a\n\nssh-ed25519 BBBB", "ssh-ed25519 AAAA ops@b\nssh-rsa CCCC ops")
+	if merged != "ssh-ed25519 AAAA user@a\nssh-ed25519 BBBB\nssh-rsa CCCC ops" {
+		t.Fatalf("unexpected merge result: %q", merged)
+	}
+	if Merge("", " ") != "" {
+		t.Fatal("empty groups should merge to empty string")
+	}
+}
@@ -0,0 +1,65 @@
+package dto
+
+import "time"
+
+// CapacityResponse 是各地域候选节点容量和各套餐可交付情况的快照。
+type CapacityResponse struct {
+	Ratios      InstancePlacementRatios `json:"ratios"`
+	Regions     []CapacityRegion        `json:"regions"`
+	Plans       []CapacityPlan          `json:"plans"`
+	GeneratedAt time.Time               `json:"generated_at"`
+}
+
+// CapacityRegion 汇总地域内全部候选节点的容量；上限均为物理容量乘以超分比例。
+type CapacityRegion struct {
+	RegionNo          string         `json:"region_no"`
+	ClusterNo         string         `json:"cluster_no"`
+	CPULimit          float64        `json:"cpu_limit"`
+	CommittedCPU      int            `json:"committed_cpu"`
+	MemoryLimitMB     float64        `json:"memory_limit_mb"`
+	CommittedMemoryMB int64          `json:"committed_memory_mb"`
+	StorageLimitGB    float64        `json:"storage_limit_gb"`
+	CommittedDiskGB   int64          `json:"committed_disk_gb"`
+	Instances         int            `json:"instances"`
+	Nodes             []CapacityNode `json:"nodes"`
+}
+
+type CapacityNode struct {
+	Node              string  `json:"node"`
+	Storage           string  `json:"storage"`
+	Online            bool    `json:"online"`
+	MaxCPU            int     `json:"max_cpu"`
+	CPUUsage          float64 `json:"cpu_usage"`
+	CPULimit          float64 `json:"cpu_limit"`
+	CommittedCPU      int     `json:"committed_cpu"`
+	MemoryTotalMB     int64   `json:"memory_total_mb"`
+	MemoryUsedMB      int64   `json:"memory_used_mb"`
+	MemoryLimitMB     float64 `json:"memory_limit_mb"`
+	CommittedMemoryMB int64   `json:"committed_memory_mb"`
+	StorageTotalGB    int64   `json:"storage_total_gb"`
+	StorageUsedGB     int64   `json:"storage_used_gb"`
+	StorageLimitGB    float64 `json:"storage_limit_gb"`
+	CommittedDiskGB   int64   `json:"committed_disk_gb"`
+	Instances         int     `json:"instances"`
+}
+
+// CapacityPlan 是套餐在各在售地域能否再交付一台实例的判断结果。
+type CapacityPlan struct {
+	PlanNo            string               `json:"plan_no"`
+	PlanName          string               `json:"plan_name"`
+	Status            string               `json:"status"`
+	CapacitySoldOutAt *time.Time           `json:"capacity_sold_out_at"`
+	CPUCores          int                  `json:"cpu_cores"`
+	MemoryMB          int                  `json:"memory_mb"`
+	DiskGB            int                  `json:"disk_gb"`
+	Available         bool                 `json:"available"`
+	Regions           []CapacityPlanRegion `json:"regions"`
+}
+
+// CapacityPlanRegion 中 Node 为可放置的节点；不可交付时 Reason 给出各候选节点的裁决原因。
+type CapacityPlanRegion struct {
+	RegionNo  string  `json:"region_no"`
+	Available bool    `json:"available"`
+	Node      *string `json:"node"`
+	Reason    *string `json:"reason"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+// MCPClusterQuery 指定节点、虚拟机和存储查询所在的 PVE 集群；为空时查询默认集群。
+type MCPClusterQuery struct {
+	ClusterNo string `form:"cluster_no" validate:"omitempty,max=32"`
+}
+
+// MCPCluster 是已登记 PVE 集群的健康状态。Status 为 online 表示 MCP 接口可达，unreachable 表示探测失败并在 Error 给出原因，
+// disabled 表示虚拟化接口未启用；Regions 是绑定该集群的售卖地域，Instances 是集群内未释放实例数。
+type MCPCluster struct {
+	ClusterNo   string             `json:"cluster_no"`
+	Name        string             `json:"name"`
+	Status      string             `json:"status"`
+	Nodes       int                `json:"nodes"`
+	OnlineNodes int                `json:"online_nodes"`
+	LatencyMS   int64              `json:"latency_ms"`
+	Error       *string            `json:"error"`
+	Regions     []MCPClusterRegion `json:"regions"`
+	Instances   int64              `json:"instances"`
+	CheckedAt   time.Time          `json:"checked_at"`
+}
+
+type MCPClusterRegion struct {
+	RegionNo string `json:"region_no"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+}
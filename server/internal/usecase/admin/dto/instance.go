@@ -5,12 +5,14 @@ import "time"
 type InstanceListQuery struct {
 	Page        int    `form:"page" validate:"omitempty,min=1"`
 	PerPage     int    `form:"per_page" validate:"omitempty,min=1,max=100"`
-	Status      string `form:"status" validate:"omitempty,oneof=creating running stopped error releasing released"`
+	Status      string `form:"status" validate:"omitempty,oneof=creating running stopped error releasing released suspended"`
 	InstanceNo  string `form:"instance_no" validate:"omitempty,max=64"`
 	OrderNo     string `form:"order_no" validate:"omitempty,max=64"`
+	ClusterNo   string `form:"cluster_no" validate:"omitempty,max=32"`
 	UserKeyword string `form:"user_keyword" validate:"omitempty,max=128"`
 	DateFrom    string `form:"date_from" validate:"omitempty,max=32"`
 	DateTo      string `form:"date_to" validate:"omitempty,max=32"`
+	Drifted     bool   `form:"drifted"`
 }
 
 type InstanceMappingListQuery struct {
@@ -31,10 +33,12 @@ type InstanceMappingRequest struct {
 	TemplateNo      string  `json:"template_no" validate:"required,max=64"`
 	NetworkTypeNo   string  `json:"network_type_no" validate:"omitempty,max=64"`
 	Node            string  `json:"node" validate:"required,max=128"`
+	PlacementNodes  *string `json:"placement_nodes" validate:"omitempty,max=500"`
 	Storage         string  `json:"storage" validate:"required,max=128"`
 	DiskSource      string  `json:"disk_source" validate:"required,max=255"`
 	DiskFormat      *string `json:"disk_format" validate:"omitempty,max=32"`
 	DiskInterface   *string `json:"disk_interface" validate:"omitempty,max=32"`
+	DataDiskStorage *string `json:"data_disk_storage" validate:"omitempty,max=128"`
 	SnippetsStorage *string `json:"snippets_storage" validate:"omitempty,max=128"`
 	CIUser          *string `json:"ci_user" validate:"omitempty,max=64"`
 	SSHKeys         *string `json:"ssh_keys" validate:"omitempty,max=10000"`
@@ -46,6 +50,7 @@ type InstanceMappingRequest struct {
 	VMIDStart       uint    `json:"vmid_start" validate:"required,min=1"`
 	VMIDEnd         uint    `json:"vmid_end" validate:"required,min=1"`
 	NextVMID        uint    `json:"next_vmid" validate:"required,min=1"`
+	ReuseVMIDs      bool    `json:"reuse_released_vmids"`
 	Status          string  `json:"status" validate:"required,oneof=active inactive"`
 	Remark          *string `json:"remark" validate:"omitempty,max=500"`
 }
@@ -59,10 +64,12 @@ type InstanceMappingItem struct {
 	TemplateNo      string    `json:"template_no"`
 	NetworkTypeNo   string    `json:"network_type_no"`
 	Node            string    `json:"node"`
+	PlacementNodes  *string   `json:"placement_nodes"`
 	Storage         string    `json:"storage"`
 	DiskSource      string    `json:"disk_source"`
 	DiskFormat      *string   `json:"disk_format"`
 	DiskInterface   *string   `json:"disk_interface"`
+	DataDiskStorage *string   `json:"data_disk_storage"`
 	SnippetsStorage *string   `json:"snippets_storage"`
 	CIUser          *string   `json:"ci_user"`
 	SSHKeys         *string   `json:"ssh_keys"`
@@ -74,6 +81,9 @@ type InstanceMappingItem struct {
 	VMIDStart       uint      `json:"vmid_start"`
 	VMIDEnd         uint      `json:"vmid_end"`
 	NextVMID        uint      `json:"next_vmid"`
+	ReuseVMIDs      bool      `json:"reuse_released_vmids"`
+	VMIDRemaining   int       `json:"vmid_remaining"`
+	VMIDLow         bool      `json:"vmid_low"`
 	Status          string    `json:"status"`
 	Remark          *string   `json:"remark"`
 	CreatedAt       time.Time `json:"created_at"`
@@ -90,41 +100,62 @@ type InstanceItem struct {
 	RegionName               string           `json:"region_name"`
 	NetworkTypeName          *string          `json:"network_type_name"`
 	TemplateName             string           `json:"template_name"`
+	ClusterNo                string           `json:"cluster_no"`
 	ExternalNode             string           `json:"external_node"`
 	ExternalVMID             uint             `json:"external_vmid"`
+	ConfigDrift              []string         `json:"config_drift"`
 	ServiceStartedAt         *time.Time       `json:"service_started_at"`
 	ExpiresAt                *time.Time       `json:"expires_at"`
 	ExpireNoticeSentAt       *time.Time       `json:"expire_notice_sent_at"`
 	ExpireReleaseScheduledAt *time.Time       `json:"expire_release_scheduled_at"`
 	ExpireReleasedAt         *time.Time       `json:"expire_released_at"`
-	CreatedAt                time.Time        `json:"created_at"`
-	ReleasedAt               *time.Time       `json:"released_at"`
+	// SuspendSource 是暂停来源：expiry 到期暂停，admin 后台暂停；未暂停时为空。
+	SuspendSource *string    `json:"suspend_source"`
+	SuspendReason *string    `json:"suspend_reason"`
+	SuspendedAt   *time.Time `json:"suspended_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReleasedAt    *time.Time `json:"released_at"`
 }
 
 type InstanceDetail struct {
 	InstanceItem
-	ProductNo                string               `json:"product_no"`
-	PlanNo                   string               `json:"plan_no"`
-	CPUCores                 int                  `json:"cpu_cores"`
-	MemoryMB                 int                  `json:"memory_mb"`
-	SystemDiskGB             int                  `json:"system_disk_gb"`
-	DataDiskGB               int                  `json:"data_disk_gb"`
-	BandwidthMbps            int                  `json:"bandwidth_mbps"`
-	RegionNo                 string               `json:"region_no"`
-	NetworkTypeNo            *string              `json:"network_type_no"`
-	TemplateNo               string               `json:"template_no"`
-	OSFamily                 string               `json:"os_family"`
-	OSDistribution           string               `json:"os_distribution"`
-	OSVersion                string               `json:"os_version"`
-	ExternalResourceLocation *string              `json:"external_resource_location"`
-	LastErrorCode            *string              `json:"last_error_code"`
-	LastErrorMessage         *string              `json:"last_error_message"`
+	ProductNo     string `json:"product_no"`
+	PlanNo        string `json:"plan_no"`
+	CPUCores      int    `json:"cpu_cores"`
+	MemoryMB      int    `json:"memory_mb"`
+	SystemDiskGB  int    `json:"system_disk_gb"`
+	DataDiskGB    int    `json:"data_disk_gb"`
+	BandwidthMbps int    `json:"bandwidth_mbps"`
+	TrafficGB     *int   `json:"traffic_gb"`
+	// TrafficOverageAction 是本月流量超额后当前生效的限制：throttle 限速、suspend 暂停，空表示未限制。
+	TrafficOverageAction     *string    `json:"traffic_overage_action"`
+	RegionNo                 string     `json:"region_no"`
+	NetworkTypeNo            *string    `json:"network_type_no"`
+	TemplateNo               string     `json:"template_no"`
+	OSFamily                 string     `json:"os_family"`
+	OSDistribution           string     `json:"os_distribution"`
+	OSVersion                string     `json:"os_version"`
+	ExternalResourceLocation *string    `json:"external_resource_location"`
+	LastErrorCode            *string    `json:"last_error_code"`
+	LastErrorMessage         *string    `json:"last_error_message"`
+	ConfigCheckedAt          *time.Time `json:"config_checked_at"`
+	// FirewallStatus 是安全组下发状态：synced 已生效，pending 待下次同步重试，空表示未配置安全组。
+	FirewallStatus   *string    `json:"firewall_status"`
+	FirewallSyncedAt *time.Time `json:"firewall_synced_at"`
+	// IPAddresses 是实例从地址池分配的地址，第一个为 ipconfig0 主地址；未使用地址池时为空。
+	IPAddresses []string `json:"ip_addresses"`
+	// RootPasswordSet 表示实例保存了加密的 root 密码；后台不提供明文查看。
+	RootPasswordSet          bool                 `json:"root_password_set"`
+	RootPasswordRevealedAt   *time.Time           `json:"root_password_revealed_at"`
 	ExpireNoticeSentAt       *time.Time           `json:"expire_notice_sent_at"`
 	ExpireReleaseScheduledAt *time.Time           `json:"expire_release_scheduled_at"`
 	ExpireReleasedAt         *time.Time           `json:"expire_released_at"`
 	RenewalAvailable         bool                 `json:"renewal_available"`
 	LatestRenewalOrder       *RenewalOrderSummary `json:"latest_renewal_order"`
 	Operations               []InstanceOperation  `json:"operations"`
+	// Rescue 是进行中的救援模式，MountedISO 是光驱中挂载的安装镜像，未使用时为空。
+	Rescue     *InstanceRescueState `json:"rescue"`
+	MountedISO *InstanceMountedISO  `json:"mounted_iso"`
 }
 
 type RenewalOrderSummary struct {
@@ -143,17 +174,165 @@ type InstanceExpiresAtRequest struct {
 	Remark    *string   `json:"remark" validate:"omitempty,max=500"`
 }
 
+// InstanceSuspendRequest 是后台暂停实例的请求，原因会展示给用户。
+type InstanceSuspendRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type InstanceUnsuspendRequest struct {
+	Remark *string `json:"remark" validate:"omitempty,max=500"`
+}
+
+type InstanceReinstallRequest struct {
+	TemplateNo string `json:"template_no" validate:"required,max=64"`
+}
+
+type InstanceReinstallTemplate struct {
+	TemplateNo   string  `json:"template_no"`
+	Code         string  `json:"code"`
+	Name         string  `json:"name"`
+	OSFamily     string  `json:"os_family"`
+	Distribution string  `json:"distribution"`
+	Version      string  `json:"version"`
+	Architecture string  `json:"architecture"`
+	Summary      *string `json:"summary"`
+	Current      bool    `json:"current"`
+}
+
+// InstanceConsoleRequest 申请控制台会话；type 为空时按 vnc 处理。
+type InstanceConsoleRequest struct {
+	Type string `json:"type" validate:"omitempty,oneof=vnc terminal"`
+}
+
+// InstanceConsoleSession 是一次性控制台会话；websocket_path 需在 expires_at 前连接，password 为 VNC 客户端连接密码。
+type InstanceConsoleSession struct {
+	Type               string    `json:"type"`
+	WebsocketPath      string    `json:"websocket_path"`
+	Password           string    `json:"password"`
+	ExpiresAt          time.Time `json:"expires_at"`
+	MaxDurationSeconds int       `json:"max_duration_seconds"`
+}
+
+type InstanceSnapshotCreateRequest struct {
+	Description *string `json:"description" validate:"omitempty,max=255"`
+}
+
+type InstanceSnapshotItem struct {
+	SnapshotNo       string     `json:"snapshot_no"`
+	Name             string     `json:"name"`
+	Description      *string    `json:"description"`
+	Status           string     `json:"status"`
+	CreatedByUserID  *uint64    `json:"created_by_user_id"`
+	CreatedByAdminID *uint64    `json:"created_by_admin_id"`
+	LastRolledBackAt *time.Time `json:"last_rolled_back_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// InstanceSnapshotList 返回实例快照和套餐快照配额，used 只统计占用配额的快照。
+type InstanceSnapshotList struct {
+	Quota int                    `json:"quota"`
+	Used  int64                  `json:"used"`
+	List  []InstanceSnapshotItem `json:"list"`
+}
+
+type InstanceBackupCreateRequest struct {
+	Note *string `json:"note" validate:"omitempty,max=255"`
+}
+
+// InstanceBackupPolicyRequest 保存实例定时备份策略；weekly 时 weekday 生效，0 表示星期日。
+type InstanceBackupPolicyRequest struct {
+	Frequency      string `json:"frequency" validate:"required,oneof=daily weekly"`
+	Weekday        int    `json:"weekday" validate:"min=0,max=6"`
+	Hour           int    `json:"hour" validate:"min=0,max=23"`
+	RetentionCount int    `json:"retention_count" validate:"required,min=1,max=30"`
+	Enabled        bool   `json:"enabled"`
+}
+
+type InstanceBackupPolicy struct {
+	Frequency      string     `json:"frequency"`
+	Weekday        int        `json:"weekday"`
+	Hour           int        `json:"hour"`
+	RetentionCount int        `json:"retention_count"`
+	Enabled        bool       `json:"enabled"`
+	NextRunAt      *time.Time `json:"next_run_at"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+}
+
+type InstanceBackupItem struct {
+	BackupNo         string     `json:"backup_no"`
+	Source           string     `json:"source"`
+	Status           string     `json:"status"`
+	Node             string     `json:"node"`
+	Storage          string     `json:"storage"`
+	VolumeID         *string    `json:"volume_id"`
+	Mode             string     `json:"mode"`
+	Note             *string    `json:"note"`
+	TaskNo           *string    `json:"task_no"`
+	CPUCores         int        `json:"cpu_cores"`
+	MemoryMB         int        `json:"memory_mb"`
+	SystemDiskGB     int        `json:"system_disk_gb"`
+	DataDiskGB       int        `json:"data_disk_gb"`
+	TemplateNo       string     `json:"template_no"`
+	TemplateName     string     `json:"template_name"`
+	CreatedByUserID  *uint64    `json:"created_by_user_id"`
+	CreatedByAdminID *uint64    `json:"created_by_admin_id"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	LastRestoredAt   *time.Time `json:"last_restored_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// InstanceBackupList 返回实例备份、定时备份策略和手动备份上限，manual_used 只统计占用上限的手动备份。
+type InstanceBackupList struct {
+	ManualLimit int                   `json:"manual_limit"`
+	ManualUsed  int64                 `json:"manual_used"`
+	Policy      *InstanceBackupPolicy `json:"policy"`
+	List        []InstanceBackupItem  `json:"list"`
+}
+
 type InstanceOperation struct {
-	OperationNo         string     `json:"operation_no"`
-	Action              string     `json:"action"`
-	Status              string     `json:"status"`
-	ExternalOperationID *string    `json:"external_operation_id"`
-	OperationLocation   *string    `json:"operation_location"`
-	ResourceLocation    *string    `json:"resource_location"`
-	ErrorCode           *string    `json:"error_code"`
-	ErrorMessage        *string    `json:"error_message"`
-	CreatedAt           time.Time  `json:"created_at"`
-	CompletedAt         *time.Time `json:"completed_at"`
+	OperationNo         string  `json:"operation_no"`
+	Action              string  `json:"action"`
+	Status              string  `json:"status"`
+	ExternalOperationID *string `json:"external_operation_id"`
+	OperationLocation   *string `json:"operation_location"`
+	ResourceLocation    *string `json:"resource_location"`
+	ErrorCode           *string `json:"error_code"`
+	ErrorMessage        *string `json:"error_message"`
+	// Placement 是多节点交付或迁移的调度决策，仅调度过的 provision 和 migrate 操作返回。
+	Placement   *InstancePlacement `json:"placement,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	CompletedAt *time.Time         `json:"completed_at"`
+}
+
+type InstancePlacement struct {
+	MappingNo  string                       `json:"mapping_no"`
+	Node       string                       `json:"node"`
+	Ratios     InstancePlacementRatios      `json:"ratios"`
+	Candidates []InstancePlacementCandidate `json:"candidates"`
+}
+
+type InstancePlacementRatios struct {
+	CPU              float64 `json:"cpu"`
+	Memory           float64 `json:"memory"`
+	Storage          float64 `json:"storage"`
+	UserAntiAffinity bool    `json:"user_anti_affinity"`
+}
+
+type InstancePlacementCandidate struct {
+	Node              string  `json:"node"`
+	Eligible          bool    `json:"eligible"`
+	Reason            string  `json:"reason,omitempty"`
+	Load              float64 `json:"load"`
+	MaxCPU            int     `json:"max_cpu"`
+	CPUUsage          float64 `json:"cpu_usage"`
+	MemoryTotalMB     int64   `json:"memory_total_mb"`
+	MemoryUsedMB      int64   `json:"memory_used_mb"`
+	StorageTotalGB    int64   `json:"storage_total_gb"`
+	StorageUsedGB     int64   `json:"storage_used_gb"`
+	CommittedCPU      int     `json:"committed_cpu"`
+	CommittedMemoryMB int64   `json:"committed_memory_mb"`
+	CommittedDiskGB   int64   `json:"committed_disk_gb"`
+	UserInstances     int     `json:"user_instances"`
 }
 
 type ProvisionResponse struct {
@@ -0,0 +1,70 @@
+package dto
+
+import "time"
+
+type IPPoolListQuery struct {
+	Page          int    `form:"page" validate:"omitempty,min=1"`
+	PerPage       int    `form:"per_page" validate:"omitempty,min=1,max=100"`
+	Status        string `form:"status" validate:"omitempty,oneof=active inactive"`
+	RegionNo      string `form:"region_no" validate:"omitempty,max=64"`
+	NetworkTypeNo string `form:"network_type_no" validate:"omitempty,max=64"`
+}
+
+type IPPoolCreateRequest struct {
+	PoolNo         string   `json:"pool_no" validate:"omitempty,max=64"`
+	Name           string   `json:"name" validate:"required,max=128"`
+	RegionNo       string   `json:"region_no" validate:"required,max=64"`
+	NetworkTypeNo  string   `json:"network_type_no" validate:"omitempty,max=64"`
+	CIDR           string   `json:"cidr" validate:"required,max=64"`
+	Gateway        string   `json:"gateway" validate:"required,max=64"`
+	ReservedRanges []string `json:"reserved_ranges" validate:"omitempty,max=64,dive,max=64"`
+	Status         string   `json:"status" validate:"required,oneof=active inactive"`
+	Remark         *string  `json:"remark" validate:"omitempty,max=500"`
+}
+
+type IPPoolUpdateRequest struct {
+	Name   string  `json:"name" validate:"required,max=128"`
+	Status string  `json:"status" validate:"required,oneof=active inactive"`
+	Remark *string `json:"remark" validate:"omitempty,max=500"`
+}
+
+type IPPoolItem struct {
+	ID             uint64    `json:"id"`
+	PoolNo         string    `json:"pool_no"`
+	Name           string    `json:"name"`
+	RegionNo       string    `json:"region_no"`
+	NetworkTypeNo  string    `json:"network_type_no"`
+	CIDR           string    `json:"cidr"`
+	PrefixLength   int       `json:"prefix_length"`
+	Gateway        string    `json:"gateway"`
+	Status         string    `json:"status"`
+	Remark         *string   `json:"remark"`
+	TotalCount     int64     `json:"total_count"`
+	AvailableCount int64     `json:"available_count"`
+	ReservedCount  int64     `json:"reserved_count"`
+	AllocatedCount int64     `json:"allocated_count"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type IPAddressListQuery struct {
+	Page    int    `form:"page" validate:"omitempty,min=1"`
+	PerPage int    `form:"per_page" validate:"omitempty,min=1,max=100"`
+	Status  string `form:"status" validate:"omitempty,oneof=available reserved allocated"`
+	Keyword string `form:"keyword" validate:"omitempty,max=64"`
+}
+
+type IPAddressActionRequest struct {
+	Remark *string `json:"remark" validate:"omitempty,max=255"`
+}
+
+type IPAddressItem struct {
+	ID          uint64     `json:"id"`
+	PoolNo      string     `json:"pool_no"`
+	Address     string     `json:"address"`
+	Status      string     `json:"status"`
+	InstanceNo  *string    `json:"instance_no"`
+	AllocatedAt *time.Time `json:"allocated_at"`
+	Remark      *string    `json:"remark"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,58 @@
+package dto
+
+import "time"
+
+type ISOListQuery struct {
+	Page    int    `form:"page" validate:"omitempty,min=1"`
+	PerPage int    `form:"per_page" validate:"omitempty,min=1,max=100"`
+	Purpose string `form:"purpose" validate:"omitempty,oneof=rescue install"`
+	Status  string `form:"status" validate:"omitempty,oneof=active inactive"`
+	Keyword string `form:"keyword" validate:"omitempty,max=128"`
+}
+
+// ISORequest 创建或更新镜像库条目；volume 为 PVE 存储卷 ID（如 cephfs:iso/debian-12.iso），
+// media 为 disk 时以临时磁盘方式挂载，只允许用于救援介质。创建后 purpose 不可修改。
+type ISORequest struct {
+	ISONo       string  `json:"iso_no" validate:"omitempty,max=64"`
+	Name        string  `json:"name" validate:"required,max=128"`
+	Purpose     string  `json:"purpose" validate:"required,oneof=rescue install"`
+	Media       string  `json:"media" validate:"required,oneof=iso disk"`
+	Volume      string  `json:"volume" validate:"required,max=255"`
+	OSFamily    *string `json:"os_family" validate:"omitempty,max=32"`
+	Description *string `json:"description" validate:"omitempty,max=500"`
+	Status      string  `json:"status" validate:"required,oneof=active inactive"`
+	SortOrder   int     `json:"sort_order" validate:"omitempty,min=0,max=9999"`
+}
+
+type ISOItem struct {
+	ISONo       string    `json:"iso_no"`
+	Name        string    `json:"name"`
+	Purpose     string    `json:"purpose"`
+	Media       string    `json:"media"`
+	Volume      string    `json:"volume"`
+	OSFamily    *string   `json:"os_family"`
+	Description *string   `json:"description"`
+	Status      string    `json:"status"`
+	SortOrder   int       `json:"sort_order"`
+	InUseCount  int64     `json:"in_use_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// InstanceRescueRequest 让实例进入救援模式；iso_no 为空时使用排序最靠前的可用救援介质。
+type InstanceRescueRequest struct {
+	ISONo string `json:"iso_no" validate:"omitempty,max=64"`
+}
+
+// InstanceRescueState 是实例进行中的救援模式；后台不返回临时密码明文。
+type InstanceRescueState struct {
+	ISONo     string     `json:"iso_no"`
+	ISOName   string     `json:"iso_name"`
+	StartedAt *time.Time `json:"started_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type InstanceMountedISO struct {
+	ISONo   string `json:"iso_no"`
+	ISOName string `json:"iso_name"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+type InstanceMetricsQuery struct {
+	Range string `form:"range" validate:"omitempty,oneof=hour day week month"`
+}
+
+// NodeMetricsQuery 的 cluster_no 为空时查询默认集群的节点。
+type NodeMetricsQuery struct {
+	Range     string `form:"range" validate:"omitempty,oneof=hour day week month"`
+	ClusterNo string `form:"cluster_no" validate:"omitempty,max=32"`
+}
+
+// InstanceMetrics 是一段时间范围内的性能采样；采样点超过上限时已按时间分桶取平均。
+type InstanceMetrics struct {
+	Range       string        `json:"range"`
+	Points      []MetricPoint `json:"points"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// MetricPoint 的 cpu_percent 为 0-100，内存单位 MB，磁盘和网络单位字节/秒；字段为 null 表示该时段无数据。
+type MetricPoint struct {
+	Time          time.Time `json:"time"`
+	CPUPercent    *float64  `json:"cpu_percent"`
+	MemoryUsedMB  *float64  `json:"memory_used_mb"`
+	MemoryTotalMB *float64  `json:"memory_total_mb"`
+	DiskReadBps   *float64  `json:"disk_read_bps"`
+	DiskWriteBps  *float64  `json:"disk_write_bps"`
+	NetInBps      *float64  `json:"net_in_bps"`
+	NetOutBps     *float64  `json:"net_out_bps"`
+}
+
+// NodeMetrics 是节点整体的性能采样，节点不提供磁盘读写；Instances 和 Allocated* 为该节点上未释放实例的数量与已分配规格合计。
+type NodeMetrics struct {
+	ClusterNo         string        `json:"cluster_no"`
+	Node              string        `json:"node"`
+	Range             string        `json:"range"`
+	Instances         int           `json:"instances"`
+	AllocatedCPUCores int           `json:"allocated_cpu_cores"`
+	AllocatedMemoryMB int64         `json:"allocated_memory_mb"`
+	AllocatedDiskGB   int64         `json:"allocated_disk_gb"`
+	Points            []MetricPoint `json:"points"`
+	GeneratedAt       time.Time     `json:"generated_at"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+// InstanceMigrateRequest 把实例迁移到同地域的另一个节点；target_node 为空时按容量调度自动选择。
+// mode 为空按 auto 处理：运行中实例先在线迁移，失败后自动改为离线迁移。
+type InstanceMigrateRequest struct {
+	TargetNode string `json:"target_node" validate:"omitempty,max=64"`
+	Mode       string `json:"mode" validate:"omitempty,oneof=auto online offline"`
+}
+
+// InstanceMigrationTargets 是实例可迁移的同地域节点，Candidates 按调度优先级排序，不含当前节点。
+type InstanceMigrationTargets struct {
+	InstanceNo  string                       `json:"instance_no"`
+	CurrentNode string                       `json:"current_node"`
+	Candidates  []InstancePlacementCandidate `json:"candidates"`
+}
+
+// NodeEvacuateRequest 把节点上的全部未释放实例迁移到同地域其他节点，mode 含义同单实例迁移；cluster_no 为空时指默认集群的节点。
+type NodeEvacuateRequest struct {
+	ClusterNo string `json:"cluster_no" validate:"omitempty,max=32"`
+	Mode      string `json:"mode" validate:"omitempty,oneof=auto online offline"`
+}
+
+// NodeEvacuateResult 是节点疏散的受理结果；Queued 已投递迁移任务，由 Worker 逐台执行，Skipped 给出未迁移原因。
+type NodeEvacuateResult struct {
+	ClusterNo string             `json:"cluster_no"`
+	Node      string             `json:"node"`
+	Queued    []NodeEvacuateItem `json:"queued"`
+	Skipped   []NodeEvacuateItem `json:"skipped"`
+}
+
+type NodeEvacuateItem struct {
+	InstanceNo string `json:"instance_no"`
+	Status     string `json:"status"`
+	TargetNode string `json:"target_node,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
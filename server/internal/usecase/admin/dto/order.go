@@ -45,6 +45,9 @@ type AdminOrderDetail struct {
 	UserNote           *string `json:"user_note"`
 	CancelReason       *string `json:"cancel_reason"`
 	ClosedReason       *string `json:"closed_reason"`
+	SourceBackupNo     *string `json:"source_backup_no"`
+	ChangeFromPlanNo   *string `json:"change_from_plan_no"`
+	CreditAmountCents  uint64  `json:"credit_amount_cents"`
 	ProductNo          string  `json:"product_no"`
 	ProductType        string  `json:"product_type"`
 	ProductSummary     *string `json:"product_summary"`
@@ -62,6 +62,7 @@ type ProductPlanRequest struct {
 	BandwidthMbps  int     `json:"bandwidth_mbps" validate:"required,min=1,max=100000"`
 	TrafficGB      *int    `json:"traffic_gb" validate:"omitempty,min=0,max=10000000"`
 	PublicIPCount  int     `json:"public_ip_count" validate:"omitempty,min=0,max=1024"`
+	SnapshotQuota  int     `json:"snapshot_quota" validate:"omitempty,min=0,max=64"`
 	Virtualization string  `json:"virtualization" validate:"required,oneof=kvm"`
 	Architecture   string  `json:"architecture" validate:"required,oneof=x86_64"`
 	IsFeatured     bool    `json:"is_featured"`
@@ -75,27 +76,30 @@ type ProductPlanStatusRequest struct {
 }
 
 type ProductPlanItem struct {
-	ID             uint64    `json:"id"`
-	PlanNo         string    `json:"plan_no"`
-	ProductID      uint64    `json:"product_id"`
-	Code           string    `json:"code"`
-	Name           string    `json:"name"`
-	Summary        *string   `json:"summary"`
-	CPUCores       int       `json:"cpu_cores"`
-	MemoryMB       int       `json:"memory_mb"`
-	SystemDiskGB   int       `json:"system_disk_gb"`
-	DataDiskGB     int       `json:"data_disk_gb"`
-	BandwidthMbps  int       `json:"bandwidth_mbps"`
-	TrafficGB      *int      `json:"traffic_gb"`
-	PublicIPCount  int       `json:"public_ip_count"`
-	Virtualization string    `json:"virtualization"`
-	Architecture   string    `json:"architecture"`
-	IsFeatured     bool      `json:"is_featured"`
-	Status         string    `json:"status"`
-	Visible        bool      `json:"visible"`
-	SortOrder      int       `json:"sort_order"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             uint64  `json:"id"`
+	PlanNo         string  `json:"plan_no"`
+	ProductID      uint64  `json:"product_id"`
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	Summary        *string `json:"summary"`
+	CPUCores       int     `json:"cpu_cores"`
+	MemoryMB       int     `json:"memory_mb"`
+	SystemDiskGB   int     `json:"system_disk_gb"`
+	DataDiskGB     int     `json:"data_disk_gb"`
+	BandwidthMbps  int     `json:"bandwidth_mbps"`
+	TrafficGB      *int    `json:"traffic_gb"`
+	PublicIPCount  int     `json:"public_ip_count"`
+	SnapshotQuota  int     `json:"snapshot_quota"`
+	Virtualization string  `json:"virtualization"`
+	Architecture   string  `json:"architecture"`
+	IsFeatured     bool    `json:"is_featured"`
+	Status         string  `json:"status"`
+	// CapacitySoldOutAt 非空表示套餐因地域容量不足被自动售罄。
+	CapacitySoldOutAt *time.Time `json:"capacity_sold_out_at"`
+	Visible           bool       `json:"visible"`
+	SortOrder         int        `json:"sort_order"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
 }
 
 type PlanPriceRequest struct {
@@ -129,8 +133,10 @@ type SalesRegionListQuery struct {
 	Status  string `form:"status" validate:"omitempty,oneof=active inactive"`
 }
 
+// SalesRegionRequest 的 ClusterNo 为空时绑定默认集群；地域下有未释放实例或启用中的交付映射时不能改绑。
 type SalesRegionRequest struct {
 	RegionNo  string  `json:"region_no" validate:"omitempty,max=64"`
+	ClusterNo string  `json:"cluster_no" validate:"omitempty,max=32"`
 	Code      string  `json:"code" validate:"required,min=2,max=64"`
 	Name      string  `json:"name" validate:"required,min=1,max=128"`
 	Country   *string `json:"country" validate:"omitempty,max=64"`
@@ -144,6 +150,7 @@ type SalesRegionRequest struct {
 type SalesRegionItem struct {
 	ID        uint64    `json:"id"`
 	RegionNo  string    `json:"region_no"`
+	ClusterNo string    `json:"cluster_no"`
 	Code      string    `json:"code"`
 	Name      string    `json:"name"`
 	Country   *string   `json:"country"`
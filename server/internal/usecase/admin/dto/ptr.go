@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+type PTRRecordListQuery struct {
+	Page    int    `form:"page" validate:"omitempty,min=1"`
+	PerPage int    `form:"per_page" validate:"omitempty,min=1,max=100"`
+	Status  string `form:"status" validate:"omitempty,oneof=pending_review applying active failed rejected"`
+	Keyword string `form:"keyword" validate:"omitempty,max=64"`
+}
+
+// PTRReviewRequest 是审批或驳回 PTR 的备注；驳回时备注会展示给用户。
+type PTRReviewRequest struct {
+	Remark *string `json:"remark" validate:"omitempty,max=255"`
+}
+
+type PTRRecordItem struct {
+	ID              uint64     `json:"id"`
+	Address         string     `json:"address"`
+	InstanceNo      string     `json:"instance_no"`
+	UserID          uint64     `json:"user_id"`
+	Hostname        string     `json:"hostname"`
+	AppliedHostname *string    `json:"applied_hostname"`
+	Status          string     `json:"status"`
+	LastError       *string    `json:"last_error"`
+	ReviewedBy      *uint64    `json:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	ReviewRemark    *string    `json:"review_remark"`
+	AppliedAt       *time.Time `json:"applied_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
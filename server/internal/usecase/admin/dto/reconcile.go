@@ -0,0 +1,77 @@
+package dto
+
+import "time"
+
+type ReconcileReportListQuery struct {
+	Page    int `form:"page" validate:"omitempty,min=1"`
+	PerPage int `form:"per_page" validate:"omitempty,min=1,max=100"`
+}
+
+// ReconcileReportItem 是一次对账的汇总；NodeErrors 列出未能列出 VM 的节点，这些节点上的实例不判定幽灵。
+type ReconcileReportItem struct {
+	ReportNo      string               `json:"report_no"`
+	Status        string               `json:"status"`
+	TaskNo        *string              `json:"task_no"`
+	NodeCount     int                  `json:"node_count"`
+	VMCount       int                  `json:"vm_count"`
+	InstanceCount int                  `json:"instance_count"`
+	OrphanCount   int                  `json:"orphan_count"`
+	GhostCount    int                  `json:"ghost_count"`
+	DriftCount    int                  `json:"drift_count"`
+	NodeErrors    []ReconcileNodeError `json:"node_errors"`
+	ErrorMessage  *string              `json:"error_message"`
+	StartedAt     time.Time            `json:"started_at"`
+	FinishedAt    *time.Time           `json:"finished_at"`
+}
+
+type ReconcileNodeError struct {
+	ClusterNo string `json:"cluster_no"`
+	Node      string `json:"node"`
+	Message   string `json:"message"`
+}
+
+type ReconcileItemListQuery struct {
+	Page    int    `form:"page" validate:"omitempty,min=1"`
+	PerPage int    `form:"per_page" validate:"omitempty,min=1,max=100"`
+	Kind    string `form:"kind" validate:"omitempty,oneof=orphan ghost drift"`
+	Status  string `form:"status" validate:"omitempty,oneof=open resolved"`
+}
+
+type ReconcileItem struct {
+	ID             uint64     `json:"id"`
+	ReportNo       string     `json:"report_no"`
+	Kind           string     `json:"kind"`
+	ClusterNo      string     `json:"cluster_no"`
+	Node           string     `json:"node"`
+	VMID           uint       `json:"vmid"`
+	VMName         *string    `json:"vm_name"`
+	VMStatus       *string    `json:"vm_status"`
+	VMCPUs         *int       `json:"vm_cpus"`
+	VMMemoryMB     *int       `json:"vm_memory_mb"`
+	InstanceNo     *string    `json:"instance_no"`
+	InstanceStatus *string    `json:"instance_status"`
+	Drift          []string   `json:"drift"`
+	Status         string     `json:"status"`
+	Resolution     *string    `json:"resolution"`
+	ResolvedBy     *uint64    `json:"resolved_by"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	Remark         *string    `json:"remark"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ReconcileAdoptRequest 把孤儿 VM 接管到用户名下；套餐组合用于生成实例规格快照和零元订单，
+// ExpiresAt 为空时按计费周期从接管时刻起算。
+type ReconcileAdoptRequest struct {
+	UserID        uint64     `json:"user_id" validate:"required,min=1"`
+	PlanNo        string     `json:"plan_no" validate:"required,max=64"`
+	BillingCycle  string     `json:"billing_cycle" validate:"required,oneof=monthly quarterly semi_yearly yearly"`
+	RegionNo      string     `json:"region_no" validate:"required,max=64"`
+	NetworkTypeNo string     `json:"network_type_no" validate:"required,max=64"`
+	TemplateNo    string     `json:"template_no" validate:"required,max=64"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	Remark        *string    `json:"remark" validate:"omitempty,max=500"`
+}
+
+type ReconcileResolveRequest struct {
+	Remark *string `json:"remark" validate:"omitempty,max=500"`
+}
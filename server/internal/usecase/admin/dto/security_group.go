@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+type SecurityGroupRule struct {
+	Direction   string  `json:"direction"`
+	Protocol    string  `json:"protocol"`
+	PortRange   *string `json:"port_range"`
+	CIDR        string  `json:"cidr"`
+	Description *string `json:"description"`
+}
+
+type SecurityGroupItem struct {
+	GroupNo     string              `json:"group_no"`
+	Name        string              `json:"name"`
+	Description *string             `json:"description"`
+	Rules       []SecurityGroupRule `json:"rules"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// InstanceFirewall 是实例绑定的安全组（按下发顺序）和防火墙下发状态。
+type InstanceFirewall struct {
+	InstanceNo     string              `json:"instance_no"`
+	Status         *string             `json:"status"`
+	SyncedAt       *time.Time          `json:"synced_at"`
+	SecurityGroups []SecurityGroupItem `json:"security_groups"`
+}
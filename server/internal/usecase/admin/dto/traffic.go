@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+// InstanceTraffic 是实例月流量用量；Current 为当前计费月，History 按计费月倒序为最近数月（含当前月）。
+// OverageAction 是当前生效的超额限制：throttle 限速、suspend 暂停，空表示未限制。
+type InstanceTraffic struct {
+	InstanceNo    string         `json:"instance_no"`
+	Direction     string         `json:"direction"`
+	OveragePolicy string         `json:"overage_policy"`
+	OverageAction *string        `json:"overage_action"`
+	Current       TrafficUsage   `json:"current"`
+	History       []TrafficUsage `json:"history"`
+}
+
+// TrafficUsage 的字节数均为本计费月累计；quota_gb 为空表示不限流量，此时 used_percent 恒为 0。
+type TrafficUsage struct {
+	Period             string     `json:"period"`
+	QuotaGB            *int       `json:"quota_gb"`
+	InBytes            uint64     `json:"in_bytes"`
+	OutBytes           uint64     `json:"out_bytes"`
+	BilledBytes        uint64     `json:"billed_bytes"`
+	UsedPercent        float64    `json:"used_percent"`
+	Exceeded           bool       `json:"exceeded"`
+	OverageAction      *string    `json:"overage_action"`
+	OverageBilledGB    uint64     `json:"overage_billed_gb"`
+	OverageBilledCents uint64     `json:"overage_billed_cents"`
+	LastSampledAt      *time.Time `json:"last_sampled_at"`
+}
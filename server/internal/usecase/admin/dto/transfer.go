@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+type InstanceTransferListQuery struct {
+	Page    int    `form:"page" validate:"omitempty,min=1"`
+	PerPage int    `form:"per_page" validate:"omitempty,min=1,max=100"`
+	Status  string `form:"status" validate:"omitempty,oneof=pending completed rejected cancelled"`
+	Keyword string `form:"keyword" validate:"omitempty,max=64"`
+}
+
+// InstanceForceTransferRequest 由管理员直接把实例转移到目标账号；account 可填写用户名或邮箱。
+type InstanceForceTransferRequest struct {
+	Account string `json:"account" validate:"required,max=128"`
+	Reason  string `json:"reason" validate:"required,max=500"`
+}
+
+type InstanceTransferItem struct {
+	TransferNo   string     `json:"transfer_no"`
+	InstanceNo   string     `json:"instance_no"`
+	FromUserID   uint64     `json:"from_user_id"`
+	FromUsername string     `json:"from_username"`
+	ToUserID     uint64     `json:"to_user_id"`
+	ToUsername   string     `json:"to_username"`
+	Status       string     `json:"status"`
+	Remark       *string    `json:"remark"`
+	ForcedBy     *uint64    `json:"forced_by"`
+	ForceReason  *string    `json:"force_reason"`
+	RespondedAt  *time.Time `json:"responded_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,429 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+)
+
+// ErrBackupScheduleSkipped 表示定时备份任务已被策略变更或实例释放覆盖，Worker 应直接结束任务。
+var ErrBackupScheduleSkipped = errors.New("backup schedule skipped")
+
+// SetBackupConfig 注入备份存储配置；未注入或未配置存储时备份接口返回冲突错误。
+func (s *Service) SetBackupConfig(cfg config.BackupConfig) *Service {
+	s.backup = cfg
+	return s
+}
+
+// Backups 返回实例未删除的备份、定时备份策略和手动备份上限占用。
+func (s *Service) Backups(ctx context.Context, instanceNo string) (admindto.InstanceBackupList, error) {
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.InstanceBackupList{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return admindto.InstanceBackupList{}, err
+	}
+	used, err := s.instances.CountManualBackups(ctx, nil, row.ID)
+	if err != nil {
+		return admindto.InstanceBackupList{}, err
+	}
+	result := admindto.InstanceBackupList{ManualLimit: s.backup.ManualLimit, ManualUsed: used, List: []admindto.InstanceBackupItem{}}
+	if policy, err := s.instances.BackupPolicy(ctx, row.ID); err == nil {
+		item := backupPolicyItem(policy)
+		result.Policy = &item
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.InstanceBackupList{}, err
+	}
+	rows, err := s.instances.Backups(ctx, row.ID)
+	if err != nil {
+		return admindto.InstanceBackupList{}, err
+	}
+	for _, backup := range rows {
+		result.List = append(result.List, backupItem(backup))
+	}
+	return result, nil
+}
+
+func (s *Service) Backup(ctx context.Context, instanceNo string, backupNo string) (admindto.InstanceBackupItem, error) {
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.InstanceBackupItem{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return admindto.InstanceBackupItem{}, err
+	}
+	backup, err := s.instances.BackupByNo(ctx, strings.TrimSpace(backupNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && (backup.InstanceID != row.ID || backup.Status == domaininstance.BackupStatusDeleted)) {
+		return admindto.InstanceBackupItem{}, apperrors.ErrNotFound.WithMessage("备份不存在")
+	}
+	if err != nil {
+		return admindto.InstanceBackupItem{}, err
+	}
+	return backupItem(backup), nil
+}
+
+func (s *Service) CreateBackup(ctx context.Context, operatorID uint64, instanceNo string, req admindto.InstanceBackupCreateRequest) (admindto.InstanceBackupItem, error) {
+	if !s.backup.Enabled() {
+		return admindto.InstanceBackupItem{}, backupUnavailableError()
+	}
+	var backup mysqlinstance.Backup
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		used, err := s.instances.CountManualBackups(ctx, tx, current.ID)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		if used >= int64(s.backup.ManualLimit) {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("手动备份数量已达上限")
+		}
+		backup = newBackup(current, s.backup, domaininstance.BackupSourceManual, req.Note, nil, &operatorID, nil)
+		if err := s.instances.CreateBackup(ctx, tx, &backup); err != nil {
+			return operationPlan{}, err
+		}
+		return s.backupPlan(backup), nil
+	}
+	if _, err := s.startOperation(ctx, instanceNo, &operatorID, nil, domaininstance.OperationBackupCreate, apperrors.ErrConflict.WithMessage("实例已有未完成操作"), nil, planner); err != nil {
+		return admindto.InstanceBackupItem{}, err
+	}
+	return s.backupByNo(ctx, backup.BackupNo)
+}
+
+// RestoreBackup 用实例自己的备份覆盖当前 VM，要求实例已关机。
+func (s *Service) RestoreBackup(ctx context.Context, operatorID uint64, instanceNo string, backupNo string) (admindto.InstanceBackupItem, error) {
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		backup, err := s.lockBackup(ctx, tx, current.ID, backupNo)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		if backup.Status != domaininstance.BackupStatusAvailable || backup.VolumeID == nil {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("当前备份状态不能恢复")
+		}
+		input := mcppve.RestoreVMRequest{VMID: current.ExternalVMID, Archive: *backup.VolumeID, Force: true}
+		return operationPlan{payload: backupPayload(backup), call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).RestoreVM(ctx, row.ExternalNode, input)
+		}}, nil
+	}
+	if _, err := s.startOperation(ctx, instanceNo, &operatorID, nil, domaininstance.OperationBackupRestore, apperrors.ErrConflict.WithMessage("实例已有未完成操作，暂不能恢复备份"), nil, planner); err != nil {
+		return admindto.InstanceBackupItem{}, err
+	}
+	return s.backupByNo(ctx, strings.TrimSpace(backupNo))
+}
+
+// DeleteBackup 同步删除备份卷。创建失败的备份在存储上不存在，只在本地标记删除。
+func (s *Service) DeleteBackup(ctx context.Context, operatorID uint64, instanceNo string, backupNo string) (admindto.InstanceBackupItem, error) {
+	var backup mysqlinstance.Backup
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if backup, err = s.lockBackup(ctx, tx, current.ID, backupNo); err != nil {
+			return err
+		}
+		if !domaininstance.CanDeleteBackup(backup.Status) {
+			return apperrors.ErrConflict.WithMessage("当前备份状态不能删除")
+		}
+		next := domaininstance.BackupStatusDeleting
+		updates := map[string]any{"status": next}
+		if backup.Status == domaininstance.BackupStatusFailed || backup.VolumeID == nil {
+			next = domaininstance.BackupStatusDeleted
+			updates = map[string]any{"status": next, "deleted_at": time.Now()}
+		} else {
+			if !s.mcp.Enabled() {
+				return mcpUnavailableError()
+			}
+			if err := s.ensureNoRunningOperation(ctx, tx, current.ID, apperrors.ErrConflict.WithMessage("实例已有未完成操作，暂不能删除备份")); err != nil {
+				return err
+			}
+		}
+		if err := s.instances.UpdateBackup(ctx, tx, backup.ID, updates); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance.backup_delete", ObjectType: objectType, ObjectID: current.InstanceNo, BeforeData: backupAudit(backup), AfterData: map[string]any{"status": next}, Remark: "删除实例备份"})
+	})
+	if err != nil {
+		return admindto.InstanceBackupItem{}, err
+	}
+	if backup.Status == domaininstance.BackupStatusAvailable && backup.VolumeID != nil {
+		if err := s.removeBackupVolume(ctx, backup); err != nil {
+			return admindto.InstanceBackupItem{}, externalError(err)
+		}
+	}
+	return s.backupByNo(ctx, backup.BackupNo)
+}
+
+// UpdateBackupPolicy 保存定时备份策略，启用时按新策略计算下次执行时间并投递 Worker 任务。
+func (s *Service) UpdateBackupPolicy(ctx context.Context, operatorID uint64, instanceNo string, req admindto.InstanceBackupPolicyRequest) (admindto.InstanceBackupPolicy, error) {
+	if req.Enabled && !s.backup.Enabled() {
+		return admindto.InstanceBackupPolicy{}, backupUnavailableError()
+	}
+	if !domaininstance.IsKnownBackupFrequency(req.Frequency) {
+		return admindto.InstanceBackupPolicy{}, apperrors.ErrValidation.WithMessage("备份频率不支持")
+	}
+	var saved mysqlinstance.BackupPolicy
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if current.Status == domaininstance.StatusReleasing || current.Status == domaininstance.StatusReleased {
+			return apperrors.ErrConflict.WithMessage("实例已释放，不能设置定时备份")
+		}
+		before, err := s.instances.BackupPolicyForUpdate(ctx, tx, current.ID)
+		exists := err == nil
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		saved = backupPolicyFromRequest(current.ID, req.Frequency, req.Weekday, req.Hour, req.RetentionCount, req.Enabled, time.Now())
+		if exists {
+			saved.ID = before.ID
+			saved.LastRunAt = before.LastRunAt
+			err = s.instances.UpdateBackupPolicy(ctx, tx, before.ID, backupPolicyUpdateMap(saved))
+		} else {
+			err = s.instances.CreateBackupPolicy(ctx, tx, &saved)
+		}
+		if err != nil {
+			return err
+		}
+		if saved.NextRunAt != nil {
+			if err := s.enqueueBackupSchedule(ctx, tx, current.InstanceNo, *saved.NextRunAt); err != nil {
+				return err
+			}
+		}
+		var beforeData map[string]any
+		if exists {
+			beforeData = backupPolicyAudit(before)
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance.backup_policy", ObjectType: objectType, ObjectID: current.InstanceNo, BeforeData: beforeData, AfterData: backupPolicyAudit(saved), Remark: "更新定时备份策略"})
+	})
+	if err != nil {
+		return admindto.InstanceBackupPolicy{}, err
+	}
+	return backupPolicyItem(saved), nil
+}
+
+// TriggerScheduledBackup 供 Worker 执行定时备份任务：先推进策略并投递下一次任务，再触发本次备份。
+// 同一任务重入时按 task_no 返回已触发的备份，不会重复备份。
+func (s *Service) TriggerScheduledBackup(ctx context.Context, taskNo string, instanceNo string, scheduledAt time.Time) (mysqlinstance.Backup, error) {
+	if backup, err := s.instances.BackupByTaskNo(ctx, taskNo); err == nil {
+		return backup, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return mysqlinstance.Backup{}, err
+	}
+	if err := s.advanceBackupSchedule(ctx, instanceNo, scheduledAt); err != nil {
+		return mysqlinstance.Backup{}, err
+	}
+	if !s.backup.Enabled() {
+		return mysqlinstance.Backup{}, errors.New("未配置备份存储，定时备份未执行")
+	}
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		backup := newBackup(current, s.backup, domaininstance.BackupSourceScheduled, nil, nil, nil, &taskNo)
+		if err := s.instances.CreateBackup(ctx, tx, &backup); err != nil {
+			return operationPlan{}, err
+		}
+		return s.backupPlan(backup), nil
+	}
+	if _, err := s.startOperation(ctx, instanceNo, nil, nil, domaininstance.OperationBackupCreate, ErrOperationPending, nil, planner); err != nil {
+		return mysqlinstance.Backup{}, err
+	}
+	return s.instances.BackupByTaskNo(ctx, taskNo)
+}
+
+// advanceBackupSchedule 校验任务仍对应当前策略的计划时间，并把策略推进到下一次。
+// last_run_at 记录已推进的计划时间，任务因实例忙碌延后重入时仍可继续执行本次备份。
+func (s *Service) advanceBackupSchedule(ctx context.Context, instanceNo string, scheduledAt time.Time) error {
+	return mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBackupScheduleSkipped
+		}
+		if err != nil {
+			return err
+		}
+		if current.Status == domaininstance.StatusReleasing || current.Status == domaininstance.StatusReleased {
+			return ErrBackupScheduleSkipped
+		}
+		policy, err := s.instances.BackupPolicyForUpdate(ctx, tx, current.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBackupScheduleSkipped
+		}
+		if err != nil {
+			return err
+		}
+		if !policy.Enabled {
+			return ErrBackupScheduleSkipped
+		}
+		if sameScheduleTime(policy.LastRunAt, scheduledAt) {
+			return nil
+		}
+		if !sameScheduleTime(policy.NextRunAt, scheduledAt) {
+			return ErrBackupScheduleSkipped
+		}
+		after := scheduledAt
+		if now := time.Now(); now.After(after) {
+			// Worker 停机积压时直接跳到下一个未来时间点，不补跑错过的备份。
+			after = now
+		}
+		next := normalizeDBTime(domaininstance.NextBackupRun(policy.Frequency, policy.Weekday, policy.Hour, after))
+		if err := s.instances.UpdateBackupPolicy(ctx, tx, policy.ID, map[string]any{"last_run_at": normalizeDBTime(scheduledAt), "next_run_at": next}); err != nil {
+			return err
+		}
+		return s.enqueueBackupSchedule(ctx, tx, current.InstanceNo, next)
+	})
+}
+
+func (s *Service) backupPlan(backup mysqlinstance.Backup) operationPlan {
+	input := mcppve.BackupVMRequest{Storage: backup.Storage, Mode: backup.Mode, Compress: s.backup.Compress, Notes: backup.BackupNo}
+	return operationPlan{payload: backupPayload(backup), call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+		return s.mcp.Cluster(row.ClusterNo).BackupVM(ctx, row.ExternalNode, row.ExternalVMID, input)
+	}}
+}
+
+// removeBackupVolume 删除已标记 deleting 的备份卷；上游失败时恢复为 available，便于重试。
+func (s *Service) removeBackupVolume(ctx context.Context, backup mysqlinstance.Backup) error {
+	if err := s.mcp.Cluster(backup.ClusterNo).DeleteBackup(ctx, backup.Node, backup.Storage, value(backup.VolumeID)); err != nil {
+		_ = s.instances.UpdateBackup(context.Background(), nil, backup.ID, map[string]any{"status": domaininstance.BackupStatusAvailable})
+		return err
+	}
+	return s.instances.UpdateBackup(ctx, nil, backup.ID, map[string]any{"status": domaininstance.BackupStatusDeleted, "deleted_at": time.Now()})
+}
+
+func (s *Service) lockBackup(ctx context.Context, tx *gorm.DB, instanceID uint64, backupNo string) (mysqlinstance.Backup, error) {
+	backup, err := s.instances.BackupForUpdate(ctx, tx, instanceID, strings.TrimSpace(backupNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return mysqlinstance.Backup{}, apperrors.ErrNotFound.WithMessage("备份不存在")
+	}
+	return backup, err
+}
+
+func (s *Service) backupByNo(ctx context.Context, backupNo string) (admindto.InstanceBackupItem, error) {
+	backup, err := s.instances.BackupByNo(ctx, backupNo)
+	if err != nil {
+		return admindto.InstanceBackupItem{}, err
+	}
+	return backupItem(backup), nil
+}
+
+// settleBackup 按备份操作结果回写备份状态；备份成功时从上游 resourceLocation 解析备份卷 ID。
+func (s *Service) settleBackup(ctx context.Context, tx *gorm.DB, op mysqlinstance.Operation, succeeded bool, resourceLocation string) error {
+	backupNo, updates := backupSettlementUpdates(op, succeeded, resourceLocation, time.Now())
+	if backupNo == "" {
+		return nil
+	}
+	return s.instances.UpdateBackupByNo(ctx, tx, backupNo, updates)
+}
+
+func (s *Service) enqueueBackupSchedule(ctx context.Context, tx *gorm.DB, instanceNo string, runAt time.Time) error {
+	data, _ := json.Marshal(map[string]string{"instance_no": instanceNo, "scheduled_at": runAt.Format(time.RFC3339Nano)})
+	idempotencyKey := "backup_schedule:" + instanceNo + ":" + runAt.Format(time.RFC3339Nano)
+	objectNo := instanceNo
+	task := mysqlinstance.Task{TaskNo: fmt.Sprintf("TASK-%d", time.Now().UnixNano()), TaskType: domaininstance.TaskTypeBackupScheduled, IdempotencyKey: &idempotencyKey, Status: domaininstance.TaskStatusPending, ObjectType: stringPtr(objectType), ObjectNo: &objectNo, Payload: stringPtr(string(data)), MaxAttempts: 5, ScheduledAt: normalizeDBTime(runAt)}
+	return s.instances.CreateTaskIgnoreDuplicate(ctx, tx, &task)
+}
+
+func newBackup(current mysqlinstance.Instance, cfg config.BackupConfig, source string, note *string, userID *uint64, adminID *uint64, taskNo *string) mysqlinstance.Backup {
+	return mysqlinstance.Backup{BackupNo: fmt.Sprintf("BAK-%d", time.Now().UnixNano()), InstanceID: current.ID, Source: source, Status: domaininstance.BackupStatusCreating, ClusterNo: current.ClusterNo, Node: current.ExternalNode, Storage: strings.TrimSpace(cfg.Storage), Mode: cfg.Mode, Note: normalizeOptional(note), TaskNo: taskNo, CPUCores: current.CPUCores, MemoryMB: current.MemoryMB, SystemDiskGB: current.SystemDiskGB, DataDiskGB: current.DataDiskGB, TemplateNo: current.TemplateNo, TemplateName: current.TemplateName, OSFamily: current.OSFamily, OSDistribution: current.OSDistribution, OSVersion: current.OSVersion, CreatedByUserID: userID, CreatedByAdminID: adminID}
+}
+
+func backupPayload(backup mysqlinstance.Backup) mysqlinstance.BackupPayload {
+	return mysqlinstance.BackupPayload{BackupNo: backup.BackupNo}
+}
+
+func backupSettlementUpdates(op mysqlinstance.Operation, succeeded bool, resourceLocation string, now time.Time) (string, map[string]any) {
+	status := domaininstance.BackupStatusAfterOperation(op.Action, succeeded)
+	if status == "" || op.Payload == nil {
+		return "", nil
+	}
+	var payload mysqlinstance.BackupPayload
+	if err := json.Unmarshal([]byte(*op.Payload), &payload); err != nil || strings.TrimSpace(payload.BackupNo) == "" {
+		return "", nil
+	}
+	updates := map[string]any{"status": status}
+	switch op.Action {
+	case domaininstance.OperationBackupCreate:
+		updates["completed_at"] = now
+		if succeeded {
+			volumeID := backupVolumeID(resourceLocation)
+			if volumeID == "" {
+				// 上游未返回备份卷时无法恢复或清理，按失败处理。
+				updates["status"] = domaininstance.BackupStatusFailed
+			} else {
+				updates["volume_id"] = volumeID
+			}
+		}
+	case domaininstance.OperationBackupRestore:
+		if succeeded {
+			updates["last_restored_at"] = now
+		}
+	}
+	return payload.BackupNo, updates
+}
+
+// backupVolumeID 从备份操作的 resourceLocation 中取出 PVE 卷 ID，兼容直接返回卷 ID 和 .../backups/{volid} 两种形式。
+func backupVolumeID(location string) string {
+	location = strings.TrimSpace(location)
+	if index := strings.LastIndex(location, "/backups/"); index >= 0 {
+		location = location[index+len("/backups/"):]
+	}
+	if unescaped, err := url.PathUnescape(location); err == nil {
+		location = unescaped
+	}
+	return strings.TrimSpace(location)
+}
+
+func backupPolicyFromRequest(instanceID uint64, frequency string, weekday int, hour int, retention int, enabled bool, now time.Time) mysqlinstance.BackupPolicy {
+	policy := mysqlinstance.BackupPolicy{InstanceID: instanceID, Frequency: frequency, Weekday: weekday, Hour: hour, RetentionCount: retention, Enabled: enabled}
+	if enabled {
+		next := normalizeDBTime(domaininstance.NextBackupRun(frequency, weekday, hour, now))
+		policy.NextRunAt = &next
+	}
+	return policy
+}
+
+func backupPolicyUpdateMap(policy mysqlinstance.BackupPolicy) map[string]any {
+	return map[string]any{"frequency": policy.Frequency, "weekday": policy.Weekday, "hour": policy.Hour, "retention_count": policy.RetentionCount, "enabled": policy.Enabled, "next_run_at": policy.NextRunAt}
+}
+
+func sameScheduleTime(value *time.Time, scheduledAt time.Time) bool {
+	return value != nil && value.Truncate(time.Millisecond).Equal(scheduledAt.Truncate(time.Millisecond))
+}
+
+func backupUnavailableError() error {
+	return apperrors.ErrConflict.WithMessage("未配置备份存储，暂不支持备份")
+}
+
+func backupItem(backup mysqlinstance.Backup) admindto.InstanceBackupItem {
+	return admindto.InstanceBackupItem{BackupNo: backup.BackupNo, Source: backup.Source, Status: backup.Status, Node: backup.Node, Storage: backup.Storage, VolumeID: backup.VolumeID, Mode: backup.Mode, Note: backup.Note, TaskNo: backup.TaskNo, CPUCores: backup.CPUCores, MemoryMB: backup.MemoryMB, SystemDiskGB: backup.SystemDiskGB, DataDiskGB: backup.DataDiskGB, TemplateNo: backup.TemplateNo, TemplateName: backup.TemplateName, CreatedByUserID: backup.CreatedByUserID, CreatedByAdminID: backup.CreatedByAdminID, CompletedAt: backup.CompletedAt, LastRestoredAt: backup.LastRestoredAt, CreatedAt: backup.CreatedAt}
+}
+
+func backupPolicyItem(policy mysqlinstance.BackupPolicy) admindto.InstanceBackupPolicy {
+	return admindto.InstanceBackupPolicy{Frequency: policy.Frequency, Weekday: policy.Weekday, Hour: policy.Hour, RetentionCount: policy.RetentionCount, Enabled: policy.Enabled, NextRunAt: policy.NextRunAt, LastRunAt: policy.LastRunAt}
+}
+
+func backupAudit(backup mysqlinstance.Backup) map[string]any {
+	return map[string]any{"backup_no": backup.BackupNo, "source": backup.Source, "status": backup.Status, "storage": backup.Storage, "volume_id": backup.VolumeID}
+}
+
+func backupPolicyAudit(policy mysqlinstance.BackupPolicy) map[string]any {
+	return map[string]any{"frequency": policy.Frequency, "weekday": policy.Weekday, "hour": policy.Hour, "retention_count": policy.RetentionCount, "enabled": policy.Enabled, "next_run_at": policy.NextRunAt}
+}
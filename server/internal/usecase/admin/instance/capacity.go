@@ -0,0 +1,230 @@
+package instance
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaincatalog "github.com/AeolianCloud/pveCloud/server/internal/domain/catalog"
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	mysqlcatalog "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/catalog"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+)
+
+// capacityPlan 是单个套餐的容量判断；mapped 为 false 表示套餐没有任何地域配置了交付映射，不参与自动售罄。
+type capacityPlan struct {
+	plan   mysqlcatalog.ProductPlan
+	item   admindto.CapacityPlan
+	mapped bool
+}
+
+// Capacity 汇总各地域候选节点的容量占用和各套餐在售地域的可交付情况，供管理端首页展示。
+func (s *Service) Capacity(ctx context.Context) (admindto.CapacityResponse, error) {
+	regions, plans, err := s.capacitySnapshot(ctx)
+	if err != nil {
+		return admindto.CapacityResponse{}, err
+	}
+	ratios := s.overcommitRatios()
+	result := admindto.CapacityResponse{Ratios: admindto.InstancePlacementRatios{CPU: ratios.CPU, Memory: ratios.Memory, Storage: ratios.Storage, UserAntiAffinity: s.placement.UserAntiAffinity}, Regions: regions, Plans: make([]admindto.CapacityPlan, 0, len(plans)), GeneratedAt: time.Now()}
+	for _, plan := range plans {
+		result.Plans = append(result.Plans, plan.item)
+	}
+	return result, nil
+}
+
+// SyncPlanCapacity 按容量快照把地域容量不足的在售套餐置为售罄，并把自动售罄且容量恢复的套餐恢复在售，
+// 返回状态发生变化的套餐数。状态在事务内按锁定的套餐重新判断，避免覆盖管理员同时做出的人工调整。
+func (s *Service) SyncPlanCapacity(ctx context.Context) (int, error) {
+	_, plans, err := s.capacitySnapshot(ctx)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for _, plan := range plans {
+		if !plan.mapped {
+			continue
+		}
+		if _, ok := domaincatalog.CapacityPlanStatus(plan.plan.Status, plan.plan.CapacitySoldOutAt != nil, plan.item.Available); !ok {
+			continue
+		}
+		updated := false
+		if err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+			current, err := s.catalog.FindPlanByIDForUpdate(ctx, tx, plan.plan.ID)
+			if err != nil {
+				return err
+			}
+			status, ok := domaincatalog.CapacityPlanStatus(current.Status, current.CapacitySoldOutAt != nil, plan.item.Available)
+			if !ok {
+				return nil
+			}
+			updates := map[string]any{"status": status, "capacity_sold_out_at": nil}
+			remark := "地域容量恢复，自动恢复在售"
+			if status == domaincatalog.StatusSoldOut {
+				updates["capacity_sold_out_at"] = time.Now()
+				remark = "地域容量不足，自动售罄"
+			}
+			if err := s.catalog.UpdatePlan(ctx, tx, current.ID, updates); err != nil {
+				return err
+			}
+			updated = true
+			return s.audit.Record(ctx, tx, AdminAuditWriteInput{Action: "product_plan.capacity_status.update", ObjectType: "product_catalog", ObjectID: current.PlanNo, BeforeData: map[string]any{"status": current.Status}, AfterData: map[string]any{"status": status, "regions": plan.item.Regions}, Remark: remark})
+		}); err != nil {
+			return changed, err
+		}
+		if updated {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// capacitySnapshot 按地域绑定的集群分别读取一次上游节点与存储容量，按启用的交付映射计算各地域节点容量，并判断在售和售罄套餐
+// 在各在售地域能否按当前超分比例再放置一台实例。任一集群不可用时返回错误，调用方不得据此改动售卖状态。
+func (s *Service) capacitySnapshot(ctx context.Context) ([]admindto.CapacityRegion, []capacityPlan, error) {
+	if !s.mcp.Enabled() {
+		return nil, nil, mcpUnavailableError()
+	}
+	mappings, err := s.instances.ActiveMappings(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	plans, err := s.catalog.CapacityPlans(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	planIDs := make([]uint64, 0, len(plans))
+	for _, plan := range plans {
+		planIDs = append(planIDs, plan.ID)
+	}
+	var planRegions []mysqlcatalog.PlanRegionRow
+	if len(planIDs) > 0 {
+		if planRegions, err = s.catalog.ActivePlanRegions(ctx, planIDs); err != nil {
+			return nil, nil, err
+		}
+	}
+	clusters, err := s.regionClusters(ctx, mappings)
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes := map[string][]string{}
+	var clusterNos []string
+	for _, mapping := range mappings {
+		clusterNo := clusters[mapping.RegionNo]
+		if _, ok := nodes[clusterNo]; !ok {
+			clusterNos = append(clusterNos, clusterNo)
+		}
+		for _, node := range domaininstance.PlacementNodes(mapping.Node, value(mapping.PlacementNodes)) {
+			if !slices.Contains(nodes[clusterNo], node) {
+				nodes[clusterNo] = append(nodes[clusterNo], node)
+			}
+		}
+	}
+	inventories := map[string]clusterCapacity{}
+	for _, clusterNo := range clusterNos {
+		nodeList, storageList, err := s.clusterInventory(ctx, clusterNo)
+		if err != nil {
+			return nil, nil, err
+		}
+		allocations, err := s.instances.NodeAllocations(ctx, clusterNo, nodes[clusterNo], 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		inventories[clusterNo] = clusterCapacity{nodeList: nodeList, storageList: storageList, allocations: allocations}
+	}
+	capacitiesFor := func(mapping mysqlinstance.ProvisionMapping) []domaininstance.NodeCapacity {
+		inventory := inventories[clusters[mapping.RegionNo]]
+		return nodeCapacities(domaininstance.PlacementNodes(mapping.Node, value(mapping.PlacementNodes)), inventory.nodeList, inventory.storageList, mapping.Storage, inventory.allocations)
+	}
+	ratios := s.overcommitRatios()
+	return capacityRegions(mappings, clusters, capacitiesFor, ratios), capacityPlans(plans, planRegions, mappings, capacitiesFor, ratios), nil
+}
+
+// clusterCapacity 是容量快照中单个集群的上游节点、存储列表和本地已分配规格。
+type clusterCapacity struct {
+	nodeList    []mcppve.Node
+	storageList []mcppve.Storage
+	allocations []mysqlinstance.NodeAllocation
+}
+
+// capacityRegions 按地域汇总候选节点，地域顺序沿用映射查询的排序；同一节点在地域内只计一次，存储取首个引用该节点的映射。
+func capacityRegions(mappings []mysqlinstance.ProvisionMapping, clusters map[string]string, capacitiesFor func(mysqlinstance.ProvisionMapping) []domaininstance.NodeCapacity, ratios domaininstance.OvercommitRatios) []admindto.CapacityRegion {
+	const mib = 1024 * 1024
+	const gib = 1024 * mib
+	indexes := map[string]int{}
+	seen := map[string]bool{}
+	var regions []admindto.CapacityRegion
+	for _, mapping := range mappings {
+		index, ok := indexes[mapping.RegionNo]
+		if !ok {
+			index = len(regions)
+			indexes[mapping.RegionNo] = index
+			regions = append(regions, admindto.CapacityRegion{RegionNo: mapping.RegionNo, ClusterNo: clusters[mapping.RegionNo], Nodes: []admindto.CapacityNode{}})
+		}
+		region := &regions[index]
+		for _, capacity := range capacitiesFor(mapping) {
+			if seen[mapping.RegionNo+"/"+capacity.Node] {
+				continue
+			}
+			seen[mapping.RegionNo+"/"+capacity.Node] = true
+			node := admindto.CapacityNode{Node: capacity.Node, Storage: mapping.Storage, Online: capacity.Online, MaxCPU: capacity.MaxCPU, CPUUsage: capacity.CPUUsage, CPULimit: float64(capacity.MaxCPU) * ratios.CPU, CommittedCPU: capacity.CommittedCPU, MemoryTotalMB: capacity.MemoryTotalBytes / mib, MemoryUsedMB: capacity.MemoryUsedBytes / mib, MemoryLimitMB: float64(capacity.MemoryTotalBytes/mib) * ratios.Memory, CommittedMemoryMB: capacity.CommittedMemoryMB, StorageTotalGB: capacity.StorageTotalBytes / gib, StorageUsedGB: capacity.StorageUsedBytes / gib, StorageLimitGB: float64(capacity.StorageTotalBytes/gib) * ratios.Storage, CommittedDiskGB: capacity.CommittedDiskGB, Instances: capacity.Instances}
+			region.Nodes = append(region.Nodes, node)
+			region.CommittedCPU += node.CommittedCPU
+			region.CommittedMemoryMB += node.CommittedMemoryMB
+			region.CommittedDiskGB += node.CommittedDiskGB
+			region.Instances += node.Instances
+			if node.Online {
+				region.CPULimit += node.CPULimit
+				region.MemoryLimitMB += node.MemoryLimitMB
+				region.StorageLimitGB += node.StorageLimitGB
+			}
+		}
+	}
+	return regions
+}
+
+// capacityPlans 判断套餐在每个在售地域能否再交付一台实例：地域内任一启用映射的任一候选节点可放置即视为有货。
+func capacityPlans(plans []mysqlcatalog.ProductPlan, planRegions []mysqlcatalog.PlanRegionRow, mappings []mysqlinstance.ProvisionMapping, capacitiesFor func(mysqlinstance.ProvisionMapping) []domaininstance.NodeCapacity, ratios domaininstance.OvercommitRatios) []capacityPlan {
+	result := make([]capacityPlan, 0, len(plans))
+	for _, plan := range plans {
+		demand := domaininstance.PlacementDemand{CPUCores: plan.CPUCores, MemoryMB: plan.MemoryMB, DiskGB: plan.SystemDiskGB + plan.DataDiskGB}
+		item := capacityPlan{plan: plan, item: admindto.CapacityPlan{PlanNo: plan.PlanNo, PlanName: plan.Name, Status: plan.Status, CapacitySoldOutAt: plan.CapacitySoldOutAt, CPUCores: plan.CPUCores, MemoryMB: plan.MemoryMB, DiskGB: demand.DiskGB, Regions: []admindto.CapacityPlanRegion{}}}
+		for _, planRegion := range planRegions {
+			if planRegion.PlanID != plan.ID {
+				continue
+			}
+			region := admindto.CapacityPlanRegion{RegionNo: planRegion.RegionNo}
+			var reasons []string
+			for _, mapping := range mappings {
+				if mapping.PlanNo != plan.PlanNo || mapping.RegionNo != planRegion.RegionNo {
+					continue
+				}
+				item.mapped = true
+				node, candidates := domaininstance.ChoosePlacement(capacitiesFor(mapping), demand, ratios, false)
+				if node != "" {
+					region.Available, region.Node, reasons = true, stringPtr(node), nil
+					break
+				}
+				for _, candidate := range candidates {
+					reasons = append(reasons, candidate.Node+" "+candidate.Reason)
+				}
+			}
+			switch {
+			case region.Available:
+				item.item.Available = true
+			case len(reasons) == 0:
+				region.Reason = stringPtr("未配置启用的交付映射")
+			default:
+				region.Reason = stringPtr(strings.Join(reasons, "；"))
+			}
+			item.item.Regions = append(item.item.Regions, region)
+		}
+		result = append(result, item)
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
+	domainwallet "github.com/AeolianCloud/pveCloud/server/internal/domain/wallet"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqlorder "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/order"
+	mysqlwallet "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/wallet"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+)
+
+// ErrChangePlanSkipped 表示变更套餐订单已结束或不再需要调整规格，Worker 应直接结束任务。
+var ErrChangePlanSkipped = errors.New("change plan task skipped")
+
+// ApplyChangePlan 为已支付（或无需支付）的变更套餐订单发起 resize 操作。
+// 实例状态或套餐已变化时订单转为 error，等待后台人工处理或退款。
+func (s *Service) ApplyChangePlan(ctx context.Context, orderNo string) (admindto.InstanceDetail, error) {
+	order, err := s.orders.FindByOrderNo(ctx, strings.TrimSpace(orderNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.InstanceDetail{}, ErrChangePlanSkipped
+	}
+	if err != nil {
+		return admindto.InstanceDetail{}, err
+	}
+	if order.OrderType != domainorder.TypeChangePlan || order.Status != domainorder.StatusProvisioning || order.RelatedInstanceNo == nil {
+		return admindto.InstanceDetail{}, ErrChangePlanSkipped
+	}
+	payload := mysqlinstance.ResizePayload{OrderNo: order.OrderNo, PlanNo: order.PlanNo, PlanName: order.PlanName, CPUCores: order.CPUCores, MemoryMB: order.MemoryMB, BandwidthMbps: order.BandwidthMbps, TrafficGB: order.TrafficGB, CreditCents: order.CreditAmountCents}
+	guard := func(current mysqlinstance.Instance) error {
+		if current.UserID != order.UserID || current.PlanNo != value(order.ChangeFromPlanNo) {
+			return apperrors.ErrConflict.WithMessage("实例归属或套餐已变化，变更套餐订单无法生效")
+		}
+		return nil
+	}
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		req := mcppve.ResizeVMRequest{Cores: payload.CPUCores, Memory: payload.MemoryMB, NetworkRate: domaininstance.NetworkRateMBps(payload.BandwidthMbps)}
+		if value(current.TrafficOverageAction) == domaininstance.TrafficOverageThrottle {
+			// 流量超额限速期间保持限速带宽，新套餐流量是否足够由下次流量采样判断并恢复。
+			req.NetworkRate = domaininstance.NetworkRateMBps(s.traffic.ThrottleMbps)
+		}
+		return operationPlan{payload: payload, call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).ResizeVM(ctx, row.ExternalNode, row.ExternalVMID, req)
+		}}, nil
+	}
+	detail, err := s.startOperation(ctx, *order.RelatedInstanceNo, nil, &order.UserID, domaininstance.OperationResize, ErrOperationPending, guard, planner)
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) && (appErr.Code == apperrors.ErrConflict.Code || appErr.Code == apperrors.ErrNotFound.Code) {
+		// 状态冲突不会随重试消失，订单直接转 error；上游调用失败已由 settleChangePlan 回写。
+		if updateErr := s.orders.Update(ctx, nil, order.ID, map[string]any{"status": domainorder.StatusError}); updateErr != nil {
+			return admindto.InstanceDetail{}, updateErr
+		}
+	}
+	return detail, err
+}
+
+// settleChangePlan 按 resize 操作结果结算变更套餐订单；成功时把降配差价退还到钱包，非 resize 操作直接跳过。
+func (s *Service) settleChangePlan(ctx context.Context, tx *gorm.DB, op mysqlinstance.Operation, succeeded bool) error {
+	payload, ok := resizePayload(op)
+	if !ok || payload.OrderNo == "" {
+		return nil
+	}
+	order, err := s.orders.OrderForUpdate(ctx, tx, payload.OrderNo)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if order.Status != domainorder.StatusProvisioning {
+		return nil
+	}
+	if !succeeded {
+		return s.orders.Update(ctx, tx, order.ID, map[string]any{"status": domainorder.StatusError})
+	}
+	if err := s.orders.Update(ctx, tx, order.ID, map[string]any{"status": domainorder.StatusFulfilled}); err != nil {
+		return err
+	}
+	if payload.CreditCents == 0 {
+		return nil
+	}
+	return s.creditChangePlan(ctx, tx, order, payload.CreditCents)
+}
+
+// creditChangePlan 把降配差价记入用户 CNY 钱包；钱包账户不存在时自动开户，按订单号幂等。
+func (s *Service) creditChangePlan(ctx context.Context, tx *gorm.DB, order mysqlorder.Order, amount uint64) error {
+	account, err := s.wallets.AccountByUserCurrencyForUpdate(ctx, tx, order.UserID, domainwallet.CurrencyCNY)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		account = mysqlwallet.Account{WalletNo: fmt.Sprintf("WAL-%d", time.Now().UnixNano()), UserID: order.UserID, Currency: domainwallet.CurrencyCNY, Status: domainwallet.AccountStatusActive}
+		err = s.wallets.CreateAccount(ctx, tx, &account)
+	}
+	if err != nil {
+		return err
+	}
+	key := "plan_credit:" + order.OrderNo
+	if _, err := s.wallets.LedgerByIdempotency(ctx, tx, account.ID, key); err == nil {
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	before := account.AvailableBalanceCents
+	after := before + amount
+	if err := s.wallets.UpdateAccount(ctx, tx, account.ID, map[string]any{"available_balance_cents": after, "total_refunded_cents": account.TotalRefundedCents + amount}); err != nil {
+		return err
+	}
+	data, _ := json.Marshal(map[string]any{"order_no": order.OrderNo, "instance_no": value(order.RelatedInstanceNo), "from_plan_no": value(order.ChangeFromPlanNo), "to_plan_no": order.PlanNo})
+	summary := string(data)
+	entry := mysqlwallet.LedgerEntry{EntryNo: fmt.Sprintf("WLE-%d", time.Now().UnixNano()), WalletID: account.ID, WalletNo: account.WalletNo, UserID: order.UserID, Direction: domainwallet.DirectionCredit, EntryType: domainwallet.EntryTypePlanCredit, AmountCents: amount, BalanceBeforeCents: before, BalanceAfterCents: after, Currency: account.Currency, RelatedType: domainwallet.RelatedTypeOrder, RelatedNo: order.OrderNo, IdempotencyKey: key, Summary: &summary}
+	return s.wallets.CreateLedgerEntry(ctx, tx, &entry)
+}
+
+func resizePayload(op mysqlinstance.Operation) (mysqlinstance.ResizePayload, bool) {
+	if op.Action != domaininstance.OperationResize || op.Payload == nil {
+		return mysqlinstance.ResizePayload{}, false
+	}
+	var payload mysqlinstance.ResizePayload
+	if err := json.Unmarshal([]byte(*op.Payload), &payload); err != nil {
+		return mysqlinstance.ResizePayload{}, false
+	}
+	return payload, true
+}
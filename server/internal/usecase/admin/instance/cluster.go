@@ -0,0 +1,114 @@
+package instance
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlcatalog "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/catalog"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+)
+
+// clusterNode 是集群内的一个节点；节点名只在集群内唯一，跨集群汇总时以它作为键。
+type clusterNode struct {
+	clusterNo string
+	node      string
+}
+
+// Clusters 并发探测每个已登记集群的 MCP 接口，返回可达状态、节点在线数、响应耗时以及绑定的地域和实例数。
+// 单个集群探测失败只体现在该集群的状态中，不影响其他集群。
+func (s *Service) Clusters(ctx context.Context) ([]admindto.MCPCluster, error) {
+	regions, err := s.catalog.SalesRegions(ctx, mysqlcatalog.SalesRegionListFilters{})
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.instances.ClusterInstanceCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clients := s.mcp.Clusters()
+	items := make([]admindto.MCPCluster, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		items[i] = admindto.MCPCluster{ClusterNo: client.ClusterNo(), Name: client.Name(), Regions: []admindto.MCPClusterRegion{}, Instances: counts[client.ClusterNo()]}
+		for _, region := range regions {
+			if region.ClusterNo == client.ClusterNo() {
+				items[i].Regions = append(items[i].Regions, admindto.MCPClusterRegion{RegionNo: region.RegionNo, Name: region.Name, Status: region.Status})
+			}
+		}
+		wg.Add(1)
+		go func(item *admindto.MCPCluster, client *mcppve.Client) {
+			defer wg.Done()
+			probeCluster(ctx, item, client)
+		}(&items[i], client)
+	}
+	wg.Wait()
+	return items, nil
+}
+
+// probeCluster 以节点列表作为健康探测，记录响应耗时；接口未启用时不发起请求。
+func probeCluster(ctx context.Context, item *admindto.MCPCluster, client *mcppve.Client) {
+	started := time.Now()
+	var result []mcppve.Node
+	var err error
+	if client.Enabled() {
+		result, err = client.Nodes(ctx)
+		item.LatencyMS = time.Since(started).Milliseconds()
+	}
+	item.CheckedAt = time.Now()
+	nodes := mcpNodes(result)
+	statuses := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		statuses = append(statuses, node.Status)
+	}
+	item.Status, item.OnlineNodes = domaininstance.ClusterHealth(client.Enabled(), err, statuses)
+	item.Nodes = len(nodes)
+	if err != nil {
+		item.Error = stringPtr(err.Error())
+	}
+}
+
+// regionCluster 返回售卖地域绑定的集群编号；地域记录已删除时按默认集群处理，与迁移前的存量数据一致。
+func (s *Service) regionCluster(ctx context.Context, regionNo string) (string, error) {
+	clusters, err := s.regionClusters(ctx, []mysqlinstance.ProvisionMapping{{RegionNo: regionNo}})
+	if err != nil {
+		return "", err
+	}
+	return clusters[regionNo], nil
+}
+
+// regionClusters 返回交付映射所属地域到集群编号的映射；交付映射继承地域绑定的集群，不单独保存。
+func (s *Service) regionClusters(ctx context.Context, mappings []mysqlinstance.ProvisionMapping) (map[string]string, error) {
+	regionNos := make([]string, 0, len(mappings))
+	for _, mapping := range mappings {
+		regionNos = append(regionNos, mapping.RegionNo)
+	}
+	clusters, err := s.catalog.RegionClusters(ctx, regionNos)
+	if err != nil {
+		return nil, err
+	}
+	for _, regionNo := range regionNos {
+		if clusters[regionNo] == "" {
+			clusters[regionNo] = config.DefaultMCPPVECluster
+		}
+	}
+	return clusters, nil
+}
+
+// clusterInventory 读取集群的上游节点和存储列表，供交付调度、迁移和容量统计计算节点容量。
+func (s *Service) clusterInventory(ctx context.Context, clusterNo string) ([]mcppve.Node, []mcppve.Storage, error) {
+	client := s.mcp.Cluster(clusterNo)
+	nodeList, err := client.Nodes(ctx)
+	if err != nil {
+		return nil, nil, externalError(err)
+	}
+	storageList, err := client.Storage(ctx)
+	if err != nil {
+		return nil, nil, externalError(err)
+	}
+	return nodeList, storageList, nil
+}
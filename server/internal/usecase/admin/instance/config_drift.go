@@ -0,0 +1,58 @@
+package instance
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+)
+
+// checkConfigDrift 读取 VM 实际配置并与实例规格快照比对，结果写入 config_drift。
+// 上游配置查询失败只跳过本次核对，不影响状态同步结果。
+func (s *Service) checkConfigDrift(ctx context.Context, instanceNo string) error {
+	row, err := s.instances.Detail(ctx, instanceNo)
+	if err != nil {
+		return err
+	}
+	config, err := s.mcp.Cluster(row.ClusterNo).VMConfig(ctx, row.ExternalNode, row.ExternalVMID)
+	if err != nil {
+		return nil
+	}
+	expected := instanceSpec(row.Instance)
+	if value(row.TrafficOverageAction) == domaininstance.TrafficOverageThrottle {
+		// 流量超额限速期间网卡按限速带宽运行，不视为漂移。
+		expected.BandwidthMbps = s.traffic.ThrottleMbps
+	}
+	var stored *string
+	if drift := domaininstance.ConfigDrift(expected, vmConfigSpec(config)); len(drift) > 0 {
+		joined := strings.Join(drift, ",")
+		stored = &joined
+	}
+	if err := s.instances.UpdateInstance(ctx, nil, row.ID, map[string]any{"config_drift": stored, "config_checked_at": time.Now()}); err != nil {
+		return err
+	}
+	if stored != nil && value(stored) != value(row.ConfigDrift) {
+		_ = s.audit.Record(ctx, nil, AdminAuditWriteInput{Action: "instance.config_drift", ObjectType: objectType, ObjectID: row.InstanceNo, BeforeData: map[string]any{"config_drift": row.ConfigDrift}, AfterData: map[string]any{"config_drift": *stored}, Remark: "实例实际配置与订单规格不一致"})
+	}
+	return nil
+}
+
+func instanceSpec(row mysqlinstance.Instance) domaininstance.Spec {
+	return domaininstance.Spec{CPUCores: row.CPUCores, MemoryMB: row.MemoryMB, SystemDiskGB: row.SystemDiskGB, DataDiskGB: row.DataDiskGB, BandwidthMbps: row.BandwidthMbps}
+}
+
+// vmConfigSpec 把上游配置换算为实例规格：第一块磁盘视为系统盘，其余磁盘合计为数据盘。
+func vmConfigSpec(config mcppve.VMConfig) domaininstance.Spec {
+	spec := domaininstance.Spec{CPUCores: config.Cores, MemoryMB: config.Memory, BandwidthMbps: domaininstance.BandwidthFromRate(config.NetworkRate)}
+	for i, disk := range config.Disks {
+		if i == 0 {
+			spec.SystemDiskGB = disk.SizeGB
+			continue
+		}
+		spec.DataDiskGB += disk.SizeGB
+	}
+	return spec
+}
@@ -0,0 +1,167 @@
+package instance
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/cache"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+)
+
+// consoleSession 是 Redis 中暂存的控制台会话，只能被 WebSocket 连接消费一次。
+type consoleSession struct {
+	AdminID    uint64               `json:"admin_id"`
+	InstanceNo string               `json:"instance_no"`
+	ClusterNo  string               `json:"cluster_no"`
+	Node       string               `json:"node"`
+	VMID       uint                 `json:"vmid"`
+	Type       string               `json:"type"`
+	Ticket     mcppve.ConsoleTicket `json:"ticket"`
+}
+
+// ConsoleConnection 是已建立的上游控制台连接，Deadline 为本次会话的最长保持时间。
+type ConsoleConnection struct {
+	Upstream   io.ReadWriteCloser
+	AdminID    uint64
+	InstanceNo string
+	Type       string
+	Deadline   time.Time
+}
+
+// SetConsole 注入控制台会话存储和时长配置；未注入时控制台接口返回冲突错误。
+func (s *Service) SetConsole(redis *cache.Redis, cfg config.ConsoleConfig) *Service {
+	s.redis = redis
+	s.console = cfg
+	return s
+}
+
+// CreateConsole 为运行中的实例申请控制台票据，并签发一次性 WebSocket 会话令牌。
+func (s *Service) CreateConsole(ctx context.Context, operatorID uint64, instanceNo string, req admindto.InstanceConsoleRequest) (admindto.InstanceConsoleSession, error) {
+	if s.redis == nil || !s.console.Enabled {
+		return admindto.InstanceConsoleSession{}, apperrors.ErrConflict.WithMessage("控制台暂未开放")
+	}
+	consoleType := strings.TrimSpace(req.Type)
+	if consoleType == "" {
+		consoleType = domaininstance.ConsoleTypeVNC
+	}
+	if !domaininstance.IsKnownConsoleType(consoleType) {
+		return admindto.InstanceConsoleSession{}, apperrors.ErrValidation.WithMessage("控制台类型不支持")
+	}
+	row, err := s.consoleInstance(ctx, instanceNo)
+	if err != nil {
+		return admindto.InstanceConsoleSession{}, err
+	}
+	ticket, err := s.mcp.Cluster(row.ClusterNo).CreateConsoleTicket(ctx, row.ExternalNode, row.ExternalVMID, consoleType)
+	if err != nil {
+		return admindto.InstanceConsoleSession{}, externalError(err)
+	}
+	token, err := newConsoleToken()
+	if err != nil {
+		return admindto.InstanceConsoleSession{}, err
+	}
+	data, err := json.Marshal(consoleSession{AdminID: operatorID, InstanceNo: row.InstanceNo, ClusterNo: row.ClusterNo, Node: row.ExternalNode, VMID: row.ExternalVMID, Type: consoleType, Ticket: ticket})
+	if err != nil {
+		return admindto.InstanceConsoleSession{}, err
+	}
+	if err := s.redis.Client().Set(ctx, s.consoleKey(token), data, s.console.ConnectTTL()).Err(); err != nil {
+		return admindto.InstanceConsoleSession{}, err
+	}
+	if err := s.audit.Record(ctx, s.db, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance.console", ObjectType: objectType, ObjectID: row.InstanceNo, AfterData: map[string]any{"type": consoleType, "node": row.ExternalNode, "vmid": row.ExternalVMID}, Remark: "打开实例控制台"}); err != nil {
+		_ = s.redis.Client().Del(context.Background(), s.consoleKey(token)).Err()
+		return admindto.InstanceConsoleSession{}, err
+	}
+	return admindto.InstanceConsoleSession{
+		Type:               consoleType,
+		WebsocketPath:      "/admin-api/instance-consoles/" + token,
+		Password:           ticket.Ticket,
+		ExpiresAt:          time.Now().Add(s.console.ConnectTTL()),
+		MaxDurationSeconds: s.console.MaxDurationSeconds,
+	}, nil
+}
+
+// ConnectConsole 消费会话令牌并连接上游控制台；令牌过期、已使用或实例已不可连接时拒绝。
+func (s *Service) ConnectConsole(ctx context.Context, token string) (ConsoleConnection, error) {
+	if s.redis == nil || !s.console.Enabled {
+		return ConsoleConnection{}, apperrors.ErrConflict.WithMessage("控制台暂未开放")
+	}
+	raw, err := s.redis.Client().GetDel(ctx, s.consoleKey(strings.TrimSpace(token))).Result()
+	if errors.Is(err, goredis.Nil) {
+		return ConsoleConnection{}, apperrors.ErrUnauthorized.WithMessage("控制台会话已过期，请重新打开")
+	}
+	if err != nil {
+		return ConsoleConnection{}, err
+	}
+	var session consoleSession
+	if err := json.Unmarshal([]byte(raw), &session); err != nil {
+		return ConsoleConnection{}, apperrors.ErrUnauthorized.WithMessage("控制台会话无效")
+	}
+	row, err := s.consoleInstance(ctx, session.InstanceNo)
+	if err != nil {
+		return ConsoleConnection{}, err
+	}
+	if row.ExternalNode != session.Node || row.ExternalVMID != session.VMID {
+		return ConsoleConnection{}, apperrors.ErrConflict.WithMessage("实例位置已变化，请重新打开控制台")
+	}
+	upstream, err := s.mcp.Cluster(session.ClusterNo).DialConsole(ctx, session.Node, session.VMID, session.Ticket)
+	if err != nil {
+		return ConsoleConnection{}, externalError(err)
+	}
+	return ConsoleConnection{Upstream: upstream, AdminID: session.AdminID, InstanceNo: session.InstanceNo, Type: session.Type, Deadline: time.Now().Add(s.console.MaxDuration())}, nil
+}
+
+// ProxyConsole 在浏览器连接和上游控制台之间转发数据，到达单次会话时长上限时断开并记录会话结束。
+func (s *Service) ProxyConsole(ctx context.Context, client io.ReadWriteCloser, conn ConsoleConnection) {
+	startedAt := time.Now()
+	proxyCtx, cancel := context.WithDeadline(ctx, conn.Deadline)
+	defer cancel()
+	_ = mcppve.ProxyConsole(proxyCtx, client, conn.Upstream)
+	s.closeConsole(context.WithoutCancel(ctx), conn, startedAt)
+}
+
+func (s *Service) closeConsole(ctx context.Context, conn ConsoleConnection, startedAt time.Time) {
+	after := map[string]any{"type": conn.Type, "duration_seconds": int(time.Since(startedAt).Seconds()), "reached_limit": !time.Now().Before(conn.Deadline)}
+	_ = s.audit.Record(ctx, s.db, AdminAuditWriteInput{AdminID: &conn.AdminID, Action: "instance.console_close", ObjectType: objectType, ObjectID: conn.InstanceNo, AfterData: after, Remark: "关闭实例控制台"})
+}
+
+func (s *Service) consoleInstance(ctx context.Context, instanceNo string) (mysqlinstance.InstanceRow, error) {
+	if !s.mcp.Enabled() {
+		return mysqlinstance.InstanceRow{}, mcpUnavailableError()
+	}
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return mysqlinstance.InstanceRow{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return mysqlinstance.InstanceRow{}, err
+	}
+	if !domaininstance.CanOpenConsole(row.Status) {
+		return mysqlinstance.InstanceRow{}, apperrors.ErrConflict.WithMessage("实例未运行，无法打开控制台")
+	}
+	return row, nil
+}
+
+func (s *Service) consoleKey(token string) string {
+	return s.redis.Key("admin", "instance_console", token)
+}
+
+func newConsoleToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,98 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/password"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/secretbox"
+)
+
+// SetCredentialConfig 注入实例凭据加密配置；未配置加密密钥时交付不生成 root 密码。
+func (s *Service) SetCredentialConfig(cfg config.CredentialConfig) *Service {
+	s.credentials, _ = secretbox.New(cfg.EncryptionKey)
+	s.passwordLength = cfg.RootPasswordLength
+	return s
+}
+
+// newRootPassword 生成随机 root 密码并返回明文和密文；未配置加密密钥时返回空值，交付沿用镜像默认登录方式。
+// 明文只用于下发上游，不得写入数据库、日志或操作 payload。
+func (s *Service) newRootPassword() (string, *string, error) {
+	if s.credentials == nil {
+		return "", nil, nil
+	}
+	plain, err := password.Generate(s.passwordLength)
+	if err != nil {
+		return "", nil, err
+	}
+	sealed, err := s.credentials.Seal(plain)
+	if err != nil {
+		return "", nil, err
+	}
+	return plain, &sealed, nil
+}
+
+// errCredentialResetSkipped 表示转移后的密码重置已不适用：实例已再次转移或已进入释放流程。
+var errCredentialResetSkipped = errors.New("instance credential reset skipped")
+
+// ResetCredentialsByWorker 在实例转移给 userID 后重置 root 密码，使原所有者掌握的密码失效；成功后新所有者可查看一次新密码。
+// 实例未运行（guest agent 不可用）或已有未完成操作时延后重入；实例已释放、再次转移或未配置凭据加密时跳过。
+func (s *Service) ResetCredentialsByWorker(ctx context.Context, instanceNo string, userID uint64) error {
+	if s.credentials == nil {
+		return nil
+	}
+	guard := func(current mysqlinstance.Instance) error {
+		if current.UserID != userID || !domaininstance.CanTransfer(current.Status) {
+			return errCredentialResetSkipped
+		}
+		return nil
+	}
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if guard(row.Instance) != nil {
+		return nil
+	}
+	if !canOperate(row.Status, domaininstance.OperationResetPassword) {
+		return ErrOperationPending
+	}
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		plain, sealed, err := s.newRootPassword()
+		if err != nil {
+			return operationPlan{}, err
+		}
+		req := mcppve.SetVMPasswordRequest{Password: plain}
+		return operationPlan{payload: mysqlinstance.ResetPasswordPayload{PasswordCiphertext: *sealed}, call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).SetVMPassword(ctx, row.ExternalNode, row.ExternalVMID, req)
+		}}, nil
+	}
+	_, err = s.startOperation(ctx, instanceNo, nil, &userID, domaininstance.OperationResetPassword, ErrOperationPending, guard, planner)
+	if errors.Is(err, errCredentialResetSkipped) {
+		return nil
+	}
+	return err
+}
+
+// resetPasswordUpdates 在重置密码成功后替换实例密码密文，并清除查看标记允许用户再查看一次。
+func resetPasswordUpdates(op mysqlinstance.Operation) map[string]any {
+	if op.Action != domaininstance.OperationResetPassword || op.Payload == nil {
+		return nil
+	}
+	var payload mysqlinstance.ResetPasswordPayload
+	if err := json.Unmarshal([]byte(*op.Payload), &payload); err != nil || payload.PasswordCiphertext == "" {
+		return nil
+	}
+	return map[string]any{"root_password_ciphertext": payload.PasswordCiphertext, "root_password_revealed_at": nil}
+}
@@ -0,0 +1,140 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+)
+
+// InstanceFirewall 返回实例绑定的安全组、规则和防火墙下发状态。
+func (s *Service) InstanceFirewall(ctx context.Context, instanceNo string) (admindto.InstanceFirewall, error) {
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.InstanceFirewall{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return admindto.InstanceFirewall{}, err
+	}
+	groups, err := s.instances.InstanceSecurityGroups(ctx, nil, row.ID)
+	if err != nil {
+		return admindto.InstanceFirewall{}, err
+	}
+	ids := make([]uint64, 0, len(groups))
+	for _, group := range groups {
+		ids = append(ids, group.ID)
+	}
+	rules, err := s.instances.SecurityGroupRules(ctx, nil, ids)
+	if err != nil {
+		return admindto.InstanceFirewall{}, err
+	}
+	rulesByGroup := make(map[uint64][]admindto.SecurityGroupRule, len(groups))
+	for _, rule := range rules {
+		rulesByGroup[rule.SecurityGroupID] = append(rulesByGroup[rule.SecurityGroupID], admindto.SecurityGroupRule{Direction: rule.Direction, Protocol: rule.Protocol, PortRange: rule.PortRange, CIDR: rule.CIDR, Description: rule.Description})
+	}
+	items := make([]admindto.SecurityGroupItem, 0, len(groups))
+	for _, group := range groups {
+		groupRules := rulesByGroup[group.ID]
+		if groupRules == nil {
+			groupRules = []admindto.SecurityGroupRule{}
+		}
+		items = append(items, admindto.SecurityGroupItem{GroupNo: group.GroupNo, Name: group.Name, Description: group.Description, Rules: groupRules, UpdatedAt: group.UpdatedAt})
+	}
+	return admindto.InstanceFirewall{InstanceNo: row.InstanceNo, Status: row.FirewallStatus, SyncedAt: row.FirewallSyncedAt, SecurityGroups: items}, nil
+}
+
+// reconcileFirewall 核对 VM 防火墙与实例绑定的安全组：存在未确认的本地变更或上游配置被改动时整体重新下发。
+// 从未配置安全组的实例不接管上游防火墙；上游查询或下发失败只跳过本次核对，不影响状态同步结果。
+func (s *Service) reconcileFirewall(ctx context.Context, instanceNo string) error {
+	row, err := s.instances.Detail(ctx, instanceNo)
+	if err != nil {
+		return err
+	}
+	if row.FirewallStatus == nil {
+		return nil
+	}
+	groups, err := s.instances.InstanceSecurityGroups(ctx, nil, row.ID)
+	if err != nil {
+		return err
+	}
+	rules, err := s.instances.InstanceFirewallRules(ctx, nil, row.ID)
+	if err != nil {
+		return err
+	}
+	expected := vmFirewall(len(groups) > 0, rules)
+	drifted := false
+	if value(row.FirewallStatus) == domaininstance.FirewallStatusSynced {
+		actual, err := s.mcp.Cluster(row.ClusterNo).VMFirewall(ctx, row.ExternalNode, row.ExternalVMID)
+		if err != nil {
+			return nil
+		}
+		if firewallMatches(expected, actual) {
+			return nil
+		}
+		drifted = true
+	}
+	if err := s.mcp.Cluster(row.ClusterNo).SetVMFirewall(ctx, row.ExternalNode, row.ExternalVMID, expected); err != nil {
+		return nil
+	}
+	if err := s.instances.UpdateInstance(ctx, nil, row.ID, map[string]any{"firewall_status": domaininstance.FirewallStatusSynced, "firewall_synced_at": time.Now()}); err != nil {
+		return err
+	}
+	if drifted {
+		_ = s.audit.Record(ctx, nil, AdminAuditWriteInput{Action: "instance.firewall_drift", ObjectType: objectType, ObjectID: row.InstanceNo, AfterData: map[string]any{"rules": len(expected.Rules), "enabled": expected.Enabled}, Remark: "实例防火墙与安全组不一致，已重新下发"})
+	}
+	return nil
+}
+
+// vmFirewall 把安全组规则转换为上游防火墙配置；未绑定安全组时关闭防火墙并清空规则。
+func vmFirewall(enabled bool, rows []mysqlinstance.SecurityGroupRule) mcppve.VMFirewall {
+	if !enabled {
+		return mcppve.VMFirewall{PolicyIn: domaininstance.FirewallPolicyAccept, PolicyOut: domaininstance.FirewallPolicyAccept, Rules: []mcppve.FirewallRule{}}
+	}
+	domainRules := make([]domaininstance.FirewallRule, 0, len(rows))
+	rules := make([]mcppve.FirewallRule, 0, len(rows))
+	for _, row := range rows {
+		rule := domaininstance.FirewallRule{Direction: row.Direction, Protocol: row.Protocol, PortRange: value(row.PortRange), CIDR: row.CIDR}
+		domainRules = append(domainRules, rule)
+		item := mcppve.FirewallRule{Type: rule.Direction, Action: domaininstance.FirewallPolicyAccept, DPort: strings.ReplaceAll(rule.PortRange, "-", ":"), Comment: value(row.Description)}
+		if rule.Protocol != domaininstance.FirewallProtocolAny {
+			item.Proto = rule.Protocol
+		}
+		if rule.Direction == domaininstance.FirewallDirectionIn {
+			item.Source = rule.CIDR
+		} else {
+			item.Dest = rule.CIDR
+		}
+		rules = append(rules, item)
+	}
+	policyIn, policyOut := domaininstance.FirewallPolicies(domainRules)
+	return mcppve.VMFirewall{Enabled: true, PolicyIn: policyIn, PolicyOut: policyOut, Rules: rules}
+}
+
+// firewallMatches 比较期望配置与上游实际配置；规则备注不参与比较，防火墙关闭时只比较开关。
+func firewallMatches(expected, actual mcppve.VMFirewall) bool {
+	if expected.Enabled != actual.Enabled {
+		return false
+	}
+	if !expected.Enabled {
+		return true
+	}
+	if !strings.EqualFold(expected.PolicyIn, actual.PolicyIn) || !strings.EqualFold(expected.PolicyOut, actual.PolicyOut) || len(expected.Rules) != len(actual.Rules) {
+		return false
+	}
+	for i, want := range expected.Rules {
+		got := actual.Rules[i]
+		if !strings.EqualFold(want.Type, got.Type) || !strings.EqualFold(want.Action, got.Action) || !strings.EqualFold(want.Proto, got.Proto) ||
+			want.DPort != got.DPort || want.Source != got.Source || want.Dest != got.Dest {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,261 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+	adminsupport "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/support"
+)
+
+const ipPoolObjectType = "ip_pool"
+
+func (s *Service) ListIPPools(ctx context.Context, query admindto.IPPoolListQuery) (admindto.PageResponse[admindto.IPPoolItem], error) {
+	page, perPage := adminsupport.NormalizePage(query.Page, query.PerPage)
+	rows, total, err := s.instances.ListIPPools(ctx, mysqlinstance.IPPoolFilters{Status: query.Status, RegionNo: query.RegionNo, NetworkTypeNo: query.NetworkTypeNo}, perPage, (page-1)*perPage)
+	if err != nil {
+		return admindto.PageResponse[admindto.IPPoolItem]{}, err
+	}
+	items := make([]admindto.IPPoolItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, ipPoolItem(row))
+	}
+	return adminsupport.PageResponse(items, total, page, perPage), nil
+}
+
+// CreateIPPool 创建地址池并一次性展开全部主机地址；网关和保留区间内的地址直接标记为保留。
+func (s *Service) CreateIPPool(ctx context.Context, operatorID uint64, req admindto.IPPoolCreateRequest) (admindto.IPPoolItem, error) {
+	layout, err := domaininstance.ExpandIPPool(req.CIDR, req.Gateway, req.ReservedRanges)
+	if err != nil {
+		return admindto.IPPoolItem{}, ipPoolLayoutError(err)
+	}
+	pool := mysqlinstance.IPPool{PoolNo: strings.TrimSpace(req.PoolNo), Name: strings.TrimSpace(req.Name), RegionNo: strings.TrimSpace(req.RegionNo), NetworkTypeNo: strings.TrimSpace(req.NetworkTypeNo), CIDR: layout.CIDR, PrefixLength: layout.PrefixLength, Gateway: layout.Gateway, Status: strings.TrimSpace(req.Status), Remark: normalizeOptional(req.Remark)}
+	if pool.PoolNo == "" {
+		pool.PoolNo = fmt.Sprintf("IPP-%d", time.Now().UnixNano())
+	}
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		if err := s.instances.CreateIPPool(ctx, tx, &pool); err != nil {
+			return err
+		}
+		addresses := make([]mysqlinstance.IPAddress, 0, len(layout.Addresses))
+		for _, item := range layout.Addresses {
+			address := mysqlinstance.IPAddress{PoolID: pool.ID, Address: item.Address, Status: domaininstance.IPAddressStatusAvailable}
+			if item.Reserved {
+				address.Status = domaininstance.IPAddressStatusReserved
+				address.Remark = nullableString(item.Remark)
+			}
+			addresses = append(addresses, address)
+		}
+		if err := s.instances.CreateIPAddresses(ctx, tx, addresses); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "ip_pool.create", ObjectType: ipPoolObjectType, ObjectID: pool.PoolNo, AfterData: ipPoolAudit(pool), Remark: "创建 IP 地址池"})
+	})
+	if err != nil {
+		return admindto.IPPoolItem{}, err
+	}
+	return s.ipPool(ctx, pool.PoolNo)
+}
+
+// UpdateIPPool 只允许修改名称、状态和备注；网段和网关影响已分配地址的网络配置，创建后不可修改。
+func (s *Service) UpdateIPPool(ctx context.Context, operatorID uint64, poolNo string, req admindto.IPPoolUpdateRequest) (admindto.IPPoolItem, error) {
+	var pool mysqlinstance.IPPool
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.IPPoolForUpdate(ctx, tx, strings.TrimSpace(poolNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("IP 地址池不存在")
+		}
+		if err != nil {
+			return err
+		}
+		next := current
+		next.Name = strings.TrimSpace(req.Name)
+		next.Status = strings.TrimSpace(req.Status)
+		next.Remark = normalizeOptional(req.Remark)
+		if err := s.instances.UpdateIPPool(ctx, tx, current.ID, map[string]any{"name": next.Name, "status": next.Status, "remark": next.Remark}); err != nil {
+			return err
+		}
+		pool = next
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "ip_pool.update", ObjectType: ipPoolObjectType, ObjectID: current.PoolNo, BeforeData: ipPoolAudit(current), AfterData: ipPoolAudit(next), Remark: "更新 IP 地址池"})
+	})
+	if err != nil {
+		return admindto.IPPoolItem{}, err
+	}
+	return s.ipPool(ctx, pool.PoolNo)
+}
+
+func (s *Service) IPAddresses(ctx context.Context, poolNo string, query admindto.IPAddressListQuery) (admindto.PageResponse[admindto.IPAddressItem], error) {
+	pool, err := s.instances.IPPoolByNo(ctx, strings.TrimSpace(poolNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.PageResponse[admindto.IPAddressItem]{}, apperrors.ErrNotFound.WithMessage("IP 地址池不存在")
+	}
+	if err != nil {
+		return admindto.PageResponse[admindto.IPAddressItem]{}, err
+	}
+	page, perPage := adminsupport.NormalizePage(query.Page, query.PerPage)
+	rows, total, err := s.instances.ListIPAddresses(ctx, mysqlinstance.IPAddressFilters{PoolID: pool.ID, Status: query.Status, Keyword: query.Keyword}, perPage, (page-1)*perPage)
+	if err != nil {
+		return admindto.PageResponse[admindto.IPAddressItem]{}, err
+	}
+	items := make([]admindto.IPAddressItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, ipAddressItem(pool, row))
+	}
+	return adminsupport.PageResponse(items, total, page, perPage), nil
+}
+
+// ReserveIPAddress 把可用地址标记为保留，保留地址不参与自动分配。
+func (s *Service) ReserveIPAddress(ctx context.Context, operatorID uint64, poolNo string, id uint64, req admindto.IPAddressActionRequest) (admindto.IPAddressItem, error) {
+	return s.changeIPAddress(ctx, operatorID, poolNo, id, "ip_address.reserve", "保留 IP 地址", func(ctx context.Context, tx *gorm.DB, current mysqlinstance.IPAddress) (mysqlinstance.IPAddress, error) {
+		if current.Status != domaininstance.IPAddressStatusAvailable {
+			return current, apperrors.ErrConflict.WithMessage("只有可用地址可以保留")
+		}
+		current.Status = domaininstance.IPAddressStatusReserved
+		current.Remark = normalizeOptional(req.Remark)
+		return current, nil
+	})
+}
+
+// ReclaimIPAddress 把保留地址或残留在已释放实例上的地址退回可用；仍被未释放实例占用的地址不能回收。
+func (s *Service) ReclaimIPAddress(ctx context.Context, operatorID uint64, poolNo string, id uint64, req admindto.IPAddressActionRequest) (admindto.IPAddressItem, error) {
+	return s.changeIPAddress(ctx, operatorID, poolNo, id, "ip_address.reclaim", "回收 IP 地址", func(ctx context.Context, tx *gorm.DB, current mysqlinstance.IPAddress) (mysqlinstance.IPAddress, error) {
+		switch current.Status {
+		case domaininstance.IPAddressStatusReserved:
+		case domaininstance.IPAddressStatusAllocated:
+			if current.InstanceNo != nil {
+				// released 是终态，这里只读不加锁，避免与释放同步的实例锁、地址锁顺序相反。
+				instance, err := s.instances.Detail(ctx, *current.InstanceNo)
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return current, err
+				}
+				if err == nil && instance.Status != domaininstance.StatusReleased {
+					return current, apperrors.ErrConflict.WithMessage("地址仍被未释放的实例占用")
+				}
+			}
+		default:
+			return current, apperrors.ErrConflict.WithMessage("地址已是可用状态")
+		}
+		current.Status = domaininstance.IPAddressStatusAvailable
+		current.InstanceID, current.InstanceNo, current.AllocatedAt = nil, nil, nil
+		current.Remark = normalizeOptional(req.Remark)
+		return current, nil
+	})
+}
+
+func (s *Service) changeIPAddress(ctx context.Context, operatorID uint64, poolNo string, id uint64, action string, remark string, plan func(ctx context.Context, tx *gorm.DB, current mysqlinstance.IPAddress) (mysqlinstance.IPAddress, error)) (admindto.IPAddressItem, error) {
+	var item admindto.IPAddressItem
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		pool, err := s.instances.IPPoolForUpdate(ctx, tx, strings.TrimSpace(poolNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("IP 地址池不存在")
+		}
+		if err != nil {
+			return err
+		}
+		current, err := s.instances.IPAddressForUpdate(ctx, tx, pool.ID, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("IP 地址不存在")
+		}
+		if err != nil {
+			return err
+		}
+		next, err := plan(ctx, tx, current)
+		if err != nil {
+			return err
+		}
+		if err := s.instances.UpdateIPAddress(ctx, tx, current.ID, map[string]any{"status": next.Status, "instance_id": next.InstanceID, "instance_no": next.InstanceNo, "allocated_at": next.AllocatedAt, "remark": next.Remark}); err != nil {
+			return err
+		}
+		item = ipAddressItem(pool, next)
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: action, ObjectType: "ip_address", ObjectID: current.Address, BeforeData: ipAddressAudit(pool, current), AfterData: ipAddressAudit(pool, next), Remark: remark})
+	})
+	if err != nil {
+		return admindto.IPAddressItem{}, err
+	}
+	return item, nil
+}
+
+// allocateInstanceIPs 在交付事务内为实例分配 count 个地址。地域没有可用地址池时返回空列表，
+// 交付沿用映射上的 ipconfig0；存在地址池但地址不足时拒绝交付。
+func (s *Service) allocateInstanceIPs(ctx context.Context, tx *gorm.DB, instance mysqlinstance.Instance, count int) ([]mysqlinstance.IPLease, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+	pools, err := s.instances.IPPoolsForAllocation(ctx, tx, instance.RegionNo, value(instance.NetworkTypeNo))
+	if err != nil || len(pools) == 0 {
+		return nil, err
+	}
+	now := normalizeDBTime(time.Now())
+	for _, pool := range pools {
+		ok, err := s.instances.AllocateIPAddresses(ctx, tx, pool.ID, instance.ID, instance.InstanceNo, count, now)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return s.instances.InstanceIPLeases(ctx, tx, instance.ID)
+		}
+	}
+	return nil, apperrors.ErrConflict.WithMessage("IP 地址池可用地址不足")
+}
+
+func (s *Service) ipPool(ctx context.Context, poolNo string) (admindto.IPPoolItem, error) {
+	row, err := s.instances.IPPoolRow(ctx, poolNo)
+	if err != nil {
+		return admindto.IPPoolItem{}, err
+	}
+	return ipPoolItem(row), nil
+}
+
+// ipConfig0 优先使用实例分配的主地址生成静态网络配置，未分配地址时回退到映射上的 ipconfig0。
+func ipConfig0(leases []mysqlinstance.IPLease, mapping mysqlinstance.ProvisionMapping) string {
+	if len(leases) == 0 {
+		return value(mapping.IPConfig0)
+	}
+	return domaininstance.IPConfig(leases[0].Address, leases[0].PrefixLength, leases[0].Gateway)
+}
+
+func leaseAddresses(leases []mysqlinstance.IPLease) []string {
+	addresses := make([]string, 0, len(leases))
+	for _, lease := range leases {
+		addresses = append(addresses, lease.Address)
+	}
+	return addresses
+}
+
+func ipPoolLayoutError(err error) error {
+	switch {
+	case errors.Is(err, domaininstance.ErrIPPoolTooLarge):
+		return apperrors.ErrValidation.WithMessage(fmt.Sprintf("单个地址池最多 %d 个地址", domaininstance.MaxIPPoolAddresses))
+	case errors.Is(err, domaininstance.ErrInvalidGateway):
+		return apperrors.ErrValidation.WithMessage("网关必须是网段内的 IPv4 地址")
+	case errors.Is(err, domaininstance.ErrInvalidReservedSpan):
+		return apperrors.ErrValidation.WithMessage("保留地址区间不合法")
+	default:
+		return apperrors.ErrValidation.WithMessage("网段必须是合法的 IPv4 CIDR")
+	}
+}
+
+func ipPoolItem(row mysqlinstance.IPPoolRow) admindto.IPPoolItem {
+	return admindto.IPPoolItem{ID: row.ID, PoolNo: row.PoolNo, Name: row.Name, RegionNo: row.RegionNo, NetworkTypeNo: row.NetworkTypeNo, CIDR: row.CIDR, PrefixLength: row.PrefixLength, Gateway: row.Gateway, Status: row.Status, Remark: row.Remark, TotalCount: row.TotalCount, AvailableCount: row.AvailableCount, ReservedCount: row.ReservedCount, AllocatedCount: row.AllocatedCount, CreatedAt: row.CreatedAt, UpdatedAt: row.UpdatedAt}
+}
+
+func ipAddressItem(pool mysqlinstance.IPPool, row mysqlinstance.IPAddress) admindto.IPAddressItem {
+	return admindto.IPAddressItem{ID: row.ID, PoolNo: pool.PoolNo, Address: row.Address, Status: row.Status, InstanceNo: row.InstanceNo, AllocatedAt: row.AllocatedAt, Remark: row.Remark, UpdatedAt: row.UpdatedAt}
+}
+
+func ipPoolAudit(pool mysqlinstance.IPPool) map[string]any {
+	return map[string]any{"pool_no": pool.PoolNo, "name": pool.Name, "region_no": pool.RegionNo, "network_type_no": pool.NetworkTypeNo, "cidr": pool.CIDR, "gateway": pool.Gateway, "status": pool.Status}
+}
+
+func ipAddressAudit(pool mysqlinstance.IPPool, row mysqlinstance.IPAddress) map[string]any {
+	return map[string]any{"pool_no": pool.PoolNo, "address": row.Address, "status": row.Status, "instance_no": row.InstanceNo, "remark": row.Remark}
+}
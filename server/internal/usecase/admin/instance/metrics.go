@@ -0,0 +1,170 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/cache"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+)
+
+const bytesPerMB = 1 << 20
+
+// SetMetrics 注入监控缓存和采样配置；未注入 Redis 时每次请求直接读取上游。
+func (s *Service) SetMetrics(redis *cache.Redis, cfg config.MetricsConfig) *Service {
+	s.redis = redis
+	s.metrics = cfg
+	return s
+}
+
+// InstanceMetrics 返回实例在指定时间范围内的性能采样，缓存与用户端共用。
+func (s *Service) InstanceMetrics(ctx context.Context, instanceNo string, query admindto.InstanceMetricsQuery) (admindto.InstanceMetrics, error) {
+	metricsRange, err := normalizeMetricsRange(query.Range)
+	if err != nil {
+		return admindto.InstanceMetrics{}, err
+	}
+	if !s.mcp.Enabled() {
+		return admindto.InstanceMetrics{}, mcpUnavailableError()
+	}
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.InstanceMetrics{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return admindto.InstanceMetrics{}, err
+	}
+	if row.Status == domaininstance.StatusReleased || row.ExternalVMID == 0 {
+		return admindto.InstanceMetrics{}, apperrors.ErrConflict.WithMessage("实例未交付或已释放，暂无监控数据")
+	}
+	key := s.metricsKey("vm", row.ClusterNo, row.ExternalNode, strconv.FormatUint(uint64(row.ExternalVMID), 10), metricsRange)
+	points, err := s.cachedMetrics(ctx, key, func() ([]domaininstance.MetricPoint, error) {
+		rows, err := s.mcp.Cluster(row.ClusterNo).VMMetrics(ctx, row.ExternalNode, row.ExternalVMID, metricsRange)
+		if err != nil {
+			return nil, err
+		}
+		points := make([]domaininstance.MetricPoint, 0, len(rows))
+		for _, item := range rows {
+			points = append(points, rrdMetricPoint(item, item.Mem, item.MaxMem))
+		}
+		return points, nil
+	})
+	if err != nil {
+		return admindto.InstanceMetrics{}, err
+	}
+	return admindto.InstanceMetrics{Range: metricsRange, Points: metricPoints(points), GeneratedAt: time.Now()}, nil
+}
+
+// NodeMetrics 返回集群节点整体性能采样，并附带本地记录的该节点未释放实例数量和已分配规格合计。
+func (s *Service) NodeMetrics(ctx context.Context, node string, query admindto.NodeMetricsQuery) (admindto.NodeMetrics, error) {
+	metricsRange, err := normalizeMetricsRange(query.Range)
+	if err != nil {
+		return admindto.NodeMetrics{}, err
+	}
+	node = strings.TrimSpace(node)
+	if node == "" {
+		return admindto.NodeMetrics{}, apperrors.ErrValidation.WithMessage("节点不能为空")
+	}
+	if !s.mcp.Enabled() {
+		return admindto.NodeMetrics{}, mcpUnavailableError()
+	}
+	client := s.mcp.Cluster(query.ClusterNo)
+	points, err := s.cachedMetrics(ctx, s.metricsKey("node", client.ClusterNo(), node, metricsRange), func() ([]domaininstance.MetricPoint, error) {
+		rows, err := client.NodeMetrics(ctx, node, metricsRange)
+		if err != nil {
+			return nil, err
+		}
+		points := make([]domaininstance.MetricPoint, 0, len(rows))
+		for _, item := range rows {
+			points = append(points, rrdMetricPoint(item, item.MemUsed, item.MemTotal))
+		}
+		return points, nil
+	})
+	if err != nil {
+		return admindto.NodeMetrics{}, err
+	}
+	allocations, err := s.instances.NodeAllocations(ctx, client.ClusterNo(), []string{node}, 0)
+	if err != nil {
+		return admindto.NodeMetrics{}, err
+	}
+	result := admindto.NodeMetrics{ClusterNo: client.ClusterNo(), Node: node, Range: metricsRange, Points: metricPoints(points), GeneratedAt: time.Now()}
+	for _, allocation := range allocations {
+		result.Instances = allocation.Instances
+		result.AllocatedCPUCores = allocation.CPUCores
+		result.AllocatedMemoryMB = allocation.MemoryMB
+		result.AllocatedDiskGB = allocation.DiskGB
+	}
+	return result, nil
+}
+
+// cachedMetrics 优先读取短期缓存，未命中时查询上游并分桶后回写；缓存读写失败只回退到上游查询。
+func (s *Service) cachedMetrics(ctx context.Context, key string, load func() ([]domaininstance.MetricPoint, error)) ([]domaininstance.MetricPoint, error) {
+	if key != "" {
+		if raw, err := s.redis.Client().Get(ctx, key).Bytes(); err == nil {
+			var cached []domaininstance.MetricPoint
+			if json.Unmarshal(raw, &cached) == nil {
+				return cached, nil
+			}
+		}
+	}
+	points, err := load()
+	if err != nil {
+		return nil, externalError(err)
+	}
+	points = domaininstance.DownsampleMetrics(points, s.metrics.MaxPoints)
+	if key != "" {
+		if data, err := json.Marshal(points); err == nil {
+			_ = s.redis.Client().Set(ctx, key, data, s.metrics.CacheTTL()).Err()
+		}
+	}
+	return points, nil
+}
+
+// metricsKey 返回监控缓存 key，与用户端共用同一命名空间；未启用缓存时返回空串。
+func (s *Service) metricsKey(parts ...string) string {
+	if s.redis == nil || s.metrics.CacheTTLSeconds <= 0 {
+		return ""
+	}
+	return s.redis.Key(append([]string{"metrics"}, parts...)...)
+}
+
+func normalizeMetricsRange(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return domaininstance.MetricsRangeHour, nil
+	}
+	if !domaininstance.IsKnownMetricsRange(value) {
+		return "", apperrors.ErrValidation.WithMessage("监控时间范围不支持")
+	}
+	return value, nil
+}
+
+func rrdMetricPoint(item mcppve.RRDPoint, memUsed, memTotal *float64) domaininstance.MetricPoint {
+	return domaininstance.MetricPoint{
+		Time:          item.Time,
+		CPUPercent:    domaininstance.ScaleMetric(item.CPU, 100),
+		MemoryUsedMB:  domaininstance.ScaleMetric(memUsed, 1.0/bytesPerMB),
+		MemoryTotalMB: domaininstance.ScaleMetric(memTotal, 1.0/bytesPerMB),
+		DiskReadBps:   item.DiskRead,
+		DiskWriteBps:  item.DiskWrite,
+		NetInBps:      item.NetIn,
+		NetOutBps:     item.NetOut,
+	}
+}
+
+func metricPoints(points []domaininstance.MetricPoint) []admindto.MetricPoint {
+	items := make([]admindto.MetricPoint, 0, len(points))
+	for _, point := range points {
+		items = append(items, admindto.MetricPoint{Time: time.Unix(point.Time, 0), CPUPercent: point.CPUPercent, MemoryUsedMB: point.MemoryUsedMB, MemoryTotalMB: point.MemoryTotalMB, DiskReadBps: point.DiskReadBps, DiskWriteBps: point.DiskWriteBps, NetInBps: point.NetInBps, NetOutBps: point.NetOutBps})
+	}
+	return items
+}
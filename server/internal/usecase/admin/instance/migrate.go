@@ -0,0 +1,381 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+)
+
+const nodeObjectType = "mcp_node"
+
+var errMigrateSkipped = errors.New("migrate skipped")
+
+// migrationPool 缓存一次迁移调度所需的交付映射和单个集群的上游节点、存储容量；迁移只在集群内进行。
+// assigned 记录本批已指派到各节点的实例，节点疏散时计入目标节点占用，避免多台实例挤到同一节点。
+type migrationPool struct {
+	clusterNo   string
+	mappings    []mysqlinstance.ProvisionMapping
+	nodeList    []mcppve.Node
+	storageList []mcppve.Storage
+	assigned    map[string][]mysqlinstance.Instance
+}
+
+// MigrationTargets 返回实例可迁移的同地域节点及容量裁决，供后台选择目标节点。
+func (s *Service) MigrationTargets(ctx context.Context, instanceNo string) (admindto.InstanceMigrationTargets, error) {
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.InstanceMigrationTargets{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return admindto.InstanceMigrationTargets{}, err
+	}
+	pool, err := s.newMigrationPool(ctx, row.ClusterNo)
+	if err != nil {
+		return admindto.InstanceMigrationTargets{}, err
+	}
+	_, candidates, err := s.migrationCandidates(ctx, pool, row.Instance)
+	if err != nil {
+		return admindto.InstanceMigrationTargets{}, err
+	}
+	result := admindto.InstanceMigrationTargets{InstanceNo: row.InstanceNo, CurrentNode: row.ExternalNode, Candidates: make([]admindto.InstancePlacementCandidate, 0, len(candidates))}
+	for _, candidate := range candidates {
+		result.Candidates = append(result.Candidates, admindto.InstancePlacementCandidate(placementCandidate(candidate)))
+	}
+	return result, nil
+}
+
+// Migrate 把实例迁移到同地域的另一个节点，未指定目标时按交付调度规则选择；迁移完成后由同步回写实例所在节点。
+func (s *Service) Migrate(ctx context.Context, operatorID uint64, instanceNo string, req admindto.InstanceMigrateRequest) (admindto.InstanceDetail, error) {
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.InstanceDetail{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return admindto.InstanceDetail{}, err
+	}
+	if !domaininstance.CanMigrate(row.Status) {
+		return admindto.InstanceDetail{}, apperrors.ErrConflict.WithMessage("当前实例状态不能执行该操作")
+	}
+	if _, _, err := domaininstance.MigrateStrategy(row.Status, req.Mode); err != nil {
+		return admindto.InstanceDetail{}, migrateModeError(err)
+	}
+	if strings.TrimSpace(req.TargetNode) == row.ExternalNode {
+		return admindto.InstanceDetail{}, apperrors.ErrConflict.WithMessage("实例已在目标节点")
+	}
+	pool, err := s.newMigrationPool(ctx, row.ClusterNo)
+	if err != nil {
+		return admindto.InstanceDetail{}, err
+	}
+	mappingNo, candidates, err := s.migrationCandidates(ctx, pool, row.Instance)
+	if err != nil {
+		return admindto.InstanceDetail{}, err
+	}
+	target, reason := chooseMigrationTarget(candidates, req.TargetNode)
+	if target == "" {
+		return admindto.InstanceDetail{}, apperrors.ErrConflict.WithMessage(reason)
+	}
+	placement := s.placementRecord(mappingNo, target, candidates)
+	return s.startOperation(ctx, row.InstanceNo, &operatorID, nil, domaininstance.OperationMigrate, apperrors.ErrConflict.WithMessage("实例已有未完成操作"), nil, s.migratePlanner(row.ExternalNode, target, req.Mode, placement, false))
+}
+
+// EvacuateNode 为集群节点上的每台未释放实例选择同地域目标节点并投递迁移任务，由 Worker 逐台执行；
+// 不能迁移的实例在结果中给出原因，不影响其他实例。
+func (s *Service) EvacuateNode(ctx context.Context, operatorID uint64, node string, req admindto.NodeEvacuateRequest) (admindto.NodeEvacuateResult, error) {
+	node = strings.TrimSpace(node)
+	clusterNo := s.mcp.Cluster(req.ClusterNo).ClusterNo()
+	result := admindto.NodeEvacuateResult{ClusterNo: clusterNo, Node: node, Queued: []admindto.NodeEvacuateItem{}, Skipped: []admindto.NodeEvacuateItem{}}
+	if node == "" {
+		return result, apperrors.ErrValidation.WithMessage("节点不能为空")
+	}
+	rows, err := s.instances.NodeInstances(ctx, clusterNo, node)
+	if err != nil {
+		return result, err
+	}
+	if len(rows) == 0 {
+		return result, nil
+	}
+	pool, err := s.newMigrationPool(ctx, clusterNo)
+	if err != nil {
+		return result, err
+	}
+	batch := time.Now().UnixNano()
+	objectType := "instance"
+	tasks := make([]mysqlinstance.Task, 0, len(rows))
+	for _, row := range rows {
+		item := admindto.NodeEvacuateItem{InstanceNo: row.InstanceNo, Status: row.Status}
+		target, reason, err := s.evacuationTarget(ctx, pool, row, req.Mode)
+		if err != nil {
+			return result, err
+		}
+		if target == "" {
+			item.Reason = reason
+			result.Skipped = append(result.Skipped, item)
+			continue
+		}
+		pool.assigned[target] = append(pool.assigned[target], row)
+		item.TargetNode = target
+		result.Queued = append(result.Queued, item)
+
+		data, _ := json.Marshal(map[string]any{"instance_no": row.InstanceNo, "source_node": node, "target_node": target, "mode": req.Mode, "admin_id": operatorID})
+		objectNo := row.InstanceNo
+		key := fmt.Sprintf("migrate:%s:%d", row.InstanceNo, batch)
+		tasks = append(tasks, mysqlinstance.Task{TaskNo: fmt.Sprintf("TASK-%d-%d", batch, len(tasks)), TaskType: domaininstance.TaskTypeMigrate, IdempotencyKey: &key, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &objectNo, Payload: stringPtr(string(data)), MaxAttempts: 10, ScheduledAt: normalizeDBTime(time.Now())})
+	}
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		for i := range tasks {
+			if err := s.instances.CreateTaskIgnoreDuplicate(ctx, tx, &tasks[i]); err != nil {
+				return err
+			}
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance.evacuate", ObjectType: nodeObjectType, ObjectID: clusterNo + "/" + node, AfterData: map[string]any{"mode": req.Mode, "queued": result.Queued, "skipped": result.Skipped}, Remark: "疏散节点"})
+	})
+	if err != nil {
+		return admindto.NodeEvacuateResult{}, err
+	}
+	return result, nil
+}
+
+// MigrateByWorker 执行节点疏散投递的迁移；实例已不在源节点、已释放或状态不能迁移时跳过，实例忙碌时延后重入。
+func (s *Service) MigrateByWorker(ctx context.Context, instanceNo string, sourceNode string, targetNode string, mode string, adminID *uint64) error {
+	guard := func(current mysqlinstance.Instance) error {
+		if current.ExternalNode != sourceNode || !domaininstance.CanMigrate(current.Status) {
+			return errMigrateSkipped
+		}
+		return nil
+	}
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if guard(row.Instance) != nil {
+		return nil
+	}
+	_, err = s.startOperation(ctx, row.InstanceNo, adminID, nil, domaininstance.OperationMigrate, ErrOperationPending, guard, s.migratePlanner(sourceNode, targetNode, mode, nil, false))
+	if errors.Is(err, errMigrateSkipped) {
+		return nil
+	}
+	return err
+}
+
+func (s *Service) newMigrationPool(ctx context.Context, clusterNo string) (*migrationPool, error) {
+	if !s.mcp.Enabled() {
+		return nil, mcpUnavailableError()
+	}
+	mappings, err := s.instances.ActiveMappings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodeList, storageList, err := s.clusterInventory(ctx, clusterNo)
+	if err != nil {
+		return nil, err
+	}
+	return &migrationPool{clusterNo: clusterNo, mappings: mappings, nodeList: nodeList, storageList: storageList, assigned: map[string][]mysqlinstance.Instance{}}, nil
+}
+
+// migrationCandidates 按交付调度的容量规则评估实例在同地域其他节点上的放置，返回统计存储所用映射的编号和按优先级排序的候选。
+func (s *Service) migrationCandidates(ctx context.Context, pool *migrationPool, row mysqlinstance.Instance) (string, []domaininstance.PlacementCandidate, error) {
+	nodes, mapping, ok := migrationScope(pool.mappings, row)
+	if !ok || len(nodes) == 0 {
+		return mapping.MappingNo, nil, nil
+	}
+	allocations, err := s.instances.NodeAllocations(ctx, pool.clusterNo, nodes, row.UserID)
+	if err != nil {
+		return "", nil, err
+	}
+	capacities := nodeCapacities(nodes, pool.nodeList, pool.storageList, mapping.Storage, allocations)
+	for i := range capacities {
+		for _, assigned := range pool.assigned[capacities[i].Node] {
+			capacities[i].CommittedCPU += assigned.CPUCores
+			capacities[i].CommittedMemoryMB += int64(assigned.MemoryMB)
+			capacities[i].CommittedDiskGB += int64(assigned.SystemDiskGB + assigned.DataDiskGB)
+			capacities[i].Instances++
+			if assigned.UserID == row.UserID {
+				capacities[i].UserInstances++
+			}
+		}
+	}
+	demand := domaininstance.PlacementDemand{CPUCores: row.CPUCores, MemoryMB: row.MemoryMB, DiskGB: row.SystemDiskGB + row.DataDiskGB}
+	_, candidates := domaininstance.ChoosePlacement(capacities, demand, s.overcommitRatios(), s.placement.UserAntiAffinity)
+	return mapping.MappingNo, candidates, nil
+}
+
+// evacuationTarget 为疏散中的单台实例选择目标节点；实例不能迁移时返回空节点和原因，只有读取数据失败才返回错误。
+func (s *Service) evacuationTarget(ctx context.Context, pool *migrationPool, row mysqlinstance.Instance, mode string) (string, string, error) {
+	if !domaininstance.CanMigrate(row.Status) {
+		return "", "当前实例状态不能迁移", nil
+	}
+	if _, _, err := domaininstance.MigrateStrategy(row.Status, mode); err != nil {
+		return "", migrateModeError(err).Message, nil
+	}
+	_, candidates, err := s.migrationCandidates(ctx, pool, row)
+	if err != nil {
+		return "", "", err
+	}
+	target, reason := chooseMigrationTarget(candidates, "")
+	return target, reason, nil
+}
+
+// migratePlanner 在事务内确认实例仍在源节点，并按当前状态决定在线或离线迁移；placement 为本次调度的候选快照，Worker 重入时为 nil。
+// 离线迁移运行中的实例时本次只关机，迁移和重新开机由同步依次发起；restart 表示 VM 已由前一阶段关机，迁移结束后需要重新开机。
+func (s *Service) migratePlanner(sourceNode string, targetNode string, mode string, placement *mysqlinstance.Placement, restart bool) operationPlanner {
+	return func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		if current.ExternalNode != sourceNode {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("实例所在节点已变化，请刷新后重试")
+		}
+		if targetNode == "" || targetNode == sourceNode {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("实例已在目标节点")
+		}
+		online, fallback, err := domaininstance.MigrateStrategy(current.Status, mode)
+		if err != nil {
+			return operationPlan{}, migrateModeError(err)
+		}
+		if mode == "" {
+			mode = domaininstance.MigrateModeAuto
+		}
+		payload := mysqlinstance.MigratePayload{SourceNode: sourceNode, TargetNode: targetNode, Mode: mode, Online: online, Fallback: fallback, Restart: restart}
+		if !online && current.Status == domaininstance.StatusRunning {
+			payload.Stage = domaininstance.MigrateStageStop
+			payload.Restart = true
+			return operationPlan{payload: payload, placement: placement, call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+				return s.mcp.Cluster(row.ClusterNo).StopVM(ctx, row.ExternalNode, row.ExternalVMID)
+			}}, nil
+		}
+		req := mcppve.MigrateVMRequest{Target: targetNode, Online: online, WithLocalDisks: true}
+		return operationPlan{payload: payload, placement: placement, call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).MigrateVM(ctx, row.ExternalNode, row.ExternalVMID, req)
+		}}, nil
+	}
+}
+
+// migrateFallback 在迁移失败后继续处理：自动方式的在线迁移失败时改为离线迁移到同一目标节点，
+// 由迁移关机的 VM 离线迁移失败时在源节点重新开机；其他操作不处理。
+func (s *Service) migrateFallback(ctx context.Context, row mysqlinstance.Instance, op mysqlinstance.Operation) error {
+	payload, ok := migratePayload(op)
+	if !ok {
+		return nil
+	}
+	var err error
+	switch {
+	case payload.Online && payload.Fallback:
+		_, err = s.startOperation(ctx, row.InstanceNo, op.AdminID, nil, domaininstance.OperationMigrate, ErrOperationPending, nil, s.migratePlanner(payload.SourceNode, payload.TargetNode, domaininstance.MigrateModeOffline, nil, false))
+	case payload.Restart && payload.Stage == "":
+		_, err = s.startOperation(ctx, row.InstanceNo, op.AdminID, nil, domaininstance.OperationStart, ErrOperationPending, nil, nil)
+	}
+	return err
+}
+
+// migrateNextStage 在离线迁移运行中实例的某一阶段成功后发起下一阶段：关机完成后迁移，迁移完成后在目标节点重新开机。
+// 发起了下一阶段时返回 true。
+func (s *Service) migrateNextStage(ctx context.Context, row mysqlinstance.Instance, op mysqlinstance.Operation) (bool, error) {
+	payload, ok := migratePayload(op)
+	if !ok || !payload.Restart {
+		return false, nil
+	}
+	if payload.Stage == domaininstance.MigrateStageStop {
+		_, err := s.startOperation(ctx, row.InstanceNo, op.AdminID, nil, domaininstance.OperationMigrate, ErrOperationPending, nil, s.migratePlanner(payload.SourceNode, payload.TargetNode, domaininstance.MigrateModeOffline, nil, true))
+		return true, err
+	}
+	_, err := s.startOperation(ctx, row.InstanceNo, op.AdminID, nil, domaininstance.OperationStart, ErrOperationPending, nil, nil)
+	return true, err
+}
+
+// recordMigration 在迁移成功时写入迁移前后节点的审计，与发起迁移时的审计对应。
+func (s *Service) recordMigration(ctx context.Context, tx *gorm.DB, row mysqlinstance.Instance, op mysqlinstance.Operation) error {
+	payload, ok := migratePayload(op)
+	if !ok || payload.Stage == domaininstance.MigrateStageStop {
+		return nil
+	}
+	return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: op.AdminID, Action: "instance.migrate.complete", ObjectType: objectType, ObjectID: row.InstanceNo, BeforeData: map[string]any{"node": payload.SourceNode, "vmid": row.ExternalVMID}, AfterData: map[string]any{"node": payload.TargetNode, "vmid": row.ExternalVMID, "online": payload.Online}, Remark: "实例迁移完成"})
+}
+
+// migrationScope 返回实例所在地域启用映射覆盖的其他节点，以及容量统计使用的映射：优先取与实例套餐和模板一致的映射，
+// 否则取该地域的第一条映射。地域没有启用映射时 ok 为 false。
+func migrationScope(mappings []mysqlinstance.ProvisionMapping, row mysqlinstance.Instance) ([]string, mysqlinstance.ProvisionMapping, bool) {
+	var nodes []string
+	var mapping mysqlinstance.ProvisionMapping
+	found, exact := false, false
+	for _, item := range mappings {
+		if item.RegionNo != row.RegionNo {
+			continue
+		}
+		matches := item.PlanNo == row.PlanNo && item.TemplateNo == row.TemplateNo
+		if !found || (matches && !exact) {
+			mapping, found, exact = item, true, matches
+		}
+		for _, node := range domaininstance.PlacementNodes(item.Node, value(item.PlacementNodes)) {
+			if node != row.ExternalNode && !slices.Contains(nodes, node) {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return nodes, mapping, found
+}
+
+// chooseMigrationTarget 从按优先级排序的候选中取目标节点；指定目标时只校验该节点可放置。无法迁移时返回空节点和原因。
+func chooseMigrationTarget(candidates []domaininstance.PlacementCandidate, targetNode string) (string, string) {
+	if len(candidates) == 0 {
+		return "", "实例所在地域没有其他可迁移节点"
+	}
+	targetNode = strings.TrimSpace(targetNode)
+	for _, candidate := range candidates {
+		if targetNode == "" && candidate.Eligible {
+			return candidate.Node, ""
+		}
+		if candidate.Node == targetNode {
+			if !candidate.Eligible {
+				return "", "目标节点不满足迁移要求：" + candidate.Reason
+			}
+			return candidate.Node, ""
+		}
+	}
+	if targetNode != "" {
+		return "", "目标节点不在实例所在地域的候选节点中"
+	}
+	return "", "没有满足容量要求的迁移节点：" + placementRejections(candidates)
+}
+
+// migrateCompletionUpdates 在迁移成功后把实例所在节点改为目标节点，关机阶段只把实例置为关机，其他操作返回 nil。
+func migrateCompletionUpdates(op mysqlinstance.Operation) map[string]any {
+	payload, ok := migratePayload(op)
+	if !ok {
+		return nil
+	}
+	if payload.Stage == domaininstance.MigrateStageStop {
+		return map[string]any{"status": domaininstance.StatusStopped}
+	}
+	return map[string]any{"external_node": payload.TargetNode}
+}
+
+func migratePayload(op mysqlinstance.Operation) (mysqlinstance.MigratePayload, bool) {
+	if op.Action != domaininstance.OperationMigrate || op.Payload == nil {
+		return mysqlinstance.MigratePayload{}, false
+	}
+	var payload mysqlinstance.MigratePayload
+	if err := json.Unmarshal([]byte(*op.Payload), &payload); err != nil || strings.TrimSpace(payload.TargetNode) == "" {
+		return mysqlinstance.MigratePayload{}, false
+	}
+	return payload, true
+}
+
+func migrateModeError(err error) *apperrors.AppError {
+	if errors.Is(err, domaininstance.ErrMigrateOnlineRequired) {
+		return apperrors.ErrConflict.WithMessage("只有运行中的实例可以在线迁移")
+	}
+	return apperrors.ErrValidation.WithMessage("迁移方式不支持")
+}
@@ -0,0 +1,184 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqlorder "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/order"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+)
+
+// SetPlacementConfig 注入多节点交付调度的超分比例和用户反亲和策略；未注入时按不超分、不反亲和调度。
+func (s *Service) SetPlacementConfig(cfg config.PlacementConfig) *Service {
+	s.placement = cfg
+	return s
+}
+
+// placeOrder 为订单在交付映射的候选节点中选择放置节点。映射未配置额外候选节点、订单不可交付或缺少映射时
+// 返回 nil，由交付事务按原有规则处理；调度需要访问上游实时容量，因此必须在交付事务之外调用。
+func (s *Service) placeOrder(ctx context.Context, order mysqlorder.Order) (*mysqlinstance.Placement, error) {
+	if !domainorder.CanProvision(order.Status) {
+		return nil, nil
+	}
+	mapping, err := s.instances.MappingForProvision(ctx, nil, order.PlanNo, order.RegionNo, order.TemplateNo, order.NetworkTypeNo)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	nodes := domaininstance.PlacementNodes(mapping.Node, value(mapping.PlacementNodes))
+	if len(nodes) < 2 {
+		return nil, nil
+	}
+	clusterNo, err := s.regionCluster(ctx, mapping.RegionNo)
+	if err != nil {
+		return nil, err
+	}
+	nodeList, storageList, err := s.clusterInventory(ctx, clusterNo)
+	if err != nil {
+		return nil, err
+	}
+	allocations, err := s.instances.NodeAllocations(ctx, clusterNo, nodes, order.UserID)
+	if err != nil {
+		return nil, err
+	}
+	capacities := nodeCapacities(nodes, nodeList, storageList, mapping.Storage, allocations)
+	ratios := s.overcommitRatios()
+	demand := domaininstance.PlacementDemand{CPUCores: order.CPUCores, MemoryMB: order.MemoryMB, DiskGB: order.SystemDiskGB + order.DataDiskGB}
+	node, candidates := domaininstance.ChoosePlacement(capacities, demand, ratios, s.placement.UserAntiAffinity)
+	if node == "" {
+		return nil, apperrors.ErrConflict.WithMessage("没有满足容量要求的交付节点：" + placementRejections(candidates))
+	}
+	return s.placementRecord(mapping.MappingNo, node, candidates), nil
+}
+
+// placementRecord 组装写入操作记录的调度决策，包含当前超分配置和全部候选的裁决结果。
+func (s *Service) placementRecord(mappingNo string, node string, candidates []domaininstance.PlacementCandidate) *mysqlinstance.Placement {
+	ratios := s.overcommitRatios()
+	placement := &mysqlinstance.Placement{MappingNo: mappingNo, Node: node, Ratios: mysqlinstance.PlacementRatios{CPU: ratios.CPU, Memory: ratios.Memory, Storage: ratios.Storage, UserAntiAffinity: s.placement.UserAntiAffinity}}
+	for _, candidate := range candidates {
+		placement.Candidates = append(placement.Candidates, placementCandidate(candidate))
+	}
+	return placement
+}
+
+func placementRejections(candidates []domaininstance.PlacementCandidate) string {
+	reasons := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		reasons = append(reasons, candidate.Node+" "+candidate.Reason)
+	}
+	return strings.Join(reasons, "；")
+}
+
+// placementNode 校验调度结果仍适用于事务内锁定的映射，返回实例应交付的节点；未调度时使用映射主节点。
+func placementNode(placement *mysqlinstance.Placement, mapping mysqlinstance.ProvisionMapping) (string, error) {
+	if placement == nil {
+		return mapping.Node, nil
+	}
+	if placement.MappingNo != mapping.MappingNo || !slices.Contains(domaininstance.PlacementNodes(mapping.Node, value(mapping.PlacementNodes)), placement.Node) {
+		return "", apperrors.ErrConflict.WithMessage("交付映射候选节点已变更，请重试")
+	}
+	return placement.Node, nil
+}
+
+func (s *Service) overcommitRatios() domaininstance.OvercommitRatios {
+	ratios := domaininstance.OvercommitRatios{CPU: s.placement.CPUOvercommitRatio, Memory: s.placement.MemoryOvercommitRatio, Storage: s.placement.StorageOvercommitRatio}
+	if ratios.CPU <= 0 {
+		ratios.CPU = 1
+	}
+	if ratios.Memory <= 0 {
+		ratios.Memory = 1
+	}
+	if ratios.Storage <= 0 {
+		ratios.Storage = 1
+	}
+	return ratios
+}
+
+// nodeCapacities 合并上游节点列表、存储列表和本地已分配规格。存储按映射的系统盘存储统计，
+// 上游未返回节点专属条目时使用共享存储条目；找不到存储时容量记为未知。
+func nodeCapacities(nodes []string, nodeList []mcppve.Node, storageList []mcppve.Storage, storage string, allocations []mysqlinstance.NodeAllocation) []domaininstance.NodeCapacity {
+	upstream := map[string]mcppve.Node{}
+	for _, item := range nodeList {
+		upstream[mcpNode(item, "").Node] = item
+	}
+	capacities := make([]domaininstance.NodeCapacity, 0, len(nodes))
+	for _, node := range nodes {
+		item := upstream[node]
+		capacity := domaininstance.NodeCapacity{Node: node, Online: strings.TrimSpace(item.Status) == "online", MaxCPU: item.MaxCPU, CPUUsage: item.CPU, MemoryTotalBytes: item.MaxMem, MemoryUsedBytes: item.Mem}
+		capacity.StorageTotalBytes, capacity.StorageUsedBytes = storageUsage(storageList, storage, node)
+		for _, allocation := range allocations {
+			if allocation.Node == node {
+				capacity.CommittedCPU = allocation.CPUCores
+				capacity.CommittedMemoryMB = allocation.MemoryMB
+				capacity.CommittedDiskGB = allocation.DiskGB
+				capacity.Instances = allocation.Instances
+				capacity.UserInstances = allocation.UserInstances
+			}
+		}
+		capacities = append(capacities, capacity)
+	}
+	return capacities
+}
+
+func storageUsage(storageList []mcppve.Storage, storage string, node string) (int64, int64) {
+	var total, used int64
+	for _, item := range storageList {
+		if strings.TrimSpace(item.Storage) != storage {
+			continue
+		}
+		owner := strings.TrimSpace(item.Node)
+		if owner != "" && owner != node {
+			continue
+		}
+		total, used = item.Total, item.Used
+		if total == 0 {
+			total, used = item.MaxDisk, item.Disk
+		}
+		if owner == node {
+			break
+		}
+	}
+	return total, used
+}
+
+func placementCandidate(candidate domaininstance.PlacementCandidate) mysqlinstance.PlacementCandidate {
+	const mib = 1024 * 1024
+	const gib = 1024 * mib
+	return mysqlinstance.PlacementCandidate{Node: candidate.Node, Eligible: candidate.Eligible, Reason: candidate.Reason, Load: candidate.Load, MaxCPU: candidate.MaxCPU, CPUUsage: candidate.CPUUsage, MemoryTotalMB: candidate.MemoryTotalBytes / mib, MemoryUsedMB: candidate.MemoryUsedBytes / mib, StorageTotalGB: candidate.StorageTotalBytes / gib, StorageUsedGB: candidate.StorageUsedBytes / gib, CommittedCPU: candidate.CommittedCPU, CommittedMemoryMB: candidate.CommittedMemoryMB, CommittedDiskGB: candidate.CommittedDiskGB, UserInstances: candidate.UserInstances}
+}
+
+// placementText 序列化调度决策，写入 provision 或 migrate 操作；未调度时返回 nil。
+func placementText(placement *mysqlinstance.Placement) (*string, error) {
+	if placement == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(placement)
+	if err != nil {
+		return nil, err
+	}
+	return stringPtr(string(data)), nil
+}
+
+// normalizePlacementNodes 把候选节点输入规整为逗号分隔列表，空输入返回 nil。
+func normalizePlacementNodes(raw *string) *string {
+	if raw == nil {
+		return nil
+	}
+	nodes := domaininstance.PlacementNodes("", *raw)
+	if len(nodes) == 0 {
+		return nil
+	}
+	return stringPtr(strings.Join(nodes, ","))
+}
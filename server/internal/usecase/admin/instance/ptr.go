@@ -0,0 +1,156 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/rdns"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+	adminsupport "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/support"
+)
+
+const ptrObjectType = "ip_ptr_record"
+
+// SetRDNS 注入 PTR 管理配置、DNS 后端和正向解析器；resolver 为空时使用系统解析器。
+func (s *Service) SetRDNS(cfg config.RDNSConfig, backend rdns.Backend, resolver rdns.Resolver) *Service {
+	if resolver == nil {
+		resolver = rdns.DefaultResolver()
+	}
+	s.rdns, s.dns, s.resolver = cfg, backend, resolver
+	return s
+}
+
+// PTRRecords 返回 PTR 记录列表，按更新时间升序，便于按提交顺序处理审批队列。
+func (s *Service) PTRRecords(ctx context.Context, query admindto.PTRRecordListQuery) (admindto.PageResponse[admindto.PTRRecordItem], error) {
+	page, perPage := adminsupport.NormalizePage(query.Page, query.PerPage)
+	rows, total, err := s.instances.ListPTRRecords(ctx, mysqlinstance.PTRRecordFilters{Status: query.Status, Keyword: query.Keyword}, perPage, (page-1)*perPage)
+	if err != nil {
+		return admindto.PageResponse[admindto.PTRRecordItem]{}, err
+	}
+	items := make([]admindto.PTRRecordItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, ptrRecordItem(row))
+	}
+	return adminsupport.PageResponse(items, total, page, perPage), nil
+}
+
+// ApprovePTRRecord 审批待审或重试失败的 PTR：重新确认主机名仍正向解析到该地址后写入 DNS。
+func (s *Service) ApprovePTRRecord(ctx context.Context, operatorID uint64, id uint64, req admindto.PTRReviewRequest) (admindto.PTRRecordItem, error) {
+	if !s.rdns.Enabled || s.dns == nil {
+		return admindto.PTRRecordItem{}, apperrors.ErrConflict.WithMessage("未启用 PTR 管理")
+	}
+	current, err := s.instances.PTRRecordByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.PTRRecordItem{}, apperrors.ErrNotFound.WithMessage("PTR 记录不存在")
+	}
+	if err != nil {
+		return admindto.PTRRecordItem{}, err
+	}
+	resolved, err := s.resolver.LookupHost(ctx, current.Hostname)
+	if err != nil || !domaininstance.PTRForwardConfirmed(current.Address, resolved) {
+		return admindto.PTRRecordItem{}, apperrors.ErrConflict.WithMessage("主机名当前未正向解析到该地址")
+	}
+	var record mysqlinstance.PTRRecord
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		locked, err := s.instances.PTRRecordForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if locked.Status != domaininstance.PTRStatusPendingReview && locked.Status != domaininstance.PTRStatusFailed {
+			return apperrors.ErrConflict.WithMessage("只有待审批或写入失败的记录可以审批")
+		}
+		if locked.Hostname != current.Hostname {
+			return apperrors.ErrConflict.WithMessage("用户已修改主机名，请刷新后重试")
+		}
+		now := time.Now()
+		if err := s.instances.UpdatePTRRecord(ctx, tx, locked.ID, map[string]any{"status": domaininstance.PTRStatusApplying, "reviewed_by": operatorID, "reviewed_at": now, "review_remark": normalizeOptional(req.Remark)}); err != nil {
+			return err
+		}
+		record = locked
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "ptr.approve", ObjectType: ptrObjectType, ObjectID: locked.Address, BeforeData: map[string]any{"status": locked.Status, "applied_hostname": locked.AppliedHostname}, AfterData: map[string]any{"hostname": locked.Hostname, "instance_no": locked.InstanceNo}, Remark: "审批 PTR 记录"})
+	})
+	if err != nil {
+		return admindto.PTRRecordItem{}, err
+	}
+	s.applyPTR(ctx, record)
+	return s.ptrRecord(ctx, id)
+}
+
+// RejectPTRRecord 驳回待审批的 PTR；已生效的旧记录不受影响。
+func (s *Service) RejectPTRRecord(ctx context.Context, operatorID uint64, id uint64, req admindto.PTRReviewRequest) (admindto.PTRRecordItem, error) {
+	remark := normalizeOptional(req.Remark)
+	if remark == nil {
+		return admindto.PTRRecordItem{}, apperrors.ErrValidation.WithMessage("请填写驳回原因")
+	}
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.PTRRecordForUpdate(ctx, tx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("PTR 记录不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if current.Status != domaininstance.PTRStatusPendingReview && current.Status != domaininstance.PTRStatusFailed {
+			return apperrors.ErrConflict.WithMessage("只有待审批或写入失败的记录可以驳回")
+		}
+		if err := s.instances.UpdatePTRRecord(ctx, tx, current.ID, map[string]any{"status": domaininstance.PTRStatusRejected, "reviewed_by": operatorID, "reviewed_at": time.Now(), "review_remark": remark}); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "ptr.reject", ObjectType: ptrObjectType, ObjectID: current.Address, BeforeData: map[string]any{"status": current.Status, "hostname": current.Hostname}, AfterData: map[string]any{"status": domaininstance.PTRStatusRejected}, Remark: *remark})
+	})
+	if err != nil {
+		return admindto.PTRRecordItem{}, err
+	}
+	return s.ptrRecord(ctx, id)
+}
+
+// applyPTR 把记录写入 DNS 后端并保存结果；失败时标记 failed，保留在审批队列中等待重试。
+func (s *Service) applyPTR(ctx context.Context, record mysqlinstance.PTRRecord) {
+	if err := s.dns.SetPTR(ctx, record.Address, record.Hostname); err != nil {
+		_ = s.instances.UpdatePTRRecord(ctx, nil, record.ID, map[string]any{"status": domaininstance.PTRStatusFailed, "last_error": ptrErrorMessage(err)})
+		return
+	}
+	_ = s.instances.UpdatePTRRecord(ctx, nil, record.ID, map[string]any{"status": domaininstance.PTRStatusActive, "applied_hostname": record.Hostname, "applied_at": time.Now(), "last_error": nil})
+}
+
+// removePTRs 在实例释放后从 DNS 后端删除已生效的 PTR；删除失败只影响 DNS 残留，不阻塞释放。
+func (s *Service) removePTRs(ctx context.Context, records []mysqlinstance.PTRRecord) {
+	if s.dns == nil {
+		return
+	}
+	for _, record := range records {
+		if record.AppliedHostname != nil {
+			_ = s.dns.DeletePTR(ctx, record.Address)
+		}
+	}
+}
+
+func (s *Service) ptrRecord(ctx context.Context, id uint64) (admindto.PTRRecordItem, error) {
+	row, err := s.instances.PTRRecordByID(ctx, id)
+	if err != nil {
+		return admindto.PTRRecordItem{}, err
+	}
+	return ptrRecordItem(row), nil
+}
+
+func ptrRecordItem(row mysqlinstance.PTRRecord) admindto.PTRRecordItem {
+	return admindto.PTRRecordItem{ID: row.ID, Address: row.Address, InstanceNo: row.InstanceNo, UserID: row.UserID, Hostname: row.Hostname, AppliedHostname: row.AppliedHostname, Status: row.Status, LastError: row.LastError, ReviewedBy: row.ReviewedBy, ReviewedAt: row.ReviewedAt, ReviewRemark: row.ReviewRemark, AppliedAt: row.AppliedAt, CreatedAt: row.CreatedAt, UpdatedAt: row.UpdatedAt}
+}
+
+// ptrErrorMessage 截断 DNS 后端错误，避免超出字段长度。
+func ptrErrorMessage(err error) string {
+	message := strings.TrimSpace(err.Error())
+	if runes := []rune(message); len(runes) > 200 {
+		message = string(runes[:200])
+	}
+	return message
+}
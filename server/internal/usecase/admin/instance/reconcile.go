@@ -0,0 +1,485 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqlorder "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/order"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/textutil"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+	adminsupport "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/support"
+)
+
+const (
+	reconcileObjectType = "instance_reconcile"
+
+	// reconcileErrorVMMissing 是对账确认 VM 丢失后写入实例的错误码。
+	reconcileErrorVMMissing = "reconcile_vm_missing"
+)
+
+// Reconcile 由管理员手动触发一次对账，返回生成的报告。
+func (s *Service) Reconcile(ctx context.Context, operatorID uint64) (admindto.ReconcileReportItem, error) {
+	return s.reconcile(ctx, nil, &operatorID)
+}
+
+// ReconcileByWorker 由周期对账任务触发，报告记录任务编号。
+func (s *Service) ReconcileByWorker(ctx context.Context, taskNo string) (admindto.ReconcileReportItem, error) {
+	return s.reconcile(ctx, stringPtr(taskNo), nil)
+}
+
+func (s *Service) ListReconcileReports(ctx context.Context, query admindto.ReconcileReportListQuery) (admindto.PageResponse[admindto.ReconcileReportItem], error) {
+	page, perPage := adminsupport.NormalizePage(query.Page, query.PerPage)
+	rows, total, err := s.instances.ListReconcileReports(ctx, perPage, (page-1)*perPage)
+	if err != nil {
+		return admindto.PageResponse[admindto.ReconcileReportItem]{}, err
+	}
+	items := make([]admindto.ReconcileReportItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, reconcileReportItem(row))
+	}
+	return adminsupport.PageResponse(items, total, page, perPage), nil
+}
+
+func (s *Service) ReconcileItems(ctx context.Context, reportNo string, query admindto.ReconcileItemListQuery) (admindto.PageResponse[admindto.ReconcileItem], error) {
+	report, err := s.instances.ReconcileReportByNo(ctx, strings.TrimSpace(reportNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.PageResponse[admindto.ReconcileItem]{}, apperrors.ErrNotFound.WithMessage("对账报告不存在")
+	}
+	if err != nil {
+		return admindto.PageResponse[admindto.ReconcileItem]{}, err
+	}
+	page, perPage := adminsupport.NormalizePage(query.Page, query.PerPage)
+	rows, total, err := s.instances.ListReconcileItems(ctx, mysqlinstance.ReconcileItemFilters{ReportID: report.ID, Kind: query.Kind, Status: query.Status}, perPage, (page-1)*perPage)
+	if err != nil {
+		return admindto.PageResponse[admindto.ReconcileItem]{}, err
+	}
+	items := make([]admindto.ReconcileItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, reconcileItem(row))
+	}
+	return adminsupport.PageResponse(items, total, page, perPage), nil
+}
+
+// AdoptReconcileOrphan 把孤儿 VM 接管到用户名下：按所选套餐组合生成一笔零元已完成订单和实例记录，
+// 实例状态取 VM 当前电源状态，并按到期时间投递到期提醒和释放任务。VM 本身不做任何变更。
+func (s *Service) AdoptReconcileOrphan(ctx context.Context, operatorID uint64, id uint64, req admindto.ReconcileAdoptRequest) (admindto.ReconcileItem, error) {
+	item, err := s.openReconcileItem(ctx, id, domaininstance.ReconcileKindOrphan)
+	if err != nil {
+		return admindto.ReconcileItem{}, err
+	}
+	vm, found, err := s.liveVM(ctx, item.ClusterNo, item.Node, item.VMID)
+	if err != nil {
+		return admindto.ReconcileItem{}, err
+	}
+	if !found {
+		return admindto.ReconcileItem{}, apperrors.ErrConflict.WithMessage("虚拟机已不存在，请重新对账")
+	}
+	if _, err := s.users.FindUserByID(ctx, nil, req.UserID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.ReconcileItem{}, apperrors.ErrValidation.WithMessage("用户不存在")
+	} else if err != nil {
+		return admindto.ReconcileItem{}, err
+	}
+	selection, err := s.orders.CatalogSelection(ctx, strings.TrimSpace(req.PlanNo), strings.TrimSpace(req.BillingCycle), strings.TrimSpace(req.RegionNo), strings.TrimSpace(req.TemplateNo), strings.TrimSpace(req.NetworkTypeNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.ReconcileItem{}, apperrors.ErrValidation.WithMessage("套餐、周期、地域、网络类型或系统模板不可用")
+	}
+	if err != nil {
+		return admindto.ReconcileItem{}, err
+	}
+	months, ok := domainorder.BillingCycleMonths(selection.BillingCycle)
+	if !ok {
+		return admindto.ReconcileItem{}, apperrors.ErrValidation.WithMessage("订单周期不支持")
+	}
+	clusterNo, err := s.regionCluster(ctx, selection.RegionNo)
+	if err != nil {
+		return admindto.ReconcileItem{}, err
+	}
+	if clusterNo != item.ClusterNo {
+		return admindto.ReconcileItem{}, apperrors.ErrValidation.WithMessage("所选地域未绑定该虚拟机所在集群")
+	}
+	now := normalizeDBTime(time.Now())
+	expiresAt := normalizeDBTime(now.AddDate(0, months, 0))
+	if req.ExpiresAt != nil {
+		expiresAt = normalizeDBTime(*req.ExpiresAt)
+	}
+	if !expiresAt.After(now) {
+		return admindto.ReconcileItem{}, apperrors.ErrValidation.WithMessage("到期时间必须晚于当前时间")
+	}
+	remark := normalizeOptional(req.Remark)
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		if _, err := s.lockOpenReconcileItem(ctx, tx, id); err != nil {
+			return err
+		}
+		if existing, err := s.instances.InstanceByExternalVM(ctx, tx, item.ClusterNo, item.Node, item.VMID); err == nil {
+			return apperrors.ErrConflict.WithMessage("该节点 VMID 已关联未释放实例：" + existing.InstanceNo)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		order := adoptedOrder(req.UserID, id, selection, item, remark, now)
+		if err := s.orders.Create(ctx, tx, &order); err != nil {
+			return err
+		}
+		created := instanceFromOrder(order, item.Node, item.VMID)
+		created.ClusterNo = item.ClusterNo
+		created.Status = domaininstance.MapVMStatus(vm.Status)
+		created.ServiceStartedAt = &now
+		created.ExpiresAt = &expiresAt
+		if s.lifecycle.AutoReleaseEnabled {
+			releaseAt := normalizeDBTime(expiresAt.Add(time.Duration(s.lifecycle.ExpireReleaseAfterSeconds) * time.Second))
+			created.ExpireReleaseScheduledAt = &releaseAt
+		}
+		if err := s.instances.CreateInstance(ctx, tx, &created); err != nil {
+			return err
+		}
+		if err := s.enqueueLifecycleTasks(ctx, tx, created.InstanceNo, expiresAt); err != nil {
+			return err
+		}
+		if err := s.instances.UpdateReconcileItem(ctx, tx, id, map[string]any{"status": domaininstance.ReconcileItemResolved, "resolution": domaininstance.ReconcileResolutionAdopted, "instance_id": created.ID, "instance_no": created.InstanceNo, "instance_status": created.Status, "resolved_by": operatorID, "resolved_at": now, "remark": remark}); err != nil {
+			return err
+		}
+		after := instanceAudit(created)
+		after["user_id"] = created.UserID
+		after["plan_no"] = created.PlanNo
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance.reconcile.adopt", ObjectType: objectType, ObjectID: created.InstanceNo, AfterData: after, Remark: "接管孤儿虚拟机"})
+	})
+	if err != nil {
+		return admindto.ReconcileItem{}, err
+	}
+	return s.reconcileItemDetail(ctx, id)
+}
+
+// MarkReconcileGhostError 把 VM 已丢失的幽灵实例标记为异常。标记前重新列出节点 VM，VM 已恢复时拒绝处理。
+func (s *Service) MarkReconcileGhostError(ctx context.Context, operatorID uint64, id uint64, req admindto.ReconcileResolveRequest) (admindto.ReconcileItem, error) {
+	item, err := s.openReconcileItem(ctx, id, domaininstance.ReconcileKindGhost)
+	if err != nil {
+		return admindto.ReconcileItem{}, err
+	}
+	if _, found, err := s.liveVM(ctx, item.ClusterNo, item.Node, item.VMID); err != nil {
+		return admindto.ReconcileItem{}, err
+	} else if found {
+		return admindto.ReconcileItem{}, apperrors.ErrConflict.WithMessage("虚拟机已在节点上出现，请重新对账")
+	}
+	remark := normalizeOptional(req.Remark)
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		if _, err := s.lockOpenReconcileItem(ctx, tx, id); err != nil {
+			return err
+		}
+		current, err := s.instances.InstanceForUpdate(ctx, tx, value(item.InstanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if current.ClusterNo != item.ClusterNo || current.ExternalNode != item.Node || current.ExternalVMID != item.VMID {
+			return apperrors.ErrConflict.WithMessage("实例所在节点已变更，请重新对账")
+		}
+		switch current.Status {
+		case domaininstance.StatusCreating, domaininstance.StatusReleasing, domaininstance.StatusReleased:
+			return apperrors.ErrConflict.WithMessage("当前实例状态不能标记为异常")
+		}
+		if err := s.ensureNoRunningOperation(ctx, tx, current.ID, nil); err != nil {
+			return err
+		}
+		message := fmt.Sprintf("对账未在节点 %s 找到 VMID %d", item.Node, item.VMID)
+		if err := s.instances.UpdateInstance(ctx, tx, current.ID, map[string]any{"status": domaininstance.StatusError, "last_error_code": reconcileErrorVMMissing, "last_error_message": message}); err != nil {
+			return err
+		}
+		now := normalizeDBTime(time.Now())
+		if err := s.instances.UpdateReconcileItem(ctx, tx, id, map[string]any{"status": domaininstance.ReconcileItemResolved, "resolution": domaininstance.ReconcileResolutionMarkedError, "instance_status": domaininstance.StatusError, "resolved_by": operatorID, "resolved_at": now, "remark": remark}); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance.reconcile.mark_error", ObjectType: objectType, ObjectID: current.InstanceNo, BeforeData: instanceAudit(current), AfterData: map[string]any{"status": domaininstance.StatusError, "last_error_message": message, "remark": remark}, Remark: "对账幽灵实例标记为异常"})
+	})
+	if err != nil {
+		return admindto.ReconcileItem{}, err
+	}
+	return s.reconcileItemDetail(ctx, id)
+}
+
+// DeleteReconcileOrphan 删除孤儿 VM。删除前重新列出节点 VM 并确认没有未释放实例占用该 VMID；
+// 上游接受删除请求后即标记差异已处理，删除进度以上游任务为准。
+func (s *Service) DeleteReconcileOrphan(ctx context.Context, operatorID uint64, id uint64, req admindto.ReconcileResolveRequest) (admindto.ReconcileItem, error) {
+	item, err := s.openReconcileItem(ctx, id, domaininstance.ReconcileKindOrphan)
+	if err != nil {
+		return admindto.ReconcileItem{}, err
+	}
+	if _, found, err := s.liveVM(ctx, item.ClusterNo, item.Node, item.VMID); err != nil {
+		return admindto.ReconcileItem{}, err
+	} else if !found {
+		return admindto.ReconcileItem{}, apperrors.ErrConflict.WithMessage("虚拟机已不存在，请重新对账")
+	}
+	if existing, err := s.instances.InstanceByExternalVM(ctx, nil, item.ClusterNo, item.Node, item.VMID); err == nil {
+		return admindto.ReconcileItem{}, apperrors.ErrConflict.WithMessage("该虚拟机已关联实例：" + existing.InstanceNo)
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.ReconcileItem{}, err
+	}
+	accepted, err := s.mcp.Cluster(item.ClusterNo).DeleteVM(ctx, item.Node, item.VMID)
+	if err != nil {
+		return admindto.ReconcileItem{}, externalError(err)
+	}
+	remark := normalizeOptional(req.Remark)
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		if _, err := s.lockOpenReconcileItem(ctx, tx, id); err != nil {
+			return err
+		}
+		now := normalizeDBTime(time.Now())
+		if err := s.instances.UpdateReconcileItem(ctx, tx, id, map[string]any{"status": domaininstance.ReconcileItemResolved, "resolution": domaininstance.ReconcileResolutionDeleted, "resolved_by": operatorID, "resolved_at": now, "remark": remark}); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance.reconcile.delete_vm", ObjectType: reconcileObjectType, ObjectID: fmt.Sprintf("%s/%s/%d", item.ClusterNo, item.Node, item.VMID), BeforeData: map[string]any{"cluster_no": item.ClusterNo, "node": item.Node, "vmid": item.VMID, "vm_name": item.VMName, "vm_status": item.VMStatus}, AfterData: map[string]any{"operation_id": accepted.OperationID, "remark": remark}, Remark: "删除对账孤儿虚拟机"})
+	})
+	if err != nil {
+		return admindto.ReconcileItem{}, err
+	}
+	return s.reconcileItemDetail(ctx, id)
+}
+
+// reconcile 列出交付映射和未释放实例涉及的全部集群节点上的 VM 并与实例记录比对，写入一份报告；
+// 节点名和 VMID 只在集群内唯一，比对按集群分别进行。
+// 单个节点列表失败时记入报告并跳过该节点；全部节点失败时报告标记为失败并返回错误，供周期任务重试。
+func (s *Service) reconcile(ctx context.Context, taskNo *string, adminID *uint64) (admindto.ReconcileReportItem, error) {
+	if !s.mcp.Enabled() {
+		return admindto.ReconcileReportItem{}, mcpUnavailableError()
+	}
+	mappings, err := s.instances.AllMappings(ctx)
+	if err != nil {
+		return admindto.ReconcileReportItem{}, err
+	}
+	rows, err := s.instances.ReconcileInstances(ctx)
+	if err != nil {
+		return admindto.ReconcileReportItem{}, err
+	}
+	clusters, err := s.regionClusters(ctx, mappings)
+	if err != nil {
+		return admindto.ReconcileReportItem{}, err
+	}
+	report := mysqlinstance.ReconcileReport{ReportNo: fmt.Sprintf("REC-%d", time.Now().UnixNano()), Status: domaininstance.ReconcileReportRunning, TaskNo: taskNo, AdminID: adminID, StartedAt: normalizeDBTime(time.Now())}
+	if err := s.instances.CreateReconcileReport(ctx, nil, &report); err != nil {
+		return admindto.ReconcileReportItem{}, err
+	}
+	nodes := reconcileNodes(mappings, clusters, rows)
+	vms := map[string][]domaininstance.ReconcileVM{}
+	scanned := map[string][]string{}
+	scannedCount, vmCount := 0, 0
+	nodeErrors := []admindto.ReconcileNodeError{}
+	var lastErr error
+	for _, node := range nodes {
+		list, err := s.mcp.Cluster(node.clusterNo).NodeVMs(ctx, node.node)
+		if err != nil {
+			nodeErrors = append(nodeErrors, admindto.ReconcileNodeError{ClusterNo: node.clusterNo, Node: node.node, Message: textutil.TrimTo(externalStoredMessage(err), 255)})
+			lastErr = err
+			continue
+		}
+		scannedCount++
+		scanned[node.clusterNo] = append(scanned[node.clusterNo], node.node)
+		nodeVMs := reconcileVMs(node.node, list)
+		vmCount += len(nodeVMs)
+		vms[node.clusterNo] = append(vms[node.clusterNo], nodeVMs...)
+	}
+	errorsText, err := json.Marshal(nodeErrors)
+	if err != nil {
+		return admindto.ReconcileReportItem{}, err
+	}
+	if scannedCount == 0 && len(nodes) > 0 {
+		_ = s.instances.UpdateReconcileReport(context.Background(), nil, report.ID, map[string]any{"status": domaininstance.ReconcileReportFailed, "node_count": len(nodes), "node_errors": string(errorsText), "error_message": "全部节点列出虚拟机失败", "finished_at": normalizeDBTime(time.Now())})
+		return admindto.ReconcileReportItem{}, externalError(lastErr)
+	}
+	instances := map[string][]domaininstance.ReconcileInstance{}
+	for _, row := range rows {
+		instances[row.ClusterNo] = append(instances[row.ClusterNo], domaininstance.ReconcileInstance{ID: row.ID, InstanceNo: row.InstanceNo, Status: row.Status, Node: row.ExternalNode, VMID: row.ExternalVMID, CPUCores: row.CPUCores, MemoryMB: row.MemoryMB})
+	}
+	var items []mysqlinstance.ReconcileItem
+	updates := map[string]any{"status": domaininstance.ReconcileReportSucceeded, "node_count": len(nodes), "vm_count": vmCount, "instance_count": len(rows), "node_errors": string(errorsText), "finished_at": normalizeDBTime(time.Now())}
+	counts := map[string]int{}
+	for _, clusterNo := range reconcileClusters(nodes) {
+		for _, finding := range domaininstance.Reconcile(vms[clusterNo], instances[clusterNo], scanned[clusterNo], managedVMID(mappings, clusters, clusterNo)) {
+			items = append(items, reconcileItemFromFinding(report.ID, clusterNo, finding))
+			counts[finding.Kind]++
+		}
+	}
+	updates["orphan_count"] = counts[domaininstance.ReconcileKindOrphan]
+	updates["ghost_count"] = counts[domaininstance.ReconcileKindGhost]
+	updates["drift_count"] = counts[domaininstance.ReconcileKindDrift]
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		if err := s.instances.CreateReconcileItems(ctx, tx, items); err != nil {
+			return err
+		}
+		return s.instances.UpdateReconcileReport(ctx, tx, report.ID, updates)
+	})
+	if err != nil {
+		_ = s.instances.UpdateReconcileReport(context.Background(), nil, report.ID, map[string]any{"status": domaininstance.ReconcileReportFailed, "error_message": textutil.TrimTo(err.Error(), 500), "finished_at": normalizeDBTime(time.Now())})
+		return admindto.ReconcileReportItem{}, err
+	}
+	saved, err := s.instances.ReconcileReportByNo(ctx, report.ReportNo)
+	if err != nil {
+		return admindto.ReconcileReportItem{}, err
+	}
+	return reconcileReportItem(saved), nil
+}
+
+// openReconcileItem 读取待处理的对账差异并校验差异类型，处理动作在事务内还会重新锁定确认。
+func (s *Service) openReconcileItem(ctx context.Context, id uint64, kind string) (mysqlinstance.ReconcileItemRow, error) {
+	if !s.mcp.Enabled() {
+		return mysqlinstance.ReconcileItemRow{}, mcpUnavailableError()
+	}
+	item, err := s.instances.ReconcileItemRow(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return item, apperrors.ErrNotFound.WithMessage("对账差异不存在")
+	}
+	if err != nil {
+		return item, err
+	}
+	if item.Kind != kind {
+		return item, apperrors.ErrConflict.WithMessage("该对账差异不支持此处理方式")
+	}
+	if item.Status != domaininstance.ReconcileItemOpen {
+		return item, apperrors.ErrConflict.WithMessage("对账差异已处理")
+	}
+	return item, nil
+}
+
+func (s *Service) lockOpenReconcileItem(ctx context.Context, tx *gorm.DB, id uint64) (mysqlinstance.ReconcileItem, error) {
+	item, err := s.instances.ReconcileItemForUpdate(ctx, tx, id)
+	if err != nil {
+		return item, err
+	}
+	if item.Status != domaininstance.ReconcileItemOpen {
+		return item, apperrors.ErrConflict.WithMessage("对账差异已处理")
+	}
+	return item, nil
+}
+
+// liveVM 重新列出集群节点 VM 并查找 vmid，返回 VM 当前状态以及是否仍存在。
+func (s *Service) liveVM(ctx context.Context, clusterNo string, node string, vmid uint) (domaininstance.ReconcileVM, bool, error) {
+	list, err := s.mcp.Cluster(clusterNo).NodeVMs(ctx, node)
+	if err != nil {
+		return domaininstance.ReconcileVM{}, false, externalError(err)
+	}
+	for _, vm := range reconcileVMs(node, list) {
+		if vm.VMID == vmid {
+			return vm, true, nil
+		}
+	}
+	return domaininstance.ReconcileVM{}, false, nil
+}
+
+func (s *Service) reconcileItemDetail(ctx context.Context, id uint64) (admindto.ReconcileItem, error) {
+	row, err := s.instances.ReconcileItemRow(ctx, id)
+	if err != nil {
+		return admindto.ReconcileItem{}, err
+	}
+	return reconcileItem(row), nil
+}
+
+// reconcileNodes 汇总交付映射候选节点（按地域绑定的集群）和未释放实例所在节点，保持首次出现的顺序。
+func reconcileNodes(mappings []mysqlinstance.ProvisionMapping, clusters map[string]string, rows []mysqlinstance.Instance) []clusterNode {
+	var nodes []clusterNode
+	seen := map[clusterNode]bool{}
+	add := func(node clusterNode) {
+		if node.node != "" && !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	for _, mapping := range mappings {
+		for _, node := range domaininstance.PlacementNodes(mapping.Node, value(mapping.PlacementNodes)) {
+			add(clusterNode{clusterNo: clusters[mapping.RegionNo], node: node})
+		}
+	}
+	for _, row := range rows {
+		add(clusterNode{clusterNo: row.ClusterNo, node: row.ExternalNode})
+	}
+	return nodes
+}
+
+// reconcileClusters 返回扫描节点涉及的集群，保持首次出现的顺序。
+func reconcileClusters(nodes []clusterNode) []string {
+	var clusters []string
+	seen := map[string]bool{}
+	for _, node := range nodes {
+		if !seen[node.clusterNo] {
+			seen[node.clusterNo] = true
+			clusters = append(clusters, node.clusterNo)
+		}
+	}
+	return clusters
+}
+
+// managedVMID 判断集群内的 VM 是否落在引用该节点的任一交付映射（含停用映射）的 VMID 区间内。
+func managedVMID(mappings []mysqlinstance.ProvisionMapping, clusters map[string]string, clusterNo string) func(node string, vmid uint) bool {
+	return func(node string, vmid uint) bool {
+		for _, mapping := range mappings {
+			if clusters[mapping.RegionNo] != clusterNo || vmid < mapping.VMIDStart || vmid > mapping.VMIDEnd {
+				continue
+			}
+			for _, candidate := range domaininstance.PlacementNodes(mapping.Node, value(mapping.PlacementNodes)) {
+				if candidate == node {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+func reconcileVMs(node string, list []mcppve.VM) []domaininstance.ReconcileVM {
+	const mib = 1024 * 1024
+	vms := make([]domaininstance.ReconcileVM, 0, len(list))
+	for _, item := range list {
+		if item.VMID == 0 {
+			continue
+		}
+		vms = append(vms, domaininstance.ReconcileVM{Node: node, VMID: item.VMID, Name: strings.TrimSpace(item.Name), Status: strings.TrimSpace(item.Status), CPUs: item.CPUs, MemoryMB: int(item.MaxMem / mib), Template: item.Template == 1})
+	}
+	return vms
+}
+
+func reconcileItemFromFinding(reportID uint64, clusterNo string, finding domaininstance.ReconcileFinding) mysqlinstance.ReconcileItem {
+	item := mysqlinstance.ReconcileItem{ReportID: reportID, Kind: finding.Kind, ClusterNo: clusterNo, Node: finding.Node, VMID: finding.VMID, Status: domaininstance.ReconcileItemOpen}
+	if vm := finding.VM; vm != nil {
+		cpus, memory := vm.CPUs, vm.MemoryMB
+		item.VMName, item.VMStatus, item.VMCPUs, item.VMMemoryMB = nullableString(vm.Name), nullableString(vm.Status), &cpus, &memory
+	}
+	if instance := finding.Instance; instance != nil {
+		id := instance.ID
+		item.InstanceID, item.InstanceNo, item.InstanceStatus = &id, stringPtr(instance.InstanceNo), stringPtr(instance.Status)
+	}
+	if len(finding.Drift) > 0 {
+		item.Drift = stringPtr(strings.Join(finding.Drift, ","))
+	}
+	return item
+}
+
+// adoptedOrder 为接管的孤儿 VM 生成零元已完成订单，作为实例的来源订单；幂等键绑定对账差异。
+func adoptedOrder(userID uint64, itemID uint64, selection mysqlorder.CatalogSelection, item mysqlinstance.ReconcileItemRow, remark *string, now time.Time) mysqlorder.Order {
+	note := fmt.Sprintf("对账接管孤儿虚拟机 %s/%d", item.Node, item.VMID)
+	if remark != nil {
+		note += "：" + *remark
+	}
+	return mysqlorder.Order{OrderNo: fmt.Sprintf("ORD-%d", time.Now().UnixNano()), UserID: userID, ClientToken: fmt.Sprintf("reconcile-adopt-%d", itemID), Status: domainorder.StatusFulfilled, OrderType: domainorder.TypePurchase, PaymentStatus: domainorder.PaymentStatusManualConfirmed, PaidAt: &now, ProductNo: selection.ProductNo, ProductType: selection.ProductType, ProductName: selection.ProductName, ProductSummary: selection.ProductSummary, PlanNo: selection.PlanNo, PlanCode: selection.PlanCode, PlanName: selection.PlanName, PlanSummary: selection.PlanSummary, CPUCores: selection.CPUCores, MemoryMB: selection.MemoryMB, SystemDiskGB: selection.SystemDiskGB, DataDiskGB: selection.DataDiskGB, BandwidthMbps: selection.BandwidthMbps, TrafficGB: selection.TrafficGB, PublicIPCount: selection.PublicIPCount, Virtualization: selection.Virtualization, Architecture: selection.Architecture, BillingCycle: selection.BillingCycle, PriceCents: selection.PriceCents, OriginalPriceCents: selection.OriginalPriceCents, Currency: selection.Currency, Quantity: 1, RegionNo: selection.RegionNo, RegionCode: selection.RegionCode, RegionName: selection.RegionName, NetworkTypeNo: selection.NetworkTypeNo, NetworkTypeCode: selection.NetworkTypeCode, NetworkTypeName: selection.NetworkTypeName, TemplateNo: selection.TemplateNo, TemplateCode: selection.TemplateCode, TemplateName: selection.TemplateName, OSFamily: selection.OSFamily, OSDistribution: selection.OSDistribution, OSVersion: selection.OSVersion, OSArchitecture: selection.OSArchitecture, AdminNote: stringPtr(note)}
+}
+
+func reconcileReportItem(row mysqlinstance.ReconcileReport) admindto.ReconcileReportItem {
+	item := admindto.ReconcileReportItem{ReportNo: row.ReportNo, Status: row.Status, TaskNo: row.TaskNo, NodeCount: row.NodeCount, VMCount: row.VMCount, InstanceCount: row.InstanceCount, OrphanCount: row.OrphanCount, GhostCount: row.GhostCount, DriftCount: row.DriftCount, NodeErrors: []admindto.ReconcileNodeError{}, ErrorMessage: row.ErrorMessage, StartedAt: row.StartedAt, FinishedAt: row.FinishedAt}
+	if row.NodeErrors != nil {
+		_ = json.Unmarshal([]byte(*row.NodeErrors), &item.NodeErrors)
+	}
+	return item
+}
+
+func reconcileItem(row mysqlinstance.ReconcileItemRow) admindto.ReconcileItem {
+	return admindto.ReconcileItem{ID: row.ID, ReportNo: row.ReportNo, Kind: row.Kind, ClusterNo: row.ClusterNo, Node: row.Node, VMID: row.VMID, VMName: row.VMName, VMStatus: row.VMStatus, VMCPUs: row.VMCPUs, VMMemoryMB: row.VMMemoryMB, InstanceNo: row.InstanceNo, InstanceStatus: row.InstanceStatus, Drift: splitDrift(row.Drift), Status: row.Status, Resolution: row.Resolution, ResolvedBy: row.ResolvedBy, ResolvedAt: row.ResolvedAt, Remark: row.Remark, CreatedAt: row.CreatedAt}
+}
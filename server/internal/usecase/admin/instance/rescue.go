@@ -0,0 +1,292 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/password"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+	adminsupport "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/support"
+)
+
+const isoObjectType = "instance_iso"
+
+var errRescueExitSkipped = errors.New("rescue exit skipped")
+
+// SetRescueConfig 注入救援模式配置；临时密码依赖凭据加密密钥，未配置时救援模式不可用。
+func (s *Service) SetRescueConfig(cfg config.RescueConfig) *Service {
+	s.rescue = cfg
+	return s
+}
+
+func (s *Service) ListISOs(ctx context.Context, query admindto.ISOListQuery) (admindto.PageResponse[admindto.ISOItem], error) {
+	page, perPage := adminsupport.NormalizePage(query.Page, query.PerPage)
+	rows, total, err := s.instances.ListISOs(ctx, mysqlinstance.ISOFilters{Purpose: query.Purpose, Status: query.Status, Keyword: query.Keyword}, perPage, (page-1)*perPage)
+	if err != nil {
+		return admindto.PageResponse[admindto.ISOItem]{}, err
+	}
+	items := make([]admindto.ISOItem, 0, len(rows))
+	for _, row := range rows {
+		inUse, err := s.instances.CountInstancesUsingISO(ctx, nil, row.ISONo)
+		if err != nil {
+			return admindto.PageResponse[admindto.ISOItem]{}, err
+		}
+		items = append(items, isoItem(row, inUse))
+	}
+	return adminsupport.PageResponse(items, total, page, perPage), nil
+}
+
+// CreateISO 向镜像库添加救援介质或安装镜像；只登记上游已存在的卷，不负责上传。
+func (s *Service) CreateISO(ctx context.Context, operatorID uint64, req admindto.ISORequest) (admindto.ISOItem, error) {
+	iso := isoFromRequest(req)
+	if err := domaininstance.ValidateISO(iso.Purpose, iso.Media, iso.Volume); err != nil {
+		return admindto.ISOItem{}, isoValidationError(err)
+	}
+	if iso.ISONo == "" {
+		iso.ISONo = fmt.Sprintf("ISO-%d", time.Now().UnixNano())
+	}
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		if _, err := s.instances.ISOForUpdate(ctx, tx, iso.ISONo); err == nil {
+			return apperrors.ErrConflict.WithMessage("镜像编号已存在")
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := s.instances.CreateISO(ctx, tx, &iso); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance_iso.create", ObjectType: isoObjectType, ObjectID: iso.ISONo, AfterData: isoAudit(iso), Remark: "添加镜像"})
+	})
+	if err != nil {
+		return admindto.ISOItem{}, err
+	}
+	return s.iso(ctx, iso.ISONo)
+}
+
+// UpdateISO 修改镜像库条目；用途创建后不可修改，停用后不再出现在用户可选列表，已挂载的实例不受影响。
+func (s *Service) UpdateISO(ctx context.Context, operatorID uint64, isoNo string, req admindto.ISORequest) (admindto.ISOItem, error) {
+	next := isoFromRequest(req)
+	if err := domaininstance.ValidateISO(next.Purpose, next.Media, next.Volume); err != nil {
+		return admindto.ISOItem{}, isoValidationError(err)
+	}
+	var current mysqlinstance.ISO
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		var err error
+		current, err = s.instances.ISOForUpdate(ctx, tx, strings.TrimSpace(isoNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("镜像不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if next.Purpose != current.Purpose {
+			return apperrors.ErrValidation.WithMessage("镜像用途创建后不可修改")
+		}
+		next.ID = current.ID
+		next.ISONo = current.ISONo
+		if err := s.instances.UpdateISO(ctx, tx, current.ID, map[string]any{"name": next.Name, "media": next.Media, "volume": next.Volume, "os_family": next.OSFamily, "description": next.Description, "status": next.Status, "sort_order": next.SortOrder}); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance_iso.update", ObjectType: isoObjectType, ObjectID: current.ISONo, BeforeData: isoAudit(current), AfterData: isoAudit(next), Remark: "更新镜像"})
+	})
+	if err != nil {
+		return admindto.ISOItem{}, err
+	}
+	return s.iso(ctx, current.ISONo)
+}
+
+// EnterRescue 由后台让实例以救援介质引导；临时密码只对实例所有者展示，到时由 Worker 自动退出救援。
+func (s *Service) EnterRescue(ctx context.Context, operatorID uint64, instanceNo string, req admindto.InstanceRescueRequest) (admindto.InstanceDetail, error) {
+	if err := s.rescueAvailable(); err != nil {
+		return admindto.InstanceDetail{}, err
+	}
+	return s.startOperation(ctx, instanceNo, &operatorID, nil, domaininstance.OperationRescueEnter, apperrors.ErrConflict.WithMessage("实例已有未完成操作"), nil, s.rescuePlanner(req.ISONo))
+}
+
+func (s *Service) ExitRescue(ctx context.Context, operatorID uint64, instanceNo string) (admindto.InstanceDetail, error) {
+	return s.operateWithGuard(ctx, instanceNo, &operatorID, nil, domaininstance.OperationRescueExit, func(current mysqlinstance.Instance) error {
+		if current.RescueStartedAt == nil {
+			return apperrors.ErrConflict.WithMessage("实例未处于救援模式")
+		}
+		return nil
+	})
+}
+
+// ExitRescueByWorker 在救援到时后退出救援；救援已退出、被重新进入或实例已释放时跳过，实例忙碌时延后重入。
+func (s *Service) ExitRescueByWorker(ctx context.Context, instanceNo string, expectedExpiresAt time.Time) error {
+	guard := func(current mysqlinstance.Instance) error {
+		if current.RescueExpiresAt == nil || !current.RescueExpiresAt.Truncate(time.Millisecond).Equal(expectedExpiresAt.Truncate(time.Millisecond)) {
+			return errRescueExitSkipped
+		}
+		return nil
+	}
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !domaininstance.CanExitRescue(row.Status) || guard(row.Instance) != nil {
+		return nil
+	}
+	_, err = s.startOperation(ctx, instanceNo, nil, nil, domaininstance.OperationRescueExit, ErrOperationPending, guard, nil)
+	if errors.Is(err, errRescueExitSkipped) {
+		return nil
+	}
+	return err
+}
+
+func (s *Service) rescueAvailable() error {
+	if !s.rescue.Enabled || s.credentials == nil {
+		return apperrors.ErrConflict.WithMessage("救援模式暂未开放")
+	}
+	return nil
+}
+
+// rescuePlanner 选择救援介质并生成临时密码；光驱中挂载着镜像时需先弹出，避免救援介质与安装镜像争用光驱。
+func (s *Service) rescuePlanner(isoNo string) operationPlanner {
+	return func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		if current.MountedISONo != nil {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("请先弹出光驱中的镜像")
+		}
+		iso, err := s.instances.ActiveISO(ctx, tx, domaininstance.ISOPurposeRescue, isoNo)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if strings.TrimSpace(isoNo) != "" {
+				return operationPlan{}, apperrors.ErrValidation.WithMessage("救援介质不存在或已停用")
+			}
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("暂无可用的救援介质")
+		}
+		if err != nil {
+			return operationPlan{}, err
+		}
+		plain, err := password.Generate(s.passwordLength)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		sealed, err := s.credentials.Seal(plain)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		payload := mysqlinstance.RescuePayload{ISONo: iso.ISONo, ISOName: iso.Name, PasswordCiphertext: sealed, ExpiresAt: normalizeDBTime(time.Now().Add(s.rescue.Timeout()))}
+		req := mcppve.RescueVMRequest{Volume: iso.Volume, Media: iso.Media, Password: plain}
+		return operationPlan{payload: payload, call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).EnterRescue(ctx, row.ExternalNode, row.ExternalVMID, req)
+		}}, nil
+	}
+}
+
+// scheduleRescueExit 在进入救援成功后投递到时自动退出任务；幂等键包含到期时间，重复同步不会重复投递。
+func (s *Service) scheduleRescueExit(ctx context.Context, tx *gorm.DB, instanceNo string, op mysqlinstance.Operation) error {
+	payload, ok := rescuePayload(op)
+	if !ok {
+		return nil
+	}
+	expiresAt := normalizeDBTime(payload.ExpiresAt)
+	data, _ := json.Marshal(map[string]string{"instance_no": instanceNo, "scheduled_at": expiresAt.Format(time.RFC3339Nano)})
+	objectType := "instance"
+	objectNo := strings.TrimSpace(instanceNo)
+	key := "rescue_exit:" + objectNo + ":" + expiresAt.Format(time.RFC3339Nano)
+	task := mysqlinstance.Task{TaskNo: fmt.Sprintf("TASK-%d", time.Now().UnixNano()), TaskType: domaininstance.TaskTypeRescueExit, IdempotencyKey: &key, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &objectNo, Payload: stringPtr(string(data)), MaxAttempts: 10, ScheduledAt: expiresAt}
+	return s.instances.CreateTaskIgnoreDuplicate(ctx, tx, &task)
+}
+
+// rescueState 返回实例详情展示的救援状态和已挂载镜像，镜像名称从镜像库读取。
+func (s *Service) rescueState(ctx context.Context, row mysqlinstance.Instance) (*admindto.InstanceRescueState, *admindto.InstanceMountedISO, error) {
+	if row.RescueStartedAt == nil && row.MountedISONo == nil {
+		return nil, nil, nil
+	}
+	names, err := s.instances.ISONames(ctx, []string{value(row.RescueISONo), value(row.MountedISONo)})
+	if err != nil {
+		return nil, nil, err
+	}
+	var rescue *admindto.InstanceRescueState
+	if row.RescueStartedAt != nil {
+		rescue = &admindto.InstanceRescueState{ISONo: value(row.RescueISONo), ISOName: names[value(row.RescueISONo)], StartedAt: row.RescueStartedAt, ExpiresAt: row.RescueExpiresAt}
+	}
+	var mounted *admindto.InstanceMountedISO
+	if row.MountedISONo != nil {
+		mounted = &admindto.InstanceMountedISO{ISONo: *row.MountedISONo, ISOName: names[*row.MountedISONo]}
+	}
+	return rescue, mounted, nil
+}
+
+func (s *Service) iso(ctx context.Context, isoNo string) (admindto.ISOItem, error) {
+	row, err := s.instances.ISOByNo(ctx, isoNo)
+	if err != nil {
+		return admindto.ISOItem{}, err
+	}
+	inUse, err := s.instances.CountInstancesUsingISO(ctx, nil, row.ISONo)
+	if err != nil {
+		return admindto.ISOItem{}, err
+	}
+	return isoItem(row, inUse), nil
+}
+
+// rescueCompletionUpdates 在救援或光驱操作成功后回写实例的救援状态和挂载镜像，其他操作返回 nil。
+func rescueCompletionUpdates(op mysqlinstance.Operation) map[string]any {
+	switch op.Action {
+	case domaininstance.OperationRescueEnter:
+		payload, ok := rescuePayload(op)
+		if !ok {
+			return nil
+		}
+		return map[string]any{"rescue_iso_no": payload.ISONo, "rescue_started_at": normalizeDBTime(time.Now()), "rescue_expires_at": normalizeDBTime(payload.ExpiresAt), "rescue_password_ciphertext": payload.PasswordCiphertext}
+	case domaininstance.OperationRescueExit:
+		return map[string]any{"rescue_iso_no": nil, "rescue_started_at": nil, "rescue_expires_at": nil, "rescue_password_ciphertext": nil}
+	case domaininstance.OperationISOMount:
+		if op.Payload == nil {
+			return nil
+		}
+		var payload mysqlinstance.ISOPayload
+		if err := json.Unmarshal([]byte(*op.Payload), &payload); err != nil || payload.ISONo == "" {
+			return nil
+		}
+		return map[string]any{"mounted_iso_no": payload.ISONo}
+	case domaininstance.OperationISOUnmount:
+		return map[string]any{"mounted_iso_no": nil}
+	default:
+		return nil
+	}
+}
+
+func rescuePayload(op mysqlinstance.Operation) (mysqlinstance.RescuePayload, bool) {
+	if op.Action != domaininstance.OperationRescueEnter || op.Payload == nil {
+		return mysqlinstance.RescuePayload{}, false
+	}
+	var payload mysqlinstance.RescuePayload
+	if err := json.Unmarshal([]byte(*op.Payload), &payload); err != nil || payload.ISONo == "" || payload.ExpiresAt.IsZero() {
+		return mysqlinstance.RescuePayload{}, false
+	}
+	return payload, true
+}
+
+func isoFromRequest(req admindto.ISORequest) mysqlinstance.ISO {
+	return mysqlinstance.ISO{ISONo: strings.TrimSpace(req.ISONo), Name: strings.TrimSpace(req.Name), Purpose: strings.TrimSpace(req.Purpose), Media: strings.TrimSpace(req.Media), Volume: strings.TrimSpace(req.Volume), OSFamily: normalizeOptional(req.OSFamily), Description: normalizeOptional(req.Description), Status: strings.TrimSpace(req.Status), SortOrder: req.SortOrder}
+}
+
+func isoValidationError(err error) error {
+	if errors.Is(err, domaininstance.ErrISOMediaInvalid) {
+		return apperrors.ErrValidation.WithMessage("只有救援介质可以使用磁盘方式挂载")
+	}
+	return apperrors.ErrValidation.WithMessage("镜像卷格式应为 存储:路径，例如 cephfs:iso/debian-12.iso")
+}
+
+func isoItem(row mysqlinstance.ISO, inUse int64) admindto.ISOItem {
+	return admindto.ISOItem{ISONo: row.ISONo, Name: row.Name, Purpose: row.Purpose, Media: row.Media, Volume: row.Volume, OSFamily: row.OSFamily, Description: row.Description, Status: row.Status, SortOrder: row.SortOrder, InUseCount: inUse, CreatedAt: row.CreatedAt, UpdatedAt: row.UpdatedAt}
+}
+
+func isoAudit(row mysqlinstance.ISO) map[string]any {
+	return map[string]any{"iso_no": row.ISONo, "name": row.Name, "purpose": row.Purpose, "media": row.Media, "volume": row.Volume, "status": row.Status, "sort_order": row.SortOrder}
+}
@@ -13,15 +13,23 @@ import (
 	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
 	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
 	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/rdns"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/cache"
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlcatalog "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/catalog"
 	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
 	mysqlorder "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/order"
 	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	mysqluser "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/user"
+	mysqlwallet "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/wallet"
 	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/secretbox"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/sshkey"
 	"github.com/AeolianCloud/pveCloud/server/internal/shared/textutil"
 	adminaudit "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/audit"
 	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
 	adminsupport "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/support"
+	webrealname "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/realname"
 )
 
 const objectType = "instance"
@@ -34,20 +42,46 @@ var (
 type AdminAuditService = adminaudit.AdminAuditService
 type AdminAuditWriteInput = adminaudit.AdminAuditWriteInput
 
+// operationPlan 描述实例操作需要额外保存的输入快照和专用上游调用。
+type operationPlan struct {
+	payload   any
+	placement *mysqlinstance.Placement
+	call      func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error)
+}
+
+type operationPlanner func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error)
+
 type Service struct {
 	db        *gorm.DB
 	orders    *mysqlorder.Repository
 	instances *mysqlinstance.Repository
+	catalog   *mysqlcatalog.Repository
+	users     *mysqluser.Repository
+	wallets   *mysqlwallet.Repository
 	mcp       *mcppve.Client
 	lifecycle config.InstanceLifecycleConfig
+	backup    config.BackupConfig
+	redis     *cache.Redis
+	console   config.ConsoleConfig
+	metrics   config.MetricsConfig
+	placement config.PlacementConfig
+	traffic   config.TrafficConfig
+	rdns      config.RDNSConfig
+	rescue    config.RescueConfig
+	dns       rdns.Backend
+	resolver  rdns.Resolver
+	realName  *webrealname.RealNameService
 	audit     *AdminAuditService
+
+	credentials    *secretbox.Box
+	passwordLength int
 }
 
 func NewService(db *gorm.DB, mcp *mcppve.Client, audit *AdminAuditService, lifecycle config.InstanceLifecycleConfig) *Service {
 	if audit == nil {
 		audit = adminaudit.NewAdminAuditService(db)
 	}
-	return &Service{db: db, orders: mysqlorder.NewRepository(db), instances: mysqlinstance.NewRepository(db), mcp: mcp, lifecycle: lifecycle, audit: audit}
+	return &Service{db: db, orders: mysqlorder.NewRepository(db), instances: mysqlinstance.NewRepository(db), catalog: mysqlcatalog.NewRepository(db), users: mysqluser.NewRepository(db), wallets: mysqlwallet.NewRepository(db), mcp: mcp, lifecycle: lifecycle, audit: audit}
 }
 
 func (s *Service) ListMappings(ctx context.Context, query admindto.InstanceMappingListQuery) (admindto.PageResponse[admindto.InstanceMappingItem], error) {
@@ -58,7 +92,7 @@ func (s *Service) ListMappings(ctx context.Context, query admindto.InstanceMappi
 	}
 	items := make([]admindto.InstanceMappingItem, 0, len(rows))
 	for _, row := range rows {
-		items = append(items, mappingItem(row))
+		items = append(items, s.mappingItem(row))
 	}
 	return adminsupport.PageResponse(items, total, page, perPage), nil
 }
@@ -83,7 +117,7 @@ func (s *Service) CreateMapping(ctx context.Context, operatorID uint64, req admi
 	if err != nil {
 		return admindto.InstanceMappingItem{}, err
 	}
-	return mappingItem(mapping), nil
+	return s.mappingItem(mapping), nil
 }
 
 func (s *Service) UpdateMapping(ctx context.Context, operatorID uint64, id uint64, req admindto.InstanceMappingRequest) (admindto.InstanceMappingItem, error) {
@@ -121,36 +155,36 @@ func (s *Service) UpdateMapping(ctx context.Context, operatorID uint64, id uint6
 	if err != nil {
 		return admindto.InstanceMappingItem{}, err
 	}
-	return mappingItem(updated), nil
+	return s.mappingItem(updated), nil
 }
 
-func (s *Service) Nodes(ctx context.Context) ([]admindto.MCPNode, error) {
-	result, err := s.mcp.Nodes(ctx)
+func (s *Service) Nodes(ctx context.Context, query admindto.MCPClusterQuery) ([]admindto.MCPNode, error) {
+	result, err := s.mcp.Cluster(query.ClusterNo).Nodes(ctx)
 	if err != nil {
 		return nil, externalError(err)
 	}
 	return mcpNodes(result), nil
 }
 
-func (s *Service) Node(ctx context.Context, node string) (admindto.MCPNode, error) {
+func (s *Service) Node(ctx context.Context, query admindto.MCPClusterQuery, node string) (admindto.MCPNode, error) {
 	node = strings.TrimSpace(node)
-	result, err := s.mcp.Node(ctx, node)
+	result, err := s.mcp.Cluster(query.ClusterNo).Node(ctx, node)
 	if err != nil {
 		return admindto.MCPNode{}, externalError(err)
 	}
 	return mcpNode(result, node), nil
 }
 
-func (s *Service) NodeVMs(ctx context.Context, node string) ([]admindto.MCPVM, error) {
-	result, err := s.mcp.NodeVMs(ctx, strings.TrimSpace(node))
+func (s *Service) NodeVMs(ctx context.Context, query admindto.MCPClusterQuery, node string) ([]admindto.MCPVM, error) {
+	result, err := s.mcp.Cluster(query.ClusterNo).NodeVMs(ctx, strings.TrimSpace(node))
 	if err != nil {
 		return nil, externalError(err)
 	}
 	return mcpVMs(result), nil
 }
 
-func (s *Service) Storage(ctx context.Context) ([]admindto.MCPStorage, error) {
-	result, err := s.mcp.Storage(ctx)
+func (s *Service) Storage(ctx context.Context, query admindto.MCPClusterQuery) ([]admindto.MCPStorage, error) {
+	result, err := s.mcp.Cluster(query.ClusterNo).Storage(ctx)
 	if err != nil {
 		return nil, externalError(err)
 	}
@@ -164,7 +198,26 @@ func (s *Service) Provision(ctx context.Context, operatorID uint64, orderNo stri
 	var created mysqlinstance.Instance
 	var op mysqlinstance.Operation
 	var mapping mysqlinstance.ProvisionMapping
-	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+	var source *mysqlinstance.Backup
+	var userKeys string
+	var leases []mysqlinstance.IPLease
+	rootPassword, sealedPassword, err := s.newRootPassword()
+	if err != nil {
+		return admindto.ProvisionResponse{}, err
+	}
+	var placement *mysqlinstance.Placement
+	var host *hostVMIDs
+	if pending, err := s.orders.FindByOrderNo(ctx, strings.TrimSpace(orderNo)); err == nil {
+		if placement, err = s.placeOrder(ctx, pending); err != nil {
+			return admindto.ProvisionResponse{}, err
+		}
+		if host, err = s.listHostVMIDs(ctx, pending); err != nil {
+			return admindto.ProvisionResponse{}, err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.ProvisionResponse{}, err
+	}
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
 		order, err := s.orders.OrderForUpdate(ctx, tx, strings.TrimSpace(orderNo))
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return apperrors.ErrNotFound.WithMessage("订单不存在")
@@ -187,18 +240,54 @@ func (s *Service) Provision(ctx context.Context, operatorID uint64, orderNo stri
 		if err != nil {
 			return err
 		}
-		if mapping.NextVMID > mapping.VMIDEnd {
-			return apperrors.ErrConflict.WithMessage("交付映射虚拟机编号已耗尽")
+		if order.SourceBackupNo != nil {
+			backup, err := s.instances.BackupByNo(ctx, *order.SourceBackupNo)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperrors.ErrValidation.WithMessage("恢复来源备份不存在")
+			}
+			if err != nil {
+				return err
+			}
+			if backup.Status != domaininstance.BackupStatusAvailable || backup.VolumeID == nil {
+				return apperrors.ErrConflict.WithMessage("恢复来源备份当前不可用")
+			}
+			if host != nil && backup.ClusterNo != host.clusterNo {
+				return apperrors.ErrConflict.WithMessage("恢复来源备份不在目标地域的集群，不能跨集群恢复")
+			}
+			source = &backup
+		}
+		node, err := placementNode(placement, mapping)
+		if err != nil {
+			return err
 		}
-		vmid := mapping.NextVMID
-		if err := s.instances.AdvanceMappingVMID(ctx, tx, mapping.ID, vmid+1); err != nil {
+		vmid, err := s.allocateVMID(ctx, tx, mapping, host)
+		if err != nil {
 			return err
 		}
-		created = instanceFromOrder(order, mapping, vmid)
+		created = instanceFromOrder(order, node, vmid)
+		created.ClusterNo = host.clusterNo
+		userKeys = value(order.SSHKeys)
+		if source == nil {
+			// 从备份恢复时沿用备份内的系统密码，不生成新密码。
+			created.RootPasswordCiphertext = sealedPassword
+		}
 		if err := s.instances.CreateInstance(ctx, tx, &created); err != nil {
 			return err
 		}
+		if leases, err = s.allocateInstanceIPs(ctx, tx, created, order.PublicIPCount); err != nil {
+			return err
+		}
 		op = newOperation(created.ID, &order.ID, &operatorID, nil, domaininstance.OperationProvision)
+		if source != nil {
+			data, err := json.Marshal(backupPayload(*source))
+			if err != nil {
+				return err
+			}
+			op.Payload = stringPtr(string(data))
+		}
+		if op.Placement, err = placementText(placement); err != nil {
+			return err
+		}
 		if err := s.instances.CreateOperation(ctx, tx, &op); err != nil {
 			return err
 		}
@@ -210,9 +299,24 @@ func (s *Service) Provision(ctx context.Context, operatorID uint64, orderNo stri
 	if err != nil {
 		return admindto.ProvisionResponse{}, err
 	}
-	accepted, callErr := s.mcp.CreateVM(ctx, mapping.Node, createVMRequest(created, mapping))
+	var accepted mcppve.AsyncAccepted
+	var callErr error
+	if source != nil {
+		// 从备份恢复为新 VM 时沿用备份内的磁盘和 cloud-init 配置，只重新生成 MAC 等唯一标识。
+		// 分配了新地址时覆盖备份内的网络配置，避免与来源实例地址冲突。
+		req := mcppve.RestoreVMRequest{VMID: created.ExternalVMID, Archive: *source.VolumeID, Storage: mapping.Storage, Unique: true}
+		if len(leases) > 0 {
+			req.IPConfig0 = ipConfig0(leases, mapping)
+		}
+		accepted, callErr = s.mcp.Cluster(created.ClusterNo).RestoreVM(ctx, created.ExternalNode, req)
+	} else {
+		req := createVMRequest(created, mapping, userKeys)
+		req.CIPassword = rootPassword
+		req.IPConfig0 = ipConfig0(leases, mapping)
+		accepted, callErr = s.mcp.Cluster(created.ClusterNo).CreateVM(ctx, created.ExternalNode, req)
+	}
 	if callErr != nil {
-		_ = s.markOperationFailed(context.Background(), created.ID, op.ID, callErr)
+		_ = s.markOperationFailed(context.Background(), created.ID, op, callErr)
 		return admindto.ProvisionResponse{}, externalError(callErr)
 	}
 	if err := s.instances.UpdateOperation(ctx, nil, op.ID, map[string]any{"external_operation_id": nullableString(accepted.OperationID), "operation_location": nullableString(accepted.OperationLocation), "resource_location": nullableString(accepted.Location)}); err != nil {
@@ -221,7 +325,7 @@ func (s *Service) Provision(ctx context.Context, operatorID uint64, orderNo stri
 	if err := s.instances.UpdateInstance(ctx, nil, created.ID, map[string]any{"external_resource_location": nullableString(accepted.Location)}); err != nil {
 		return admindto.ProvisionResponse{}, err
 	}
-	if err := s.enqueueOperationSync(ctx, nil, created.InstanceNo, op.OperationNo); err != nil {
+	if err := s.enqueueOperationSync(ctx, nil, created.InstanceNo, op.OperationNo, s.mcp.Cluster(created.ClusterNo).OperationPollDelay(accepted)); err != nil {
 		return admindto.ProvisionResponse{}, err
 	}
 	return s.provisionResponse(ctx, created.InstanceNo)
@@ -232,7 +336,7 @@ func (s *Service) List(ctx context.Context, query admindto.InstanceListQuery) (a
 		return admindto.PageResponse[admindto.InstanceItem]{}, apperrors.ErrValidation.WithMessage("实例状态不支持")
 	}
 	page, perPage := adminsupport.NormalizePage(query.Page, query.PerPage)
-	rows, total, err := s.instances.ListInstances(ctx, mysqlinstance.InstanceFilters{Status: query.Status, InstanceNo: query.InstanceNo, OrderNo: query.OrderNo, UserKeyword: query.UserKeyword, DateFrom: query.DateFrom, DateTo: query.DateTo}, perPage, (page-1)*perPage)
+	rows, total, err := s.instances.ListInstances(ctx, mysqlinstance.InstanceFilters{Status: query.Status, InstanceNo: query.InstanceNo, OrderNo: query.OrderNo, ClusterNo: query.ClusterNo, UserKeyword: query.UserKeyword, DateFrom: query.DateFrom, DateTo: query.DateTo, Drifted: query.Drifted}, perPage, (page-1)*perPage)
 	if err != nil {
 		return admindto.PageResponse[admindto.InstanceItem]{}, err
 	}
@@ -255,16 +359,51 @@ func (s *Service) Stop(ctx context.Context, operatorID uint64, instanceNo string
 	return s.operate(ctx, instanceNo, &operatorID, nil, domaininstance.OperationStop)
 }
 
+func (s *Service) Reboot(ctx context.Context, operatorID uint64, instanceNo string) (admindto.InstanceDetail, error) {
+	return s.operate(ctx, instanceNo, &operatorID, nil, domaininstance.OperationReboot)
+}
+
+func (s *Service) Shutdown(ctx context.Context, operatorID uint64, instanceNo string) (admindto.InstanceDetail, error) {
+	return s.operate(ctx, instanceNo, &operatorID, nil, domaininstance.OperationShutdown)
+}
+
+func (s *Service) Reset(ctx context.Context, operatorID uint64, instanceNo string) (admindto.InstanceDetail, error) {
+	return s.operate(ctx, instanceNo, &operatorID, nil, domaininstance.OperationReset)
+}
+
+func (s *Service) ReinstallTemplates(ctx context.Context, instanceNo string) ([]admindto.InstanceReinstallTemplate, error) {
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return nil, err
+	}
+	templates, err := s.instances.PlanTemplates(ctx, row.PlanNo)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]admindto.InstanceReinstallTemplate, 0, len(templates))
+	for _, template := range templates {
+		items = append(items, admindto.InstanceReinstallTemplate{TemplateNo: template.TemplateNo, Code: template.Code, Name: template.Name, OSFamily: template.OSFamily, Distribution: template.Distribution, Version: template.Version, Architecture: template.Architecture, Summary: template.Summary, Current: template.TemplateNo == row.TemplateNo})
+	}
+	return items, nil
+}
+
+func (s *Service) Reinstall(ctx context.Context, operatorID uint64, instanceNo string, req admindto.InstanceReinstallRequest) (admindto.InstanceDetail, error) {
+	return s.startOperation(ctx, instanceNo, &operatorID, nil, domaininstance.OperationReinstall, apperrors.ErrConflict.WithMessage("实例已有未完成操作"), nil, s.reinstallPlanner(req.TemplateNo))
+}
+
 func (s *Service) Release(ctx context.Context, operatorID uint64, instanceNo string) (admindto.InstanceDetail, error) {
 	return s.operate(ctx, instanceNo, &operatorID, nil, domaininstance.OperationRelease)
 }
 
 func (s *Service) Sync(ctx context.Context, operatorID uint64, instanceNo string) (admindto.InstanceDetail, error) {
-	return s.sync(ctx, strings.TrimSpace(instanceNo), &operatorID, true)
+	return s.sync(ctx, strings.TrimSpace(instanceNo), &operatorID, true, nil)
 }
 
 func (s *Service) SyncByWorker(ctx context.Context, instanceNo string) (admindto.InstanceDetail, error) {
-	return s.sync(ctx, strings.TrimSpace(instanceNo), nil, false)
+	return s.sync(ctx, strings.TrimSpace(instanceNo), nil, false, nil)
 }
 
 func (s *Service) ReleaseExpiredByWorker(ctx context.Context, instanceNo string, expectedExpiresAt time.Time) (admindto.InstanceDetail, error) {
@@ -312,6 +451,11 @@ func (s *Service) UpdateExpiresAt(ctx context.Context, operatorID uint64, instan
 		if err := s.instances.UpdateInstance(ctx, tx, current.ID, updates); err != nil {
 			return err
 		}
+		if domaininstance.ResumesOnRenewal(current.Status, value(current.SuspendSource)) {
+			if err := s.instances.UnsuspendInstance(ctx, tx, current.ID); err != nil {
+				return err
+			}
+		}
 		if err := s.enqueueLifecycleTasks(ctx, tx, current.InstanceNo, expiresAt); err != nil {
 			return err
 		}
@@ -332,11 +476,16 @@ func (s *Service) operateWithGuard(ctx context.Context, instanceNo string, admin
 }
 
 func (s *Service) operateWithGuardWithPendingError(ctx context.Context, instanceNo string, adminID *uint64, userID *uint64, action string, pendingErr error, guard func(mysqlinstance.Instance) error) (admindto.InstanceDetail, error) {
+	return s.startOperation(ctx, instanceNo, adminID, userID, action, pendingErr, guard, nil)
+}
+
+func (s *Service) startOperation(ctx context.Context, instanceNo string, adminID *uint64, userID *uint64, action string, pendingErr error, guard func(mysqlinstance.Instance) error, planner operationPlanner) (admindto.InstanceDetail, error) {
 	if !s.mcp.Enabled() {
 		return admindto.InstanceDetail{}, mcpUnavailableError()
 	}
 	var row mysqlinstance.Instance
 	var op mysqlinstance.Operation
+	var plan operationPlan
 	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
 		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -348,6 +497,13 @@ func (s *Service) operateWithGuardWithPendingError(ctx context.Context, instance
 		if !canOperate(current.Status, action) {
 			return apperrors.ErrConflict.WithMessage("当前实例状态不能执行该操作")
 		}
+		if current.RescueStartedAt != nil && domaininstance.BlockedInRescue(action) {
+			// Worker 发起的操作延后重入，等待用户退出或到时自动退出救援。
+			if errors.Is(pendingErr, ErrOperationPending) {
+				return pendingErr
+			}
+			return apperrors.ErrConflict.WithMessage("实例处于救援模式，请先退出救援")
+		}
 		if guard != nil {
 			if err := guard(current); err != nil {
 				return err
@@ -356,8 +512,25 @@ func (s *Service) operateWithGuardWithPendingError(ctx context.Context, instance
 		if err := s.ensureNoRunningOperation(ctx, tx, current.ID, pendingErr); err != nil {
 			return err
 		}
+		if planner != nil {
+			if plan, err = planner(ctx, tx, current); err != nil {
+				return err
+			}
+		}
 		row = current
 		op = newOperation(current.ID, &current.OrderID, adminID, userID, action)
+		afterData := map[string]any{"action": action}
+		if plan.payload != nil {
+			data, err := json.Marshal(plan.payload)
+			if err != nil {
+				return err
+			}
+			op.Payload = stringPtr(string(data))
+			afterData["payload"] = plan.payload
+		}
+		if op.Placement, err = placementText(plan.placement); err != nil {
+			return err
+		}
 		if err := s.instances.CreateOperation(ctx, tx, &op); err != nil {
 			return err
 		}
@@ -366,25 +539,81 @@ func (s *Service) operateWithGuardWithPendingError(ctx context.Context, instance
 				return err
 			}
 		}
-		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: adminID, Action: "instance." + action, ObjectType: objectType, ObjectID: current.InstanceNo, BeforeData: instanceAudit(current), AfterData: map[string]any{"action": action}, Remark: "触发实例操作"})
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: adminID, Action: "instance." + action, ObjectType: objectType, ObjectID: current.InstanceNo, BeforeData: instanceAudit(current), AfterData: afterData, Remark: "触发实例操作"})
 	})
 	if err != nil {
 		return admindto.InstanceDetail{}, err
 	}
-	accepted, callErr := s.callOperation(ctx, row, action)
+	call := plan.call
+	if call == nil {
+		call = func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.callOperation(ctx, row, action)
+		}
+	}
+	accepted, callErr := call(ctx, row)
 	if callErr != nil {
-		_ = s.markOperationFailed(context.Background(), row.ID, op.ID, callErr)
+		_ = s.markOperationFailed(context.Background(), row.ID, op, callErr)
+		_ = s.settleOperationResources(context.Background(), nil, op, false, "")
 		return admindto.InstanceDetail{}, externalError(callErr)
 	}
 	if err := s.instances.UpdateOperation(ctx, nil, op.ID, map[string]any{"external_operation_id": nullableString(accepted.OperationID), "operation_location": nullableString(accepted.OperationLocation), "resource_location": nullableString(accepted.Location)}); err != nil {
 		return admindto.InstanceDetail{}, err
 	}
-	if err := s.enqueueOperationSync(ctx, nil, row.InstanceNo, op.OperationNo); err != nil {
+	if err := s.enqueueOperationSync(ctx, nil, row.InstanceNo, op.OperationNo, s.mcp.Cluster(row.ClusterNo).OperationPollDelay(accepted)); err != nil {
 		return admindto.InstanceDetail{}, err
 	}
 	return s.detail(ctx, row.InstanceNo)
 }
 
+func (s *Service) reinstallPlanner(templateNo string) operationPlanner {
+	return func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		template, err := s.instances.PlanTemplate(ctx, current.PlanNo, templateNo)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return operationPlan{}, apperrors.ErrValidation.WithMessage("系统模板不适用于当前套餐")
+		}
+		if err != nil {
+			return operationPlan{}, err
+		}
+		mapping, err := s.instances.MappingForProvision(ctx, tx, current.PlanNo, current.RegionNo, template.TemplateNo, value(current.NetworkTypeNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return operationPlan{}, apperrors.ErrValidation.WithMessage("缺少匹配的实例交付映射")
+		}
+		if err != nil {
+			return operationPlan{}, err
+		}
+		userKeys, err := s.instanceSSHKeys(ctx, current)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		leases, err := s.instances.InstanceIPLeases(ctx, tx, current.ID)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		req := reinstallVMRequest(mapping, userKeys)
+		req.DiskSize = current.SystemDiskGB
+		req.IPConfig0 = ipConfig0(leases, mapping)
+		payload := mysqlinstance.ReinstallPayload{MappingNo: mapping.MappingNo, TemplateNo: template.TemplateNo, TemplateName: template.Name, OSFamily: template.OSFamily, OSDistribution: template.Distribution, OSVersion: template.Version}
+		return operationPlan{payload: payload, call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).ReinstallVM(ctx, row.ExternalNode, row.ExternalVMID, req)
+		}}, nil
+	}
+}
+
+// instanceSSHKeys 返回新购订单快照的用户公钥，重装时沿用；实例已不属于下单用户时不再注入。
+func (s *Service) instanceSSHKeys(ctx context.Context, current mysqlinstance.Instance) (string, error) {
+	order, err := s.orders.FindByOrderNo(ctx, current.OrderNo)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if order.UserID != current.UserID {
+		return "", nil
+	}
+	return value(order.SSHKeys), nil
+}
+
 func (s *Service) ensureNoRunningOperation(ctx context.Context, tx *gorm.DB, instanceID uint64, pendingErr error) error {
 	_, err := s.instances.LatestRunningOperationForUpdate(ctx, tx, instanceID, domaininstance.OperationSync)
 	if err == nil {
@@ -399,7 +628,9 @@ func (s *Service) ensureNoRunningOperation(ctx context.Context, tx *gorm.DB, ins
 	return err
 }
 
-func (s *Service) sync(ctx context.Context, instanceNo string, adminID *uint64, recordSyncOperation bool) (admindto.InstanceDetail, error) {
+// sync 查询实例最近一次运行中操作的上游结果并落库，再按 VM 实际状态刷新实例。pushed 为操作完成回调携带的结果，
+// 与运行中操作的 operation ID 一致时直接使用，不再查询上游。
+func (s *Service) sync(ctx context.Context, instanceNo string, adminID *uint64, recordSyncOperation bool, pushed *mcppve.Operation) (admindto.InstanceDetail, error) {
 	if !s.mcp.Enabled() {
 		return admindto.InstanceDetail{}, mcpUnavailableError()
 	}
@@ -446,7 +677,7 @@ func (s *Service) sync(ctx context.Context, instanceNo string, adminID *uint64,
 			}
 			return admindto.InstanceDetail{}, ErrOperationPending
 		}
-		result, callErr := s.mcp.Operation(ctx, strings.TrimSpace(*latestOp.ExternalOperationID))
+		result, callErr := s.operationResult(ctx, row.ClusterNo, strings.TrimSpace(*latestOp.ExternalOperationID), pushed)
 		if callErr != nil {
 			if recordSyncOperation {
 				_ = s.markSyncFailed(context.Background(), syncOp.ID, callErr)
@@ -457,14 +688,32 @@ func (s *Service) sync(ctx context.Context, instanceNo string, adminID *uint64,
 			if err := s.applyOperationFailure(ctx, row, latestOp, syncOp, recordSyncOperation, result); err != nil {
 				return admindto.InstanceDetail{}, err
 			}
+			if err := s.migrateFallback(ctx, row, latestOp); err != nil {
+				return admindto.InstanceDetail{}, err
+			}
 			return s.detail(ctx, row.InstanceNo)
 		}
 		if isOperationSucceeded(result.Status) {
-			now := time.Now()
-			if err := s.instances.UpdateOperation(ctx, nil, latestOp.ID, map[string]any{"status": domaininstance.OperationStatusSucceeded, "resource_location": nullableString(result.ResourceLocation), "completed_at": now}); err != nil {
+			if err := s.applyOperationSuccess(ctx, row, latestOp, result); err != nil {
 				return admindto.InstanceDetail{}, err
 			}
 			latestOpSucceeded = true
+			started, err := s.migrateNextStage(ctx, row, latestOp)
+			if err != nil {
+				return admindto.InstanceDetail{}, err
+			}
+			if started {
+				if recordSyncOperation {
+					if err := s.markSyncSucceeded(ctx, syncOp.ID); err != nil {
+						return admindto.InstanceDetail{}, err
+					}
+				}
+				return s.detail(ctx, row.InstanceNo)
+			}
+			// 迁移完成后 VM 已在目标节点，后续状态查询改用目标节点。
+			if payload, ok := migratePayload(latestOp); ok && payload.Stage != domaininstance.MigrateStageStop {
+				row.ExternalNode = payload.TargetNode
+			}
 		} else {
 			if recordSyncOperation {
 				if err := s.markSyncSucceeded(ctx, syncOp.ID); err != nil {
@@ -487,6 +736,7 @@ func (s *Service) sync(ctx context.Context, instanceNo string, adminID *uint64,
 			}
 			return admindto.InstanceDetail{}, ErrOperationPending
 		}
+		var releasedPTRs []mysqlinstance.PTRRecord
 		if err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
 			now := time.Now()
 			if recordSyncOperation {
@@ -494,18 +744,36 @@ func (s *Service) sync(ctx context.Context, instanceNo string, adminID *uint64,
 					return err
 				}
 			}
+			if err := s.instances.MarkInstanceSnapshotsDeleted(ctx, tx, row.ID, now); err != nil {
+				return err
+			}
+			if err := s.instances.DisableBackupPolicy(ctx, tx, row.ID); err != nil {
+				return err
+			}
+			if err := s.instances.ReleaseInstanceIPAddresses(ctx, tx, row.ID); err != nil {
+				return err
+			}
+			if err := s.instances.DeleteInstanceSecurityGroups(ctx, tx, row.ID); err != nil {
+				return err
+			}
+			records, err := s.instances.DeleteInstancePTRRecords(ctx, tx, row.ID)
+			if err != nil {
+				return err
+			}
+			releasedPTRs = records
 			return s.instances.UpdateInstance(ctx, tx, row.ID, releaseCompletionUpdates(latestOp, now))
 		}); err != nil {
 			return admindto.InstanceDetail{}, err
 		}
+		s.removePTRs(ctx, releasedPTRs)
 		return s.detail(ctx, row.InstanceNo)
 	}
-	vm, callErr := s.mcp.VM(ctx, row.ExternalNode, row.ExternalVMID)
+	vm, callErr := s.mcp.Cluster(row.ClusterNo).VM(ctx, row.ExternalNode, row.ExternalVMID)
 	if callErr != nil {
 		if recordSyncOperation {
 			_ = s.markSyncFailed(context.Background(), syncOp.ID, callErr)
 		}
-		if err := s.instances.UpdateInstance(ctx, nil, row.ID, map[string]any{"status": domaininstance.StatusError, "last_error_code": nullableString("mcp_query_failed"), "last_error_message": nullableString(externalStoredMessage(callErr))}); err != nil {
+		if err := s.instances.UpdateInstance(ctx, nil, row.ID, map[string]any{"status": domaininstance.SyncedStatus(row.Status, domaininstance.StatusError), "last_error_code": nullableString("mcp_query_failed"), "last_error_message": nullableString(externalStoredMessage(callErr))}); err != nil {
 			return admindto.InstanceDetail{}, err
 		}
 		return admindto.InstanceDetail{}, externalError(callErr)
@@ -514,9 +782,48 @@ func (s *Service) sync(ctx context.Context, instanceNo string, adminID *uint64,
 	if err := s.applyVMStatus(ctx, row, syncOp, recordSyncOperation, mappedStatus); err != nil {
 		return admindto.InstanceDetail{}, err
 	}
+	if row.Status == domaininstance.StatusSuspended && mappedStatus == domaininstance.StatusRunning {
+		if err := s.enforceSuspend(ctx, row.InstanceNo); err != nil {
+			return admindto.InstanceDetail{}, err
+		}
+	}
+	if mappedStatus == domaininstance.StatusRunning || mappedStatus == domaininstance.StatusStopped {
+		if err := s.checkConfigDrift(ctx, row.InstanceNo); err != nil {
+			return admindto.InstanceDetail{}, err
+		}
+		if err := s.reconcileFirewall(ctx, row.InstanceNo); err != nil {
+			return admindto.InstanceDetail{}, err
+		}
+	}
 	return s.detail(ctx, row.InstanceNo)
 }
 
+func (s *Service) operationResult(ctx context.Context, clusterNo string, externalID string, pushed *mcppve.Operation) (mcppve.Operation, error) {
+	if pushed != nil && pushed.ID == externalID {
+		return *pushed, nil
+	}
+	return s.mcp.Cluster(clusterNo).Operation(ctx, externalID)
+}
+
+func (s *Service) applyOperationSuccess(ctx context.Context, row mysqlinstance.Instance, latestOp mysqlinstance.Operation, result mcppve.Operation) error {
+	now := time.Now()
+	return mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		if err := s.instances.UpdateOperation(ctx, tx, latestOp.ID, map[string]any{"status": domaininstance.OperationStatusSucceeded, "resource_location": nullableString(result.ResourceLocation), "completed_at": now}); err != nil {
+			return err
+		}
+		if err := s.settleOperationResources(ctx, tx, latestOp, true, result.ResourceLocation); err != nil {
+			return err
+		}
+		if err := s.scheduleRescueExit(ctx, tx, row.InstanceNo, latestOp); err != nil {
+			return err
+		}
+		if err := s.recordMigration(ctx, tx, row, latestOp); err != nil {
+			return err
+		}
+		return s.instances.UpdateInstance(ctx, tx, row.ID, operationCompletionUpdates(latestOp))
+	})
+}
+
 func (s *Service) applyOperationFailure(ctx context.Context, row mysqlinstance.Instance, latestOp mysqlinstance.Operation, syncOp mysqlinstance.Operation, recordSyncOperation bool, result mcppve.Operation) error {
 	message := "虚拟化操作失败"
 	code := "mcp_operation_failed"
@@ -533,7 +840,15 @@ func (s *Service) applyOperationFailure(ctx context.Context, row mysqlinstance.I
 				return err
 			}
 		}
-		return s.instances.UpdateInstance(ctx, tx, row.ID, map[string]any{"status": domaininstance.StatusError, "last_error_code": nullableString(code), "last_error_message": nullableString(message)})
+		if err := s.settleOperationResources(ctx, tx, latestOp, false, ""); err != nil {
+			return err
+		}
+		instanceUpdates := map[string]any{"status": domaininstance.SyncedStatus(row.Status, domaininstance.StatusError), "last_error_code": nullableString(code), "last_error_message": nullableString(message)}
+		if latestOp.Action == domaininstance.OperationMigrate {
+			// 迁移失败时 VM 仍留在源节点，保持原状态，只记录错误。
+			delete(instanceUpdates, "status")
+		}
+		return s.instances.UpdateInstance(ctx, tx, row.ID, instanceUpdates)
 	})
 }
 
@@ -545,7 +860,7 @@ func (s *Service) applyVMStatus(ctx context.Context, row mysqlinstance.Instance,
 				return err
 			}
 		}
-		instanceUpdates := map[string]any{"status": mappedStatus, "last_error_code": nil, "last_error_message": nil}
+		instanceUpdates := map[string]any{"status": domaininstance.SyncedStatus(row.Status, mappedStatus), "last_error_code": nil, "last_error_message": nil}
 		if (mappedStatus == domaininstance.StatusRunning || mappedStatus == domaininstance.StatusStopped) && row.Status == domaininstance.StatusCreating {
 			order, err := s.orders.FindByOrderNo(ctx, row.OrderNo)
 			if err != nil {
@@ -580,26 +895,52 @@ func (s *Service) markSyncSucceeded(ctx context.Context, operationID uint64) err
 func (s *Service) callOperation(ctx context.Context, row mysqlinstance.Instance, action string) (mcppve.AsyncAccepted, error) {
 	switch action {
 	case domaininstance.OperationStart:
-		return s.mcp.StartVM(ctx, row.ExternalNode, row.ExternalVMID)
+		return s.mcp.Cluster(row.ClusterNo).StartVM(ctx, row.ExternalNode, row.ExternalVMID)
 	case domaininstance.OperationStop:
-		return s.mcp.StopVM(ctx, row.ExternalNode, row.ExternalVMID)
+		return s.mcp.Cluster(row.ClusterNo).StopVM(ctx, row.ExternalNode, row.ExternalVMID)
+	case domaininstance.OperationReboot:
+		return s.mcp.Cluster(row.ClusterNo).RebootVM(ctx, row.ExternalNode, row.ExternalVMID)
+	case domaininstance.OperationShutdown:
+		return s.mcp.Cluster(row.ClusterNo).ShutdownVM(ctx, row.ExternalNode, row.ExternalVMID)
+	case domaininstance.OperationReset:
+		return s.mcp.Cluster(row.ClusterNo).ResetVM(ctx, row.ExternalNode, row.ExternalVMID)
+	case domaininstance.OperationRescueExit:
+		return s.mcp.Cluster(row.ClusterNo).ExitRescue(ctx, row.ExternalNode, row.ExternalVMID)
+	case domaininstance.OperationISOUnmount:
+		return s.mcp.Cluster(row.ClusterNo).UnmountISO(ctx, row.ExternalNode, row.ExternalVMID)
 	case domaininstance.OperationRelease:
-		return s.mcp.DeleteVM(ctx, row.ExternalNode, row.ExternalVMID)
+		return s.mcp.Cluster(row.ClusterNo).DeleteVM(ctx, row.ExternalNode, row.ExternalVMID)
 	default:
 		return mcppve.AsyncAccepted{}, apperrors.ErrValidation.WithMessage("实例操作不支持")
 	}
 }
 
-func (s *Service) markOperationFailed(ctx context.Context, instanceID uint64, operationID uint64, err error) error {
+// settleOperationResources 在操作结束时回写操作关联的快照、备份或变更套餐订单状态，其他操作直接跳过。
+func (s *Service) settleOperationResources(ctx context.Context, tx *gorm.DB, op mysqlinstance.Operation, succeeded bool, resourceLocation string) error {
+	if err := s.settleSnapshot(ctx, tx, op, succeeded); err != nil {
+		return err
+	}
+	if err := s.settleChangePlan(ctx, tx, op, succeeded); err != nil {
+		return err
+	}
+	return s.settleBackup(ctx, tx, op, succeeded, resourceLocation)
+}
+
+func (s *Service) markOperationFailed(ctx context.Context, instanceID uint64, op mysqlinstance.Operation, err error) error {
 	now := time.Now()
 	message := externalStoredMessage(err)
 	if len(message) > 500 {
 		message = message[:500]
 	}
-	if updateErr := s.instances.UpdateOperation(ctx, nil, operationID, map[string]any{"status": domaininstance.OperationStatusFailed, "error_code": nullableString("mcp_call_failed"), "error_message": nullableString(message), "completed_at": now}); updateErr != nil {
+	if updateErr := s.instances.UpdateOperation(ctx, nil, op.ID, map[string]any{"status": domaininstance.OperationStatusFailed, "error_code": nullableString("mcp_call_failed"), "error_message": nullableString(message), "completed_at": now}); updateErr != nil {
 		return updateErr
 	}
-	return s.instances.UpdateInstance(ctx, nil, instanceID, map[string]any{"status": domaininstance.StatusError, "last_error_code": nullableString("mcp_call_failed"), "last_error_message": nullableString(message)})
+	instanceUpdates := map[string]any{"status": domaininstance.StatusError, "last_error_code": nullableString("mcp_call_failed"), "last_error_message": nullableString(message)}
+	if op.Action == domaininstance.OperationMigrate {
+		// 迁移未被上游接受时 VM 仍留在源节点，保持原状态，只记录错误。
+		delete(instanceUpdates, "status")
+	}
+	return s.instances.UpdateInstance(ctx, nil, instanceID, instanceUpdates)
 }
 
 func (s *Service) markSyncFailed(ctx context.Context, operationID uint64, err error) error {
@@ -640,27 +981,71 @@ func (s *Service) detail(ctx context.Context, instanceNo string) (admindto.Insta
 	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return admindto.InstanceDetail{}, err
 	}
-	return instanceDetail(row, ops, latest), nil
+	leases, err := s.instances.InstanceIPLeases(ctx, nil, row.ID)
+	if err != nil {
+		return admindto.InstanceDetail{}, err
+	}
+	detail := instanceDetail(row, ops, latest)
+	detail.IPAddresses = leaseAddresses(leases)
+	if detail.Rescue, detail.MountedISO, err = s.rescueState(ctx, row.Instance); err != nil {
+		return admindto.InstanceDetail{}, err
+	}
+	return detail, nil
 }
 
 func mappingFromRequest(req admindto.InstanceMappingRequest) mysqlinstance.ProvisionMapping {
-	return mysqlinstance.ProvisionMapping{MappingNo: strings.TrimSpace(req.MappingNo), ProductNo: normalizeOptional(req.ProductNo), PlanNo: strings.TrimSpace(req.PlanNo), RegionNo: strings.TrimSpace(req.RegionNo), TemplateNo: strings.TrimSpace(req.TemplateNo), NetworkTypeNo: strings.TrimSpace(req.NetworkTypeNo), Node: strings.TrimSpace(req.Node), Storage: strings.TrimSpace(req.Storage), DiskSource: strings.TrimSpace(req.DiskSource), DiskFormat: normalizeOptional(req.DiskFormat), DiskInterface: normalizeOptional(req.DiskInterface), SnippetsStorage: normalizeOptional(req.SnippetsStorage), CIUser: normalizeOptional(req.CIUser), SSHKeys: normalizeOptional(req.SSHKeys), IPConfig0: normalizeOptional(req.IPConfig0), Nameserver: normalizeOptional(req.Nameserver), SearchDomain: normalizeOptional(req.SearchDomain), CIPackages: normalizeOptional(req.CIPackages), AptMirror: normalizeOptional(req.AptMirror), VMIDStart: req.VMIDStart, VMIDEnd: req.VMIDEnd, NextVMID: req.NextVMID, Status: strings.TrimSpace(req.Status), Remark: normalizeOptional(req.Remark)}
+	return mysqlinstance.ProvisionMapping{MappingNo: strings.TrimSpace(req.MappingNo), ProductNo: normalizeOptional(req.ProductNo), PlanNo: strings.TrimSpace(req.PlanNo), RegionNo: strings.TrimSpace(req.RegionNo), TemplateNo: strings.TrimSpace(req.TemplateNo), NetworkTypeNo: strings.TrimSpace(req.NetworkTypeNo), Node: strings.TrimSpace(req.Node), PlacementNodes: normalizePlacementNodes(req.PlacementNodes), Storage: strings.TrimSpace(req.Storage), DiskSource: strings.TrimSpace(req.DiskSource), DiskFormat: normalizeOptional(req.DiskFormat), DiskInterface: normalizeOptional(req.DiskInterface), DataDiskStorage: normalizeOptional(req.DataDiskStorage), SnippetsStorage: normalizeOptional(req.SnippetsStorage), CIUser: normalizeOptional(req.CIUser), SSHKeys: normalizeOptional(req.SSHKeys), IPConfig0: normalizeOptional(req.IPConfig0), Nameserver: normalizeOptional(req.Nameserver), SearchDomain: normalizeOptional(req.SearchDomain), CIPackages: normalizeOptional(req.CIPackages), AptMirror: normalizeOptional(req.AptMirror), VMIDStart: req.VMIDStart, VMIDEnd: req.VMIDEnd, NextVMID: req.NextVMID, ReuseVMIDs: req.ReuseVMIDs, Status: strings.TrimSpace(req.Status), Remark: normalizeOptional(req.Remark)}
 }
 
 func mappingUpdateMap(mapping mysqlinstance.ProvisionMapping) map[string]any {
-	return map[string]any{"mapping_no": mapping.MappingNo, "product_no": mapping.ProductNo, "plan_no": mapping.PlanNo, "region_no": mapping.RegionNo, "template_no": mapping.TemplateNo, "network_type_no": mapping.NetworkTypeNo, "node": mapping.Node, "storage": mapping.Storage, "disk_source": mapping.DiskSource, "disk_format": mapping.DiskFormat, "disk_interface": mapping.DiskInterface, "snippets_storage": mapping.SnippetsStorage, "ci_user": mapping.CIUser, "ssh_keys": mapping.SSHKeys, "ip_config0": mapping.IPConfig0, "nameserver": mapping.Nameserver, "search_domain": mapping.SearchDomain, "ci_packages": mapping.CIPackages, "apt_mirror": mapping.AptMirror, "vmid_start": mapping.VMIDStart, "vmid_end": mapping.VMIDEnd, "next_vmid": mapping.NextVMID, "status": mapping.Status, "remark": mapping.Remark}
+	return map[string]any{"mapping_no": mapping.MappingNo, "product_no": mapping.ProductNo, "plan_no": mapping.PlanNo, "region_no": mapping.RegionNo, "template_no": mapping.TemplateNo, "network_type_no": mapping.NetworkTypeNo, "node": mapping.Node, "placement_nodes": mapping.PlacementNodes, "storage": mapping.Storage, "disk_source": mapping.DiskSource, "disk_format": mapping.DiskFormat, "disk_interface": mapping.DiskInterface, "data_disk_storage": mapping.DataDiskStorage, "snippets_storage": mapping.SnippetsStorage, "ci_user": mapping.CIUser, "ssh_keys": mapping.SSHKeys, "ip_config0": mapping.IPConfig0, "nameserver": mapping.Nameserver, "search_domain": mapping.SearchDomain, "ci_packages": mapping.CIPackages, "apt_mirror": mapping.AptMirror, "vmid_start": mapping.VMIDStart, "vmid_end": mapping.VMIDEnd, "next_vmid": mapping.NextVMID, "reuse_released_vmids": mapping.ReuseVMIDs, "status": mapping.Status, "remark": mapping.Remark}
 }
 
-func instanceFromOrder(order mysqlorder.Order, mapping mysqlinstance.ProvisionMapping, vmid uint) mysqlinstance.Instance {
-	return mysqlinstance.Instance{InstanceNo: fmt.Sprintf("INS-%d", time.Now().UnixNano()), UserID: order.UserID, OrderID: order.ID, OrderNo: order.OrderNo, Status: domaininstance.StatusCreating, ProductNo: order.ProductNo, ProductName: order.ProductName, PlanNo: order.PlanNo, PlanName: order.PlanName, CPUCores: order.CPUCores, MemoryMB: order.MemoryMB, SystemDiskGB: order.SystemDiskGB, DataDiskGB: order.DataDiskGB, BandwidthMbps: order.BandwidthMbps, RegionNo: order.RegionNo, RegionName: order.RegionName, NetworkTypeNo: nullableString(order.NetworkTypeNo), NetworkTypeName: nullableString(order.NetworkTypeName), TemplateNo: order.TemplateNo, TemplateName: order.TemplateName, OSFamily: order.OSFamily, OSDistribution: order.OSDistribution, OSVersion: order.OSVersion, ExternalNode: mapping.Node, ExternalVMID: vmid}
+// splitDrift 把逗号分隔的配置漂移字段还原为列表，未漂移时返回空列表。
+func splitDrift(drift *string) []string {
+	if drift == nil || strings.TrimSpace(*drift) == "" {
+		return []string{}
+	}
+	return strings.Split(*drift, ",")
 }
 
-func createVMRequest(instance mysqlinstance.Instance, mapping mysqlinstance.ProvisionMapping) mcppve.CreateVMRequest {
+func instanceFromOrder(order mysqlorder.Order, node string, vmid uint) mysqlinstance.Instance {
+	return mysqlinstance.Instance{InstanceNo: fmt.Sprintf("INS-%d", time.Now().UnixNano()), UserID: order.UserID, OrderID: order.ID, OrderNo: order.OrderNo, Status: domaininstance.StatusCreating, ProductNo: order.ProductNo, ProductName: order.ProductName, PlanNo: order.PlanNo, PlanName: order.PlanName, CPUCores: order.CPUCores, MemoryMB: order.MemoryMB, SystemDiskGB: order.SystemDiskGB, DataDiskGB: order.DataDiskGB, BandwidthMbps: order.BandwidthMbps, TrafficGB: order.TrafficGB, RegionNo: order.RegionNo, RegionName: order.RegionName, NetworkTypeNo: nullableString(order.NetworkTypeNo), NetworkTypeName: nullableString(order.NetworkTypeName), TemplateNo: order.TemplateNo, TemplateName: order.TemplateName, OSFamily: order.OSFamily, OSDistribution: order.OSDistribution, OSVersion: order.OSVersion, ExternalNode: node, ExternalVMID: vmid}
+}
+
+// createVMRequest 组装新建 VM 请求；userKeys 为订单快照的用户公钥，与映射上的运维公钥合并去重后注入。
+func createVMRequest(instance mysqlinstance.Instance, mapping mysqlinstance.ProvisionMapping, userKeys string) mcppve.CreateVMRequest {
 	req := mcppve.CreateVMRequest{VMID: instance.ExternalVMID, Name: instance.InstanceNo, Cores: instance.CPUCores, Memory: instance.MemoryMB, Storage: mapping.Storage, DiskSource: mapping.DiskSource}
 	req.DiskFormat = value(mapping.DiskFormat)
 	req.DiskInterface = value(mapping.DiskInterface)
+	req.DiskSize = instance.SystemDiskGB
+	if instance.DataDiskGB > 0 {
+		req.DataDiskSize = instance.DataDiskGB
+		req.DataDiskStorage = mapping.Storage
+		if storage := value(mapping.DataDiskStorage); storage != "" {
+			req.DataDiskStorage = storage
+		}
+	}
+	req.NetworkRate = domaininstance.NetworkRateMBps(instance.BandwidthMbps)
 	req.CIUser = value(mapping.CIUser)
-	req.SSHKeys = value(mapping.SSHKeys)
+	req.SSHKeys = sshkey.Merge(userKeys, value(mapping.SSHKeys))
+	req.IPConfig0 = value(mapping.IPConfig0)
+	req.Nameserver = value(mapping.Nameserver)
+	req.SearchDomain = value(mapping.SearchDomain)
+	req.SnippetsStorage = value(mapping.SnippetsStorage)
+	req.AptMirror = value(mapping.AptMirror)
+	if mapping.CIPackages != nil {
+		_ = json.Unmarshal([]byte(*mapping.CIPackages), &req.CIPackages)
+	}
+	return req
+}
+
+func reinstallVMRequest(mapping mysqlinstance.ProvisionMapping, userKeys string) mcppve.ReinstallVMRequest {
+	req := mcppve.ReinstallVMRequest{Storage: mapping.Storage, DiskSource: mapping.DiskSource}
+	req.DiskFormat = value(mapping.DiskFormat)
+	req.DiskInterface = value(mapping.DiskInterface)
+	req.CIUser = value(mapping.CIUser)
+	req.SSHKeys = sshkey.Merge(userKeys, value(mapping.SSHKeys))
 	req.IPConfig0 = value(mapping.IPConfig0)
 	req.Nameserver = value(mapping.Nameserver)
 	req.SearchDomain = value(mapping.SearchDomain)
@@ -676,16 +1061,21 @@ func newOperation(instanceID uint64, orderID *uint64, adminID *uint64, userID *u
 	return mysqlinstance.Operation{OperationNo: fmt.Sprintf("OP-%d", time.Now().UnixNano()), InstanceID: instanceID, OrderID: orderID, AdminID: adminID, UserID: userID, Action: action, Status: domaininstance.OperationStatusRunning}
 }
 
-func (s *Service) enqueueOperationSync(ctx context.Context, tx *gorm.DB, instanceNo string, operationNo string) error {
+// enqueueOperationSync 登记异步操作的同步任务；delay 为首次轮询延迟，启用回调的集群以轮询作为兜底。
+func (s *Service) enqueueOperationSync(ctx context.Context, tx *gorm.DB, instanceNo string, operationNo string, delay time.Duration) error {
 	payload := map[string]string{"instance_no": instanceNo}
 	data, _ := json.Marshal(payload)
-	idempotencyKey := "operation_sync:" + strings.TrimSpace(operationNo)
+	idempotencyKey := operationSyncKey(operationNo)
 	objectType := "instance"
 	objectNo := strings.TrimSpace(instanceNo)
-	task := mysqlinstance.Task{TaskNo: fmt.Sprintf("TASK-%d", time.Now().UnixNano()), TaskType: domaininstance.TaskTypeOperationSync, IdempotencyKey: &idempotencyKey, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &objectNo, Payload: stringPtr(string(data)), MaxAttempts: 20, ScheduledAt: normalizeDBTime(time.Now())}
+	task := mysqlinstance.Task{TaskNo: fmt.Sprintf("TASK-%d", time.Now().UnixNano()), TaskType: domaininstance.TaskTypeOperationSync, IdempotencyKey: &idempotencyKey, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &objectNo, Payload: stringPtr(string(data)), MaxAttempts: 20, ScheduledAt: normalizeDBTime(time.Now().Add(delay))}
 	return s.instances.CreateTaskIgnoreDuplicate(ctx, tx, &task)
 }
 
+func operationSyncKey(operationNo string) string {
+	return "operation_sync:" + strings.TrimSpace(operationNo)
+}
+
 func (s *Service) enqueueLifecycleTasks(ctx context.Context, tx *gorm.DB, instanceNo string, expiresAt time.Time) error {
 	expiresAt = normalizeDBTime(expiresAt)
 	payload := map[string]string{"instance_no": instanceNo, "expires_at": expiresAt.Format(time.RFC3339Nano)}
@@ -701,6 +1091,11 @@ func (s *Service) enqueueLifecycleTasks(ctx context.Context, tx *gorm.DB, instan
 	if err := s.instances.CreateTaskIgnoreDuplicate(ctx, tx, &noticeTask); err != nil {
 		return err
 	}
+	if s.lifecycle.AutoSuspendEnabled {
+		if err := s.instances.EnqueueExpirySuspend(ctx, tx, objectNo, expiresAt, string(data)); err != nil {
+			return err
+		}
+	}
 	if !s.lifecycle.AutoReleaseEnabled {
 		return nil
 	}
@@ -715,6 +1110,32 @@ func canOperate(status string, action string) bool {
 		return domaininstance.CanStart(status)
 	case domaininstance.OperationStop:
 		return domaininstance.CanStop(status)
+	case domaininstance.OperationReboot:
+		return domaininstance.CanReboot(status)
+	case domaininstance.OperationShutdown:
+		return domaininstance.CanShutdown(status)
+	case domaininstance.OperationReset:
+		return domaininstance.CanReset(status)
+	case domaininstance.OperationReinstall:
+		return domaininstance.CanReinstall(status)
+	case domaininstance.OperationResize:
+		return domaininstance.CanResize(status)
+	case domaininstance.OperationResetPassword:
+		return domaininstance.CanResetPassword(status)
+	case domaininstance.OperationSnapshotCreate, domaininstance.OperationSnapshotRollback, domaininstance.OperationSnapshotDelete:
+		return domaininstance.CanSnapshot(status)
+	case domaininstance.OperationBackupCreate:
+		return domaininstance.CanBackup(status)
+	case domaininstance.OperationBackupRestore:
+		return domaininstance.CanRestoreBackup(status)
+	case domaininstance.OperationRescueEnter:
+		return domaininstance.CanEnterRescue(status)
+	case domaininstance.OperationRescueExit:
+		return domaininstance.CanExitRescue(status)
+	case domaininstance.OperationISOMount, domaininstance.OperationISOUnmount:
+		return domaininstance.CanMountISO(status)
+	case domaininstance.OperationMigrate:
+		return domaininstance.CanMigrate(status)
 	case domaininstance.OperationRelease:
 		return domaininstance.CanRelease(status)
 	default:
@@ -732,15 +1153,15 @@ func isOperationFailed(status string) bool {
 	return normalized == "failed" || normalized == "canceled" || normalized == "cancelled"
 }
 
+// externalError 把 MCP 调用错误映射为外部依赖不可用，消息按错误码给出可展示的原因。
 func externalError(err error) error {
 	if err == nil {
 		return nil
 	}
-	return mcpUnavailableError()
+	return apperrors.ErrExternalUnavailable.WithMessage(mcppve.UserMessage(err))
 }
 
-func mcpNodes(value any) []admindto.MCPNode {
-	items := anySlice(value)
+func mcpNodes(items []mcppve.Node) []admindto.MCPNode {
 	nodes := make([]admindto.MCPNode, 0, len(items))
 	for _, item := range items {
 		nodes = append(nodes, mcpNode(item, ""))
@@ -748,10 +1169,9 @@ func mcpNodes(value any) []admindto.MCPNode {
 	return nodes
 }
 
-func mcpNode(value any, fallback string) admindto.MCPNode {
-	raw := anyMap(value)
-	node := stringField(raw, "node")
-	name := stringField(raw, "name")
+func mcpNode(item mcppve.Node, fallback string) admindto.MCPNode {
+	node := strings.TrimSpace(item.Node)
+	name := strings.TrimSpace(item.Name)
 	if node == "" {
 		node = fallback
 	}
@@ -761,137 +1181,44 @@ func mcpNode(value any, fallback string) admindto.MCPNode {
 	if name == "" {
 		name = node
 	}
-	return admindto.MCPNode{Node: node, Name: name, Status: stringField(raw, "status")}
+	return admindto.MCPNode{Node: node, Name: name, Status: strings.TrimSpace(item.Status)}
 }
 
-func mcpVMs(value any) []admindto.MCPVM {
-	items := anySlice(value)
+func mcpVMs(items []mcppve.VM) []admindto.MCPVM {
 	vms := make([]admindto.MCPVM, 0, len(items))
 	for _, item := range items {
-		raw := anyMap(item)
-		vms = append(vms, admindto.MCPVM{VMID: uintField(raw, "vmid"), Name: stringField(raw, "name"), Status: stringField(raw, "status"), CPUs: intField(raw, "cpus"), Mem: int64Field(raw, "mem"), MaxMem: int64Field(raw, "maxmem")})
+		vms = append(vms, admindto.MCPVM{VMID: item.VMID, Name: strings.TrimSpace(item.Name), Status: strings.TrimSpace(item.Status), CPUs: item.CPUs, Mem: item.Mem, MaxMem: item.MaxMem})
 	}
 	return vms
 }
 
-func mcpStorageList(value any) []admindto.MCPStorage {
-	items := anySlice(value)
+func mcpStorageList(items []mcppve.Storage) []admindto.MCPStorage {
 	storage := make([]admindto.MCPStorage, 0, len(items))
 	for _, item := range items {
-		raw := anyMap(item)
-		storageName := stringField(raw, "storage")
-		name := stringField(raw, "name")
+		storageName := strings.TrimSpace(item.Storage)
+		name := strings.TrimSpace(item.Name)
 		if storageName == "" {
 			storageName = name
 		}
 		if name == "" {
 			name = storageName
 		}
-		storage = append(storage, admindto.MCPStorage{Storage: storageName, Name: name, Type: stringField(raw, "type"), Status: stringField(raw, "status")})
+		storage = append(storage, admindto.MCPStorage{Storage: storageName, Name: name, Type: strings.TrimSpace(item.Type), Status: strings.TrimSpace(item.Status)})
 	}
 	return storage
 }
 
-func anySlice(value any) []any {
-	switch typed := value.(type) {
-	case []any:
-		return typed
-	case []map[string]any:
-		items := make([]any, 0, len(typed))
-		for _, item := range typed {
-			items = append(items, item)
-		}
-		return items
-	default:
-		return nil
-	}
-}
-
-func anyMap(value any) map[string]any {
-	if typed, ok := value.(map[string]any); ok {
-		return typed
-	}
-	return nil
-}
-
-func stringField(raw map[string]any, key string) string {
-	if raw == nil {
-		return ""
-	}
-	switch value := raw[key].(type) {
-	case string:
-		return strings.TrimSpace(value)
-	case json.Number:
-		return value.String()
-	default:
-		return ""
-	}
-}
-
-func uintField(raw map[string]any, key string) uint {
-	if raw == nil {
-		return 0
-	}
-	switch value := raw[key].(type) {
-	case float64:
-		return uint(value)
-	case json.Number:
-		parsed, _ := value.Int64()
-		if parsed > 0 {
-			return uint(parsed)
-		}
-	case int:
-		if value > 0 {
-			return uint(value)
-		}
-	}
-	return 0
-}
-
-func intField(raw map[string]any, key string) int {
-	if raw == nil {
-		return 0
-	}
-	switch value := raw[key].(type) {
-	case float64:
-		return int(value)
-	case json.Number:
-		parsed, _ := value.Int64()
-		return int(parsed)
-	case int:
-		return value
-	}
-	return 0
-}
-
-func int64Field(raw map[string]any, key string) int64 {
-	if raw == nil {
-		return 0
-	}
-	switch value := raw[key].(type) {
-	case float64:
-		return int64(value)
-	case json.Number:
-		parsed, _ := value.Int64()
-		return parsed
-	case int64:
-		return value
-	case int:
-		return int64(value)
-	}
-	return 0
-}
-
 func mcpUnavailableError() error {
 	return apperrors.ErrExternalUnavailable.WithMessage("虚拟化管理接口暂不可用")
 }
 
-func mappingItem(row mysqlinstance.ProvisionMapping) admindto.InstanceMappingItem {
-	return admindto.InstanceMappingItem{ID: row.ID, MappingNo: row.MappingNo, ProductNo: row.ProductNo, PlanNo: row.PlanNo, RegionNo: row.RegionNo, TemplateNo: row.TemplateNo, NetworkTypeNo: row.NetworkTypeNo, Node: row.Node, Storage: row.Storage, DiskSource: row.DiskSource, DiskFormat: row.DiskFormat, DiskInterface: row.DiskInterface, SnippetsStorage: row.SnippetsStorage, CIUser: row.CIUser, SSHKeys: row.SSHKeys, IPConfig0: row.IPConfig0, Nameserver: row.Nameserver, SearchDomain: row.SearchDomain, CIPackages: row.CIPackages, AptMirror: row.AptMirror, VMIDStart: row.VMIDStart, VMIDEnd: row.VMIDEnd, NextVMID: row.NextVMID, Status: row.Status, Remark: row.Remark, CreatedAt: row.CreatedAt, UpdatedAt: row.UpdatedAt}
+// mappingItem 附带 VMID 余量和告警标记，便于运维在编号耗尽阻断交付前扩容区间或开启复用。
+func (s *Service) mappingItem(row mysqlinstance.ProvisionMapping) admindto.InstanceMappingItem {
+	return admindto.InstanceMappingItem{ID: row.ID, MappingNo: row.MappingNo, ProductNo: row.ProductNo, PlanNo: row.PlanNo, RegionNo: row.RegionNo, TemplateNo: row.TemplateNo, NetworkTypeNo: row.NetworkTypeNo, Node: row.Node, PlacementNodes: row.PlacementNodes, Storage: row.Storage, DiskSource: row.DiskSource, DiskFormat: row.DiskFormat, DiskInterface: row.DiskInterface, DataDiskStorage: row.DataDiskStorage, SnippetsStorage: row.SnippetsStorage, CIUser: row.CIUser, SSHKeys: row.SSHKeys, IPConfig0: row.IPConfig0, Nameserver: row.Nameserver, SearchDomain: row.SearchDomain, CIPackages: row.CIPackages, AptMirror: row.AptMirror, VMIDStart: row.VMIDStart, VMIDEnd: row.VMIDEnd, NextVMID: row.NextVMID, ReuseVMIDs: row.ReuseVMIDs, VMIDRemaining: domaininstance.VMIDRemaining(row.VMIDEnd, row.NextVMID), VMIDLow: s.vmidLow(row), Status: row.Status, Remark: row.Remark, CreatedAt: row.CreatedAt, UpdatedAt: row.UpdatedAt}
 }
 
 func instanceItem(row mysqlinstance.InstanceRow) admindto.InstanceItem {
-	return admindto.InstanceItem{InstanceNo: row.InstanceNo, OrderNo: row.OrderNo, User: admindto.OrderUserSummary{ID: row.UserID, Username: row.Username, Email: row.Email, DisplayName: row.DisplayName}, Status: row.Status, ProductName: row.ProductName, PlanName: row.PlanName, RegionName: row.RegionName, NetworkTypeName: row.NetworkTypeName, TemplateName: row.TemplateName, ExternalNode: row.ExternalNode, ExternalVMID: row.ExternalVMID, ServiceStartedAt: row.ServiceStartedAt, ExpiresAt: row.ExpiresAt, ExpireNoticeSentAt: row.ExpireNoticeSentAt, ExpireReleaseScheduledAt: row.ExpireReleaseScheduledAt, ExpireReleasedAt: row.ExpireReleasedAt, CreatedAt: row.CreatedAt, ReleasedAt: row.ReleasedAt}
+	return admindto.InstanceItem{InstanceNo: row.InstanceNo, OrderNo: row.OrderNo, User: admindto.OrderUserSummary{ID: row.UserID, Username: row.Username, Email: row.Email, DisplayName: row.DisplayName}, Status: row.Status, ProductName: row.ProductName, PlanName: row.PlanName, RegionName: row.RegionName, NetworkTypeName: row.NetworkTypeName, TemplateName: row.TemplateName, ClusterNo: row.ClusterNo, ExternalNode: row.ExternalNode, ExternalVMID: row.ExternalVMID, ConfigDrift: splitDrift(row.ConfigDrift), ServiceStartedAt: row.ServiceStartedAt, ExpiresAt: row.ExpiresAt, ExpireNoticeSentAt: row.ExpireNoticeSentAt, ExpireReleaseScheduledAt: row.ExpireReleaseScheduledAt, ExpireReleasedAt: row.ExpireReleasedAt, SuspendSource: row.SuspendSource, SuspendReason: row.SuspendReason, SuspendedAt: row.SuspendedAt, CreatedAt: row.CreatedAt, ReleasedAt: row.ReleasedAt}
 }
 
 func instanceDetail(row mysqlinstance.InstanceRow, ops []mysqlinstance.Operation, latest *admindto.RenewalOrderSummary) admindto.InstanceDetail {
@@ -899,7 +1226,7 @@ func instanceDetail(row mysqlinstance.InstanceRow, ops []mysqlinstance.Operation
 	for _, op := range ops {
 		items = append(items, operationItem(op))
 	}
-	return admindto.InstanceDetail{InstanceItem: instanceItem(row), ProductNo: row.ProductNo, PlanNo: row.PlanNo, CPUCores: row.CPUCores, MemoryMB: row.MemoryMB, SystemDiskGB: row.SystemDiskGB, DataDiskGB: row.DataDiskGB, BandwidthMbps: row.BandwidthMbps, RegionNo: row.RegionNo, NetworkTypeNo: row.NetworkTypeNo, TemplateNo: row.TemplateNo, OSFamily: row.OSFamily, OSDistribution: row.OSDistribution, OSVersion: row.OSVersion, ExternalResourceLocation: row.ExternalResourceLocation, LastErrorCode: row.LastErrorCode, LastErrorMessage: row.LastErrorMessage, RenewalAvailable: row.Status != domaininstance.StatusReleased && row.Status != domaininstance.StatusReleasing, LatestRenewalOrder: latest, Operations: items}
+	return admindto.InstanceDetail{InstanceItem: instanceItem(row), ProductNo: row.ProductNo, PlanNo: row.PlanNo, CPUCores: row.CPUCores, MemoryMB: row.MemoryMB, SystemDiskGB: row.SystemDiskGB, DataDiskGB: row.DataDiskGB, BandwidthMbps: row.BandwidthMbps, TrafficGB: row.TrafficGB, TrafficOverageAction: row.TrafficOverageAction, RegionNo: row.RegionNo, NetworkTypeNo: row.NetworkTypeNo, TemplateNo: row.TemplateNo, OSFamily: row.OSFamily, OSDistribution: row.OSDistribution, OSVersion: row.OSVersion, ExternalResourceLocation: row.ExternalResourceLocation, LastErrorCode: row.LastErrorCode, LastErrorMessage: row.LastErrorMessage, ConfigCheckedAt: row.ConfigCheckedAt, FirewallStatus: row.FirewallStatus, FirewallSyncedAt: row.FirewallSyncedAt, RootPasswordSet: row.RootPasswordCiphertext != nil, RootPasswordRevealedAt: row.RootPasswordRevealedAt, RenewalAvailable: row.Status != domaininstance.StatusReleased && row.Status != domaininstance.StatusReleasing, LatestRenewalOrder: latest, Operations: items}
 }
 
 func renewalSummary(order mysqlorder.Order) *admindto.RenewalOrderSummary {
@@ -907,7 +1234,14 @@ func renewalSummary(order mysqlorder.Order) *admindto.RenewalOrderSummary {
 }
 
 func operationItem(op mysqlinstance.Operation) admindto.InstanceOperation {
-	return admindto.InstanceOperation{OperationNo: op.OperationNo, Action: op.Action, Status: op.Status, ExternalOperationID: op.ExternalOperationID, OperationLocation: op.OperationLocation, ResourceLocation: op.ResourceLocation, ErrorCode: op.ErrorCode, ErrorMessage: op.ErrorMessage, CreatedAt: op.CreatedAt, CompletedAt: op.CompletedAt}
+	item := admindto.InstanceOperation{OperationNo: op.OperationNo, Action: op.Action, Status: op.Status, ExternalOperationID: op.ExternalOperationID, OperationLocation: op.OperationLocation, ResourceLocation: op.ResourceLocation, ErrorCode: op.ErrorCode, ErrorMessage: op.ErrorMessage, CreatedAt: op.CreatedAt, CompletedAt: op.CompletedAt}
+	if op.Placement != nil {
+		var placement admindto.InstancePlacement
+		if err := json.Unmarshal([]byte(*op.Placement), &placement); err == nil {
+			item.Placement = &placement
+		}
+	}
+	return item
 }
 
 func releaseCompletionUpdates(op mysqlinstance.Operation, now time.Time) map[string]any {
@@ -920,8 +1254,32 @@ func releaseCompletionUpdates(op mysqlinstance.Operation, now time.Time) map[str
 	return updates
 }
 
+// operationCompletionUpdates 返回上游操作成功后需要回写到实例的字段。
+func operationCompletionUpdates(op mysqlinstance.Operation) map[string]any {
+	if updates := resetPasswordUpdates(op); updates != nil {
+		return updates
+	}
+	if updates := rescueCompletionUpdates(op); updates != nil {
+		return updates
+	}
+	if updates := migrateCompletionUpdates(op); updates != nil {
+		return updates
+	}
+	if payload, ok := resizePayload(op); ok && strings.TrimSpace(payload.PlanNo) != "" {
+		return map[string]any{"plan_no": payload.PlanNo, "plan_name": payload.PlanName, "cpu_cores": payload.CPUCores, "memory_mb": payload.MemoryMB, "bandwidth_mbps": payload.BandwidthMbps, "traffic_gb": payload.TrafficGB}
+	}
+	if op.Action != domaininstance.OperationReinstall || op.Payload == nil {
+		return nil
+	}
+	var payload mysqlinstance.ReinstallPayload
+	if err := json.Unmarshal([]byte(*op.Payload), &payload); err != nil || strings.TrimSpace(payload.TemplateNo) == "" {
+		return nil
+	}
+	return map[string]any{"template_no": payload.TemplateNo, "template_name": payload.TemplateName, "os_family": payload.OSFamily, "os_distribution": payload.OSDistribution, "os_version": payload.OSVersion}
+}
+
 func mappingAudit(mapping mysqlinstance.ProvisionMapping) map[string]any {
-	return map[string]any{"mapping_no": mapping.MappingNo, "plan_no": mapping.PlanNo, "region_no": mapping.RegionNo, "template_no": mapping.TemplateNo, "network_type_no": mapping.NetworkTypeNo, "node": mapping.Node, "storage": mapping.Storage, "disk_source": mapping.DiskSource, "status": mapping.Status}
+	return map[string]any{"mapping_no": mapping.MappingNo, "plan_no": mapping.PlanNo, "region_no": mapping.RegionNo, "template_no": mapping.TemplateNo, "network_type_no": mapping.NetworkTypeNo, "node": mapping.Node, "placement_nodes": mapping.PlacementNodes, "storage": mapping.Storage, "data_disk_storage": mapping.DataDiskStorage, "disk_source": mapping.DiskSource, "status": mapping.Status}
 }
 
 func instanceAudit(row mysqlinstance.Instance) map[string]any {
@@ -969,9 +1327,14 @@ func validateCIPackages(value *string) error {
 	return nil
 }
 
+// externalStoredMessage 生成写入实例和操作记录的失败原因，附带错误码便于运维对照上游日志。
 func externalStoredMessage(err error) string {
 	if err == nil {
 		return ""
 	}
-	return "虚拟化管理接口调用失败"
+	message := "虚拟化管理接口调用失败：" + mcppve.UserMessage(err)
+	if code := mcppve.ErrorCode(err); code != "" {
+		message += "（" + code + "）"
+	}
+	return message
 }
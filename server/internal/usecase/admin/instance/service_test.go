@@ -2,12 +2,26 @@ package instance
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"gorm.io/gorm"
+
 	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
 	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
 	"github.com/AeolianCloud/pveCloud/server/internal/testutil/mysqltest"
 	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
 )
@@ -27,9 +41,85 @@ func TestReleaseCompletionUpdatesMarksExpiryOnlyForWorkerRelease(t *testing.T) {
 	}
 }
 
+func TestOperationCompletionUpdatesAppliesReinstallTemplate(t *testing.T) {
+	payload := `{"mapping_no":"MAP-2","template_no":"TPL-2","template_name":"Debian","os_family":"linux","os_distribution":"debian","os_version":"12"}`
+	updates := operationCompletionUpdates(mysqlinstance.Operation{Action: domaininstance.OperationReinstall, Payload: &payload})
+	if updates["template_no"] != "TPL-2" || updates["template_name"] != "Debian" || updates["os_distribution"] != "debian" || updates["os_version"] != "12" {
+		t.Fatalf("reinstall completion should write target template snapshot, got %#v", updates)
+	}
+
+	if updates := operationCompletionUpdates(mysqlinstance.Operation{Action: domaininstance.OperationStart, Payload: &payload}); len(updates) != 0 {
+		t.Fatalf("non-reinstall operations must not change template fields: %#v", updates)
+	}
+	if updates := operationCompletionUpdates(mysqlinstance.Operation{Action: domaininstance.OperationReinstall}); len(updates) != 0 {
+		t.Fatalf("reinstall without payload must not change template fields: %#v", updates)
+	}
+}
+
+func TestOperationCompletionUpdatesAppliesResizePlan(t *testing.T) {
+	payload := `{"order_no":"ORD-1","plan_no":"PLAN-2","plan_name":"4C8G","cpu_cores":4,"memory_mb":8192,"bandwidth_mbps":20,"traffic_gb":500,"credit_cents":0}`
+	updates := operationCompletionUpdates(mysqlinstance.Operation{Action: domaininstance.OperationResize, Payload: &payload})
+	if updates["plan_no"] != "PLAN-2" || updates["plan_name"] != "4C8G" || updates["cpu_cores"] != 4 || updates["memory_mb"] != 8192 || updates["bandwidth_mbps"] != 20 {
+		t.Fatalf("resize completion should write target plan snapshot, got %#v", updates)
+	}
+	if traffic, ok := updates["traffic_gb"].(*int); !ok || traffic == nil || *traffic != 500 {
+		t.Fatalf("resize completion should write target plan traffic quota, got %#v", updates["traffic_gb"])
+	}
+	if _, ok := updates["template_no"]; ok {
+		t.Fatalf("resize completion must not change template fields: %#v", updates)
+	}
+}
+
+func TestSnapshotSettlementUpdatesFollowsOperationOutcome(t *testing.T) {
+	now := time.Date(2026, 5, 23, 12, 0, 0, 0, time.UTC)
+	payload := `{"snapshot_no":"SNAP-1","name":"snap1"}`
+
+	snapshotNo, updates := snapshotSettlementUpdates(mysqlinstance.Operation{Action: domaininstance.OperationSnapshotDelete, Payload: &payload}, true, now)
+	if snapshotNo != "SNAP-1" || updates["status"] != domaininstance.SnapshotStatusDeleted || updates["deleted_at"] != now {
+		t.Fatalf("deleted snapshot should be marked deleted, got %q %#v", snapshotNo, updates)
+	}
+	_, updates = snapshotSettlementUpdates(mysqlinstance.Operation{Action: domaininstance.OperationSnapshotRollback, Payload: &payload}, true, now)
+	if updates["status"] != domaininstance.SnapshotStatusAvailable || updates["last_rolled_back_at"] != now {
+		t.Fatalf("rollback should keep snapshot available and record rollback time, got %#v", updates)
+	}
+	_, updates = snapshotSettlementUpdates(mysqlinstance.Operation{Action: domaininstance.OperationSnapshotRollback, Payload: &payload}, false, now)
+	if _, ok := updates["last_rolled_back_at"]; ok {
+		t.Fatalf("failed rollback must not record rollback time: %#v", updates)
+	}
+	_, updates = snapshotSettlementUpdates(mysqlinstance.Operation{Action: domaininstance.OperationSnapshotCreate, Payload: &payload}, false, now)
+	if updates["status"] != domaininstance.SnapshotStatusFailed {
+		t.Fatalf("failed create should mark snapshot failed, got %#v", updates)
+	}
+
+	if snapshotNo, _ := snapshotSettlementUpdates(mysqlinstance.Operation{Action: domaininstance.OperationStart, Payload: &payload}, true, now); snapshotNo != "" {
+		t.Fatalf("non-snapshot operations must not touch snapshots, got %q", snapshotNo)
+	}
+}
+
+func TestBackupSettlementUpdatesRecordsVolumeID(t *testing.T) {
+	now := time.Date(2026, 5, 23, 12, 0, 0, 0, time.UTC)
+	payload := `{"backup_no":"BAK-1"}`
+
+	backupNo, updates := backupSettlementUpdates(mysqlinstance.Operation{Action: domaininstance.OperationBackupCreate, Payload: &payload}, true, "/api/pve/nodes/pve1/storage/backup/backups/backup%3Avzdump-qemu-101.vma.zst", now)
+	if backupNo != "BAK-1" || updates["status"] != domaininstance.BackupStatusAvailable || updates["volume_id"] != "backup:vzdump-qemu-101.vma.zst" {
+		t.Fatalf("created backup should be available with volume id, got %q %#v", backupNo, updates)
+	}
+	_, updates = backupSettlementUpdates(mysqlinstance.Operation{Action: domaininstance.OperationBackupCreate, Payload: &payload}, true, "", now)
+	if updates["status"] != domaininstance.BackupStatusFailed {
+		t.Fatalf("backup without volume id should be failed, got %#v", updates)
+	}
+	_, updates = backupSettlementUpdates(mysqlinstance.Operation{Action: domaininstance.OperationBackupRestore, Payload: &payload}, true, "", now)
+	if updates["status"] != domaininstance.BackupStatusAvailable || updates["last_restored_at"] != now {
+		t.Fatalf("restore should keep backup available and record restore time, got %#v", updates)
+	}
+	if backupNo, _ := backupSettlementUpdates(mysqlinstance.Operation{Action: domaininstance.OperationSnapshotCreate, Payload: &payload}, true, "", now); backupNo != "" {
+		t.Fatalf("non-backup operations must not touch backups, got %q", backupNo)
+	}
+}
+
 func TestUpdateExpiresAtReschedulesLifecycleTasksAndWritesAudit(t *testing.T) {
 	db := mysqltest.Open(t)
-	mysqltest.Exec(t, db, instanceUsersSchema, instanceOrdersSchema, instanceInstancesSchema, instanceOperationsSchema, instanceAsyncTasksSchema, instanceAdminAuditLogsSchema)
+	mysqltest.Exec(t, db, instanceUsersSchema, instanceOrdersSchema, instanceInstancesSchema, instanceOperationsSchema, instanceAsyncTasksSchema, instanceAdminAuditLogsSchema, instanceIPPoolsSchema, instanceIPAddressesSchema)
 
 	instanceNo := "INS-expires-1"
 	oldExpiresAt := time.Now().AddDate(0, 1, 0).Truncate(time.Millisecond)
@@ -134,27 +224,38 @@ CREATE TABLE orders (
   status VARCHAR(32) NOT NULL DEFAULT 'pending',
   order_type VARCHAR(32) NOT NULL DEFAULT 'purchase',
   related_instance_no VARCHAR(64) NULL,
+  change_from_plan_no VARCHAR(64) NULL,
+  source_backup_no VARCHAR(64) NULL,
+  ssh_keys TEXT NULL,
   product_no VARCHAR(64) NOT NULL DEFAULT '',
   product_type VARCHAR(32) NOT NULL DEFAULT 'server',
   product_name VARCHAR(128) NOT NULL DEFAULT '',
+  product_summary VARCHAR(255) NULL,
   plan_no VARCHAR(64) NOT NULL DEFAULT '',
   plan_code VARCHAR(64) NOT NULL DEFAULT '',
   plan_name VARCHAR(128) NOT NULL DEFAULT '',
+  plan_summary VARCHAR(255) NULL,
   cpu_cores INT NOT NULL DEFAULT 0,
   memory_mb INT NOT NULL DEFAULT 0,
   system_disk_gb INT NOT NULL DEFAULT 0,
   data_disk_gb INT NOT NULL DEFAULT 0,
   bandwidth_mbps INT NOT NULL DEFAULT 0,
+  traffic_gb INT NULL,
   public_ip_count INT NOT NULL DEFAULT 1,
   virtualization VARCHAR(32) NOT NULL DEFAULT '',
   architecture VARCHAR(32) NOT NULL DEFAULT '',
   billing_cycle VARCHAR(32) NOT NULL DEFAULT 'monthly',
   price_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  original_price_cents BIGINT UNSIGNED NULL,
   currency VARCHAR(16) NOT NULL DEFAULT 'CNY',
   quantity INT NOT NULL DEFAULT 1,
   total_amount_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  credit_amount_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
   payment_status VARCHAR(32) NOT NULL DEFAULT 'unpaid',
   paid_at DATETIME(3) NULL,
+  payment_provider VARCHAR(32) NULL,
+  payment_trade_no VARCHAR(128) NULL,
+  payment_callback_payload TEXT NULL,
   region_no VARCHAR(64) NOT NULL DEFAULT '',
   region_code VARCHAR(64) NOT NULL DEFAULT '',
   region_name VARCHAR(128) NOT NULL DEFAULT '',
@@ -168,8 +269,14 @@ CREATE TABLE orders (
   os_distribution VARCHAR(64) NOT NULL DEFAULT '',
   os_version VARCHAR(64) NOT NULL DEFAULT '',
   os_architecture VARCHAR(32) NOT NULL DEFAULT '',
+  user_note VARCHAR(500) NULL,
+  admin_note VARCHAR(500) NULL,
+  cancel_reason VARCHAR(255) NULL,
+  closed_reason VARCHAR(255) NULL,
   created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
   updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  cancelled_at DATETIME(3) NULL,
+  closed_at DATETIME(3) NULL,
   UNIQUE KEY uk_orders_order_no (order_no)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
 
@@ -190,6 +297,8 @@ CREATE TABLE instances (
   system_disk_gb INT NOT NULL,
   data_disk_gb INT NOT NULL DEFAULT 0,
   bandwidth_mbps INT NOT NULL,
+  traffic_gb INT NULL,
+  traffic_overage_action VARCHAR(16) NULL,
   region_no VARCHAR(64) NOT NULL,
   region_name VARCHAR(128) NOT NULL,
   network_type_no VARCHAR(64) NULL,
@@ -199,11 +308,26 @@ CREATE TABLE instances (
   os_family VARCHAR(32) NOT NULL,
   os_distribution VARCHAR(64) NOT NULL,
   os_version VARCHAR(64) NOT NULL,
+  cluster_no VARCHAR(32) NOT NULL DEFAULT 'default',
   external_node VARCHAR(128) NOT NULL,
   external_vmid INT UNSIGNED NOT NULL,
   external_resource_location VARCHAR(255) NULL,
   last_error_code VARCHAR(64) NULL,
   last_error_message VARCHAR(500) NULL,
+  config_drift VARCHAR(255) NULL,
+  config_checked_at DATETIME(3) NULL,
+  firewall_status VARCHAR(16) NULL,
+  firewall_synced_at DATETIME(3) NULL,
+  suspend_source VARCHAR(16) NULL,
+  suspend_reason VARCHAR(255) NULL,
+  suspended_at DATETIME(3) NULL,
+  rescue_iso_no VARCHAR(64) NULL,
+  rescue_started_at DATETIME(3) NULL,
+  rescue_expires_at DATETIME(3) NULL,
+  rescue_password_ciphertext TEXT NULL,
+  mounted_iso_no VARCHAR(64) NULL,
+  root_password_ciphertext TEXT NULL,
+  root_password_revealed_at DATETIME(3) NULL,
   service_started_at DATETIME(3) NULL,
   expires_at DATETIME(3) NULL,
   expire_notice_sent_at DATETIME(3) NULL,
@@ -230,6 +354,8 @@ CREATE TABLE instance_operations (
   resource_location VARCHAR(255) NULL,
   error_code VARCHAR(64) NULL,
   error_message VARCHAR(500) NULL,
+  payload TEXT NULL,
+  placement TEXT NULL,
   created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
   updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
   completed_at DATETIME(3) NULL,
@@ -284,3 +410,1748 @@ CREATE TABLE admin_audit_logs (
   KEY idx_admin_audit_logs_action_created (action, created_at),
   KEY idx_admin_audit_logs_object (object_type, object_id)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+func TestVMConfigSpecTreatsFirstDiskAsSystemDisk(t *testing.T) {
+	spec := vmConfigSpec(mcppve.VMConfig{Cores: 2, Memory: 2048, NetworkRate: 2.5, Disks: []mcppve.VMDisk{{Key: "scsi0", SizeGB: 40}, {Key: "scsi1", SizeGB: 60}, {Key: "scsi2", SizeGB: 40}}})
+	if spec.SystemDiskGB != 40 || spec.DataDiskGB != 100 || spec.BandwidthMbps != 20 || spec.CPUCores != 2 || spec.MemoryMB != 2048 {
+		t.Fatalf("unexpected spec from vm config: %#v", spec)
+	}
+}
+
+func TestTrafficCountersReadsNodeVMNetworkCounters(t *testing.T) {
+	counters := trafficCounters([]mcppve.VM{
+		{VMID: 101, NetIn: 1024, NetOut: 4096},
+		{VMID: 102},
+		{Name: "no-vmid", NetIn: 1},
+	})
+	if len(counters) != 2 || counters[101] != (trafficCounter{netIn: 1024, netOut: 4096}) || counters[102] != (trafficCounter{}) {
+		t.Fatalf("unexpected traffic counters: %#v", counters)
+	}
+}
+
+func TestVMFirewallBuildsRulesAndDetectsDrift(t *testing.T) {
+	port := "8000-8080"
+	expected := vmFirewall(true, []mysqlinstance.SecurityGroupRule{
+		{Direction: "in", Protocol: "tcp", PortRange: &port, CIDR: "0.0.0.0/0"},
+		{Direction: "out", Protocol: "any", CIDR: "10.0.0.0/8"},
+	})
+	if !expected.Enabled || expected.PolicyIn != "DROP" || expected.PolicyOut != "DROP" {
+		t.Fatalf("unexpected firewall policies: %+v", expected)
+	}
+	if expected.Rules[0].DPort != "8000:8080" || expected.Rules[0].Source != "0.0.0.0/0" || expected.Rules[1].Proto != "" || expected.Rules[1].Dest != "10.0.0.0/8" {
+		t.Fatalf("unexpected firewall rules: %+v", expected.Rules)
+	}
+	actual := expected
+	actual.PolicyIn = "drop"
+	actual.Rules = append([]mcppve.FirewallRule(nil), expected.Rules...)
+	actual.Rules[0].Comment = "edited upstream"
+	if !firewallMatches(expected, actual) {
+		t.Fatal("policy case and comments should not count as drift")
+	}
+	actual.Rules[0].DPort = "22"
+	if firewallMatches(expected, actual) {
+		t.Fatal("changed port should count as drift")
+	}
+	if !firewallMatches(vmFirewall(false, nil), mcppve.VMFirewall{Rules: actual.Rules}) {
+		t.Fatal("disabled firewall should only compare the switch")
+	}
+}
+
+func TestCreateVMRequestMergesOrderAndMappingSSHKeys(t *testing.T) {
+	mappingKeys := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOps ops\nssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIUser dup"
+	req := createVMRequest(mysqlinstance.Instance{SystemDiskGB: 40}, mysqlinstance.ProvisionMapping{SSHKeys: &mappingKeys}, "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIUser laptop")
+	want := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIUser laptop\nssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOps ops"
+	if req.SSHKeys != want {
+		t.Fatalf("ssh keys should merge order keys first and dedupe mapping keys, got %q", req.SSHKeys)
+	}
+}
+
+func TestOperationCompletionUpdatesReplacesResetPassword(t *testing.T) {
+	payload := `{"password_ciphertext":"v1:abc"}`
+	updates := operationCompletionUpdates(mysqlinstance.Operation{Action: domaininstance.OperationResetPassword, Payload: &payload})
+	if updates["root_password_ciphertext"] != "v1:abc" {
+		t.Fatalf("reset password completion should store new cipher text, got %#v", updates)
+	}
+	if value, ok := updates["root_password_revealed_at"]; !ok || value != nil {
+		t.Fatalf("reset password completion should clear revealed flag, got %#v", updates)
+	}
+}
+
+func TestOperationCompletionUpdatesMovesMigratedInstance(t *testing.T) {
+	payload := `{"source_node":"pve1","target_node":"pve2","mode":"auto","online":true,"fallback":true}`
+	updates := operationCompletionUpdates(mysqlinstance.Operation{Action: domaininstance.OperationMigrate, Payload: &payload})
+	if updates["external_node"] != "pve2" || len(updates) != 1 {
+		t.Fatalf("migrate completion should only move the instance to the target node, got %#v", updates)
+	}
+	if updates := operationCompletionUpdates(mysqlinstance.Operation{Action: domaininstance.OperationMigrate}); len(updates) != 0 {
+		t.Fatalf("migrate without payload must not change the node: %#v", updates)
+	}
+}
+
+func TestMigrationScopeUsesRegionNodesExceptCurrent(t *testing.T) {
+	extra := "pve2, pve3"
+	mappings := []mysqlinstance.ProvisionMapping{
+		{MappingNo: "MAP-1", RegionNo: "HK", PlanNo: "PLAN-1", TemplateNo: "TPL-1", Node: "pve1", Storage: "local-lvm"},
+		{MappingNo: "MAP-2", RegionNo: "HK", PlanNo: "PLAN-2", TemplateNo: "TPL-1", Node: "pve1", PlacementNodes: &extra, Storage: "ceph"},
+		{MappingNo: "MAP-3", RegionNo: "SG", PlanNo: "PLAN-2", TemplateNo: "TPL-1", Node: "pve9", Storage: "ceph"},
+	}
+	nodes, mapping, ok := migrationScope(mappings, mysqlinstance.Instance{RegionNo: "HK", PlanNo: "PLAN-2", TemplateNo: "TPL-1", ExternalNode: "pve1"})
+	if !ok || mapping.MappingNo != "MAP-2" || len(nodes) != 2 || nodes[0] != "pve2" || nodes[1] != "pve3" {
+		t.Fatalf("migration scope should cover other region nodes and prefer the matching mapping, got %v %s %v", nodes, mapping.MappingNo, ok)
+	}
+	if _, _, ok := migrationScope(mappings, mysqlinstance.Instance{RegionNo: "JP", ExternalNode: "pve1"}); ok {
+		t.Fatal("region without mappings should have no migration scope")
+	}
+}
+
+func TestChooseMigrationTargetHonoursEligibility(t *testing.T) {
+	candidates := []domaininstance.PlacementCandidate{
+		{NodeCapacity: domaininstance.NodeCapacity{Node: "pve2"}, Eligible: true},
+		{NodeCapacity: domaininstance.NodeCapacity{Node: "pve3"}, Reason: "CPU 超出超分上限"},
+	}
+	if node, _ := chooseMigrationTarget(candidates, ""); node != "pve2" {
+		t.Fatalf("automatic target should be the first eligible candidate, got %q", node)
+	}
+	if node, reason := chooseMigrationTarget(candidates, "pve3"); node != "" || reason == "" {
+		t.Fatalf("ineligible explicit target should be rejected, got %q %q", node, reason)
+	}
+	if node, reason := chooseMigrationTarget(candidates, "pve9"); node != "" || reason == "" {
+		t.Fatalf("target outside the region should be rejected, got %q %q", node, reason)
+	}
+	if node, reason := chooseMigrationTarget(nil, ""); node != "" || reason == "" {
+		t.Fatalf("empty candidates should explain why, got %q %q", node, reason)
+	}
+}
+
+func TestReconcileNodesScopesNodesAndManagedRangesByCluster(t *testing.T) {
+	mappings := []mysqlinstance.ProvisionMapping{
+		{MappingNo: "MAP-1", RegionNo: "HK", Node: "pve1", VMIDStart: 1000, VMIDEnd: 1999},
+		{MappingNo: "MAP-2", RegionNo: "SG", Node: "pve1", VMIDStart: 5000, VMIDEnd: 5999},
+	}
+	clusters := map[string]string{"HK": "default", "SG": "sg"}
+	rows := []mysqlinstance.Instance{{ClusterNo: "sg", ExternalNode: "pve2"}, {ClusterNo: "default", ExternalNode: "pve1"}}
+	nodes := reconcileNodes(mappings, clusters, rows)
+	want := []clusterNode{{"default", "pve1"}, {"sg", "pve1"}, {"sg", "pve2"}}
+	if len(nodes) != len(want) {
+		t.Fatalf("same node name in different clusters should be scanned separately, got %v", nodes)
+	}
+	for i := range want {
+		if nodes[i] != want[i] {
+			t.Fatalf("node %d = %v, want %v", i, nodes[i], want[i])
+		}
+	}
+	if got := reconcileClusters(nodes); len(got) != 2 || got[0] != "default" || got[1] != "sg" {
+		t.Fatalf("clusters should keep first-seen order, got %v", got)
+	}
+	managed := managedVMID(mappings, clusters, "sg")
+	if !managed("pve1", 5001) || managed("pve1", 1001) {
+		t.Fatal("managed VMID ranges should only come from mappings of the same cluster")
+	}
+}
+
+func TestOperationResultUsesPushedEventForMatchingOperation(t *testing.T) {
+	pushed := mcppve.Operation{ID: "op-1", Status: "failed", Error: &mcppve.OperationError{Code: "vm_locked", Message: "VM is locked"}}
+	result, err := (&Service{}).operationResult(context.Background(), config.DefaultMCPPVECluster, "op-1", &pushed)
+	if err != nil {
+		t.Fatalf("pushed event should be used without querying MCP: %v", err)
+	}
+	if result.ID != "op-1" || result.Status != "failed" || result.Error == nil || result.Error.Code != "vm_locked" {
+		t.Fatalf("unexpected operation result: %#v", result)
+	}
+}
+
+const instanceIPPoolsSchema = `
+CREATE TABLE ip_pools (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  pool_no VARCHAR(64) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  region_no VARCHAR(64) NOT NULL,
+  network_type_no VARCHAR(64) NOT NULL DEFAULT '',
+  cidr VARCHAR(64) NOT NULL,
+  prefix_length TINYINT UNSIGNED NOT NULL,
+  gateway VARCHAR(64) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'active',
+  remark VARCHAR(500) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_ip_pools_pool_no (pool_no),
+  UNIQUE KEY uk_ip_pools_region_cidr (region_no, cidr)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceIPAddressesSchema = `
+CREATE TABLE ip_addresses (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  pool_id BIGINT UNSIGNED NOT NULL,
+  address VARCHAR(64) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'available',
+  instance_id BIGINT UNSIGNED NULL,
+  instance_no VARCHAR(64) NULL,
+  allocated_at DATETIME(3) NULL,
+  remark VARCHAR(255) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_ip_addresses_pool_address (pool_id, address),
+  KEY idx_ip_addresses_instance (instance_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+// fakeMCP 模拟 MCP-PVE 接口：查询按 "方法 路径" 返回 reads 中预设的 JSON，未预设时返回 404；
+// 写操作返回 202 和递增的 Operation-Location，rejects 中的写操作返回上游错误。写请求按顺序记录在 calls 中。
+type fakeMCP struct {
+	mu      sync.Mutex
+	reads   map[string]string
+	rejects map[string]string
+	calls   []string
+	bodies  map[string]string
+}
+
+func newFakeMCP(t *testing.T) (*fakeMCP, *mcppve.Client) {
+	t.Helper()
+	fake := &fakeMCP{reads: map[string]string{}, rejects: map[string]string{}, bodies: map[string]string{}}
+	server := httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(server.Close)
+	client, err := mcppve.NewClient(config.MCPPVEConfig{Enabled: true, BaseURL: server.URL, TimeoutSeconds: 5})
+	if err != nil {
+		t.Fatalf("new mcp client: %v", err)
+	}
+	return fake, client
+}
+
+func (f *fakeMCP) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := r.Method + " " + r.URL.Path
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodGet {
+		body, ok := f.reads[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error":{"code":"not_found","message":"not found"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, body)
+		return
+	}
+	data, _ := io.ReadAll(r.Body)
+	f.calls = append(f.calls, key)
+	f.bodies[key] = string(data)
+	if code, ok := f.rejects[key]; ok {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, `{"error":{"code":"`+code+`","message":"rejected"}}`)
+		return
+	}
+	w.Header().Set("Operation-Location", fmt.Sprintf("/api/pve/operations/op-%d", len(f.calls)))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (f *fakeMCP) set(key string, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads[key] = body
+}
+
+func (f *fakeMCP) reject(key string, code string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rejects[key] = code
+}
+
+func (f *fakeMCP) writes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *fakeMCP) body(key string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.bodies[key]
+}
+
+// openProvisionDB 建立交付所需的表并写入用户、绑定默认集群的地域和 node-a/node-b 两个候选节点的交付映射。
+func openProvisionDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := mysqltest.Open(t)
+	mysqltest.Exec(t, db, instanceUsersSchema, instanceOrdersSchema, instanceInstancesSchema, instanceOperationsSchema, instanceAsyncTasksSchema, instanceAdminAuditLogsSchema, instanceIPPoolsSchema, instanceIPAddressesSchema, instanceSalesRegionsSchema, instanceProvisionMappingsSchema)
+	if err := db.Exec(`INSERT INTO users (id, username, email, password_hash, status) VALUES (?, ?, ?, ?, ?)`, 21, "provision-user", "provision@example.com", "hash", "active").Error; err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO sales_regions (region_no, cluster_no, code, name) VALUES (?, ?, ?, ?)`, "REG-1", config.DefaultMCPPVECluster, "cn", "China").Error; err != nil {
+		t.Fatalf("insert region: %v", err)
+	}
+	if err := db.Exec(`
+INSERT INTO instance_provision_mappings (
+  mapping_no, plan_no, region_no, template_no, network_type_no, node, placement_nodes, storage, disk_source,
+  vmid_start, vmid_end, next_vmid, status
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		"MAP-1", "PLAN-1", "REG-1", "TPL-1", "", "node-a", "node-b", "local-lvm", "local:import/ubuntu.qcow2", 1000, 1099, 1000, "active",
+	).Error; err != nil {
+		t.Fatalf("insert mapping: %v", err)
+	}
+	return db
+}
+
+// insertProvisionOrder 写入一笔待交付的 2C2G 订单，匹配 openProvisionDB 写入的交付映射。
+func insertProvisionOrder(t *testing.T, db *gorm.DB, id uint64, orderNo string) {
+	t.Helper()
+	if err := db.Exec(`
+INSERT INTO orders (
+  id, order_no, user_id, client_token, status, product_no, product_name, plan_no, plan_name,
+  cpu_cores, memory_mb, system_disk_gb, bandwidth_mbps, public_ip_count, payment_status,
+  region_no, region_name, template_no, template_name, os_family, os_distribution, os_version
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, orderNo, 21, orderNo+"-token", domainorder.StatusPending, "PROD-1", "Server", "PLAN-1", "Basic",
+		2, 2048, 40, 100, 1, "paid", "REG-1", "China", "TPL-1", "Ubuntu", "linux", "ubuntu", "22.04",
+	).Error; err != nil {
+		t.Fatalf("insert order %s: %v", orderNo, err)
+	}
+}
+
+// setProvisionInventory 预设两个候选节点的上游容量、共享存储和节点 VM 列表。
+func setProvisionInventory(fake *fakeMCP, nodes string, vmsA string, vmsB string) {
+	fake.set("GET /api/pve/nodes", nodes)
+	fake.set("GET /api/pve/storage", `[{"storage":"local-lvm","total":1099511627776,"used":0}]`)
+	fake.set("GET /api/pve/nodes/node-a/vms", vmsA)
+	fake.set("GET /api/pve/nodes/node-b/vms", vmsB)
+}
+
+const provisionNodesBalanced = `[
+  {"node":"node-a","status":"online","maxcpu":32,"cpu":0.1,"maxmem":68719476736,"mem":8589934592},
+  {"node":"node-b","status":"online","maxcpu":32,"cpu":0.1,"maxmem":68719476736,"mem":8589934592}
+]`
+
+func TestProvisionAllocatesPoolAddressesAndRejectsExhaustedPool(t *testing.T) {
+	db := openProvisionDB(t)
+	insertProvisionOrder(t, db, 51, "ORD-ip-1")
+	insertProvisionOrder(t, db, 52, "ORD-ip-2")
+	if err := db.Exec(`INSERT INTO ip_pools (id, pool_no, name, region_no, cidr, prefix_length, gateway) VALUES (?, ?, ?, ?, ?, ?, ?)`, 61, "POOL-1", "public", "REG-1", "10.0.0.0/24", 24, "10.0.0.1").Error; err != nil {
+		t.Fatalf("insert pool: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO ip_addresses (pool_id, address, status) VALUES (?, ?, ?), (?, ?, ?)`, 61, "10.0.0.10", domaininstance.IPAddressStatusAvailable, 61, "10.0.0.11", domaininstance.IPAddressStatusReserved).Error; err != nil {
+		t.Fatalf("insert addresses: %v", err)
+	}
+	fake, client := newFakeMCP(t)
+	setProvisionInventory(fake, provisionNodesBalanced, `[]`, `[]`)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{})
+
+	created, err := service.Provision(context.Background(), 77, "ORD-ip-1")
+	if err != nil {
+		t.Fatalf("provision first order: %v", err)
+	}
+	if got := created.Instance.IPAddresses; len(got) != 1 || got[0] != "10.0.0.10" {
+		t.Fatalf("provisioned instance should lease the only available address, got %#v", got)
+	}
+	if body := fake.body("POST /api/pve/nodes/" + created.Instance.ExternalNode + "/vms"); !strings.Contains(body, `"ipConfig0":"ip=10.0.0.10/24,gw=10.0.0.1"`) {
+		t.Fatalf("create request should carry the leased address, got %s", body)
+	}
+
+	// 地址池已无可用地址（保留地址不参与分配），第二笔交付必须整体回滚。
+	if _, err := service.Provision(context.Background(), 77, "ORD-ip-2"); apperrors.From(err).Code != apperrors.ErrConflict.Code {
+		t.Fatalf("provision without free address should conflict, got %v", err)
+	}
+	if writes := fake.writes(); len(writes) != 1 {
+		t.Fatalf("rejected provision must not call MCP, got %#v", writes)
+	}
+
+	var address struct {
+		Status     string  `gorm:"column:status"`
+		InstanceNo *string `gorm:"column:instance_no"`
+	}
+	if err := db.Table("ip_addresses").Where("address = ?", "10.0.0.10").Take(&address).Error; err != nil {
+		t.Fatalf("load address: %v", err)
+	}
+	if address.Status != domaininstance.IPAddressStatusAllocated || address.InstanceNo == nil || *address.InstanceNo != created.Instance.InstanceNo {
+		t.Fatalf("address should be allocated to the provisioned instance, got %#v", address)
+	}
+	var instances int64
+	if err := db.Table("instances").Count(&instances).Error; err != nil {
+		t.Fatalf("count instances: %v", err)
+	}
+	if instances != 1 {
+		t.Fatalf("rejected provision must roll back its instance, got %d instances", instances)
+	}
+	var pending int64
+	if err := db.Table("orders").Where("status = ?", domainorder.StatusPending).Count(&pending).Error; err != nil {
+		t.Fatalf("count pending orders: %v", err)
+	}
+	if pending != 1 {
+		t.Fatalf("rejected order should stay pending, got %d pending orders", pending)
+	}
+}
+
+func TestProvisionPlacesOrderOnEligibleCandidateNode(t *testing.T) {
+	db := openProvisionDB(t)
+	insertProvisionOrder(t, db, 51, "ORD-place-1")
+	fake, client := newFakeMCP(t)
+	// node-a 实时可用内存只剩 1 GiB，不能放下 2 GiB 的实例；node-b 空闲。
+	setProvisionInventory(fake, `[
+  {"node":"node-a","status":"online","maxcpu":32,"cpu":0.1,"maxmem":68719476736,"mem":67645734912},
+  {"node":"node-b","status":"online","maxcpu":32,"cpu":0.1,"maxmem":68719476736,"mem":8589934592}
+]`, `[]`, `[]`)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{})
+
+	created, err := service.Provision(context.Background(), 77, "ORD-place-1")
+	if err != nil {
+		t.Fatalf("provision: %v", err)
+	}
+	if created.Instance.ExternalNode != "node-b" {
+		t.Fatalf("provision should place on the eligible candidate node-b, got %s", created.Instance.ExternalNode)
+	}
+	if writes := fake.writes(); len(writes) != 1 || writes[0] != "POST /api/pve/nodes/node-b/vms" {
+		t.Fatalf("create request should target the placed node, got %#v", writes)
+	}
+
+	var op mysqlinstance.Operation
+	if err := db.Where("action = ?", domaininstance.OperationProvision).Take(&op).Error; err != nil {
+		t.Fatalf("load provision operation: %v", err)
+	}
+	if op.Placement == nil {
+		t.Fatal("provision operation should record the placement decision")
+	}
+	var placement mysqlinstance.Placement
+	if err := json.Unmarshal([]byte(*op.Placement), &placement); err != nil {
+		t.Fatalf("decode placement: %v", err)
+	}
+	if placement.MappingNo != "MAP-1" || placement.Node != "node-b" || len(placement.Candidates) != 2 {
+		t.Fatalf("unexpected placement record: %#v", placement)
+	}
+	rejected := placement.Candidates[1]
+	if rejected.Node != "node-a" || rejected.Eligible || rejected.Reason == "" {
+		t.Fatalf("placement should record why node-a was rejected, got %#v", rejected)
+	}
+}
+
+func TestProvisionRejectsOrderWhenNoCandidateHasCapacity(t *testing.T) {
+	db := openProvisionDB(t)
+	insertProvisionOrder(t, db, 51, "ORD-place-full")
+	fake, client := newFakeMCP(t)
+	setProvisionInventory(fake, `[
+  {"node":"node-a","status":"online","maxcpu":32,"cpu":0.1,"maxmem":68719476736,"mem":67645734912},
+  {"node":"node-b","status":"offline"}
+]`, `[]`, `[]`)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{})
+
+	if _, err := service.Provision(context.Background(), 77, "ORD-place-full"); apperrors.From(err).Code != apperrors.ErrConflict.Code {
+		t.Fatalf("provision without capacity should conflict, got %v", err)
+	}
+	if writes := fake.writes(); len(writes) != 0 {
+		t.Fatalf("provision without capacity must not call MCP, got %#v", writes)
+	}
+	var instances int64
+	if err := db.Table("instances").Count(&instances).Error; err != nil {
+		t.Fatalf("count instances: %v", err)
+	}
+	if instances != 0 {
+		t.Fatalf("provision without capacity must not create instances, got %d", instances)
+	}
+}
+
+func TestSyncPlanCapacityTogglesAutoSoldOutPlans(t *testing.T) {
+	db := openProvisionDB(t)
+	mysqltest.Exec(t, db, instanceProductsSchema, instanceProductPlansSchema, instancePlanRegionsSchema)
+	if err := db.Exec(`INSERT INTO products (id, product_no, type, slug, name, status) VALUES (?, ?, ?, ?, ?, ?)`, 71, "PROD-1", "server", "server", "Server", "active").Error; err != nil {
+		t.Fatalf("insert product: %v", err)
+	}
+	// PLAN-1 在售；PLAN-2 由管理员人工售罄，容量同步不得改动。
+	if err := db.Exec(`INSERT INTO product_plans (id, plan_no, product_id, code, name, cpu_cores, memory_mb, system_disk_gb, bandwidth_mbps, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		81, "PLAN-1", 71, "basic", "Basic", 2, 2048, 40, 100, "active",
+		82, "PLAN-2", 71, "manual", "Manual", 2, 2048, 40, 100, "sold_out",
+	).Error; err != nil {
+		t.Fatalf("insert plans: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO plan_regions (plan_id, region_id) SELECT id, (SELECT id FROM sales_regions WHERE region_no = ?) FROM product_plans`, "REG-1").Error; err != nil {
+		t.Fatalf("insert plan regions: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO instance_provision_mappings (mapping_no, plan_no, region_no, template_no, node, placement_nodes, storage, disk_source, vmid_start, vmid_end, next_vmid, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		"MAP-2", "PLAN-2", "REG-1", "TPL-1", "node-a", "node-b", "local-lvm", "local:import/ubuntu.qcow2", 2000, 2099, 2000, "active",
+	).Error; err != nil {
+		t.Fatalf("insert second mapping: %v", err)
+	}
+	fake, client := newFakeMCP(t)
+	fake.set("GET /api/pve/storage", `[{"storage":"local-lvm","total":1099511627776,"used":0}]`)
+	fake.set("GET /api/pve/nodes", `[
+  {"node":"node-a","status":"online","maxcpu":32,"cpu":0.1,"maxmem":68719476736,"mem":67645734912},
+  {"node":"node-b","status":"offline"}
+]`)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{})
+
+	type planState struct {
+		PlanNo            string     `gorm:"column:plan_no"`
+		Status            string     `gorm:"column:status"`
+		CapacitySoldOutAt *time.Time `gorm:"column:capacity_sold_out_at"`
+	}
+	loadPlans := func() map[string]planState {
+		var rows []planState
+		if err := db.Table("product_plans").Select("plan_no, status, capacity_sold_out_at").Scan(&rows).Error; err != nil {
+			t.Fatalf("load plans: %v", err)
+		}
+		states := map[string]planState{}
+		for _, row := range rows {
+			states[row.PlanNo] = row
+		}
+		return states
+	}
+
+	changed, err := service.SyncPlanCapacity(context.Background())
+	if err != nil {
+		t.Fatalf("sync without capacity: %v", err)
+	}
+	plans := loadPlans()
+	if changed != 1 || plans["PLAN-1"].Status != "sold_out" || plans["PLAN-1"].CapacitySoldOutAt == nil {
+		t.Fatalf("plan without capacity should be auto sold out, changed=%d plans=%#v", changed, plans)
+	}
+	if plans["PLAN-2"].Status != "sold_out" || plans["PLAN-2"].CapacitySoldOutAt != nil {
+		t.Fatalf("manually sold out plan must stay untouched, got %#v", plans["PLAN-2"])
+	}
+
+	// 再次同步容量仍不足时不重复改动。
+	if changed, err := service.SyncPlanCapacity(context.Background()); err != nil || changed != 0 {
+		t.Fatalf("repeated sync should be a no-op, changed=%d err=%v", changed, err)
+	}
+
+	fake.set("GET /api/pve/nodes", provisionNodesBalanced)
+	changed, err = service.SyncPlanCapacity(context.Background())
+	if err != nil {
+		t.Fatalf("sync with recovered capacity: %v", err)
+	}
+	plans = loadPlans()
+	if changed != 1 || plans["PLAN-1"].Status != "active" || plans["PLAN-1"].CapacitySoldOutAt != nil {
+		t.Fatalf("auto sold out plan should return to sale once capacity recovers, changed=%d plans=%#v", changed, plans)
+	}
+	if plans["PLAN-2"].Status != "sold_out" {
+		t.Fatalf("manually sold out plan must not be restored automatically, got %#v", plans["PLAN-2"])
+	}
+
+	var auditCount int64
+	if err := db.Table("admin_audit_logs").Where("action = ? AND object_id = ?", "product_plan.capacity_status.update", "PLAN-1").Count(&auditCount).Error; err != nil {
+		t.Fatalf("count audit logs: %v", err)
+	}
+	if auditCount != 2 {
+		t.Fatalf("each capacity status change should be audited, got %d", auditCount)
+	}
+}
+
+func TestReconcileReportsOrphanAndAdoptsItForUser(t *testing.T) {
+	db := openProvisionDB(t)
+	mysqltest.Exec(t, db, instanceReconcileReportsSchema, instanceReconcileItemsSchema, instanceProductsSchema, instanceProductPlansSchema, instancePlanRegionsSchema, instancePlanPricesSchema, instanceNetworkTypesSchema, instancePlanNetworkTypesSchema, instanceOSTemplatesSchema, instancePlanOSTemplatesSchema)
+	seedCatalogSelection(t, db)
+	if err := db.Exec(`
+INSERT INTO instances (
+  id, instance_no, user_id, order_id, order_no, status, product_no, product_name, plan_no, plan_name,
+  cpu_cores, memory_mb, system_disk_gb, bandwidth_mbps, region_no, region_name,
+  template_no, template_name, os_family, os_distribution, os_version, external_node, external_vmid
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		41, "INS-known", 21, 31, "ORD-known", domaininstance.StatusRunning, "PROD-1", "Server", "PLAN-1", "Basic",
+		2, 2048, 40, 100, "REG-1", "China", "TPL-1", "Ubuntu", "linux", "ubuntu", "22.04", "node-a", 1000,
+	).Error; err != nil {
+		t.Fatalf("insert instance: %v", err)
+	}
+	fake, client := newFakeMCP(t)
+	// 1005 落在映射 VMID 区间内但没有实例记录，是孤儿；100 在区间外，视为节点上的基础设施 VM。
+	fake.set("GET /api/pve/nodes/node-a/vms", `[
+  {"vmid":100,"name":"infra","status":"running","cpus":2,"maxmem":2147483648},
+  {"vmid":1000,"name":"INS-known","status":"running","cpus":2,"maxmem":2147483648},
+  {"vmid":1005,"name":"lost","status":"stopped","cpus":2,"maxmem":2147483648}
+]`)
+	fake.set("GET /api/pve/nodes/node-b/vms", `[]`)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{ExpireNoticeBeforeSeconds: 86400})
+
+	report, err := service.Reconcile(context.Background(), 77)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if report.Status != domaininstance.ReconcileReportSucceeded || report.NodeCount != 2 || report.OrphanCount != 1 || report.GhostCount != 0 || report.DriftCount != 0 {
+		t.Fatalf("unexpected reconcile report: %#v", report)
+	}
+	var item mysqlinstance.ReconcileItem
+	if err := db.Where("kind = ?", domaininstance.ReconcileKindOrphan).Take(&item).Error; err != nil {
+		t.Fatalf("load orphan item: %v", err)
+	}
+	if item.Node != "node-a" || item.VMID != 1005 || item.ClusterNo != config.DefaultMCPPVECluster {
+		t.Fatalf("unexpected orphan item: %#v", item)
+	}
+
+	req := admindto.ReconcileAdoptRequest{UserID: 21, PlanNo: "PLAN-1", BillingCycle: "monthly", RegionNo: "REG-1", NetworkTypeNo: "NET-1", TemplateNo: "TPL-1"}
+	adopted, err := service.AdoptReconcileOrphan(context.Background(), 77, item.ID, req)
+	if err != nil {
+		t.Fatalf("adopt orphan: %v", err)
+	}
+	if adopted.Status != domaininstance.ReconcileItemResolved || adopted.Resolution == nil || *adopted.Resolution != domaininstance.ReconcileResolutionAdopted || adopted.InstanceNo == nil {
+		t.Fatalf("adopted item should be resolved with the new instance, got %#v", adopted)
+	}
+	var instance mysqlinstance.Instance
+	if err := db.Where("instance_no = ?", *adopted.InstanceNo).Take(&instance).Error; err != nil {
+		t.Fatalf("load adopted instance: %v", err)
+	}
+	if instance.UserID != 21 || instance.ExternalNode != "node-a" || instance.ExternalVMID != 1005 || instance.Status != domaininstance.StatusStopped || instance.ExpiresAt == nil {
+		t.Fatalf("adopted instance should take over the VM with its power state, got %#v", instance)
+	}
+	var order struct {
+		Status      string `gorm:"column:status"`
+		TotalAmount int64  `gorm:"column:total_amount_cents"`
+	}
+	if err := db.Table("orders").Where("id = ?", instance.OrderID).Take(&order).Error; err != nil {
+		t.Fatalf("load adoption order: %v", err)
+	}
+	if order.Status != domainorder.StatusFulfilled || order.TotalAmount != 0 {
+		t.Fatalf("adoption should create a fulfilled zero-amount order, got %#v", order)
+	}
+	var notices int64
+	if err := db.Table("async_tasks").Where("task_type = ? AND object_no = ?", domaininstance.TaskTypeExpiryNotice, instance.InstanceNo).Count(&notices).Error; err != nil {
+		t.Fatalf("count expiry notices: %v", err)
+	}
+	if notices != 1 {
+		t.Fatalf("adopted instance should get an expiry notice task, got %d", notices)
+	}
+
+	if _, err := service.AdoptReconcileOrphan(context.Background(), 77, item.ID, req); apperrors.From(err).Code != apperrors.ErrConflict.Code {
+		t.Fatalf("resolved item must not be adopted twice, got %v", err)
+	}
+	report, err = service.Reconcile(context.Background(), 77)
+	if err != nil {
+		t.Fatalf("reconcile after adoption: %v", err)
+	}
+	if report.OrphanCount != 0 || report.InstanceCount != 2 {
+		t.Fatalf("adopted VM should no longer be reported as orphan, got %#v", report)
+	}
+}
+
+func TestProvisionSkipsVMIDsTakenUpstreamOrByActiveInstances(t *testing.T) {
+	db := openProvisionDB(t)
+	insertProvisionOrder(t, db, 51, "ORD-vmid-1")
+	// 1001 被未释放实例占用；1000 在 node-b 上被人工创建的 VM 占用，两者都不能分配。
+	if err := db.Exec(`
+INSERT INTO instances (
+  id, instance_no, user_id, order_id, order_no, status, product_no, product_name, plan_no, plan_name,
+  cpu_cores, memory_mb, system_disk_gb, bandwidth_mbps, region_no, region_name,
+  template_no, template_name, os_family, os_distribution, os_version, external_node, external_vmid
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		41, "INS-active", 21, 31, "ORD-active", domaininstance.StatusStopped, "PROD-1", "Server", "PLAN-1", "Basic",
+		2, 2048, 40, 100, "REG-1", "China", "TPL-1", "Ubuntu", "linux", "ubuntu", "22.04", "node-a", 1001,
+	).Error; err != nil {
+		t.Fatalf("insert instance: %v", err)
+	}
+	fake, client := newFakeMCP(t)
+	setProvisionInventory(fake, provisionNodesBalanced, `[{"vmid":1001,"name":"INS-active","status":"stopped"}]`, `[{"vmid":1000,"name":"manual","status":"running"}]`)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{})
+
+	created, err := service.Provision(context.Background(), 77, "ORD-vmid-1")
+	if err != nil {
+		t.Fatalf("provision: %v", err)
+	}
+	if created.Instance.ExternalVMID != 1002 {
+		t.Fatalf("provision should skip colliding VMIDs and use 1002, got %d", created.Instance.ExternalVMID)
+	}
+	if body := fake.body("POST /api/pve/nodes/" + created.Instance.ExternalNode + "/vms"); !strings.Contains(body, `"vmid":1002`) {
+		t.Fatalf("create request should use the allocated VMID, got %s", body)
+	}
+	var next uint
+	if err := db.Table("instance_provision_mappings").Where("mapping_no = ?", "MAP-1").Pluck("next_vmid", &next).Error; err != nil {
+		t.Fatalf("load next vmid: %v", err)
+	}
+	if next != 1003 {
+		t.Fatalf("mapping next_vmid should advance past the allocated VMID, got %d", next)
+	}
+}
+
+func TestProvisionReusesReleasedVMIDWhenMappingAllowsIt(t *testing.T) {
+	db := openProvisionDB(t)
+	insertProvisionOrder(t, db, 51, "ORD-vmid-reuse")
+	if err := db.Exec(`UPDATE instance_provision_mappings SET reuse_released_vmids = 1, next_vmid = 1010 WHERE mapping_no = ?`, "MAP-1").Error; err != nil {
+		t.Fatalf("enable vmid reuse: %v", err)
+	}
+	// 1003 和 1004 都曾被已释放实例使用，但 1003 在上游仍有残留 VM，只能复用 1004。
+	for i, vmid := range []uint{1003, 1004} {
+		if err := db.Exec(`
+INSERT INTO instances (
+  instance_no, user_id, order_id, order_no, status, product_no, product_name, plan_no, plan_name,
+  cpu_cores, memory_mb, system_disk_gb, bandwidth_mbps, region_no, region_name,
+  template_no, template_name, os_family, os_distribution, os_version, external_node, external_vmid
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			fmt.Sprintf("INS-released-%d", i), 21, 31, "ORD-released", domaininstance.StatusReleased, "PROD-1", "Server", "PLAN-1", "Basic",
+			2, 2048, 40, 100, "REG-1", "China", "TPL-1", "Ubuntu", "linux", "ubuntu", "22.04", "node-a", vmid,
+		).Error; err != nil {
+			t.Fatalf("insert released instance: %v", err)
+		}
+	}
+	fake, client := newFakeMCP(t)
+	setProvisionInventory(fake, provisionNodesBalanced, `[{"vmid":1003,"name":"leftover","status":"stopped"}]`, `[]`)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{})
+
+	created, err := service.Provision(context.Background(), 77, "ORD-vmid-reuse")
+	if err != nil {
+		t.Fatalf("provision: %v", err)
+	}
+	if created.Instance.ExternalVMID != 1004 {
+		t.Fatalf("provision should reuse the free released VMID 1004, got %d", created.Instance.ExternalVMID)
+	}
+	var next uint
+	if err := db.Table("instance_provision_mappings").Where("mapping_no = ?", "MAP-1").Pluck("next_vmid", &next).Error; err != nil {
+		t.Fatalf("load next vmid: %v", err)
+	}
+	if next != 1010 {
+		t.Fatalf("reusing a released VMID must not advance next_vmid, got %d", next)
+	}
+}
+
+// insertRunningInstance 写入用户 21 在 node-a 上运行中的实例，默认归属 REG-1/PLAN-1 和默认集群。
+func insertRunningInstance(t *testing.T, db *gorm.DB, id uint64, instanceNo string, vmid uint) {
+	t.Helper()
+	if err := db.Exec(`
+INSERT INTO instances (
+  id, instance_no, user_id, order_id, order_no, status, product_no, product_name, plan_no, plan_name,
+  cpu_cores, memory_mb, system_disk_gb, bandwidth_mbps, region_no, region_name,
+  template_no, template_name, os_family, os_distribution, os_version, external_node, external_vmid
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, instanceNo, 21, 31, "ORD-"+instanceNo, domaininstance.StatusRunning, "PROD-1", "Server", "PLAN-1", "Basic",
+		2, 2048, 40, 100, "REG-1", "China", "TPL-1", "Ubuntu", "linux", "ubuntu", "22.04", "node-a", vmid,
+	).Error; err != nil {
+		t.Fatalf("insert instance %s: %v", instanceNo, err)
+	}
+}
+
+func TestInstanceAndNodeMetricsDownsampleUpstreamSamples(t *testing.T) {
+	db := openProvisionDB(t)
+	insertRunningInstance(t, db, 61, "INS-metrics", 1001)
+	fake, client := newFakeMCP(t)
+	// 四个采样按 MaxPoints=2 两两合并；第二个采样缺少 CPU，平均值只取有数据的采样。
+	fake.set("GET /api/pve/nodes/node-a/vms/1001/rrddata", `[
+  {"time":1760000000,"cpu":0.2,"mem":1073741824,"maxmem":2147483648,"netin":100,"netout":10},
+  {"time":1760000060,"mem":1073741824,"maxmem":2147483648,"netin":300,"netout":30},
+  {"time":1760000120,"cpu":0.5,"mem":536870912,"maxmem":2147483648,"netin":0,"netout":0},
+  {"time":1760000180,"cpu":0.7,"mem":536870912,"maxmem":2147483648,"netin":0,"netout":0}
+]`)
+	fake.set("GET /api/pve/nodes/node-a/rrddata", `[
+  {"time":1760000000,"cpu":0.1,"memused":8589934592,"memtotal":68719476736}
+]`)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{}).SetMetrics(nil, config.MetricsConfig{MaxPoints: 2})
+
+	metrics, err := service.InstanceMetrics(context.Background(), "INS-metrics", admindto.InstanceMetricsQuery{})
+	if err != nil {
+		t.Fatalf("instance metrics: %v", err)
+	}
+	if metrics.Range != domaininstance.MetricsRangeHour || len(metrics.Points) != 2 {
+		t.Fatalf("metrics should default to hour and downsample to 2 points, got %s/%d", metrics.Range, len(metrics.Points))
+	}
+	first, second := metrics.Points[0], metrics.Points[1]
+	if !first.Time.Equal(time.Unix(1760000000, 0)) || first.CPUPercent == nil || *first.CPUPercent != 20 || *first.MemoryUsedMB != 1024 || *first.NetInBps != 200 {
+		t.Fatalf("first bucket mismatch: %+v", first)
+	}
+	if second.CPUPercent == nil || *second.CPUPercent != 60 || *second.MemoryUsedMB != 512 || *second.MemoryTotalMB != 2048 {
+		t.Fatalf("second bucket mismatch: %+v", second)
+	}
+
+	if _, err := service.InstanceMetrics(context.Background(), "INS-metrics", admindto.InstanceMetricsQuery{Range: "decade"}); apperrors.From(err).Code != apperrors.ErrValidation.Code {
+		t.Fatalf("unknown range should be rejected, got %v", err)
+	}
+
+	node, err := service.NodeMetrics(context.Background(), "node-a", admindto.NodeMetricsQuery{Range: domaininstance.MetricsRangeHour})
+	if err != nil {
+		t.Fatalf("node metrics: %v", err)
+	}
+	if node.ClusterNo != config.DefaultMCPPVECluster || node.Instances != 1 || node.AllocatedCPUCores != 2 || node.AllocatedMemoryMB != 2048 || node.AllocatedDiskGB != 40 {
+		t.Fatalf("node aggregates mismatch: %+v", node)
+	}
+	if len(node.Points) != 1 || node.Points[0].MemoryUsedMB == nil || *node.Points[0].MemoryUsedMB != 8192 {
+		t.Fatalf("node points mismatch: %+v", node.Points)
+	}
+
+	if err := db.Exec(`UPDATE instances SET status = ? WHERE id = ?`, domaininstance.StatusReleased, 61).Error; err != nil {
+		t.Fatalf("release instance: %v", err)
+	}
+	if _, err := service.InstanceMetrics(context.Background(), "INS-metrics", admindto.InstanceMetricsQuery{}); apperrors.From(err).Code != apperrors.ErrConflict.Code {
+		t.Fatalf("released instance should have no metrics, got %v", err)
+	}
+}
+
+// openTrafficDB 在交付表基础上建立流量、钱包和通知表，写入 1 GB 流量套餐的运行中实例和余额为 balance 分的钱包。
+func openTrafficDB(t *testing.T, balance uint64) *gorm.DB {
+	t.Helper()
+	db := openProvisionDB(t)
+	mysqltest.Exec(t, db, instanceTrafficUsagesSchema, instanceWalletAccountsSchema, instanceWalletLedgerEntriesSchema, instanceNotificationsSchema)
+	insertRunningInstance(t, db, 61, "INS-traffic", 1001)
+	if err := db.Exec(`UPDATE instances SET traffic_gb = ?, service_started_at = ? WHERE id = ?`, 1, time.Now().Add(-24*time.Hour), 61).Error; err != nil {
+		t.Fatalf("set traffic quota: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO wallet_accounts (id, wallet_no, user_id, currency, status, available_balance_cents) VALUES (?, ?, ?, ?, ?, ?)`, 5, "WAL-21", 21, "CNY", "active", balance).Error; err != nil {
+		t.Fatalf("insert wallet: %v", err)
+	}
+	return db
+}
+
+func trafficTestConfig() config.TrafficConfig {
+	return config.TrafficConfig{Direction: domaininstance.TrafficDirectionOut, OveragePolicy: domaininstance.TrafficOverageBill, ThrottleMbps: 8, OveragePriceCentsPerGB: 100}
+}
+
+func loadTrafficUsage(t *testing.T, db *gorm.DB, period string) mysqlinstance.TrafficUsage {
+	t.Helper()
+	var usage mysqlinstance.TrafficUsage
+	if err := db.Where("instance_id = ? AND period = ?", 61, period).First(&usage).Error; err != nil {
+		t.Fatalf("load traffic usage %s: %v", period, err)
+	}
+	return usage
+}
+
+func walletBalance(t *testing.T, db *gorm.DB) uint64 {
+	t.Helper()
+	var balance uint64
+	if err := db.Table("wallet_accounts").Where("id = ?", 5).Pluck("available_balance_cents", &balance).Error; err != nil {
+		t.Fatalf("load wallet balance: %v", err)
+	}
+	return balance
+}
+
+func trafficOverageAction(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	var action string
+	if err := db.Raw(`SELECT COALESCE(traffic_overage_action, '') FROM instances WHERE id = ?`, 61).Scan(&action).Error; err != nil {
+		t.Fatalf("load overage action: %v", err)
+	}
+	return action
+}
+
+func TestMeterTrafficBillsOverageOncePerPeriodAndGB(t *testing.T) {
+	db := openTrafficDB(t, 10000)
+	_, client := newFakeMCP(t)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{}).SetTrafficConfig(trafficTestConfig())
+	ctx := context.Background()
+	row := mysqlinstance.Instance{ID: 61}
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+
+	if _, err := service.meterInstance(ctx, row, trafficCounter{}, now); err != nil {
+		t.Fatalf("baseline sample: %v", err)
+	}
+	// 出方向累计 2.5 GB，超出 1 GB 配额 1.5 GB，按开始计费的 GB 扣 2 GB。
+	counter := trafficCounter{netOut: 5 * domaininstance.BytesPerGB / 2}
+	transition, err := service.meterInstance(ctx, row, counter, now.Add(5*time.Minute))
+	if err != nil {
+		t.Fatalf("overage sample: %v", err)
+	}
+	if transition != nil {
+		t.Fatalf("a charged overage should not restrict the instance, got %+v", transition)
+	}
+	if balance := walletBalance(t, db); balance != 9800 {
+		t.Fatalf("overage should debit 2 GB, balance %d", balance)
+	}
+	usage := loadTrafficUsage(t, db, "2026-03")
+	if usage.OverageBilledGB != 2 || usage.OverageBilledCents != 200 || usage.OverageAction == nil || *usage.OverageAction != domaininstance.TrafficOverageBill {
+		t.Fatalf("usage should record the billed overage: %+v", usage)
+	}
+
+	// 计数器未增长时重复采样不再扣费。
+	if _, err := service.meterInstance(ctx, row, counter, now.Add(10*time.Minute)); err != nil {
+		t.Fatalf("repeat sample: %v", err)
+	}
+	// 即使用量记录丢失了已扣 GB，同一计费月和累计 GB 的流水幂等键也阻止重复扣费。
+	err = mysqltx.NewManager(db).WithinContext(ctx, func(tx *gorm.DB) error {
+		stale := usage
+		stale.OverageBilledGB, stale.OverageBilledCents = 0, 0
+		charged, err := service.chargeTrafficOverage(ctx, tx, mysqlinstance.Instance{UserID: 21, InstanceNo: "INS-traffic"}, stale, 2, map[string]any{})
+		if err == nil && !charged {
+			t.Fatalf("an already charged GB should count as charged")
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("recharge same overage: %v", err)
+	}
+	var entries int64
+	if err := db.Table("wallet_ledger_entries").Where("wallet_id = ? AND entry_type = ?", 5, "traffic_overage").Count(&entries).Error; err != nil {
+		t.Fatalf("count ledger entries: %v", err)
+	}
+	if balance := walletBalance(t, db); entries != 1 || balance != 9800 {
+		t.Fatalf("overage should be debited once, entries %d balance %d", entries, balance)
+	}
+
+	// 再多用 1 GB 后只补扣新增的 1 GB。
+	if _, err := service.meterInstance(ctx, row, trafficCounter{netOut: 7 * domaininstance.BytesPerGB / 2}, now.Add(15*time.Minute)); err != nil {
+		t.Fatalf("further overage sample: %v", err)
+	}
+	if usage := loadTrafficUsage(t, db, "2026-03"); usage.OverageBilledGB != 3 || usage.OverageBilledCents != 300 || walletBalance(t, db) != 9700 {
+		t.Fatalf("further overage should debit only the new GB: %+v balance %d", usage, walletBalance(t, db))
+	}
+}
+
+func TestMeterTrafficThrottlesWithoutBalanceAndUnthrottlesAfterRecharge(t *testing.T) {
+	db := openTrafficDB(t, 50)
+	fake, client := newFakeMCP(t)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{}).SetTrafficConfig(trafficTestConfig())
+	ctx := context.Background()
+	setVMCounters := func(netOut uint64) {
+		fake.set("GET /api/pve/nodes/node-a/vms", fmt.Sprintf(`[{"vmid":1001,"name":"INS-traffic","status":"running","netin":0,"netout":%d}]`, netOut))
+	}
+
+	setVMCounters(0)
+	if _, err := service.MeterTrafficByWorker(ctx); err != nil {
+		t.Fatalf("baseline meter: %v", err)
+	}
+	setVMCounters(2 * domaininstance.BytesPerGB)
+	result, err := service.MeterTrafficByWorker(ctx)
+	if err != nil {
+		t.Fatalf("overage meter: %v", err)
+	}
+	if result.Restricted != 1 || walletBalance(t, db) != 50 {
+		t.Fatalf("insufficient balance should throttle without debiting: %+v balance %d", result, walletBalance(t, db))
+	}
+	resize := "POST /api/pve/nodes/node-a/vms/1001/resize"
+	if writes := fake.writes(); len(writes) != 1 || writes[0] != resize || !strings.Contains(fake.body(resize), `"networkRate":1`) {
+		t.Fatalf("throttle should resize the NIC rate to 8 Mbps, got %v %s", writes, fake.body(resize))
+	}
+	if action := trafficOverageAction(t, db); action != domaininstance.TrafficOverageThrottle {
+		t.Fatalf("instance should be marked throttled, got %q", action)
+	}
+
+	// 限速操作仍未完成时充值，解除限速延后到下次采样，不与运行中的操作并发调整 VM。
+	if err := db.Exec(`UPDATE wallet_accounts SET available_balance_cents = ? WHERE id = ?`, 10000, 5).Error; err != nil {
+		t.Fatalf("recharge wallet: %v", err)
+	}
+	result, err = service.MeterTrafficByWorker(ctx)
+	if err != nil {
+		t.Fatalf("deferred meter: %v", err)
+	}
+	if result.Deferred != 1 || result.Restored != 0 || len(fake.writes()) != 1 {
+		t.Fatalf("unthrottle should wait for the running resize: %+v writes %v", result, fake.writes())
+	}
+	if action := trafficOverageAction(t, db); action != domaininstance.TrafficOverageThrottle {
+		t.Fatalf("deferred unthrottle should keep the throttle marker, got %q", action)
+	}
+
+	if err := db.Exec(`UPDATE instance_operations SET status = ?, completed_at = ? WHERE instance_id = ?`, domaininstance.OperationStatusSucceeded, time.Now(), 61).Error; err != nil {
+		t.Fatalf("complete throttle operation: %v", err)
+	}
+	result, err = service.MeterTrafficByWorker(ctx)
+	if err != nil {
+		t.Fatalf("restore meter: %v", err)
+	}
+	if result.Restored != 1 || walletBalance(t, db) != 9900 {
+		t.Fatalf("recharge should debit the overage and lift the throttle: %+v balance %d", result, walletBalance(t, db))
+	}
+	writes := fake.writes()
+	if len(writes) != 2 || writes[1] != resize || !strings.Contains(fake.body(resize), `"networkRate":12.5`) {
+		t.Fatalf("unthrottle should restore the plan bandwidth, got %v %s", writes, fake.body(resize))
+	}
+	if action := trafficOverageAction(t, db); action != "" {
+		t.Fatalf("instance throttle marker should be cleared, got %q", action)
+	}
+}
+
+func TestMeterTrafficDefersThrottleWhileStatusCannotResize(t *testing.T) {
+	db := openTrafficDB(t, 0)
+	fake, client := newFakeMCP(t)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{}).SetTrafficConfig(trafficTestConfig())
+	ctx := context.Background()
+	setVMCounters := func(netOut uint64) {
+		fake.set("GET /api/pve/nodes/node-a/vms", fmt.Sprintf(`[{"vmid":1001,"name":"INS-traffic","status":"running","netin":0,"netout":%d}]`, netOut))
+	}
+
+	setVMCounters(0)
+	if _, err := service.MeterTrafficByWorker(ctx); err != nil {
+		t.Fatalf("baseline meter: %v", err)
+	}
+	// 重装中的实例处于 creating，不能调整规格；限速延后到下次采样，计量本身不报错。
+	if err := db.Exec(`UPDATE instances SET status = ? WHERE id = ?`, domaininstance.StatusCreating, 61).Error; err != nil {
+		t.Fatalf("mark instance creating: %v", err)
+	}
+	setVMCounters(2 * domaininstance.BytesPerGB)
+	result, err := service.MeterTrafficByWorker(ctx)
+	if err != nil {
+		t.Fatalf("meter while creating should not fail: %v", err)
+	}
+	if result.Deferred != 1 || result.Restricted != 0 || len(fake.writes()) != 0 {
+		t.Fatalf("throttle should wait for an operable status: %+v writes %v", result, fake.writes())
+	}
+	if action := trafficOverageAction(t, db); action != "" {
+		t.Fatalf("deferred throttle should roll back the marker, got %q", action)
+	}
+
+	if err := db.Exec(`UPDATE instances SET status = ? WHERE id = ?`, domaininstance.StatusRunning, 61).Error; err != nil {
+		t.Fatalf("mark instance running: %v", err)
+	}
+	result, err = service.MeterTrafficByWorker(ctx)
+	if err != nil {
+		t.Fatalf("retry meter: %v", err)
+	}
+	resize := "POST /api/pve/nodes/node-a/vms/1001/resize"
+	if writes := fake.writes(); result.Restricted != 1 || len(writes) != 1 || writes[0] != resize {
+		t.Fatalf("throttle should apply once the instance is running: %+v writes %v", result, writes)
+	}
+	if action := trafficOverageAction(t, db); action != domaininstance.TrafficOverageThrottle {
+		t.Fatalf("instance should be marked throttled, got %q", action)
+	}
+}
+
+func TestMeterTrafficCarriesCountersIntoNewMonth(t *testing.T) {
+	db := openTrafficDB(t, 0)
+	_, client := newFakeMCP(t)
+	cfg := trafficTestConfig()
+	cfg.OveragePolicy = domaininstance.TrafficOverageThrottle
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{}).SetTrafficConfig(cfg)
+	ctx := context.Background()
+	row := mysqlinstance.Instance{ID: 61}
+	lastSample := time.Date(2026, 1, 31, 23, 50, 0, 0, time.Local)
+
+	if _, err := service.meterInstance(ctx, row, trafficCounter{}, lastSample.Add(-time.Hour)); err != nil {
+		t.Fatalf("baseline sample: %v", err)
+	}
+	transition, err := service.meterInstance(ctx, row, trafficCounter{netOut: 2 * domaininstance.BytesPerGB}, lastSample)
+	if err != nil {
+		t.Fatalf("january sample: %v", err)
+	}
+	if transition == nil || transition.to != domaininstance.TrafficOverageThrottle {
+		t.Fatalf("january overage should throttle, got %+v", transition)
+	}
+
+	// 跨月后的第一次采样继承一月最后的计数器，跨月间隔内的 100 字节计入二月，并解除一月的限速。
+	transition, err = service.meterInstance(ctx, row, trafficCounter{netOut: 2*domaininstance.BytesPerGB + 100}, lastSample.Add(20*time.Minute))
+	if err != nil {
+		t.Fatalf("february sample: %v", err)
+	}
+	if transition == nil || transition.from != domaininstance.TrafficOverageThrottle || transition.to != "" {
+		t.Fatalf("new month should lift the throttle, got %+v", transition)
+	}
+	january := loadTrafficUsage(t, db, "2026-01")
+	february := loadTrafficUsage(t, db, "2026-02")
+	if january.OutBytes != 2*domaininstance.BytesPerGB {
+		t.Fatalf("january usage should stay at its own traffic, got %d", january.OutBytes)
+	}
+	if february.OutBytes != 100 || february.LastNetOut != 2*domaininstance.BytesPerGB+100 || february.OverageAction != nil {
+		t.Fatalf("february should continue from january counters: %+v", february)
+	}
+
+	// 计数器回退说明 VM 重启，当前值即为重启后的流量。
+	if _, err := service.meterInstance(ctx, row, trafficCounter{netOut: 40}, lastSample.Add(40*time.Minute)); err != nil {
+		t.Fatalf("restart sample: %v", err)
+	}
+	if february := loadTrafficUsage(t, db, "2026-02"); february.OutBytes != 140 || february.LastNetOut != 40 {
+		t.Fatalf("counter reset should add the new counter value: %+v", february)
+	}
+}
+
+func TestForceTransferRotatesRootPasswordForRecipient(t *testing.T) {
+	db := openProvisionDB(t)
+	mysqltest.Exec(t, db, instanceTransfersSchema, instanceSecurityGroupsSchema, instancePTRRecordsSchema, instanceTicketsSchema, instanceNotificationsSchema)
+	insertRunningInstance(t, db, 61, "INS-transfer", 1001)
+	if err := db.Exec(`INSERT INTO users (id, username, email, password_hash, status) VALUES (?, ?, ?, ?, ?)`, 22, "receiver", "receiver@example.com", "hash", "active").Error; err != nil {
+		t.Fatalf("insert receiver: %v", err)
+	}
+	// 原所有者已查看过 root 密码。
+	if err := db.Exec(`UPDATE instances SET root_password_ciphertext = ?, root_password_revealed_at = ? WHERE id = ?`, "sealed-old", time.Now(), 61).Error; err != nil {
+		t.Fatalf("seed revealed password: %v", err)
+	}
+	fake, client := newFakeMCP(t)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{}).SetCredentialConfig(config.CredentialConfig{EncryptionKey: "test_credential_key_32_bytes_long", RootPasswordLength: 16})
+	ctx := context.Background()
+
+	if _, err := service.ForceTransfer(ctx, 77, "INS-transfer", admindto.InstanceForceTransferRequest{Account: "receiver", Reason: "账号合并"}); err != nil {
+		t.Fatalf("force transfer: %v", err)
+	}
+	var row mysqlinstance.Instance
+	if err := db.Where("id = ?", 61).First(&row).Error; err != nil {
+		t.Fatalf("load instance: %v", err)
+	}
+	if row.UserID != 22 || row.RootPasswordCiphertext != nil || row.RootPasswordRevealedAt != nil {
+		t.Fatalf("transfer should move the instance and drop the old password: user %d ciphertext %v revealed %v", row.UserID, row.RootPasswordCiphertext, row.RootPasswordRevealedAt)
+	}
+	var tasks int64
+	if err := db.Table("async_tasks").Where("task_type = ? AND object_no = ?", domaininstance.TaskTypeCredentialReset, "INS-transfer").Count(&tasks).Error; err != nil {
+		t.Fatalf("count credential reset tasks: %v", err)
+	}
+	if tasks != 1 {
+		t.Fatalf("transfer should schedule one password reset, got %d", tasks)
+	}
+
+	// 实例关机时 guest agent 不可用，重置延后；已不属于该用户的重置直接跳过。
+	if err := db.Exec(`UPDATE instances SET status = ? WHERE id = ?`, domaininstance.StatusStopped, 61).Error; err != nil {
+		t.Fatalf("stop instance: %v", err)
+	}
+	if err := service.ResetCredentialsByWorker(ctx, "INS-transfer", 22); !errors.Is(err, ErrOperationPending) {
+		t.Fatalf("stopped instance should defer the reset, got %v", err)
+	}
+	if err := db.Exec(`UPDATE instances SET status = ? WHERE id = ?`, domaininstance.StatusRunning, 61).Error; err != nil {
+		t.Fatalf("start instance: %v", err)
+	}
+	if err := service.ResetCredentialsByWorker(ctx, "INS-transfer", 21); err != nil {
+		t.Fatalf("stale reset should be skipped, got %v", err)
+	}
+	if writes := fake.writes(); len(writes) != 0 {
+		t.Fatalf("deferred or skipped resets must not reach upstream, got %v", writes)
+	}
+
+	if err := service.ResetCredentialsByWorker(ctx, "INS-transfer", 22); err != nil {
+		t.Fatalf("reset credentials: %v", err)
+	}
+	reset := "POST /api/pve/nodes/node-a/vms/1001/password"
+	if writes := fake.writes(); len(writes) != 1 || writes[0] != reset || !strings.Contains(fake.body(reset), `"password":"`) {
+		t.Fatalf("reset should set a new password upstream, got %v", writes)
+	}
+	fake.set("GET /api/pve/operations/op-1", `{"id":"op-1","status":"succeeded"}`)
+	fake.set("GET /api/pve/nodes/node-a/vms/1001", `{"vmid":1001,"name":"INS-transfer","status":"running"}`)
+	if _, err := service.SyncByWorker(ctx, "INS-transfer"); err != nil {
+		t.Fatalf("sync reset: %v", err)
+	}
+	if err := db.Where("id = ?", 61).First(&row).Error; err != nil {
+		t.Fatalf("load instance: %v", err)
+	}
+	if row.RootPasswordCiphertext == nil || *row.RootPasswordCiphertext == "sealed-old" || row.RootPasswordRevealedAt != nil {
+		t.Fatalf("recipient should get a new password that can be revealed once: %v %v", row.RootPasswordCiphertext, row.RootPasswordRevealedAt)
+	}
+	if plain, err := service.credentials.Open(*row.RootPasswordCiphertext); err != nil || !strings.Contains(fake.body(reset), plain) {
+		t.Fatalf("stored password should match the one sent upstream: %v", err)
+	}
+}
+
+func TestSuspendExpiredByWorkerStopsVMAndKeepsRenewedInstance(t *testing.T) {
+	db := openProvisionDB(t)
+	mysqltest.Exec(t, db, instanceNotificationsSchema)
+	insertRunningInstance(t, db, 61, "INS-expired", 1001)
+	insertRunningInstance(t, db, 62, "INS-renewed", 1002)
+	expiredAt := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	renewedAt := time.Now().AddDate(0, 1, 0).Truncate(time.Millisecond)
+	if err := db.Exec(`UPDATE instances SET expires_at = ? WHERE id = ?`, expiredAt, 61).Error; err != nil {
+		t.Fatalf("expire instance: %v", err)
+	}
+	if err := db.Exec(`UPDATE instances SET expires_at = ? WHERE id = ?`, renewedAt, 62).Error; err != nil {
+		t.Fatalf("renew instance: %v", err)
+	}
+	fake, client := newFakeMCP(t)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{AutoReleaseEnabled: true, ExpireReleaseAfterSeconds: 7 * 86400})
+	ctx := context.Background()
+
+	// 任务投递后实例已续费，或到期时间已被调整，都不暂停。
+	if err := service.SuspendExpiredByWorker(ctx, "TASK-renewed", "INS-renewed", expiredAt); err != nil {
+		t.Fatalf("renewed instance should be skipped, got %v", err)
+	}
+	if err := service.SuspendExpiredByWorker(ctx, "TASK-moved", "INS-expired", expiredAt.Add(-time.Minute)); err != nil {
+		t.Fatalf("adjusted expiry should be skipped, got %v", err)
+	}
+	if writes := fake.writes(); len(writes) != 0 {
+		t.Fatalf("skipped suspends must not reach upstream, got %v", writes)
+	}
+
+	if err := service.SuspendExpiredByWorker(ctx, "TASK-expired", "INS-expired", expiredAt); err != nil {
+		t.Fatalf("suspend expired instance: %v", err)
+	}
+	if writes := fake.writes(); len(writes) != 1 || writes[0] != "POST /api/pve/nodes/node-a/vms/1001/stop" {
+		t.Fatalf("expiry suspend should stop the vm, got %v", writes)
+	}
+	var row mysqlinstance.Instance
+	if err := db.Where("id = ?", 61).First(&row).Error; err != nil {
+		t.Fatalf("load instance: %v", err)
+	}
+	if row.Status != domaininstance.StatusSuspended || value(row.SuspendSource) != domaininstance.SuspendSourceExpiry || row.SuspendedAt == nil {
+		t.Fatalf("expired instance should be suspended by expiry: status %s source %v", row.Status, row.SuspendSource)
+	}
+	var ops int64
+	if err := db.Table("instance_operations").Where("instance_id = ? AND action = ?", 61, domaininstance.OperationSuspend).Count(&ops).Error; err != nil {
+		t.Fatalf("count operations: %v", err)
+	}
+	if ops != 1 {
+		t.Fatalf("expiry suspend should record one suspend operation, got %d", ops)
+	}
+	var content string
+	if err := db.Raw(`SELECT COALESCE(content_summary, '') FROM notifications WHERE notification_no = ?`, "NTF-TASK-expired-EMAIL").Scan(&content).Error; err != nil {
+		t.Fatalf("load notification: %v", err)
+	}
+	releaseAt := row.ExpiresAt.Add(7 * 24 * time.Hour).Format("2006-01-02 15:04")
+	if !strings.Contains(content, releaseAt) {
+		t.Fatalf("expiry notice should tell the user the release time %s, got %q", releaseAt, content)
+	}
+
+	fake.set("GET /api/pve/operations/op-1", `{"id":"op-1","status":"succeeded"}`)
+	fake.set("GET /api/pve/nodes/node-a/vms/1001", `{"vmid":1001,"name":"INS-expired","status":"stopped"}`)
+	if _, err := service.SyncByWorker(ctx, "INS-expired"); err != nil {
+		t.Fatalf("sync suspend: %v", err)
+	}
+	if err := db.Where("id = ?", 61).First(&row).Error; err != nil {
+		t.Fatalf("load instance: %v", err)
+	}
+	if row.Status != domaininstance.StatusSuspended {
+		t.Fatalf("stopped vm should keep the instance suspended, got %s", row.Status)
+	}
+
+	// 未续费的到期暂停不能由后台直接解除。
+	_, err := service.Unsuspend(ctx, 77, "INS-expired", admindto.InstanceUnsuspendRequest{})
+	if apperrors.From(err).Code != apperrors.ErrConflict.Code {
+		t.Fatalf("unsuspending an unpaid expiry should conflict, got %v", err)
+	}
+	if err := db.Exec(`UPDATE instances SET expires_at = ? WHERE id = ?`, renewedAt, 61).Error; err != nil {
+		t.Fatalf("extend instance: %v", err)
+	}
+	if _, err := service.Unsuspend(ctx, 77, "INS-expired", admindto.InstanceUnsuspendRequest{}); err != nil {
+		t.Fatalf("unsuspend extended instance: %v", err)
+	}
+	if err := db.Where("id = ?", 61).First(&row).Error; err != nil {
+		t.Fatalf("load instance: %v", err)
+	}
+	if row.Status != domaininstance.StatusStopped || row.SuspendSource != nil {
+		t.Fatalf("unsuspended instance should be stopped with the source cleared: %s %v", row.Status, row.SuspendSource)
+	}
+}
+
+func TestExitRescueByWorkerWaitsForBusyInstanceAndClearsRescue(t *testing.T) {
+	db := openProvisionDB(t)
+	mysqltest.Exec(t, db, instanceISOsSchema)
+	insertRunningInstance(t, db, 61, "INS-rescue", 1001)
+	if err := db.Exec(`INSERT INTO instance_isos (iso_no, name, purpose, volume) VALUES (?, ?, ?, ?)`, "ISO-rescue", "SystemRescue", domaininstance.ISOPurposeRescue, "cephfs:iso/systemrescue.iso").Error; err != nil {
+		t.Fatalf("insert rescue iso: %v", err)
+	}
+	rescueExpiresAt := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	if err := db.Exec(`UPDATE instances SET rescue_iso_no = ?, rescue_started_at = ?, rescue_expires_at = ?, rescue_password_ciphertext = ? WHERE id = ?`, "ISO-rescue", rescueExpiresAt.Add(-time.Hour), rescueExpiresAt, "sealed-rescue", 61).Error; err != nil {
+		t.Fatalf("enter rescue: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO instance_operations (operation_no, instance_id, action, status) VALUES (?, ?, ?, ?)`, "OP-busy", 61, domaininstance.OperationReboot, domaininstance.OperationStatusRunning).Error; err != nil {
+		t.Fatalf("insert running operation: %v", err)
+	}
+	fake, client := newFakeMCP(t)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{})
+	ctx := context.Background()
+
+	// 救援被重新进入后到期时间变化，旧任务跳过。
+	if err := service.ExitRescueByWorker(ctx, "INS-rescue", rescueExpiresAt.Add(-time.Hour)); err != nil {
+		t.Fatalf("stale exit should be skipped, got %v", err)
+	}
+	if err := service.ExitRescueByWorker(ctx, "INS-rescue", rescueExpiresAt); !errors.Is(err, ErrOperationPending) {
+		t.Fatalf("busy instance should defer the exit, got %v", err)
+	}
+	if writes := fake.writes(); len(writes) != 0 {
+		t.Fatalf("skipped or deferred exits must not reach upstream, got %v", writes)
+	}
+
+	if err := db.Exec(`UPDATE instance_operations SET status = ? WHERE operation_no = ?`, domaininstance.OperationStatusSucceeded, "OP-busy").Error; err != nil {
+		t.Fatalf("finish running operation: %v", err)
+	}
+	if err := service.ExitRescueByWorker(ctx, "INS-rescue", rescueExpiresAt); err != nil {
+		t.Fatalf("exit rescue: %v", err)
+	}
+	if writes := fake.writes(); len(writes) != 1 || writes[0] != "DELETE /api/pve/nodes/node-a/vms/1001/rescue" {
+		t.Fatalf("timed exit should leave rescue upstream, got %v", writes)
+	}
+	fake.set("GET /api/pve/operations/op-1", `{"id":"op-1","status":"succeeded"}`)
+	fake.set("GET /api/pve/nodes/node-a/vms/1001", `{"vmid":1001,"name":"INS-rescue","status":"running"}`)
+	if _, err := service.SyncByWorker(ctx, "INS-rescue"); err != nil {
+		t.Fatalf("sync rescue exit: %v", err)
+	}
+	var row mysqlinstance.Instance
+	if err := db.Where("id = ?", 61).First(&row).Error; err != nil {
+		t.Fatalf("load instance: %v", err)
+	}
+	if row.RescueStartedAt != nil || row.RescueExpiresAt != nil || row.RescueISONo != nil || row.RescuePasswordCiphertext != nil || row.Status != domaininstance.StatusRunning {
+		t.Fatalf("completed exit should clear the rescue state: %s %v %v", row.Status, row.RescueStartedAt, row.RescueISONo)
+	}
+}
+
+func TestMigrateRejectedUpstreamKeepsInstanceRunning(t *testing.T) {
+	db := openProvisionDB(t)
+	insertRunningInstance(t, db, 61, "INS-migrate", 1001)
+	fake, client := newFakeMCP(t)
+	fake.reject("POST /api/pve/nodes/node-a/vms/1001/migrate", "migration_refused")
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{})
+	adminID := uint64(77)
+
+	if err := service.MigrateByWorker(context.Background(), "INS-migrate", "node-a", "node-b", domaininstance.MigrateModeOnline, &adminID); err == nil {
+		t.Fatalf("rejected migration should fail")
+	}
+	var row mysqlinstance.Instance
+	if err := db.Where("id = ?", 61).First(&row).Error; err != nil {
+		t.Fatalf("load instance: %v", err)
+	}
+	if row.Status != domaininstance.StatusRunning || row.ExternalNode != "node-a" || value(row.LastErrorCode) != "mcp_call_failed" {
+		t.Fatalf("rejected migration should keep the instance running on the source node and record the error: %s %s %v", row.Status, row.ExternalNode, row.LastErrorCode)
+	}
+	var op mysqlinstance.Operation
+	if err := db.Where("instance_id = ? AND action = ?", 61, domaininstance.OperationMigrate).First(&op).Error; err != nil {
+		t.Fatalf("load operation: %v", err)
+	}
+	if op.Status != domaininstance.OperationStatusFailed {
+		t.Fatalf("rejected migration should fail its operation, got %s", op.Status)
+	}
+}
+
+func TestMigrateFallbackStopsMigratesOfflineAndRestarts(t *testing.T) {
+	db := openProvisionDB(t)
+	insertRunningInstance(t, db, 61, "INS-migrate", 1001)
+	fake, client := newFakeMCP(t)
+	service := NewService(db, client, nil, config.InstanceLifecycleConfig{})
+	ctx := context.Background()
+	adminID := uint64(77)
+	loadInstance := func() mysqlinstance.Instance {
+		t.Helper()
+		var row mysqlinstance.Instance
+		if err := db.Where("id = ?", 61).First(&row).Error; err != nil {
+			t.Fatalf("load instance: %v", err)
+		}
+		return row
+	}
+
+	if err := service.MigrateByWorker(ctx, "INS-migrate", "node-a", "node-b", domaininstance.MigrateModeAuto, &adminID); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	migrate := "POST /api/pve/nodes/node-a/vms/1001/migrate"
+	if !strings.Contains(fake.body(migrate), `"online":true`) {
+		t.Fatalf("auto migration of a running vm should try online first, got %s", fake.body(migrate))
+	}
+
+	// 在线迁移失败后 PVE 不接受对运行中的 VM 离线迁移，先关机。
+	fake.set("GET /api/pve/operations/op-1", `{"id":"op-1","status":"failed","error":{"code":"migration_failed","message":"failed"}}`)
+	if _, err := service.SyncByWorker(ctx, "INS-migrate"); err != nil {
+		t.Fatalf("sync online failure: %v", err)
+	}
+	if writes := fake.writes(); len(writes) != 2 || writes[1] != "POST /api/pve/nodes/node-a/vms/1001/stop" {
+		t.Fatalf("fallback should stop the vm before migrating offline, got %v", writes)
+	}
+	if row := loadInstance(); row.Status != domaininstance.StatusRunning || row.ExternalNode != "node-a" {
+		t.Fatalf("failed online migration should keep the instance running on the source node: %s %s", row.Status, row.ExternalNode)
+	}
+
+	fake.set("GET /api/pve/operations/op-2", `{"id":"op-2","status":"succeeded"}`)
+	if _, err := service.SyncByWorker(ctx, "INS-migrate"); err != nil {
+		t.Fatalf("sync stop stage: %v", err)
+	}
+	if writes := fake.writes(); len(writes) != 3 || writes[2] != migrate || strings.Contains(fake.body(migrate), `"online":true`) {
+		t.Fatalf("stopped vm should be migrated offline, got %v %s", writes, fake.body(migrate))
+	}
+	if row := loadInstance(); row.Status != domaininstance.StatusStopped || row.ExternalNode != "node-a" {
+		t.Fatalf("stop stage should not move the instance: %s %s", row.Status, row.ExternalNode)
+	}
+
+	fake.set("GET /api/pve/operations/op-3", `{"id":"op-3","status":"succeeded"}`)
+	if _, err := service.SyncByWorker(ctx, "INS-migrate"); err != nil {
+		t.Fatalf("sync offline migration: %v", err)
+	}
+	if writes := fake.writes(); len(writes) != 4 || writes[3] != "POST /api/pve/nodes/node-b/vms/1001/start" {
+		t.Fatalf("migrated vm should be started on the target node, got %v", writes)
+	}
+	if row := loadInstance(); row.ExternalNode != "node-b" {
+		t.Fatalf("offline migration should move the instance to node-b, got %s", row.ExternalNode)
+	}
+
+	fake.set("GET /api/pve/operations/op-4", `{"id":"op-4","status":"succeeded"}`)
+	fake.set("GET /api/pve/nodes/node-b/vms/1001", `{"vmid":1001,"name":"INS-migrate","status":"running"}`)
+	if _, err := service.SyncByWorker(ctx, "INS-migrate"); err != nil {
+		t.Fatalf("sync restart: %v", err)
+	}
+	if row := loadInstance(); row.Status != domaininstance.StatusRunning || row.ExternalNode != "node-b" {
+		t.Fatalf("instance should be running on node-b after the fallback, got %s %s", row.Status, row.ExternalNode)
+	}
+	var completions int64
+	if err := db.Table("admin_audit_logs").Where("action = ?", "instance.migrate.complete").Count(&completions).Error; err != nil {
+		t.Fatalf("count migration audits: %v", err)
+	}
+	if completions != 1 {
+		t.Fatalf("only the offline migration should record a completed migration, got %d", completions)
+	}
+}
+
+// seedCatalogSelection 写入 PLAN-1 在 REG-1/NET-1/TPL-1 上可按月购买的商品目录，供接管孤儿 VM 选择套餐组合。
+func seedCatalogSelection(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	statements := []struct {
+		sql  string
+		args []any
+	}{
+		{`INSERT INTO products (id, product_no, type, slug, name, status, visible) VALUES (?, ?, ?, ?, ?, ?, ?)`, []any{71, "PROD-1", "server", "server", "Server", "active", 1}},
+		{`INSERT INTO product_plans (id, plan_no, product_id, code, name, cpu_cores, memory_mb, system_disk_gb, bandwidth_mbps, status, visible) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, []any{81, "PLAN-1", 71, "basic", "Basic", 2, 2048, 40, 100, "active", 1}},
+		{`INSERT INTO plan_prices (plan_id, billing_cycle, price_cents) VALUES (?, ?, ?)`, []any{81, "monthly", 3000}},
+		{`INSERT INTO plan_regions (plan_id, region_id) SELECT ?, id FROM sales_regions WHERE region_no = ?`, []any{81, "REG-1"}},
+		{`INSERT INTO network_types (id, network_type_no, code, name) VALUES (?, ?, ?, ?)`, []any{91, "NET-1", "classic", "Classic"}},
+		{`INSERT INTO plan_network_types (plan_id, network_type_id) VALUES (?, ?)`, []any{81, 91}},
+		{`INSERT INTO server_os_templates (id, template_no, code, name, os_family, distribution, version) VALUES (?, ?, ?, ?, ?, ?, ?)`, []any{101, "TPL-1", "ubuntu-22", "Ubuntu", "linux", "ubuntu", "22.04"}},
+		{`INSERT INTO plan_os_templates (plan_id, template_id) VALUES (?, ?)`, []any{81, 101}},
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement.sql, statement.args...).Error; err != nil {
+			t.Fatalf("seed catalog: %v", err)
+		}
+	}
+}
+
+const instanceSalesRegionsSchema = `
+CREATE TABLE sales_regions (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  region_no VARCHAR(64) NOT NULL,
+  cluster_no VARCHAR(32) NOT NULL DEFAULT 'default',
+  code VARCHAR(64) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  country VARCHAR(64) NULL,
+  city VARCHAR(64) NULL,
+  summary VARCHAR(255) NULL,
+  status VARCHAR(32) NOT NULL DEFAULT 'active',
+  visible TINYINT(1) NOT NULL DEFAULT 1,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_sales_regions_region_no (region_no),
+  UNIQUE KEY uk_sales_regions_code (code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceProvisionMappingsSchema = `
+CREATE TABLE instance_provision_mappings (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  mapping_no VARCHAR(64) NOT NULL,
+  product_no VARCHAR(64) NULL,
+  plan_no VARCHAR(64) NOT NULL,
+  region_no VARCHAR(64) NOT NULL,
+  template_no VARCHAR(64) NOT NULL,
+  network_type_no VARCHAR(64) NOT NULL DEFAULT '',
+  node VARCHAR(128) NOT NULL,
+  placement_nodes VARCHAR(500) NULL,
+  storage VARCHAR(128) NOT NULL,
+  disk_source VARCHAR(255) NOT NULL,
+  disk_format VARCHAR(32) NULL,
+  disk_interface VARCHAR(32) NULL,
+  data_disk_storage VARCHAR(128) NULL,
+  snippets_storage VARCHAR(128) NULL,
+  ci_user VARCHAR(64) NULL,
+  ssh_keys TEXT NULL,
+  ip_config0 VARCHAR(255) NULL,
+  nameserver VARCHAR(128) NULL,
+  search_domain VARCHAR(128) NULL,
+  ci_packages TEXT NULL,
+  apt_mirror VARCHAR(255) NULL,
+  vmid_start INT UNSIGNED NOT NULL,
+  vmid_end INT UNSIGNED NOT NULL,
+  next_vmid INT UNSIGNED NOT NULL,
+  reuse_released_vmids TINYINT(1) NOT NULL DEFAULT 0,
+  status VARCHAR(32) NOT NULL DEFAULT 'active',
+  remark VARCHAR(500) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_instance_provision_mappings_mapping_no (mapping_no),
+  UNIQUE KEY uk_instance_provision_mappings_scope (plan_no, region_no, template_no, network_type_no, status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceProductsSchema = `
+CREATE TABLE products (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  product_no VARCHAR(64) NOT NULL,
+  type VARCHAR(32) NOT NULL,
+  slug VARCHAR(96) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  summary VARCHAR(255) NULL,
+  description TEXT NULL,
+  status VARCHAR(32) NOT NULL DEFAULT 'draft',
+  visible TINYINT(1) NOT NULL DEFAULT 0,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_products_product_no (product_no),
+  UNIQUE KEY uk_products_slug (slug)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceProductPlansSchema = `
+CREATE TABLE product_plans (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  plan_no VARCHAR(64) NOT NULL,
+  product_id BIGINT UNSIGNED NOT NULL,
+  code VARCHAR(96) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  summary VARCHAR(255) NULL,
+  cpu_cores INT NOT NULL,
+  memory_mb INT NOT NULL,
+  system_disk_gb INT NOT NULL,
+  data_disk_gb INT NOT NULL DEFAULT 0,
+  bandwidth_mbps INT NOT NULL,
+  traffic_gb INT NULL,
+  public_ip_count INT NOT NULL DEFAULT 1,
+  snapshot_quota INT NOT NULL DEFAULT 0,
+  virtualization VARCHAR(32) NOT NULL DEFAULT 'kvm',
+  architecture VARCHAR(32) NOT NULL DEFAULT 'x86_64',
+  is_featured TINYINT(1) NOT NULL DEFAULT 0,
+  status VARCHAR(32) NOT NULL DEFAULT 'draft',
+  capacity_sold_out_at DATETIME(3) NULL,
+  visible TINYINT(1) NOT NULL DEFAULT 0,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_product_plans_plan_no (plan_no),
+  UNIQUE KEY uk_product_plans_code (code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instancePlanRegionsSchema = `
+CREATE TABLE plan_regions (
+  plan_id BIGINT UNSIGNED NOT NULL,
+  region_id BIGINT UNSIGNED NOT NULL,
+  status VARCHAR(32) NOT NULL DEFAULT 'active',
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  PRIMARY KEY (plan_id, region_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instancePlanPricesSchema = `
+CREATE TABLE plan_prices (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  plan_id BIGINT UNSIGNED NOT NULL,
+  billing_cycle VARCHAR(32) NOT NULL,
+  price_cents BIGINT UNSIGNED NOT NULL,
+  original_price_cents BIGINT UNSIGNED NULL,
+  currency VARCHAR(16) NOT NULL DEFAULT 'CNY',
+  status VARCHAR(32) NOT NULL DEFAULT 'active',
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_plan_prices_plan_cycle (plan_id, billing_cycle)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceNetworkTypesSchema = `
+CREATE TABLE network_types (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  network_type_no VARCHAR(64) NOT NULL,
+  code VARCHAR(64) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  summary VARCHAR(255) NULL,
+  status VARCHAR(32) NOT NULL DEFAULT 'active',
+  visible TINYINT(1) NOT NULL DEFAULT 1,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_network_types_network_type_no (network_type_no),
+  UNIQUE KEY uk_network_types_code (code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instancePlanNetworkTypesSchema = `
+CREATE TABLE plan_network_types (
+  plan_id BIGINT UNSIGNED NOT NULL,
+  network_type_id BIGINT UNSIGNED NOT NULL,
+  status VARCHAR(32) NOT NULL DEFAULT 'active',
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  PRIMARY KEY (plan_id, network_type_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceOSTemplatesSchema = `
+CREATE TABLE server_os_templates (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  template_no VARCHAR(64) NOT NULL,
+  code VARCHAR(96) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  os_family VARCHAR(32) NOT NULL,
+  distribution VARCHAR(64) NOT NULL,
+  version VARCHAR(64) NOT NULL,
+  architecture VARCHAR(32) NOT NULL DEFAULT 'x86_64',
+  summary VARCHAR(255) NULL,
+  status VARCHAR(32) NOT NULL DEFAULT 'active',
+  visible TINYINT(1) NOT NULL DEFAULT 1,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_server_os_templates_template_no (template_no),
+  UNIQUE KEY uk_server_os_templates_code (code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instancePlanOSTemplatesSchema = `
+CREATE TABLE plan_os_templates (
+  plan_id BIGINT UNSIGNED NOT NULL,
+  template_id BIGINT UNSIGNED NOT NULL,
+  status VARCHAR(32) NOT NULL DEFAULT 'active',
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  PRIMARY KEY (plan_id, template_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceReconcileReportsSchema = `
+CREATE TABLE instance_reconcile_reports (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  report_no VARCHAR(64) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'running',
+  task_no VARCHAR(64) NULL,
+  admin_id BIGINT UNSIGNED NULL,
+  node_count INT NOT NULL DEFAULT 0,
+  vm_count INT NOT NULL DEFAULT 0,
+  instance_count INT NOT NULL DEFAULT 0,
+  orphan_count INT NOT NULL DEFAULT 0,
+  ghost_count INT NOT NULL DEFAULT 0,
+  drift_count INT NOT NULL DEFAULT 0,
+  node_errors TEXT NULL,
+  error_message VARCHAR(500) NULL,
+  started_at DATETIME(3) NOT NULL,
+  finished_at DATETIME(3) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_instance_reconcile_reports_report_no (report_no)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceReconcileItemsSchema = `
+CREATE TABLE instance_reconcile_items (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  report_id BIGINT UNSIGNED NOT NULL,
+  kind VARCHAR(16) NOT NULL,
+  cluster_no VARCHAR(32) NOT NULL DEFAULT 'default',
+  node VARCHAR(128) NOT NULL,
+  vmid INT UNSIGNED NOT NULL,
+  vm_name VARCHAR(255) NULL,
+  vm_status VARCHAR(32) NULL,
+  vm_cpus INT NULL,
+  vm_memory_mb INT NULL,
+  instance_id BIGINT UNSIGNED NULL,
+  instance_no VARCHAR(64) NULL,
+  instance_status VARCHAR(32) NULL,
+  drift VARCHAR(255) NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'open',
+  resolution VARCHAR(32) NULL,
+  resolved_by BIGINT UNSIGNED NULL,
+  resolved_at DATETIME(3) NULL,
+  remark VARCHAR(500) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_instance_reconcile_items_report_vm (report_id, cluster_no, node, vmid)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceTrafficUsagesSchema = `
+CREATE TABLE instance_traffic_usages (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  instance_id BIGINT UNSIGNED NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  period CHAR(7) NOT NULL,
+  quota_gb INT NULL,
+  in_bytes BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  out_bytes BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  billed_bytes BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  last_netin BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  last_netout BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  last_sampled_at DATETIME(3) NULL,
+  warning_notified_at DATETIME(3) NULL,
+  exceeded_notified_at DATETIME(3) NULL,
+  overage_action VARCHAR(16) NULL,
+  overage_applied_at DATETIME(3) NULL,
+  overage_billed_gb BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  overage_billed_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_instance_traffic_usages_instance_period (instance_id, period)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceWalletAccountsSchema = `
+CREATE TABLE wallet_accounts (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  wallet_no VARCHAR(64) NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  currency VARCHAR(16) NOT NULL DEFAULT 'CNY',
+  status VARCHAR(32) NOT NULL DEFAULT 'active',
+  available_balance_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  total_recharged_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  total_spent_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  total_refunded_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_wallet_accounts_wallet_no (wallet_no),
+  UNIQUE KEY uk_wallet_accounts_user_currency (user_id, currency)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceWalletLedgerEntriesSchema = `
+CREATE TABLE wallet_ledger_entries (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  entry_no VARCHAR(64) NOT NULL,
+  wallet_id BIGINT UNSIGNED NOT NULL,
+  wallet_no VARCHAR(64) NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  direction VARCHAR(16) NOT NULL,
+  entry_type VARCHAR(32) NOT NULL,
+  amount_cents BIGINT UNSIGNED NOT NULL,
+  balance_before_cents BIGINT UNSIGNED NOT NULL,
+  balance_after_cents BIGINT UNSIGNED NOT NULL,
+  currency VARCHAR(16) NOT NULL DEFAULT 'CNY',
+  related_type VARCHAR(32) NOT NULL,
+  related_no VARCHAR(64) NOT NULL,
+  idempotency_key VARCHAR(160) NOT NULL,
+  summary JSON NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_wallet_ledger_entries_entry_no (entry_no),
+  UNIQUE KEY uk_wallet_ledger_entries_idempotency (wallet_id, idempotency_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceNotificationsSchema = `
+CREATE TABLE notifications (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  notification_no VARCHAR(64) NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  channel VARCHAR(16) NOT NULL,
+  scene VARCHAR(64) NOT NULL,
+  target VARCHAR(191) NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  subject VARCHAR(255) NULL,
+  content_summary VARCHAR(500) NULL,
+  related_object_type VARCHAR(64) NULL,
+  related_object_no VARCHAR(64) NULL,
+  task_no VARCHAR(64) NULL,
+  error_code VARCHAR(64) NULL,
+  error_message VARCHAR(500) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  sent_at DATETIME(3) NULL,
+  UNIQUE KEY uk_notifications_notification_no (notification_no)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceTransfersSchema = `
+CREATE TABLE instance_transfers (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  transfer_no VARCHAR(64) NOT NULL,
+  instance_id BIGINT UNSIGNED NOT NULL,
+  instance_no VARCHAR(64) NOT NULL,
+  from_user_id BIGINT UNSIGNED NOT NULL,
+  to_user_id BIGINT UNSIGNED NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  remark VARCHAR(500) NULL,
+  forced_by BIGINT UNSIGNED NULL,
+  force_reason VARCHAR(500) NULL,
+  responded_at DATETIME(3) NULL,
+  completed_at DATETIME(3) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_instance_transfers_transfer_no (transfer_no)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceSecurityGroupsSchema = `
+CREATE TABLE instance_security_groups (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  instance_id BIGINT UNSIGNED NOT NULL,
+  security_group_id BIGINT UNSIGNED NOT NULL,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_instance_security_groups_instance_group (instance_id, security_group_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instancePTRRecordsSchema = `
+CREATE TABLE ip_ptr_records (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  ip_address_id BIGINT UNSIGNED NOT NULL,
+  address VARCHAR(64) NOT NULL,
+  instance_id BIGINT UNSIGNED NOT NULL,
+  instance_no VARCHAR(64) NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  hostname VARCHAR(253) NOT NULL,
+  applied_hostname VARCHAR(253) NULL,
+  status VARCHAR(16) NOT NULL,
+  last_error VARCHAR(500) NULL,
+  reviewed_by BIGINT UNSIGNED NULL,
+  reviewed_at DATETIME(3) NULL,
+  review_remark VARCHAR(500) NULL,
+  applied_at DATETIME(3) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_ip_ptr_records_ip_address (ip_address_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceTicketsSchema = `
+CREATE TABLE tickets (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  ticket_no VARCHAR(64) NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  instance_id BIGINT UNSIGNED NULL,
+  instance_no VARCHAR(64) NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceISOsSchema = `
+CREATE TABLE instance_isos (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  iso_no VARCHAR(64) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  purpose VARCHAR(16) NOT NULL,
+  media VARCHAR(16) NOT NULL DEFAULT 'iso',
+  volume VARCHAR(255) NOT NULL,
+  os_family VARCHAR(32) NULL,
+  description VARCHAR(500) NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'active',
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_instance_isos_iso_no (iso_no)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
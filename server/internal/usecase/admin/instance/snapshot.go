@@ -0,0 +1,216 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+)
+
+// Snapshots 返回实例未删除的快照，以及当前套餐的快照配额占用。
+func (s *Service) Snapshots(ctx context.Context, instanceNo string) (admindto.InstanceSnapshotList, error) {
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.InstanceSnapshotList{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return admindto.InstanceSnapshotList{}, err
+	}
+	quota, err := s.instances.PlanSnapshotQuota(ctx, nil, row.PlanNo)
+	if err != nil {
+		return admindto.InstanceSnapshotList{}, err
+	}
+	used, err := s.instances.CountQuotaSnapshots(ctx, nil, row.ID)
+	if err != nil {
+		return admindto.InstanceSnapshotList{}, err
+	}
+	rows, err := s.instances.Snapshots(ctx, row.ID)
+	if err != nil {
+		return admindto.InstanceSnapshotList{}, err
+	}
+	items := make([]admindto.InstanceSnapshotItem, 0, len(rows))
+	for _, snapshot := range rows {
+		items = append(items, snapshotItem(snapshot))
+	}
+	return admindto.InstanceSnapshotList{Quota: quota, Used: used, List: items}, nil
+}
+
+func (s *Service) CreateSnapshot(ctx context.Context, operatorID uint64, instanceNo string, req admindto.InstanceSnapshotCreateRequest) (admindto.InstanceSnapshotItem, error) {
+	var snapshot mysqlinstance.Snapshot
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		quota, err := s.instances.PlanSnapshotQuota(ctx, tx, current.PlanNo)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		used, err := s.instances.CountQuotaSnapshots(ctx, tx, current.ID)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		if used >= int64(quota) {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("快照数量已达套餐上限")
+		}
+		snapshot = newSnapshot(current.ID, req.Description, nil, &operatorID)
+		if err := s.instances.CreateSnapshot(ctx, tx, &snapshot); err != nil {
+			return operationPlan{}, err
+		}
+		input := mcppve.CreateSnapshotRequest{Name: snapshot.Name, Description: value(snapshot.Description)}
+		return operationPlan{payload: snapshotPayload(snapshot), call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).CreateSnapshot(ctx, row.ExternalNode, row.ExternalVMID, input)
+		}}, nil
+	}
+	if _, err := s.startOperation(ctx, instanceNo, &operatorID, nil, domaininstance.OperationSnapshotCreate, apperrors.ErrConflict.WithMessage("实例已有未完成操作"), nil, planner); err != nil {
+		return admindto.InstanceSnapshotItem{}, err
+	}
+	return s.snapshot(ctx, snapshot.SnapshotNo)
+}
+
+// RollbackSnapshot 回滚实例到指定快照；实例存在未完成操作时拒绝回滚，避免与其他上游任务交错。
+func (s *Service) RollbackSnapshot(ctx context.Context, operatorID uint64, instanceNo string, snapshotNo string) (admindto.InstanceSnapshotItem, error) {
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		snapshot, err := s.lockSnapshot(ctx, tx, current.ID, snapshotNo)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		if !domaininstance.CanRollbackSnapshot(snapshot.Status) {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("当前快照状态不能回滚")
+		}
+		return operationPlan{payload: snapshotPayload(snapshot), call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).RollbackSnapshot(ctx, row.ExternalNode, row.ExternalVMID, snapshot.Name)
+		}}, nil
+	}
+	if _, err := s.startOperation(ctx, instanceNo, &operatorID, nil, domaininstance.OperationSnapshotRollback, apperrors.ErrConflict.WithMessage("实例已有未完成操作，暂不能回滚快照"), nil, planner); err != nil {
+		return admindto.InstanceSnapshotItem{}, err
+	}
+	return s.snapshot(ctx, strings.TrimSpace(snapshotNo))
+}
+
+// DeleteSnapshot 删除实例快照。创建失败的快照在上游并不存在，只在本地标记删除。
+func (s *Service) DeleteSnapshot(ctx context.Context, operatorID uint64, instanceNo string, snapshotNo string) (admindto.InstanceSnapshotItem, error) {
+	discarded, err := s.discardFailedSnapshot(ctx, operatorID, instanceNo, snapshotNo)
+	if err != nil {
+		return admindto.InstanceSnapshotItem{}, err
+	}
+	if !discarded {
+		planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+			snapshot, err := s.lockSnapshot(ctx, tx, current.ID, snapshotNo)
+			if err != nil {
+				return operationPlan{}, err
+			}
+			if snapshot.Status != domaininstance.SnapshotStatusAvailable {
+				return operationPlan{}, apperrors.ErrConflict.WithMessage("当前快照状态不能删除")
+			}
+			if err := s.instances.UpdateSnapshot(ctx, tx, snapshot.ID, map[string]any{"status": domaininstance.SnapshotStatusDeleting}); err != nil {
+				return operationPlan{}, err
+			}
+			return operationPlan{payload: snapshotPayload(snapshot), call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+				return s.mcp.Cluster(row.ClusterNo).DeleteSnapshot(ctx, row.ExternalNode, row.ExternalVMID, snapshot.Name)
+			}}, nil
+		}
+		if _, err := s.startOperation(ctx, instanceNo, &operatorID, nil, domaininstance.OperationSnapshotDelete, apperrors.ErrConflict.WithMessage("实例已有未完成操作"), nil, planner); err != nil {
+			return admindto.InstanceSnapshotItem{}, err
+		}
+	}
+	return s.snapshot(ctx, strings.TrimSpace(snapshotNo))
+}
+
+func (s *Service) discardFailedSnapshot(ctx context.Context, operatorID uint64, instanceNo string, snapshotNo string) (bool, error) {
+	discarded := false
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		snapshot, err := s.lockSnapshot(ctx, tx, current.ID, snapshotNo)
+		if err != nil {
+			return err
+		}
+		if !domaininstance.CanDeleteSnapshot(snapshot.Status) {
+			return apperrors.ErrConflict.WithMessage("当前快照状态不能删除")
+		}
+		if snapshot.Status != domaininstance.SnapshotStatusFailed {
+			return nil
+		}
+		if err := s.instances.UpdateSnapshot(ctx, tx, snapshot.ID, map[string]any{"status": domaininstance.SnapshotStatusDeleted, "deleted_at": time.Now()}); err != nil {
+			return err
+		}
+		discarded = true
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance." + domaininstance.OperationSnapshotDelete, ObjectType: objectType, ObjectID: current.InstanceNo, BeforeData: snapshotAudit(snapshot), AfterData: map[string]any{"status": domaininstance.SnapshotStatusDeleted}, Remark: "清理创建失败的快照"})
+	})
+	return discarded, err
+}
+
+func (s *Service) lockSnapshot(ctx context.Context, tx *gorm.DB, instanceID uint64, snapshotNo string) (mysqlinstance.Snapshot, error) {
+	snapshot, err := s.instances.SnapshotForUpdate(ctx, tx, instanceID, strings.TrimSpace(snapshotNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return mysqlinstance.Snapshot{}, apperrors.ErrNotFound.WithMessage("快照不存在")
+	}
+	return snapshot, err
+}
+
+func (s *Service) snapshot(ctx context.Context, snapshotNo string) (admindto.InstanceSnapshotItem, error) {
+	snapshot, err := s.instances.SnapshotByNo(ctx, snapshotNo)
+	if err != nil {
+		return admindto.InstanceSnapshotItem{}, err
+	}
+	return snapshotItem(snapshot), nil
+}
+
+// settleSnapshot 按快照操作结果回写快照状态，非快照操作直接跳过。
+func (s *Service) settleSnapshot(ctx context.Context, tx *gorm.DB, op mysqlinstance.Operation, succeeded bool) error {
+	snapshotNo, updates := snapshotSettlementUpdates(op, succeeded, time.Now())
+	if snapshotNo == "" {
+		return nil
+	}
+	return s.instances.UpdateSnapshotByNo(ctx, tx, snapshotNo, updates)
+}
+
+func newSnapshot(instanceID uint64, description *string, userID *uint64, adminID *uint64) mysqlinstance.Snapshot {
+	// PVE 快照名只允许字母开头的短标识，这里与对外编号共用同一时间戳。
+	now := time.Now().UnixNano()
+	return mysqlinstance.Snapshot{SnapshotNo: fmt.Sprintf("SNAP-%d", now), InstanceID: instanceID, Name: fmt.Sprintf("snap%d", now), Description: normalizeOptional(description), Status: domaininstance.SnapshotStatusCreating, CreatedByUserID: userID, CreatedByAdminID: adminID}
+}
+
+func snapshotPayload(snapshot mysqlinstance.Snapshot) mysqlinstance.SnapshotPayload {
+	return mysqlinstance.SnapshotPayload{SnapshotNo: snapshot.SnapshotNo, Name: snapshot.Name}
+}
+
+func snapshotSettlementUpdates(op mysqlinstance.Operation, succeeded bool, now time.Time) (string, map[string]any) {
+	status := domaininstance.SnapshotStatusAfterOperation(op.Action, succeeded)
+	if status == "" || op.Payload == nil {
+		return "", nil
+	}
+	var payload mysqlinstance.SnapshotPayload
+	if err := json.Unmarshal([]byte(*op.Payload), &payload); err != nil || strings.TrimSpace(payload.SnapshotNo) == "" {
+		return "", nil
+	}
+	updates := map[string]any{"status": status}
+	if status == domaininstance.SnapshotStatusDeleted {
+		updates["deleted_at"] = now
+	}
+	if op.Action == domaininstance.OperationSnapshotRollback && succeeded {
+		updates["last_rolled_back_at"] = now
+	}
+	return payload.SnapshotNo, updates
+}
+
+func snapshotItem(snapshot mysqlinstance.Snapshot) admindto.InstanceSnapshotItem {
+	return admindto.InstanceSnapshotItem{SnapshotNo: snapshot.SnapshotNo, Name: snapshot.Name, Description: snapshot.Description, Status: snapshot.Status, CreatedByUserID: snapshot.CreatedByUserID, CreatedByAdminID: snapshot.CreatedByAdminID, LastRolledBackAt: snapshot.LastRolledBackAt, CreatedAt: snapshot.CreatedAt}
+}
+
+func snapshotAudit(snapshot mysqlinstance.Snapshot) map[string]any {
+	return map[string]any{"snapshot_no": snapshot.SnapshotNo, "name": snapshot.Name, "status": snapshot.Status}
+}
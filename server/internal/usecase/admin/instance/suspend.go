@@ -0,0 +1,211 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+)
+
+var errSuspendSkipped = errors.New("suspend skipped")
+
+// Suspend 由后台暂停实例，用于滥用等人工处置；原因展示给用户，续费不会解除，只能由后台解除。
+func (s *Service) Suspend(ctx context.Context, operatorID uint64, instanceNo string, req admindto.InstanceSuspendRequest) (admindto.InstanceDetail, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return admindto.InstanceDetail{}, apperrors.ErrValidation.WithMessage("请填写暂停原因")
+	}
+	instanceNo = strings.TrimSpace(instanceNo)
+	if err := s.suspend(ctx, instanceNo, &operatorID, domaininstance.SuspendSourceAdmin, reason, fmt.Sprintf("NTF-SUSPEND-%d-EMAIL", time.Now().UnixNano()), nil); err != nil {
+		return admindto.InstanceDetail{}, err
+	}
+	return s.detail(ctx, instanceNo)
+}
+
+// SuspendExpiredByWorker 在实例到期时暂停实例；到期时间已被续费或调整、实例不处于可暂停状态时跳过。
+func (s *Service) SuspendExpiredByWorker(ctx context.Context, taskNo string, instanceNo string, expectedExpiresAt time.Time) error {
+	err := s.suspend(ctx, strings.TrimSpace(instanceNo), nil, domaininstance.SuspendSourceExpiry, domaininstance.ExpirySuspendReason, "NTF-"+taskNo+"-EMAIL", func(current mysqlinstance.Instance) error {
+		if current.ExpiresAt == nil || current.ExpiresAt.After(time.Now()) {
+			return errSuspendSkipped
+		}
+		if !current.ExpiresAt.Truncate(time.Millisecond).Equal(expectedExpiresAt.Truncate(time.Millisecond)) {
+			return errSuspendSkipped
+		}
+		if !domaininstance.CanSuspend(current.Status) {
+			return errSuspendSkipped
+		}
+		return nil
+	})
+	if errors.Is(err, errSuspendSkipped) {
+		return nil
+	}
+	return err
+}
+
+// Unsuspend 由后台解除暂停，实例回到关机状态由用户自行开机；到期暂停的实例仍未续费时需先调整到期时间。
+func (s *Service) Unsuspend(ctx context.Context, operatorID uint64, instanceNo string, req admindto.InstanceUnsuspendRequest) (admindto.InstanceDetail, error) {
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if current.Status != domaininstance.StatusSuspended {
+			return apperrors.ErrConflict.WithMessage("实例未暂停")
+		}
+		if value(current.SuspendSource) == domaininstance.SuspendSourceExpiry && (current.ExpiresAt == nil || !current.ExpiresAt.After(time.Now())) {
+			return apperrors.ErrConflict.WithMessage("实例仍处于到期状态，请先续费或调整到期时间")
+		}
+		if err := s.instances.UnsuspendInstance(ctx, tx, current.ID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance.unsuspend", ObjectType: objectType, ObjectID: current.InstanceNo, BeforeData: map[string]any{"status": current.Status, "suspend_source": current.SuspendSource, "suspend_reason": current.SuspendReason}, AfterData: map[string]any{"status": domaininstance.StatusStopped, "remark": req.Remark}, Remark: "解除实例暂停"})
+	})
+	if err != nil {
+		return admindto.InstanceDetail{}, err
+	}
+	return s.detail(ctx, strings.TrimSpace(instanceNo))
+}
+
+// suspend 把实例置为暂停并记录来源和原因；实例运行中时创建暂停操作关闭 VM，关机结果由操作同步任务确认。
+// 关机下发失败时回滚暂停标记，由调用方重试。
+func (s *Service) suspend(ctx context.Context, instanceNo string, adminID *uint64, source string, reason string, notificationNo string, guard func(mysqlinstance.Instance) error) error {
+	if !s.mcp.Enabled() {
+		return mcpUnavailableError()
+	}
+	var pendingErr error = apperrors.ErrConflict.WithMessage("实例已有未完成操作")
+	if adminID == nil {
+		pendingErr = ErrOperationPending
+	}
+	var row mysqlinstance.Instance
+	var op mysqlinstance.Operation
+	stopVM := false
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, instanceNo)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if guard != nil {
+			if err := guard(current); err != nil {
+				return err
+			}
+		}
+		if current.Status == domaininstance.StatusSuspended {
+			return apperrors.ErrConflict.WithMessage("实例已暂停")
+		}
+		if !domaininstance.CanSuspend(current.Status) {
+			return apperrors.ErrConflict.WithMessage("当前实例状态不能暂停")
+		}
+		if err := s.ensureNoRunningOperation(ctx, tx, current.ID, pendingErr); err != nil {
+			return err
+		}
+		if err := s.instances.UpdateInstance(ctx, tx, current.ID, map[string]any{"status": domaininstance.StatusSuspended, "suspend_source": source, "suspend_reason": reason, "suspended_at": time.Now()}); err != nil {
+			return err
+		}
+		row = current
+		if current.Status == domaininstance.StatusRunning {
+			stopVM = true
+			op = newOperation(current.ID, &current.OrderID, adminID, nil, domaininstance.OperationSuspend)
+			if err := s.instances.CreateOperation(ctx, tx, &op); err != nil {
+				return err
+			}
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: adminID, Action: "instance.suspend", ObjectType: objectType, ObjectID: current.InstanceNo, BeforeData: instanceAudit(current), AfterData: map[string]any{"status": domaininstance.StatusSuspended, "suspend_source": source, "suspend_reason": reason}, Remark: reason})
+	})
+	if err != nil {
+		return err
+	}
+	if stopVM {
+		if err := s.stopSuspended(ctx, row, op); err != nil {
+			_ = s.instances.UpdateInstance(context.Background(), nil, row.ID, map[string]any{"status": row.Status, "suspend_source": nil, "suspend_reason": nil, "suspended_at": nil})
+			return err
+		}
+	}
+	_ = s.notifySuspended(ctx, row, source, reason, notificationNo)
+	return nil
+}
+
+// enforceSuspend 在同步发现暂停实例的 VM 被重新开机时再次关机；下发失败返回错误，由同步任务按退避重试。
+func (s *Service) enforceSuspend(ctx context.Context, instanceNo string) error {
+	var row mysqlinstance.Instance
+	var op mysqlinstance.Operation
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, instanceNo)
+		if err != nil {
+			return err
+		}
+		if current.Status != domaininstance.StatusSuspended {
+			return errSuspendSkipped
+		}
+		if err := s.ensureNoRunningOperation(ctx, tx, current.ID, errSuspendSkipped); err != nil {
+			return err
+		}
+		row = current
+		op = newOperation(current.ID, &current.OrderID, nil, nil, domaininstance.OperationSuspend)
+		if err := s.instances.CreateOperation(ctx, tx, &op); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{Action: "instance.suspend.enforce", ObjectType: objectType, ObjectID: current.InstanceNo, BeforeData: map[string]any{"status": current.Status, "vm_status": domaininstance.StatusRunning}, AfterData: map[string]any{"action": domaininstance.OperationSuspend}, Remark: "暂停实例的虚拟机被开机，重新关机"})
+	})
+	if errors.Is(err, errSuspendSkipped) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.stopSuspended(ctx, row, op)
+}
+
+// stopSuspended 下发关机并投递操作同步任务；下发失败时只把操作记录为失败，实例状态由调用方处理。
+func (s *Service) stopSuspended(ctx context.Context, row mysqlinstance.Instance, op mysqlinstance.Operation) error {
+	accepted, callErr := s.mcp.Cluster(row.ClusterNo).StopVM(ctx, row.ExternalNode, row.ExternalVMID)
+	if callErr != nil {
+		message := externalStoredMessage(callErr)
+		if len(message) > 500 {
+			message = message[:500]
+		}
+		_ = s.instances.UpdateOperation(context.Background(), nil, op.ID, map[string]any{"status": domaininstance.OperationStatusFailed, "error_code": nullableString("mcp_call_failed"), "error_message": nullableString(message), "completed_at": time.Now()})
+		return externalError(callErr)
+	}
+	if err := s.instances.UpdateOperation(ctx, nil, op.ID, map[string]any{"external_operation_id": nullableString(accepted.OperationID), "operation_location": nullableString(accepted.OperationLocation), "resource_location": nullableString(accepted.Location)}); err != nil {
+		return err
+	}
+	return s.enqueueOperationSync(ctx, nil, row.InstanceNo, op.OperationNo, s.mcp.Cluster(row.ClusterNo).OperationPollDelay(accepted))
+}
+
+// notifySuspended 通知用户实例已暂停及原因；到期暂停同时提示释放时间。
+func (s *Service) notifySuspended(ctx context.Context, row mysqlinstance.Instance, source string, reason string, notificationNo string) error {
+	user, err := s.users.FindUserByID(ctx, nil, row.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(user.Email) == "" {
+		return nil
+	}
+	content := fmt.Sprintf("实例 %s 已被平台暂停，原因：%s。如有疑问请提交工单。", row.InstanceNo, reason)
+	if source == domaininstance.SuspendSourceExpiry {
+		content = fmt.Sprintf("实例 %s 已到期并被暂停，虚拟机已关机。续费后实例自动恢复。", row.InstanceNo)
+		if s.lifecycle.AutoReleaseEnabled && row.ExpiresAt != nil {
+			releaseAt := row.ExpiresAt.Add(time.Duration(s.lifecycle.ExpireReleaseAfterSeconds) * time.Second)
+			content = fmt.Sprintf("实例 %s 已到期并被暂停，虚拟机已关机。请在 %s 前完成续费，续费后实例自动恢复；逾期实例将被释放，数据无法恢复。", row.InstanceNo, releaseAt.Format("2006-01-02 15:04"))
+		}
+	}
+	return s.instances.EnqueueEmailNotification(ctx, nil, mysqlinstance.Notification{NotificationNo: notificationNo, UserID: row.UserID, Scene: domaininstance.NotificationSceneSuspended, Target: user.Email, Subject: stringPtr("实例已暂停"), ContentSummary: stringPtr(content), RelatedObjectType: stringPtr(objectType), RelatedObjectNo: stringPtr(row.InstanceNo)})
+}
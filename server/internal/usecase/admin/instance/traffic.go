@@ -0,0 +1,371 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	domainwallet "github.com/AeolianCloud/pveCloud/server/internal/domain/wallet"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	mysqlwallet "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/wallet"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+)
+
+// trafficHistoryMonths 是流量查询返回的最近计费月数量（含当前月）。
+const trafficHistoryMonths = 6
+
+// TrafficMeterResult 是一次流量采样的汇总，写入周期任务结果。
+type TrafficMeterResult struct {
+	Instances  int `json:"instances"`
+	Sampled    int `json:"sampled"`
+	Restricted int `json:"restricted"`
+	Restored   int `json:"restored"`
+	Deferred   int `json:"deferred"`
+	NodeErrors int `json:"node_errors"`
+}
+
+// trafficCounter 是上游 VM 自启动以来的网卡累计字节数。
+type trafficCounter struct {
+	netIn  uint64
+	netOut uint64
+}
+
+// SetTrafficConfig 注入流量计量和超额处理配置。
+func (s *Service) SetTrafficConfig(cfg config.TrafficConfig) *Service {
+	s.traffic = cfg
+	return s
+}
+
+// InstanceTraffic 返回实例当前计费月和最近数月的流量用量；尚未采样的月份按 0 返回。
+func (s *Service) InstanceTraffic(ctx context.Context, instanceNo string) (admindto.InstanceTraffic, error) {
+	row, err := s.instances.Detail(ctx, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.InstanceTraffic{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return admindto.InstanceTraffic{}, err
+	}
+	usages, err := s.instances.TrafficUsages(ctx, row.ID, trafficHistoryMonths)
+	if err != nil {
+		return admindto.InstanceTraffic{}, err
+	}
+	period := domaininstance.TrafficPeriod(time.Now())
+	result := admindto.InstanceTraffic{InstanceNo: row.InstanceNo, Direction: s.traffic.Direction, OveragePolicy: s.traffic.OveragePolicy, OverageAction: row.TrafficOverageAction, Current: admindto.TrafficUsage{Period: period, QuotaGB: row.TrafficGB}, History: make([]admindto.TrafficUsage, 0, len(usages))}
+	for _, usage := range usages {
+		item := trafficUsageItem(usage)
+		if usage.Period == period {
+			result.Current = item
+		}
+		result.History = append(result.History, item)
+	}
+	return result, nil
+}
+
+// MeterTrafficByWorker 由周期任务触发：按节点批量读取 VM 网卡累计计数器，把两次采样间的增量累计到当月流量，
+// 并按配额发送预警邮件、执行或解除超额处理。单个节点或实例失败不影响其余实例，错误合并返回。
+func (s *Service) MeterTrafficByWorker(ctx context.Context) (TrafficMeterResult, error) {
+	var result TrafficMeterResult
+	if !s.mcp.Enabled() {
+		return result, nil
+	}
+	rows, err := s.instances.MeteredInstances(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Instances = len(rows)
+	byNode := map[clusterNode][]mysqlinstance.Instance{}
+	nodes := make([]clusterNode, 0)
+	for _, row := range rows {
+		key := clusterNode{clusterNo: row.ClusterNo, node: row.ExternalNode}
+		if _, ok := byNode[key]; !ok {
+			nodes = append(nodes, key)
+		}
+		byNode[key] = append(byNode[key], row)
+	}
+	var errs []error
+	for _, node := range nodes {
+		list, err := s.mcp.Cluster(node.clusterNo).NodeVMs(ctx, node.node)
+		if err != nil {
+			result.NodeErrors++
+			errs = append(errs, fmt.Errorf("集群 %s 节点 %s 虚拟机列表读取失败: %w", node.clusterNo, node.node, err))
+			continue
+		}
+		counters := trafficCounters(list)
+		for _, row := range byNode[node] {
+			counter, ok := counters[row.ExternalVMID]
+			if !ok {
+				// VM 缺失由资源对账处理，这里只跳过。
+				continue
+			}
+			transition, err := s.meterInstance(ctx, row, counter, time.Now())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("实例 %s 流量采样失败: %w", row.InstanceNo, err))
+				continue
+			}
+			result.Sampled++
+			if transition == nil {
+				continue
+			}
+			if err := s.applyTrafficRestriction(ctx, row, *transition); errors.Is(err, ErrOperationPending) {
+				result.Deferred++
+				continue
+			} else if err != nil {
+				errs = append(errs, fmt.Errorf("实例 %s 流量超额处理失败: %w", row.InstanceNo, err))
+				continue
+			}
+			if transition.to == "" {
+				result.Restored++
+			} else {
+				result.Restricted++
+			}
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// trafficTransition 是实例当前生效的超额限制需要发生的变化；to 为空表示解除限制。
+type trafficTransition struct {
+	from string
+	to   string
+}
+
+// meterInstance 在一个事务内累计本次采样增量、创建预警邮件、结算 bill 策略扣费，并决定实例应处于的限制状态。
+// 上游调用在事务外由 applyTrafficRestriction 执行。
+func (s *Service) meterInstance(ctx context.Context, row mysqlinstance.Instance, counter trafficCounter, now time.Time) (*trafficTransition, error) {
+	now = normalizeDBTime(now)
+	period := domaininstance.TrafficPeriod(now)
+	var transition *trafficTransition
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceByID(ctx, tx, row.ID)
+		if err != nil {
+			return err
+		}
+		usage, err := s.instances.TrafficUsageForUpdate(ctx, tx, current.ID, period)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			usage = mysqlinstance.TrafficUsage{InstanceID: current.ID, UserID: current.UserID, Period: period, QuotaGB: current.TrafficGB}
+			previous, prevErr := s.instances.LatestTrafficUsage(ctx, tx, current.ID)
+			if prevErr != nil && !errors.Is(prevErr, gorm.ErrRecordNotFound) {
+				return prevErr
+			}
+			if prevErr == nil {
+				// 新计费月继承上月最后一次采样的计数器，跨月间隔内的流量计入新月。
+				usage.LastNetIn, usage.LastNetOut, usage.LastSampledAt = previous.LastNetIn, previous.LastNetOut, previous.LastSampledAt
+			}
+			if err := s.instances.CreateTrafficUsage(ctx, tx, &usage); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+		updates := map[string]any{"quota_gb": current.TrafficGB, "last_netin": counter.netIn, "last_netout": counter.netOut, "last_sampled_at": now}
+		if usage.LastSampledAt != nil {
+			// 首次采样只记录基线；此后累加计数器增量，计数器回退说明 VM 重启过。
+			usage.InBytes += domaininstance.TrafficCounterDelta(usage.LastNetIn, counter.netIn)
+			usage.OutBytes += domaininstance.TrafficCounterDelta(usage.LastNetOut, counter.netOut)
+		}
+		billed := domaininstance.TrafficBilledBytes(usage.InBytes, usage.OutBytes, s.traffic.Direction)
+		updates["in_bytes"], updates["out_bytes"], updates["billed_bytes"] = usage.InBytes, usage.OutBytes, billed
+
+		quota := domaininstance.TrafficQuotaBytes(current.TrafficGB)
+		percent := domaininstance.TrafficUsedPercent(billed, quota)
+		exceeded := quota > 0 && billed > quota
+		if quota > 0 && percent >= domaininstance.TrafficWarningPercent && usage.WarningNotifiedAt == nil {
+			if err := s.enqueueTrafficNotification(ctx, tx, current, period, domaininstance.NotificationSceneTrafficWarning, percent); err != nil {
+				return err
+			}
+			updates["warning_notified_at"] = now
+		}
+		if exceeded && usage.ExceededNotifiedAt == nil {
+			if err := s.enqueueTrafficNotification(ctx, tx, current, period, domaininstance.NotificationSceneTrafficExceeded, percent); err != nil {
+				return err
+			}
+			updates["exceeded_notified_at"] = now
+		}
+
+		desired := ""
+		if exceeded {
+			desired = s.traffic.OveragePolicy
+			if desired == domaininstance.TrafficOverageBill {
+				charged, err := s.chargeTrafficOverage(ctx, tx, current, usage, domaininstance.TrafficOverageGB(billed, quota), updates)
+				if err != nil {
+					return err
+				}
+				desired = ""
+				if !charged {
+					// 余额不足时改为限速，充值后下次采样补扣并解除限速。
+					desired = domaininstance.TrafficOverageThrottle
+				}
+			}
+			if usage.OverageAction == nil {
+				updates["overage_action"] = s.traffic.OveragePolicy
+				updates["overage_applied_at"] = now
+			}
+		}
+		if err := s.instances.UpdateTrafficUsage(ctx, tx, usage.ID, updates); err != nil {
+			return err
+		}
+		if from := value(current.TrafficOverageAction); from != desired {
+			if err := s.instances.UpdateInstance(ctx, tx, current.ID, map[string]any{"traffic_overage_action": nullableString(desired)}); err != nil {
+				return err
+			}
+			transition = &trafficTransition{from: from, to: desired}
+		}
+		return nil
+	})
+	return transition, err
+}
+
+// chargeTrafficOverage 把尚未扣费的超额 GB 从用户 CNY 钱包扣除，按实例、计费月和累计 GB 幂等；
+// 钱包不存在、已停用或余额不足时不扣费并返回 false。
+func (s *Service) chargeTrafficOverage(ctx context.Context, tx *gorm.DB, row mysqlinstance.Instance, usage mysqlinstance.TrafficUsage, overageGB uint64, updates map[string]any) (bool, error) {
+	if overageGB <= usage.OverageBilledGB {
+		return true, nil
+	}
+	amount := (overageGB - usage.OverageBilledGB) * s.traffic.OveragePriceCentsPerGB
+	account, err := s.wallets.AccountByUserCurrencyForUpdate(ctx, tx, row.UserID, domainwallet.CurrencyCNY)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if account.Status != domainwallet.AccountStatusActive || account.AvailableBalanceCents < amount {
+		return false, nil
+	}
+	key := fmt.Sprintf("traffic_overage:%s:%s:%d", row.InstanceNo, usage.Period, overageGB)
+	if _, err := s.wallets.LedgerByIdempotency(ctx, tx, account.ID, key); err == nil {
+		updates["overage_billed_gb"] = overageGB
+		return true, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	before := account.AvailableBalanceCents
+	after := before - amount
+	if err := s.wallets.UpdateAccount(ctx, tx, account.ID, map[string]any{"available_balance_cents": after, "total_spent_cents": account.TotalSpentCents + amount}); err != nil {
+		return false, err
+	}
+	data, _ := json.Marshal(map[string]any{"instance_no": row.InstanceNo, "period": usage.Period, "overage_gb": overageGB, "charged_gb": overageGB - usage.OverageBilledGB, "price_cents_per_gb": s.traffic.OveragePriceCentsPerGB})
+	summary := string(data)
+	entry := mysqlwallet.LedgerEntry{EntryNo: fmt.Sprintf("WLE-%d", time.Now().UnixNano()), WalletID: account.ID, WalletNo: account.WalletNo, UserID: row.UserID, Direction: domainwallet.DirectionDebit, EntryType: domainwallet.EntryTypeTrafficOverage, AmountCents: amount, BalanceBeforeCents: before, BalanceAfterCents: after, Currency: account.Currency, RelatedType: domainwallet.RelatedTypeInstance, RelatedNo: row.InstanceNo, IdempotencyKey: key, Summary: &summary}
+	if err := s.wallets.CreateLedgerEntry(ctx, tx, &entry); err != nil {
+		return false, err
+	}
+	updates["overage_billed_gb"] = overageGB
+	updates["overage_billed_cents"] = usage.OverageBilledCents + amount
+	return true, nil
+}
+
+// applyTrafficRestriction 在上游执行限制变化：限速和解除限速作为网卡速率调整操作执行，暂停和恢复复用关机、开机操作，
+// 都经过实例锁和未完成操作检查。实例已有未完成操作、当前状态不能调整规格或上游调用失败时回滚实例上的限制标记，
+// 下次采样重试；前两者返回 ErrOperationPending。
+func (s *Service) applyTrafficRestriction(ctx context.Context, row mysqlinstance.Instance, transition trafficTransition) error {
+	var err error
+	if transition.from == domaininstance.TrafficOverageSuspend && canOperate(row.Status, domaininstance.OperationStart) {
+		_, err = s.operateWithGuardWithPendingError(ctx, row.InstanceNo, nil, nil, domaininstance.OperationStart, ErrOperationPending, nil)
+	}
+	if err == nil {
+		switch transition.to {
+		case domaininstance.TrafficOverageThrottle:
+			err = s.setTrafficNetworkRate(ctx, row, transition.to, s.traffic.ThrottleMbps)
+		case domaininstance.TrafficOverageSuspend:
+			if canOperate(row.Status, domaininstance.OperationStop) {
+				_, err = s.operateWithGuardWithPendingError(ctx, row.InstanceNo, nil, nil, domaininstance.OperationStop, ErrOperationPending, nil)
+			}
+		default:
+			if transition.from == domaininstance.TrafficOverageThrottle {
+				err = s.setTrafficNetworkRate(ctx, row, transition.to, row.BandwidthMbps)
+			}
+		}
+	}
+	if err != nil {
+		_ = s.instances.UpdateInstance(context.Background(), nil, row.ID, map[string]any{"traffic_overage_action": nullableString(transition.from)})
+		return err
+	}
+	return s.audit.Record(ctx, nil, AdminAuditWriteInput{Action: "instance.traffic_overage", ObjectType: objectType, ObjectID: row.InstanceNo, BeforeData: map[string]any{"traffic_overage_action": nullableString(transition.from)}, AfterData: map[string]any{"traffic_overage_action": nullableString(transition.to)}, Remark: "实例流量超额处理"})
+}
+
+// setTrafficNetworkRate 以不改套餐的 resize 操作调整网卡限速，CPU 和内存沿用加锁读取的实例规格；
+// 创建中、救援、迁移等不能调整规格的状态返回 ErrOperationPending，等实例恢复运行或关机后再调整。
+func (s *Service) setTrafficNetworkRate(ctx context.Context, row mysqlinstance.Instance, action string, mbps int) error {
+	if !canOperate(row.Status, domaininstance.OperationResize) {
+		return ErrOperationPending
+	}
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		payload := map[string]any{"traffic_overage_action": nullableString(action), "network_rate_mbps": mbps}
+		return operationPlan{payload: payload, call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).ResizeVM(ctx, row.ExternalNode, row.ExternalVMID, mcppve.ResizeVMRequest{Cores: row.CPUCores, Memory: row.MemoryMB, NetworkRate: domaininstance.NetworkRateMBps(mbps)})
+		}}, nil
+	}
+	_, err := s.startOperation(ctx, row.InstanceNo, nil, nil, domaininstance.OperationResize, ErrOperationPending, nil, planner)
+	return err
+}
+
+// enqueueTrafficNotification 创建流量预警或超额邮件通知及发送任务，同一实例、计费月和场景只创建一次。
+func (s *Service) enqueueTrafficNotification(ctx context.Context, tx *gorm.DB, row mysqlinstance.Instance, period string, scene string, percent float64) error {
+	user, err := s.users.FindUserByID(ctx, tx, row.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(user.Email) == "" {
+		return nil
+	}
+	level := "80"
+	subject := "实例流量即将用尽"
+	content := fmt.Sprintf("实例 %s 本月（%s）已使用套餐流量 %.1f%%（套餐 %d GB）。%s", row.InstanceNo, period, percent, *row.TrafficGB, trafficPolicyNotice(s.traffic.OveragePolicy))
+	if scene == domaininstance.NotificationSceneTrafficExceeded {
+		level = "100"
+		subject = "实例流量已超额"
+		content = fmt.Sprintf("实例 %s 本月（%s）流量已超出套餐 %d GB。%s", row.InstanceNo, period, *row.TrafficGB, trafficPolicyNotice(s.traffic.OveragePolicy))
+	}
+	notificationNo := fmt.Sprintf("NTF-TRAFFIC-%s-%s-%s-EMAIL", row.InstanceNo, period, level)
+	taskNo := "TASK-" + notificationNo
+	notification := mysqlinstance.Notification{NotificationNo: notificationNo, UserID: row.UserID, Channel: domaininstance.NotificationChannelEmail, Scene: scene, Target: user.Email, Status: domaininstance.NotificationStatusPending, Subject: stringPtr(subject), ContentSummary: stringPtr(content), RelatedObjectType: stringPtr(objectType), RelatedObjectNo: stringPtr(row.InstanceNo), TaskNo: stringPtr(taskNo)}
+	if err := s.instances.CreateNotificationIgnoreDuplicate(ctx, tx, &notification); err != nil {
+		return err
+	}
+	data, _ := json.Marshal(map[string]string{"notification_no": notificationNo})
+	idempotencyKey := "notification_send:" + notificationNo
+	task := mysqlinstance.Task{TaskNo: taskNo, TaskType: domaininstance.TaskTypeEmailSend, IdempotencyKey: &idempotencyKey, Status: domaininstance.TaskStatusPending, ObjectType: stringPtr("notification"), ObjectNo: stringPtr(notificationNo), Payload: stringPtr(string(data)), MaxAttempts: 10, ScheduledAt: time.Now()}
+	return s.instances.CreateTaskIgnoreDuplicate(ctx, tx, &task)
+}
+
+func trafficPolicyNotice(policy string) string {
+	switch policy {
+	case domaininstance.TrafficOverageSuspend:
+		return "超出后实例将被关机暂停，下个计费月自动恢复。"
+	case domaininstance.TrafficOverageBill:
+		return "超出部分按 GB 从钱包余额扣费，余额不足时网络将被限速。"
+	default:
+		return "超出后网络将被限速，下个计费月自动恢复。"
+	}
+}
+
+// trafficCounters 从节点 VM 列表读取各 VM 的网卡累计计数器。
+func trafficCounters(list []mcppve.VM) map[uint]trafficCounter {
+	counters := map[uint]trafficCounter{}
+	for _, item := range list {
+		if item.VMID == 0 {
+			continue
+		}
+		counters[item.VMID] = trafficCounter{netIn: uint64(max(item.NetIn, 0)), netOut: uint64(max(item.NetOut, 0))}
+	}
+	return counters
+}
+
+func trafficUsageItem(usage mysqlinstance.TrafficUsage) admindto.TrafficUsage {
+	quota := domaininstance.TrafficQuotaBytes(usage.QuotaGB)
+	return admindto.TrafficUsage{Period: usage.Period, QuotaGB: usage.QuotaGB, InBytes: usage.InBytes, OutBytes: usage.OutBytes, BilledBytes: usage.BilledBytes, UsedPercent: domaininstance.TrafficUsedPercent(usage.BilledBytes, quota), Exceeded: quota > 0 && usage.BilledBytes > quota, OverageAction: usage.OverageAction, OverageBilledGB: usage.OverageBilledGB, OverageBilledCents: usage.OverageBilledCents, LastSampledAt: usage.LastSampledAt}
+}
@@ -0,0 +1,148 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	domainuser "github.com/AeolianCloud/pveCloud/server/internal/domain/user"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	mysqluser "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/user"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	admindto "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/dto"
+	adminsupport "github.com/AeolianCloud/pveCloud/server/internal/usecase/admin/support"
+	webrealname "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/realname"
+)
+
+// SetRealName 注入用户端实名服务；强制转移同样要求接收方满足下单实名规则。
+func (s *Service) SetRealName(realName *webrealname.RealNameService) *Service {
+	s.realName = realName
+	return s
+}
+
+// InstanceTransfers 返回全部实例转移记录，按创建时间倒序。
+func (s *Service) InstanceTransfers(ctx context.Context, query admindto.InstanceTransferListQuery) (admindto.PageResponse[admindto.InstanceTransferItem], error) {
+	page, perPage := adminsupport.NormalizePage(query.Page, query.PerPage)
+	rows, total, err := s.instances.ListInstanceTransfers(ctx, mysqlinstance.InstanceTransferFilters{Status: query.Status, Keyword: query.Keyword}, perPage, (page-1)*perPage)
+	if err != nil {
+		return admindto.PageResponse[admindto.InstanceTransferItem]{}, err
+	}
+	items := make([]admindto.InstanceTransferItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, instanceTransferItem(row))
+	}
+	return adminsupport.PageResponse(items, total, page, perPage), nil
+}
+
+// ForceTransfer 由管理员直接把实例转移到目标账号，不需要双方确认；实例上待确认的用户转移被撤销，
+// 归属变更和关联数据迁移与用户接收转移一致，并写入审计记录。
+func (s *Service) ForceTransfer(ctx context.Context, operatorID uint64, instanceNo string, req admindto.InstanceForceTransferRequest) (admindto.InstanceTransferItem, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return admindto.InstanceTransferItem{}, apperrors.ErrValidation.WithMessage("请填写转移原因")
+	}
+	target, err := s.users.FindUserByAccount(ctx, req.Account)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return admindto.InstanceTransferItem{}, apperrors.ErrNotFound.WithMessage("接收账号不存在")
+	}
+	if err != nil {
+		return admindto.InstanceTransferItem{}, err
+	}
+	if target.Status != domainuser.StatusActive {
+		return admindto.InstanceTransferItem{}, apperrors.ErrValidation.WithMessage("接收账号不可用")
+	}
+	if s.realName != nil {
+		err := s.realName.RequireApprovedForOrder(ctx, target.ID)
+		var appErr *apperrors.AppError
+		if errors.As(err, &appErr) && appErr.Code == apperrors.ErrForbidden.Code {
+			return admindto.InstanceTransferItem{}, apperrors.ErrConflict.WithMessage("接收账号尚未完成实名认证")
+		}
+		if err != nil {
+			return admindto.InstanceTransferItem{}, err
+		}
+	}
+	transferNo := fmt.Sprintf("TRF-%d", time.Now().UnixNano())
+	var moved mysqlinstance.TransferResult
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if current.UserID == target.ID {
+			return apperrors.ErrValidation.WithMessage("实例已属于该账号")
+		}
+		if !domaininstance.CanTransfer(current.Status) {
+			return apperrors.ErrConflict.WithMessage("实例当前状态不能转移")
+		}
+		pending, err := s.instances.PendingInstanceTransfers(ctx, tx, current.ID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, item := range pending {
+			if err := s.instances.UpdateInstanceTransfer(ctx, tx, item.ID, map[string]any{"status": domaininstance.TransferStatusCancelled, "responded_at": now}); err != nil {
+				return err
+			}
+		}
+		moved, err = s.instances.MoveInstanceOwner(ctx, tx, current, target.ID, transferNo, now)
+		if err != nil {
+			return err
+		}
+		transfer := mysqlinstance.InstanceTransfer{TransferNo: transferNo, InstanceID: current.ID, InstanceNo: current.InstanceNo, FromUserID: current.UserID, ToUserID: target.ID, Status: domaininstance.TransferStatusCompleted, ForcedBy: &operatorID, ForceReason: &reason, CompletedAt: &now}
+		if err := s.instances.CreateInstanceTransfer(ctx, tx, &transfer); err != nil {
+			return err
+		}
+		if err := s.notifyTransferCompleted(ctx, tx, transfer); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, AdminAuditWriteInput{AdminID: &operatorID, Action: "instance.transfer.force", ObjectType: objectType, ObjectID: current.InstanceNo, BeforeData: map[string]any{"user_id": current.UserID, "pending_transfers": len(pending)}, AfterData: map[string]any{"user_id": target.ID, "transfer_no": transferNo, "cancelled_orders": moved.CancelledOrderNos, "moved_tickets": moved.MovedTickets, "detached_security_groups": moved.DetachedGroups}, Remark: reason})
+	})
+	if err != nil {
+		return admindto.InstanceTransferItem{}, err
+	}
+	if moved.DetachedGroups {
+		_ = s.reconcileFirewall(ctx, strings.TrimSpace(instanceNo))
+	}
+	row, err := s.instances.InstanceTransferRow(ctx, transferNo)
+	if err != nil {
+		return admindto.InstanceTransferItem{}, err
+	}
+	return instanceTransferItem(row), nil
+}
+
+// notifyTransferCompleted 通知原所有者和接收方实例已被管理员转移。
+func (s *Service) notifyTransferCompleted(ctx context.Context, tx *gorm.DB, transfer mysqlinstance.InstanceTransfer) error {
+	sender, err := s.users.FindUserByID(ctx, tx, transfer.FromUserID)
+	if err != nil {
+		return err
+	}
+	recipient, err := s.users.FindUserByID(ctx, tx, transfer.ToUserID)
+	if err != nil {
+		return err
+	}
+	if err := s.notifyTransfer(ctx, tx, sender, transfer, "FROM", "实例已转出", fmt.Sprintf("平台已将实例 %s 转移到其他账号，相关待支付的续费订单已取消。如有疑问请提交工单。", transfer.InstanceNo)); err != nil {
+		return err
+	}
+	return s.notifyTransfer(ctx, tx, recipient, transfer, "TO", "实例已转入", fmt.Sprintf("平台已将实例 %s 转入你的账号，请按需重新绑定安全组并检查续费设置。原 root 密码已作废，系统将在实例运行时自动重置，完成后可在实例详情查看新密码；原所有者注入的 SSH 公钥仍保留在系统内，请登录后清理或重装系统。", transfer.InstanceNo))
+}
+
+func (s *Service) notifyTransfer(ctx context.Context, tx *gorm.DB, user mysqluser.User, transfer mysqlinstance.InstanceTransfer, side string, subject string, content string) error {
+	if strings.TrimSpace(user.Email) == "" {
+		return nil
+	}
+	notificationNo := fmt.Sprintf("NTF-%s-COMPLETED-%s-EMAIL", transfer.TransferNo, side)
+	return s.instances.EnqueueEmailNotification(ctx, tx, mysqlinstance.Notification{NotificationNo: notificationNo, UserID: user.ID, Scene: domaininstance.NotificationSceneTransferCompleted, Target: user.Email, Subject: stringPtr(subject), ContentSummary: stringPtr(content), RelatedObjectType: stringPtr(objectType), RelatedObjectNo: stringPtr(transfer.InstanceNo)})
+}
+
+func instanceTransferItem(row mysqlinstance.InstanceTransferRow) admindto.InstanceTransferItem {
+	return admindto.InstanceTransferItem{TransferNo: row.TransferNo, InstanceNo: row.InstanceNo, FromUserID: row.FromUserID, FromUsername: row.FromUsername, ToUserID: row.ToUserID, ToUsername: row.ToUsername, Status: row.Status, Remark: row.Remark, ForcedBy: row.ForcedBy, ForceReason: row.ForceReason, RespondedAt: row.RespondedAt, CompletedAt: row.CompletedAt, CreatedAt: row.CreatedAt}
+}
@@ -0,0 +1,94 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"maps"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqlorder "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/order"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+)
+
+// hostVMIDs 是交付前从映射全部候选节点列出的上游 VMID。PVE 集群内 VMID 全局唯一，因此不只检查放置节点；
+// clusterNo 是映射所属地域绑定的集群，实例交付后记录在实例上。
+type hostVMIDs struct {
+	mappingNo string
+	clusterNo string
+	vmids     map[uint]bool
+}
+
+// listHostVMIDs 列出订单匹配映射全部候选节点上已存在的 VM，包括人工创建的 VM 和模板。
+// 订单不可交付或缺少映射时返回 nil，由交付事务给出原有错误；需要访问上游，因此必须在交付事务之外调用。
+func (s *Service) listHostVMIDs(ctx context.Context, order mysqlorder.Order) (*hostVMIDs, error) {
+	if !domainorder.CanProvision(order.Status) {
+		return nil, nil
+	}
+	mapping, err := s.instances.MappingForProvision(ctx, nil, order.PlanNo, order.RegionNo, order.TemplateNo, order.NetworkTypeNo)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	clusterNo, err := s.regionCluster(ctx, mapping.RegionNo)
+	if err != nil {
+		return nil, err
+	}
+	host := &hostVMIDs{mappingNo: mapping.MappingNo, clusterNo: clusterNo, vmids: map[uint]bool{}}
+	for _, node := range domaininstance.PlacementNodes(mapping.Node, value(mapping.PlacementNodes)) {
+		list, err := s.mcp.Cluster(clusterNo).NodeVMs(ctx, node)
+		if err != nil {
+			return nil, externalError(err)
+		}
+		for _, item := range list {
+			if item.VMID > 0 {
+				host.vmids[item.VMID] = true
+			}
+		}
+	}
+	return host, nil
+}
+
+// allocateVMID 在已锁定的交付映射上分配 VMID：跳过上游已存在和未释放实例占用的编号，映射开启复用时优先使用
+// 已释放实例的编号，只有顺延分配才推进 next_vmid。映射在列出上游 VM 后发生变化时要求重试，避免用旧节点列表判断冲突。
+func (s *Service) allocateVMID(ctx context.Context, tx *gorm.DB, mapping mysqlinstance.ProvisionMapping, host *hostVMIDs) (uint, error) {
+	if host == nil || host.mappingNo != mapping.MappingNo {
+		return 0, apperrors.ErrConflict.WithMessage("交付映射已变更，请重试")
+	}
+	nodes := domaininstance.PlacementNodes(mapping.Node, value(mapping.PlacementNodes))
+	active, err := s.instances.ActiveVMIDs(ctx, tx, host.clusterNo, nodes, mapping.VMIDStart, mapping.VMIDEnd)
+	if err != nil {
+		return 0, err
+	}
+	used := maps.Clone(host.vmids)
+	for _, vmid := range active {
+		used[vmid] = true
+	}
+	var released []uint
+	if mapping.ReuseVMIDs {
+		if released, err = s.instances.ReleasedVMIDs(ctx, tx, host.clusterNo, nodes, mapping.VMIDStart, mapping.VMIDEnd); err != nil {
+			return 0, err
+		}
+	}
+	allocation, ok := domaininstance.AllocateVMID(mapping.VMIDStart, mapping.VMIDEnd, mapping.NextVMID, released, used, mapping.ReuseVMIDs)
+	if !ok {
+		return 0, apperrors.ErrConflict.WithMessage("交付映射虚拟机编号已耗尽")
+	}
+	if allocation.NextVMID != mapping.NextVMID {
+		if err := s.instances.AdvanceMappingVMID(ctx, tx, mapping.ID, allocation.NextVMID); err != nil {
+			return 0, err
+		}
+	}
+	return allocation.VMID, nil
+}
+
+// vmidLow 判断映射剩余未分配 VMID 是否低于告警水位；水位为 0 时只在耗尽时告警。
+func (s *Service) vmidLow(mapping mysqlinstance.ProvisionMapping) bool {
+	remaining := domaininstance.VMIDRemaining(mapping.VMIDEnd, mapping.NextVMID)
+	return remaining == 0 || remaining <= s.placement.VMIDLowWatermark
+}
@@ -0,0 +1,68 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+)
+
+// OperationWebhook 处理 MCP PVE 推送的操作完成事件：校验签名后按集群和上游 operation ID 找到实例操作，
+// 以事件携带的结果执行与 sync 相同的落库逻辑，并结束该操作的轮询兜底任务。
+// 操作已由轮询处理或事件仍是中间状态时直接返回成功；找不到操作时返回 404，上游可稍后重投，轮询任务仍会兜底。
+func (s *Service) OperationWebhook(ctx context.Context, clusterNo string, timestamp string, signature string, body []byte) error {
+	event, err := s.mcp.Cluster(clusterNo).OperationEvent(timestamp, signature, body, time.Now())
+	switch {
+	case errors.Is(err, mcppve.ErrWebhookDisabled):
+		return apperrors.ErrNotFound.WithMessage("集群未启用操作回调")
+	case errors.Is(err, mcppve.ErrWebhookSignature):
+		return apperrors.ErrUnauthorized.WithMessage("回调签名校验失败")
+	case errors.Is(err, mcppve.ErrWebhookExpired):
+		return apperrors.ErrUnauthorized.WithMessage("回调时间戳已过期")
+	case errors.Is(err, mcppve.ErrWebhookPayload):
+		return apperrors.ErrValidation.WithMessage("回调内容格式错误")
+	case err != nil:
+		return err
+	}
+	if !isOperationSucceeded(event.Status) && !isOperationFailed(event.Status) {
+		return nil
+	}
+	op, err := s.instances.OperationByExternalID(ctx, strings.TrimSpace(clusterNo), event.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperrors.ErrNotFound.WithMessage("操作不存在")
+	}
+	if err != nil {
+		return err
+	}
+	if op.Status != domaininstance.OperationStatusRunning {
+		return nil
+	}
+	if _, err := s.sync(ctx, op.InstanceNo, nil, false, &event); err != nil && !errors.Is(err, ErrOperationPending) {
+		return err
+	}
+	return s.finishOperationPolling(ctx, op.OperationNo)
+}
+
+// finishOperationPolling 结束回调已处理操作的轮询兜底任务；任务正被 Worker 执行时保持不动，由 Worker 自行完成。
+func (s *Service) finishOperationPolling(ctx context.Context, operationNo string) error {
+	return mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		task, err := s.instances.TaskByIdempotencyKeyForUpdate(ctx, tx, operationSyncKey(operationNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if task.Status != domaininstance.TaskStatusPending {
+			return nil
+		}
+		return s.instances.UpdateTask(ctx, tx, task.ID, map[string]any{"status": domaininstance.TaskStatusSucceeded, "locked_by": nil, "locked_until": nil, "last_error_code": nil, "last_error_message": nil, "completed_at": time.Now()})
+	})
+}
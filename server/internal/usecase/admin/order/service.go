@@ -115,6 +115,11 @@ func (s *Service) ConfirmRenewal(ctx context.Context, operatorID uint64, orderNo
 		if order.RelatedInstanceNo == nil || strings.TrimSpace(*order.RelatedInstanceNo) == "" {
 			return apperrors.ErrConflict.WithMessage("续费订单未关联实例")
 		}
+		if _, err := s.orders.ActiveChangePlanByInstanceNo(ctx, tx, *order.RelatedInstanceNo); err == nil {
+			return apperrors.ErrConflict.WithMessage("实例有未完成的变更套餐订单，续费订单暂不可确认")
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
 		instance, err := s.instances.InstanceForUpdate(ctx, tx, *order.RelatedInstanceNo)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return apperrors.ErrNotFound.WithMessage("关联实例不存在")
@@ -138,6 +143,11 @@ func (s *Service) ConfirmRenewal(ctx context.Context, operatorID uint64, orderNo
 		if err := s.instances.UpdateInstance(ctx, tx, instance.ID, instanceUpdates); err != nil {
 			return err
 		}
+		if domaininstance.ResumesOnRenewal(instance.Status, firstNonEmptyValue(instance.SuspendSource, "")) {
+			if err := s.instances.UnsuspendInstance(ctx, tx, instance.ID); err != nil {
+				return err
+			}
+		}
 		if err := s.enqueueLifecycleTasks(ctx, tx, instance.InstanceNo, nextExpiresAt); err != nil {
 			return err
 		}
@@ -207,7 +217,7 @@ func adminOrderItem(row mysqlorder.OrderRow) admindto.AdminOrderItem {
 }
 
 func adminOrderDetail(row mysqlorder.OrderRow) admindto.AdminOrderDetail {
-	return admindto.AdminOrderDetail{AdminOrderItem: adminOrderItem(row), UserNote: row.UserNote, CancelReason: row.CancelReason, ClosedReason: row.ClosedReason, ProductNo: row.ProductNo, ProductType: row.ProductType, ProductSummary: row.ProductSummary, PlanNo: row.PlanNo, PlanCode: row.PlanCode, PlanSummary: row.PlanSummary, CPUCores: row.CPUCores, MemoryMB: row.MemoryMB, SystemDiskGB: row.SystemDiskGB, DataDiskGB: row.DataDiskGB, BandwidthMbps: row.BandwidthMbps, TrafficGB: row.TrafficGB, PublicIPCount: row.PublicIPCount, Virtualization: row.Virtualization, Architecture: row.Architecture, PriceCents: row.PriceCents, OriginalPriceCents: row.OriginalPriceCents, Quantity: row.Quantity, RegionNo: row.RegionNo, RegionCode: row.RegionCode, RegionName: row.RegionName, NetworkTypeNo: row.NetworkTypeNo, NetworkTypeCode: row.NetworkTypeCode, NetworkTypeName: row.NetworkTypeName, TemplateNo: row.TemplateNo, TemplateCode: row.TemplateCode, TemplateName: row.TemplateName, OSFamily: row.OSFamily, OSDistribution: row.OSDistribution, OSVersion: row.OSVersion, OSArchitecture: row.OSArchitecture}
+	return admindto.AdminOrderDetail{AdminOrderItem: adminOrderItem(row), UserNote: row.UserNote, CancelReason: row.CancelReason, ClosedReason: row.ClosedReason, SourceBackupNo: row.SourceBackupNo, ChangeFromPlanNo: row.ChangeFromPlanNo, CreditAmountCents: row.CreditAmountCents, ProductNo: row.ProductNo, ProductType: row.ProductType, ProductSummary: row.ProductSummary, PlanNo: row.PlanNo, PlanCode: row.PlanCode, PlanSummary: row.PlanSummary, CPUCores: row.CPUCores, MemoryMB: row.MemoryMB, SystemDiskGB: row.SystemDiskGB, DataDiskGB: row.DataDiskGB, BandwidthMbps: row.BandwidthMbps, TrafficGB: row.TrafficGB, PublicIPCount: row.PublicIPCount, Virtualization: row.Virtualization, Architecture: row.Architecture, PriceCents: row.PriceCents, OriginalPriceCents: row.OriginalPriceCents, Quantity: row.Quantity, RegionNo: row.RegionNo, RegionCode: row.RegionCode, RegionName: row.RegionName, NetworkTypeNo: row.NetworkTypeNo, NetworkTypeCode: row.NetworkTypeCode, NetworkTypeName: row.NetworkTypeName, TemplateNo: row.TemplateNo, TemplateCode: row.TemplateCode, TemplateName: row.TemplateName, OSFamily: row.OSFamily, OSDistribution: row.OSDistribution, OSVersion: row.OSVersion, OSArchitecture: row.OSArchitecture}
 }
 
 func auditSnapshot(order mysqlorder.Order) map[string]any {
@@ -229,6 +239,11 @@ func (s *Service) enqueueLifecycleTasks(ctx context.Context, tx *gorm.DB, instan
 	if err := s.instances.CreateTaskIgnoreDuplicate(ctx, tx, &noticeTask); err != nil {
 		return err
 	}
+	if s.lifecycle.AutoSuspendEnabled {
+		if err := s.instances.EnqueueExpirySuspend(ctx, tx, objectNo, expiresAt, string(data)); err != nil {
+			return err
+		}
+	}
 	if !s.lifecycle.AutoReleaseEnabled {
 		return nil
 	}
@@ -175,6 +175,9 @@ CREATE TABLE orders (
   status VARCHAR(32) NOT NULL DEFAULT 'pending',
   order_type VARCHAR(32) NOT NULL DEFAULT 'purchase',
   related_instance_no VARCHAR(64) NULL,
+  change_from_plan_no VARCHAR(64) NULL,
+  source_backup_no VARCHAR(64) NULL,
+  ssh_keys TEXT NULL,
   product_no VARCHAR(64) NOT NULL,
   product_type VARCHAR(32) NOT NULL,
   product_name VARCHAR(128) NOT NULL,
@@ -198,6 +201,7 @@ CREATE TABLE orders (
   currency VARCHAR(16) NOT NULL,
   quantity INT NOT NULL DEFAULT 1,
   total_amount_cents BIGINT UNSIGNED NOT NULL,
+  credit_amount_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
   payment_status VARCHAR(32) NOT NULL DEFAULT 'unpaid',
   paid_at DATETIME(3) NULL,
   payment_provider VARCHAR(32) NULL,
@@ -245,6 +249,8 @@ CREATE TABLE instances (
   system_disk_gb INT NOT NULL,
   data_disk_gb INT NOT NULL DEFAULT 0,
   bandwidth_mbps INT NOT NULL,
+  traffic_gb INT NULL,
+  traffic_overage_action VARCHAR(16) NULL,
   region_no VARCHAR(64) NOT NULL,
   region_name VARCHAR(128) NOT NULL,
   network_type_no VARCHAR(64) NULL,
@@ -259,6 +265,12 @@ CREATE TABLE instances (
   external_resource_location VARCHAR(255) NULL,
   last_error_code VARCHAR(64) NULL,
   last_error_message VARCHAR(500) NULL,
+  config_drift VARCHAR(255) NULL,
+  config_checked_at DATETIME(3) NULL,
+  firewall_status VARCHAR(16) NULL,
+  firewall_synced_at DATETIME(3) NULL,
+  root_password_ciphertext TEXT NULL,
+  root_password_revealed_at DATETIME(3) NULL,
   service_started_at DATETIME(3) NULL,
   expires_at DATETIME(3) NULL,
   expire_notice_sent_at DATETIME(3) NULL,
@@ -150,6 +150,9 @@ func (s *Service) CreateRefund(ctx context.Context, operatorID uint64, paymentNo
 				return apperrors.ErrConflict.WithMessage("新购已交付订单需先释放实例")
 			}
 		}
+		if order.OrderType == domainorder.TypeChangePlan && order.Status != domainorder.StatusError {
+			return apperrors.ErrConflict.WithMessage("变更套餐订单仅在规格调整失败后可退款")
+		}
 		// 发票 v1 不支持红冲或作废，退款本地事实创建前必须阻断已被有效发票占用的订单。
 		if blocked, err := s.invoices.HasActiveOrderInvoice(ctx, tx, order.ID); err != nil {
 			return err
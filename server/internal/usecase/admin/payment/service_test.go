@@ -327,6 +327,9 @@ CREATE TABLE orders (
   status VARCHAR(32) NOT NULL DEFAULT 'pending',
   order_type VARCHAR(32) NOT NULL DEFAULT 'purchase',
   related_instance_no VARCHAR(64) NULL,
+  change_from_plan_no VARCHAR(64) NULL,
+  source_backup_no VARCHAR(64) NULL,
+  ssh_keys TEXT NULL,
   product_no VARCHAR(64) NOT NULL,
   product_type VARCHAR(32) NOT NULL,
   product_name VARCHAR(128) NOT NULL,
@@ -346,6 +349,7 @@ CREATE TABLE orders (
   currency VARCHAR(16) NOT NULL DEFAULT 'CNY',
   quantity INT NOT NULL DEFAULT 1,
   total_amount_cents BIGINT UNSIGNED NOT NULL,
+  credit_amount_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
   payment_status VARCHAR(32) NOT NULL DEFAULT 'unpaid',
   paid_at DATETIME(3) NULL,
   payment_provider VARCHAR(32) NULL,
@@ -9,6 +9,7 @@ import (
 
 	"gorm.io/gorm"
 
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
 	mysqlcatalog "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/catalog"
 	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
 	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
@@ -23,6 +24,7 @@ type ProductCatalogService struct {
 	db           *gorm.DB
 	catalog      *mysqlcatalog.Repository
 	auditService *AdminAuditService
+	mcp          config.MCPPVEConfig
 }
 
 func NewProductCatalogService(db *gorm.DB, auditService *AdminAuditService) *ProductCatalogService {
@@ -32,6 +34,12 @@ func NewProductCatalogService(db *gorm.DB, auditService *AdminAuditService) *Pro
 	return &ProductCatalogService{db: db, catalog: mysqlcatalog.NewRepository(db), auditService: auditService}
 }
 
+// SetMCPPVEConfig 注入集群登记表，售卖地域只能绑定已登记的集群。
+func (s *ProductCatalogService) SetMCPPVEConfig(cfg config.MCPPVEConfig) *ProductCatalogService {
+	s.mcp = cfg
+	return s
+}
+
 func (s *ProductCatalogService) Products(ctx context.Context, query admindto.ProductListQuery) (admindto.PageResponse[admindto.ProductItem], error) {
 	page, perPage := adminsupport.NormalizePage(query.Page, query.PerPage)
 	products, total, err := s.catalog.Products(ctx, mysqlcatalog.ProductListFilters{
@@ -191,7 +199,12 @@ func (s *ProductCatalogService) UpdatePlan(ctx context.Context, operatorID uint6
 		if updates.PublicIPCount == 0 {
 			updates.PublicIPCount = 1
 		}
-		if err := s.catalog.UpdatePlan(ctx, tx, id, planUpdateMap(updates)); err != nil {
+		values := planUpdateMap(updates)
+		if updates.Status != current.Status {
+			// 人工改动状态后不再由容量同步自动恢复。
+			values["capacity_sold_out_at"] = nil
+		}
+		if err := s.catalog.UpdatePlan(ctx, tx, id, values); err != nil {
 			return err
 		}
 		updated, err = s.catalog.FindPlanByID(ctx, tx, id)
@@ -401,6 +414,9 @@ func (s *ProductCatalogService) CreateSalesRegion(ctx context.Context, operatorI
 	if region.RegionNo == "" {
 		region.RegionNo = generatedNo("REG")
 	}
+	if !s.mcp.HasCluster(region.ClusterNo) {
+		return admindto.SalesRegionItem{}, apperrors.ErrValidation.WithMessage("虚拟化集群未登记")
+	}
 	if err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
 		if err := s.catalog.CreateSalesRegion(ctx, tx, &region); err != nil {
 			return err
@@ -423,6 +439,11 @@ func (s *ProductCatalogService) UpdateSalesRegion(ctx context.Context, operatorI
 		if updates.RegionNo == "" {
 			updates.RegionNo = current.RegionNo
 		}
+		if updates.ClusterNo != current.ClusterNo {
+			if err := s.checkRegionClusterChange(ctx, tx, current.RegionNo, updates.ClusterNo); err != nil {
+				return err
+			}
+		}
 		if err := s.catalog.UpdateSalesRegion(ctx, tx, id, regionUpdateMap(updates)); err != nil {
 			return err
 		}
@@ -437,6 +458,24 @@ func (s *ProductCatalogService) UpdateSalesRegion(ctx context.Context, operatorI
 	return regionItem(updated), nil
 }
 
+// checkRegionClusterChange 校验地域改绑集群：目标集群需已登记，地域下的实例和交付映射都属于原集群，存在时不能改绑。
+func (s *ProductCatalogService) checkRegionClusterChange(ctx context.Context, tx *gorm.DB, regionNo string, clusterNo string) error {
+	if !s.mcp.HasCluster(clusterNo) {
+		return apperrors.ErrValidation.WithMessage("虚拟化集群未登记")
+	}
+	instances, mappings, err := s.catalog.CountRegionDeployments(ctx, tx, regionNo)
+	if err != nil {
+		return err
+	}
+	if instances > 0 {
+		return apperrors.ErrConflict.WithMessage("销售地域下仍有未释放实例，不能改绑集群")
+	}
+	if mappings > 0 {
+		return apperrors.ErrConflict.WithMessage("销售地域下仍有启用中的交付映射，请先停用后再改绑集群")
+	}
+	return nil
+}
+
 func (s *ProductCatalogService) DeleteSalesRegion(ctx context.Context, operatorID uint64, id uint64) error {
 	return mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
 		current, err := s.findSalesRegionForUpdate(ctx, tx, id)
@@ -787,19 +826,23 @@ func productUpdateMap(product mysqlcatalog.Product) map[string]any {
 }
 
 func planFromRequest(req admindto.ProductPlanRequest) mysqlcatalog.ProductPlan {
-	return mysqlcatalog.ProductPlan{PlanNo: strings.TrimSpace(req.PlanNo), ProductID: req.ProductID, Code: strings.TrimSpace(req.Code), Name: strings.TrimSpace(req.Name), Summary: textutil.NormalizeOptionalString(req.Summary), CPUCores: req.CPUCores, MemoryMB: req.MemoryMB, SystemDiskGB: req.SystemDiskGB, DataDiskGB: req.DataDiskGB, BandwidthMbps: req.BandwidthMbps, TrafficGB: req.TrafficGB, PublicIPCount: req.PublicIPCount, Virtualization: strings.TrimSpace(req.Virtualization), Architecture: strings.TrimSpace(req.Architecture), IsFeatured: req.IsFeatured, Status: strings.TrimSpace(req.Status), Visible: req.Visible, SortOrder: req.SortOrder}
+	return mysqlcatalog.ProductPlan{PlanNo: strings.TrimSpace(req.PlanNo), ProductID: req.ProductID, Code: strings.TrimSpace(req.Code), Name: strings.TrimSpace(req.Name), Summary: textutil.NormalizeOptionalString(req.Summary), CPUCores: req.CPUCores, MemoryMB: req.MemoryMB, SystemDiskGB: req.SystemDiskGB, DataDiskGB: req.DataDiskGB, BandwidthMbps: req.BandwidthMbps, TrafficGB: req.TrafficGB, PublicIPCount: req.PublicIPCount, SnapshotQuota: req.SnapshotQuota, Virtualization: strings.TrimSpace(req.Virtualization), Architecture: strings.TrimSpace(req.Architecture), IsFeatured: req.IsFeatured, Status: strings.TrimSpace(req.Status), Visible: req.Visible, SortOrder: req.SortOrder}
 }
 
 func planUpdateMap(plan mysqlcatalog.ProductPlan) map[string]any {
-	return map[string]any{"plan_no": plan.PlanNo, "product_id": plan.ProductID, "code": plan.Code, "name": plan.Name, "summary": plan.Summary, "cpu_cores": plan.CPUCores, "memory_mb": plan.MemoryMB, "system_disk_gb": plan.SystemDiskGB, "data_disk_gb": plan.DataDiskGB, "bandwidth_mbps": plan.BandwidthMbps, "traffic_gb": plan.TrafficGB, "public_ip_count": plan.PublicIPCount, "virtualization": plan.Virtualization, "architecture": plan.Architecture, "is_featured": plan.IsFeatured, "status": plan.Status, "visible": plan.Visible, "sort_order": plan.SortOrder}
+	return map[string]any{"plan_no": plan.PlanNo, "product_id": plan.ProductID, "code": plan.Code, "name": plan.Name, "summary": plan.Summary, "cpu_cores": plan.CPUCores, "memory_mb": plan.MemoryMB, "system_disk_gb": plan.SystemDiskGB, "data_disk_gb": plan.DataDiskGB, "bandwidth_mbps": plan.BandwidthMbps, "traffic_gb": plan.TrafficGB, "public_ip_count": plan.PublicIPCount, "snapshot_quota": plan.SnapshotQuota, "virtualization": plan.Virtualization, "architecture": plan.Architecture, "is_featured": plan.IsFeatured, "status": plan.Status, "visible": plan.Visible, "sort_order": plan.SortOrder}
 }
 
 func regionFromRequest(req admindto.SalesRegionRequest) mysqlcatalog.SalesRegion {
-	return mysqlcatalog.SalesRegion{RegionNo: strings.TrimSpace(req.RegionNo), Code: strings.TrimSpace(req.Code), Name: strings.TrimSpace(req.Name), Country: textutil.NormalizeOptionalString(req.Country), City: textutil.NormalizeOptionalString(req.City), Summary: textutil.NormalizeOptionalString(req.Summary), Status: strings.TrimSpace(req.Status), Visible: req.Visible, SortOrder: req.SortOrder}
+	clusterNo := strings.TrimSpace(req.ClusterNo)
+	if clusterNo == "" {
+		clusterNo = config.DefaultMCPPVECluster
+	}
+	return mysqlcatalog.SalesRegion{RegionNo: strings.TrimSpace(req.RegionNo), ClusterNo: clusterNo, Code: strings.TrimSpace(req.Code), Name: strings.TrimSpace(req.Name), Country: textutil.NormalizeOptionalString(req.Country), City: textutil.NormalizeOptionalString(req.City), Summary: textutil.NormalizeOptionalString(req.Summary), Status: strings.TrimSpace(req.Status), Visible: req.Visible, SortOrder: req.SortOrder}
 }
 
 func regionUpdateMap(region mysqlcatalog.SalesRegion) map[string]any {
-	return map[string]any{"region_no": region.RegionNo, "code": region.Code, "name": region.Name, "country": region.Country, "city": region.City, "summary": region.Summary, "status": region.Status, "visible": region.Visible, "sort_order": region.SortOrder}
+	return map[string]any{"region_no": region.RegionNo, "cluster_no": region.ClusterNo, "code": region.Code, "name": region.Name, "country": region.Country, "city": region.City, "summary": region.Summary, "status": region.Status, "visible": region.Visible, "sort_order": region.SortOrder}
 }
 
 func templateFromRequest(req admindto.ServerOSTemplateRequest) mysqlcatalog.ServerOSTemplate {
@@ -823,7 +866,7 @@ func productItem(product mysqlcatalog.Product) admindto.ProductItem {
 }
 
 func planItem(plan mysqlcatalog.ProductPlan) admindto.ProductPlanItem {
-	return admindto.ProductPlanItem{ID: plan.ID, PlanNo: plan.PlanNo, ProductID: plan.ProductID, Code: plan.Code, Name: plan.Name, Summary: plan.Summary, CPUCores: plan.CPUCores, MemoryMB: plan.MemoryMB, SystemDiskGB: plan.SystemDiskGB, DataDiskGB: plan.DataDiskGB, BandwidthMbps: plan.BandwidthMbps, TrafficGB: plan.TrafficGB, PublicIPCount: plan.PublicIPCount, Virtualization: plan.Virtualization, Architecture: plan.Architecture, IsFeatured: plan.IsFeatured, Status: plan.Status, Visible: plan.Visible, SortOrder: plan.SortOrder, CreatedAt: plan.CreatedAt, UpdatedAt: plan.UpdatedAt}
+	return admindto.ProductPlanItem{ID: plan.ID, PlanNo: plan.PlanNo, ProductID: plan.ProductID, Code: plan.Code, Name: plan.Name, Summary: plan.Summary, CPUCores: plan.CPUCores, MemoryMB: plan.MemoryMB, SystemDiskGB: plan.SystemDiskGB, DataDiskGB: plan.DataDiskGB, BandwidthMbps: plan.BandwidthMbps, TrafficGB: plan.TrafficGB, PublicIPCount: plan.PublicIPCount, SnapshotQuota: plan.SnapshotQuota, Virtualization: plan.Virtualization, Architecture: plan.Architecture, IsFeatured: plan.IsFeatured, Status: plan.Status, CapacitySoldOutAt: plan.CapacitySoldOutAt, Visible: plan.Visible, SortOrder: plan.SortOrder, CreatedAt: plan.CreatedAt, UpdatedAt: plan.UpdatedAt}
 }
 
 func priceItem(price mysqlcatalog.PlanPrice) admindto.PlanPriceItem {
@@ -831,7 +874,7 @@ func priceItem(price mysqlcatalog.PlanPrice) admindto.PlanPriceItem {
 }
 
 func regionItem(region mysqlcatalog.SalesRegion) admindto.SalesRegionItem {
-	return admindto.SalesRegionItem{ID: region.ID, RegionNo: region.RegionNo, Code: region.Code, Name: region.Name, Country: region.Country, City: region.City, Summary: region.Summary, Status: region.Status, Visible: region.Visible, SortOrder: region.SortOrder, CreatedAt: region.CreatedAt, UpdatedAt: region.UpdatedAt}
+	return admindto.SalesRegionItem{ID: region.ID, RegionNo: region.RegionNo, ClusterNo: region.ClusterNo, Code: region.Code, Name: region.Name, Country: region.Country, City: region.City, Summary: region.Summary, Status: region.Status, Visible: region.Visible, SortOrder: region.SortOrder, CreatedAt: region.CreatedAt, UpdatedAt: region.UpdatedAt}
 }
 
 func templateItem(template mysqlcatalog.ServerOSTemplate) admindto.ServerOSTemplateItem {
@@ -849,7 +892,7 @@ func planAudit(plan mysqlcatalog.ProductPlan) map[string]any {
 	return map[string]any{"id": plan.ID, "plan_no": plan.PlanNo, "product_id": plan.ProductID, "code": plan.Code, "name": plan.Name, "status": plan.Status, "visible": plan.Visible}
 }
 func regionAudit(region mysqlcatalog.SalesRegion) map[string]any {
-	return map[string]any{"id": region.ID, "region_no": region.RegionNo, "code": region.Code, "name": region.Name, "status": region.Status, "visible": region.Visible}
+	return map[string]any{"id": region.ID, "region_no": region.RegionNo, "cluster_no": region.ClusterNo, "code": region.Code, "name": region.Name, "status": region.Status, "visible": region.Visible}
 }
 func templateAudit(template mysqlcatalog.ServerOSTemplate) map[string]any {
 	return map[string]any{"id": template.ID, "template_no": template.TemplateNo, "code": template.Code, "name": template.Name, "status": template.Status, "visible": template.Visible}
@@ -134,10 +134,12 @@ CREATE TABLE product_plans (
   bandwidth_mbps INT NOT NULL,
   traffic_gb INT NULL,
   public_ip_count INT NOT NULL DEFAULT 1,
+  snapshot_quota INT NOT NULL DEFAULT 0,
   virtualization VARCHAR(32) NOT NULL,
   architecture VARCHAR(32) NOT NULL,
   is_featured TINYINT(1) NOT NULL DEFAULT 0,
   status VARCHAR(32) NOT NULL DEFAULT 'draft',
+  capacity_sold_out_at DATETIME(3) NULL,
   visible TINYINT(1) NOT NULL DEFAULT 1,
   sort_order INT NOT NULL DEFAULT 0,
   created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
@@ -36,6 +36,7 @@ type ServerCatalogPlan struct {
 	BandwidthMbps  int
 	TrafficGB      *int
 	PublicIPCount  int
+	SnapshotQuota  int
 	Virtualization string
 	Architecture   string
 	IsFeatured     bool
@@ -189,7 +190,7 @@ func catalogProducts(products []mysqlcatalog.Product, plans []mysqlcatalog.Produ
 		if !domaincatalog.HasRenderablePlanParts(len(planPrices), len(planRegions), len(planTemplates), len(planNetworkTypes)) {
 			continue
 		}
-		plansByProduct[plan.ProductID] = append(plansByProduct[plan.ProductID], ServerCatalogPlan{PlanNo: plan.PlanNo, Code: plan.Code, Name: plan.Name, Summary: plan.Summary, CPUCores: plan.CPUCores, MemoryMB: plan.MemoryMB, SystemDiskGB: plan.SystemDiskGB, DataDiskGB: plan.DataDiskGB, BandwidthMbps: plan.BandwidthMbps, TrafficGB: plan.TrafficGB, PublicIPCount: plan.PublicIPCount, Virtualization: plan.Virtualization, Architecture: plan.Architecture, IsFeatured: plan.IsFeatured, Status: plan.Status, Prices: planPrices, Regions: planRegions, OSTemplates: planTemplates, NetworkTypes: planNetworkTypes})
+		plansByProduct[plan.ProductID] = append(plansByProduct[plan.ProductID], ServerCatalogPlan{PlanNo: plan.PlanNo, Code: plan.Code, Name: plan.Name, Summary: plan.Summary, CPUCores: plan.CPUCores, MemoryMB: plan.MemoryMB, SystemDiskGB: plan.SystemDiskGB, DataDiskGB: plan.DataDiskGB, BandwidthMbps: plan.BandwidthMbps, TrafficGB: plan.TrafficGB, PublicIPCount: plan.PublicIPCount, SnapshotQuota: plan.SnapshotQuota, Virtualization: plan.Virtualization, Architecture: plan.Architecture, IsFeatured: plan.IsFeatured, Status: plan.Status, Prices: planPrices, Regions: planRegions, OSTemplates: planTemplates, NetworkTypes: planNetworkTypes})
 	}
 
 	items := make([]ServerCatalogProduct, 0, len(products))
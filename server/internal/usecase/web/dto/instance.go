@@ -5,7 +5,7 @@ import "time"
 type InstanceListQuery struct {
 	Page    int    `form:"page" validate:"omitempty,min=1"`
 	PerPage int    `form:"per_page" validate:"omitempty,min=1,max=100"`
-	Status  string `form:"status" validate:"omitempty,oneof=creating running stopped error releasing released"`
+	Status  string `form:"status" validate:"omitempty,oneof=creating running stopped error releasing released suspended"`
 }
 
 type InstanceItem struct {
@@ -22,30 +22,51 @@ type InstanceItem struct {
 	ExpireStatus            string               `json:"expire_status"`
 	ReleaseCountdownSeconds *int64               `json:"release_countdown_seconds"`
 	LatestRenewalOrder      *RenewalOrderSummary `json:"latest_renewal_order"`
-	CreatedAt               time.Time            `json:"created_at"`
-	ReleasedAt              *time.Time           `json:"released_at"`
+	// SuspendSource 是暂停来源：expiry 到期暂停，续费后自动恢复；admin 后台暂停，需联系客服解除。
+	SuspendSource *string    `json:"suspend_source"`
+	SuspendReason *string    `json:"suspend_reason"`
+	SuspendedAt   *time.Time `json:"suspended_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReleasedAt    *time.Time `json:"released_at"`
 }
 
 type InstanceDetail struct {
 	InstanceItem
-	ProductNo                string              `json:"product_no"`
-	PlanNo                   string              `json:"plan_no"`
-	CPUCores                 int                 `json:"cpu_cores"`
-	MemoryMB                 int                 `json:"memory_mb"`
-	SystemDiskGB             int                 `json:"system_disk_gb"`
-	DataDiskGB               int                 `json:"data_disk_gb"`
-	BandwidthMbps            int                 `json:"bandwidth_mbps"`
-	RegionNo                 string              `json:"region_no"`
-	NetworkTypeNo            *string             `json:"network_type_no"`
-	TemplateNo               string              `json:"template_no"`
-	OSFamily                 string              `json:"os_family"`
-	OSDistribution           string              `json:"os_distribution"`
-	OSVersion                string              `json:"os_version"`
-	ExpireNoticeSentAt       *time.Time          `json:"expire_notice_sent_at"`
-	ExpireReleaseScheduledAt *time.Time          `json:"expire_release_scheduled_at"`
-	ExpireReleasedAt         *time.Time          `json:"expire_released_at"`
-	RenewalAvailable         bool                `json:"renewal_available"`
-	Operations               []InstanceOperation `json:"operations"`
+	ProductNo     string `json:"product_no"`
+	PlanNo        string `json:"plan_no"`
+	CPUCores      int    `json:"cpu_cores"`
+	MemoryMB      int    `json:"memory_mb"`
+	SystemDiskGB  int    `json:"system_disk_gb"`
+	DataDiskGB    int    `json:"data_disk_gb"`
+	BandwidthMbps int    `json:"bandwidth_mbps"`
+	TrafficGB     *int   `json:"traffic_gb"`
+	// TrafficOverageAction 是本月流量超额后当前生效的限制：throttle 限速、suspend 暂停，空表示未限制。
+	TrafficOverageAction     *string    `json:"traffic_overage_action"`
+	RegionNo                 string     `json:"region_no"`
+	NetworkTypeNo            *string    `json:"network_type_no"`
+	TemplateNo               string     `json:"template_no"`
+	OSFamily                 string     `json:"os_family"`
+	OSDistribution           string     `json:"os_distribution"`
+	OSVersion                string     `json:"os_version"`
+	ExpireNoticeSentAt       *time.Time `json:"expire_notice_sent_at"`
+	ExpireReleaseScheduledAt *time.Time `json:"expire_release_scheduled_at"`
+	ExpireReleasedAt         *time.Time `json:"expire_released_at"`
+	// IPAddresses 是实例分配的公网地址，第一个为主地址；未使用地址池交付时为空。
+	IPAddresses []string `json:"ip_addresses"`
+	// RootPasswordAvailable 表示当前 root 密码尚未查看，可调用查看接口取回一次。
+	RootPasswordAvailable bool                `json:"root_password_available"`
+	RenewalAvailable      bool                `json:"renewal_available"`
+	Operations            []InstanceOperation `json:"operations"`
+	// Rescue 是进行中的救援模式，MountedISO 是光驱中挂载的安装镜像，未使用时为空。
+	Rescue     *InstanceRescueState `json:"rescue"`
+	MountedISO *InstanceMountedISO  `json:"mounted_iso"`
+}
+
+// InstanceRootPassword 是只返回一次的实例 root 密码，前端不得缓存或写入日志。
+type InstanceRootPassword struct {
+	InstanceNo string    `json:"instance_no"`
+	Password   string    `json:"password"`
+	RevealedAt time.Time `json:"revealed_at"`
 }
 
 type RenewalOrderSummary struct {
@@ -59,6 +80,112 @@ type RenewalOrderSummary struct {
 	CreatedAt        time.Time  `json:"created_at"`
 }
 
+type InstanceReinstallRequest struct {
+	TemplateNo string `json:"template_no" validate:"required,max=64"`
+}
+
+type InstanceReinstallTemplate struct {
+	TemplateNo   string  `json:"template_no"`
+	Code         string  `json:"code"`
+	Name         string  `json:"name"`
+	OSFamily     string  `json:"os_family"`
+	Distribution string  `json:"distribution"`
+	Version      string  `json:"version"`
+	Architecture string  `json:"architecture"`
+	Summary      *string `json:"summary"`
+	Current      bool    `json:"current"`
+}
+
+type InstanceSnapshotCreateRequest struct {
+	Description *string `json:"description" validate:"omitempty,max=255"`
+}
+
+type InstanceSnapshotItem struct {
+	SnapshotNo       string     `json:"snapshot_no"`
+	Description      *string    `json:"description"`
+	Status           string     `json:"status"`
+	LastRolledBackAt *time.Time `json:"last_rolled_back_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// InstanceSnapshotList 返回实例快照和套餐快照配额，used 只统计占用配额的快照。
+type InstanceSnapshotList struct {
+	Quota int                    `json:"quota"`
+	Used  int64                  `json:"used"`
+	List  []InstanceSnapshotItem `json:"list"`
+}
+
+type InstanceBackupCreateRequest struct {
+	Note *string `json:"note" validate:"omitempty,max=255"`
+}
+
+// InstanceBackupPolicyRequest 保存实例定时备份策略；weekly 时 weekday 生效，0 表示星期日。
+type InstanceBackupPolicyRequest struct {
+	Frequency      string `json:"frequency" validate:"required,oneof=daily weekly"`
+	Weekday        int    `json:"weekday" validate:"min=0,max=6"`
+	Hour           int    `json:"hour" validate:"min=0,max=23"`
+	RetentionCount int    `json:"retention_count" validate:"required,min=1,max=30"`
+	Enabled        bool   `json:"enabled"`
+}
+
+type InstanceBackupPolicy struct {
+	Frequency      string     `json:"frequency"`
+	Weekday        int        `json:"weekday"`
+	Hour           int        `json:"hour"`
+	RetentionCount int        `json:"retention_count"`
+	Enabled        bool       `json:"enabled"`
+	NextRunAt      *time.Time `json:"next_run_at"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+}
+
+type InstanceBackupItem struct {
+	BackupNo       string     `json:"backup_no"`
+	Source         string     `json:"source"`
+	Status         string     `json:"status"`
+	Note           *string    `json:"note"`
+	TemplateNo     string     `json:"template_no"`
+	TemplateName   string     `json:"template_name"`
+	SystemDiskGB   int        `json:"system_disk_gb"`
+	DataDiskGB     int        `json:"data_disk_gb"`
+	CompletedAt    *time.Time `json:"completed_at"`
+	LastRestoredAt *time.Time `json:"last_restored_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// InstanceBackupMetadata 是备份元数据，只包含恢复所需的规格和系统信息，不暴露 PVE 节点、存储和卷 ID。
+type InstanceBackupMetadata struct {
+	InstanceBackupItem
+	InstanceNo     string `json:"instance_no"`
+	Mode           string `json:"mode"`
+	CPUCores       int    `json:"cpu_cores"`
+	MemoryMB       int    `json:"memory_mb"`
+	OSFamily       string `json:"os_family"`
+	OSDistribution string `json:"os_distribution"`
+	OSVersion      string `json:"os_version"`
+}
+
+// InstanceBackupList 返回实例备份、定时备份策略和手动备份上限，manual_used 只统计占用上限的手动备份。
+type InstanceBackupList struct {
+	ManualLimit int                   `json:"manual_limit"`
+	ManualUsed  int64                 `json:"manual_used"`
+	Policy      *InstanceBackupPolicy `json:"policy"`
+	List        []InstanceBackupItem  `json:"list"`
+}
+
+// InstanceConsoleRequest 申请控制台会话；type 为空时按 vnc 处理。
+type InstanceConsoleRequest struct {
+	Type string `json:"type" validate:"omitempty,oneof=vnc terminal"`
+}
+
+// InstanceConsoleSession 是一次性控制台会话；websocket_path 需在 expires_at 前连接，password 为 VNC 客户端连接密码。
+type InstanceConsoleSession struct {
+	Type               string    `json:"type"`
+	WebsocketPath      string    `json:"websocket_path"`
+	Password           string    `json:"password"`
+	ExpiresAt          time.Time `json:"expires_at"`
+	MaxDurationSeconds int       `json:"max_duration_seconds"`
+}
+
 type InstanceOperation struct {
 	OperationNo string     `json:"operation_no"`
 	Action      string     `json:"action"`
@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+type InstanceMetricsQuery struct {
+	Range string `form:"range" validate:"omitempty,oneof=hour day week month"`
+}
+
+// InstanceMetrics 是一段时间范围内的性能采样；采样点超过上限时已按时间分桶取平均。
+type InstanceMetrics struct {
+	Range       string        `json:"range"`
+	Points      []MetricPoint `json:"points"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// MetricPoint 的 cpu_percent 为 0-100，内存单位 MB，磁盘和网络单位字节/秒；字段为 null 表示该时段无数据。
+type MetricPoint struct {
+	Time          time.Time `json:"time"`
+	CPUPercent    *float64  `json:"cpu_percent"`
+	MemoryUsedMB  *float64  `json:"memory_used_mb"`
+	MemoryTotalMB *float64  `json:"memory_total_mb"`
+	DiskReadBps   *float64  `json:"disk_read_bps"`
+	DiskWriteBps  *float64  `json:"disk_write_bps"`
+	NetInBps      *float64  `json:"net_in_bps"`
+	NetOutBps     *float64  `json:"net_out_bps"`
+}
@@ -19,6 +19,10 @@ type OrderCreateRequest struct {
 	Quantity      int     `json:"quantity" validate:"omitempty,min=1,max=1"`
 	ClientToken   string  `json:"client_token" validate:"required,max=128"`
 	UserNote      *string `json:"user_note" validate:"omitempty,max=500"`
+	// SourceBackupNo 非空时新实例从该备份恢复，而不是按系统模板全新安装。
+	SourceBackupNo *string `json:"source_backup_no" validate:"omitempty,max=64"`
+	// SSHKeyNos 为交付时注入实例的用户 SSH 公钥编号，下单时快照公钥内容。
+	SSHKeyNos []string `json:"ssh_key_nos" validate:"omitempty,max=10,dive,required,max=64"`
 }
 
 type OrderListQuery struct {
@@ -49,6 +53,9 @@ type OrderItem struct {
 type OrderDetail struct {
 	OrderItem
 	UserNote           *string `json:"user_note"`
+	SourceBackupNo     *string `json:"source_backup_no"`
+	ChangeFromPlanNo   *string `json:"change_from_plan_no"`
+	CreditAmountCents  uint64  `json:"credit_amount_cents"`
 	ProductNo          string  `json:"product_no"`
 	ProductType        string  `json:"product_type"`
 	ProductSummary     *string `json:"product_summary"`
@@ -90,3 +97,30 @@ type RenewalOrderCreateRequest struct {
 	BillingCycle string `json:"billing_cycle" validate:"required,oneof=monthly quarterly semi_yearly yearly"`
 	ClientToken  string `json:"client_token" validate:"required,max=128"`
 }
+
+type ChangePlanQuoteQuery struct {
+	PlanNo string `form:"plan_no" validate:"required,max=64"`
+}
+
+type ChangePlanOrderCreateRequest struct {
+	PlanNo      string `json:"plan_no" validate:"required,max=64"`
+	ClientToken string `json:"client_token" validate:"required,max=128"`
+}
+
+// ChangePlanQuote 是变更套餐按剩余服务期折算的报价；charge 与 credit 至多一项大于 0。
+type ChangePlanQuote struct {
+	InstanceNo        string     `json:"instance_no"`
+	CurrentPlanNo     string     `json:"current_plan_no"`
+	PlanNo            string     `json:"plan_no"`
+	PlanName          string     `json:"plan_name"`
+	CPUCores          int        `json:"cpu_cores"`
+	MemoryMB          int        `json:"memory_mb"`
+	BandwidthMbps     int        `json:"bandwidth_mbps"`
+	BillingCycle      string     `json:"billing_cycle"`
+	CurrentPriceCents uint64     `json:"current_price_cents"`
+	TargetPriceCents  uint64     `json:"target_price_cents"`
+	ChargeAmountCents uint64     `json:"charge_amount_cents"`
+	CreditAmountCents uint64     `json:"credit_amount_cents"`
+	Currency          string     `json:"currency"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+}
@@ -25,6 +25,7 @@ type ServerCatalogPlan struct {
 	BandwidthMbps  int                        `json:"bandwidth_mbps"`
 	TrafficGB      *int                       `json:"traffic_gb"`
 	PublicIPCount  int                        `json:"public_ip_count"`
+	SnapshotQuota  int                        `json:"snapshot_quota"`
 	Virtualization string                     `json:"virtualization"`
 	Architecture   string                     `json:"architecture"`
 	IsFeatured     bool                       `json:"is_featured"`
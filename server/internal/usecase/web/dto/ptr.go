@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+// InstancePTRRequest 设置实例地址的反向解析主机名；主机名必须已正向解析（A/AAAA）到该地址。
+type InstancePTRRequest struct {
+	Address  string `json:"address" validate:"required,max=64"`
+	Hostname string `json:"hostname" validate:"required,max=253"`
+}
+
+// PTRRecord 是实例单个地址的 PTR 状态；未设置过 PTR 的地址 hostname 和 status 为空。
+// applied_hostname 为当前已写入 DNS 的主机名，修改待审批期间旧记录继续生效。
+type PTRRecord struct {
+	Address         string     `json:"address"`
+	Hostname        *string    `json:"hostname"`
+	AppliedHostname *string    `json:"applied_hostname"`
+	Status          *string    `json:"status"`
+	LastError       *string    `json:"last_error"`
+	ReviewRemark    *string    `json:"review_remark"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+}
+
+// InstancePTR 返回实例全部地址的 PTR 状态；enabled 为 false 时只读，auto_apply 为 false 时提交后需后台审批。
+type InstancePTR struct {
+	InstanceNo string      `json:"instance_no"`
+	Enabled    bool        `json:"enabled"`
+	AutoApply  bool        `json:"auto_apply"`
+	Records    []PTRRecord `json:"records"`
+}
@@ -0,0 +1,44 @@
+package dto
+
+import "time"
+
+// ISOItem 是镜像库中用户可见的条目；rescue 用于进入救援模式，install 可挂载到实例光驱。
+type ISOItem struct {
+	ISONo       string  `json:"iso_no"`
+	Name        string  `json:"name"`
+	Purpose     string  `json:"purpose"`
+	OSFamily    *string `json:"os_family"`
+	Description *string `json:"description"`
+}
+
+// InstanceRescueRequest 让实例进入救援模式；iso_no 为空时使用排序最靠前的可用救援介质。
+type InstanceRescueRequest struct {
+	ISONo string `json:"iso_no" validate:"omitempty,max=64"`
+}
+
+// InstanceISOMountRequest 把安装镜像挂载到实例光驱；boot 为 true 时下次启动优先从光驱引导。
+type InstanceISOMountRequest struct {
+	ISONo string `json:"iso_no" validate:"required,max=64"`
+	Boot  bool   `json:"boot"`
+}
+
+// InstanceRescueState 是实例详情中的救援状态，不包含临时密码。
+type InstanceRescueState struct {
+	ISONo     string     `json:"iso_no"`
+	ISOName   string     `json:"iso_name"`
+	StartedAt *time.Time `json:"started_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type InstanceMountedISO struct {
+	ISONo   string `json:"iso_no"`
+	ISOName string `json:"iso_name"`
+}
+
+// InstanceRescue 返回救援模式的登录信息；救援期间临时密码可重复查看，前端不得缓存或写入日志。
+type InstanceRescue struct {
+	InstanceNo string `json:"instance_no"`
+	Active     bool   `json:"active"`
+	InstanceRescueState
+	Password string `json:"password,omitempty"`
+}
@@ -0,0 +1,65 @@
+package dto
+
+import "time"
+
+// SecurityGroupRuleRequest 是一条放行规则；port_range 为单端口或 "起-止" 范围，icmp/any 时留空；
+// cidr 为入站来源或出站目标地址段，单个 IP 按主机地址处理。
+type SecurityGroupRuleRequest struct {
+	Direction   string  `json:"direction" validate:"required,oneof=in out"`
+	Protocol    string  `json:"protocol" validate:"required,oneof=tcp udp icmp any"`
+	PortRange   string  `json:"port_range" validate:"max=16"`
+	CIDR        string  `json:"cidr" validate:"required,max=64"`
+	Description *string `json:"description" validate:"omitempty,max=128"`
+}
+
+// SecurityGroupCreateRequest 新增安全组；rules 为空且指定 os_family 时按系统族预置 SSH 或远程桌面等入站规则。
+type SecurityGroupCreateRequest struct {
+	Name        string                     `json:"name" validate:"required,max=64"`
+	Description *string                    `json:"description" validate:"omitempty,max=255"`
+	OSFamily    string                     `json:"os_family" validate:"omitempty,oneof=linux windows bsd"`
+	Rules       []SecurityGroupRuleRequest `json:"rules" validate:"omitempty,max=50,dive"`
+}
+
+// SecurityGroupUpdateRequest 整体替换安全组名称、说明和规则，变更会下发到所有绑定实例。
+type SecurityGroupUpdateRequest struct {
+	Name        string                     `json:"name" validate:"required,max=64"`
+	Description *string                    `json:"description" validate:"omitempty,max=255"`
+	Rules       []SecurityGroupRuleRequest `json:"rules" validate:"max=50,dive"`
+}
+
+type SecurityGroupRule struct {
+	Direction   string  `json:"direction"`
+	Protocol    string  `json:"protocol"`
+	PortRange   *string `json:"port_range"`
+	CIDR        string  `json:"cidr"`
+	Description *string `json:"description"`
+}
+
+type SecurityGroupItem struct {
+	GroupNo       string              `json:"group_no"`
+	Name          string              `json:"name"`
+	Description   *string             `json:"description"`
+	Rules         []SecurityGroupRule `json:"rules"`
+	InstanceCount int64               `json:"instance_count"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+// SecurityGroupList 返回当前用户安全组和创建上限。
+type SecurityGroupList struct {
+	Limit int                 `json:"limit"`
+	List  []SecurityGroupItem `json:"list"`
+}
+
+// InstanceSecurityGroupsRequest 整体替换实例绑定的安全组，顺序即规则下发顺序；空列表表示解绑全部并关闭防火墙。
+type InstanceSecurityGroupsRequest struct {
+	GroupNos []string `json:"group_nos" validate:"max=5,dive,required,max=64"`
+}
+
+// InstanceFirewall 是实例绑定的安全组和下发状态；status 为 pending 时本地变更尚未确认生效，下次实例同步时重试。
+type InstanceFirewall struct {
+	InstanceNo     string              `json:"instance_no"`
+	Status         *string             `json:"status"`
+	SyncedAt       *time.Time          `json:"synced_at"`
+	SecurityGroups []SecurityGroupItem `json:"security_groups"`
+}
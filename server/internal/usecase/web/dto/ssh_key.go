@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+/**
+ * SSHKeyCreateRequest 表示当前用户新增 SSH 公钥请求。
+ */
+type SSHKeyCreateRequest struct {
+	Name      string `json:"name" validate:"required,max=64"`
+	PublicKey string `json:"public_key" validate:"required,max=8192"`
+}
+
+/**
+ * SSHKeyItem 表示当前用户已保存的 SSH 公钥；公钥正文只返回指纹，避免列表泄露完整内容。
+ */
+type SSHKeyItem struct {
+	KeyNo       string    `json:"key_no"`
+	Name        string    `json:"name"`
+	KeyType     string    `json:"key_type"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+/**
+ * SSHKeyList 返回当前用户 SSH 公钥列表和保存上限。
+ */
+type SSHKeyList struct {
+	Limit int          `json:"limit"`
+	List  []SSHKeyItem `json:"list"`
+}
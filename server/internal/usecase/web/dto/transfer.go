@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+// InstanceTransferRequest 向另一个用户账号发起实例转移；account 可填写对方用户名或邮箱。
+type InstanceTransferRequest struct {
+	Account string  `json:"account" validate:"required,max=128"`
+	Remark  *string `json:"remark" validate:"omitempty,max=500"`
+}
+
+type InstanceTransferListQuery struct {
+	Page      int    `form:"page" validate:"omitempty,min=1"`
+	PerPage   int    `form:"per_page" validate:"omitempty,min=1,max=100"`
+	Direction string `form:"direction" validate:"omitempty,oneof=incoming outgoing"`
+	Status    string `form:"status" validate:"omitempty,oneof=pending completed rejected cancelled"`
+}
+
+// InstanceTransferItem 是当前用户发起（outgoing）或接收（incoming）的一条转移记录；forced 表示由管理员强制转移。
+type InstanceTransferItem struct {
+	TransferNo   string     `json:"transfer_no"`
+	InstanceNo   string     `json:"instance_no"`
+	Direction    string     `json:"direction"`
+	FromUsername string     `json:"from_username"`
+	ToUsername   string     `json:"to_username"`
+	Status       string     `json:"status"`
+	Remark       *string    `json:"remark"`
+	Forced       bool       `json:"forced"`
+	RespondedAt  *time.Time `json:"responded_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
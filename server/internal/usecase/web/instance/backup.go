@@ -0,0 +1,284 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/textutil"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
+)
+
+// SetBackupConfig 注入备份存储配置；未注入或未配置存储时备份接口返回冲突错误。
+func (s *Service) SetBackupConfig(cfg config.BackupConfig) *Service {
+	s.backup = cfg
+	return s
+}
+
+// Backups 返回当前用户实例未删除的备份、定时备份策略和手动备份上限占用。
+func (s *Service) Backups(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceBackupList, error) {
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.InstanceBackupList{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return webdto.InstanceBackupList{}, err
+	}
+	used, err := s.instances.CountManualBackups(ctx, nil, row.ID)
+	if err != nil {
+		return webdto.InstanceBackupList{}, err
+	}
+	result := webdto.InstanceBackupList{ManualLimit: s.backup.ManualLimit, ManualUsed: used, List: []webdto.InstanceBackupItem{}}
+	if policy, err := s.instances.BackupPolicy(ctx, row.ID); err == nil {
+		item := backupPolicyItem(policy)
+		result.Policy = &item
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.InstanceBackupList{}, err
+	}
+	rows, err := s.instances.Backups(ctx, row.ID)
+	if err != nil {
+		return webdto.InstanceBackupList{}, err
+	}
+	for _, backup := range rows {
+		result.List = append(result.List, backupItem(backup))
+	}
+	return result, nil
+}
+
+// BackupMetadata 返回备份元数据，供用户核对或下载留存。
+func (s *Service) BackupMetadata(ctx context.Context, userID uint64, instanceNo string, backupNo string) (webdto.InstanceBackupMetadata, error) {
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.InstanceBackupMetadata{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return webdto.InstanceBackupMetadata{}, err
+	}
+	backup, err := s.instances.BackupByNo(ctx, strings.TrimSpace(backupNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && (backup.InstanceID != row.ID || backup.Status == domaininstance.BackupStatusDeleted)) {
+		return webdto.InstanceBackupMetadata{}, apperrors.ErrNotFound.WithMessage("备份不存在")
+	}
+	if err != nil {
+		return webdto.InstanceBackupMetadata{}, err
+	}
+	return webdto.InstanceBackupMetadata{InstanceBackupItem: backupItem(backup), InstanceNo: row.InstanceNo, Mode: backup.Mode, CPUCores: backup.CPUCores, MemoryMB: backup.MemoryMB, OSFamily: backup.OSFamily, OSDistribution: backup.OSDistribution, OSVersion: backup.OSVersion}, nil
+}
+
+func (s *Service) CreateBackup(ctx context.Context, userID uint64, instanceNo string, req webdto.InstanceBackupCreateRequest) (webdto.InstanceBackupItem, error) {
+	if !s.backup.Enabled() {
+		return webdto.InstanceBackupItem{}, backupUnavailableError()
+	}
+	var backup mysqlinstance.Backup
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		used, err := s.instances.CountManualBackups(ctx, tx, current.ID)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		if used >= int64(s.backup.ManualLimit) {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("手动备份数量已达上限，请先删除旧备份")
+		}
+		backup = newBackup(current, s.backup, req.Note, userID)
+		if err := s.instances.CreateBackup(ctx, tx, &backup); err != nil {
+			return operationPlan{}, err
+		}
+		input := mcppve.BackupVMRequest{Storage: backup.Storage, Mode: backup.Mode, Compress: s.backup.Compress, Notes: backup.BackupNo}
+		return operationPlan{payload: backupPayload(backup), call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).BackupVM(ctx, row.ExternalNode, row.ExternalVMID, input)
+		}}, nil
+	}
+	detail, err := s.startOperation(ctx, userID, instanceNo, domaininstance.OperationBackupCreate, planner)
+	if err != nil {
+		return webdto.InstanceBackupItem{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.backup.create", "instance", detail.InstanceNo, "创建实例备份："+backup.BackupNo)
+	return s.backupByNo(ctx, backup.BackupNo)
+}
+
+// RestoreBackup 用实例自己的备份覆盖当前系统，要求实例已关机。
+func (s *Service) RestoreBackup(ctx context.Context, userID uint64, instanceNo string, backupNo string) (webdto.InstanceBackupItem, error) {
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		backup, err := s.lockBackup(ctx, tx, current.ID, backupNo)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		if backup.Status != domaininstance.BackupStatusAvailable || backup.VolumeID == nil {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("当前备份状态不能恢复")
+		}
+		input := mcppve.RestoreVMRequest{VMID: current.ExternalVMID, Archive: *backup.VolumeID, Force: true}
+		return operationPlan{payload: backupPayload(backup), call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).RestoreVM(ctx, row.ExternalNode, input)
+		}}, nil
+	}
+	detail, err := s.startOperation(ctx, userID, instanceNo, domaininstance.OperationBackupRestore, planner)
+	if err != nil {
+		return webdto.InstanceBackupItem{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.backup.restore", "instance", detail.InstanceNo, "恢复实例备份："+strings.TrimSpace(backupNo))
+	return s.backupByNo(ctx, strings.TrimSpace(backupNo))
+}
+
+// DeleteBackup 同步删除备份卷。创建失败的备份在存储上不存在，只在本地标记删除。
+func (s *Service) DeleteBackup(ctx context.Context, userID uint64, instanceNo string, backupNo string) (webdto.InstanceBackupItem, error) {
+	var backup mysqlinstance.Backup
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && current.UserID != userID) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if backup, err = s.lockBackup(ctx, tx, current.ID, backupNo); err != nil {
+			return err
+		}
+		if !domaininstance.CanDeleteBackup(backup.Status) {
+			return apperrors.ErrConflict.WithMessage("当前备份状态不能删除")
+		}
+		if backup.Status == domaininstance.BackupStatusFailed || backup.VolumeID == nil {
+			return s.instances.UpdateBackup(ctx, tx, backup.ID, map[string]any{"status": domaininstance.BackupStatusDeleted, "deleted_at": time.Now()})
+		}
+		if !s.mcp.Enabled() {
+			return mcpUnavailableError()
+		}
+		if err := s.ensureNoRunningOperation(ctx, tx, current.ID); err != nil {
+			return err
+		}
+		return s.instances.UpdateBackup(ctx, tx, backup.ID, map[string]any{"status": domaininstance.BackupStatusDeleting})
+	})
+	if err != nil {
+		return webdto.InstanceBackupItem{}, err
+	}
+	if backup.Status == domaininstance.BackupStatusAvailable && backup.VolumeID != nil {
+		if err := s.mcp.Cluster(backup.ClusterNo).DeleteBackup(ctx, backup.Node, backup.Storage, *backup.VolumeID); err != nil {
+			_ = s.instances.UpdateBackup(context.Background(), nil, backup.ID, map[string]any{"status": domaininstance.BackupStatusAvailable})
+			return webdto.InstanceBackupItem{}, externalError(err)
+		}
+		if err := s.instances.UpdateBackup(ctx, nil, backup.ID, map[string]any{"status": domaininstance.BackupStatusDeleted, "deleted_at": time.Now()}); err != nil {
+			return webdto.InstanceBackupItem{}, err
+		}
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.backup.delete", "instance", strings.TrimSpace(instanceNo), "删除实例备份："+backup.BackupNo)
+	return s.backupByNo(ctx, backup.BackupNo)
+}
+
+// UpdateBackupPolicy 保存定时备份策略，启用时计算下次执行时间并投递 Worker 任务。
+func (s *Service) UpdateBackupPolicy(ctx context.Context, userID uint64, instanceNo string, req webdto.InstanceBackupPolicyRequest) (webdto.InstanceBackupPolicy, error) {
+	if req.Enabled && !s.backup.Enabled() {
+		return webdto.InstanceBackupPolicy{}, backupUnavailableError()
+	}
+	if !domaininstance.IsKnownBackupFrequency(req.Frequency) {
+		return webdto.InstanceBackupPolicy{}, apperrors.ErrValidation.WithMessage("备份频率不支持")
+	}
+	var saved mysqlinstance.BackupPolicy
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && current.UserID != userID) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if current.Status == domaininstance.StatusReleasing || current.Status == domaininstance.StatusReleased {
+			return apperrors.ErrConflict.WithMessage("实例已释放，不能设置定时备份")
+		}
+		saved = mysqlinstance.BackupPolicy{InstanceID: current.ID, Frequency: req.Frequency, Weekday: req.Weekday, Hour: req.Hour, RetentionCount: req.RetentionCount, Enabled: req.Enabled}
+		if req.Enabled {
+			next := domaininstance.NextBackupRun(req.Frequency, req.Weekday, req.Hour, time.Now()).Truncate(time.Millisecond)
+			saved.NextRunAt = &next
+		}
+		before, err := s.instances.BackupPolicyForUpdate(ctx, tx, current.ID)
+		if err == nil {
+			saved.ID = before.ID
+			saved.LastRunAt = before.LastRunAt
+			err = s.instances.UpdateBackupPolicy(ctx, tx, before.ID, map[string]any{"frequency": saved.Frequency, "weekday": saved.Weekday, "hour": saved.Hour, "retention_count": saved.RetentionCount, "enabled": saved.Enabled, "next_run_at": saved.NextRunAt})
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = s.instances.CreateBackupPolicy(ctx, tx, &saved)
+		}
+		if err != nil {
+			return err
+		}
+		if saved.NextRunAt == nil {
+			return nil
+		}
+		return s.enqueueBackupSchedule(ctx, tx, current.InstanceNo, *saved.NextRunAt)
+	})
+	if err != nil {
+		return webdto.InstanceBackupPolicy{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.backup.policy", "instance", strings.TrimSpace(instanceNo), "更新定时备份策略")
+	return backupPolicyItem(saved), nil
+}
+
+func (s *Service) lockBackup(ctx context.Context, tx *gorm.DB, instanceID uint64, backupNo string) (mysqlinstance.Backup, error) {
+	backup, err := s.instances.BackupForUpdate(ctx, tx, instanceID, strings.TrimSpace(backupNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return mysqlinstance.Backup{}, apperrors.ErrNotFound.WithMessage("备份不存在")
+	}
+	return backup, err
+}
+
+func (s *Service) backupByNo(ctx context.Context, backupNo string) (webdto.InstanceBackupItem, error) {
+	backup, err := s.instances.BackupByNo(ctx, backupNo)
+	if err != nil {
+		return webdto.InstanceBackupItem{}, err
+	}
+	return backupItem(backup), nil
+}
+
+// settleBackup 在上游调用直接失败时回写备份状态；异步结果由 Worker 同步时回写。
+func (s *Service) settleBackup(ctx context.Context, op mysqlinstance.Operation, succeeded bool) error {
+	status := domaininstance.BackupStatusAfterOperation(op.Action, succeeded)
+	if status == "" || op.Payload == nil {
+		return nil
+	}
+	var payload mysqlinstance.BackupPayload
+	if err := json.Unmarshal([]byte(*op.Payload), &payload); err != nil || strings.TrimSpace(payload.BackupNo) == "" {
+		return nil
+	}
+	updates := map[string]any{"status": status}
+	if op.Action == domaininstance.OperationBackupCreate {
+		updates["completed_at"] = time.Now()
+	}
+	return s.instances.UpdateBackupByNo(ctx, nil, payload.BackupNo, updates)
+}
+
+func (s *Service) enqueueBackupSchedule(ctx context.Context, tx *gorm.DB, instanceNo string, runAt time.Time) error {
+	data, _ := json.Marshal(map[string]string{"instance_no": instanceNo, "scheduled_at": runAt.Format(time.RFC3339Nano)})
+	idempotencyKey := "backup_schedule:" + instanceNo + ":" + runAt.Format(time.RFC3339Nano)
+	objectType := "instance"
+	objectNo := instanceNo
+	task := mysqlinstance.Task{TaskNo: fmt.Sprintf("TASK-%d", time.Now().UnixNano()), TaskType: domaininstance.TaskTypeBackupScheduled, IdempotencyKey: &idempotencyKey, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &objectNo, Payload: stringPtr(string(data)), MaxAttempts: 5, ScheduledAt: runAt}
+	return s.instances.CreateTaskIgnoreDuplicate(ctx, tx, &task)
+}
+
+func newBackup(current mysqlinstance.Instance, cfg config.BackupConfig, note *string, userID uint64) mysqlinstance.Backup {
+	return mysqlinstance.Backup{BackupNo: fmt.Sprintf("BAK-%d", time.Now().UnixNano()), InstanceID: current.ID, Source: domaininstance.BackupSourceManual, Status: domaininstance.BackupStatusCreating, ClusterNo: current.ClusterNo, Node: current.ExternalNode, Storage: strings.TrimSpace(cfg.Storage), Mode: cfg.Mode, Note: textutil.NormalizeOptionalString(note), CPUCores: current.CPUCores, MemoryMB: current.MemoryMB, SystemDiskGB: current.SystemDiskGB, DataDiskGB: current.DataDiskGB, TemplateNo: current.TemplateNo, TemplateName: current.TemplateName, OSFamily: current.OSFamily, OSDistribution: current.OSDistribution, OSVersion: current.OSVersion, CreatedByUserID: &userID}
+}
+
+func backupPayload(backup mysqlinstance.Backup) mysqlinstance.BackupPayload {
+	return mysqlinstance.BackupPayload{BackupNo: backup.BackupNo}
+}
+
+func backupUnavailableError() error {
+	return apperrors.ErrConflict.WithMessage("备份服务暂未开放")
+}
+
+func backupItem(backup mysqlinstance.Backup) webdto.InstanceBackupItem {
+	return webdto.InstanceBackupItem{BackupNo: backup.BackupNo, Source: backup.Source, Status: backup.Status, Note: backup.Note, TemplateNo: backup.TemplateNo, TemplateName: backup.TemplateName, SystemDiskGB: backup.SystemDiskGB, DataDiskGB: backup.DataDiskGB, CompletedAt: backup.CompletedAt, LastRestoredAt: backup.LastRestoredAt, CreatedAt: backup.CreatedAt}
+}
+
+func backupPolicyItem(policy mysqlinstance.BackupPolicy) webdto.InstanceBackupPolicy {
+	return webdto.InstanceBackupPolicy{Frequency: policy.Frequency, Weekday: policy.Weekday, Hour: policy.Hour, RetentionCount: policy.RetentionCount, Enabled: policy.Enabled, NextRunAt: policy.NextRunAt, LastRunAt: policy.LastRunAt}
+}
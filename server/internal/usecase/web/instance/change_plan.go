@@ -0,0 +1,188 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqlorder "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/order"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
+)
+
+// changePlanQuote 是变更套餐报价的内部结果，目标套餐快照用于生成订单。
+type changePlanQuote struct {
+	selection    mysqlorder.CatalogSelection
+	currentCents uint64
+	chargeCents  uint64
+	creditCents  uint64
+}
+
+// ChangePlanQuote 按实例当前计费周期和剩余服务期计算变更到目标套餐的差价。
+func (s *Service) ChangePlanQuote(ctx context.Context, userID uint64, instanceNo string, query webdto.ChangePlanQuoteQuery) (webdto.ChangePlanQuote, error) {
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.ChangePlanQuote{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return webdto.ChangePlanQuote{}, err
+	}
+	quote, err := s.quoteChangePlan(ctx, nil, row, strings.TrimSpace(query.PlanNo), time.Now())
+	if err != nil {
+		return webdto.ChangePlanQuote{}, err
+	}
+	return changePlanQuoteDTO(row, quote), nil
+}
+
+// CreateChangePlanOrder 创建变更套餐订单。升配订单待支付后生效；差价为 0 或降配时订单直接进入交付，
+// 规格调整成功后再把降配差价退还到钱包。
+func (s *Service) CreateChangePlanOrder(ctx context.Context, userID uint64, instanceNo string, req webdto.ChangePlanOrderCreateRequest) (webdto.OrderDetail, error) {
+	clientToken := strings.TrimSpace(req.ClientToken)
+	instanceNo = strings.TrimSpace(instanceNo)
+	if existing, err := s.orders.FindByUserClientToken(ctx, userID, clientToken); err == nil {
+		return changePlanIdempotentOrder(existing, instanceNo)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.OrderDetail{}, err
+	}
+	var created mysqlorder.Order
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, instanceNo)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && current.UserID != userID) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := s.orders.ActiveChangePlanByInstanceNo(ctx, tx, current.InstanceNo); err == nil {
+			return apperrors.ErrConflict.WithMessage("实例已有未完成的变更套餐订单")
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if _, err := s.orders.PendingRenewalByInstanceNo(ctx, tx, current.InstanceNo); err == nil {
+			return apperrors.ErrConflict.WithMessage("实例有待支付的续费订单，请先支付或取消")
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		now := time.Now().Truncate(time.Millisecond)
+		quote, err := s.quoteChangePlan(ctx, tx, current, strings.TrimSpace(req.PlanNo), now)
+		if err != nil {
+			return err
+		}
+		created = changePlanOrderFromQuote(userID, current, clientToken, quote)
+		if quote.chargeCents == 0 {
+			created.Status = domainorder.StatusProvisioning
+			created.PaymentStatus = domainorder.PaymentStatusPaid
+			created.PaidAt = &now
+		}
+		if err := s.orders.Create(ctx, tx, &created); err != nil {
+			return err
+		}
+		if quote.chargeCents == 0 {
+			return s.enqueueChangePlan(ctx, tx, created.OrderNo, now)
+		}
+		return nil
+	})
+	if err != nil {
+		if existing, findErr := s.orders.FindByUserClientToken(ctx, userID, clientToken); findErr == nil {
+			return changePlanIdempotentOrder(existing, instanceNo)
+		}
+		return webdto.OrderDetail{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "order", "order.change_plan.create", "order", created.OrderNo, fmt.Sprintf("创建变更套餐订单：%s → %s", value(created.ChangeFromPlanNo), created.PlanNo))
+	order, err := s.orders.FindByOrderNo(ctx, created.OrderNo)
+	if err != nil {
+		return webdto.OrderDetail{}, err
+	}
+	return webOrderDetail(order), nil
+}
+
+func (s *Service) quoteChangePlan(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance, planNo string, now time.Time) (changePlanQuote, error) {
+	if !domaininstance.CanResize(current.Status) {
+		return changePlanQuote{}, apperrors.ErrConflict.WithMessage("当前实例状态不能变更套餐")
+	}
+	if current.ExpiresAt == nil || !current.ExpiresAt.After(now) {
+		return changePlanQuote{}, apperrors.ErrConflict.WithMessage("实例已到期，请先续费")
+	}
+	if planNo == current.PlanNo {
+		return changePlanQuote{}, apperrors.ErrValidation.WithMessage("目标套餐与当前套餐相同")
+	}
+	purchase, err := s.orders.FindByOrderNo(ctx, current.OrderNo)
+	if err != nil {
+		return changePlanQuote{}, err
+	}
+	months, ok := domainorder.BillingCycleMonths(purchase.BillingCycle)
+	if !ok {
+		return changePlanQuote{}, apperrors.ErrValidation.WithMessage("订单周期不支持")
+	}
+	networkTypeNo := value(current.NetworkTypeNo)
+	target, err := s.orders.CatalogSelection(ctx, planNo, purchase.BillingCycle, current.RegionNo, current.TemplateNo, networkTypeNo)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return changePlanQuote{}, apperrors.ErrValidation.WithMessage("目标套餐在当前地域、网络类型或系统模板下不可用")
+	}
+	if err != nil {
+		return changePlanQuote{}, err
+	}
+	if target.ProductNo != current.ProductNo {
+		return changePlanQuote{}, apperrors.ErrValidation.WithMessage("只能变更为同一产品下的套餐")
+	}
+	if target.SystemDiskGB != current.SystemDiskGB || target.DataDiskGB != current.DataDiskGB {
+		return changePlanQuote{}, apperrors.ErrValidation.WithMessage("目标套餐磁盘规格与当前实例不同，暂不支持变更")
+	}
+	if _, err := s.instances.MappingForProvision(ctx, tx, planNo, current.RegionNo, current.TemplateNo, networkTypeNo); errors.Is(err, gorm.ErrRecordNotFound) {
+		return changePlanQuote{}, apperrors.ErrConflict.WithMessage("目标套餐暂不可变更，请联系客服")
+	} else if err != nil {
+		return changePlanQuote{}, err
+	}
+	currentCents := purchase.PriceCents
+	if selection, err := s.orders.CatalogSelection(ctx, current.PlanNo, purchase.BillingCycle, current.RegionNo, current.TemplateNo, networkTypeNo); err == nil {
+		currentCents = selection.PriceCents
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return changePlanQuote{}, err
+	} else if purchase.PlanNo != current.PlanNo {
+		// 套餐下架且实例已变更过套餐时，新购订单价格不再代表当前套餐，无法可靠折算。
+		return changePlanQuote{}, apperrors.ErrConflict.WithMessage("当前套餐价格不可用，暂不能变更")
+	}
+	charge, credit := domainorder.ProratedChange(currentCents, target.PriceCents, months, now, *current.ExpiresAt)
+	return changePlanQuote{selection: target, currentCents: currentCents, chargeCents: charge, creditCents: credit}, nil
+}
+
+func (s *Service) enqueueChangePlan(ctx context.Context, tx *gorm.DB, orderNo string, now time.Time) error {
+	objectType := "order"
+	objectNo := orderNo
+	key := domaininstance.TaskTypeChangePlan + ":" + orderNo
+	payload, _ := json.Marshal(map[string]string{"order_no": orderNo})
+	task := mysqlinstance.Task{TaskNo: fmt.Sprintf("TASK-%d", time.Now().UnixNano()), TaskType: domaininstance.TaskTypeChangePlan, IdempotencyKey: &key, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &objectNo, Payload: stringPtr(string(payload)), MaxAttempts: 10, ScheduledAt: now}
+	return s.instances.CreateTaskIgnoreDuplicate(ctx, tx, &task)
+}
+
+func changePlanIdempotentOrder(existing mysqlorder.Order, instanceNo string) (webdto.OrderDetail, error) {
+	if existing.OrderType == domainorder.TypeChangePlan && existing.RelatedInstanceNo != nil && strings.TrimSpace(*existing.RelatedInstanceNo) == instanceNo {
+		return webOrderDetail(existing), nil
+	}
+	return webdto.OrderDetail{}, apperrors.ErrConflict.WithMessage("幂等键已被其它订单使用")
+}
+
+func changePlanOrderFromQuote(userID uint64, current mysqlinstance.Instance, clientToken string, quote changePlanQuote) mysqlorder.Order {
+	order := renewalOrderFromSelection(userID, current.InstanceNo, clientToken, quote.selection)
+	fromPlanNo := current.PlanNo
+	order.OrderType = domainorder.TypeChangePlan
+	order.ChangeFromPlanNo = &fromPlanNo
+	order.TotalAmountCents = quote.chargeCents
+	order.CreditAmountCents = quote.creditCents
+	return order
+}
+
+func changePlanQuoteDTO(row mysqlinstance.Instance, quote changePlanQuote) webdto.ChangePlanQuote {
+	selection := quote.selection
+	return webdto.ChangePlanQuote{InstanceNo: row.InstanceNo, CurrentPlanNo: row.PlanNo, PlanNo: selection.PlanNo, PlanName: selection.PlanName, CPUCores: selection.CPUCores, MemoryMB: selection.MemoryMB, BandwidthMbps: selection.BandwidthMbps, BillingCycle: selection.BillingCycle, CurrentPriceCents: quote.currentCents, TargetPriceCents: selection.PriceCents, ChargeAmountCents: quote.chargeCents, CreditAmountCents: quote.creditCents, Currency: selection.Currency, ExpiresAt: row.ExpiresAt}
+}
@@ -0,0 +1,170 @@
+package instance
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/cache"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
+)
+
+// consoleSession 是 Redis 中暂存的控制台会话，只能被 WebSocket 连接消费一次。
+type consoleSession struct {
+	UserID     uint64               `json:"user_id"`
+	InstanceNo string               `json:"instance_no"`
+	ClusterNo  string               `json:"cluster_no"`
+	Node       string               `json:"node"`
+	VMID       uint                 `json:"vmid"`
+	Type       string               `json:"type"`
+	Ticket     mcppve.ConsoleTicket `json:"ticket"`
+}
+
+// ConsoleConnection 是已建立的上游控制台连接，Deadline 为本次会话的最长保持时间。
+type ConsoleConnection struct {
+	Upstream   io.ReadWriteCloser
+	UserID     uint64
+	InstanceNo string
+	Type       string
+	Deadline   time.Time
+}
+
+// SetConsole 注入控制台会话存储和时长配置；未注入时控制台接口返回冲突错误。
+func (s *Service) SetConsole(redis *cache.Redis, cfg config.ConsoleConfig) *Service {
+	s.redis = redis
+	s.console = cfg
+	return s
+}
+
+// CreateConsole 为运行中的实例申请控制台票据，并签发一次性 WebSocket 会话令牌。
+func (s *Service) CreateConsole(ctx context.Context, userID uint64, instanceNo string, req webdto.InstanceConsoleRequest) (webdto.InstanceConsoleSession, error) {
+	if s.redis == nil || !s.console.Enabled {
+		return webdto.InstanceConsoleSession{}, apperrors.ErrConflict.WithMessage("控制台暂未开放")
+	}
+	consoleType := strings.TrimSpace(req.Type)
+	if consoleType == "" {
+		consoleType = domaininstance.ConsoleTypeVNC
+	}
+	if !domaininstance.IsKnownConsoleType(consoleType) {
+		return webdto.InstanceConsoleSession{}, apperrors.ErrValidation.WithMessage("控制台类型不支持")
+	}
+	row, err := s.consoleInstance(ctx, userID, instanceNo)
+	if err != nil {
+		return webdto.InstanceConsoleSession{}, err
+	}
+	ticket, err := s.mcp.Cluster(row.ClusterNo).CreateConsoleTicket(ctx, row.ExternalNode, row.ExternalVMID, consoleType)
+	if err != nil {
+		return webdto.InstanceConsoleSession{}, externalError(err)
+	}
+	token, err := newConsoleToken()
+	if err != nil {
+		return webdto.InstanceConsoleSession{}, err
+	}
+	data, err := json.Marshal(consoleSession{UserID: userID, InstanceNo: row.InstanceNo, ClusterNo: row.ClusterNo, Node: row.ExternalNode, VMID: row.ExternalVMID, Type: consoleType, Ticket: ticket})
+	if err != nil {
+		return webdto.InstanceConsoleSession{}, err
+	}
+	if err := s.redis.Client().Set(ctx, s.consoleKey(token), data, s.console.ConnectTTL()).Err(); err != nil {
+		return webdto.InstanceConsoleSession{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.console.open", "instance", row.InstanceNo, "申请实例控制台："+consoleType)
+	return webdto.InstanceConsoleSession{
+		Type:               consoleType,
+		WebsocketPath:      "/api/instance-consoles/" + token,
+		Password:           ticket.Ticket,
+		ExpiresAt:          time.Now().Add(s.console.ConnectTTL()),
+		MaxDurationSeconds: s.console.MaxDurationSeconds,
+	}, nil
+}
+
+// ConnectConsole 消费会话令牌并连接上游控制台；令牌过期、已使用或实例已不可连接时拒绝。
+func (s *Service) ConnectConsole(ctx context.Context, token string) (ConsoleConnection, error) {
+	if s.redis == nil || !s.console.Enabled {
+		return ConsoleConnection{}, apperrors.ErrConflict.WithMessage("控制台暂未开放")
+	}
+	raw, err := s.redis.Client().GetDel(ctx, s.consoleKey(strings.TrimSpace(token))).Result()
+	if errors.Is(err, goredis.Nil) {
+		return ConsoleConnection{}, apperrors.ErrUnauthorized.WithMessage("控制台会话已过期，请重新打开")
+	}
+	if err != nil {
+		return ConsoleConnection{}, err
+	}
+	var session consoleSession
+	if err := json.Unmarshal([]byte(raw), &session); err != nil {
+		return ConsoleConnection{}, apperrors.ErrUnauthorized.WithMessage("控制台会话无效")
+	}
+	row, err := s.consoleInstance(ctx, session.UserID, session.InstanceNo)
+	if err != nil {
+		return ConsoleConnection{}, err
+	}
+	if row.ExternalNode != session.Node || row.ExternalVMID != session.VMID {
+		return ConsoleConnection{}, apperrors.ErrConflict.WithMessage("实例位置已变化，请重新打开控制台")
+	}
+	upstream, err := s.mcp.Cluster(session.ClusterNo).DialConsole(ctx, session.Node, session.VMID, session.Ticket)
+	if err != nil {
+		return ConsoleConnection{}, externalError(err)
+	}
+	return ConsoleConnection{Upstream: upstream, UserID: session.UserID, InstanceNo: session.InstanceNo, Type: session.Type, Deadline: time.Now().Add(s.console.MaxDuration())}, nil
+}
+
+// ProxyConsole 在浏览器连接和上游控制台之间转发数据，到达单次会话时长上限时断开并记录会话结束。
+func (s *Service) ProxyConsole(ctx context.Context, client io.ReadWriteCloser, conn ConsoleConnection) {
+	startedAt := time.Now()
+	proxyCtx, cancel := context.WithDeadline(ctx, conn.Deadline)
+	defer cancel()
+	_ = mcppve.ProxyConsole(proxyCtx, client, conn.Upstream)
+	s.closeConsole(context.WithoutCancel(ctx), conn, startedAt)
+}
+
+func (s *Service) closeConsole(ctx context.Context, conn ConsoleConnection, startedAt time.Time) {
+	duration := time.Since(startedAt).Truncate(time.Second)
+	summary := fmt.Sprintf("关闭实例控制台：%s，持续 %s", conn.Type, duration)
+	if !time.Now().Before(conn.Deadline) {
+		summary += "，已达单次会话时长上限"
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(conn.UserID, "", ""), "instance", "instance.console.close", "instance", conn.InstanceNo, summary)
+}
+
+func (s *Service) consoleInstance(ctx context.Context, userID uint64, instanceNo string) (mysqlinstance.Instance, error) {
+	if !s.mcp.Enabled() {
+		return mysqlinstance.Instance{}, mcpUnavailableError()
+	}
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return mysqlinstance.Instance{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return mysqlinstance.Instance{}, err
+	}
+	if !domaininstance.CanOpenConsole(row.Status) {
+		return mysqlinstance.Instance{}, apperrors.ErrConflict.WithMessage("实例未运行，无法打开控制台")
+	}
+	return row, nil
+}
+
+func (s *Service) consoleKey(token string) string {
+	return s.redis.Key("web", "instance_console", token)
+}
+
+func newConsoleToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,90 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/password"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/secretbox"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
+)
+
+// SetCredentialConfig 注入实例凭据加密配置；未配置加密密钥时查看和重置密码接口返回冲突错误。
+func (s *Service) SetCredentialConfig(cfg config.CredentialConfig) *Service {
+	s.credentials, _ = secretbox.New(cfg.EncryptionKey)
+	s.passwordLength = cfg.RootPasswordLength
+	return s
+}
+
+// RevealRootPassword 返回实例 root 密码，每个密码只能查看一次；查看后如遗忘只能重置。
+func (s *Service) RevealRootPassword(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceRootPassword, error) {
+	if s.credentials == nil {
+		return webdto.InstanceRootPassword{}, apperrors.ErrConflict.WithMessage("实例密码服务暂未开放")
+	}
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.InstanceRootPassword{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return webdto.InstanceRootPassword{}, err
+	}
+	if row.RootPasswordCiphertext == nil {
+		return webdto.InstanceRootPassword{}, apperrors.ErrNotFound.WithMessage("实例没有可查看的密码")
+	}
+	if row.RootPasswordRevealedAt != nil {
+		return webdto.InstanceRootPassword{}, apperrors.ErrConflict.WithMessage("密码已查看过，如遗忘请重置密码")
+	}
+	// 先解密再标记已查看，避免密钥变更导致密码被标记后再也无法取回。
+	plain, err := s.credentials.Open(*row.RootPasswordCiphertext)
+	if err != nil {
+		return webdto.InstanceRootPassword{}, apperrors.ErrConflict.WithMessage("密码无法读取，请重置密码")
+	}
+	now := time.Now()
+	affected, err := s.instances.MarkRootPasswordRevealed(ctx, nil, row.ID, now)
+	if err != nil {
+		return webdto.InstanceRootPassword{}, err
+	}
+	if affected == 0 {
+		return webdto.InstanceRootPassword{}, apperrors.ErrConflict.WithMessage("密码已查看过，如遗忘请重置密码")
+	}
+	_ = s.logs.SecurityNoTx(ctx, weblogging.Snapshot(userID, "", ""), "", "instance.password.reveal", "success", "查看实例密码："+row.InstanceNo)
+	return webdto.InstanceRootPassword{InstanceNo: row.InstanceNo, Password: plain, RevealedAt: now}, nil
+}
+
+// ResetPassword 生成新的随机密码并通过 guest agent 下发；操作成功后新密码可查看一次。
+func (s *Service) ResetPassword(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceDetail, error) {
+	if s.credentials == nil {
+		return webdto.InstanceDetail{}, apperrors.ErrConflict.WithMessage("实例密码服务暂未开放")
+	}
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		plain, err := password.Generate(s.passwordLength)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		sealed, err := s.credentials.Seal(plain)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		req := mcppve.SetVMPasswordRequest{Password: plain}
+		return operationPlan{payload: mysqlinstance.ResetPasswordPayload{PasswordCiphertext: sealed}, call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).SetVMPassword(ctx, row.ExternalNode, row.ExternalVMID, req)
+		}}, nil
+	}
+	detail, err := s.startOperation(ctx, userID, instanceNo, domaininstance.OperationResetPassword, planner)
+	if err != nil {
+		_ = s.logs.SecurityNoTx(ctx, weblogging.Snapshot(userID, "", ""), "", "instance.password.reset", "failed", "重置实例密码失败："+strings.TrimSpace(instanceNo))
+		return webdto.InstanceDetail{}, err
+	}
+	_ = s.logs.SecurityNoTx(ctx, weblogging.Snapshot(userID, "", ""), "", "instance.password.reset", "success", "重置实例密码："+detail.InstanceNo)
+	return detail, nil
+}
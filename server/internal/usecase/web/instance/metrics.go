@@ -0,0 +1,102 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/cache"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+)
+
+const bytesPerMB = 1 << 20
+
+// SetMetrics 注入监控缓存和采样配置；未注入 Redis 时每次请求直接读取上游。
+func (s *Service) SetMetrics(redis *cache.Redis, cfg config.MetricsConfig) *Service {
+	s.redis = redis
+	s.metrics = cfg
+	return s
+}
+
+// Metrics 返回用户实例在指定时间范围内的性能采样，range 为空时按最近一小时返回。
+func (s *Service) Metrics(ctx context.Context, userID uint64, instanceNo string, query webdto.InstanceMetricsQuery) (webdto.InstanceMetrics, error) {
+	metricsRange := strings.TrimSpace(query.Range)
+	if metricsRange == "" {
+		metricsRange = domaininstance.MetricsRangeHour
+	}
+	if !domaininstance.IsKnownMetricsRange(metricsRange) {
+		return webdto.InstanceMetrics{}, apperrors.ErrValidation.WithMessage("监控时间范围不支持")
+	}
+	if !s.mcp.Enabled() {
+		return webdto.InstanceMetrics{}, mcpUnavailableError()
+	}
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.InstanceMetrics{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return webdto.InstanceMetrics{}, err
+	}
+	if row.Status == domaininstance.StatusReleased || row.ExternalVMID == 0 {
+		return webdto.InstanceMetrics{}, apperrors.ErrConflict.WithMessage("实例未交付或已释放，暂无监控数据")
+	}
+	points, err := s.vmMetrics(ctx, row.ClusterNo, row.ExternalNode, row.ExternalVMID, metricsRange)
+	if err != nil {
+		return webdto.InstanceMetrics{}, err
+	}
+	return webdto.InstanceMetrics{Range: metricsRange, Points: metricPoints(points), GeneratedAt: time.Now()}, nil
+}
+
+// vmMetrics 优先读取短期缓存；缓存读写失败不影响返回，只回退到上游查询。
+func (s *Service) vmMetrics(ctx context.Context, clusterNo string, node string, vmid uint, metricsRange string) ([]domaininstance.MetricPoint, error) {
+	var key string
+	if s.redis != nil && s.metrics.CacheTTLSeconds > 0 {
+		key = s.redis.Key("metrics", "vm", clusterNo, node, strconv.FormatUint(uint64(vmid), 10), metricsRange)
+		if raw, err := s.redis.Client().Get(ctx, key).Bytes(); err == nil {
+			var cached []domaininstance.MetricPoint
+			if json.Unmarshal(raw, &cached) == nil {
+				return cached, nil
+			}
+		}
+	}
+	rows, err := s.mcp.Cluster(clusterNo).VMMetrics(ctx, node, vmid, metricsRange)
+	if err != nil {
+		return nil, externalError(err)
+	}
+	points := make([]domaininstance.MetricPoint, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, domaininstance.MetricPoint{
+			Time:          row.Time,
+			CPUPercent:    domaininstance.ScaleMetric(row.CPU, 100),
+			MemoryUsedMB:  domaininstance.ScaleMetric(row.Mem, 1.0/bytesPerMB),
+			MemoryTotalMB: domaininstance.ScaleMetric(row.MaxMem, 1.0/bytesPerMB),
+			DiskReadBps:   row.DiskRead,
+			DiskWriteBps:  row.DiskWrite,
+			NetInBps:      row.NetIn,
+			NetOutBps:     row.NetOut,
+		})
+	}
+	points = domaininstance.DownsampleMetrics(points, s.metrics.MaxPoints)
+	if key != "" {
+		if data, err := json.Marshal(points); err == nil {
+			_ = s.redis.Client().Set(ctx, key, data, s.metrics.CacheTTL()).Err()
+		}
+	}
+	return points, nil
+}
+
+func metricPoints(points []domaininstance.MetricPoint) []webdto.MetricPoint {
+	items := make([]webdto.MetricPoint, 0, len(points))
+	for _, point := range points {
+		items = append(items, webdto.MetricPoint{Time: time.Unix(point.Time, 0), CPUPercent: point.CPUPercent, MemoryUsedMB: point.MemoryUsedMB, MemoryTotalMB: point.MemoryTotalMB, DiskReadBps: point.DiskReadBps, DiskWriteBps: point.DiskWriteBps, NetInBps: point.NetInBps, NetOutBps: point.NetOutBps})
+	}
+	return items
+}
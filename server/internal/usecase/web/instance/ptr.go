@@ -0,0 +1,205 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/rdns"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
+)
+
+// SetRDNS 注入 PTR 管理配置、DNS 后端和正向解析器；resolver 为空时使用系统解析器。
+func (s *Service) SetRDNS(cfg config.RDNSConfig, backend rdns.Backend, resolver rdns.Resolver) *Service {
+	if resolver == nil {
+		resolver = rdns.DefaultResolver()
+	}
+	s.rdns, s.dns, s.resolver = cfg, backend, resolver
+	return s
+}
+
+// InstancePTR 返回实例当前占用的全部地址及其 PTR 状态。
+func (s *Service) InstancePTR(ctx context.Context, userID uint64, instanceNo string) (webdto.InstancePTR, error) {
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.InstancePTR{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return webdto.InstancePTR{}, err
+	}
+	return s.instancePTR(ctx, row.ID, row.InstanceNo)
+}
+
+// UpdateInstancePTR 为实例地址设置 PTR 主机名。主机名必须正向解析到该地址；
+// 开启自动写入时立即写入 DNS，否则进入后台审批队列，审批前已生效的旧记录保持不变。
+func (s *Service) UpdateInstancePTR(ctx context.Context, userID uint64, instanceNo string, req webdto.InstancePTRRequest) (webdto.InstancePTR, error) {
+	if !s.rdns.Enabled || s.dns == nil {
+		return webdto.InstancePTR{}, apperrors.ErrConflict.WithMessage("暂未开放 PTR 设置")
+	}
+	hostname, err := domaininstance.NormalizePTRHostname(req.Hostname)
+	if err != nil {
+		return webdto.InstancePTR{}, apperrors.ErrValidation.WithMessage("主机名格式不正确")
+	}
+	address, err := netip.ParseAddr(strings.TrimSpace(req.Address))
+	if err != nil {
+		return webdto.InstancePTR{}, apperrors.ErrValidation.WithMessage("IP 地址格式不正确")
+	}
+	row, target, err := s.ptrTarget(ctx, userID, instanceNo, address.Unmap().String())
+	if err != nil {
+		return webdto.InstancePTR{}, err
+	}
+	resolved, err := s.resolver.LookupHost(ctx, hostname)
+	if err != nil || !domaininstance.PTRForwardConfirmed(target.Address, resolved) {
+		return webdto.InstancePTR{}, apperrors.ErrValidation.WithMessage("主机名未正向解析到该地址，请先添加 A/AAAA 记录")
+	}
+	status := domaininstance.PTRStatusPendingReview
+	if s.rdns.AutoApply {
+		status = domaininstance.PTRStatusApplying
+	}
+	var record mysqlinstance.PTRRecord
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.PTRRecordByAddressForUpdate(ctx, tx, target.ID)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			record = mysqlinstance.PTRRecord{IPAddressID: target.ID, Address: target.Address, InstanceID: row.ID, InstanceNo: row.InstanceNo, UserID: userID, Hostname: hostname, Status: status}
+			if err := s.instances.CreatePTRRecord(ctx, tx, &record); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			if current.Hostname == hostname && current.Status == domaininstance.PTRStatusActive {
+				record = current
+				return nil
+			}
+			if err := s.instances.UpdatePTRRecord(ctx, tx, current.ID, map[string]any{"hostname": hostname, "status": status, "last_error": nil, "reviewed_by": nil, "reviewed_at": nil, "review_remark": nil}); err != nil {
+				return err
+			}
+			record = current
+			record.Hostname, record.Status = hostname, status
+		}
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "instance", "instance.ptr.update", "instance", row.InstanceNo, "设置 PTR："+target.Address+" -> "+hostname)
+	})
+	if err != nil {
+		return webdto.InstancePTR{}, err
+	}
+	if record.Status == domaininstance.PTRStatusApplying {
+		s.applyPTR(ctx, record)
+	}
+	return s.instancePTR(ctx, row.ID, row.InstanceNo)
+}
+
+// DeleteInstancePTR 删除实例地址的 PTR；已写入 DNS 的记录先从 DNS 后端删除，删除失败时保留本地记录。
+func (s *Service) DeleteInstancePTR(ctx context.Context, userID uint64, instanceNo string, address string) (webdto.InstancePTR, error) {
+	if !s.rdns.Enabled || s.dns == nil {
+		return webdto.InstancePTR{}, apperrors.ErrConflict.WithMessage("暂未开放 PTR 设置")
+	}
+	parsed, err := netip.ParseAddr(strings.TrimSpace(address))
+	if err != nil {
+		return webdto.InstancePTR{}, apperrors.ErrValidation.WithMessage("IP 地址格式不正确")
+	}
+	row, target, err := s.ptrTarget(ctx, userID, instanceNo, parsed.Unmap().String())
+	if err != nil {
+		return webdto.InstancePTR{}, err
+	}
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.PTRRecordByAddressForUpdate(ctx, tx, target.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("该地址未设置 PTR")
+		}
+		if err != nil {
+			return err
+		}
+		if current.AppliedHostname != nil {
+			if err := s.dns.DeletePTR(ctx, current.Address); err != nil {
+				return apperrors.ErrExternalUnavailable.WithMessage("DNS 服务暂不可用，请稍后重试")
+			}
+		}
+		if err := s.instances.DeletePTRRecord(ctx, tx, current.ID); err != nil {
+			return err
+		}
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "instance", "instance.ptr.delete", "instance", row.InstanceNo, "删除 PTR："+current.Address)
+	})
+	if err != nil {
+		return webdto.InstancePTR{}, err
+	}
+	return s.instancePTR(ctx, row.ID, row.InstanceNo)
+}
+
+// ptrTarget 校验实例归属和状态，返回实例当前占用的指定地址。
+func (s *Service) ptrTarget(ctx context.Context, userID uint64, instanceNo string, address string) (mysqlinstance.Instance, mysqlinstance.InstanceAddress, error) {
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return mysqlinstance.Instance{}, mysqlinstance.InstanceAddress{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return mysqlinstance.Instance{}, mysqlinstance.InstanceAddress{}, err
+	}
+	if row.Status == domaininstance.StatusReleasing || row.Status == domaininstance.StatusReleased {
+		return mysqlinstance.Instance{}, mysqlinstance.InstanceAddress{}, apperrors.ErrConflict.WithMessage("实例已释放，不能设置 PTR")
+	}
+	addresses, err := s.instances.InstanceAddresses(ctx, nil, row.ID)
+	if err != nil {
+		return mysqlinstance.Instance{}, mysqlinstance.InstanceAddress{}, err
+	}
+	for _, item := range addresses {
+		if item.Address == address {
+			return row, item, nil
+		}
+	}
+	return mysqlinstance.Instance{}, mysqlinstance.InstanceAddress{}, apperrors.ErrNotFound.WithMessage("该地址不属于此实例")
+}
+
+// applyPTR 把记录写入 DNS 后端并保存结果；失败时标记 failed，由用户重新提交或管理员重试。
+func (s *Service) applyPTR(ctx context.Context, record mysqlinstance.PTRRecord) {
+	if err := s.dns.SetPTR(ctx, record.Address, record.Hostname); err != nil {
+		_ = s.instances.UpdatePTRRecord(ctx, nil, record.ID, map[string]any{"status": domaininstance.PTRStatusFailed, "last_error": ptrErrorMessage(err)})
+		return
+	}
+	_ = s.instances.UpdatePTRRecord(ctx, nil, record.ID, map[string]any{"status": domaininstance.PTRStatusActive, "applied_hostname": record.Hostname, "applied_at": time.Now(), "last_error": nil})
+}
+
+func (s *Service) instancePTR(ctx context.Context, instanceID uint64, instanceNo string) (webdto.InstancePTR, error) {
+	addresses, err := s.instances.InstanceAddresses(ctx, nil, instanceID)
+	if err != nil {
+		return webdto.InstancePTR{}, err
+	}
+	records, err := s.instances.InstancePTRRecords(ctx, nil, instanceID)
+	if err != nil {
+		return webdto.InstancePTR{}, err
+	}
+	byAddress := make(map[uint64]mysqlinstance.PTRRecord, len(records))
+	for _, record := range records {
+		byAddress[record.IPAddressID] = record
+	}
+	items := make([]webdto.PTRRecord, 0, len(addresses))
+	for _, address := range addresses {
+		item := webdto.PTRRecord{Address: address.Address}
+		if record, ok := byAddress[address.ID]; ok {
+			hostname, status, updatedAt := record.Hostname, record.Status, record.UpdatedAt
+			item.Hostname, item.Status, item.UpdatedAt = &hostname, &status, &updatedAt
+			item.AppliedHostname, item.LastError, item.ReviewRemark = record.AppliedHostname, record.LastError, record.ReviewRemark
+		}
+		items = append(items, item)
+	}
+	return webdto.InstancePTR{InstanceNo: instanceNo, Enabled: s.rdns.Enabled && s.dns != nil, AutoApply: s.rdns.AutoApply, Records: items}, nil
+}
+
+// ptrErrorMessage 截断 DNS 后端错误，避免超出字段长度。
+func ptrErrorMessage(err error) string {
+	message := strings.TrimSpace(err.Error())
+	if runes := []rune(message); len(runes) > 200 {
+		message = string(runes[:200])
+	}
+	return message
+}
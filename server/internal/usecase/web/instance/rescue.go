@@ -0,0 +1,193 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/password"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
+)
+
+// SetRescueConfig 注入救援模式配置；临时密码依赖凭据加密密钥，未配置时救援模式不可用。
+func (s *Service) SetRescueConfig(cfg config.RescueConfig) *Service {
+	s.rescue = cfg
+	return s
+}
+
+// ISOs 返回镜像库中启用的救援介质和安装镜像。
+func (s *Service) ISOs(ctx context.Context) ([]webdto.ISOItem, error) {
+	rows, _, err := s.instances.ListISOs(ctx, mysqlinstance.ISOFilters{Status: domaininstance.ISOStatusActive}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]webdto.ISOItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, webdto.ISOItem{ISONo: row.ISONo, Name: row.Name, Purpose: row.Purpose, OSFamily: row.OSFamily, Description: row.Description})
+	}
+	return items, nil
+}
+
+// EnterRescue 以救援介质引导实例并生成临时密码，到时由 Worker 自动退出救援。
+func (s *Service) EnterRescue(ctx context.Context, userID uint64, instanceNo string, req webdto.InstanceRescueRequest) (webdto.InstanceDetail, error) {
+	if !s.rescue.Enabled || s.credentials == nil {
+		return webdto.InstanceDetail{}, apperrors.ErrConflict.WithMessage("救援模式暂未开放")
+	}
+	detail, err := s.startOperation(ctx, userID, instanceNo, domaininstance.OperationRescueEnter, s.rescuePlanner(req.ISONo))
+	if err != nil {
+		return webdto.InstanceDetail{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.rescue_enter", "instance", detail.InstanceNo, "进入救援模式")
+	return detail, nil
+}
+
+func (s *Service) ExitRescue(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceDetail, error) {
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		if current.RescueStartedAt == nil {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("实例未处于救援模式")
+		}
+		return operationPlan{call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).ExitRescue(ctx, row.ExternalNode, row.ExternalVMID)
+		}}, nil
+	}
+	detail, err := s.startOperation(ctx, userID, instanceNo, domaininstance.OperationRescueExit, planner)
+	if err != nil {
+		return webdto.InstanceDetail{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.rescue_exit", "instance", detail.InstanceNo, "退出救援模式")
+	return detail, nil
+}
+
+// Rescue 返回实例救援状态；救援期间临时密码可重复查看，每次查看都写安全日志。
+func (s *Service) Rescue(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceRescue, error) {
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.InstanceRescue{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return webdto.InstanceRescue{}, err
+	}
+	result := webdto.InstanceRescue{InstanceNo: row.InstanceNo}
+	state, _, err := s.rescueState(ctx, row)
+	if err != nil {
+		return webdto.InstanceRescue{}, err
+	}
+	if state == nil {
+		return result, nil
+	}
+	result.Active = true
+	result.InstanceRescueState = *state
+	if row.RescuePasswordCiphertext != nil && s.credentials != nil {
+		plain, err := s.credentials.Open(*row.RescuePasswordCiphertext)
+		if err != nil {
+			return webdto.InstanceRescue{}, apperrors.ErrConflict.WithMessage("救援密码无法读取，请退出后重新进入救援")
+		}
+		result.Password = plain
+		_ = s.logs.SecurityNoTx(ctx, weblogging.Snapshot(userID, "", ""), "", "instance.rescue.password.view", "success", "查看救援密码："+row.InstanceNo)
+	}
+	return result, nil
+}
+
+// MountISO 把镜像库中的安装镜像挂载到实例光驱；救援期间不能挂载，光驱已有镜像时需先弹出。
+func (s *Service) MountISO(ctx context.Context, userID uint64, instanceNo string, req webdto.InstanceISOMountRequest) (webdto.InstanceDetail, error) {
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		if current.MountedISONo != nil {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("光驱中已挂载镜像，请先弹出")
+		}
+		iso, err := s.instances.ActiveISO(ctx, tx, domaininstance.ISOPurposeInstall, strings.TrimSpace(req.ISONo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return operationPlan{}, apperrors.ErrValidation.WithMessage("镜像不存在或已停用")
+		}
+		if err != nil {
+			return operationPlan{}, err
+		}
+		payload := mysqlinstance.ISOPayload{ISONo: iso.ISONo, ISOName: iso.Name, Boot: req.Boot}
+		mountReq := mcppve.MountISORequest{Volume: iso.Volume, Boot: req.Boot}
+		return operationPlan{payload: payload, call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).MountISO(ctx, row.ExternalNode, row.ExternalVMID, mountReq)
+		}}, nil
+	}
+	detail, err := s.startOperation(ctx, userID, instanceNo, domaininstance.OperationISOMount, planner)
+	if err != nil {
+		return webdto.InstanceDetail{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.iso_mount", "instance", detail.InstanceNo, "挂载镜像："+strings.TrimSpace(req.ISONo))
+	return detail, nil
+}
+
+func (s *Service) UnmountISO(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceDetail, error) {
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		if current.MountedISONo == nil {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("光驱中没有挂载镜像")
+		}
+		return operationPlan{call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).UnmountISO(ctx, row.ExternalNode, row.ExternalVMID)
+		}}, nil
+	}
+	detail, err := s.startOperation(ctx, userID, instanceNo, domaininstance.OperationISOUnmount, planner)
+	if err != nil {
+		return webdto.InstanceDetail{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.iso_unmount", "instance", detail.InstanceNo, "弹出镜像")
+	return detail, nil
+}
+
+// rescuePlanner 选择救援介质并生成临时密码；光驱中挂载着镜像时需先弹出。
+func (s *Service) rescuePlanner(isoNo string) operationPlanner {
+	return func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		if current.MountedISONo != nil {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("请先弹出光驱中的镜像")
+		}
+		iso, err := s.instances.ActiveISO(ctx, tx, domaininstance.ISOPurposeRescue, isoNo)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if strings.TrimSpace(isoNo) != "" {
+				return operationPlan{}, apperrors.ErrValidation.WithMessage("救援介质不存在或已停用")
+			}
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("暂无可用的救援介质")
+		}
+		if err != nil {
+			return operationPlan{}, err
+		}
+		plain, err := password.Generate(s.passwordLength)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		sealed, err := s.credentials.Seal(plain)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		payload := mysqlinstance.RescuePayload{ISONo: iso.ISONo, ISOName: iso.Name, PasswordCiphertext: sealed, ExpiresAt: time.Now().Add(s.rescue.Timeout()).Truncate(time.Millisecond)}
+		req := mcppve.RescueVMRequest{Volume: iso.Volume, Media: iso.Media, Password: plain}
+		return operationPlan{payload: payload, call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).EnterRescue(ctx, row.ExternalNode, row.ExternalVMID, req)
+		}}, nil
+	}
+}
+
+func (s *Service) rescueState(ctx context.Context, row mysqlinstance.Instance) (*webdto.InstanceRescueState, *webdto.InstanceMountedISO, error) {
+	if row.RescueStartedAt == nil && row.MountedISONo == nil {
+		return nil, nil, nil
+	}
+	names, err := s.instances.ISONames(ctx, []string{value(row.RescueISONo), value(row.MountedISONo)})
+	if err != nil {
+		return nil, nil, err
+	}
+	var rescue *webdto.InstanceRescueState
+	if row.RescueStartedAt != nil {
+		rescue = &webdto.InstanceRescueState{ISONo: value(row.RescueISONo), ISOName: names[value(row.RescueISONo)], StartedAt: row.RescueStartedAt, ExpiresAt: row.RescueExpiresAt}
+	}
+	var mounted *webdto.InstanceMountedISO
+	if row.MountedISONo != nil {
+		mounted = &webdto.InstanceMountedISO{ISONo: *row.MountedISONo, ISOName: names[*row.MountedISONo]}
+	}
+	return rescue, mounted, nil
+}
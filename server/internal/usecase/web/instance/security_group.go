@@ -0,0 +1,372 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	mysqluser "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/user"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
+)
+
+// SecurityGroups 返回当前用户的安全组、规则和各自绑定的实例数量。
+func (s *Service) SecurityGroups(ctx context.Context, userID uint64) (webdto.SecurityGroupList, error) {
+	groups, err := s.instances.SecurityGroups(ctx, userID)
+	if err != nil {
+		return webdto.SecurityGroupList{}, err
+	}
+	items, err := s.securityGroupItems(ctx, groups)
+	if err != nil {
+		return webdto.SecurityGroupList{}, err
+	}
+	return webdto.SecurityGroupList{Limit: domaininstance.MaxSecurityGroups, List: items}, nil
+}
+
+func (s *Service) SecurityGroup(ctx context.Context, userID uint64, groupNo string) (webdto.SecurityGroupItem, error) {
+	group, err := s.instances.SecurityGroupByNo(ctx, userID, strings.TrimSpace(groupNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.SecurityGroupItem{}, apperrors.ErrNotFound.WithMessage("安全组不存在")
+	}
+	if err != nil {
+		return webdto.SecurityGroupItem{}, err
+	}
+	return s.securityGroupItem(ctx, group)
+}
+
+// CreateSecurityGroup 新增安全组；未提交规则但指定系统族时按系统族预置默认入站规则。
+func (s *Service) CreateSecurityGroup(ctx context.Context, userID uint64, req webdto.SecurityGroupCreateRequest) (webdto.SecurityGroupItem, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return webdto.SecurityGroupItem{}, apperrors.ErrValidation.WithMessage("安全组名称不能为空")
+	}
+	var rules []mysqlinstance.SecurityGroupRule
+	if len(req.Rules) == 0 && strings.TrimSpace(req.OSFamily) != "" {
+		for _, rule := range domaininstance.DefaultSecurityGroupRules(req.OSFamily) {
+			rules = append(rules, securityGroupRuleRow(rule, nil))
+		}
+	} else {
+		normalized, err := normalizeSecurityGroupRules(req.Rules)
+		if err != nil {
+			return webdto.SecurityGroupItem{}, err
+		}
+		rules = normalized
+	}
+	var created mysqlinstance.SecurityGroup
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		// 锁定用户行，串行化同一用户的并发新增，保证数量上限准确。
+		if _, err := mysqluser.NewRepository(s.db).FindUserByIDForUpdate(ctx, tx, userID); errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrUnauthorized
+		} else if err != nil {
+			return err
+		}
+		total, err := s.instances.CountSecurityGroups(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if total >= domaininstance.MaxSecurityGroups {
+			return apperrors.ErrConflict.WithMessage(fmt.Sprintf("最多只能创建 %d 个安全组", domaininstance.MaxSecurityGroups))
+		}
+		created = mysqlinstance.SecurityGroup{GroupNo: fmt.Sprintf("SG-%d", time.Now().UnixNano()), UserID: userID, Name: name, Description: trimmedOptional(req.Description)}
+		if err := s.instances.CreateSecurityGroup(ctx, tx, &created); err != nil {
+			return err
+		}
+		if err := s.instances.ReplaceSecurityGroupRules(ctx, tx, created.ID, rules); err != nil {
+			return err
+		}
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "instance", "security_group.create", "security_group", created.GroupNo, fmt.Sprintf("新增安全组：%s（%d 条规则）", name, len(rules)))
+	})
+	if err != nil {
+		return webdto.SecurityGroupItem{}, err
+	}
+	return s.securityGroupItem(ctx, created)
+}
+
+// UpdateSecurityGroup 整体替换安全组名称、说明和规则，并把新规则下发到所有绑定实例。
+// 下发失败的实例保持 pending，由下次实例同步重试，不影响本次保存结果。
+func (s *Service) UpdateSecurityGroup(ctx context.Context, userID uint64, groupNo string, req webdto.SecurityGroupUpdateRequest) (webdto.SecurityGroupItem, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return webdto.SecurityGroupItem{}, apperrors.ErrValidation.WithMessage("安全组名称不能为空")
+	}
+	rules, err := normalizeSecurityGroupRules(req.Rules)
+	if err != nil {
+		return webdto.SecurityGroupItem{}, err
+	}
+	var group mysqlinstance.SecurityGroup
+	var attached []mysqlinstance.Instance
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.SecurityGroupForUpdate(ctx, tx, userID, strings.TrimSpace(groupNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("安全组不存在")
+		}
+		if err != nil {
+			return err
+		}
+		description := trimmedOptional(req.Description)
+		if err := s.instances.UpdateSecurityGroup(ctx, tx, current.ID, map[string]any{"name": name, "description": description}); err != nil {
+			return err
+		}
+		if err := s.instances.ReplaceSecurityGroupRules(ctx, tx, current.ID, rules); err != nil {
+			return err
+		}
+		attached, err = s.instances.SecurityGroupInstances(ctx, tx, current.ID)
+		if err != nil {
+			return err
+		}
+		for _, row := range attached {
+			if err := s.instances.UpdateInstance(ctx, tx, row.ID, map[string]any{"firewall_status": domaininstance.FirewallStatusPending}); err != nil {
+				return err
+			}
+		}
+		current.Name = name
+		current.Description = description
+		group = current
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "instance", "security_group.update", "security_group", current.GroupNo, fmt.Sprintf("更新安全组：%s（%d 条规则，%d 台实例）", name, len(rules), len(attached)))
+	})
+	if err != nil {
+		return webdto.SecurityGroupItem{}, err
+	}
+	for _, row := range attached {
+		s.applyFirewall(ctx, row)
+	}
+	return s.securityGroupItem(ctx, group)
+}
+
+// DeleteSecurityGroup 删除未绑定任何实例的安全组。
+func (s *Service) DeleteSecurityGroup(ctx context.Context, userID uint64, groupNo string) error {
+	return mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		group, err := s.instances.SecurityGroupForUpdate(ctx, tx, userID, strings.TrimSpace(groupNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrNotFound.WithMessage("安全组不存在")
+		}
+		if err != nil {
+			return err
+		}
+		counts, err := s.instances.SecurityGroupAttachmentCounts(ctx, tx, []uint64{group.ID})
+		if err != nil {
+			return err
+		}
+		if counts[group.ID] > 0 {
+			return apperrors.ErrConflict.WithMessage("安全组仍绑定实例，请先解绑")
+		}
+		if err := s.instances.DeleteSecurityGroup(ctx, tx, group.ID); err != nil {
+			return err
+		}
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "instance", "security_group.delete", "security_group", group.GroupNo, "删除安全组："+group.Name)
+	})
+}
+
+// InstanceFirewall 返回实例绑定的安全组和防火墙下发状态。
+func (s *Service) InstanceFirewall(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceFirewall, error) {
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.InstanceFirewall{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return webdto.InstanceFirewall{}, err
+	}
+	return s.instanceFirewall(ctx, row)
+}
+
+// UpdateInstanceSecurityGroups 整体替换实例绑定的安全组并下发到 VM 防火墙；解绑全部时关闭 VM 防火墙。
+func (s *Service) UpdateInstanceSecurityGroups(ctx context.Context, userID uint64, instanceNo string, req webdto.InstanceSecurityGroupsRequest) (webdto.InstanceFirewall, error) {
+	groupNos := uniqueTrimmed(req.GroupNos)
+	if len(groupNos) > domaininstance.MaxInstanceSecurityGroups {
+		return webdto.InstanceFirewall{}, apperrors.ErrValidation.WithMessage(fmt.Sprintf("单个实例最多绑定 %d 个安全组", domaininstance.MaxInstanceSecurityGroups))
+	}
+	var row mysqlinstance.Instance
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && current.UserID != userID) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if current.Status == domaininstance.StatusReleasing || current.Status == domaininstance.StatusReleased {
+			return apperrors.ErrConflict.WithMessage("实例已释放，不能设置安全组")
+		}
+		if current.ExternalVMID == 0 || current.Status == domaininstance.StatusCreating {
+			return apperrors.ErrConflict.WithMessage("实例尚未交付完成，不能设置安全组")
+		}
+		groups, err := s.instances.SecurityGroupsByNos(ctx, tx, userID, groupNos)
+		if err != nil {
+			return err
+		}
+		if len(groups) != len(groupNos) {
+			return apperrors.ErrNotFound.WithMessage("安全组不存在")
+		}
+		byNo := make(map[string]uint64, len(groups))
+		for _, group := range groups {
+			byNo[group.GroupNo] = group.ID
+		}
+		groupIDs := make([]uint64, 0, len(groupNos))
+		for _, groupNo := range groupNos {
+			groupIDs = append(groupIDs, byNo[groupNo])
+		}
+		if err := s.instances.ReplaceInstanceSecurityGroups(ctx, tx, current.ID, groupIDs); err != nil {
+			return err
+		}
+		if err := s.instances.UpdateInstance(ctx, tx, current.ID, map[string]any{"firewall_status": domaininstance.FirewallStatusPending}); err != nil {
+			return err
+		}
+		row = current
+		summary := "解绑实例全部安全组"
+		if len(groupNos) > 0 {
+			summary = "设置实例安全组：" + strings.Join(groupNos, ",")
+		}
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "instance", "instance.security_groups.update", "instance", current.InstanceNo, summary)
+	})
+	if err != nil {
+		return webdto.InstanceFirewall{}, err
+	}
+	s.applyFirewall(ctx, row)
+	current, err := s.instances.UserInstance(ctx, userID, row.InstanceNo)
+	if err != nil {
+		return webdto.InstanceFirewall{}, err
+	}
+	return s.instanceFirewall(ctx, current)
+}
+
+// applyFirewall 按实例当前绑定的安全组整体替换 VM 防火墙；成功后标记 synced，
+// 失败时保持 pending 等待实例同步重试。已释放或未交付的实例跳过。
+func (s *Service) applyFirewall(ctx context.Context, row mysqlinstance.Instance) {
+	if !s.mcp.Enabled() || row.ExternalVMID == 0 || row.Status == domaininstance.StatusReleasing || row.Status == domaininstance.StatusReleased {
+		return
+	}
+	groups, err := s.instances.InstanceSecurityGroups(ctx, nil, row.ID)
+	if err != nil {
+		return
+	}
+	rules, err := s.instances.InstanceFirewallRules(ctx, nil, row.ID)
+	if err != nil {
+		return
+	}
+	if err := s.mcp.Cluster(row.ClusterNo).SetVMFirewall(ctx, row.ExternalNode, row.ExternalVMID, vmFirewall(len(groups) > 0, rules)); err != nil {
+		return
+	}
+	_ = s.instances.UpdateInstance(ctx, nil, row.ID, map[string]any{"firewall_status": domaininstance.FirewallStatusSynced, "firewall_synced_at": time.Now()})
+}
+
+func (s *Service) instanceFirewall(ctx context.Context, row mysqlinstance.Instance) (webdto.InstanceFirewall, error) {
+	groups, err := s.instances.InstanceSecurityGroups(ctx, nil, row.ID)
+	if err != nil {
+		return webdto.InstanceFirewall{}, err
+	}
+	items, err := s.securityGroupItems(ctx, groups)
+	if err != nil {
+		return webdto.InstanceFirewall{}, err
+	}
+	return webdto.InstanceFirewall{InstanceNo: row.InstanceNo, Status: row.FirewallStatus, SyncedAt: row.FirewallSyncedAt, SecurityGroups: items}, nil
+}
+
+func (s *Service) securityGroupItem(ctx context.Context, group mysqlinstance.SecurityGroup) (webdto.SecurityGroupItem, error) {
+	items, err := s.securityGroupItems(ctx, []mysqlinstance.SecurityGroup{group})
+	if err != nil {
+		return webdto.SecurityGroupItem{}, err
+	}
+	return items[0], nil
+}
+
+func (s *Service) securityGroupItems(ctx context.Context, groups []mysqlinstance.SecurityGroup) ([]webdto.SecurityGroupItem, error) {
+	ids := make([]uint64, 0, len(groups))
+	for _, group := range groups {
+		ids = append(ids, group.ID)
+	}
+	rules, err := s.instances.SecurityGroupRules(ctx, nil, ids)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.instances.SecurityGroupAttachmentCounts(ctx, nil, ids)
+	if err != nil {
+		return nil, err
+	}
+	rulesByGroup := make(map[uint64][]webdto.SecurityGroupRule, len(groups))
+	for _, rule := range rules {
+		rulesByGroup[rule.SecurityGroupID] = append(rulesByGroup[rule.SecurityGroupID], webdto.SecurityGroupRule{Direction: rule.Direction, Protocol: rule.Protocol, PortRange: rule.PortRange, CIDR: rule.CIDR, Description: rule.Description})
+	}
+	items := make([]webdto.SecurityGroupItem, 0, len(groups))
+	for _, group := range groups {
+		groupRules := rulesByGroup[group.ID]
+		if groupRules == nil {
+			groupRules = []webdto.SecurityGroupRule{}
+		}
+		items = append(items, webdto.SecurityGroupItem{GroupNo: group.GroupNo, Name: group.Name, Description: group.Description, Rules: groupRules, InstanceCount: counts[group.ID], CreatedAt: group.CreatedAt, UpdatedAt: group.UpdatedAt})
+	}
+	return items, nil
+}
+
+func normalizeSecurityGroupRules(rules []webdto.SecurityGroupRuleRequest) ([]mysqlinstance.SecurityGroupRule, error) {
+	if len(rules) > domaininstance.MaxSecurityGroupRules {
+		return nil, apperrors.ErrValidation.WithMessage(fmt.Sprintf("单个安全组最多 %d 条规则", domaininstance.MaxSecurityGroupRules))
+	}
+	rows := make([]mysqlinstance.SecurityGroupRule, 0, len(rules))
+	for i, item := range rules {
+		rule, err := domaininstance.NormalizeFirewallRule(domaininstance.FirewallRule{Direction: item.Direction, Protocol: item.Protocol, PortRange: item.PortRange, CIDR: item.CIDR})
+		if err != nil {
+			return nil, apperrors.ErrValidation.WithMessage(fmt.Sprintf("第 %d 条规则：%s", i+1, err.Error()))
+		}
+		rows = append(rows, securityGroupRuleRow(rule, trimmedOptional(item.Description)))
+	}
+	return rows, nil
+}
+
+func securityGroupRuleRow(rule domaininstance.FirewallRule, description *string) mysqlinstance.SecurityGroupRule {
+	return mysqlinstance.SecurityGroupRule{Direction: rule.Direction, Protocol: rule.Protocol, PortRange: nullableString(rule.PortRange), CIDR: rule.CIDR, Description: description}
+}
+
+// vmFirewall 把安全组规则转换为上游防火墙配置；未绑定安全组时关闭防火墙并清空规则。
+func vmFirewall(enabled bool, rows []mysqlinstance.SecurityGroupRule) mcppve.VMFirewall {
+	if !enabled {
+		return mcppve.VMFirewall{PolicyIn: domaininstance.FirewallPolicyAccept, PolicyOut: domaininstance.FirewallPolicyAccept, Rules: []mcppve.FirewallRule{}}
+	}
+	domainRules := make([]domaininstance.FirewallRule, 0, len(rows))
+	rules := make([]mcppve.FirewallRule, 0, len(rows))
+	for _, row := range rows {
+		rule := domaininstance.FirewallRule{Direction: row.Direction, Protocol: row.Protocol, PortRange: value(row.PortRange), CIDR: row.CIDR}
+		domainRules = append(domainRules, rule)
+		item := mcppve.FirewallRule{Type: rule.Direction, Action: domaininstance.FirewallPolicyAccept, DPort: strings.ReplaceAll(rule.PortRange, "-", ":"), Comment: value(row.Description)}
+		if rule.Protocol != domaininstance.FirewallProtocolAny {
+			item.Proto = rule.Protocol
+		}
+		if rule.Direction == domaininstance.FirewallDirectionIn {
+			item.Source = rule.CIDR
+		} else {
+			item.Dest = rule.CIDR
+		}
+		rules = append(rules, item)
+	}
+	policyIn, policyOut := domaininstance.FirewallPolicies(domainRules)
+	return mcppve.VMFirewall{Enabled: true, PolicyIn: policyIn, PolicyOut: policyOut, Rules: rules}
+}
+
+func trimmedOptional(ptr *string) *string {
+	if ptr == nil {
+		return nil
+	}
+	return nullableString(*ptr)
+}
+
+func uniqueTrimmed(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, item := range values {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		result = append(result, item)
+	}
+	return result
+}
@@ -14,12 +14,19 @@ import (
 	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
 	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
 	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/rdns"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/cache"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
 	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
 	mysqlorder "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/order"
 	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	mysqluser "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/user"
 	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/secretbox"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/sshkey"
 	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
 	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
+	webrealname "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/realname"
 )
 
 const (
@@ -28,16 +35,38 @@ const (
 	maxPerPage     = 100
 )
 
+// operationPlan 描述实例操作需要额外保存的输入快照和专用上游调用。
+type operationPlan struct {
+	payload any
+	call    func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error)
+}
+
+type operationPlanner func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error)
+
 type Service struct {
 	db        *gorm.DB
 	instances *mysqlinstance.Repository
 	orders    *mysqlorder.Repository
+	users     *mysqluser.Repository
+	realName  *webrealname.RealNameService
 	logs      *weblogging.Recorder
 	mcp       *mcppve.Client
+	backup    config.BackupConfig
+	redis     *cache.Redis
+	console   config.ConsoleConfig
+	metrics   config.MetricsConfig
+	traffic   config.TrafficConfig
+	rdns      config.RDNSConfig
+	rescue    config.RescueConfig
+	dns       rdns.Backend
+	resolver  rdns.Resolver
+
+	credentials    *secretbox.Box
+	passwordLength int
 }
 
 func NewService(db *gorm.DB, mcp *mcppve.Client) *Service {
-	return &Service{db: db, instances: mysqlinstance.NewRepository(db), orders: mysqlorder.NewRepository(db), logs: weblogging.NewRecorder(db), mcp: mcp}
+	return &Service{db: db, instances: mysqlinstance.NewRepository(db), orders: mysqlorder.NewRepository(db), users: mysqluser.NewRepository(db), logs: weblogging.NewRecorder(db), mcp: mcp}
 }
 
 func (s *Service) List(ctx context.Context, userID uint64, query webdto.InstanceListQuery) (webdto.PageResponse[webdto.InstanceItem], error) {
@@ -52,7 +81,7 @@ func (s *Service) List(ctx context.Context, userID uint64, query webdto.Instance
 	items := make([]webdto.InstanceItem, 0, len(rows))
 	for _, row := range rows {
 		var latest *webdto.RenewalOrderSummary
-		if renewal, err := s.orders.LatestRenewalByInstanceNo(ctx, row.InstanceNo); err == nil {
+		if renewal, err := s.orders.LatestUserRenewalByInstanceNo(ctx, userID, row.InstanceNo); err == nil {
 			latest = renewalSummary(renewal)
 		}
 		items = append(items, instanceItem(row.Instance, latest))
@@ -73,12 +102,24 @@ func (s *Service) Detail(ctx context.Context, userID uint64, instanceNo string)
 		return webdto.InstanceDetail{}, err
 	}
 	var latest *webdto.RenewalOrderSummary
-	if renewal, err := s.orders.LatestRenewalByInstanceNo(ctx, row.InstanceNo); err == nil {
+	if renewal, err := s.orders.LatestUserRenewalByInstanceNo(ctx, userID, row.InstanceNo); err == nil {
 		latest = renewalSummary(renewal)
 	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return webdto.InstanceDetail{}, err
 	}
-	return instanceDetail(row, ops, latest), nil
+	leases, err := s.instances.InstanceIPLeases(ctx, nil, row.ID)
+	if err != nil {
+		return webdto.InstanceDetail{}, err
+	}
+	detail := instanceDetail(row, ops, latest)
+	detail.IPAddresses = make([]string, 0, len(leases))
+	for _, lease := range leases {
+		detail.IPAddresses = append(detail.IPAddresses, lease.Address)
+	}
+	if detail.Rescue, detail.MountedISO, err = s.rescueState(ctx, row); err != nil {
+		return webdto.InstanceDetail{}, err
+	}
+	return detail, nil
 }
 
 func (s *Service) CreateRenewalOrder(ctx context.Context, userID uint64, instanceNo string, req webdto.RenewalOrderCreateRequest) (webdto.OrderDetail, error) {
@@ -104,6 +145,12 @@ func (s *Service) CreateRenewalOrder(ctx context.Context, userID uint64, instanc
 		if current.Status == domaininstance.StatusReleased || current.Status == domaininstance.StatusReleasing {
 			return apperrors.ErrConflict.WithMessage("当前实例不能创建续费订单")
 		}
+		// 变更套餐的差价按变更前的到期时间折算，变更结束前续费会让新套餐按旧价格延长服务期。
+		if _, err := s.orders.ActiveChangePlanByInstanceNo(ctx, tx, current.InstanceNo); err == nil {
+			return apperrors.ErrConflict.WithMessage("实例有未完成的变更套餐订单，请完成后再续费")
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
 		networkTypeNo := ""
 		if current.NetworkTypeNo != nil {
 			networkTypeNo = *current.NetworkTypeNo
@@ -146,12 +193,57 @@ func (s *Service) Stop(ctx context.Context, userID uint64, instanceNo string) (w
 	return s.operate(ctx, userID, instanceNo, domaininstance.OperationStop)
 }
 
+func (s *Service) Reboot(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceDetail, error) {
+	return s.operate(ctx, userID, instanceNo, domaininstance.OperationReboot)
+}
+
+func (s *Service) Shutdown(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceDetail, error) {
+	return s.operate(ctx, userID, instanceNo, domaininstance.OperationShutdown)
+}
+
+func (s *Service) Reset(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceDetail, error) {
+	return s.operate(ctx, userID, instanceNo, domaininstance.OperationReset)
+}
+
+func (s *Service) ReinstallTemplates(ctx context.Context, userID uint64, instanceNo string) ([]webdto.InstanceReinstallTemplate, error) {
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return nil, err
+	}
+	templates, err := s.instances.PlanTemplates(ctx, row.PlanNo)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]webdto.InstanceReinstallTemplate, 0, len(templates))
+	for _, template := range templates {
+		items = append(items, webdto.InstanceReinstallTemplate{TemplateNo: template.TemplateNo, Code: template.Code, Name: template.Name, OSFamily: template.OSFamily, Distribution: template.Distribution, Version: template.Version, Architecture: template.Architecture, Summary: template.Summary, Current: template.TemplateNo == row.TemplateNo})
+	}
+	return items, nil
+}
+
+func (s *Service) Reinstall(ctx context.Context, userID uint64, instanceNo string, req webdto.InstanceReinstallRequest) (webdto.InstanceDetail, error) {
+	detail, err := s.startOperation(ctx, userID, instanceNo, domaininstance.OperationReinstall, s.reinstallPlanner(req.TemplateNo))
+	if err != nil {
+		return webdto.InstanceDetail{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.reinstall", "instance", detail.InstanceNo, "重装实例系统："+strings.TrimSpace(req.TemplateNo))
+	return detail, nil
+}
+
 func (s *Service) operate(ctx context.Context, userID uint64, instanceNo string, action string) (webdto.InstanceDetail, error) {
+	return s.startOperation(ctx, userID, instanceNo, action, nil)
+}
+
+func (s *Service) startOperation(ctx context.Context, userID uint64, instanceNo string, action string, planner operationPlanner) (webdto.InstanceDetail, error) {
 	if !s.mcp.Enabled() {
 		return webdto.InstanceDetail{}, mcpUnavailableError()
 	}
 	var row mysqlinstance.Instance
 	var op mysqlinstance.Operation
+	var plan operationPlan
 	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
 		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
 		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && current.UserID != userID) {
@@ -160,20 +252,47 @@ func (s *Service) operate(ctx context.Context, userID uint64, instanceNo string,
 		if err != nil {
 			return err
 		}
+		if current.Status == domaininstance.StatusSuspended {
+			return apperrors.ErrConflict.WithMessage(suspendedMessage(current))
+		}
 		if !canOperate(current.Status, action) {
 			return apperrors.ErrConflict.WithMessage("当前实例状态不能执行该操作")
 		}
+		if value(current.TrafficOverageAction) == domaininstance.TrafficOverageSuspend && !domaininstance.AllowedWhileTrafficSuspended(action) {
+			return apperrors.ErrConflict.WithMessage("实例本月流量已超额，暂停至下个计费月")
+		}
+		if current.RescueStartedAt != nil && domaininstance.BlockedInRescue(action) {
+			return apperrors.ErrConflict.WithMessage("实例处于救援模式，请先退出救援")
+		}
 		if err := s.ensureNoRunningOperation(ctx, tx, current.ID); err != nil {
 			return err
 		}
+		if planner != nil {
+			if plan, err = planner(ctx, tx, current); err != nil {
+				return err
+			}
+		}
 		row = current
 		op = mysqlinstance.Operation{OperationNo: fmt.Sprintf("OP-%d", time.Now().UnixNano()), InstanceID: current.ID, OrderID: &current.OrderID, UserID: &userID, Action: action, Status: domaininstance.OperationStatusRunning}
+		if plan.payload != nil {
+			data, err := json.Marshal(plan.payload)
+			if err != nil {
+				return err
+			}
+			op.Payload = stringPtr(string(data))
+		}
 		return s.instances.CreateOperation(ctx, tx, &op)
 	})
 	if err != nil {
 		return webdto.InstanceDetail{}, err
 	}
-	accepted, callErr := s.callOperation(ctx, row, action)
+	call := plan.call
+	if call == nil {
+		call = func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.callOperation(ctx, row, action)
+		}
+	}
+	accepted, callErr := call(ctx, row)
 	if callErr != nil {
 		now := time.Now()
 		message := externalStoredMessage(callErr)
@@ -181,17 +300,70 @@ func (s *Service) operate(ctx context.Context, userID uint64, instanceNo string,
 			message = message[:500]
 		}
 		_ = s.instances.UpdateOperation(context.Background(), nil, op.ID, map[string]any{"status": domaininstance.OperationStatusFailed, "error_code": "mcp_call_failed", "error_message": message, "completed_at": now})
-		return webdto.InstanceDetail{}, mcpUnavailableError()
+		_ = s.settleSnapshot(context.Background(), op, false)
+		_ = s.settleBackup(context.Background(), op, false)
+		return webdto.InstanceDetail{}, externalError(callErr)
 	}
 	if err := s.instances.UpdateOperation(ctx, nil, op.ID, map[string]any{"external_operation_id": nullableString(accepted.OperationID), "operation_location": nullableString(accepted.OperationLocation), "resource_location": nullableString(accepted.Location)}); err != nil {
 		return webdto.InstanceDetail{}, err
 	}
-	if err := s.enqueueOperationSync(ctx, row.InstanceNo, op.OperationNo); err != nil {
+	if err := s.enqueueOperationSync(ctx, row.InstanceNo, op.OperationNo, s.mcp.Cluster(row.ClusterNo).OperationPollDelay(accepted)); err != nil {
 		return webdto.InstanceDetail{}, err
 	}
 	return s.Detail(ctx, userID, row.InstanceNo)
 }
 
+func (s *Service) reinstallPlanner(templateNo string) operationPlanner {
+	return func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		template, err := s.instances.PlanTemplate(ctx, current.PlanNo, templateNo)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return operationPlan{}, apperrors.ErrValidation.WithMessage("系统模板不适用于当前套餐")
+		}
+		if err != nil {
+			return operationPlan{}, err
+		}
+		mapping, err := s.instances.MappingForProvision(ctx, tx, current.PlanNo, current.RegionNo, template.TemplateNo, value(current.NetworkTypeNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("该系统模板暂不可重装，请联系客服")
+		}
+		if err != nil {
+			return operationPlan{}, err
+		}
+		userKeys, err := s.instanceSSHKeys(ctx, current)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		leases, err := s.instances.InstanceIPLeases(ctx, tx, current.ID)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		req := reinstallVMRequest(mapping, userKeys)
+		req.DiskSize = current.SystemDiskGB
+		if len(leases) > 0 {
+			req.IPConfig0 = domaininstance.IPConfig(leases[0].Address, leases[0].PrefixLength, leases[0].Gateway)
+		}
+		payload := mysqlinstance.ReinstallPayload{MappingNo: mapping.MappingNo, TemplateNo: template.TemplateNo, TemplateName: template.Name, OSFamily: template.OSFamily, OSDistribution: template.Distribution, OSVersion: template.Version}
+		return operationPlan{payload: payload, call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).ReinstallVM(ctx, row.ExternalNode, row.ExternalVMID, req)
+		}}, nil
+	}
+}
+
+// instanceSSHKeys 返回新购订单快照的用户公钥，重装时沿用；实例已不属于下单用户时不再注入。
+func (s *Service) instanceSSHKeys(ctx context.Context, current mysqlinstance.Instance) (string, error) {
+	order, err := s.orders.FindByOrderNo(ctx, current.OrderNo)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if order.UserID != current.UserID {
+		return "", nil
+	}
+	return value(order.SSHKeys), nil
+}
+
 func (s *Service) ensureNoRunningOperation(ctx context.Context, tx *gorm.DB, instanceID uint64) error {
 	_, err := s.instances.LatestRunningOperationForUpdate(ctx, tx, instanceID, domaininstance.OperationSync)
 	if err == nil {
@@ -206,9 +378,15 @@ func (s *Service) ensureNoRunningOperation(ctx context.Context, tx *gorm.DB, ins
 func (s *Service) callOperation(ctx context.Context, row mysqlinstance.Instance, action string) (mcppve.AsyncAccepted, error) {
 	switch action {
 	case domaininstance.OperationStart:
-		return s.mcp.StartVM(ctx, row.ExternalNode, row.ExternalVMID)
+		return s.mcp.Cluster(row.ClusterNo).StartVM(ctx, row.ExternalNode, row.ExternalVMID)
 	case domaininstance.OperationStop:
-		return s.mcp.StopVM(ctx, row.ExternalNode, row.ExternalVMID)
+		return s.mcp.Cluster(row.ClusterNo).StopVM(ctx, row.ExternalNode, row.ExternalVMID)
+	case domaininstance.OperationReboot:
+		return s.mcp.Cluster(row.ClusterNo).RebootVM(ctx, row.ExternalNode, row.ExternalVMID)
+	case domaininstance.OperationShutdown:
+		return s.mcp.Cluster(row.ClusterNo).ShutdownVM(ctx, row.ExternalNode, row.ExternalVMID)
+	case domaininstance.OperationReset:
+		return s.mcp.Cluster(row.ClusterNo).ResetVM(ctx, row.ExternalNode, row.ExternalVMID)
 	default:
 		return mcppve.AsyncAccepted{}, apperrors.ErrValidation.WithMessage("实例操作不支持")
 	}
@@ -220,6 +398,28 @@ func canOperate(status string, action string) bool {
 		return domaininstance.CanStart(status)
 	case domaininstance.OperationStop:
 		return domaininstance.CanStop(status)
+	case domaininstance.OperationReboot:
+		return domaininstance.CanReboot(status)
+	case domaininstance.OperationShutdown:
+		return domaininstance.CanShutdown(status)
+	case domaininstance.OperationReset:
+		return domaininstance.CanReset(status)
+	case domaininstance.OperationReinstall:
+		return domaininstance.CanReinstall(status)
+	case domaininstance.OperationResetPassword:
+		return domaininstance.CanResetPassword(status)
+	case domaininstance.OperationSnapshotCreate, domaininstance.OperationSnapshotRollback, domaininstance.OperationSnapshotDelete:
+		return domaininstance.CanSnapshot(status)
+	case domaininstance.OperationBackupCreate:
+		return domaininstance.CanBackup(status)
+	case domaininstance.OperationBackupRestore:
+		return domaininstance.CanRestoreBackup(status)
+	case domaininstance.OperationRescueEnter:
+		return domaininstance.CanEnterRescue(status)
+	case domaininstance.OperationRescueExit:
+		return domaininstance.CanExitRescue(status)
+	case domaininstance.OperationISOMount, domaininstance.OperationISOUnmount:
+		return domaininstance.CanMountISO(status)
 	default:
 		return false
 	}
@@ -227,7 +427,7 @@ func canOperate(status string, action string) bool {
 
 func instanceItem(row mysqlinstance.Instance, latest *webdto.RenewalOrderSummary) webdto.InstanceItem {
 	countdown := releaseCountdown(row)
-	return webdto.InstanceItem{InstanceNo: row.InstanceNo, OrderNo: row.OrderNo, Status: row.Status, ProductName: row.ProductName, PlanName: row.PlanName, RegionName: row.RegionName, NetworkTypeName: row.NetworkTypeName, TemplateName: row.TemplateName, ServiceStartedAt: row.ServiceStartedAt, ExpiresAt: row.ExpiresAt, ExpireStatus: expireStatus(row), ReleaseCountdownSeconds: countdown, LatestRenewalOrder: latest, CreatedAt: row.CreatedAt, ReleasedAt: row.ReleasedAt}
+	return webdto.InstanceItem{InstanceNo: row.InstanceNo, OrderNo: row.OrderNo, Status: row.Status, ProductName: row.ProductName, PlanName: row.PlanName, RegionName: row.RegionName, NetworkTypeName: row.NetworkTypeName, TemplateName: row.TemplateName, ServiceStartedAt: row.ServiceStartedAt, ExpiresAt: row.ExpiresAt, ExpireStatus: expireStatus(row), ReleaseCountdownSeconds: countdown, LatestRenewalOrder: latest, SuspendSource: row.SuspendSource, SuspendReason: row.SuspendReason, SuspendedAt: row.SuspendedAt, CreatedAt: row.CreatedAt, ReleasedAt: row.ReleasedAt}
 }
 
 func instanceDetail(row mysqlinstance.Instance, ops []mysqlinstance.Operation, latest *webdto.RenewalOrderSummary) webdto.InstanceDetail {
@@ -235,7 +435,24 @@ func instanceDetail(row mysqlinstance.Instance, ops []mysqlinstance.Operation, l
 	for _, op := range ops {
 		items = append(items, webdto.InstanceOperation{OperationNo: op.OperationNo, Action: op.Action, Status: op.Status, CreatedAt: op.CreatedAt, CompletedAt: op.CompletedAt})
 	}
-	return webdto.InstanceDetail{InstanceItem: instanceItem(row, latest), ProductNo: row.ProductNo, PlanNo: row.PlanNo, CPUCores: row.CPUCores, MemoryMB: row.MemoryMB, SystemDiskGB: row.SystemDiskGB, DataDiskGB: row.DataDiskGB, BandwidthMbps: row.BandwidthMbps, RegionNo: row.RegionNo, NetworkTypeNo: row.NetworkTypeNo, TemplateNo: row.TemplateNo, OSFamily: row.OSFamily, OSDistribution: row.OSDistribution, OSVersion: row.OSVersion, ExpireNoticeSentAt: row.ExpireNoticeSentAt, ExpireReleaseScheduledAt: row.ExpireReleaseScheduledAt, ExpireReleasedAt: row.ExpireReleasedAt, RenewalAvailable: row.Status != domaininstance.StatusReleased && row.Status != domaininstance.StatusReleasing, Operations: items}
+	return webdto.InstanceDetail{InstanceItem: instanceItem(row, latest), ProductNo: row.ProductNo, PlanNo: row.PlanNo, CPUCores: row.CPUCores, MemoryMB: row.MemoryMB, SystemDiskGB: row.SystemDiskGB, DataDiskGB: row.DataDiskGB, BandwidthMbps: row.BandwidthMbps, TrafficGB: row.TrafficGB, TrafficOverageAction: row.TrafficOverageAction, RegionNo: row.RegionNo, NetworkTypeNo: row.NetworkTypeNo, TemplateNo: row.TemplateNo, OSFamily: row.OSFamily, OSDistribution: row.OSDistribution, OSVersion: row.OSVersion, ExpireNoticeSentAt: row.ExpireNoticeSentAt, ExpireReleaseScheduledAt: row.ExpireReleaseScheduledAt, ExpireReleasedAt: row.ExpireReleasedAt, RootPasswordAvailable: row.RootPasswordCiphertext != nil && row.RootPasswordRevealedAt == nil, RenewalAvailable: row.Status != domaininstance.StatusReleased && row.Status != domaininstance.StatusReleasing, Operations: items}
+}
+
+func reinstallVMRequest(mapping mysqlinstance.ProvisionMapping, userKeys string) mcppve.ReinstallVMRequest {
+	req := mcppve.ReinstallVMRequest{Storage: mapping.Storage, DiskSource: mapping.DiskSource}
+	req.DiskFormat = value(mapping.DiskFormat)
+	req.DiskInterface = value(mapping.DiskInterface)
+	req.CIUser = value(mapping.CIUser)
+	req.SSHKeys = sshkey.Merge(userKeys, value(mapping.SSHKeys))
+	req.IPConfig0 = value(mapping.IPConfig0)
+	req.Nameserver = value(mapping.Nameserver)
+	req.SearchDomain = value(mapping.SearchDomain)
+	req.SnippetsStorage = value(mapping.SnippetsStorage)
+	req.AptMirror = value(mapping.AptMirror)
+	if mapping.CIPackages != nil {
+		_ = json.Unmarshal([]byte(*mapping.CIPackages), &req.CIPackages)
+	}
+	return req
 }
 
 func renewalOrderFromSelection(userID uint64, instanceNo string, clientToken string, selection mysqlorder.CatalogSelection) mysqlorder.Order {
@@ -247,13 +464,14 @@ func renewalSummary(order mysqlorder.Order) *webdto.RenewalOrderSummary {
 	return &webdto.RenewalOrderSummary{OrderNo: order.OrderNo, Status: order.Status, PaymentStatus: order.PaymentStatus, BillingCycle: order.BillingCycle, TotalAmountCents: order.TotalAmountCents, Currency: order.Currency, PaidAt: order.PaidAt, CreatedAt: order.CreatedAt}
 }
 
-func (s *Service) enqueueOperationSync(ctx context.Context, instanceNo string, operationNo string) error {
+// enqueueOperationSync 登记异步操作的同步任务；delay 为首次轮询延迟，启用回调的集群以轮询作为兜底。
+func (s *Service) enqueueOperationSync(ctx context.Context, instanceNo string, operationNo string, delay time.Duration) error {
 	payload := map[string]string{"instance_no": instanceNo}
 	data, _ := json.Marshal(payload)
 	idempotencyKey := "operation_sync:" + strings.TrimSpace(operationNo)
 	objectType := "instance"
 	objectNo := strings.TrimSpace(instanceNo)
-	task := mysqlinstance.Task{TaskNo: fmt.Sprintf("TASK-%d", time.Now().UnixNano()), TaskType: domaininstance.TaskTypeOperationSync, IdempotencyKey: &idempotencyKey, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &objectNo, Payload: stringPtr(string(data)), MaxAttempts: 20, ScheduledAt: time.Now().Truncate(time.Millisecond)}
+	task := mysqlinstance.Task{TaskNo: fmt.Sprintf("TASK-%d", time.Now().UnixNano()), TaskType: domaininstance.TaskTypeOperationSync, IdempotencyKey: &idempotencyKey, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &objectNo, Payload: stringPtr(string(data)), MaxAttempts: 20, ScheduledAt: time.Now().Add(delay).Truncate(time.Millisecond)}
 	return s.instances.CreateTaskIgnoreDuplicate(ctx, nil, &task)
 }
 
@@ -270,7 +488,7 @@ func webOrderItem(order mysqlorder.Order) webdto.OrderItem {
 }
 
 func webOrderDetail(order mysqlorder.Order) webdto.OrderDetail {
-	return webdto.OrderDetail{OrderItem: webOrderItem(order), UserNote: order.UserNote, ProductNo: order.ProductNo, ProductType: order.ProductType, ProductSummary: order.ProductSummary, PlanNo: order.PlanNo, PlanCode: order.PlanCode, PlanSummary: order.PlanSummary, CPUCores: order.CPUCores, MemoryMB: order.MemoryMB, SystemDiskGB: order.SystemDiskGB, DataDiskGB: order.DataDiskGB, BandwidthMbps: order.BandwidthMbps, TrafficGB: order.TrafficGB, PublicIPCount: order.PublicIPCount, Virtualization: order.Virtualization, Architecture: order.Architecture, PriceCents: order.PriceCents, OriginalPriceCents: order.OriginalPriceCents, Quantity: order.Quantity, RegionNo: order.RegionNo, RegionCode: order.RegionCode, RegionName: order.RegionName, NetworkTypeNo: order.NetworkTypeNo, NetworkTypeCode: order.NetworkTypeCode, NetworkTypeName: order.NetworkTypeName, TemplateNo: order.TemplateNo, TemplateCode: order.TemplateCode, TemplateName: order.TemplateName, OSFamily: order.OSFamily, OSDistribution: order.OSDistribution, OSVersion: order.OSVersion, OSArchitecture: order.OSArchitecture}
+	return webdto.OrderDetail{OrderItem: webOrderItem(order), UserNote: order.UserNote, SourceBackupNo: order.SourceBackupNo, ChangeFromPlanNo: order.ChangeFromPlanNo, CreditAmountCents: order.CreditAmountCents, ProductNo: order.ProductNo, ProductType: order.ProductType, ProductSummary: order.ProductSummary, PlanNo: order.PlanNo, PlanCode: order.PlanCode, PlanSummary: order.PlanSummary, CPUCores: order.CPUCores, MemoryMB: order.MemoryMB, SystemDiskGB: order.SystemDiskGB, DataDiskGB: order.DataDiskGB, BandwidthMbps: order.BandwidthMbps, TrafficGB: order.TrafficGB, PublicIPCount: order.PublicIPCount, Virtualization: order.Virtualization, Architecture: order.Architecture, PriceCents: order.PriceCents, OriginalPriceCents: order.OriginalPriceCents, Quantity: order.Quantity, RegionNo: order.RegionNo, RegionCode: order.RegionCode, RegionName: order.RegionName, NetworkTypeNo: order.NetworkTypeNo, NetworkTypeCode: order.NetworkTypeCode, NetworkTypeName: order.NetworkTypeName, TemplateNo: order.TemplateNo, TemplateCode: order.TemplateCode, TemplateName: order.TemplateName, OSFamily: order.OSFamily, OSDistribution: order.OSDistribution, OSVersion: order.OSVersion, OSArchitecture: order.OSArchitecture}
 }
 
 func expireStatus(row mysqlinstance.Instance) string {
@@ -286,6 +504,14 @@ func expireStatus(row mysqlinstance.Instance) string {
 	return "expired"
 }
 
+// suspendedMessage 返回暂停实例拒绝操作时展示给用户的提示，包含暂停原因。
+func suspendedMessage(row mysqlinstance.Instance) string {
+	if reason := value(row.SuspendReason); reason != "" {
+		return "实例已暂停：" + reason
+	}
+	return "实例已暂停"
+}
+
 func releaseCountdown(row mysqlinstance.Instance) *int64 {
 	if row.ExpireReleaseScheduledAt == nil {
 		return nil
@@ -309,6 +535,13 @@ func stringPtr(value string) *string {
 	return &value
 }
 
+func value(ptr *string) string {
+	if ptr == nil {
+		return ""
+	}
+	return strings.TrimSpace(*ptr)
+}
+
 func normalizePage(page, perPage int) (int, int) {
 	if page < 1 {
 		page = defaultPage
@@ -334,9 +567,18 @@ func mcpUnavailableError() error {
 	return apperrors.ErrExternalUnavailable.WithMessage("虚拟化管理接口暂不可用")
 }
 
+// externalError 把 MCP 调用错误映射为外部依赖不可用，消息按错误码给出可展示的原因。
+func externalError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return apperrors.ErrExternalUnavailable.WithMessage(mcppve.UserMessage(err))
+}
+
+// externalStoredMessage 生成写入操作记录的失败原因；用户端可见，只使用映射后的中文说明。
 func externalStoredMessage(err error) string {
 	if err == nil {
 		return ""
 	}
-	return "虚拟化管理接口调用失败"
+	return "虚拟化管理接口调用失败：" + mcppve.UserMessage(err)
 }
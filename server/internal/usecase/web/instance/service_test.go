@@ -2,13 +2,16 @@ package instance
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"gorm.io/gorm"
 
 	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
 	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
 	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
 	"github.com/AeolianCloud/pveCloud/server/internal/testutil/mysqltest"
 	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
@@ -175,6 +178,278 @@ func TestCreateRenewalOrderRejectsClientTokenUsedByOtherOrder(t *testing.T) {
 	}
 }
 
+func TestRenewalAndChangePlanOrdersExcludeEachOther(t *testing.T) {
+	db := openRenewalOrderDB(t)
+	seedRenewalCatalog(t, db)
+	seedRenewalUserAndInstance(t, db, 11, "INS-renew-change", domaininstance.StatusRunning)
+	service := NewService(db, nil)
+	ctx := context.Background()
+
+	renewal, err := service.CreateRenewalOrder(ctx, 11, "INS-renew-change", webdto.RenewalOrderCreateRequest{BillingCycle: "monthly", ClientToken: "renew-change-1"})
+	if err != nil {
+		t.Fatalf("create renewal order: %v", err)
+	}
+	_, err = service.CreateChangePlanOrder(ctx, 11, "INS-renew-change", webdto.ChangePlanOrderCreateRequest{PlanNo: "PLAN-2", ClientToken: "change-1"})
+	assertAppErrorCode(t, err, apperrors.ErrConflict.Code)
+
+	// 续费取消后改为进行中的变更套餐订单，续费需等变更结束。
+	if err := db.Exec(`UPDATE orders SET status = ? WHERE order_no = ?`, domainorder.StatusCancelled, renewal.OrderNo).Error; err != nil {
+		t.Fatalf("cancel renewal order: %v", err)
+	}
+	if err := db.Exec(`UPDATE orders SET order_type = ?, status = ?, change_from_plan_no = ?, client_token = ? WHERE order_no = ?`, domainorder.TypeChangePlan, domainorder.StatusProvisioning, "PLAN-1", "change-2", renewal.OrderNo).Error; err != nil {
+		t.Fatalf("seed change plan order: %v", err)
+	}
+	_, err = service.CreateRenewalOrder(ctx, 11, "INS-renew-change", webdto.RenewalOrderCreateRequest{BillingCycle: "monthly", ClientToken: "renew-change-2"})
+	assertAppErrorCode(t, err, apperrors.ErrConflict.Code)
+
+	var orderCount int64
+	if err := db.Table("orders").Where("related_instance_no = ?", "INS-renew-change").Count(&orderCount).Error; err != nil {
+		t.Fatalf("count orders: %v", err)
+	}
+	if orderCount != 1 {
+		t.Fatalf("rejected renewal and change plan orders should not be created, got %d orders", orderCount)
+	}
+}
+
+func TestReinstallTemplatesListsOnlyActivePlanTemplates(t *testing.T) {
+	db := openRenewalOrderDB(t)
+	seedRenewalCatalog(t, db)
+	seedRenewalUserAndInstance(t, db, 17, "INS-reinstall-1", domaininstance.StatusRunning)
+	statements := []string{
+		`INSERT INTO server_os_templates (id, template_no, code, name, os_family, distribution, version, architecture, status, visible) VALUES (2, 'TPL-2', 'debian', 'Debian', 'linux', 'debian', '12', 'x86_64', 'active', 1), (3, 'TPL-3', 'hidden', 'Hidden', 'linux', 'rocky', '9', 'x86_64', 'active', 0), (4, 'TPL-4', 'unbound', 'Unbound', 'windows', 'windows', '2022', 'x86_64', 'active', 1)`,
+		`INSERT INTO plan_os_templates (plan_id, template_id, status, sort_order) VALUES (1, 2, 'active', 1), (1, 3, 'active', 2)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("seed reinstall templates with %q: %v", statement, err)
+		}
+	}
+
+	service := NewService(db, nil)
+	items, err := service.ReinstallTemplates(context.Background(), 17, "INS-reinstall-1")
+	if err != nil {
+		t.Fatalf("list reinstall templates: %v", err)
+	}
+	if len(items) != 2 || items[0].TemplateNo != "TPL-1" || items[1].TemplateNo != "TPL-2" {
+		t.Fatalf("reinstall templates should only include active visible plan templates: %#v", items)
+	}
+	if !items[0].Current || items[1].Current {
+		t.Fatalf("only the instance template should be marked current: %#v", items)
+	}
+
+	_, err = service.ReinstallTemplates(context.Background(), 18, "INS-reinstall-1")
+	assertAppErrorCode(t, err, apperrors.ErrNotFound.Code)
+}
+
+func TestSnapshotsReportsPlanQuotaUsage(t *testing.T) {
+	db := openRenewalOrderDB(t)
+	mysqltest.Exec(t, db, instanceSnapshotsSchema)
+	seedRenewalCatalog(t, db)
+	seedRenewalUserAndInstance(t, db, 19, "INS-snapshot-1", domaininstance.StatusRunning)
+	statements := []string{
+		`UPDATE product_plans SET snapshot_quota = 2 WHERE plan_no = 'PLAN-1'`,
+		`INSERT INTO instance_snapshots (snapshot_no, instance_id, name, status) SELECT 'SNAP-1', id, 'snap1', 'available' FROM instances WHERE instance_no = 'INS-snapshot-1'`,
+		`INSERT INTO instance_snapshots (snapshot_no, instance_id, name, status) SELECT 'SNAP-2', id, 'snap2', 'failed' FROM instances WHERE instance_no = 'INS-snapshot-1'`,
+		`INSERT INTO instance_snapshots (snapshot_no, instance_id, name, status) SELECT 'SNAP-3', id, 'snap3', 'deleted' FROM instances WHERE instance_no = 'INS-snapshot-1'`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("seed snapshots with %q: %v", statement, err)
+		}
+	}
+
+	service := NewService(db, nil)
+	list, err := service.Snapshots(context.Background(), 19, "INS-snapshot-1")
+	if err != nil {
+		t.Fatalf("list snapshots: %v", err)
+	}
+	if list.Quota != 2 || list.Used != 1 {
+		t.Fatalf("only live snapshots should use quota, got quota=%d used=%d", list.Quota, list.Used)
+	}
+	if len(list.List) != 2 || list.List[0].SnapshotNo != "SNAP-2" || list.List[1].SnapshotNo != "SNAP-1" {
+		t.Fatalf("deleted snapshots must be hidden: %#v", list.List)
+	}
+
+	_, err = service.Snapshots(context.Background(), 18, "INS-snapshot-1")
+	assertAppErrorCode(t, err, apperrors.ErrNotFound.Code)
+}
+
+func TestUpdateInstancePTRRequiresForwardConfirmation(t *testing.T) {
+	db := openRenewalOrderDB(t)
+	mysqltest.Exec(t, db, instanceIPAddressesSchema, instancePTRRecordsSchema)
+	seedRenewalCatalog(t, db)
+	seedRenewalUserAndInstance(t, db, 20, "INS-ptr-1", domaininstance.StatusRunning)
+	if err := db.Exec(`INSERT INTO ip_addresses (pool_id, address, status, instance_id, instance_no) SELECT 1, '192.0.2.10', 'allocated', id, instance_no FROM instances WHERE instance_no = 'INS-ptr-1'`).Error; err != nil {
+		t.Fatalf("seed address: %v", err)
+	}
+
+	backend := &stubPTRBackend{}
+	resolver := stubResolver{"mail.example.com": {"192.0.2.10"}, "other.example.com": {"192.0.2.99"}}
+	service := NewService(db, nil).SetRDNS(config.RDNSConfig{Enabled: true, AutoApply: true}, backend, resolver)
+
+	_, err := service.UpdateInstancePTR(context.Background(), 20, "INS-ptr-1", webdto.InstancePTRRequest{Address: "192.0.2.10", Hostname: "other.example.com"})
+	assertAppErrorCode(t, err, apperrors.ErrValidation.Code)
+	_, err = service.UpdateInstancePTR(context.Background(), 20, "INS-ptr-1", webdto.InstancePTRRequest{Address: "192.0.2.11", Hostname: "mail.example.com"})
+	assertAppErrorCode(t, err, apperrors.ErrNotFound.Code)
+	_, err = service.UpdateInstancePTR(context.Background(), 18, "INS-ptr-1", webdto.InstancePTRRequest{Address: "192.0.2.10", Hostname: "mail.example.com"})
+	assertAppErrorCode(t, err, apperrors.ErrNotFound.Code)
+
+	result, err := service.UpdateInstancePTR(context.Background(), 20, "INS-ptr-1", webdto.InstancePTRRequest{Address: "192.0.2.10", Hostname: "Mail.Example.com."})
+	if err != nil {
+		t.Fatalf("update ptr: %v", err)
+	}
+	if len(result.Records) != 1 || result.Records[0].Status == nil || *result.Records[0].Status != domaininstance.PTRStatusActive || result.Records[0].AppliedHostname == nil || *result.Records[0].AppliedHostname != "mail.example.com" {
+		t.Fatalf("auto applied ptr should be active: %#v", result.Records)
+	}
+	if len(backend.set) != 1 || backend.set[0] != "192.0.2.10=mail.example.com" {
+		t.Fatalf("unexpected backend writes: %#v", backend.set)
+	}
+
+	service.SetRDNS(config.RDNSConfig{Enabled: true, AutoApply: false}, backend, stubResolver{"smtp.example.com": {"192.0.2.10"}})
+	result, err = service.UpdateInstancePTR(context.Background(), 20, "INS-ptr-1", webdto.InstancePTRRequest{Address: "192.0.2.10", Hostname: "smtp.example.com"})
+	if err != nil {
+		t.Fatalf("update ptr for review: %v", err)
+	}
+	if *result.Records[0].Status != domaininstance.PTRStatusPendingReview || *result.Records[0].AppliedHostname != "mail.example.com" || len(backend.set) != 1 {
+		t.Fatalf("manual review should keep the applied record untouched: %#v", result.Records[0])
+	}
+
+	result, err = service.DeleteInstancePTR(context.Background(), 20, "INS-ptr-1", "192.0.2.10")
+	if err != nil {
+		t.Fatalf("delete ptr: %v", err)
+	}
+	if result.Records[0].Status != nil || len(backend.deleted) != 1 {
+		t.Fatalf("delete should clear the record and remove it from dns: %#v %#v", result.Records[0], backend.deleted)
+	}
+}
+
+func TestAcceptInstanceTransferMovesOwnershipOrdersAndTickets(t *testing.T) {
+	db := openRenewalOrderDB(t)
+	mysqltest.Exec(t, db, instanceTransfersSchema, instanceSecurityGroupsSchema, instancePTRRecordsSchema, transferTicketsSchema, transferNotificationsSchema, transferAsyncTasksSchema, transferOperationsSchema, instanceIPAddressesSchema, transferIPPoolsSchema)
+	seedRenewalCatalog(t, db)
+	seedRenewalUserAndInstance(t, db, 30, "INS-trf-1", domaininstance.StatusRunning)
+	if err := db.Exec(`INSERT INTO users (id, username, email, password_hash, status) VALUES (31, 'receiver', 'receiver@example.com', 'hash', 'active')`).Error; err != nil {
+		t.Fatalf("insert receiver: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO tickets (ticket_no, user_id, instance_id, instance_no) SELECT 'TIC-trf-1', 30, id, instance_no FROM instances WHERE instance_no = 'INS-trf-1'`).Error; err != nil {
+		t.Fatalf("seed ticket: %v", err)
+	}
+
+	service := NewService(db, nil)
+	renewal, err := service.CreateRenewalOrder(context.Background(), 30, "INS-trf-1", webdto.RenewalOrderCreateRequest{BillingCycle: "monthly", ClientToken: "trf-renew-1"})
+	if err != nil {
+		t.Fatalf("create renewal order: %v", err)
+	}
+	_, err = service.CreateInstanceTransfer(context.Background(), 30, "INS-trf-1", webdto.InstanceTransferRequest{Account: "renew@example.com"})
+	assertAppErrorCode(t, err, apperrors.ErrValidation.Code)
+	_, err = service.CreateInstanceTransfer(context.Background(), 31, "INS-trf-1", webdto.InstanceTransferRequest{Account: "renew-user"})
+	assertAppErrorCode(t, err, apperrors.ErrNotFound.Code)
+
+	transfer, err := service.CreateInstanceTransfer(context.Background(), 30, "INS-trf-1", webdto.InstanceTransferRequest{Account: "receiver"})
+	if err != nil {
+		t.Fatalf("create transfer: %v", err)
+	}
+	if transfer.Status != domaininstance.TransferStatusPending || transfer.Direction != "outgoing" || transfer.ToUsername != "receiver" {
+		t.Fatalf("unexpected transfer: %#v", transfer)
+	}
+	_, err = service.CreateInstanceTransfer(context.Background(), 30, "INS-trf-1", webdto.InstanceTransferRequest{Account: "receiver@example.com"})
+	assertAppErrorCode(t, err, apperrors.ErrConflict.Code)
+	_, err = service.AcceptInstanceTransfer(context.Background(), 30, transfer.TransferNo)
+	assertAppErrorCode(t, err, apperrors.ErrNotFound.Code)
+	// 原所有者已查看过 root 密码，转移后该密码必须作废并由新所有者重新获得。
+	if err := db.Exec(`UPDATE instances SET root_password_ciphertext = ?, root_password_revealed_at = ? WHERE instance_no = ?`, "sealed-old", time.Now(), "INS-trf-1").Error; err != nil {
+		t.Fatalf("seed revealed password: %v", err)
+	}
+
+	accepted, err := service.AcceptInstanceTransfer(context.Background(), 31, transfer.TransferNo)
+	if err != nil {
+		t.Fatalf("accept transfer: %v", err)
+	}
+	if accepted.Status != domaininstance.TransferStatusCompleted || accepted.Direction != "incoming" || accepted.CompletedAt == nil {
+		t.Fatalf("accepted transfer should be completed: %#v", accepted)
+	}
+	if _, err := service.Detail(context.Background(), 31, "INS-trf-1"); err != nil {
+		t.Fatalf("recipient should own instance: %v", err)
+	}
+	_, err = service.Detail(context.Background(), 30, "INS-trf-1")
+	assertAppErrorCode(t, err, apperrors.ErrNotFound.Code)
+
+	var orderStatus string
+	if err := db.Table("orders").Select("status").Where("order_no = ?", renewal.OrderNo).Scan(&orderStatus).Error; err != nil {
+		t.Fatalf("load renewal order: %v", err)
+	}
+	if orderStatus != domainorder.StatusCancelled {
+		t.Fatalf("pending renewal order should be cancelled, got %s", orderStatus)
+	}
+	var ticketOwner uint64
+	if err := db.Table("tickets").Select("user_id").Where("ticket_no = ?", "TIC-trf-1").Scan(&ticketOwner).Error; err != nil {
+		t.Fatalf("load ticket: %v", err)
+	}
+	if ticketOwner != 31 {
+		t.Fatalf("linked ticket should move to recipient, got user %d", ticketOwner)
+	}
+	var notifications, tasks int64
+	if err := db.Table("notifications").Count(&notifications).Error; err != nil {
+		t.Fatalf("count notifications: %v", err)
+	}
+	if err := db.Table("async_tasks").Where("task_type = ?", domaininstance.TaskTypeEmailSend).Count(&tasks).Error; err != nil {
+		t.Fatalf("count tasks: %v", err)
+	}
+	if notifications != 3 || tasks != 3 {
+		t.Fatalf("expected request and two completion notifications, got notifications=%d tasks=%d", notifications, tasks)
+	}
+	var credential struct {
+		Ciphertext *string    `gorm:"column:root_password_ciphertext"`
+		RevealedAt *time.Time `gorm:"column:root_password_revealed_at"`
+	}
+	if err := db.Table("instances").Select("root_password_ciphertext, root_password_revealed_at").Where("instance_no = ?", "INS-trf-1").Scan(&credential).Error; err != nil {
+		t.Fatalf("load credential: %v", err)
+	}
+	if credential.Ciphertext != nil || credential.RevealedAt != nil {
+		t.Fatalf("transfer should drop the previous owner's password and reveal state: %+v", credential)
+	}
+	var reset struct {
+		ObjectNo string
+		Payload  string
+	}
+	if err := db.Table("async_tasks").Select("object_no, payload").Where("task_type = ?", domaininstance.TaskTypeCredentialReset).Scan(&reset).Error; err != nil {
+		t.Fatalf("load credential reset task: %v", err)
+	}
+	var resetPayload struct {
+		InstanceNo string `json:"instance_no"`
+		UserID     uint64 `json:"user_id"`
+	}
+	if err := json.Unmarshal([]byte(reset.Payload), &resetPayload); err != nil || reset.ObjectNo != "INS-trf-1" || resetPayload.UserID != 31 {
+		t.Fatalf("transfer should schedule a password reset for the recipient: %+v", reset)
+	}
+	_, err = service.RejectInstanceTransfer(context.Background(), 31, transfer.TransferNo)
+	assertAppErrorCode(t, err, apperrors.ErrConflict.Code)
+}
+
+type stubResolver map[string][]string
+
+func (r stubResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addresses, ok := r[host]; ok {
+		return addresses, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+type stubPTRBackend struct {
+	set     []string
+	deleted []string
+}
+
+func (b *stubPTRBackend) SetPTR(_ context.Context, address, hostname string) error {
+	b.set = append(b.set, address+"="+hostname)
+	return nil
+}
+
+func (b *stubPTRBackend) DeletePTR(_ context.Context, address string) error {
+	b.deleted = append(b.deleted, address)
+	return nil
+}
+
 func openRenewalOrderDB(t *testing.T) *gorm.DB {
 	t.Helper()
 	db := mysqltest.Open(t)
@@ -321,6 +596,7 @@ CREATE TABLE product_plans (
   bandwidth_mbps INT NOT NULL,
   traffic_gb INT NULL,
   public_ip_count INT NOT NULL DEFAULT 1,
+  snapshot_quota INT NOT NULL DEFAULT 0,
   virtualization VARCHAR(32) NOT NULL DEFAULT 'kvm',
   architecture VARCHAR(32) NOT NULL DEFAULT 'x86_64',
   is_featured TINYINT(1) NOT NULL DEFAULT 0,
@@ -441,6 +717,9 @@ CREATE TABLE orders (
   status VARCHAR(32) NOT NULL DEFAULT 'pending',
   order_type VARCHAR(32) NOT NULL DEFAULT 'purchase',
   related_instance_no VARCHAR(64) NULL,
+  change_from_plan_no VARCHAR(64) NULL,
+  source_backup_no VARCHAR(64) NULL,
+  ssh_keys TEXT NULL,
   product_no VARCHAR(64) NOT NULL,
   product_type VARCHAR(32) NOT NULL,
   product_name VARCHAR(128) NOT NULL,
@@ -464,6 +743,7 @@ CREATE TABLE orders (
   currency VARCHAR(16) NOT NULL,
   quantity INT NOT NULL DEFAULT 1,
   total_amount_cents BIGINT UNSIGNED NOT NULL,
+  credit_amount_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
   payment_status VARCHAR(32) NOT NULL DEFAULT 'unpaid',
   paid_at DATETIME(3) NULL,
   payment_provider VARCHAR(32) NULL,
@@ -511,6 +791,8 @@ CREATE TABLE instances (
   system_disk_gb INT NOT NULL,
   data_disk_gb INT NOT NULL DEFAULT 0,
   bandwidth_mbps INT NOT NULL,
+  traffic_gb INT NULL,
+  traffic_overage_action VARCHAR(16) NULL,
   region_no VARCHAR(64) NOT NULL,
   region_name VARCHAR(128) NOT NULL,
   network_type_no VARCHAR(64) NULL,
@@ -525,6 +807,12 @@ CREATE TABLE instances (
   external_resource_location VARCHAR(255) NULL,
   last_error_code VARCHAR(64) NULL,
   last_error_message VARCHAR(500) NULL,
+  config_drift VARCHAR(255) NULL,
+  config_checked_at DATETIME(3) NULL,
+  firewall_status VARCHAR(16) NULL,
+  firewall_synced_at DATETIME(3) NULL,
+  root_password_ciphertext TEXT NULL,
+  root_password_revealed_at DATETIME(3) NULL,
   service_started_at DATETIME(3) NULL,
   expires_at DATETIME(3) NULL,
   expire_notice_sent_at DATETIME(3) NULL,
@@ -556,3 +844,189 @@ CREATE TABLE user_business_logs (
   KEY idx_user_business_logs_user_time (user_id, created_at),
   KEY idx_user_business_logs_object (object_type, object_id)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceSnapshotsSchema = `
+CREATE TABLE instance_snapshots (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  snapshot_no VARCHAR(64) NOT NULL,
+  instance_id BIGINT UNSIGNED NOT NULL,
+  name VARCHAR(40) NOT NULL,
+  description VARCHAR(255) NULL,
+  status VARCHAR(32) NOT NULL DEFAULT 'creating',
+  created_by_user_id BIGINT UNSIGNED NULL,
+  created_by_admin_id BIGINT UNSIGNED NULL,
+  last_rolled_back_at DATETIME(3) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  deleted_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_instance_snapshots_snapshot_no (snapshot_no),
+  UNIQUE KEY uk_instance_snapshots_instance_name (instance_id, name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceIPAddressesSchema = `
+CREATE TABLE ip_addresses (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  pool_id BIGINT UNSIGNED NOT NULL,
+  address VARCHAR(64) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'available',
+  instance_id BIGINT UNSIGNED NULL,
+  instance_no VARCHAR(64) NULL,
+  allocated_at DATETIME(3) NULL,
+  remark VARCHAR(255) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_ip_addresses_pool_address (pool_id, address)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instancePTRRecordsSchema = `
+CREATE TABLE ip_ptr_records (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  ip_address_id BIGINT UNSIGNED NOT NULL,
+  address VARCHAR(64) NOT NULL,
+  instance_id BIGINT UNSIGNED NOT NULL,
+  instance_no VARCHAR(64) NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  hostname VARCHAR(253) NOT NULL,
+  applied_hostname VARCHAR(253) NULL,
+  status VARCHAR(16) NOT NULL,
+  last_error VARCHAR(500) NULL,
+  reviewed_by BIGINT UNSIGNED NULL,
+  reviewed_at DATETIME(3) NULL,
+  review_remark VARCHAR(500) NULL,
+  applied_at DATETIME(3) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_ip_ptr_records_ip_address (ip_address_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceTransfersSchema = `
+CREATE TABLE instance_transfers (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  transfer_no VARCHAR(64) NOT NULL,
+  instance_id BIGINT UNSIGNED NOT NULL,
+  instance_no VARCHAR(64) NOT NULL,
+  from_user_id BIGINT UNSIGNED NOT NULL,
+  to_user_id BIGINT UNSIGNED NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  remark VARCHAR(500) NULL,
+  forced_by BIGINT UNSIGNED NULL,
+  force_reason VARCHAR(500) NULL,
+  responded_at DATETIME(3) NULL,
+  completed_at DATETIME(3) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_instance_transfers_transfer_no (transfer_no)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const instanceSecurityGroupsSchema = `
+CREATE TABLE instance_security_groups (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  instance_id BIGINT UNSIGNED NOT NULL,
+  security_group_id BIGINT UNSIGNED NOT NULL,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_instance_security_groups_instance_group (instance_id, security_group_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const transferTicketsSchema = `
+CREATE TABLE tickets (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  ticket_no VARCHAR(64) NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  instance_id BIGINT UNSIGNED NULL,
+  instance_no VARCHAR(64) NULL,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const transferNotificationsSchema = `
+CREATE TABLE notifications (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  notification_no VARCHAR(64) NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  channel VARCHAR(16) NOT NULL,
+  scene VARCHAR(64) NOT NULL,
+  target VARCHAR(191) NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  subject VARCHAR(255) NULL,
+  content_summary VARCHAR(500) NULL,
+  related_object_type VARCHAR(64) NULL,
+  related_object_no VARCHAR(64) NULL,
+  task_no VARCHAR(64) NULL,
+  error_code VARCHAR(64) NULL,
+  error_message VARCHAR(500) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  sent_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_notifications_notification_no (notification_no)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const transferAsyncTasksSchema = `
+CREATE TABLE async_tasks (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  task_no VARCHAR(64) NOT NULL,
+  task_type VARCHAR(64) NOT NULL,
+  idempotency_key VARCHAR(191) NULL,
+  status VARCHAR(32) NOT NULL,
+  object_type VARCHAR(64) NULL,
+  object_no VARCHAR(64) NULL,
+  payload JSON NULL,
+  result JSON NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  max_attempts INT NOT NULL DEFAULT 10,
+  scheduled_at DATETIME(3) NOT NULL,
+  locked_by VARCHAR(128) NULL,
+  locked_until DATETIME(3) NULL,
+  last_error_code VARCHAR(64) NULL,
+  last_error_message VARCHAR(500) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  completed_at DATETIME(3) NULL,
+  UNIQUE KEY uk_async_tasks_task_no (task_no),
+  UNIQUE KEY uk_async_tasks_idempotency_key (idempotency_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const transferOperationsSchema = `
+CREATE TABLE instance_operations (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  operation_no VARCHAR(64) NOT NULL,
+  instance_id BIGINT UNSIGNED NOT NULL,
+  order_id BIGINT UNSIGNED NULL,
+  admin_id BIGINT UNSIGNED NULL,
+  user_id BIGINT UNSIGNED NULL,
+  action VARCHAR(32) NOT NULL,
+  status VARCHAR(32) NOT NULL,
+  external_operation_id VARCHAR(128) NULL,
+  operation_location VARCHAR(255) NULL,
+  resource_location VARCHAR(255) NULL,
+  error_code VARCHAR(64) NULL,
+  error_message VARCHAR(500) NULL,
+  payload TEXT NULL,
+  placement TEXT NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  completed_at DATETIME(3) NULL,
+  UNIQUE KEY uk_instance_operations_operation_no (operation_no)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+const transferIPPoolsSchema = `
+CREATE TABLE ip_pools (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  pool_no VARCHAR(64) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  region_no VARCHAR(64) NOT NULL,
+  network_type_no VARCHAR(64) NOT NULL DEFAULT '',
+  cidr VARCHAR(64) NOT NULL,
+  prefix_length TINYINT UNSIGNED NOT NULL,
+  gateway VARCHAR(64) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'active',
+  remark VARCHAR(500) NULL,
+  created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_ip_pools_pool_no (pool_no)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
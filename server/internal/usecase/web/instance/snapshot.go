@@ -0,0 +1,201 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/integration/mcppve"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/textutil"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
+)
+
+// Snapshots 返回当前用户实例未删除的快照，以及套餐快照配额占用。
+func (s *Service) Snapshots(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceSnapshotList, error) {
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.InstanceSnapshotList{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return webdto.InstanceSnapshotList{}, err
+	}
+	quota, err := s.instances.PlanSnapshotQuota(ctx, nil, row.PlanNo)
+	if err != nil {
+		return webdto.InstanceSnapshotList{}, err
+	}
+	used, err := s.instances.CountQuotaSnapshots(ctx, nil, row.ID)
+	if err != nil {
+		return webdto.InstanceSnapshotList{}, err
+	}
+	rows, err := s.instances.Snapshots(ctx, row.ID)
+	if err != nil {
+		return webdto.InstanceSnapshotList{}, err
+	}
+	items := make([]webdto.InstanceSnapshotItem, 0, len(rows))
+	for _, snapshot := range rows {
+		items = append(items, snapshotItem(snapshot))
+	}
+	return webdto.InstanceSnapshotList{Quota: quota, Used: used, List: items}, nil
+}
+
+func (s *Service) CreateSnapshot(ctx context.Context, userID uint64, instanceNo string, req webdto.InstanceSnapshotCreateRequest) (webdto.InstanceSnapshotItem, error) {
+	var snapshot mysqlinstance.Snapshot
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		quota, err := s.instances.PlanSnapshotQuota(ctx, tx, current.PlanNo)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		used, err := s.instances.CountQuotaSnapshots(ctx, tx, current.ID)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		if used >= int64(quota) {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("快照数量已达套餐上限，请先删除旧快照")
+		}
+		snapshot = newSnapshot(current.ID, req.Description, userID)
+		if err := s.instances.CreateSnapshot(ctx, tx, &snapshot); err != nil {
+			return operationPlan{}, err
+		}
+		input := mcppve.CreateSnapshotRequest{Name: snapshot.Name, Description: value(snapshot.Description)}
+		return operationPlan{payload: snapshotPayload(snapshot), call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).CreateSnapshot(ctx, row.ExternalNode, row.ExternalVMID, input)
+		}}, nil
+	}
+	detail, err := s.startOperation(ctx, userID, instanceNo, domaininstance.OperationSnapshotCreate, planner)
+	if err != nil {
+		return webdto.InstanceSnapshotItem{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.snapshot.create", "instance", detail.InstanceNo, "创建实例快照："+snapshot.SnapshotNo)
+	return s.snapshot(ctx, snapshot.SnapshotNo)
+}
+
+// RollbackSnapshot 回滚实例到指定快照；实例存在未完成操作时由 startOperation 拒绝。
+func (s *Service) RollbackSnapshot(ctx context.Context, userID uint64, instanceNo string, snapshotNo string) (webdto.InstanceSnapshotItem, error) {
+	planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+		snapshot, err := s.lockSnapshot(ctx, tx, current.ID, snapshotNo)
+		if err != nil {
+			return operationPlan{}, err
+		}
+		if !domaininstance.CanRollbackSnapshot(snapshot.Status) {
+			return operationPlan{}, apperrors.ErrConflict.WithMessage("当前快照状态不能回滚")
+		}
+		return operationPlan{payload: snapshotPayload(snapshot), call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+			return s.mcp.Cluster(row.ClusterNo).RollbackSnapshot(ctx, row.ExternalNode, row.ExternalVMID, snapshot.Name)
+		}}, nil
+	}
+	detail, err := s.startOperation(ctx, userID, instanceNo, domaininstance.OperationSnapshotRollback, planner)
+	if err != nil {
+		return webdto.InstanceSnapshotItem{}, err
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.snapshot.rollback", "instance", detail.InstanceNo, "回滚实例快照："+strings.TrimSpace(snapshotNo))
+	return s.snapshot(ctx, strings.TrimSpace(snapshotNo))
+}
+
+// DeleteSnapshot 删除实例快照。创建失败的快照在上游并不存在，只在本地标记删除。
+func (s *Service) DeleteSnapshot(ctx context.Context, userID uint64, instanceNo string, snapshotNo string) (webdto.InstanceSnapshotItem, error) {
+	discarded, err := s.discardFailedSnapshot(ctx, userID, instanceNo, snapshotNo)
+	if err != nil {
+		return webdto.InstanceSnapshotItem{}, err
+	}
+	if !discarded {
+		planner := func(ctx context.Context, tx *gorm.DB, current mysqlinstance.Instance) (operationPlan, error) {
+			snapshot, err := s.lockSnapshot(ctx, tx, current.ID, snapshotNo)
+			if err != nil {
+				return operationPlan{}, err
+			}
+			if snapshot.Status != domaininstance.SnapshotStatusAvailable {
+				return operationPlan{}, apperrors.ErrConflict.WithMessage("当前快照状态不能删除")
+			}
+			if err := s.instances.UpdateSnapshot(ctx, tx, snapshot.ID, map[string]any{"status": domaininstance.SnapshotStatusDeleting}); err != nil {
+				return operationPlan{}, err
+			}
+			return operationPlan{payload: snapshotPayload(snapshot), call: func(ctx context.Context, row mysqlinstance.Instance) (mcppve.AsyncAccepted, error) {
+				return s.mcp.Cluster(row.ClusterNo).DeleteSnapshot(ctx, row.ExternalNode, row.ExternalVMID, snapshot.Name)
+			}}, nil
+		}
+		if _, err := s.startOperation(ctx, userID, instanceNo, domaininstance.OperationSnapshotDelete, planner); err != nil {
+			return webdto.InstanceSnapshotItem{}, err
+		}
+	}
+	_ = s.logs.BusinessNoTx(ctx, weblogging.Snapshot(userID, "", ""), "instance", "instance.snapshot.delete", "instance", strings.TrimSpace(instanceNo), "删除实例快照："+strings.TrimSpace(snapshotNo))
+	return s.snapshot(ctx, strings.TrimSpace(snapshotNo))
+}
+
+func (s *Service) discardFailedSnapshot(ctx context.Context, userID uint64, instanceNo string, snapshotNo string) (bool, error) {
+	discarded := false
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && current.UserID != userID) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		snapshot, err := s.lockSnapshot(ctx, tx, current.ID, snapshotNo)
+		if err != nil {
+			return err
+		}
+		if !domaininstance.CanDeleteSnapshot(snapshot.Status) {
+			return apperrors.ErrConflict.WithMessage("当前快照状态不能删除")
+		}
+		if snapshot.Status != domaininstance.SnapshotStatusFailed {
+			return nil
+		}
+		discarded = true
+		return s.instances.UpdateSnapshot(ctx, tx, snapshot.ID, map[string]any{"status": domaininstance.SnapshotStatusDeleted, "deleted_at": time.Now()})
+	})
+	return discarded, err
+}
+
+func (s *Service) lockSnapshot(ctx context.Context, tx *gorm.DB, instanceID uint64, snapshotNo string) (mysqlinstance.Snapshot, error) {
+	snapshot, err := s.instances.SnapshotForUpdate(ctx, tx, instanceID, strings.TrimSpace(snapshotNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return mysqlinstance.Snapshot{}, apperrors.ErrNotFound.WithMessage("快照不存在")
+	}
+	return snapshot, err
+}
+
+func (s *Service) snapshot(ctx context.Context, snapshotNo string) (webdto.InstanceSnapshotItem, error) {
+	snapshot, err := s.instances.SnapshotByNo(ctx, snapshotNo)
+	if err != nil {
+		return webdto.InstanceSnapshotItem{}, err
+	}
+	return snapshotItem(snapshot), nil
+}
+
+// settleSnapshot 在上游调用直接失败时回退快照状态；异步结果由 Worker 同步时回写。
+func (s *Service) settleSnapshot(ctx context.Context, op mysqlinstance.Operation, succeeded bool) error {
+	status := domaininstance.SnapshotStatusAfterOperation(op.Action, succeeded)
+	if status == "" || op.Payload == nil {
+		return nil
+	}
+	var payload mysqlinstance.SnapshotPayload
+	if err := json.Unmarshal([]byte(*op.Payload), &payload); err != nil || strings.TrimSpace(payload.SnapshotNo) == "" {
+		return nil
+	}
+	return s.instances.UpdateSnapshotByNo(ctx, nil, payload.SnapshotNo, map[string]any{"status": status})
+}
+
+func newSnapshot(instanceID uint64, description *string, userID uint64) mysqlinstance.Snapshot {
+	// PVE 快照名只允许字母开头的短标识，这里与对外编号共用同一时间戳。
+	now := time.Now().UnixNano()
+	return mysqlinstance.Snapshot{SnapshotNo: fmt.Sprintf("SNAP-%d", now), InstanceID: instanceID, Name: fmt.Sprintf("snap%d", now), Description: textutil.NormalizeOptionalString(description), Status: domaininstance.SnapshotStatusCreating, CreatedByUserID: &userID}
+}
+
+func snapshotPayload(snapshot mysqlinstance.Snapshot) mysqlinstance.SnapshotPayload {
+	return mysqlinstance.SnapshotPayload{SnapshotNo: snapshot.SnapshotNo, Name: snapshot.Name}
+}
+
+func snapshotItem(snapshot mysqlinstance.Snapshot) webdto.InstanceSnapshotItem {
+	return webdto.InstanceSnapshotItem{SnapshotNo: snapshot.SnapshotNo, Description: snapshot.Description, Status: snapshot.Status, LastRolledBackAt: snapshot.LastRolledBackAt, CreatedAt: snapshot.CreatedAt}
+}
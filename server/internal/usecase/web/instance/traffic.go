@@ -0,0 +1,55 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+)
+
+// trafficHistoryMonths 是流量查询返回的最近计费月数量（含当前月）。
+const trafficHistoryMonths = 6
+
+// SetTrafficConfig 注入流量计量配置，用于向用户展示计量方向和超额处理方式。
+func (s *Service) SetTrafficConfig(cfg config.TrafficConfig) *Service {
+	s.traffic = cfg
+	return s
+}
+
+// Traffic 返回用户实例当前计费月和最近数月的流量用量；Worker 尚未采样的月份按 0 返回。
+func (s *Service) Traffic(ctx context.Context, userID uint64, instanceNo string) (webdto.InstanceTraffic, error) {
+	row, err := s.instances.UserInstance(ctx, userID, strings.TrimSpace(instanceNo))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.InstanceTraffic{}, apperrors.ErrNotFound.WithMessage("实例不存在")
+	}
+	if err != nil {
+		return webdto.InstanceTraffic{}, err
+	}
+	usages, err := s.instances.TrafficUsages(ctx, row.ID, trafficHistoryMonths)
+	if err != nil {
+		return webdto.InstanceTraffic{}, err
+	}
+	period := domaininstance.TrafficPeriod(time.Now())
+	result := webdto.InstanceTraffic{InstanceNo: row.InstanceNo, Direction: s.traffic.Direction, OveragePolicy: s.traffic.OveragePolicy, OverageAction: row.TrafficOverageAction, Current: webdto.TrafficUsage{Period: period, QuotaGB: row.TrafficGB}, History: make([]webdto.TrafficUsage, 0, len(usages))}
+	for _, usage := range usages {
+		item := trafficUsageItem(usage)
+		if usage.Period == period {
+			result.Current = item
+		}
+		result.History = append(result.History, item)
+	}
+	return result, nil
+}
+
+func trafficUsageItem(usage mysqlinstance.TrafficUsage) webdto.TrafficUsage {
+	quota := domaininstance.TrafficQuotaBytes(usage.QuotaGB)
+	return webdto.TrafficUsage{Period: usage.Period, QuotaGB: usage.QuotaGB, InBytes: usage.InBytes, OutBytes: usage.OutBytes, BilledBytes: usage.BilledBytes, UsedPercent: domaininstance.TrafficUsedPercent(usage.BilledBytes, quota), Exceeded: quota > 0 && usage.BilledBytes > quota, OverageAction: usage.OverageAction, OverageBilledGB: usage.OverageBilledGB, OverageBilledCents: usage.OverageBilledCents, LastSampledAt: usage.LastSampledAt}
+}
@@ -0,0 +1,264 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
+	domainuser "github.com/AeolianCloud/pveCloud/server/internal/domain/user"
+	mysqlinstance "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/instance"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	mysqluser "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/user"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/textutil"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
+	webrealname "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/realname"
+)
+
+const (
+	transferDirectionOutgoing = "outgoing"
+	transferDirectionIncoming = "incoming"
+)
+
+// SetRealName 注入实名服务；接收实例转移前按下单同样的规则校验接收方实名状态。
+func (s *Service) SetRealName(realName *webrealname.RealNameService) *Service {
+	s.realName = realName
+	return s
+}
+
+// InstanceTransfers 返回当前用户发起和接收的实例转移记录，按创建时间倒序。
+func (s *Service) InstanceTransfers(ctx context.Context, userID uint64, query webdto.InstanceTransferListQuery) (webdto.PageResponse[webdto.InstanceTransferItem], error) {
+	if !domaininstance.IsKnownTransferStatus(query.Status) {
+		return webdto.PageResponse[webdto.InstanceTransferItem]{}, apperrors.ErrValidation.WithMessage("转移状态不支持")
+	}
+	page, perPage := normalizePage(query.Page, query.PerPage)
+	rows, total, err := s.instances.ListInstanceTransfers(ctx, mysqlinstance.InstanceTransferFilters{UserID: userID, Direction: query.Direction, Status: query.Status}, perPage, (page-1)*perPage)
+	if err != nil {
+		return webdto.PageResponse[webdto.InstanceTransferItem]{}, err
+	}
+	items := make([]webdto.InstanceTransferItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, instanceTransferItem(userID, row))
+	}
+	return pageResponse(items, total, page, perPage), nil
+}
+
+// CreateInstanceTransfer 由实例所有者向另一个账号发起转移，接收方确认前实例归属不变；同一实例同时只能有一个待确认的转移。
+func (s *Service) CreateInstanceTransfer(ctx context.Context, userID uint64, instanceNo string, req webdto.InstanceTransferRequest) (webdto.InstanceTransferItem, error) {
+	target, err := s.users.FindUserByAccount(ctx, req.Account)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return webdto.InstanceTransferItem{}, apperrors.ErrNotFound.WithMessage("接收账号不存在")
+	}
+	if err != nil {
+		return webdto.InstanceTransferItem{}, err
+	}
+	if target.ID == userID {
+		return webdto.InstanceTransferItem{}, apperrors.ErrValidation.WithMessage("不能把实例转移给自己")
+	}
+	if target.Status != domainuser.StatusActive {
+		return webdto.InstanceTransferItem{}, apperrors.ErrValidation.WithMessage("接收账号不可用")
+	}
+	transferNo := fmt.Sprintf("TRF-%d", time.Now().UnixNano())
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		current, err := s.instances.InstanceForUpdate(ctx, tx, strings.TrimSpace(instanceNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && current.UserID != userID) {
+			return apperrors.ErrNotFound.WithMessage("实例不存在")
+		}
+		if err != nil {
+			return err
+		}
+		if !domaininstance.CanTransfer(current.Status) {
+			return apperrors.ErrConflict.WithMessage("实例当前状态不能转移")
+		}
+		pending, err := s.instances.PendingInstanceTransfers(ctx, tx, current.ID)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return apperrors.ErrConflict.WithMessage("实例已有待确认的转移，请先撤销")
+		}
+		transfer := mysqlinstance.InstanceTransfer{TransferNo: transferNo, InstanceID: current.ID, InstanceNo: current.InstanceNo, FromUserID: userID, ToUserID: target.ID, Status: domaininstance.TransferStatusPending, Remark: textutil.NormalizeOptionalString(req.Remark)}
+		if err := s.instances.CreateInstanceTransfer(ctx, tx, &transfer); err != nil {
+			return err
+		}
+		content := fmt.Sprintf("有用户向你的账号转移实例 %s，请登录控制台在实例转移中接收或拒绝。", current.InstanceNo)
+		if err := s.notifyTransfer(ctx, tx, target, transfer, "TO", domaininstance.NotificationSceneTransferRequested, "收到实例转移请求", content); err != nil {
+			return err
+		}
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "instance", "instance.transfer.create", "instance", current.InstanceNo, "发起实例转移："+transferNo)
+	})
+	if err != nil {
+		return webdto.InstanceTransferItem{}, err
+	}
+	return s.instanceTransfer(ctx, userID, transferNo)
+}
+
+// AcceptInstanceTransfer 由接收方确认转移：校验接收方实名后在同一事务内变更实例归属、取消原所有者待支付的续费订单并迁移工单，
+// 双方各收到一封完成通知。原所有者的安全组随绑定解除，事务提交后按空安全组重新下发防火墙。
+func (s *Service) AcceptInstanceTransfer(ctx context.Context, userID uint64, transferNo string) (webdto.InstanceTransferItem, error) {
+	if err := s.requireRecipientRealName(ctx, userID); err != nil {
+		return webdto.InstanceTransferItem{}, err
+	}
+	transferNo = strings.TrimSpace(transferNo)
+	var moved mysqlinstance.TransferResult
+	var instanceNo string
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		transfer, err := s.pendingTransferForUpdate(ctx, tx, transferNo, func(row mysqlinstance.InstanceTransfer) bool { return row.ToUserID == userID })
+		if err != nil {
+			return err
+		}
+		current, err := s.instances.InstanceForUpdate(ctx, tx, transfer.InstanceNo)
+		if err != nil {
+			return err
+		}
+		if current.UserID != transfer.FromUserID || !domaininstance.CanTransfer(current.Status) {
+			return apperrors.ErrConflict.WithMessage("实例状态已变化，不能接收")
+		}
+		now := time.Now()
+		moved, err = s.instances.MoveInstanceOwner(ctx, tx, current, userID, transfer.TransferNo, now)
+		if err != nil {
+			return err
+		}
+		if err := s.instances.UpdateInstanceTransfer(ctx, tx, transfer.ID, map[string]any{"status": domaininstance.TransferStatusCompleted, "responded_at": now, "completed_at": now}); err != nil {
+			return err
+		}
+		if err := s.notifyTransferCompleted(ctx, tx, transfer); err != nil {
+			return err
+		}
+		instanceNo = current.InstanceNo
+		if err := s.logs.Business(ctx, tx, weblogging.Snapshot(transfer.FromUserID, "", ""), "instance", "instance.transfer.complete", "instance", current.InstanceNo, "实例已转出："+transferNo); err != nil {
+			return err
+		}
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "instance", "instance.transfer.accept", "instance", current.InstanceNo, fmt.Sprintf("接收实例转移：%s，取消原所有者待支付订单 %d 个，迁移工单 %d 个", transferNo, len(moved.CancelledOrderNos), moved.MovedTickets))
+	})
+	if err != nil {
+		return webdto.InstanceTransferItem{}, err
+	}
+	if moved.DetachedGroups {
+		if row, err := s.instances.UserInstance(ctx, userID, instanceNo); err == nil {
+			s.applyFirewall(ctx, row)
+		}
+	}
+	return s.instanceTransfer(ctx, userID, transferNo)
+}
+
+// RejectInstanceTransfer 由接收方拒绝转移，实例归属不变并通知发起方。
+func (s *Service) RejectInstanceTransfer(ctx context.Context, userID uint64, transferNo string) (webdto.InstanceTransferItem, error) {
+	transferNo = strings.TrimSpace(transferNo)
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		transfer, err := s.pendingTransferForUpdate(ctx, tx, transferNo, func(row mysqlinstance.InstanceTransfer) bool { return row.ToUserID == userID })
+		if err != nil {
+			return err
+		}
+		if err := s.instances.UpdateInstanceTransfer(ctx, tx, transfer.ID, map[string]any{"status": domaininstance.TransferStatusRejected, "responded_at": time.Now()}); err != nil {
+			return err
+		}
+		sender, err := s.users.FindUserByID(ctx, tx, transfer.FromUserID)
+		if err != nil {
+			return err
+		}
+		content := fmt.Sprintf("实例 %s 的转移请求已被接收方拒绝，实例仍归属你的账号。", transfer.InstanceNo)
+		if err := s.notifyTransfer(ctx, tx, sender, transfer, "FROM", domaininstance.NotificationSceneTransferRejected, "实例转移被拒绝", content); err != nil {
+			return err
+		}
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "instance", "instance.transfer.reject", "instance", transfer.InstanceNo, "拒绝实例转移："+transferNo)
+	})
+	if err != nil {
+		return webdto.InstanceTransferItem{}, err
+	}
+	return s.instanceTransfer(ctx, userID, transferNo)
+}
+
+// CancelInstanceTransfer 由发起方撤销尚未被处理的转移。
+func (s *Service) CancelInstanceTransfer(ctx context.Context, userID uint64, transferNo string) (webdto.InstanceTransferItem, error) {
+	transferNo = strings.TrimSpace(transferNo)
+	err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		transfer, err := s.pendingTransferForUpdate(ctx, tx, transferNo, func(row mysqlinstance.InstanceTransfer) bool { return row.FromUserID == userID })
+		if err != nil {
+			return err
+		}
+		if err := s.instances.UpdateInstanceTransfer(ctx, tx, transfer.ID, map[string]any{"status": domaininstance.TransferStatusCancelled, "responded_at": time.Now()}); err != nil {
+			return err
+		}
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "instance", "instance.transfer.cancel", "instance", transfer.InstanceNo, "撤销实例转移："+transferNo)
+	})
+	if err != nil {
+		return webdto.InstanceTransferItem{}, err
+	}
+	return s.instanceTransfer(ctx, userID, transferNo)
+}
+
+// pendingTransferForUpdate 锁定转移记录并校验当前用户是否为对应一方；非当事方按不存在处理。
+func (s *Service) pendingTransferForUpdate(ctx context.Context, tx *gorm.DB, transferNo string, allowed func(mysqlinstance.InstanceTransfer) bool) (mysqlinstance.InstanceTransfer, error) {
+	transfer, err := s.instances.InstanceTransferForUpdate(ctx, tx, transferNo)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !allowed(transfer)) {
+		return mysqlinstance.InstanceTransfer{}, apperrors.ErrNotFound.WithMessage("转移记录不存在")
+	}
+	if err != nil {
+		return mysqlinstance.InstanceTransfer{}, err
+	}
+	if transfer.Status != domaininstance.TransferStatusPending {
+		return mysqlinstance.InstanceTransfer{}, apperrors.ErrConflict.WithMessage("转移请求已处理")
+	}
+	return transfer, nil
+}
+
+// requireRecipientRealName 复用下单实名规则校验接收方；未开启下单实名要求时直接通过。
+func (s *Service) requireRecipientRealName(ctx context.Context, userID uint64) error {
+	if s.realName == nil {
+		return nil
+	}
+	err := s.realName.RequireApprovedForOrder(ctx, userID)
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) && appErr.Code == apperrors.ErrForbidden.Code {
+		return apperrors.ErrForbidden.WithMessage("请先完成实名认证后再接收实例")
+	}
+	return err
+}
+
+// notifyTransferCompleted 通知原所有者和接收方转移已完成。
+func (s *Service) notifyTransferCompleted(ctx context.Context, tx *gorm.DB, transfer mysqlinstance.InstanceTransfer) error {
+	sender, err := s.users.FindUserByID(ctx, tx, transfer.FromUserID)
+	if err != nil {
+		return err
+	}
+	recipient, err := s.users.FindUserByID(ctx, tx, transfer.ToUserID)
+	if err != nil {
+		return err
+	}
+	if err := s.notifyTransfer(ctx, tx, sender, transfer, "FROM", domaininstance.NotificationSceneTransferCompleted, "实例已转出", fmt.Sprintf("实例 %s 已转移到其他账号，相关待支付的续费订单已取消。", transfer.InstanceNo)); err != nil {
+		return err
+	}
+	return s.notifyTransfer(ctx, tx, recipient, transfer, "TO", domaininstance.NotificationSceneTransferCompleted, "实例已转入", fmt.Sprintf("实例 %s 已转入你的账号，请按需重新绑定安全组并检查续费设置。原 root 密码已作废，系统将在实例运行时自动重置，完成后可在实例详情查看新密码；原所有者注入的 SSH 公钥仍保留在系统内，请登录后清理或重装系统。", transfer.InstanceNo))
+}
+
+// notifyTransfer 为转移当事一方创建邮件通知；未填写邮箱的账号跳过。side 区分同一转移发给双方的通知编号。
+func (s *Service) notifyTransfer(ctx context.Context, tx *gorm.DB, user mysqluser.User, transfer mysqlinstance.InstanceTransfer, side string, scene string, subject string, content string) error {
+	if strings.TrimSpace(user.Email) == "" {
+		return nil
+	}
+	notificationNo := fmt.Sprintf("NTF-%s-%s-%s-EMAIL", transfer.TransferNo, strings.ToUpper(strings.TrimPrefix(scene, "instance_transfer_")), side)
+	return s.instances.EnqueueEmailNotification(ctx, tx, mysqlinstance.Notification{NotificationNo: notificationNo, UserID: user.ID, Scene: scene, Target: user.Email, Subject: stringPtr(subject), ContentSummary: stringPtr(content), RelatedObjectType: stringPtr("instance"), RelatedObjectNo: stringPtr(transfer.InstanceNo)})
+}
+
+func (s *Service) instanceTransfer(ctx context.Context, userID uint64, transferNo string) (webdto.InstanceTransferItem, error) {
+	row, err := s.instances.InstanceTransferRow(ctx, transferNo)
+	if err != nil {
+		return webdto.InstanceTransferItem{}, err
+	}
+	return instanceTransferItem(userID, row), nil
+}
+
+func instanceTransferItem(userID uint64, row mysqlinstance.InstanceTransferRow) webdto.InstanceTransferItem {
+	direction := transferDirectionIncoming
+	if row.FromUserID == userID {
+		direction = transferDirectionOutgoing
+	}
+	return webdto.InstanceTransferItem{TransferNo: row.TransferNo, InstanceNo: row.InstanceNo, Direction: direction, FromUsername: row.FromUsername, ToUsername: row.ToUsername, Status: row.Status, Remark: row.Remark, Forced: row.ForcedBy != nil, RespondedAt: row.RespondedAt, CompletedAt: row.CompletedAt, CreatedAt: row.CreatedAt}
+}
@@ -10,10 +10,14 @@ import (
 
 	"gorm.io/gorm"
 
+	domaininstance "github.com/AeolianCloud/pveCloud/server/internal/domain/instance"
 	domainorder "github.com/AeolianCloud/pveCloud/server/internal/domain/order"
+	domainuser "github.com/AeolianCloud/pveCloud/server/internal/domain/user"
 	mysqlorder "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/order"
 	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	mysqluser "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/user"
 	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/sshkey"
 	"github.com/AeolianCloud/pveCloud/server/internal/shared/textutil"
 	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
 	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
@@ -29,12 +33,13 @@ const (
 type Service struct {
 	db       *gorm.DB
 	orders   *mysqlorder.Repository
+	users    *mysqluser.Repository
 	realName *webrealname.RealNameService
 	logs     *weblogging.Recorder
 }
 
 func NewService(db *gorm.DB, realName *webrealname.RealNameService) *Service {
-	return &Service{db: db, orders: mysqlorder.NewRepository(db), realName: realName, logs: weblogging.NewRecorder(db)}
+	return &Service{db: db, orders: mysqlorder.NewRepository(db), users: mysqluser.NewRepository(db), realName: realName, logs: weblogging.NewRecorder(db)}
 }
 
 func (s *Service) Create(ctx context.Context, userID uint64, req webdto.OrderCreateRequest) (webdto.OrderDetail, error) {
@@ -63,6 +68,19 @@ func (s *Service) Create(ctx context.Context, userID uint64, req webdto.OrderCre
 		return webdto.OrderDetail{}, err
 	}
 	order := orderFromSelection(userID, clientToken, req, selection)
+	if backupNo := textutil.NormalizeOptionalString(req.SourceBackupNo); backupNo != nil {
+		if err := s.validateSourceBackup(ctx, userID, *backupNo, selection); err != nil {
+			return webdto.OrderDetail{}, err
+		}
+		order.SourceBackupNo = backupNo
+	}
+	if len(req.SSHKeyNos) > 0 {
+		keys, err := s.orderSSHKeys(ctx, userID, req.SSHKeyNos)
+		if err != nil {
+			return webdto.OrderDetail{}, err
+		}
+		order.SSHKeys = &keys
+	}
 	if err := mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error { return s.orders.Create(ctx, tx, &order) }); err != nil {
 		if existing, findErr := s.orders.FindByUserClientToken(ctx, userID, clientToken); findErr == nil {
 			return webOrderDetail(existing), nil
@@ -131,6 +149,56 @@ func (s *Service) Cancel(ctx context.Context, userID uint64, orderNo string, req
 	return webOrderDetail(updated), nil
 }
 
+// validateSourceBackup 校验恢复来源备份属于当前用户且可用，目标套餐必须沿用备份的系统模板且磁盘不小于备份时规格。
+func (s *Service) validateSourceBackup(ctx context.Context, userID uint64, backupNo string, selection mysqlorder.CatalogSelection) error {
+	backup, err := s.orders.UserBackup(ctx, userID, backupNo)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperrors.ErrValidation.WithMessage("恢复来源备份不存在")
+	}
+	if err != nil {
+		return err
+	}
+	if backup.Status != domaininstance.BackupStatusAvailable {
+		return apperrors.ErrConflict.WithMessage("恢复来源备份当前不可用")
+	}
+	if backup.TemplateNo != selection.TemplateNo {
+		return apperrors.ErrValidation.WithMessage("恢复为新实例时系统模板必须与备份一致")
+	}
+	if selection.SystemDiskGB < backup.SystemDiskGB || selection.DataDiskGB < backup.DataDiskGB {
+		return apperrors.ErrValidation.WithMessage("目标套餐磁盘容量小于备份时规格")
+	}
+	return nil
+}
+
+// orderSSHKeys 校验所选 SSH 公钥均属于当前用户，并返回合并去重后的 authorized_keys 快照。
+// 快照写入订单后，用户删除或修改公钥不影响待交付订单。
+func (s *Service) orderSSHKeys(ctx context.Context, userID uint64, keyNos []string) (string, error) {
+	unique := make([]string, 0, len(keyNos))
+	seen := map[string]bool{}
+	for _, keyNo := range keyNos {
+		keyNo = strings.TrimSpace(keyNo)
+		if keyNo != "" && !seen[keyNo] {
+			seen[keyNo] = true
+			unique = append(unique, keyNo)
+		}
+	}
+	if len(unique) > domainuser.MaxOrderSSHKeys {
+		return "", apperrors.ErrValidation.WithMessage(fmt.Sprintf("单个订单最多选择 %d 个 SSH 公钥", domainuser.MaxOrderSSHKeys))
+	}
+	rows, err := s.users.UserSSHKeysByNos(ctx, nil, userID, unique)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) != len(unique) {
+		return "", apperrors.ErrValidation.WithMessage("SSH 公钥不存在")
+	}
+	keys := make([]string, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.PublicKey)
+	}
+	return sshkey.Merge(keys...), nil
+}
+
 func orderFromSelection(userID uint64, clientToken string, req webdto.OrderCreateRequest, selection mysqlorder.CatalogSelection) mysqlorder.Order {
 	return mysqlorder.Order{OrderNo: fmt.Sprintf("ORD-%d", time.Now().UnixNano()), UserID: userID, ClientToken: clientToken, Status: domainorder.StatusPending, OrderType: domainorder.TypePurchase, PaymentStatus: domainorder.PaymentStatusUnpaid, ProductNo: selection.ProductNo, ProductType: selection.ProductType, ProductName: selection.ProductName, ProductSummary: selection.ProductSummary, PlanNo: selection.PlanNo, PlanCode: selection.PlanCode, PlanName: selection.PlanName, PlanSummary: selection.PlanSummary, CPUCores: selection.CPUCores, MemoryMB: selection.MemoryMB, SystemDiskGB: selection.SystemDiskGB, DataDiskGB: selection.DataDiskGB, BandwidthMbps: selection.BandwidthMbps, TrafficGB: selection.TrafficGB, PublicIPCount: selection.PublicIPCount, Virtualization: selection.Virtualization, Architecture: selection.Architecture, BillingCycle: selection.BillingCycle, PriceCents: selection.PriceCents, OriginalPriceCents: selection.OriginalPriceCents, Currency: selection.Currency, Quantity: 1, TotalAmountCents: selection.PriceCents, RegionNo: selection.RegionNo, RegionCode: selection.RegionCode, RegionName: selection.RegionName, NetworkTypeNo: selection.NetworkTypeNo, NetworkTypeCode: selection.NetworkTypeCode, NetworkTypeName: selection.NetworkTypeName, TemplateNo: selection.TemplateNo, TemplateCode: selection.TemplateCode, TemplateName: selection.TemplateName, OSFamily: selection.OSFamily, OSDistribution: selection.OSDistribution, OSVersion: selection.OSVersion, OSArchitecture: selection.OSArchitecture, UserNote: textutil.NormalizeOptionalString(req.UserNote)}
 }
@@ -148,7 +216,7 @@ func webOrderItem(order mysqlorder.Order) webdto.OrderItem {
 }
 
 func webOrderDetail(order mysqlorder.Order) webdto.OrderDetail {
-	return webdto.OrderDetail{OrderItem: webOrderItem(order), UserNote: order.UserNote, ProductNo: order.ProductNo, ProductType: order.ProductType, ProductSummary: order.ProductSummary, PlanNo: order.PlanNo, PlanCode: order.PlanCode, PlanSummary: order.PlanSummary, CPUCores: order.CPUCores, MemoryMB: order.MemoryMB, SystemDiskGB: order.SystemDiskGB, DataDiskGB: order.DataDiskGB, BandwidthMbps: order.BandwidthMbps, TrafficGB: order.TrafficGB, PublicIPCount: order.PublicIPCount, Virtualization: order.Virtualization, Architecture: order.Architecture, PriceCents: order.PriceCents, OriginalPriceCents: order.OriginalPriceCents, Quantity: order.Quantity, RegionNo: order.RegionNo, RegionCode: order.RegionCode, RegionName: order.RegionName, NetworkTypeNo: order.NetworkTypeNo, NetworkTypeCode: order.NetworkTypeCode, NetworkTypeName: order.NetworkTypeName, TemplateNo: order.TemplateNo, TemplateCode: order.TemplateCode, TemplateName: order.TemplateName, OSFamily: order.OSFamily, OSDistribution: order.OSDistribution, OSVersion: order.OSVersion, OSArchitecture: order.OSArchitecture}
+	return webdto.OrderDetail{OrderItem: webOrderItem(order), UserNote: order.UserNote, SourceBackupNo: order.SourceBackupNo, ChangeFromPlanNo: order.ChangeFromPlanNo, CreditAmountCents: order.CreditAmountCents, ProductNo: order.ProductNo, ProductType: order.ProductType, ProductSummary: order.ProductSummary, PlanNo: order.PlanNo, PlanCode: order.PlanCode, PlanSummary: order.PlanSummary, CPUCores: order.CPUCores, MemoryMB: order.MemoryMB, SystemDiskGB: order.SystemDiskGB, DataDiskGB: order.DataDiskGB, BandwidthMbps: order.BandwidthMbps, TrafficGB: order.TrafficGB, PublicIPCount: order.PublicIPCount, Virtualization: order.Virtualization, Architecture: order.Architecture, PriceCents: order.PriceCents, OriginalPriceCents: order.OriginalPriceCents, Quantity: order.Quantity, RegionNo: order.RegionNo, RegionCode: order.RegionCode, RegionName: order.RegionName, NetworkTypeNo: order.NetworkTypeNo, NetworkTypeCode: order.NetworkTypeCode, NetworkTypeName: order.NetworkTypeName, TemplateNo: order.TemplateNo, TemplateCode: order.TemplateCode, TemplateName: order.TemplateName, OSFamily: order.OSFamily, OSDistribution: order.OSDistribution, OSVersion: order.OSVersion, OSArchitecture: order.OSArchitecture}
 }
 
 func normalizePage(page, perPage int) (int, int) {
@@ -100,6 +100,9 @@ func (s *Service) Create(ctx context.Context, userID uint64, orderNo string, req
 	if order.Status != domainorder.StatusPending || order.PaymentStatus != domainorder.PaymentStatusUnpaid {
 		return webdto.PaymentStatus{}, apperrors.ErrConflict.WithMessage("当前订单不可支付")
 	}
+	if err := s.ensureRenewalPayable(ctx, nil, order); err != nil {
+		return webdto.PaymentStatus{}, err
+	}
 	now := time.Now()
 	row := mysqlpayment.PaymentTransaction{
 		PaymentNo:   fmt.Sprintf("PAY-%d", now.UnixNano()),
@@ -249,6 +252,9 @@ func (s *Service) createWalletPayment(ctx context.Context, userID uint64, orderN
 		if lockedOrder.Status != domainorder.StatusPending || lockedOrder.PaymentStatus != domainorder.PaymentStatusUnpaid {
 			return apperrors.ErrConflict.WithMessage("当前订单不可支付")
 		}
+		if err := s.ensureRenewalPayable(ctx, tx, lockedOrder); err != nil {
+			return err
+		}
 		account, err := s.ensureWalletAccountForUpdate(ctx, tx, userID)
 		if err != nil {
 			return err
@@ -292,6 +298,20 @@ func (s *Service) createWalletPayment(ctx context.Context, userID uint64, orderN
 	return s.statusFromPayment(ctx, created)
 }
 
+// ensureRenewalPayable 拒绝支付实例有未完成变更套餐订单的续费订单：续费订单按变更前的套餐计价，
+// 变更差价又按变更前的到期时间折算，两者交错会少付新套餐在续费期内的差价。
+func (s *Service) ensureRenewalPayable(ctx context.Context, tx *gorm.DB, order mysqlorder.Order) error {
+	if order.OrderType != domainorder.TypeRenewal || order.RelatedInstanceNo == nil {
+		return nil
+	}
+	if _, err := s.orders.ActiveChangePlanByInstanceNo(ctx, tx, *order.RelatedInstanceNo); err == nil {
+		return apperrors.ErrConflict.WithMessage("实例有未完成的变更套餐订单，续费订单暂不可支付")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
 func (s *Service) ApplyPaidForAdmin(ctx context.Context, paymentNo string) error {
 	payment, err := s.payments.PaymentByNo(ctx, strings.TrimSpace(paymentNo))
 	if err != nil {
@@ -359,9 +379,28 @@ func (s *Service) applyPaid(ctx context.Context, tx *gorm.DB, payment mysqlpayme
 	if order.OrderType == domainorder.TypeRenewal {
 		return s.applyRenewal(ctx, tx, order, payment, now)
 	}
+	if order.OrderType == domainorder.TypeChangePlan {
+		return s.applyChangePlan(ctx, tx, order, now)
+	}
 	return s.enqueueProvision(ctx, tx, order, payment, now)
 }
 
+// applyChangePlan 在升配差价支付成功后投递规格调整任务；上游调用不在支付事务内执行。
+func (s *Service) applyChangePlan(ctx context.Context, tx *gorm.DB, order mysqlorder.Order, now time.Time) error {
+	if order.RelatedInstanceNo == nil || strings.TrimSpace(*order.RelatedInstanceNo) == "" {
+		return apperrors.ErrConflict.WithMessage("变更套餐订单未关联实例")
+	}
+	if err := s.orders.Update(ctx, tx, order.ID, map[string]any{"status": domainorder.StatusProvisioning}); err != nil {
+		return err
+	}
+	objectType := "order"
+	objectNo := order.OrderNo
+	key := domaininstance.TaskTypeChangePlan + ":" + order.OrderNo
+	payload, _ := json.Marshal(map[string]string{"order_no": order.OrderNo})
+	task := mysqlinstance.Task{TaskNo: fmt.Sprintf("TASK-%d", time.Now().UnixNano()), TaskType: domaininstance.TaskTypeChangePlan, IdempotencyKey: &key, Status: domaininstance.TaskStatusPending, ObjectType: &objectType, ObjectNo: &objectNo, Payload: stringPtr(string(payload)), MaxAttempts: 10, ScheduledAt: now}
+	return s.instances.CreateTaskIgnoreDuplicate(ctx, tx, &task)
+}
+
 func (s *Service) applyRenewal(ctx context.Context, tx *gorm.DB, order mysqlorder.Order, payment mysqlpayment.PaymentTransaction, now time.Time) error {
 	if order.RelatedInstanceNo == nil || strings.TrimSpace(*order.RelatedInstanceNo) == "" {
 		return apperrors.ErrConflict.WithMessage("续费订单未关联实例")
@@ -379,6 +418,12 @@ func (s *Service) applyRenewal(ctx context.Context, tx *gorm.DB, order mysqlorde
 	if err := s.instances.UpdateInstance(ctx, tx, instance.ID, renewalInstanceUpdates(s.lifecycle, after)); err != nil {
 		return err
 	}
+	// 到期暂停的实例续费后自动解除暂停；后台人工暂停不随续费解除。
+	if domaininstance.ResumesOnRenewal(instance.Status, valueOf(instance.SuspendSource)) {
+		if err := s.instances.UnsuspendInstance(ctx, tx, instance.ID); err != nil {
+			return err
+		}
+	}
 	if err := s.enqueueLifecycleTasks(ctx, tx, instance.InstanceNo, after); err != nil {
 		return err
 	}
@@ -550,6 +595,11 @@ func (s *Service) enqueueLifecycleTasks(ctx context.Context, tx *gorm.DB, instan
 	if err := s.instances.CreateTaskIgnoreDuplicate(ctx, tx, &noticeTask); err != nil {
 		return err
 	}
+	if s.lifecycle.AutoSuspendEnabled {
+		if err := s.instances.EnqueueExpirySuspend(ctx, tx, objectNo, expiresAt, string(payload)); err != nil {
+			return err
+		}
+	}
 	if !s.lifecycle.AutoReleaseEnabled {
 		return nil
 	}
@@ -17,6 +17,7 @@ import (
 	domainpayment "github.com/AeolianCloud/pveCloud/server/internal/domain/payment"
 	integrationpayment "github.com/AeolianCloud/pveCloud/server/internal/integration/payment"
 	"github.com/AeolianCloud/pveCloud/server/internal/platform/config"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
 	"github.com/AeolianCloud/pveCloud/server/internal/testutil/mysqltest"
 	"github.com/AeolianCloud/pveCloud/server/internal/usecase/paymentalert"
 	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
@@ -99,6 +100,70 @@ func TestCallbackPaidRenewalExtendsInstanceAndCreatesEffect(t *testing.T) {
 	}
 }
 
+func TestCallbackPaidRenewalResumesOnlyExpirySuspendedInstance(t *testing.T) {
+	for _, tc := range []struct {
+		source string
+		want   string
+	}{
+		{source: "expiry", want: "stopped"},
+		{source: "admin", want: "suspended"},
+	} {
+		t.Run(tc.source, func(t *testing.T) {
+			db := mysqltest.Open(t)
+			mysqltest.Exec(t, db, paymentSystemConfigsSchema, paymentOrdersSchema, paymentTransactionsSchema, paymentInstancesSchema, paymentAsyncTasksSchema, paymentEffectsSchema)
+			seedPaymentConfigs(t, db)
+			instanceNo := "INS-renew-suspended-1"
+			seedOrder(t, db, 22, "ORD-renew-pay-1", domainorder.TypeRenewal, &instanceNo, domainorder.StatusPending, domainorder.PaymentStatusUnpaid)
+			seedInstance(t, db, 22, instanceNo, time.Now().Add(-time.Hour))
+			if err := db.Exec(`UPDATE instances SET status = ?, suspend_source = ?, suspend_reason = ?, suspended_at = ? WHERE instance_no = ?`, "suspended", tc.source, "暂停", time.Now(), instanceNo).Error; err != nil {
+				t.Fatalf("suspend instance: %v", err)
+			}
+			seedPayment(t, db, 22, "PAY-renew-1", "ORD-renew-pay-1", domainpayment.ProviderAlipay, domainpayment.MethodAlipayPage, domainpayment.StatusPending)
+
+			service := NewService(db, config.InstanceLifecycleConfig{}, fakePaymentRegistry())
+			if err := service.HandleCallback(context.Background(), domainpayment.ProviderAlipay, httptest.NewRequest("POST", "/api/payment-callbacks/alipay", nil)); err != nil {
+				t.Fatalf("handle paid callback: %v", err)
+			}
+			var instance struct {
+				Status        string
+				SuspendSource string `gorm:"column:suspend_source"`
+			}
+			if err := db.Raw(`SELECT status, COALESCE(suspend_source, '') AS suspend_source FROM instances WHERE instance_no = ?`, instanceNo).Scan(&instance).Error; err != nil {
+				t.Fatalf("load instance: %v", err)
+			}
+			if instance.Status != tc.want {
+				t.Fatalf("renewal of %s suspended instance should leave it %s, got %#v", tc.source, tc.want, instance)
+			}
+			if tc.want == "stopped" && instance.SuspendSource != "" {
+				t.Fatalf("resumed instance should clear the suspend source, got %#v", instance)
+			}
+		})
+	}
+}
+
+func TestCreatePaymentRejectsRenewalWhileChangePlanActive(t *testing.T) {
+	db := mysqltest.Open(t)
+	mysqltest.Exec(t, db, paymentSystemConfigsSchema, paymentOrdersSchema, paymentTransactionsSchema, paymentInstancesSchema, paymentAsyncTasksSchema, paymentEffectsSchema)
+	seedPaymentConfigs(t, db)
+	instanceNo := "INS-renew-change-1"
+	seedOrder(t, db, 22, "ORD-renew-change-1", domainorder.TypeRenewal, &instanceNo, domainorder.StatusPending, domainorder.PaymentStatusUnpaid)
+	// 变更套餐订单已支付、规格调整尚未完成。
+	seedOrder(t, db, 23, "ORD-change-1", domainorder.TypeChangePlan, &instanceNo, domainorder.StatusProvisioning, domainorder.PaymentStatusPaid)
+
+	service := NewService(db, config.InstanceLifecycleConfig{}, fakePaymentRegistry())
+	_, err := service.Create(context.Background(), 22, "ORD-renew-change-1", webdto.PaymentCreateRequest{Provider: domainpayment.ProviderWechat, Method: domainpayment.MethodWechatNative, ClientToken: "renew-change-token"})
+	if apperrors.From(err).Code != apperrors.ErrConflict.Code {
+		t.Fatalf("renewal payment should conflict with an active change plan order, got %v", err)
+	}
+	var count int64
+	if err := db.Table("payment_transactions").Where("order_no = ?", "ORD-renew-change-1").Count(&count).Error; err != nil {
+		t.Fatalf("count payments: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("rejected renewal payment should not create a payment, got %d", count)
+	}
+}
+
 func TestCreatePaymentFailureWritesAlertEvent(t *testing.T) {
 	db := mysqltest.Open(t)
 	mysqltest.Exec(t, db, paymentSystemConfigsSchema, paymentOrdersSchema, paymentTransactionsSchema, paymentBackendRuntimeLogsSchema)
@@ -344,6 +409,9 @@ CREATE TABLE orders (
   status VARCHAR(32) NOT NULL DEFAULT 'pending',
   order_type VARCHAR(32) NOT NULL DEFAULT 'purchase',
   related_instance_no VARCHAR(64) NULL,
+  change_from_plan_no VARCHAR(64) NULL,
+  source_backup_no VARCHAR(64) NULL,
+  ssh_keys TEXT NULL,
   product_no VARCHAR(64) NOT NULL,
   product_type VARCHAR(32) NOT NULL,
   product_name VARCHAR(128) NOT NULL,
@@ -363,6 +431,7 @@ CREATE TABLE orders (
   currency VARCHAR(16) NOT NULL DEFAULT 'CNY',
   quantity INT NOT NULL DEFAULT 1,
   total_amount_cents BIGINT UNSIGNED NOT NULL,
+  credit_amount_cents BIGINT UNSIGNED NOT NULL DEFAULT 0,
   payment_status VARCHAR(32) NOT NULL DEFAULT 'unpaid',
   paid_at DATETIME(3) NULL,
   payment_provider VARCHAR(32) NULL,
@@ -452,6 +521,9 @@ CREATE TABLE instances (
   expires_at DATETIME(3) NULL,
   expire_notice_sent_at DATETIME(3) NULL,
   expire_release_scheduled_at DATETIME(3) NULL,
+  suspend_source VARCHAR(32) NULL,
+  suspend_reason VARCHAR(500) NULL,
+  suspended_at DATETIME(3) NULL,
   created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
   updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
   released_at DATETIME(3) NULL,
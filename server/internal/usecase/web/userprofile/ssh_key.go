@@ -0,0 +1,97 @@
+package userprofile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	domainuser "github.com/AeolianCloud/pveCloud/server/internal/domain/user"
+	mysqltx "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/tx"
+	mysqluser "github.com/AeolianCloud/pveCloud/server/internal/repository/mysql/user"
+	apperrors "github.com/AeolianCloud/pveCloud/server/internal/shared/errors"
+	"github.com/AeolianCloud/pveCloud/server/internal/shared/sshkey"
+	webdto "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/dto"
+	weblogging "github.com/AeolianCloud/pveCloud/server/internal/usecase/web/logging"
+)
+
+/**
+ * ListSSHKeys 返回当前登录用户保存的 SSH 公钥。
+ */
+func (s *UserProfileService) ListSSHKeys(ctx context.Context, userID uint64) (webdto.SSHKeyList, error) {
+	rows, err := s.users.UserSSHKeys(ctx, userID)
+	if err != nil {
+		return webdto.SSHKeyList{}, err
+	}
+	items := make([]webdto.SSHKeyItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, sshKeyItem(row))
+	}
+	return webdto.SSHKeyList{Limit: domainuser.MaxSSHKeys, List: items}, nil
+}
+
+/**
+ * CreateSSHKey 校验并保存当前登录用户的 SSH 公钥，同一用户下指纹不可重复。
+ */
+func (s *UserProfileService) CreateSSHKey(ctx context.Context, userID uint64, req webdto.SSHKeyCreateRequest) (webdto.SSHKeyItem, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return webdto.SSHKeyItem{}, apperrors.ErrValidation.WithMessage("公钥名称不能为空")
+	}
+	parsed, err := sshkey.Parse(req.PublicKey)
+	if err != nil {
+		return webdto.SSHKeyItem{}, apperrors.ErrValidation.WithMessage(err.Error())
+	}
+	var created mysqluser.UserSSHKey
+	err = mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		// 锁定用户行，串行化同一用户的并发新增，保证数量上限准确。
+		if _, err := s.users.FindUserByIDForUpdate(ctx, tx, userID); errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrUnauthorized
+		} else if err != nil {
+			return err
+		}
+		total, err := s.users.CountUserSSHKeys(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if total >= domainuser.MaxSSHKeys {
+			return apperrors.ErrConflict.WithMessage(fmt.Sprintf("最多只能保存 %d 个 SSH 公钥", domainuser.MaxSSHKeys))
+		}
+		created = mysqluser.UserSSHKey{KeyNo: fmt.Sprintf("SSHK-%d", time.Now().UnixNano()), UserID: userID, Name: name, KeyType: parsed.Type, PublicKey: parsed.AuthorizedKey, Fingerprint: parsed.Fingerprint}
+		if err := s.users.CreateUserSSHKey(ctx, tx, &created); err != nil {
+			if isDuplicateEntry(err) {
+				return apperrors.ErrConflict.WithMessage("该 SSH 公钥已存在")
+			}
+			return err
+		}
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "profile", "ssh_key.create", "ssh_key", created.KeyNo, "新增 SSH 公钥："+parsed.Fingerprint)
+	})
+	if err != nil {
+		return webdto.SSHKeyItem{}, err
+	}
+	return sshKeyItem(created), nil
+}
+
+/**
+ * DeleteSSHKey 删除当前登录用户的 SSH 公钥；已交付实例中注入的公钥不受影响。
+ */
+func (s *UserProfileService) DeleteSSHKey(ctx context.Context, userID uint64, keyNo string) error {
+	keyNo = strings.TrimSpace(keyNo)
+	return mysqltx.NewManager(s.db).WithinContext(ctx, func(tx *gorm.DB) error {
+		affected, err := s.users.DeleteUserSSHKey(ctx, tx, userID, keyNo)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return apperrors.ErrNotFound.WithMessage("SSH 公钥不存在")
+		}
+		return s.logs.Business(ctx, tx, weblogging.Snapshot(userID, "", ""), "profile", "ssh_key.delete", "ssh_key", keyNo, "删除 SSH 公钥")
+	})
+}
+
+func sshKeyItem(row mysqluser.UserSSHKey) webdto.SSHKeyItem {
+	return webdto.SSHKeyItem{KeyNo: row.KeyNo, Name: row.Name, KeyType: row.KeyType, Fingerprint: row.Fingerprint, CreatedAt: row.CreatedAt}
+}
@@ -0,0 +1,17 @@
+-- Instance power operations: graceful reboot, ACPI shutdown and forced reset.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- The new actions reuse `instance_operations` and `instance_operation_sync`
+-- tasks, so only column comments and the existing `instance:operate`
+-- permission description change. No new permission code is introduced.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+ALTER TABLE `instance_operations`
+  MODIFY COLUMN `action` VARCHAR(32) NOT NULL COMMENT '操作：provision/start/stop/reboot/shutdown/reset/release/sync';
+
+UPDATE `admin_permissions`
+SET `description` = '启动、停止、重启、关机和强制重置实例'
+WHERE `code` = 'instance:operate';
@@ -0,0 +1,51 @@
+-- Instance OS reinstall from the plan's allowed server OS templates.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Reinstall reuses `instance_operations` and `instance_operation_sync` tasks.
+-- The target template snapshot is kept in `instance_operations.payload` and is
+-- written back to `instances` only after the MCP operation succeeds.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+ALTER TABLE `instance_operations`
+  MODIFY COLUMN `action` VARCHAR(32) NOT NULL COMMENT '操作：provision/start/stop/reboot/shutdown/reset/reinstall/release/sync';
+
+SET @instance_operations_payload_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instance_operations'
+    AND COLUMN_NAME = 'payload'
+);
+SET @add_instance_operations_payload_sql := IF(
+  @instance_operations_payload_column_exists = 0,
+  'ALTER TABLE `instance_operations` ADD COLUMN `payload` JSON NULL COMMENT ''操作输入快照，不保存敏感原文'' AFTER `error_message`',
+  'SELECT 1'
+);
+PREPARE add_instance_operations_payload_stmt FROM @add_instance_operations_payload_sql;
+EXECUTE add_instance_operations_payload_stmt;
+DEALLOCATE PREPARE add_instance_operations_payload_stmt;
+
+INSERT INTO `admin_permissions` (`code`, `name`, `type`, `parent_code`, `path`, `icon`, `sort_order`, `visible_in_menu`, `group_name`, `description`) VALUES
+  ('instance:reinstall', '重装实例', 'action', 'page.instances', NULL, NULL, 135, 0, '实例管理', '按套餐允许的系统模板重装实例，原系统盘数据将被清除')
+ON DUPLICATE KEY UPDATE
+  `name` = VALUES(`name`),
+  `type` = VALUES(`type`),
+  `parent_code` = VALUES(`parent_code`),
+  `path` = VALUES(`path`),
+  `icon` = VALUES(`icon`),
+  `sort_order` = VALUES(`sort_order`),
+  `visible_in_menu` = VALUES(`visible_in_menu`),
+  `group_name` = VALUES(`group_name`),
+  `description` = VALUES(`description`);
+
+INSERT INTO `admin_role_permissions` (`role_id`, `permission_id`)
+SELECT `admin_roles`.`id`, `admin_permissions`.`id`
+FROM `admin_roles`
+JOIN `admin_permissions`
+WHERE `admin_roles`.`code` = 'super_admin'
+  AND `admin_permissions`.`code` = 'instance:reinstall'
+ON DUPLICATE KEY UPDATE
+  `role_id` = VALUES(`role_id`);
@@ -0,0 +1,74 @@
+-- Instance snapshots with per-plan quota.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Snapshot create/rollback/delete reuse `instance_operations` and
+-- `instance_operation_sync` tasks. The target snapshot is kept in
+-- `instance_operations.payload`; `instance_snapshots.status` is settled when the
+-- MCP operation finishes.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+ALTER TABLE `instance_operations`
+  MODIFY COLUMN `action` VARCHAR(32) NOT NULL COMMENT '操作：provision/start/stop/reboot/shutdown/reset/reinstall/snapshot_create/snapshot_rollback/snapshot_delete/release/sync';
+
+SET @product_plans_snapshot_quota_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'product_plans'
+    AND COLUMN_NAME = 'snapshot_quota'
+);
+SET @add_product_plans_snapshot_quota_sql := IF(
+  @product_plans_snapshot_quota_column_exists = 0,
+  'ALTER TABLE `product_plans` ADD COLUMN `snapshot_quota` INT NOT NULL DEFAULT 0 COMMENT ''每个实例可保留的快照数量，0 表示不提供快照'' AFTER `public_ip_count`',
+  'SELECT 1'
+);
+PREPARE add_product_plans_snapshot_quota_stmt FROM @add_product_plans_snapshot_quota_sql;
+EXECUTE add_product_plans_snapshot_quota_stmt;
+DEALLOCATE PREPARE add_product_plans_snapshot_quota_stmt;
+
+CREATE TABLE IF NOT EXISTS `instance_snapshots` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '快照ID',
+  `snapshot_no` VARCHAR(64) NOT NULL COMMENT '对外快照编号',
+  `instance_id` BIGINT UNSIGNED NOT NULL COMMENT '实例ID',
+  `name` VARCHAR(40) NOT NULL COMMENT 'PVE 快照名',
+  `description` VARCHAR(255) NULL COMMENT '快照说明',
+  `status` VARCHAR(32) NOT NULL DEFAULT 'creating' COMMENT '快照状态：creating/available/deleting/deleted/failed',
+  `created_by_user_id` BIGINT UNSIGNED NULL COMMENT '创建用户ID',
+  `created_by_admin_id` BIGINT UNSIGNED NULL COMMENT '创建管理员ID',
+  `last_rolled_back_at` DATETIME(3) NULL COMMENT '最近回滚完成时间',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  `deleted_at` DATETIME(3) NULL COMMENT '删除完成时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_instance_snapshots_snapshot_no` (`snapshot_no`),
+  UNIQUE KEY `uk_instance_snapshots_instance_name` (`instance_id`, `name`),
+  KEY `idx_instance_snapshots_instance_status` (`instance_id`, `status`),
+  CONSTRAINT `fk_instance_snapshots_instance` FOREIGN KEY (`instance_id`) REFERENCES `instances` (`id`),
+  CONSTRAINT `fk_instance_snapshots_user` FOREIGN KEY (`created_by_user_id`) REFERENCES `users` (`id`),
+  CONSTRAINT `fk_instance_snapshots_admin` FOREIGN KEY (`created_by_admin_id`) REFERENCES `admin_users` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='实例快照';
+
+INSERT INTO `admin_permissions` (`code`, `name`, `type`, `parent_code`, `path`, `icon`, `sort_order`, `visible_in_menu`, `group_name`, `description`) VALUES
+  ('instance:snapshot', '管理实例快照', 'action', 'page.instances', NULL, NULL, 136, 0, '实例管理', '创建、回滚和删除实例快照，回滚会覆盖快照之后的数据')
+ON DUPLICATE KEY UPDATE
+  `name` = VALUES(`name`),
+  `type` = VALUES(`type`),
+  `parent_code` = VALUES(`parent_code`),
+  `path` = VALUES(`path`),
+  `icon` = VALUES(`icon`),
+  `sort_order` = VALUES(`sort_order`),
+  `visible_in_menu` = VALUES(`visible_in_menu`),
+  `group_name` = VALUES(`group_name`),
+  `description` = VALUES(`description`);
+
+INSERT INTO `admin_role_permissions` (`role_id`, `permission_id`)
+SELECT `admin_roles`.`id`, `admin_permissions`.`id`
+FROM `admin_roles`
+JOIN `admin_permissions`
+WHERE `admin_roles`.`code` = 'super_admin'
+  AND `admin_permissions`.`code` = 'instance:snapshot'
+ON DUPLICATE KEY UPDATE
+  `role_id` = VALUES(`role_id`);
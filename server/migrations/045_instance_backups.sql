@@ -0,0 +1,107 @@
+-- Instance backups with scheduled policies and restore.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Backup create/restore reuse `instance_operations` and `instance_operation_sync`
+-- tasks. Scheduled backups are driven by `instance_backup_scheduled` tasks; the
+-- policy row records the next and last planned run so retried tasks stay
+-- idempotent. Restoring a backup as a new instance goes through a normal
+-- purchase order carrying `orders.source_backup_no`.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+ALTER TABLE `instance_operations`
+  MODIFY COLUMN `action` VARCHAR(32) NOT NULL COMMENT '操作：provision/start/stop/reboot/shutdown/reset/reinstall/snapshot_create/snapshot_rollback/snapshot_delete/backup_create/backup_restore/release/sync';
+
+SET @orders_source_backup_no_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'orders'
+    AND COLUMN_NAME = 'source_backup_no'
+);
+SET @add_orders_source_backup_no_sql := IF(
+  @orders_source_backup_no_column_exists = 0,
+  'ALTER TABLE `orders` ADD COLUMN `source_backup_no` VARCHAR(64) NULL COMMENT ''从备份恢复为新实例时的来源备份编号'' AFTER `related_instance_no`',
+  'SELECT 1'
+);
+PREPARE add_orders_source_backup_no_stmt FROM @add_orders_source_backup_no_sql;
+EXECUTE add_orders_source_backup_no_stmt;
+DEALLOCATE PREPARE add_orders_source_backup_no_stmt;
+
+CREATE TABLE IF NOT EXISTS `instance_backup_policies` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '备份策略ID',
+  `instance_id` BIGINT UNSIGNED NOT NULL COMMENT '实例ID',
+  `frequency` VARCHAR(16) NOT NULL COMMENT '备份频率：daily/weekly',
+  `weekday` TINYINT NOT NULL DEFAULT 0 COMMENT '每周备份的星期，0 表示周日，仅 weekly 使用',
+  `hour` TINYINT NOT NULL DEFAULT 0 COMMENT '备份执行小时，按服务端时区',
+  `retention_count` INT NOT NULL DEFAULT 7 COMMENT '定时备份保留份数',
+  `enabled` TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+  `next_run_at` DATETIME(3) NULL COMMENT '下次计划执行时间',
+  `last_run_at` DATETIME(3) NULL COMMENT '最近已推进的计划执行时间',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_instance_backup_policies_instance` (`instance_id`),
+  CONSTRAINT `fk_instance_backup_policies_instance` FOREIGN KEY (`instance_id`) REFERENCES `instances` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='实例定时备份策略';
+
+CREATE TABLE IF NOT EXISTS `instance_backups` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '备份ID',
+  `backup_no` VARCHAR(64) NOT NULL COMMENT '对外备份编号',
+  `instance_id` BIGINT UNSIGNED NOT NULL COMMENT '实例ID',
+  `source` VARCHAR(16) NOT NULL COMMENT '备份来源：manual/scheduled',
+  `status` VARCHAR(32) NOT NULL DEFAULT 'creating' COMMENT '备份状态：creating/available/deleting/deleted/failed',
+  `node` VARCHAR(64) NOT NULL COMMENT '发起备份时的 PVE 节点',
+  `storage` VARCHAR(64) NOT NULL COMMENT '备份存储',
+  `volume_id` VARCHAR(255) NULL COMMENT 'PVE 备份卷 ID',
+  `mode` VARCHAR(16) NOT NULL COMMENT '备份模式：snapshot/suspend/stop',
+  `note` VARCHAR(255) NULL COMMENT '备份说明',
+  `task_no` VARCHAR(64) NULL COMMENT '触发定时备份的任务编号',
+  `cpu_cores` INT NOT NULL COMMENT 'CPU 核数快照',
+  `memory_mb` INT NOT NULL COMMENT '内存 MB 快照',
+  `system_disk_gb` INT NOT NULL COMMENT '系统盘 GB 快照',
+  `data_disk_gb` INT NOT NULL DEFAULT 0 COMMENT '数据盘 GB 快照',
+  `template_no` VARCHAR(64) NOT NULL COMMENT '系统模板编号快照',
+  `template_name` VARCHAR(128) NOT NULL COMMENT '系统模板名称快照',
+  `os_family` VARCHAR(32) NOT NULL COMMENT '系统族快照',
+  `os_distribution` VARCHAR(64) NOT NULL COMMENT '发行版快照',
+  `os_version` VARCHAR(64) NOT NULL COMMENT '系统版本快照',
+  `created_by_user_id` BIGINT UNSIGNED NULL COMMENT '创建用户ID',
+  `created_by_admin_id` BIGINT UNSIGNED NULL COMMENT '创建管理员ID',
+  `completed_at` DATETIME(3) NULL COMMENT '备份完成时间',
+  `last_restored_at` DATETIME(3) NULL COMMENT '最近恢复完成时间',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  `deleted_at` DATETIME(3) NULL COMMENT '删除完成时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_instance_backups_backup_no` (`backup_no`),
+  UNIQUE KEY `uk_instance_backups_task_no` (`task_no`),
+  KEY `idx_instance_backups_instance_source_status` (`instance_id`, `source`, `status`),
+  CONSTRAINT `fk_instance_backups_instance` FOREIGN KEY (`instance_id`) REFERENCES `instances` (`id`),
+  CONSTRAINT `fk_instance_backups_user` FOREIGN KEY (`created_by_user_id`) REFERENCES `users` (`id`),
+  CONSTRAINT `fk_instance_backups_admin` FOREIGN KEY (`created_by_admin_id`) REFERENCES `admin_users` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='实例备份';
+
+INSERT INTO `admin_permissions` (`code`, `name`, `type`, `parent_code`, `path`, `icon`, `sort_order`, `visible_in_menu`, `group_name`, `description`) VALUES
+  ('instance:backup', '管理实例备份', 'action', 'page.instances', NULL, NULL, 137, 0, '实例管理', '创建、恢复和删除实例备份，设置定时备份策略，恢复会覆盖实例当前数据')
+ON DUPLICATE KEY UPDATE
+  `name` = VALUES(`name`),
+  `type` = VALUES(`type`),
+  `parent_code` = VALUES(`parent_code`),
+  `path` = VALUES(`path`),
+  `icon` = VALUES(`icon`),
+  `sort_order` = VALUES(`sort_order`),
+  `visible_in_menu` = VALUES(`visible_in_menu`),
+  `group_name` = VALUES(`group_name`),
+  `description` = VALUES(`description`);
+
+INSERT INTO `admin_role_permissions` (`role_id`, `permission_id`)
+SELECT `admin_roles`.`id`, `admin_permissions`.`id`
+FROM `admin_roles`
+JOIN `admin_permissions`
+WHERE `admin_roles`.`code` = 'super_admin'
+  AND `admin_permissions`.`code` = 'instance:backup'
+ON DUPLICATE KEY UPDATE
+  `role_id` = VALUES(`role_id`);
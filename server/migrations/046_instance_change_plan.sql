@@ -0,0 +1,54 @@
+-- Plan upgrade/downgrade for delivered instances with prorated billing.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Change-plan orders reuse `orders` with `order_type = 'change_plan'`. The
+-- order snapshots the target plan; `total_amount_cents` is the prorated amount
+-- the user pays and `credit_amount_cents` is the prorated amount credited to
+-- the wallet after a downgrade succeeds. The resize itself reuses
+-- `instance_operations` (`action = 'resize'`) and is started by the
+-- `instance_change_plan` worker task.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+ALTER TABLE `orders`
+  MODIFY COLUMN `order_type` VARCHAR(32) NOT NULL DEFAULT 'purchase' COMMENT '订单类型：purchase/renewal/change_plan';
+
+ALTER TABLE `instance_operations`
+  MODIFY COLUMN `action` VARCHAR(32) NOT NULL COMMENT '操作：provision/start/stop/reboot/shutdown/reset/reinstall/resize/snapshot_create/snapshot_rollback/snapshot_delete/backup_create/backup_restore/release/sync';
+
+ALTER TABLE `wallet_ledger_entries`
+  MODIFY COLUMN `entry_type` VARCHAR(32) NOT NULL COMMENT '流水类型：recharge/payment/refund/plan_credit';
+
+SET @orders_change_from_plan_no_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'orders'
+    AND COLUMN_NAME = 'change_from_plan_no'
+);
+SET @add_orders_change_from_plan_no_sql := IF(
+  @orders_change_from_plan_no_column_exists = 0,
+  'ALTER TABLE `orders` ADD COLUMN `change_from_plan_no` VARCHAR(64) NULL COMMENT ''变更套餐前的套餐编号'' AFTER `related_instance_no`',
+  'SELECT 1'
+);
+PREPARE add_orders_change_from_plan_no_stmt FROM @add_orders_change_from_plan_no_sql;
+EXECUTE add_orders_change_from_plan_no_stmt;
+DEALLOCATE PREPARE add_orders_change_from_plan_no_stmt;
+
+SET @orders_credit_amount_cents_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'orders'
+    AND COLUMN_NAME = 'credit_amount_cents'
+);
+SET @add_orders_credit_amount_cents_sql := IF(
+  @orders_credit_amount_cents_column_exists = 0,
+  'ALTER TABLE `orders` ADD COLUMN `credit_amount_cents` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT ''变更套餐降配退还到钱包的金额，单位分'' AFTER `total_amount_cents`',
+  'SELECT 1'
+);
+PREPARE add_orders_credit_amount_cents_stmt FROM @add_orders_credit_amount_cents_sql;
+EXECUTE add_orders_credit_amount_cents_stmt;
+DEALLOCATE PREPARE add_orders_credit_amount_cents_stmt;
@@ -0,0 +1,44 @@
+-- Data disk, system disk size and NIC rate limit at provisioning, with drift check on sync.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Provisioning now sends the plan system disk size, data disk size and NIC
+-- rate limit to MCP PVE. `instance_provision_mappings.data_disk_storage`
+-- overrides the storage pool for the data disk (NULL falls back to `storage`).
+-- Instance sync compares the real VM config with the instance spec snapshot and
+-- records mismatching fields in `instances.config_drift`.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+SET @mappings_data_disk_storage_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instance_provision_mappings'
+    AND COLUMN_NAME = 'data_disk_storage'
+);
+SET @add_mappings_data_disk_storage_sql := IF(
+  @mappings_data_disk_storage_column_exists = 0,
+  'ALTER TABLE `instance_provision_mappings` ADD COLUMN `data_disk_storage` VARCHAR(128) NULL COMMENT ''数据盘存储池，NULL 表示与系统盘相同'' AFTER `disk_interface`',
+  'SELECT 1'
+);
+PREPARE add_mappings_data_disk_storage_stmt FROM @add_mappings_data_disk_storage_sql;
+EXECUTE add_mappings_data_disk_storage_stmt;
+DEALLOCATE PREPARE add_mappings_data_disk_storage_stmt;
+
+SET @instances_config_drift_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'config_drift'
+);
+SET @add_instances_config_drift_sql := IF(
+  @instances_config_drift_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `config_drift` VARCHAR(255) NULL COMMENT ''实际配置偏离规格快照的字段，逗号分隔，NULL 表示一致'' AFTER `last_error_message`, ADD COLUMN `config_checked_at` DATETIME(3) NULL COMMENT ''最近一次配置核对时间'' AFTER `config_drift`, ADD KEY `idx_instances_config_drift` (`config_drift`)',
+  'SELECT 1'
+);
+PREPARE add_instances_config_drift_stmt FROM @add_instances_config_drift_sql;
+EXECUTE add_instances_config_drift_stmt;
+DEALLOCATE PREPARE add_instances_config_drift_stmt;
@@ -0,0 +1,43 @@
+-- Per-user SSH public keys injected into instances at provisioning.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Users manage their own keys in `user_ssh_keys`. When ordering, the selected
+-- keys are snapshotted into `orders.ssh_keys` so later edits do not change a
+-- pending delivery; provisioning and reinstall merge that snapshot with the
+-- optional operator keys on `instance_provision_mappings.ssh_keys`.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+CREATE TABLE IF NOT EXISTS `user_ssh_keys` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'SSH公钥ID',
+  `key_no` VARCHAR(64) NOT NULL COMMENT 'SSH公钥编号',
+  `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+  `name` VARCHAR(64) NOT NULL COMMENT '公钥名称',
+  `key_type` VARCHAR(64) NOT NULL COMMENT '公钥算法，如 ssh-ed25519',
+  `public_key` TEXT NOT NULL COMMENT '规范化后的 authorized_keys 单行公钥',
+  `fingerprint` VARCHAR(128) NOT NULL COMMENT 'OpenSSH SHA256 指纹',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user_ssh_keys_key_no` (`key_no`),
+  UNIQUE KEY `uk_user_ssh_keys_user_fingerprint` (`user_id`, `fingerprint`),
+  CONSTRAINT `fk_user_ssh_keys_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户SSH公钥';
+
+SET @orders_ssh_keys_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'orders'
+    AND COLUMN_NAME = 'ssh_keys'
+);
+SET @add_orders_ssh_keys_sql := IF(
+  @orders_ssh_keys_column_exists = 0,
+  'ALTER TABLE `orders` ADD COLUMN `ssh_keys` TEXT NULL COMMENT ''下单时选择的用户SSH公钥快照，换行分隔'' AFTER `source_backup_no`',
+  'SELECT 1'
+);
+PREPARE add_orders_ssh_keys_stmt FROM @add_orders_ssh_keys_sql;
+EXECUTE add_orders_ssh_keys_stmt;
+DEALLOCATE PREPARE add_orders_ssh_keys_stmt;
@@ -0,0 +1,47 @@
+-- Generated root passwords delivered through cloud-init and self-service password reset.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Provisioning generates a random password, passes it to MCP-PVE as the
+-- cloud-init password and stores only the AES-GCM cipher text encrypted with
+-- `credential.encryption_key`. The owner may reveal it once; the reveal time is
+-- the reveal-once flag. A successful `reset_password` operation replaces the
+-- cipher text and clears the flag.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+ALTER TABLE `instance_operations`
+  MODIFY COLUMN `action` VARCHAR(32) NOT NULL COMMENT '操作：provision/start/stop/reboot/shutdown/reset/reinstall/resize/reset_password/snapshot_create/snapshot_rollback/snapshot_delete/backup_create/backup_restore/release/sync';
+
+SET @instances_root_password_ciphertext_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'root_password_ciphertext'
+);
+SET @add_instances_root_password_ciphertext_sql := IF(
+  @instances_root_password_ciphertext_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `root_password_ciphertext` TEXT NULL COMMENT ''加密保存的 root 密码，禁止保存明文'' AFTER `config_checked_at`',
+  'SELECT 1'
+);
+PREPARE add_instances_root_password_ciphertext_stmt FROM @add_instances_root_password_ciphertext_sql;
+EXECUTE add_instances_root_password_ciphertext_stmt;
+DEALLOCATE PREPARE add_instances_root_password_ciphertext_stmt;
+
+SET @instances_root_password_revealed_at_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'root_password_revealed_at'
+);
+SET @add_instances_root_password_revealed_at_sql := IF(
+  @instances_root_password_revealed_at_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `root_password_revealed_at` DATETIME(3) NULL COMMENT ''用户查看 root 密码的时间，非空表示已查看'' AFTER `root_password_ciphertext`',
+  'SELECT 1'
+);
+PREPARE add_instances_root_password_revealed_at_stmt FROM @add_instances_root_password_revealed_at_sql;
+EXECUTE add_instances_root_password_revealed_at_stmt;
+DEALLOCATE PREPARE add_instances_root_password_revealed_at_stmt;
@@ -0,0 +1,75 @@
+-- IPv4 address pools (IPAM) for static instance networking.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Pools are scoped by region and network type (empty network type matches any
+-- type, like provision mappings). Creating a pool materialises every host
+-- address into `ip_addresses`; the gateway and configured reserved ranges start
+-- as `reserved`. Provisioning locks the matching pools and allocates the plan's
+-- `public_ip_count` addresses in the same transaction that creates the
+-- instance; the first address becomes cloud-init `ipconfig0`. Addresses return
+-- to `available` when the instance reaches `released`. Regions without an
+-- active pool keep using the mapping's static `ip_config0`.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+CREATE TABLE IF NOT EXISTS `ip_pools` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '地址池ID',
+  `pool_no` VARCHAR(64) NOT NULL COMMENT '对外地址池编号',
+  `name` VARCHAR(128) NOT NULL COMMENT '地址池名称',
+  `region_no` VARCHAR(64) NOT NULL COMMENT '地域编号',
+  `network_type_no` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '网络类型编号，空字符串表示不限网络类型',
+  `cidr` VARCHAR(64) NOT NULL COMMENT 'IPv4 网段',
+  `prefix_length` TINYINT UNSIGNED NOT NULL COMMENT '网段前缀长度',
+  `gateway` VARCHAR(64) NOT NULL COMMENT '网关地址',
+  `status` VARCHAR(16) NOT NULL DEFAULT 'active' COMMENT '状态：active/inactive',
+  `remark` VARCHAR(500) NULL COMMENT '备注',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_ip_pools_pool_no` (`pool_no`),
+  UNIQUE KEY `uk_ip_pools_region_cidr` (`region_no`, `cidr`),
+  KEY `idx_ip_pools_region_network_status` (`region_no`, `network_type_no`, `status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='IPv4 地址池';
+
+CREATE TABLE IF NOT EXISTS `ip_addresses` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '地址ID',
+  `pool_id` BIGINT UNSIGNED NOT NULL COMMENT '地址池ID',
+  `address` VARCHAR(64) NOT NULL COMMENT 'IPv4 地址',
+  `status` VARCHAR(16) NOT NULL DEFAULT 'available' COMMENT '状态：available/reserved/allocated',
+  `instance_id` BIGINT UNSIGNED NULL COMMENT '占用实例ID',
+  `instance_no` VARCHAR(64) NULL COMMENT '占用实例编号',
+  `allocated_at` DATETIME(3) NULL COMMENT '分配时间',
+  `remark` VARCHAR(255) NULL COMMENT '保留原因等备注',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_ip_addresses_pool_address` (`pool_id`, `address`),
+  KEY `idx_ip_addresses_pool_status` (`pool_id`, `status`),
+  KEY `idx_ip_addresses_instance` (`instance_id`),
+  CONSTRAINT `fk_ip_addresses_pool` FOREIGN KEY (`pool_id`) REFERENCES `ip_pools` (`id`),
+  CONSTRAINT `fk_ip_addresses_instance` FOREIGN KEY (`instance_id`) REFERENCES `instances` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='IPv4 地址池地址';
+
+INSERT INTO `admin_permissions` (`code`, `name`, `type`, `parent_code`, `path`, `icon`, `sort_order`, `visible_in_menu`, `group_name`, `description`) VALUES
+  ('instance:ip-pool', '管理 IP 地址池', 'action', 'page.instances', NULL, NULL, 138, 0, '实例管理', '创建和维护 IP 地址池，保留或回收地址')
+ON DUPLICATE KEY UPDATE
+  `name` = VALUES(`name`),
+  `type` = VALUES(`type`),
+  `parent_code` = VALUES(`parent_code`),
+  `path` = VALUES(`path`),
+  `icon` = VALUES(`icon`),
+  `sort_order` = VALUES(`sort_order`),
+  `visible_in_menu` = VALUES(`visible_in_menu`),
+  `group_name` = VALUES(`group_name`),
+  `description` = VALUES(`description`);
+
+INSERT INTO `admin_role_permissions` (`role_id`, `permission_id`)
+SELECT `admin_roles`.`id`, `admin_permissions`.`id`
+FROM `admin_roles`
+JOIN `admin_permissions`
+WHERE `admin_roles`.`code` = 'super_admin'
+  AND `admin_permissions`.`code` = 'instance:ip-pool'
+ON DUPLICATE KEY UPDATE
+  `role_id` = VALUES(`role_id`);
@@ -0,0 +1,44 @@
+-- Multi-node placement for instance provisioning.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- A provision mapping may list extra candidate nodes besides its primary
+-- `node`. Provisioning reads live node capacity from MCP-PVE, applies the
+-- configured overcommit ratios and per-user anti-affinity, and creates the VM
+-- on the chosen node. The decision, including every candidate and why it was
+-- rejected, is stored on the provision operation for troubleshooting.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+SET @mappings_placement_nodes_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instance_provision_mappings'
+    AND COLUMN_NAME = 'placement_nodes'
+);
+SET @add_mappings_placement_nodes_sql := IF(
+  @mappings_placement_nodes_column_exists = 0,
+  'ALTER TABLE `instance_provision_mappings` ADD COLUMN `placement_nodes` VARCHAR(500) NULL COMMENT ''额外候选节点，逗号分隔；为空时只使用 node'' AFTER `node`',
+  'SELECT 1'
+);
+PREPARE add_mappings_placement_nodes_stmt FROM @add_mappings_placement_nodes_sql;
+EXECUTE add_mappings_placement_nodes_stmt;
+DEALLOCATE PREPARE add_mappings_placement_nodes_stmt;
+
+SET @operations_placement_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instance_operations'
+    AND COLUMN_NAME = 'placement'
+);
+SET @add_operations_placement_sql := IF(
+  @operations_placement_column_exists = 0,
+  'ALTER TABLE `instance_operations` ADD COLUMN `placement` JSON NULL COMMENT ''交付调度决策：选中节点和各候选节点裁决结果'' AFTER `payload`',
+  'SELECT 1'
+);
+PREPARE add_operations_placement_stmt FROM @add_operations_placement_sql;
+EXECUTE add_operations_placement_stmt;
+DEALLOCATE PREPARE add_operations_placement_stmt;
@@ -0,0 +1,30 @@
+-- Capacity-driven sold-out automation for server plans.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- The worker periodically compares allocated cores/memory/disk of
+-- non-released instances with live node capacity from MCP-PVE (after the
+-- configured overcommit ratios). An `active` plan whose sales regions can no
+-- longer fit one more instance is flipped to `sold_out` and stamped with
+-- `capacity_sold_out_at`; only stamped plans are flipped back to `active` when
+-- capacity returns. Any manual status change clears the stamp, so plans an
+-- operator marks sold out by hand are never reopened automatically.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+SET @plans_capacity_sold_out_at_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'product_plans'
+    AND COLUMN_NAME = 'capacity_sold_out_at'
+);
+SET @add_plans_capacity_sold_out_at_sql := IF(
+  @plans_capacity_sold_out_at_column_exists = 0,
+  'ALTER TABLE `product_plans` ADD COLUMN `capacity_sold_out_at` DATETIME(3) NULL COMMENT ''容量不足自动售罄时间；为空表示状态由人工维护'' AFTER `status`',
+  'SELECT 1'
+);
+PREPARE add_plans_capacity_sold_out_at_stmt FROM @add_plans_capacity_sold_out_at_sql;
+EXECUTE add_plans_capacity_sold_out_at_stmt;
+DEALLOCATE PREPARE add_plans_capacity_sold_out_at_stmt;
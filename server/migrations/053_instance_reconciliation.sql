@@ -0,0 +1,91 @@
+-- Hypervisor/database reconciliation reports.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- The worker periodically lists VMs on every node referenced by provision
+-- mappings or live instances and diffs them against `instances` by
+-- (`external_node`, `external_vmid`). Each run writes one report and one item
+-- per finding:
+--   orphan: a VM inside a mapping's VMID range with no unreleased instance;
+--   ghost:  an instance whose VM is missing from a successfully listed node;
+--   drift:  both exist but power status or CPU/memory differ.
+-- Items stay `open` until an administrator adopts an orphan into a user's
+-- account, marks a ghost as error or deletes an orphan VM.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+CREATE TABLE IF NOT EXISTS `instance_reconcile_reports` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '对账报告ID',
+  `report_no` VARCHAR(64) NOT NULL COMMENT '对外报告编号',
+  `status` VARCHAR(16) NOT NULL DEFAULT 'running' COMMENT '状态：running/succeeded/failed',
+  `task_no` VARCHAR(64) NULL COMMENT '触发的异步任务编号，管理员手动触发时为空',
+  `admin_id` BIGINT UNSIGNED NULL COMMENT '手动触发的管理员ID',
+  `node_count` INT NOT NULL DEFAULT 0 COMMENT '扫描节点数',
+  `vm_count` INT NOT NULL DEFAULT 0 COMMENT '上游 VM 数',
+  `instance_count` INT NOT NULL DEFAULT 0 COMMENT '参与比对的实例数',
+  `orphan_count` INT NOT NULL DEFAULT 0 COMMENT '孤儿 VM 数',
+  `ghost_count` INT NOT NULL DEFAULT 0 COMMENT '幽灵实例数',
+  `drift_count` INT NOT NULL DEFAULT 0 COMMENT '状态或规格漂移数',
+  `node_errors` TEXT NULL COMMENT '列表失败的节点及原因 JSON',
+  `error_message` VARCHAR(500) NULL COMMENT '对账失败原因',
+  `started_at` DATETIME(3) NOT NULL COMMENT '开始时间',
+  `finished_at` DATETIME(3) NULL COMMENT '完成时间',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_instance_reconcile_reports_report_no` (`report_no`),
+  KEY `idx_instance_reconcile_reports_created` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='虚拟化平台与实例记录对账报告';
+
+CREATE TABLE IF NOT EXISTS `instance_reconcile_items` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '对账差异ID',
+  `report_id` BIGINT UNSIGNED NOT NULL COMMENT '对账报告ID',
+  `kind` VARCHAR(16) NOT NULL COMMENT '差异类型：orphan/ghost/drift',
+  `node` VARCHAR(128) NOT NULL COMMENT 'PVE 节点',
+  `vmid` INT UNSIGNED NOT NULL COMMENT 'PVE VMID',
+  `vm_name` VARCHAR(255) NULL COMMENT '上游 VM 名称',
+  `vm_status` VARCHAR(32) NULL COMMENT '上游 VM 电源状态',
+  `vm_cpus` INT NULL COMMENT '上游 VM CPU 数',
+  `vm_memory_mb` INT NULL COMMENT '上游 VM 内存 MB',
+  `instance_id` BIGINT UNSIGNED NULL COMMENT '本地实例ID',
+  `instance_no` VARCHAR(64) NULL COMMENT '本地实例编号',
+  `instance_status` VARCHAR(32) NULL COMMENT '对账时本地实例状态',
+  `drift` VARCHAR(255) NULL COMMENT '漂移字段，逗号分隔',
+  `status` VARCHAR(16) NOT NULL DEFAULT 'open' COMMENT '处理状态：open/resolved',
+  `resolution` VARCHAR(32) NULL COMMENT '处理方式：adopted/marked_error/deleted',
+  `resolved_by` BIGINT UNSIGNED NULL COMMENT '处理管理员ID',
+  `resolved_at` DATETIME(3) NULL COMMENT '处理时间',
+  `remark` VARCHAR(500) NULL COMMENT '处理备注',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_instance_reconcile_items_report_vm` (`report_id`, `node`, `vmid`),
+  KEY `idx_instance_reconcile_items_report_kind_status` (`report_id`, `kind`, `status`),
+  CONSTRAINT `fk_instance_reconcile_items_report` FOREIGN KEY (`report_id`) REFERENCES `instance_reconcile_reports` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='对账差异明细';
+
+INSERT INTO `admin_permissions` (`code`, `name`, `type`, `parent_code`, `path`, `icon`, `sort_order`, `visible_in_menu`, `group_name`, `description`) VALUES
+  ('page.reconciliation', '资源对账', 'menu', NULL, '/reconciliation', 'GitCompare', 82, 1, '菜单', '显示资源对账菜单和页面入口'),
+  ('reconciliation:*', '资源对账全权限', 'action', 'page.reconciliation', NULL, NULL, 100, 0, '资源对账', '资源对账查看、触发和处理全部能力'),
+  ('reconciliation:run', '触发资源对账', 'action', 'page.reconciliation', NULL, NULL, 110, 0, '资源对账', '立即扫描节点并生成对账报告'),
+  ('reconciliation:resolve', '处理对账差异', 'action', 'page.reconciliation', NULL, NULL, 120, 0, '资源对账', '接管或删除孤儿 VM，将幽灵实例标记为异常')
+ON DUPLICATE KEY UPDATE
+  `name` = VALUES(`name`),
+  `type` = VALUES(`type`),
+  `parent_code` = VALUES(`parent_code`),
+  `path` = VALUES(`path`),
+  `icon` = VALUES(`icon`),
+  `sort_order` = VALUES(`sort_order`),
+  `visible_in_menu` = VALUES(`visible_in_menu`),
+  `group_name` = VALUES(`group_name`),
+  `description` = VALUES(`description`);
+
+INSERT INTO `admin_role_permissions` (`role_id`, `permission_id`)
+SELECT `admin_roles`.`id`, `admin_permissions`.`id`
+FROM `admin_roles`
+JOIN `admin_permissions`
+WHERE `admin_roles`.`code` = 'super_admin'
+  AND `admin_permissions`.`code` IN ('page.reconciliation', 'reconciliation:*', 'reconciliation:run', 'reconciliation:resolve')
+ON DUPLICATE KEY UPDATE
+  `role_id` = VALUES(`role_id`);
@@ -0,0 +1,94 @@
+-- Collision-aware VMID allocation.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Provisioning now lists VMs on every candidate node of the mapping and skips
+-- VMIDs already present on the hypervisor or held by an unreleased instance.
+-- Mappings may opt in to reusing VMIDs of released instances before consuming
+-- `next_vmid`. Released instances keep their node/VMID for history, so the
+-- uniqueness of (`external_node`, `external_vmid`) is narrowed to unreleased
+-- rows through a stored projection, the same way `async_tasks` scopes its
+-- idempotency key.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+SET @mappings_reuse_vmids_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instance_provision_mappings'
+    AND COLUMN_NAME = 'reuse_released_vmids'
+);
+SET @add_mappings_reuse_vmids_sql := IF(
+  @mappings_reuse_vmids_column_exists = 0,
+  'ALTER TABLE `instance_provision_mappings` ADD COLUMN `reuse_released_vmids` TINYINT(1) NOT NULL DEFAULT 0 COMMENT ''是否优先复用已释放实例的 VMID'' AFTER `next_vmid`',
+  'SELECT 1'
+);
+PREPARE add_mappings_reuse_vmids_stmt FROM @add_mappings_reuse_vmids_sql;
+EXECUTE add_mappings_reuse_vmids_stmt;
+DEALLOCATE PREPARE add_mappings_reuse_vmids_stmt;
+
+SET @instances_active_vm_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'external_vm_active_key'
+);
+SET @add_instances_active_vm_column_sql := IF(
+  @instances_active_vm_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `external_vm_active_key` VARCHAR(160) GENERATED ALWAYS AS (CASE WHEN `status` <> ''released'' THEN CONCAT(`external_node`, ''/'', `external_vmid`) ELSE NULL END) STORED COMMENT ''未释放实例节点与 VMID 投影'' AFTER `external_vmid`',
+  'SELECT 1'
+);
+PREPARE add_instances_active_vm_column_stmt FROM @add_instances_active_vm_column_sql;
+EXECUTE add_instances_active_vm_column_stmt;
+DEALLOCATE PREPARE add_instances_active_vm_column_stmt;
+
+SET @instances_external_vm_index_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND INDEX_NAME = 'idx_instances_external_vm'
+);
+SET @add_instances_external_vm_index_sql := IF(
+  @instances_external_vm_index_exists = 0,
+  'ALTER TABLE `instances` ADD KEY `idx_instances_external_vm` (`external_node`, `external_vmid`)',
+  'SELECT 1'
+);
+PREPARE add_instances_external_vm_index_stmt FROM @add_instances_external_vm_index_sql;
+EXECUTE add_instances_external_vm_index_stmt;
+DEALLOCATE PREPARE add_instances_external_vm_index_stmt;
+
+SET @instances_external_vm_unique_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND INDEX_NAME = 'uk_instances_external_vm'
+);
+SET @drop_instances_external_vm_unique_sql := IF(
+  @instances_external_vm_unique_exists > 0,
+  'ALTER TABLE `instances` DROP INDEX `uk_instances_external_vm`',
+  'SELECT 1'
+);
+PREPARE drop_instances_external_vm_unique_stmt FROM @drop_instances_external_vm_unique_sql;
+EXECUTE drop_instances_external_vm_unique_stmt;
+DEALLOCATE PREPARE drop_instances_external_vm_unique_stmt;
+
+SET @instances_active_vm_unique_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND INDEX_NAME = 'uk_instances_active_external_vm'
+);
+SET @add_instances_active_vm_unique_sql := IF(
+  @instances_active_vm_unique_exists = 0,
+  'ALTER TABLE `instances` ADD UNIQUE KEY `uk_instances_active_external_vm` (`external_vm_active_key`)',
+  'SELECT 1'
+);
+PREPARE add_instances_active_vm_unique_stmt FROM @add_instances_active_vm_unique_sql;
+EXECUTE add_instances_active_vm_unique_stmt;
+DEALLOCATE PREPARE add_instances_active_vm_unique_stmt;
@@ -0,0 +1,87 @@
+-- Monthly instance traffic metering and plan traffic quota enforcement.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Instances now snapshot the plan's `traffic_gb` at delivery and change-plan
+-- time; NULL or 0 means unmetered. The worker samples each VM's cumulative
+-- netin/netout counters and accumulates deltas into one
+-- `instance_traffic_usages` row per instance and calendar month (server time
+-- zone). A counter lower than the last sample means the VM restarted, so the
+-- current value is taken as the delta. Warning/exceeded emails are sent once
+-- per month at 80%/100%. On overage the configured policy applies: throttle the
+-- NIC rate, stop the VM and block power-on, or debit the wallet per started GB
+-- (`wallet_ledger_entries.entry_type = 'traffic_overage'`), falling back to
+-- throttle when the balance is insufficient. `instances.traffic_overage_action`
+-- records the restriction currently in force and is cleared when the next
+-- month begins.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+SET @instances_traffic_gb_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'traffic_gb'
+);
+SET @add_instances_traffic_gb_sql := IF(
+  @instances_traffic_gb_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `traffic_gb` INT NULL COMMENT ''套餐月流量 GB，空或 0 表示不限'' AFTER `bandwidth_mbps`',
+  'SELECT 1'
+);
+PREPARE add_instances_traffic_gb_stmt FROM @add_instances_traffic_gb_sql;
+EXECUTE add_instances_traffic_gb_stmt;
+DEALLOCATE PREPARE add_instances_traffic_gb_stmt;
+
+UPDATE `instances`
+JOIN `product_plans` ON `product_plans`.`plan_no` = `instances`.`plan_no`
+SET `instances`.`traffic_gb` = `product_plans`.`traffic_gb`
+WHERE `instances`.`traffic_gb` IS NULL
+  AND `instances`.`status` <> 'released';
+
+SET @instances_traffic_overage_action_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'traffic_overage_action'
+);
+SET @add_instances_traffic_overage_action_sql := IF(
+  @instances_traffic_overage_action_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `traffic_overage_action` VARCHAR(16) NULL COMMENT ''当前生效的流量超额限制：throttle/suspend，空表示未限制'' AFTER `traffic_gb`',
+  'SELECT 1'
+);
+PREPARE add_instances_traffic_overage_action_stmt FROM @add_instances_traffic_overage_action_sql;
+EXECUTE add_instances_traffic_overage_action_stmt;
+DEALLOCATE PREPARE add_instances_traffic_overage_action_stmt;
+
+CREATE TABLE IF NOT EXISTS `instance_traffic_usages` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '流量记录ID',
+  `instance_id` BIGINT UNSIGNED NOT NULL COMMENT '实例ID',
+  `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+  `period` CHAR(7) NOT NULL COMMENT '计费月，格式 YYYY-MM',
+  `quota_gb` INT NULL COMMENT '本月套餐流量 GB，空表示不限',
+  `in_bytes` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '本月入方向字节数',
+  `out_bytes` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '本月出方向字节数',
+  `billed_bytes` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '按计量方向计入套餐的字节数',
+  `last_netin` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '上次采样的入方向累计计数器',
+  `last_netout` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '上次采样的出方向累计计数器',
+  `last_sampled_at` DATETIME(3) NULL COMMENT '上次采样时间',
+  `warning_notified_at` DATETIME(3) NULL COMMENT '80% 预警邮件创建时间',
+  `exceeded_notified_at` DATETIME(3) NULL COMMENT '100% 超额邮件创建时间',
+  `overage_action` VARCHAR(16) NULL COMMENT '本月已执行的超额处理：throttle/suspend/bill',
+  `overage_applied_at` DATETIME(3) NULL COMMENT '超额处理首次执行时间',
+  `overage_billed_gb` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '已扣费的超额流量 GB',
+  `overage_billed_cents` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '已扣费的超额金额，单位分',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_instance_traffic_usages_instance_period` (`instance_id`, `period`),
+  KEY `idx_instance_traffic_usages_user_period` (`user_id`, `period`),
+  CONSTRAINT `fk_instance_traffic_usages_instance` FOREIGN KEY (`instance_id`) REFERENCES `instances` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='实例月流量用量';
+
+ALTER TABLE `wallet_ledger_entries`
+  MODIFY COLUMN `entry_type` VARCHAR(32) NOT NULL COMMENT '流水类型：recharge/payment/refund/plan_credit/traffic_overage',
+  MODIFY COLUMN `related_type` VARCHAR(32) NOT NULL COMMENT '关联对象类型：recharge/payment/refund/order/instance';
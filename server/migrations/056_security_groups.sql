@@ -0,0 +1,89 @@
+-- User-owned security groups pushed to the PVE VM firewall.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- A security group is a named set of allow rules (direction, protocol, port
+-- range, CIDR) owned by one user. Users attach up to five of their groups to an
+-- instance; the union of the attached rules is written to the VM firewall with
+-- inbound default DROP, and outbound default ACCEPT unless some outbound rule
+-- exists. `instances.firewall_status` stays NULL until the instance is first
+-- configured, so the platform never takes over a firewall it did not set up;
+-- `pending` means the latest local change has not been confirmed upstream and
+-- is re-applied by the next instance sync, which also corrects upstream drift.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+CREATE TABLE IF NOT EXISTS `security_groups` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '安全组ID',
+  `group_no` VARCHAR(64) NOT NULL COMMENT '安全组编号',
+  `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+  `name` VARCHAR(64) NOT NULL COMMENT '安全组名称',
+  `description` VARCHAR(255) NULL COMMENT '说明',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_security_groups_group_no` (`group_no`),
+  KEY `idx_security_groups_user` (`user_id`),
+  CONSTRAINT `fk_security_groups_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户安全组';
+
+CREATE TABLE IF NOT EXISTS `security_group_rules` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '规则ID',
+  `security_group_id` BIGINT UNSIGNED NOT NULL COMMENT '安全组ID',
+  `direction` VARCHAR(8) NOT NULL COMMENT '方向：in/out',
+  `protocol` VARCHAR(8) NOT NULL COMMENT '协议：tcp/udp/icmp/any',
+  `port_range` VARCHAR(16) NULL COMMENT '端口或端口范围，如 22、8000-8080；icmp/any 为空',
+  `cidr` VARCHAR(64) NOT NULL COMMENT '入站来源或出站目标地址段',
+  `description` VARCHAR(128) NULL COMMENT '规则说明',
+  `sort_order` INT NOT NULL DEFAULT 0 COMMENT '规则顺序',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_security_group_rules_group` (`security_group_id`, `sort_order`),
+  CONSTRAINT `fk_security_group_rules_group` FOREIGN KEY (`security_group_id`) REFERENCES `security_groups` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='安全组规则';
+
+CREATE TABLE IF NOT EXISTS `instance_security_groups` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '绑定ID',
+  `instance_id` BIGINT UNSIGNED NOT NULL COMMENT '实例ID',
+  `security_group_id` BIGINT UNSIGNED NOT NULL COMMENT '安全组ID',
+  `sort_order` INT NOT NULL DEFAULT 0 COMMENT '绑定顺序，决定规则下发顺序',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_instance_security_groups_instance_group` (`instance_id`, `security_group_id`),
+  KEY `idx_instance_security_groups_group` (`security_group_id`),
+  CONSTRAINT `fk_instance_security_groups_instance` FOREIGN KEY (`instance_id`) REFERENCES `instances` (`id`),
+  CONSTRAINT `fk_instance_security_groups_group` FOREIGN KEY (`security_group_id`) REFERENCES `security_groups` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='实例绑定的安全组';
+
+SET @instances_firewall_status_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'firewall_status'
+);
+SET @add_instances_firewall_status_sql := IF(
+  @instances_firewall_status_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `firewall_status` VARCHAR(16) NULL COMMENT ''防火墙下发状态：synced/pending，空表示未配置安全组'' AFTER `config_checked_at`',
+  'SELECT 1'
+);
+PREPARE add_instances_firewall_status_stmt FROM @add_instances_firewall_status_sql;
+EXECUTE add_instances_firewall_status_stmt;
+DEALLOCATE PREPARE add_instances_firewall_status_stmt;
+
+SET @instances_firewall_synced_at_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'firewall_synced_at'
+);
+SET @add_instances_firewall_synced_at_sql := IF(
+  @instances_firewall_synced_at_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `firewall_synced_at` DATETIME(3) NULL COMMENT ''防火墙最近一次确认下发时间'' AFTER `firewall_status`',
+  'SELECT 1'
+);
+PREPARE add_instances_firewall_synced_at_stmt FROM @add_instances_firewall_synced_at_sql;
+EXECUTE add_instances_firewall_synced_at_stmt;
+DEALLOCATE PREPARE add_instances_firewall_synced_at_stmt;
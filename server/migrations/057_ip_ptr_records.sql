@@ -0,0 +1,64 @@
+-- Reverse DNS (PTR) records for instance IP addresses.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Users set one PTR hostname per address leased to their instance. The
+-- hostname must resolve forward (A/AAAA) to the same address before it is
+-- accepted. With `rdns.auto_apply` enabled the record is written to the DNS
+-- backend right away (`applying` -> `active`/`failed`); otherwise it waits in
+-- the admin review queue as `pending_review` until approved or rejected.
+-- Records are removed from the backend and deleted when the instance reaches
+-- `released`, before the address returns to its pool.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+CREATE TABLE IF NOT EXISTS `ip_ptr_records` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'PTR 记录ID',
+  `ip_address_id` BIGINT UNSIGNED NOT NULL COMMENT '地址ID',
+  `address` VARCHAR(64) NOT NULL COMMENT 'IP 地址',
+  `instance_id` BIGINT UNSIGNED NOT NULL COMMENT '实例ID',
+  `instance_no` VARCHAR(64) NOT NULL COMMENT '实例编号',
+  `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+  `hostname` VARCHAR(253) NOT NULL COMMENT 'PTR 主机名',
+  `applied_hostname` VARCHAR(253) NULL COMMENT '当前已写入 DNS 的主机名',
+  `status` VARCHAR(16) NOT NULL COMMENT '状态：pending_review/applying/active/failed/rejected',
+  `last_error` VARCHAR(500) NULL COMMENT '最近一次写入失败原因',
+  `reviewed_by` BIGINT UNSIGNED NULL COMMENT '审批管理员ID',
+  `reviewed_at` DATETIME(3) NULL COMMENT '审批时间',
+  `review_remark` VARCHAR(500) NULL COMMENT '审批备注或驳回原因',
+  `applied_at` DATETIME(3) NULL COMMENT '最近写入成功时间',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_ip_ptr_records_ip_address` (`ip_address_id`),
+  KEY `idx_ip_ptr_records_instance` (`instance_id`),
+  KEY `idx_ip_ptr_records_status_updated` (`status`, `updated_at`),
+  CONSTRAINT `fk_ip_ptr_records_ip_address` FOREIGN KEY (`ip_address_id`) REFERENCES `ip_addresses` (`id`),
+  CONSTRAINT `fk_ip_ptr_records_instance` FOREIGN KEY (`instance_id`) REFERENCES `instances` (`id`),
+  CONSTRAINT `fk_ip_ptr_records_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='IP 反向解析记录';
+
+INSERT INTO `admin_permissions` (`code`, `name`, `type`, `parent_code`, `path`, `icon`, `sort_order`, `visible_in_menu`, `group_name`, `description`) VALUES
+  ('page.rdns', 'PTR 审批', 'menu', NULL, '/rdns', 'Globe', 83, 1, '菜单', '显示 PTR 审批菜单和页面入口'),
+  ('rdns:*', 'PTR 审批全权限', 'action', 'page.rdns', NULL, NULL, 100, 0, 'PTR 审批', 'PTR 记录查看和审批全部能力'),
+  ('rdns:review', '审批 PTR 记录', 'action', 'page.rdns', NULL, NULL, 110, 0, 'PTR 审批', '审批通过并写入 DNS、重试失败记录或驳回用户提交的 PTR')
+ON DUPLICATE KEY UPDATE
+  `name` = VALUES(`name`),
+  `type` = VALUES(`type`),
+  `parent_code` = VALUES(`parent_code`),
+  `path` = VALUES(`path`),
+  `icon` = VALUES(`icon`),
+  `sort_order` = VALUES(`sort_order`),
+  `visible_in_menu` = VALUES(`visible_in_menu`),
+  `group_name` = VALUES(`group_name`),
+  `description` = VALUES(`description`);
+
+INSERT INTO `admin_role_permissions` (`role_id`, `permission_id`)
+SELECT `admin_roles`.`id`, `admin_permissions`.`id`
+FROM `admin_roles`
+JOIN `admin_permissions`
+WHERE `admin_roles`.`code` = 'super_admin'
+  AND `admin_permissions`.`code` IN ('page.rdns', 'rdns:*', 'rdns:review')
+ON DUPLICATE KEY UPDATE
+  `role_id` = VALUES(`role_id`);
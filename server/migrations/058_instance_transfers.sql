@@ -0,0 +1,63 @@
+-- Instance ownership transfers between user accounts.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- An owner offers a delivered instance to another account by username or
+-- email; the recipient accepts or rejects it, and the owner may cancel while
+-- it is `pending`. Admins can force a transfer, which is recorded directly as
+-- `completed` with `forced_by`. On completion the instance `user_id` changes,
+-- the previous owner's pending renewal/change-plan orders are cancelled, the
+-- previous owner's tickets linked to the instance move to the recipient, PTR
+-- records follow the instance and security group bindings are detached.
+-- The recipient must pass the same real-name check as placing an order.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+CREATE TABLE IF NOT EXISTS `instance_transfers` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '转移记录ID',
+  `transfer_no` VARCHAR(64) NOT NULL COMMENT '转移编号',
+  `instance_id` BIGINT UNSIGNED NOT NULL COMMENT '实例ID',
+  `instance_no` VARCHAR(64) NOT NULL COMMENT '实例编号',
+  `from_user_id` BIGINT UNSIGNED NOT NULL COMMENT '原所有者用户ID',
+  `to_user_id` BIGINT UNSIGNED NOT NULL COMMENT '接收方用户ID',
+  `status` VARCHAR(16) NOT NULL COMMENT '状态：pending/completed/rejected/cancelled',
+  `remark` VARCHAR(500) NULL COMMENT '发起方附言',
+  `forced_by` BIGINT UNSIGNED NULL COMMENT '强制转移的管理员ID',
+  `force_reason` VARCHAR(500) NULL COMMENT '强制转移原因',
+  `responded_at` DATETIME(3) NULL COMMENT '接收方处理或发起方撤销时间',
+  `completed_at` DATETIME(3) NULL COMMENT '所有权变更完成时间',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_instance_transfers_transfer_no` (`transfer_no`),
+  KEY `idx_instance_transfers_instance_status` (`instance_id`, `status`),
+  KEY `idx_instance_transfers_from_user` (`from_user_id`, `created_at`),
+  KEY `idx_instance_transfers_to_user` (`to_user_id`, `created_at`),
+  CONSTRAINT `fk_instance_transfers_instance` FOREIGN KEY (`instance_id`) REFERENCES `instances` (`id`),
+  CONSTRAINT `fk_instance_transfers_from_user` FOREIGN KEY (`from_user_id`) REFERENCES `users` (`id`),
+  CONSTRAINT `fk_instance_transfers_to_user` FOREIGN KEY (`to_user_id`) REFERENCES `users` (`id`),
+  CONSTRAINT `fk_instance_transfers_admin` FOREIGN KEY (`forced_by`) REFERENCES `admin_users` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='实例所有权转移';
+
+INSERT INTO `admin_permissions` (`code`, `name`, `type`, `parent_code`, `path`, `icon`, `sort_order`, `visible_in_menu`, `group_name`, `description`) VALUES
+  ('instance:transfer', '转移实例', 'action', 'page.instances', NULL, NULL, 165, 0, '实例管理', '查看转移记录并强制把实例转移到其他用户账号')
+ON DUPLICATE KEY UPDATE
+  `name` = VALUES(`name`),
+  `type` = VALUES(`type`),
+  `parent_code` = VALUES(`parent_code`),
+  `path` = VALUES(`path`),
+  `icon` = VALUES(`icon`),
+  `sort_order` = VALUES(`sort_order`),
+  `visible_in_menu` = VALUES(`visible_in_menu`),
+  `group_name` = VALUES(`group_name`),
+  `description` = VALUES(`description`);
+
+INSERT INTO `admin_role_permissions` (`role_id`, `permission_id`)
+SELECT `admin_roles`.`id`, `admin_permissions`.`id`
+FROM `admin_roles`
+JOIN `admin_permissions`
+WHERE `admin_roles`.`code` = 'super_admin'
+  AND `admin_permissions`.`code` = 'instance:transfer'
+ON DUPLICATE KEY UPDATE
+  `role_id` = VALUES(`role_id`);
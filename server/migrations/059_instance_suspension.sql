@@ -0,0 +1,84 @@
+-- Suspended instance status with a grace period before expiry release.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- At expiry the worker moves a running or stopped instance to `suspended`,
+-- powers the VM off and blocks every user operation except renewal. Renewing
+-- an expiry suspension restores the instance to `stopped`; the existing
+-- `expire_release_after_seconds` window is the grace period before release.
+-- Admins may also suspend an instance by hand (for example for abuse); that
+-- suspension survives renewal and is lifted only by an admin. The reason is
+-- shown to the user.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+SET @instances_suspend_source_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'suspend_source'
+);
+SET @add_instances_suspend_source_sql := IF(
+  @instances_suspend_source_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `suspend_source` VARCHAR(16) NULL COMMENT ''暂停来源：expiry/admin，未暂停时为空'' AFTER `firewall_synced_at`',
+  'SELECT 1'
+);
+PREPARE add_instances_suspend_source_stmt FROM @add_instances_suspend_source_sql;
+EXECUTE add_instances_suspend_source_stmt;
+DEALLOCATE PREPARE add_instances_suspend_source_stmt;
+
+SET @instances_suspend_reason_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'suspend_reason'
+);
+SET @add_instances_suspend_reason_sql := IF(
+  @instances_suspend_reason_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `suspend_reason` VARCHAR(255) NULL COMMENT ''暂停原因，展示给用户'' AFTER `suspend_source`',
+  'SELECT 1'
+);
+PREPARE add_instances_suspend_reason_stmt FROM @add_instances_suspend_reason_sql;
+EXECUTE add_instances_suspend_reason_stmt;
+DEALLOCATE PREPARE add_instances_suspend_reason_stmt;
+
+SET @instances_suspended_at_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'suspended_at'
+);
+SET @add_instances_suspended_at_sql := IF(
+  @instances_suspended_at_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `suspended_at` DATETIME(3) NULL COMMENT ''暂停时间'' AFTER `suspend_reason`',
+  'SELECT 1'
+);
+PREPARE add_instances_suspended_at_stmt FROM @add_instances_suspended_at_sql;
+EXECUTE add_instances_suspended_at_stmt;
+DEALLOCATE PREPARE add_instances_suspended_at_stmt;
+
+INSERT INTO `admin_permissions` (`code`, `name`, `type`, `parent_code`, `path`, `icon`, `sort_order`, `visible_in_menu`, `group_name`, `description`) VALUES
+  ('instance:suspend', '暂停实例', 'action', 'page.instances', NULL, NULL, 166, 0, '实例管理', '人工暂停和解除暂停实例')
+ON DUPLICATE KEY UPDATE
+  `name` = VALUES(`name`),
+  `type` = VALUES(`type`),
+  `parent_code` = VALUES(`parent_code`),
+  `path` = VALUES(`path`),
+  `icon` = VALUES(`icon`),
+  `sort_order` = VALUES(`sort_order`),
+  `visible_in_menu` = VALUES(`visible_in_menu`),
+  `group_name` = VALUES(`group_name`),
+  `description` = VALUES(`description`);
+
+INSERT INTO `admin_role_permissions` (`role_id`, `permission_id`)
+SELECT `admin_roles`.`id`, `admin_permissions`.`id`
+FROM `admin_roles`
+JOIN `admin_permissions`
+WHERE `admin_roles`.`code` = 'super_admin'
+  AND `admin_permissions`.`code` = 'instance:suspend'
+ON DUPLICATE KEY UPDATE
+  `role_id` = VALUES(`role_id`);
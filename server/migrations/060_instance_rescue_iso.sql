@@ -0,0 +1,135 @@
+-- Rescue mode and an admin-curated ISO library.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- `instance_isos` lists volumes that already exist on PVE storage: rescue
+-- media (mounted as a cdrom or attached as a temporary disk) and install ISOs
+-- users may mount themselves. Entering rescue records the medium, a sealed
+-- temporary password and an expiry on the instance; the worker exits rescue
+-- automatically at expiry. The mounted install ISO is tracked separately.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+CREATE TABLE IF NOT EXISTS `instance_isos` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '镜像ID',
+  `iso_no` VARCHAR(64) NOT NULL COMMENT '镜像编号',
+  `name` VARCHAR(128) NOT NULL COMMENT '展示名称',
+  `purpose` VARCHAR(16) NOT NULL COMMENT '用途：rescue/install',
+  `media` VARCHAR(16) NOT NULL DEFAULT 'iso' COMMENT '挂载方式：iso 光驱/disk 临时磁盘，disk 仅用于救援',
+  `volume` VARCHAR(255) NOT NULL COMMENT 'PVE 存储卷 ID，如 cephfs:iso/debian-12.iso',
+  `os_family` VARCHAR(32) NULL COMMENT '系统类型',
+  `description` VARCHAR(500) NULL COMMENT '说明',
+  `status` VARCHAR(16) NOT NULL DEFAULT 'active' COMMENT '状态：active/inactive',
+  `sort_order` INT NOT NULL DEFAULT 0 COMMENT '排序，救援默认使用最靠前的介质',
+  `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
+  `updated_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_instance_isos_iso_no` (`iso_no`),
+  KEY `idx_instance_isos_purpose_status` (`purpose`, `status`, `sort_order`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='实例镜像库';
+
+SET @instances_rescue_iso_no_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'rescue_iso_no'
+);
+SET @add_instances_rescue_iso_no_sql := IF(
+  @instances_rescue_iso_no_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `rescue_iso_no` VARCHAR(64) NULL COMMENT ''救援介质镜像编号，未处于救援时为空'' AFTER `suspended_at`',
+  'SELECT 1'
+);
+PREPARE add_instances_rescue_iso_no_stmt FROM @add_instances_rescue_iso_no_sql;
+EXECUTE add_instances_rescue_iso_no_stmt;
+DEALLOCATE PREPARE add_instances_rescue_iso_no_stmt;
+
+SET @instances_rescue_started_at_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'rescue_started_at'
+);
+SET @add_instances_rescue_started_at_sql := IF(
+  @instances_rescue_started_at_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `rescue_started_at` DATETIME(3) NULL COMMENT ''进入救援时间'' AFTER `rescue_iso_no`',
+  'SELECT 1'
+);
+PREPARE add_instances_rescue_started_at_stmt FROM @add_instances_rescue_started_at_sql;
+EXECUTE add_instances_rescue_started_at_stmt;
+DEALLOCATE PREPARE add_instances_rescue_started_at_stmt;
+
+SET @instances_rescue_expires_at_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'rescue_expires_at'
+);
+SET @add_instances_rescue_expires_at_sql := IF(
+  @instances_rescue_expires_at_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `rescue_expires_at` DATETIME(3) NULL COMMENT ''救援自动退出时间'' AFTER `rescue_started_at`',
+  'SELECT 1'
+);
+PREPARE add_instances_rescue_expires_at_stmt FROM @add_instances_rescue_expires_at_sql;
+EXECUTE add_instances_rescue_expires_at_stmt;
+DEALLOCATE PREPARE add_instances_rescue_expires_at_stmt;
+
+SET @instances_rescue_password_ciphertext_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'rescue_password_ciphertext'
+);
+SET @add_instances_rescue_password_ciphertext_sql := IF(
+  @instances_rescue_password_ciphertext_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `rescue_password_ciphertext` TEXT NULL COMMENT ''救援临时密码密文'' AFTER `rescue_expires_at`',
+  'SELECT 1'
+);
+PREPARE add_instances_rescue_password_ciphertext_stmt FROM @add_instances_rescue_password_ciphertext_sql;
+EXECUTE add_instances_rescue_password_ciphertext_stmt;
+DEALLOCATE PREPARE add_instances_rescue_password_ciphertext_stmt;
+
+SET @instances_mounted_iso_no_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'mounted_iso_no'
+);
+SET @add_instances_mounted_iso_no_sql := IF(
+  @instances_mounted_iso_no_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `mounted_iso_no` VARCHAR(64) NULL COMMENT ''光驱中挂载的安装镜像编号'' AFTER `rescue_password_ciphertext`',
+  'SELECT 1'
+);
+PREPARE add_instances_mounted_iso_no_stmt FROM @add_instances_mounted_iso_no_sql;
+EXECUTE add_instances_mounted_iso_no_stmt;
+DEALLOCATE PREPARE add_instances_mounted_iso_no_stmt;
+
+ALTER TABLE `instance_operations`
+  MODIFY COLUMN `action` VARCHAR(32) NOT NULL COMMENT '操作：provision/start/stop/reboot/shutdown/reset/reinstall/resize/reset_password/snapshot_create/snapshot_rollback/snapshot_delete/backup_create/backup_restore/suspend/rescue_enter/rescue_exit/iso_mount/iso_unmount/release/sync';
+
+INSERT INTO `admin_permissions` (`code`, `name`, `type`, `parent_code`, `path`, `icon`, `sort_order`, `visible_in_menu`, `group_name`, `description`) VALUES
+  ('instance:iso', '管理镜像库', 'action', 'page.instances', NULL, NULL, 167, 0, '实例管理', '维护救援介质和用户可挂载的安装镜像')
+ON DUPLICATE KEY UPDATE
+  `name` = VALUES(`name`),
+  `type` = VALUES(`type`),
+  `parent_code` = VALUES(`parent_code`),
+  `path` = VALUES(`path`),
+  `icon` = VALUES(`icon`),
+  `sort_order` = VALUES(`sort_order`),
+  `visible_in_menu` = VALUES(`visible_in_menu`),
+  `group_name` = VALUES(`group_name`),
+  `description` = VALUES(`description`);
+
+INSERT INTO `admin_role_permissions` (`role_id`, `permission_id`)
+SELECT `admin_roles`.`id`, `admin_permissions`.`id`
+FROM `admin_roles`
+JOIN `admin_permissions`
+WHERE `admin_roles`.`code` = 'super_admin'
+  AND `admin_permissions`.`code` = 'instance:iso'
+ON DUPLICATE KEY UPDATE
+  `role_id` = VALUES(`role_id`);
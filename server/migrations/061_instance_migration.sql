@@ -0,0 +1,39 @@
+-- Live migration of instances between nodes of the same region.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- A migrate operation moves the VM to another node covered by the region's
+-- active provision mappings, online when the instance is running and offline
+-- otherwise (auto mode falls back to offline after a failed online attempt).
+-- The instance keeps its VMID; `external_node` is rewritten when the worker
+-- sync sees the upstream operation succeed. Evacuating a node queues one
+-- `instance_migrate` task per instance. No new columns are needed: the
+-- source/target nodes live in the operation payload and placement.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+ALTER TABLE `instance_operations`
+  MODIFY COLUMN `action` VARCHAR(32) NOT NULL COMMENT '操作：provision/start/stop/reboot/shutdown/reset/reinstall/resize/reset_password/snapshot_create/snapshot_rollback/snapshot_delete/backup_create/backup_restore/suspend/rescue_enter/rescue_exit/iso_mount/iso_unmount/migrate/release/sync';
+
+INSERT INTO `admin_permissions` (`code`, `name`, `type`, `parent_code`, `path`, `icon`, `sort_order`, `visible_in_menu`, `group_name`, `description`) VALUES
+  ('instance:migrate', '迁移实例', 'action', 'page.instances', NULL, NULL, 168, 0, '实例管理', '在同地域节点间迁移实例，以及疏散节点上的全部实例')
+ON DUPLICATE KEY UPDATE
+  `name` = VALUES(`name`),
+  `type` = VALUES(`type`),
+  `parent_code` = VALUES(`parent_code`),
+  `path` = VALUES(`path`),
+  `icon` = VALUES(`icon`),
+  `sort_order` = VALUES(`sort_order`),
+  `visible_in_menu` = VALUES(`visible_in_menu`),
+  `group_name` = VALUES(`group_name`),
+  `description` = VALUES(`description`);
+
+INSERT INTO `admin_role_permissions` (`role_id`, `permission_id`)
+SELECT `admin_roles`.`id`, `admin_permissions`.`id`
+FROM `admin_roles`
+JOIN `admin_permissions`
+WHERE `admin_roles`.`code` = 'super_admin'
+  AND `admin_permissions`.`code` = 'instance:migrate'
+ON DUPLICATE KEY UPDATE
+  `role_id` = VALUES(`role_id`);
@@ -0,0 +1,165 @@
+-- Multiple PVE clusters behind separate MCP endpoints.
+-- Target: MariaDB 11.4.x / InnoDB / utf8mb4.
+--
+-- Clusters are registered in `mcp_pve` config; the top-level endpoint is the
+-- `default` cluster. A sales region is bound to one cluster and its provision
+-- mappings inherit that binding. Each instance snapshots the cluster at
+-- provision time and every MCP call for it is routed there; backups record
+-- the cluster holding their volume, and restoring a backup into a new
+-- instance requires the target region to use the same cluster. Node names and
+-- VMIDs are only unique inside a cluster, so the unreleased node/VMID
+-- projection and the reconcile findings now include the cluster. Existing
+-- rows belong to `default`.
+
+SET NAMES utf8mb4;
+
+USE `pvecloud`;
+
+SET @sales_regions_cluster_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'sales_regions'
+    AND COLUMN_NAME = 'cluster_no'
+);
+SET @add_sales_regions_cluster_column_sql := IF(
+  @sales_regions_cluster_column_exists = 0,
+  'ALTER TABLE `sales_regions` ADD COLUMN `cluster_no` VARCHAR(32) NOT NULL DEFAULT ''default'' COMMENT ''绑定的 PVE 集群编号'' AFTER `region_no`',
+  'SELECT 1'
+);
+PREPARE add_sales_regions_cluster_column_stmt FROM @add_sales_regions_cluster_column_sql;
+EXECUTE add_sales_regions_cluster_column_stmt;
+DEALLOCATE PREPARE add_sales_regions_cluster_column_stmt;
+
+SET @instances_cluster_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'cluster_no'
+);
+SET @add_instances_cluster_column_sql := IF(
+  @instances_cluster_column_exists = 0,
+  'ALTER TABLE `instances` ADD COLUMN `cluster_no` VARCHAR(32) NOT NULL DEFAULT ''default'' COMMENT ''交付时所在的 PVE 集群编号'' AFTER `os_version`',
+  'SELECT 1'
+);
+PREPARE add_instances_cluster_column_stmt FROM @add_instances_cluster_column_sql;
+EXECUTE add_instances_cluster_column_stmt;
+DEALLOCATE PREPARE add_instances_cluster_column_stmt;
+
+SET @instance_backups_cluster_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instance_backups'
+    AND COLUMN_NAME = 'cluster_no'
+);
+SET @add_instance_backups_cluster_column_sql := IF(
+  @instance_backups_cluster_column_exists = 0,
+  'ALTER TABLE `instance_backups` ADD COLUMN `cluster_no` VARCHAR(32) NOT NULL DEFAULT ''default'' COMMENT ''备份卷所在的 PVE 集群编号'' AFTER `status`',
+  'SELECT 1'
+);
+PREPARE add_instance_backups_cluster_column_stmt FROM @add_instance_backups_cluster_column_sql;
+EXECUTE add_instance_backups_cluster_column_stmt;
+DEALLOCATE PREPARE add_instance_backups_cluster_column_stmt;
+
+SET @instances_active_vm_key_scoped := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND COLUMN_NAME = 'external_vm_active_key'
+    AND GENERATION_EXPRESSION LIKE '%cluster_no%'
+);
+
+SET @instances_active_vm_unique_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND INDEX_NAME = 'uk_instances_active_external_vm'
+);
+SET @drop_instances_active_vm_unique_sql := IF(
+  @instances_active_vm_key_scoped = 0 AND @instances_active_vm_unique_exists > 0,
+  'ALTER TABLE `instances` DROP INDEX `uk_instances_active_external_vm`',
+  'SELECT 1'
+);
+PREPARE drop_instances_active_vm_unique_stmt FROM @drop_instances_active_vm_unique_sql;
+EXECUTE drop_instances_active_vm_unique_stmt;
+DEALLOCATE PREPARE drop_instances_active_vm_unique_stmt;
+
+SET @drop_instances_active_vm_column_sql := IF(
+  @instances_active_vm_key_scoped = 0,
+  'ALTER TABLE `instances` DROP COLUMN `external_vm_active_key`',
+  'SELECT 1'
+);
+PREPARE drop_instances_active_vm_column_stmt FROM @drop_instances_active_vm_column_sql;
+EXECUTE drop_instances_active_vm_column_stmt;
+DEALLOCATE PREPARE drop_instances_active_vm_column_stmt;
+
+SET @add_instances_active_vm_column_sql := IF(
+  @instances_active_vm_key_scoped = 0,
+  'ALTER TABLE `instances` ADD COLUMN `external_vm_active_key` VARCHAR(200) GENERATED ALWAYS AS (CASE WHEN `status` <> ''released'' THEN CONCAT(`cluster_no`, ''/'', `external_node`, ''/'', `external_vmid`) ELSE NULL END) STORED COMMENT ''未释放实例集群、节点与 VMID 投影'' AFTER `external_vmid`',
+  'SELECT 1'
+);
+PREPARE add_instances_active_vm_column_stmt FROM @add_instances_active_vm_column_sql;
+EXECUTE add_instances_active_vm_column_stmt;
+DEALLOCATE PREPARE add_instances_active_vm_column_stmt;
+
+SET @add_instances_active_vm_unique_sql := IF(
+  @instances_active_vm_key_scoped = 0,
+  'ALTER TABLE `instances` ADD UNIQUE KEY `uk_instances_active_external_vm` (`external_vm_active_key`)',
+  'SELECT 1'
+);
+PREPARE add_instances_active_vm_unique_stmt FROM @add_instances_active_vm_unique_sql;
+EXECUTE add_instances_active_vm_unique_stmt;
+DEALLOCATE PREPARE add_instances_active_vm_unique_stmt;
+
+SET @instances_cluster_vm_index_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instances'
+    AND INDEX_NAME = 'idx_instances_cluster_vm'
+);
+SET @add_instances_cluster_vm_index_sql := IF(
+  @instances_cluster_vm_index_exists = 0,
+  'ALTER TABLE `instances` ADD KEY `idx_instances_cluster_vm` (`cluster_no`, `external_node`, `external_vmid`)',
+  'SELECT 1'
+);
+PREPARE add_instances_cluster_vm_index_stmt FROM @add_instances_cluster_vm_index_sql;
+EXECUTE add_instances_cluster_vm_index_stmt;
+DEALLOCATE PREPARE add_instances_cluster_vm_index_stmt;
+
+SET @instance_reconcile_items_cluster_column_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instance_reconcile_items'
+    AND COLUMN_NAME = 'cluster_no'
+);
+SET @add_instance_reconcile_items_cluster_column_sql := IF(
+  @instance_reconcile_items_cluster_column_exists = 0,
+  'ALTER TABLE `instance_reconcile_items` ADD COLUMN `cluster_no` VARCHAR(32) NOT NULL DEFAULT ''default'' COMMENT ''PVE 集群编号'' AFTER `kind`',
+  'SELECT 1'
+);
+PREPARE add_instance_reconcile_items_cluster_column_stmt FROM @add_instance_reconcile_items_cluster_column_sql;
+EXECUTE add_instance_reconcile_items_cluster_column_stmt;
+DEALLOCATE PREPARE add_instance_reconcile_items_cluster_column_stmt;
+
+SET @instance_reconcile_items_vm_unique_scoped := (
+  SELECT COUNT(*)
+  FROM information_schema.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'instance_reconcile_items'
+    AND INDEX_NAME = 'uk_instance_reconcile_items_report_vm'
+    AND COLUMN_NAME = 'cluster_no'
+);
+SET @rebuild_instance_reconcile_items_vm_unique_sql := IF(
+  @instance_reconcile_items_vm_unique_scoped = 0,
+  'ALTER TABLE `instance_reconcile_items` DROP INDEX `uk_instance_reconcile_items_report_vm`, ADD UNIQUE KEY `uk_instance_reconcile_items_report_vm` (`report_id`, `cluster_no`, `node`, `vmid`)',
+  'SELECT 1'
+);
+PREPARE rebuild_instance_reconcile_items_vm_unique_stmt FROM @rebuild_instance_reconcile_items_vm_unique_sql;
+EXECUTE rebuild_instance_reconcile_items_vm_unique_stmt;
+DEALLOCATE PREPARE rebuild_instance_reconcile_items_vm_unique_stmt;